@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+)
+
+func newAdminCmd(cfg *rootConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Cluster administration built on the rethinkdb system tables",
+	}
+	cmd.AddCommand(
+		newAdminStatusCmd(cfg),
+		newAdminReconfigureCmd(cfg),
+		newAdminJobsCmd(cfg),
+		newAdminUserCmd(cfg),
+	)
+	return cmd
+}
+
+// systemTable returns r.db("rethinkdb").table(name).
+func systemTable(name string) reql.Term {
+	return reql.DB("rethinkdb").Table(name)
+}
+
+// clusterOverview is the output of admin status.
+type clusterOverview struct {
+	Servers []serverOverview `json:"servers"`
+	Tables  []tableOverview  `json:"tables"`
+}
+
+type serverOverview struct {
+	Name        string `json:"name"`
+	Hostname    string `json:"hostname"`
+	Version     string `json:"version"`
+	TimeStarted string `json:"time_started,omitempty"`
+}
+
+type tableOverview struct {
+	DB                    string `json:"db"`
+	Name                  string `json:"name"`
+	Shards                int    `json:"shards"`
+	Replicas              int    `json:"replicas"`
+	ReadyReplicas         int    `json:"ready_replicas"`
+	AllReplicasReady      bool   `json:"all_replicas_ready"`
+	ReadyForOutdatedReads bool   `json:"ready_for_outdated_reads"`
+	ReadyForReads         bool   `json:"ready_for_reads"`
+	ReadyForWrites        bool   `json:"ready_for_writes"`
+}
+
+// serverStatusRow is the part of a rethinkdb.server_status document admin status uses.
+type serverStatusRow struct {
+	Name    string `json:"name"`
+	Network struct {
+		Hostname string `json:"hostname"`
+	} `json:"network"`
+	Process struct {
+		Version     string    `json:"version"`
+		TimeStarted time.Time `json:"time_started"`
+	} `json:"process"`
+}
+
+// tableStatusRow is the part of a rethinkdb.table_status document admin status uses.
+type tableStatusRow struct {
+	DB     string `json:"db"`
+	Name   string `json:"name"`
+	Shards []struct {
+		Replicas []struct {
+			Server string `json:"server"`
+			State  string `json:"state"`
+		} `json:"replicas"`
+	} `json:"shards"`
+	Status struct {
+		AllReplicasReady      bool `json:"all_replicas_ready"`
+		ReadyForOutdatedReads bool `json:"ready_for_outdated_reads"`
+		ReadyForReads         bool `json:"ready_for_reads"`
+		ReadyForWrites        bool `json:"ready_for_writes"`
+	} `json:"status"`
+}
+
+func newAdminStatusCmd(cfg *rootConfig) *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show servers, tables and replica readiness from the system tables",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runAdminStatus(cmd.Context(), cfg, asJSON, os.Stdout)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the overview as JSON")
+	return cmd
+}
+
+func runAdminStatus(ctx context.Context, cfg *rootConfig, asJSON bool, w io.Writer) error {
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	ov, err := readClusterOverview(ctx, exec, cfg)
+	if err != nil {
+		return fmt.Errorf("admin status: %w", err)
+	}
+	out, err := openOutputTarget(cfg.output, w)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return out.finish(enc.Encode(ov))
+	}
+	return out.finish(writeClusterOverview(out, ov))
+}
+
+// readClusterOverview reads server_status and table_status, ordered by name.
+func readClusterOverview(ctx context.Context, exec *query.Executor, cfg *rootConfig) (*clusterOverview, error) {
+	var servers []serverStatusRow
+	if err := fetchDecoded(ctx, exec, cfg, systemTable("server_status").OrderBy("name"), &servers); err != nil {
+		return nil, err
+	}
+	var tables []tableStatusRow
+	if err := fetchValue(ctx, exec, cfg, systemTable("table_status").OrderBy("db", "name"), &tables); err != nil {
+		return nil, err
+	}
+	return buildClusterOverview(servers, tables), nil
+}
+
+func buildClusterOverview(servers []serverStatusRow, tables []tableStatusRow) *clusterOverview {
+	ov := &clusterOverview{Servers: []serverOverview{}, Tables: []tableOverview{}}
+	for _, s := range servers {
+		so := serverOverview{Name: s.Name, Hostname: s.Network.Hostname, Version: serverVersion(s.Process.Version)}
+		if !s.Process.TimeStarted.IsZero() {
+			so.TimeStarted = s.Process.TimeStarted.UTC().Format(time.RFC3339)
+		}
+		ov.Servers = append(ov.Servers, so)
+	}
+	for _, t := range tables {
+		to := tableOverview{
+			DB:                    t.DB,
+			Name:                  t.Name,
+			Shards:                len(t.Shards),
+			AllReplicasReady:      t.Status.AllReplicasReady,
+			ReadyForOutdatedReads: t.Status.ReadyForOutdatedReads,
+			ReadyForReads:         t.Status.ReadyForReads,
+			ReadyForWrites:        t.Status.ReadyForWrites,
+		}
+		for _, sh := range t.Shards {
+			for _, r := range sh.Replicas {
+				to.Replicas++
+				if r.State == "ready" {
+					to.ReadyReplicas++
+				}
+			}
+		}
+		ov.Tables = append(ov.Tables, to)
+	}
+	return ov
+}
+
+// serverVersion shortens "rethinkdb 2.4.1~0bionic (GCC ...)" to "2.4.1~0bionic".
+func serverVersion(v string) string {
+	fields := strings.Fields(v)
+	if len(fields) >= 2 && fields[0] == "rethinkdb" {
+		return fields[1]
+	}
+	return v
+}
+
+// writeClusterOverview renders ov as two aligned sections.
+func writeClusterOverview(w io.Writer, ov *clusterOverview) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "SERVERS (%d)\n", len(ov.Servers))
+	_, _ = fmt.Fprintln(tw, "NAME\tHOSTNAME\tVERSION\tSTARTED")
+	for _, s := range ov.Servers {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, s.Hostname, s.Version, s.TimeStarted)
+	}
+	notReady := 0
+	for _, t := range ov.Tables {
+		if !t.AllReplicasReady {
+			notReady++
+		}
+	}
+	_, _ = fmt.Fprintf(tw, "\nTABLES (%d, %d not fully ready)\n", len(ov.Tables), notReady)
+	_, _ = fmt.Fprintln(tw, "TABLE\tSHARDS\tREPLICAS READY\tWRITES\tREADS\tOUTDATED READS")
+	for _, t := range ov.Tables {
+		_, _ = fmt.Fprintf(tw, "%s.%s\t%d\t%d/%d\t%s\t%s\t%s\n", t.DB, t.Name, t.Shards,
+			t.ReadyReplicas, t.Replicas, yesNo(t.ReadyForWrites), yesNo(t.ReadyForReads), yesNo(t.ReadyForOutdatedReads))
+	}
+	return tw.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"r-cli/internal/response"
+)
+
+func TestAdminCmdRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	for _, sub := range root.Commands() {
+		if sub.Name() != "admin" {
+			continue
+		}
+		for _, c := range sub.Commands() {
+			if c.Name() == "status" {
+				return
+			}
+		}
+		t.Fatal("admin status subcommand not registered")
+	}
+	t.Error("admin subcommand not registered on root command")
+}
+
+func TestBuildClusterOverview(t *testing.T) {
+	t.Parallel()
+	var servers []serverStatusRow
+	if err := response.Decode([]byte(`[{"name":"s1","network":{"hostname":"h1"},
+		"process":{"version":"rethinkdb 2.4.1~0bionic (GCC 7.3.0)","time_started":{"$reql_type$":"TIME","epoch_time":1700000000.5,"timezone":"+00:00"}}}]`), &servers); err != nil {
+		t.Fatal(err)
+	}
+	var tables []tableStatusRow
+	if err := json.Unmarshal([]byte(`[{"db":"app","name":"users",
+		"shards":[{"replicas":[{"server":"s1","state":"ready"},{"server":"s2","state":"backfilling"}]},{"replicas":[{"server":"s1","state":"ready"}]}],
+		"status":{"all_replicas_ready":false,"ready_for_outdated_reads":true,"ready_for_reads":true,"ready_for_writes":false}}]`), &tables); err != nil {
+		t.Fatal(err)
+	}
+	ov := buildClusterOverview(servers, tables)
+	wantServer := serverOverview{Name: "s1", Hostname: "h1", Version: "2.4.1~0bionic", TimeStarted: "2023-11-14T22:13:20Z"}
+	if len(ov.Servers) != 1 || ov.Servers[0] != wantServer {
+		t.Errorf("servers: got %+v", ov.Servers)
+	}
+	wantTable := tableOverview{
+		DB: "app", Name: "users", Shards: 2, Replicas: 3, ReadyReplicas: 2,
+		ReadyForOutdatedReads: true, ReadyForReads: true,
+	}
+	if len(ov.Tables) != 1 || ov.Tables[0] != wantTable {
+		t.Errorf("tables: got %+v", ov.Tables)
+	}
+
+	var buf bytes.Buffer
+	if err := writeClusterOverview(&buf, ov); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"SERVERS (1)", "TABLES (1, 1 not fully ready)", "app.users", "2/3", "2.4.1~0bionic"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestBuildClusterOverviewEmpty(t *testing.T) {
+	t.Parallel()
+	data, err := json.Marshal(buildClusterOverview(nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"servers":[],"tables":[]}` {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"rethinkdb 2.4.4 (GCC 10.2.1)": "2.4.4",
+		"custom":                       "custom",
+		"":                             "",
+	}
+	for in, want := range tests {
+		if got := serverVersion(in); got != want {
+			t.Errorf("serverVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
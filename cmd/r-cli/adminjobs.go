@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+)
+
+// jobRow is a rethinkdb.jobs document. ID is [type, uuid].
+type jobRow struct {
+	ID          []string               `json:"id"`
+	Type        string                 `json:"type"`
+	DurationSec float64                `json:"duration_sec"`
+	Servers     []string               `json:"servers"`
+	Info        map[string]interface{} `json:"info"`
+}
+
+func newAdminJobsCmd(cfg *rootConfig) *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "List running jobs (queries, index construction, backfills), longest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runAdminJobs(cmd.Context(), cfg, asJSON, os.Stdout)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the jobs as JSON")
+	cmd.AddCommand(newAdminJobsKillCmd(cfg))
+	return cmd
+}
+
+func newAdminJobsKillCmd(cfg *rootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "kill <id>",
+		Short: "Cancel a job by deleting its rethinkdb.jobs row",
+		Long: "Cancel a job by deleting its row from rethinkdb.jobs. <id> is the UUID shown\n" +
+			"by admin jobs, or the full JSON id such as '[\"query\",\"<uuid>\"]'.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdminJobsKill(cmd.Context(), cfg, args[0], os.Stdout)
+		},
+	}
+}
+
+func runAdminJobs(ctx context.Context, cfg *rootConfig, asJSON bool, w io.Writer) error {
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	jobs, err := fetchJobs(ctx, exec, cfg)
+	if err != nil {
+		return fmt.Errorf("admin jobs: %w", err)
+	}
+	out, err := openOutputTarget(cfg.output, w)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return out.finish(enc.Encode(jobs))
+	}
+	return out.finish(writeJobs(out, jobs))
+}
+
+func runAdminJobsKill(ctx context.Context, cfg *rootConfig, id string, w io.Writer) error {
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	jobs, err := fetchJobs(ctx, exec, cfg)
+	if err != nil {
+		return fmt.Errorf("admin jobs kill: %w", err)
+	}
+	job, err := findJob(jobs, id)
+	if err != nil {
+		return err
+	}
+	key := make([]interface{}, len(job.ID))
+	for i, part := range job.ID {
+		key[i] = part
+	}
+	var res struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := fetchValue(ctx, exec, cfg, systemTable("jobs").Get(reql.Array(key...)).Delete(), &res); err != nil {
+		return fmt.Errorf("admin jobs kill: %w", err)
+	}
+	data, _ := json.Marshal(res)
+	_, _ = fmt.Fprintf(w, "%s\n", data)
+	return nil
+}
+
+// fetchJobs reads rethinkdb.jobs, longest running first.
+func fetchJobs(ctx context.Context, exec *query.Executor, cfg *rootConfig) ([]jobRow, error) {
+	jobs := []jobRow{}
+	err := fetchValue(ctx, exec, cfg, systemTable("jobs").OrderBy(reql.Desc("duration_sec")), &jobs)
+	return jobs, err
+}
+
+// findJob matches id against the job UUIDs, or against the whole id when id
+// is a JSON array.
+func findJob(jobs []jobRow, id string) (*jobRow, error) {
+	var full []string
+	if strings.HasPrefix(strings.TrimSpace(id), "[") {
+		if err := json.Unmarshal([]byte(id), &full); err != nil {
+			return nil, fmt.Errorf("admin jobs kill: invalid job id %s: %w", id, err)
+		}
+	}
+	for i, j := range jobs {
+		if full != nil && slices.Equal(j.ID, full) {
+			return &jobs[i], nil
+		}
+		if full == nil && len(j.ID) == 2 && j.ID[1] == id {
+			return &jobs[i], nil
+		}
+	}
+	return nil, &queryError{err: fmt.Errorf("admin jobs kill: no job with id %s", id)}
+}
+
+// writeJobs renders one aligned line per job.
+func writeJobs(w io.Writer, jobs []jobRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tTYPE\tDURATION\tSERVERS\tINFO")
+	for _, j := range jobs {
+		uuid := ""
+		if len(j.ID) == 2 {
+			uuid = j.ID[1]
+		}
+		d := time.Duration(j.DurationSec * float64(time.Second)).Round(time.Millisecond)
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", uuid, j.Type, d, strings.Join(j.Servers, ","), jobInfo(j))
+	}
+	return tw.Flush()
+}
+
+// jobInfo summarizes the type-specific info object of a job.
+func jobInfo(j jobRow) string {
+	str := func(key string) string {
+		if v, ok := j.Info[key]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	switch j.Type {
+	case "query":
+		return strings.TrimSpace(fmt.Sprintf("%s:%s %s", str("client_address"), str("client_port"), str("query")))
+	case "index_construction":
+		return fmt.Sprintf("%s.%s index %s progress %s", str("db"), str("table"), str("index"), str("progress"))
+	case "backfill":
+		return fmt.Sprintf("%s.%s %s -> %s progress %s", str("db"), str("table"), str("source_server"), str("destination_server"), str("progress"))
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testJobs(t *testing.T) []jobRow {
+	t.Helper()
+	var jobs []jobRow
+	err := json.Unmarshal([]byte(`[
+		{"id":["query","q-1"],"type":"query","duration_sec":12.3456,"servers":["s1"],
+		 "info":{"client_address":"10.0.0.1","client_port":40000,"query":"r.table(\"t\")"}},
+		{"id":["index_construction","i-1"],"type":"index_construction","duration_sec":1,"servers":["s1","s2"],
+		 "info":{"db":"app","table":"users","index":"email","progress":0.5}},
+		{"id":["backfill","b-1"],"type":"backfill","duration_sec":0.5,"servers":["s2"],
+		 "info":{"db":"app","table":"users","source_server":"s1","destination_server":"s2","progress":0.25}},
+		{"id":["disk_compaction","d-1"],"type":"disk_compaction","duration_sec":0,"servers":["s1"],"info":{}}
+	]`), &jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobs
+}
+
+func TestAdminJobsCmd(t *testing.T) {
+	t.Parallel()
+	cmd := newAdminJobsCmd(&rootConfig{})
+	if cmd.Flags().Lookup("json") == nil {
+		t.Error("missing --json flag")
+	}
+	if len(cmd.Commands()) != 1 || cmd.Commands()[0].Name() != "kill" {
+		t.Error("expected kill subcommand")
+	}
+}
+
+func TestFindJob(t *testing.T) {
+	t.Parallel()
+	jobs := testJobs(t)
+	for _, id := range []string{"i-1", `["index_construction","i-1"]`} {
+		job, err := findJob(jobs, id)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if job.Type != "index_construction" {
+			t.Errorf("%s: got %+v", id, job)
+		}
+	}
+	if _, err := findJob(jobs, "missing"); err == nil || !isQueryError(err) {
+		t.Errorf("expected query error for unknown id, got %v", err)
+	}
+	if _, err := findJob(jobs, `["query"`); err == nil {
+		t.Error("expected error for malformed JSON id")
+	}
+	if _, err := findJob(jobs, `["backfill","q-1"]`); err == nil {
+		t.Error("expected error when the type does not match")
+	}
+}
+
+func TestWriteJobs(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := writeJobs(&buf, testJobs(t)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"q-1", "12.346s", `10.0.0.1:40000 r.table("t")`,
+		"s1,s2", "app.users index email progress 0.5",
+		"app.users s1 -> s2 progress 0.25",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
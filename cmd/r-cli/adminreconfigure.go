@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+)
+
+type reconfigureConfig struct {
+	shards   int
+	replicas int
+	dryRun   bool
+	yes      bool
+}
+
+// shardConfig is one entry of the shards array of a table config.
+type shardConfig struct {
+	PrimaryReplica string   `json:"primary_replica"`
+	Replicas       []string `json:"replicas"`
+}
+
+type tableShardConfig struct {
+	Shards []shardConfig `json:"shards"`
+}
+
+// reconfigureResult is the reply of reconfigure(); config_changes holds one
+// old_val/new_val pair for the table.
+type reconfigureResult struct {
+	Reconfigured  int `json:"reconfigured"`
+	ConfigChanges []struct {
+		OldVal tableShardConfig `json:"old_val"`
+		NewVal tableShardConfig `json:"new_val"`
+	} `json:"config_changes"`
+}
+
+func newAdminReconfigureCmd(cfg *rootConfig) *cobra.Command {
+	rc := &reconfigureConfig{}
+	cmd := &cobra.Command{
+		Use:               "reconfigure <table|db.table>",
+		ValidArgsFunction: firstArg(completeTableRef(cfg)),
+		Short:             "Preview and apply a new shard/replica layout for a table",
+		Long: "Ask the server for the layout --shards and --replicas would produce (a\n" +
+			"reconfigure dry run), print how each shard changes, then apply it after\n" +
+			"confirmation. An omitted --shards or --replicas keeps the current value.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdminReconfigure(cmd.Context(), cfg, rc, args[0], os.Stdout)
+		},
+	}
+	f := cmd.Flags()
+	f.IntVar(&rc.shards, "shards", 0, "number of shards (default: current)")
+	f.IntVar(&rc.replicas, "replicas", 0, "replicas per shard (default: current)")
+	f.BoolVar(&rc.dryRun, "dry-run", false, "print the proposed changes without applying them")
+	f.BoolVarP(&rc.yes, "yes", "y", false, "skip confirmation prompt")
+	return cmd
+}
+
+func runAdminReconfigure(ctx context.Context, cfg *rootConfig, rc *reconfigureConfig, ref string, w io.Writer) error {
+	if rc.shards < 0 || rc.replicas < 0 || (rc.shards == 0 && rc.replicas == 0) {
+		return fmt.Errorf("admin reconfigure: requires a positive --shards or --replicas")
+	}
+	tbl, err := listTable(cfg, ref)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	opts, err := reconfigureOpts(ctx, exec, cfg, tbl, rc)
+	if err != nil {
+		return fmt.Errorf("admin reconfigure: %w", err)
+	}
+	lines, changed, err := planReconfigure(ctx, exec, cfg, tbl, opts)
+	if err != nil {
+		return fmt.Errorf("admin reconfigure: %w", err)
+	}
+	for _, l := range lines {
+		_, _ = fmt.Fprintln(w, l)
+	}
+	if rc.dryRun || !changed {
+		return nil
+	}
+	if !rc.yes {
+		if err := confirm("Reconfigure "+ref+"?", os.Stdin, cfg.quiet); err != nil {
+			return err
+		}
+	}
+	var res reconfigureResult
+	if err := fetchValue(ctx, exec, cfg, tbl.Reconfigure(opts), &res); err != nil {
+		return fmt.Errorf("admin reconfigure: %w", err)
+	}
+	data, _ := json.Marshal(map[string]int{"reconfigured": res.Reconfigured})
+	_, _ = fmt.Fprintf(w, "%s\n", data)
+	return nil
+}
+
+// planReconfigure runs reconfigure() as a dry run and describes the proposed
+// layout; changed is false when it matches the current one.
+func planReconfigure(ctx context.Context, exec *query.Executor, cfg *rootConfig, tbl reql.Term, opts reql.OptArgs) ([]string, bool, error) {
+	dry := reql.OptArgs{"dry_run": true}
+	for k, v := range opts {
+		dry[k] = v
+	}
+	var plan reconfigureResult
+	if err := fetchValue(ctx, exec, cfg, tbl.Reconfigure(dry), &plan); err != nil {
+		return nil, false, err
+	}
+	if len(plan.ConfigChanges) == 0 {
+		return []string{"no changes"}, false, nil
+	}
+	old, cur := plan.ConfigChanges[0].OldVal.Shards, plan.ConfigChanges[0].NewVal.Shards
+	if slices.EqualFunc(old, cur, shardConfigEqual) {
+		return []string{"no changes"}, false, nil
+	}
+	return diffShardConfig(old, cur), true, nil
+}
+
+// reconfigureOpts fills an unset --shards or --replicas from the current
+// table config, since reconfigure() requires both.
+func reconfigureOpts(ctx context.Context, exec *query.Executor, cfg *rootConfig, tbl reql.Term, rc *reconfigureConfig) (reql.OptArgs, error) {
+	shards, replicas := rc.shards, rc.replicas
+	if shards == 0 || replicas == 0 {
+		var cur tableShardConfig
+		if err := fetchValue(ctx, exec, cfg, tbl.Config(), &cur); err != nil {
+			return nil, err
+		}
+		if shards == 0 {
+			shards = len(cur.Shards)
+		}
+		if replicas == 0 && len(cur.Shards) > 0 {
+			replicas = len(cur.Shards[0].Replicas)
+		}
+	}
+	return reql.OptArgs{"shards": shards, "replicas": replicas}, nil
+}
+
+// diffShardConfig describes how the shard layout changes, one line per shard:
+// "+" added, "-" removed, "~" changed, " " unchanged.
+func diffShardConfig(old, cur []shardConfig) []string {
+	lines := []string{fmt.Sprintf("shards: %d -> %d", len(old), len(cur))}
+	for i := range max(len(old), len(cur)) {
+		n := i + 1
+		switch {
+		case i >= len(old):
+			lines = append(lines, fmt.Sprintf("+ shard %d: %s", n, describeShard(cur[i])))
+		case i >= len(cur):
+			lines = append(lines, fmt.Sprintf("- shard %d: %s", n, describeShard(old[i])))
+		case !shardConfigEqual(old[i], cur[i]):
+			lines = append(lines, fmt.Sprintf("~ shard %d: %s -> %s", n, describeShard(old[i]), describeShard(cur[i])))
+		default:
+			lines = append(lines, fmt.Sprintf("  shard %d: %s", n, describeShard(cur[i])))
+		}
+	}
+	return lines
+}
+
+func shardConfigEqual(a, b shardConfig) bool {
+	return a.PrimaryReplica == b.PrimaryReplica && slices.Equal(a.Replicas, b.Replicas)
+}
+
+func describeShard(s shardConfig) string {
+	return fmt.Sprintf("primary %s, replicas [%s]", s.PrimaryReplica, strings.Join(s.Replicas, " "))
+}
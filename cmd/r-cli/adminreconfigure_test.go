@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAdminReconfigureFlags(t *testing.T) {
+	t.Parallel()
+	cmd := newAdminReconfigureCmd(&rootConfig{})
+	for _, name := range []string{"shards", "replicas", "dry-run", "yes"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("missing --%s flag", name)
+		}
+	}
+}
+
+func TestRunAdminReconfigureValidation(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{database: "test"}
+	for _, rc := range []reconfigureConfig{{}, {shards: -1, replicas: 1}, {shards: 1, replicas: -2}} {
+		err := runAdminReconfigure(t.Context(), cfg, &rc, "users", nil)
+		if err == nil || !strings.Contains(err.Error(), "positive") {
+			t.Errorf("%+v: expected validation error, got %v", rc, err)
+		}
+	}
+	if err := runAdminReconfigure(t.Context(), &rootConfig{}, &reconfigureConfig{shards: 2}, "users", nil); err == nil {
+		t.Error("expected error for bare table without --db")
+	}
+}
+
+func TestDiffShardConfig(t *testing.T) {
+	t.Parallel()
+	old := []shardConfig{{PrimaryReplica: "a", Replicas: []string{"a"}}}
+	cur := []shardConfig{
+		{PrimaryReplica: "a", Replicas: []string{"a"}},
+		{PrimaryReplica: "b", Replicas: []string{"b"}},
+	}
+	want := []string{
+		"shards: 1 -> 2",
+		"  shard 1: primary a, replicas [a]",
+		"+ shard 2: primary b, replicas [b]",
+	}
+	if got := diffShardConfig(old, cur); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("grow: got %q", got)
+	}
+
+	want = []string{
+		"shards: 2 -> 1",
+		"~ shard 1: primary a, replicas [a] -> primary b, replicas [b a]",
+		"- shard 2: primary b, replicas [b]",
+	}
+	shrunk := []shardConfig{{PrimaryReplica: "b", Replicas: []string{"b", "a"}}}
+	if got := diffShardConfig(cur, shrunk); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("shrink: got %q", got)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/query"
+)
+
+// userAccount is one entry of admin user list: a rethinkdb.users row with
+// the grants from rethinkdb.permissions.
+type userAccount struct {
+	User        string      `json:"user"`
+	Password    bool        `json:"password"`
+	Permissions []userGrant `json:"permissions"`
+}
+
+// userGrant is one permissions row; Scope is "global", "db" or "db.table".
+type userGrant struct {
+	Scope       string          `json:"scope"`
+	Permissions map[string]bool `json:"permissions"`
+}
+
+type permissionRow struct {
+	User        string          `json:"user"`
+	Database    string          `json:"database"`
+	Table       string          `json:"table"`
+	Permissions map[string]bool `json:"permissions"`
+}
+
+func newAdminUserCmd(cfg *rootConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage user accounts and their permissions",
+	}
+	passwd := newUserSetPasswordCmd(cfg)
+	passwd.Use = "passwd <name>"
+	cmd.AddCommand(
+		newAdminUserListCmd(cfg),
+		newUserCreateCmd(cfg),
+		newUserDeleteCmd(cfg),
+		passwd,
+		newGrantCmd(cfg),
+	)
+	return cmd
+}
+
+func newAdminUserListCmd(cfg *rootConfig) *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List users with their global, database and table permissions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runAdminUserList(cmd.Context(), cfg, asJSON, os.Stdout)
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the accounts as JSON")
+	return cmd
+}
+
+func runAdminUserList(ctx context.Context, cfg *rootConfig, asJSON bool, w io.Writer) error {
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	accounts, err := readUserAccounts(ctx, exec, cfg)
+	if err != nil {
+		return fmt.Errorf("admin user list: %w", err)
+	}
+	out, err := openOutputTarget(cfg.output, w)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return out.finish(enc.Encode(accounts))
+	}
+	return out.finish(writeUserAccounts(out, accounts))
+}
+
+func readUserAccounts(ctx context.Context, exec *query.Executor, cfg *rootConfig) ([]userAccount, error) {
+	var users []struct {
+		ID       string `json:"id"`
+		Password bool   `json:"password"`
+	}
+	if err := fetchValue(ctx, exec, cfg, systemTable("users").OrderBy("id"), &users); err != nil {
+		return nil, err
+	}
+	var perms []permissionRow
+	if err := fetchValue(ctx, exec, cfg, systemTable("permissions").CoerceTo("array"), &perms); err != nil {
+		return nil, err
+	}
+	accounts := make([]userAccount, 0, len(users))
+	for _, u := range users {
+		accounts = append(accounts, userAccount{User: u.ID, Password: u.Password, Permissions: userGrants(u.ID, perms)})
+	}
+	return accounts, nil
+}
+
+// userGrants returns the grants of user, global first, then by scope name.
+func userGrants(user string, rows []permissionRow) []userGrant {
+	grants := []userGrant{}
+	for _, r := range rows {
+		if r.User != user {
+			continue
+		}
+		scope := "global"
+		switch {
+		case r.Table != "":
+			scope = r.Database + "." + r.Table
+		case r.Database != "":
+			scope = r.Database
+		}
+		grants = append(grants, userGrant{Scope: scope, Permissions: r.Permissions})
+	}
+	sort.SliceStable(grants, func(i, j int) bool {
+		if (grants[i].Scope == "global") != (grants[j].Scope == "global") {
+			return grants[i].Scope == "global"
+		}
+		return grants[i].Scope < grants[j].Scope
+	})
+	return grants
+}
+
+// writeUserAccounts renders one line per grant, or one line for users without any.
+func writeUserAccounts(w io.Writer, accounts []userAccount) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "USER\tPASSWORD\tSCOPE\tPERMISSIONS")
+	for _, a := range accounts {
+		if len(a.Permissions) == 0 {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t-\t-\n", a.User, yesNo(a.Password))
+		}
+		for _, g := range a.Permissions {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.User, yesNo(a.Password), g.Scope, formatPermissions(g.Permissions))
+		}
+	}
+	return tw.Flush()
+}
+
+// formatPermissions renders {"read":true,"write":false} as "read,-write".
+func formatPermissions(perms map[string]bool) string {
+	parts := make([]string, 0, len(perms))
+	for _, name := range []string{"read", "write", "config", "connect"} {
+		v, ok := perms[name]
+		switch {
+		case !ok:
+		case v:
+			parts = append(parts, name)
+		default:
+			parts = append(parts, "-"+name)
+		}
+	}
+	return strings.Join(parts, ",")
+}
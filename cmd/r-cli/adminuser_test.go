@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAdminUserSubcommands(t *testing.T) {
+	t.Parallel()
+	cmd := newAdminUserCmd(&rootConfig{})
+	found := map[string]bool{}
+	for _, sub := range cmd.Commands() {
+		found[sub.Name()] = true
+	}
+	for _, name := range []string{"list", "create", "delete", "passwd", "grant"} {
+		if !found[name] {
+			t.Errorf("admin user %s subcommand not registered", name)
+		}
+	}
+}
+
+func TestUserGrants(t *testing.T) {
+	t.Parallel()
+	var rows []permissionRow
+	if err := json.Unmarshal([]byte(`[
+		{"user":"alice","database":"app","table":"users","permissions":{"write":false}},
+		{"user":"bob","permissions":{"read":true}},
+		{"user":"alice","database":"app","permissions":{"read":true,"write":true}},
+		{"user":"alice","permissions":{"connect":true}}
+	]`), &rows); err != nil {
+		t.Fatal(err)
+	}
+	grants := userGrants("alice", rows)
+	scopes := make([]string, len(grants))
+	for i, g := range grants {
+		scopes[i] = g.Scope
+	}
+	if strings.Join(scopes, "|") != "global|app|app.users" {
+		t.Errorf("scopes: got %q", scopes)
+	}
+	if got := userGrants("carol", rows); got == nil || len(got) != 0 {
+		t.Errorf("expected empty non-nil grants, got %#v", got)
+	}
+}
+
+func TestFormatPermissions(t *testing.T) {
+	t.Parallel()
+	got := formatPermissions(map[string]bool{"connect": true, "write": false, "read": true})
+	if got != "read,-write,connect" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWriteUserAccounts(t *testing.T) {
+	t.Parallel()
+	accounts := []userAccount{
+		{User: "admin", Permissions: []userGrant{}},
+		{User: "alice", Password: true, Permissions: []userGrant{
+			{Scope: "global", Permissions: map[string]bool{"read": true}},
+			{Scope: "app", Permissions: map[string]bool{"write": true}},
+		}},
+	}
+	var buf bytes.Buffer
+	if err := writeUserAccounts(&buf, accounts); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header + 3 lines, got:\n%s", buf.String())
+	}
+	if f := strings.Fields(lines[1]); strings.Join(f, " ") != "admin no - -" {
+		t.Errorf("admin line: %q", lines[1])
+	}
+	if f := strings.Fields(lines[3]); strings.Join(f, " ") != "alice yes app write" {
+		t.Errorf("alice app line: %q", lines[3])
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"r-cli/internal/query"
+)
+
+// clearScreen moves the cursor home and clears the terminal before a refresh.
+const clearScreen = "\033[H\033[2J"
+
+type clusterStatsConfig struct {
+	table  string
+	server string
+	watch  time.Duration
+	asJSON bool
+}
+
+// statsRow is one rethinkdb.stats document reduced to its throughput
+// counters. Kind is the first element of the row id: cluster, server, table
+// or table_server. Queries and connections exist only for cluster and server rows.
+type statsRow struct {
+	Kind              string   `json:"kind"`
+	DB                string   `json:"db,omitempty"`
+	Table             string   `json:"table,omitempty"`
+	Server            string   `json:"server,omitempty"`
+	QueriesPerSec     *float64 `json:"queries_per_sec,omitempty"`
+	ReadDocsPerSec    float64  `json:"read_docs_per_sec"`
+	WrittenDocsPerSec float64  `json:"written_docs_per_sec"`
+	ClientConnections *int     `json:"client_connections,omitempty"`
+}
+
+func newClusterStatsCmd(cfg *rootConfig) *cobra.Command {
+	sc := &clusterStatsConfig{}
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show read/write throughput per cluster, server and table",
+		Long: "Read rethinkdb.stats and print queries, document reads and writes per second\n" +
+			"for the cluster, each server and each table. --table and --server narrow the\n" +
+			"output to one table or server (and their per-server breakdown). --watch\n" +
+			"refreshes at the given interval until interrupted.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runClusterStats(cmd.Context(), cfg, sc, os.Stdout)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&sc.table, "table", "", "only this table (table in --db, or db.table)")
+	_ = cmd.RegisterFlagCompletionFunc("table", completeTableRef(cfg))
+	f.StringVar(&sc.server, "server", "", "only this server (by name)")
+	f.DurationVar(&sc.watch, "watch", 0, "refresh interval, e.g. 2s (0 = print once)")
+	f.BoolVar(&sc.asJSON, "json", false, "print each snapshot as one JSON array line")
+	return cmd
+}
+
+func runClusterStats(ctx context.Context, cfg *rootConfig, sc *clusterStatsConfig, w io.Writer) error {
+	if sc.watch < 0 {
+		return fmt.Errorf("--watch must be >= 0")
+	}
+	filter, err := newStatsFilter(cfg, sc)
+	if err != nil {
+		return err
+	}
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	redraw := sc.watch > 0 && !sc.asJSON && isTerminalWriter(w)
+	for {
+		rows, err := fetchStats(ctx, exec, cfg)
+		if err != nil {
+			return fmt.Errorf("stats: %w", err)
+		}
+		if redraw {
+			_, _ = io.WriteString(w, clearScreen)
+		}
+		if err := writeStatsSnapshot(w, filter.apply(rows), sc, time.Now()); err != nil {
+			return err
+		}
+		if sc.watch == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sc.watch):
+		}
+	}
+}
+
+// isTerminalWriter reports whether w is a terminal, so refreshes can redraw in place.
+func isTerminalWriter(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd())) //nolint:gosec
+}
+
+// fetchStats reads rethinkdb.stats once; each read gets its own --timeout.
+func fetchStats(ctx context.Context, exec *query.Executor, cfg *rootConfig) ([]statsRow, error) {
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+	var raw []json.RawMessage
+	if err := fetchValue(ctx, exec, cfg, systemTable("stats").CoerceTo("array"), &raw); err != nil {
+		return nil, err
+	}
+	return parseStatsRows(raw), nil
+}
+
+// parseStatsRows decodes stats documents, skipping rows without counters
+// (servers that could not be reached report only an error).
+func parseStatsRows(raw []json.RawMessage) []statsRow {
+	rows := make([]statsRow, 0, len(raw))
+	for _, r := range raw {
+		var doc struct {
+			ID          []json.RawMessage `json:"id"`
+			DB          string            `json:"db"`
+			Table       string            `json:"table"`
+			Server      string            `json:"server"`
+			QueryEngine *struct {
+				QueriesPerSec     *float64 `json:"queries_per_sec"`
+				ReadDocsPerSec    float64  `json:"read_docs_per_sec"`
+				WrittenDocsPerSec float64  `json:"written_docs_per_sec"`
+				ClientConnections *int     `json:"client_connections"`
+			} `json:"query_engine"`
+		}
+		if json.Unmarshal(r, &doc) != nil || doc.QueryEngine == nil || len(doc.ID) == 0 {
+			continue
+		}
+		row := statsRow{
+			DB: doc.DB, Table: doc.Table, Server: doc.Server,
+			QueriesPerSec:     doc.QueryEngine.QueriesPerSec,
+			ReadDocsPerSec:    doc.QueryEngine.ReadDocsPerSec,
+			WrittenDocsPerSec: doc.QueryEngine.WrittenDocsPerSec,
+			ClientConnections: doc.QueryEngine.ClientConnections,
+		}
+		_ = json.Unmarshal(doc.ID[0], &row.Kind)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// statsFilter selects the rows for --table and --server. With neither set it
+// keeps the cluster, server and table rows and drops the table_server breakdown.
+type statsFilter struct {
+	db, table, server string
+}
+
+func newStatsFilter(cfg *rootConfig, sc *clusterStatsConfig) (statsFilter, error) {
+	f := statsFilter{server: sc.server, table: sc.table}
+	if strings.Contains(sc.table, ".") {
+		db, table, err := parseTableRef(sc.table)
+		if err != nil {
+			return f, err
+		}
+		f.db, f.table = db, table
+	} else if sc.table != "" {
+		f.db = cfg.database
+	}
+	return f, nil
+}
+
+// statsKinds orders the output: cluster first, per-table breakdown last.
+var statsKinds = []string{"cluster", "server", "table", "table_server"}
+
+// apply returns the kept rows ordered by kind, then name.
+func (f statsFilter) apply(rows []statsRow) []statsRow {
+	out := []statsRow{}
+	for _, r := range rows {
+		if f.keep(r) {
+			out = append(out, r)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b statsRow) int {
+		if c := cmp.Compare(slices.Index(statsKinds, a.Kind), slices.Index(statsKinds, b.Kind)); c != 0 {
+			return c
+		}
+		return strings.Compare(statsRowName(a), statsRowName(b))
+	})
+	return out
+}
+
+func (f statsFilter) keep(r statsRow) bool {
+	tableOK := f.table == "" || (r.Table == f.table && (f.db == "" || r.DB == f.db))
+	serverOK := f.server == "" || r.Server == f.server
+	switch r.Kind {
+	case "cluster":
+		return f.table == "" && f.server == ""
+	case "server":
+		return f.table == "" && serverOK
+	case "table":
+		return f.server == "" && tableOK
+	case "table_server":
+		return (f.table != "" || f.server != "") && tableOK && serverOK
+	}
+	return false
+}
+
+// writeStatsSnapshot prints rows as an aligned table headed by the time, or
+// as one JSON array line with --json.
+func writeStatsSnapshot(w io.Writer, rows []statsRow, sc *clusterStatsConfig, at time.Time) error {
+	if sc.asJSON {
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if sc.watch > 0 {
+		_, _ = fmt.Fprintf(tw, "%s\n", at.Format("15:04:05"))
+	}
+	_, _ = fmt.Fprintln(tw, "KIND\tNAME\tQUERIES/S\tREADS/S\tWRITES/S\tCONNECTIONS")
+	for _, r := range rows {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f\t%.1f\t%s\n", r.Kind, statsRowName(r),
+			optFloat(r.QueriesPerSec), r.ReadDocsPerSec, r.WrittenDocsPerSec, optInt(r.ClientConnections))
+	}
+	return tw.Flush()
+}
+
+func statsRowName(r statsRow) string {
+	switch r.Kind {
+	case "server":
+		return r.Server
+	case "table":
+		return r.DB + "." + r.Table
+	case "table_server":
+		return r.DB + "." + r.Table + "@" + r.Server
+	}
+	return "-"
+}
+
+func optFloat(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", *v)
+}
+
+func optInt(v *int) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprint(*v)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testStatsRows(t *testing.T) []statsRow {
+	t.Helper()
+	var raw []json.RawMessage
+	err := json.Unmarshal([]byte(`[
+		{"id":["table_server","t1","s1"],"db":"app","table":"users","server":"s1",
+		 "query_engine":{"read_docs_per_sec":1,"written_docs_per_sec":2}},
+		{"id":["table","t1"],"db":"app","table":"users","query_engine":{"read_docs_per_sec":3,"written_docs_per_sec":4}},
+		{"id":["table","t2"],"db":"app","table":"orders","query_engine":{"read_docs_per_sec":0,"written_docs_per_sec":0}},
+		{"id":["server","s1"],"server":"s1","query_engine":{"queries_per_sec":5,"read_docs_per_sec":3,"written_docs_per_sec":4,"client_connections":2}},
+		{"id":["server","s2"],"server":"s2","error":"unreachable"},
+		{"id":["cluster"],"query_engine":{"queries_per_sec":5,"read_docs_per_sec":3,"written_docs_per_sec":4,"client_connections":2}}
+	]`), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parseStatsRows(raw)
+}
+
+func statsNames(rows []statsRow) string {
+	names := make([]string, len(rows))
+	for i, r := range rows {
+		names[i] = r.Kind + ":" + statsRowName(r)
+	}
+	return strings.Join(names, "|")
+}
+
+func TestParseStatsRowsSkipsErrors(t *testing.T) {
+	t.Parallel()
+	rows := testStatsRows(t)
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows without the unreachable server, got %d", len(rows))
+	}
+	for _, r := range rows {
+		if r.Kind == "server" && (r.QueriesPerSec == nil || *r.QueriesPerSec != 5 || r.ClientConnections == nil) {
+			t.Errorf("server row: %+v", r)
+		}
+		if r.Kind == "table" && (r.QueriesPerSec != nil || r.ClientConnections != nil) {
+			t.Errorf("table row should have no queries/connections: %+v", r)
+		}
+	}
+}
+
+func TestStatsFilter(t *testing.T) {
+	t.Parallel()
+	rows := testStatsRows(t)
+	tests := []struct {
+		cfg  rootConfig
+		sc   clusterStatsConfig
+		want string
+	}{
+		{rootConfig{}, clusterStatsConfig{}, "cluster:-|server:s1|table:app.orders|table:app.users"},
+		{rootConfig{database: "app"}, clusterStatsConfig{table: "users"}, "table:app.users|table_server:app.users@s1"},
+		{rootConfig{}, clusterStatsConfig{table: "other.users"}, ""},
+		{rootConfig{}, clusterStatsConfig{server: "s1"}, "server:s1|table_server:app.users@s1"},
+		{rootConfig{}, clusterStatsConfig{table: "app.users", server: "s1"}, "table_server:app.users@s1"},
+	}
+	for _, tc := range tests {
+		f, err := newStatsFilter(&tc.cfg, &tc.sc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := statsNames(f.apply(rows)); got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc.sc, got, tc.want)
+		}
+	}
+	if _, err := newStatsFilter(&rootConfig{}, &clusterStatsConfig{table: "app."}); err == nil {
+		t.Error("expected error for malformed db.table")
+	}
+}
+
+func TestWriteStatsSnapshot(t *testing.T) {
+	t.Parallel()
+	rows := testStatsRows(t)[3:] // server s1 and cluster
+	at := time.Date(2024, 1, 1, 10, 20, 30, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := writeStatsSnapshot(&buf, rows, &clusterStatsConfig{watch: time.Second}, at); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || lines[0] != "10:20:30" {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+	if f := strings.Fields(lines[2]); strings.Join(f, " ") != "server s1 5.0 3.0 4.0 2" {
+		t.Errorf("server line: %q", lines[2])
+	}
+
+	buf.Reset()
+	if err := writeStatsSnapshot(&buf, rows, &clusterStatsConfig{asJSON: true}, at); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []statsRow
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 2 {
+		t.Errorf("json output %q: %v", buf.String(), err)
+	}
+}
+
+func TestRunClusterStatsRejectsNegativeWatch(t *testing.T) {
+	t.Parallel()
+	if err := runClusterStats(t.Context(), &rootConfig{}, &clusterStatsConfig{watch: -time.Second}, nil); err == nil {
+		t.Error("expected error for negative --watch")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/reql"
+)
+
+// completionTimeout bounds the server round trip of one completion request,
+// so an unreachable server never stalls the shell.
+const completionTimeout = 2 * time.Second
+
+// staticFlagValues lists the values offered for enum-like global flags.
+var staticFlagValues = map[string][]string{
+	"format":        {"json", "jsonl", "raw", "table", "tsv", "csv", "parquet", "msgpack", "template", "sqlite"},
+	"color":         {"auto", "always", "never"},
+	"time-format":   {"native", "local", "relative", "unix-ms", "raw"},
+	"binary-format": {"native", "files", "raw"},
+	"read-mode":     {"single", "majority", "outdated"},
+	"durability":    {"hard", "soft"},
+	"protocol":      {"auto", "v1_0", "v0_4"},
+}
+
+// registerCompletions adds completion functions for the global flags: fixed
+// values for the enum flags, live database names for --db and profile names
+// for --conn-profile.
+func registerCompletions(cmd *cobra.Command, cfg *rootConfig) {
+	for flag, values := range staticFlagValues {
+		_ = cmd.RegisterFlagCompletionFunc(flag, cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp))
+	}
+	_ = cmd.RegisterFlagCompletionFunc("db", completeDBs(cfg))
+	_ = cmd.RegisterFlagCompletionFunc("conn-profile", completeProfiles)
+}
+
+// firstArg restricts fn to the first positional argument.
+func firstArg(fn cobra.CompletionFunc) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return fn(cmd, args, toComplete)
+	}
+}
+
+// completeDBs completes database names from the server.
+func completeDBs(cfg *rootConfig) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var out []string
+		completionQuery(cmd, cfg, func(_ *rootConfig, list func(reql.Term) []string) {
+			out = list(reql.DBList())
+		})
+		return withPrefix(out, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeTableRef completes a table argument: "db.table" once a dot is typed,
+// tables of --db otherwise, plus "db." stems so other databases can be reached.
+func completeTableRef(cfg *rootConfig) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var out []string
+		directive := cobra.ShellCompDirectiveNoFileComp
+		completionQuery(cmd, cfg, func(c *rootConfig, list func(reql.Term) []string) {
+			if db, _, ok := strings.Cut(toComplete, "."); ok {
+				for _, t := range list(reql.DB(db).TableList()) {
+					out = append(out, db+"."+t)
+				}
+				return
+			}
+			directive |= cobra.ShellCompDirectiveNoSpace
+			if c.database != "" {
+				out = list(reql.DB(c.database).TableList())
+			}
+			for _, db := range list(reql.DBList()) {
+				out = append(out, db+".")
+			}
+		})
+		return withPrefix(out, toComplete), directive
+	}
+}
+
+// completeProfiles completes connection profile names from the config file.
+func completeProfiles(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	path, err := configPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cf, err := loadConfigFile(path)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(cf.Profiles))
+	for name := range cf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return withPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionQuery resolves a copy of cfg the way PersistentPreRunE would
+// (cobra's completion command skips that hook), connects, and calls fn with
+// a listing function sharing one connection and one completionTimeout.
+// Password input from stdin or a prompt is disabled, since the shell owns the
+// terminal; any failure yields no candidates.
+func completionQuery(cmd *cobra.Command, cfg *rootConfig, fn func(c *rootConfig, list func(reql.Term) []string)) {
+	c := *cfg
+	c.passwordStdin, c.passwordPrompt = false, false
+	if err := c.resolve(cmd.Flags().Changed); err != nil {
+		return
+	}
+	timeout := completionTimeout
+	if c.timeout > 0 && c.timeout < timeout {
+		timeout = c.timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	exec, cleanup, err := newExecutor(&c)
+	if err != nil {
+		return
+	}
+	defer cleanup()
+	fn(&c, func(term reql.Term) []string {
+		names, err := fetchNames(ctx, exec, &c, term)
+		if err != nil {
+			return nil
+		}
+		return names
+	})
+}
+
+func withPrefix(names []string, prefix string) []string {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		if strings.HasPrefix(n, prefix) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runComplete runs cobra's hidden __complete command and returns its output lines.
+func runComplete(t *testing.T, args ...string) []string {
+	t.Helper()
+	root := newRootCmd()
+	buf := &bytes.Buffer{}
+	root.SetOut(buf)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs(append([]string{"__complete"}, args...))
+	if err := root.Execute(); err != nil {
+		t.Fatalf("__complete %v: %v", args, err)
+	}
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestCompleteFormatFlag(t *testing.T) {
+	t.Parallel()
+	got := runComplete(t, "--format", "")
+	want := []string{"json", "jsonl", "raw", "table", "tsv", "csv", "parquet", "msgpack", "template", "sqlite", ":4"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCompleteConnProfile(t *testing.T) {
+	writeTestConfig(t, testConfigYAML)
+	got := runComplete(t, "--conn-profile", "")
+	if strings.Join(got, ",") != "dev,prod,:4" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCompleteTableUnreachable(t *testing.T) {
+	t.Parallel()
+	start := time.Now()
+	got := runComplete(t, "-H", "127.0.0.1", "-P", "1", "get", "")
+	if len(got) != 1 || !strings.HasPrefix(got[0], ":") {
+		t.Errorf("expected only a directive, got %q", got)
+	}
+	if d := time.Since(start); d > completionTimeout+time.Second {
+		t.Errorf("completion took %s", d)
+	}
+}
+
+func TestCompleteSecondArgNoCandidates(t *testing.T) {
+	t.Parallel()
+	got := runComplete(t, "-H", "127.0.0.1", "-P", "1", "get", "users", "")
+	if strings.Join(got, ",") != ":4" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	t.Parallel()
+	got := withPrefix([]string{"app", "apple", "test"}, "app")
+	if strings.Join(got, ",") != "app,apple" {
+		t.Errorf("got %q", got)
+	}
+}
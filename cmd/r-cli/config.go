@@ -0,0 +1,323 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the r-cli config file: named connection profiles, the
+// profile used when --conn-profile is not given, and the REPL .alias macros.
+type configFile struct {
+	Default  string                        `yaml:"default,omitempty"`
+	Profiles map[string]*connectionProfile `yaml:"profiles,omitempty"`
+	Aliases  map[string]string             `yaml:"aliases,omitempty"`
+}
+
+// connectionProfile holds connection defaults; empty fields are not applied.
+type connectionProfile struct {
+	Host               string `yaml:"host,omitempty"`
+	Port               int    `yaml:"port,omitempty"`
+	User               string `yaml:"user,omitempty"`
+	PasswordFile       string `yaml:"password_file,omitempty"`
+	DB                 string `yaml:"db,omitempty"`
+	Format             string `yaml:"format,omitempty"`
+	TLSCert            string `yaml:"tls_cert,omitempty"`
+	TLSClientCert      string `yaml:"tls_client_cert,omitempty"`
+	TLSKey             string `yaml:"tls_key,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+	Prompt             string `yaml:"prompt,omitempty"`
+	Keymap             string `yaml:"keymap,omitempty"`
+}
+
+// profileKeys lists the keys accepted by config set, in display order.
+var profileKeys = []string{
+	"host", "port", "user", "password_file", "db", "format",
+	"tls_cert", "tls_client_cert", "tls_key", "insecure_skip_verify", "prompt", "keymap",
+}
+
+// configPath returns $RCLI_CONFIG, or r-cli/config.yaml under
+// $XDG_CONFIG_HOME (default ~/.config).
+func configPath() (string, error) {
+	if p := os.Getenv("RCLI_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("config: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "r-cli", "config.yaml"), nil
+}
+
+// loadConfigFile reads the config file at path; a missing file is an empty config.
+func loadConfigFile(path string) (*configFile, error) {
+	cf := &configFile{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cf, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cf); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cf, nil
+}
+
+// saveConfigFile writes cf to path with owner-only permissions, replacing the
+// old file atomically.
+func saveConfigFile(path string, cf *configFile) error {
+	data, err := yaml.Marshal(cf)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	name := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(name)
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(name)
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := os.Rename(name, path); err != nil {
+		_ = os.Remove(name)
+		return fmt.Errorf("config: %w", err)
+	}
+	return nil
+}
+
+// resolveProfile applies the --conn-profile profile, or the config file
+// default, to flags that were not set on the command line. Env vars are
+// applied afterwards and take precedence over the profile.
+func (c *rootConfig) resolveProfile(changed func(string) bool) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	cf, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	name := c.connProfile
+	if name == "" {
+		name = cf.Default
+	}
+	if name == "" {
+		return nil
+	}
+	p, ok := cf.Profiles[name]
+	if !ok {
+		return fmt.Errorf("config: profile %q not found in %s", name, path)
+	}
+	c.applyProfile(p, changed)
+	return nil
+}
+
+func (c *rootConfig) applyProfile(p *connectionProfile, changed func(string) bool) {
+	set := func(dst *string, flag, v string) {
+		if v != "" && !changed(flag) {
+			*dst = v
+		}
+	}
+	set(&c.host, "host", p.Host)
+	set(&c.user, "user", p.User)
+	set(&c.passwordFile, "password-file", p.PasswordFile)
+	set(&c.database, "db", p.DB)
+	set(&c.format, "format", p.Format)
+	set(&c.tlsCACert, "tls-cert", p.TLSCert)
+	set(&c.tlsClientCert, "tls-client-cert", p.TLSClientCert)
+	set(&c.tlsKey, "tls-key", p.TLSKey)
+	set(&c.replPrompt, "prompt", p.Prompt)
+	set(&c.replKeymap, "keymap", p.Keymap)
+	if p.Port != 0 && !changed("port") {
+		c.port = p.Port
+	}
+	if p.InsecureSkipVerify && !changed("insecure-skip-verify") {
+		c.insecureSkipVerify = true
+	}
+}
+
+// setProfileKey sets one key of p from its string form; an empty value clears it.
+func setProfileKey(p *connectionProfile, key, value string) error {
+	strs := map[string]*string{
+		"host": &p.Host, "user": &p.User, "password_file": &p.PasswordFile, "db": &p.DB,
+		"format": &p.Format, "tls_cert": &p.TLSCert, "tls_client_cert": &p.TLSClientCert, "tls_key": &p.TLSKey,
+		"prompt": &p.Prompt, "keymap": &p.Keymap,
+	}
+	if dst, ok := strs[key]; ok {
+		*dst = value
+		return nil
+	}
+	switch key {
+	case "port":
+		if value == "" {
+			p.Port = 0
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > 65535 {
+			return fmt.Errorf("config set: port %q: not a valid port number", value)
+		}
+		p.Port = n
+	case "insecure_skip_verify":
+		if value == "" {
+			p.InsecureSkipVerify = false
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("config set: insecure_skip_verify %q: not a boolean", value)
+		}
+		p.InsecureSkipVerify = b
+	default:
+		return fmt.Errorf("config set: unknown key %q (valid: %s)", key, strings.Join(profileKeys, ", "))
+	}
+	return nil
+}
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage connection profiles in the config file",
+		Long: "Manage named connection profiles stored in ~/.config/r-cli/config.yaml\n" +
+			"($XDG_CONFIG_HOME and RCLI_CONFIG override the location). Select a profile\n" +
+			"with --conn-profile, or make one the default with config set --default.\n" +
+			"Flags and RETHINKDB_* env vars override profile values.",
+	}
+	cmd.AddCommand(newConfigListCmd(), newConfigSetCmd())
+	return cmd
+}
+
+func newConfigListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List connection profiles (* marks the default)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := configPath()
+			if err != nil {
+				return err
+			}
+			cf, err := loadConfigFile(path)
+			if err != nil {
+				return err
+			}
+			return writeProfiles(os.Stdout, cf)
+		},
+	}
+}
+
+func newConfigSetCmd() *cobra.Command {
+	var makeDefault bool
+	cmd := &cobra.Command{
+		Use:   "set <profile> [key=value...]",
+		Short: "Create or update a connection profile",
+		Long: "Create or update a connection profile. Keys: " + strings.Join(profileKeys, ", ") + ".\n" +
+			"An empty value (key=) removes the key.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := configPath()
+			if err != nil {
+				return err
+			}
+			return runConfigSet(path, args[0], args[1:], makeDefault)
+		},
+	}
+	cmd.Flags().BoolVar(&makeDefault, "default", false, "use this profile when --conn-profile is not given")
+	return cmd
+}
+
+func runConfigSet(path, name string, pairs []string, makeDefault bool) error {
+	if name == "" {
+		return fmt.Errorf("config set: profile name must not be empty")
+	}
+	cf, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if cf.Profiles == nil {
+		cf.Profiles = map[string]*connectionProfile{}
+	}
+	p := cf.Profiles[name]
+	if p == nil {
+		p = &connectionProfile{}
+	}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("config set: expected key=value, got %q", pair)
+		}
+		if err := setProfileKey(p, key, value); err != nil {
+			return err
+		}
+	}
+	cf.Profiles[name] = p
+	if makeDefault {
+		cf.Default = name
+	}
+	return saveConfigFile(path, cf)
+}
+
+// writeProfiles renders one aligned line per profile, sorted by name.
+func writeProfiles(w io.Writer, cf *configFile) error {
+	names := make([]string, 0, len(cf.Profiles))
+	for name := range cf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "\tNAME\tADDRESS\tUSER\tDB\tFORMAT\tTLS")
+	for _, name := range names {
+		p := cf.Profiles[name]
+		mark := ""
+		if name == cf.Default {
+			mark = "*"
+		}
+		addr := "-"
+		if p.Host != "" || p.Port != 0 {
+			addr = orDash(p.Host) + ":" + orDash(portString(p.Port))
+		}
+		tls := p.TLSCert != "" || p.TLSClientCert != "" || p.InsecureSkipVerify
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", mark, name, addr,
+			orDash(p.User), orDash(p.DB), orDash(p.Format), yesNo(tls))
+	}
+	return tw.Flush()
+}
+
+func portString(port int) string {
+	if port == 0 {
+		return ""
+	}
+	return strconv.Itoa(port)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RCLI_CONFIG", path)
+	return path
+}
+
+const testConfigYAML = `default: dev
+profiles:
+  dev:
+    host: devhost
+    port: 29015
+    db: devdb
+  prod:
+    host: prodhost
+    user: ops
+    format: table
+    tls_cert: /etc/ca.pem
+    prompt: "PROD {user}@{host}> "
+`
+
+func TestConfigPath(t *testing.T) {
+	t.Setenv("RCLI_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	got, err := configPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != filepath.Join("/xdg", "r-cli", "config.yaml") {
+		t.Errorf("got %q", got)
+	}
+	t.Setenv("RCLI_CONFIG", "/custom.yaml")
+	if got, _ := configPath(); got != "/custom.yaml" {
+		t.Errorf("got %q, want /custom.yaml", got)
+	}
+}
+
+func TestLoadConfigFileMissing(t *testing.T) {
+	t.Parallel()
+	cf, err := loadConfigFile(filepath.Join(t.TempDir(), "nope.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cf.Profiles) != 0 || cf.Default != "" {
+		t.Errorf("expected empty config, got %+v", cf)
+	}
+}
+
+func TestLoadConfigFileInvalid(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("profiles: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfigFile(path); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestResolveProfileDefault(t *testing.T) {
+	writeTestConfig(t, testConfigYAML)
+	cfg := &rootConfig{host: "localhost", port: 28015, user: "admin"}
+	if err := cfg.resolveProfile(func(string) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.host != "devhost" || cfg.port != 29015 || cfg.database != "devdb" || cfg.user != "admin" {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestResolveProfileNamed(t *testing.T) {
+	writeTestConfig(t, testConfigYAML)
+	cfg := &rootConfig{host: "localhost", port: 28015, user: "admin", connProfile: "prod"}
+	if err := cfg.resolveProfile(func(string) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.host != "prodhost" || cfg.port != 28015 || cfg.user != "ops" || cfg.format != "table" || cfg.tlsCACert != "/etc/ca.pem" ||
+		cfg.replPrompt != "PROD {user}@{host}> " {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestResolveProfileFlagWins(t *testing.T) {
+	writeTestConfig(t, testConfigYAML)
+	cfg := &rootConfig{host: "flaghost", port: 28015, connProfile: "dev"}
+	if err := cfg.resolveProfile(func(name string) bool { return name == "host" }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.host != "flaghost" || cfg.port != 29015 {
+		t.Errorf("got host %q port %d", cfg.host, cfg.port)
+	}
+}
+
+func TestResolveEnvOverridesProfile(t *testing.T) {
+	writeTestConfig(t, testConfigYAML)
+	t.Setenv("RETHINKDB_HOST", "envhost")
+	cfg := &rootConfig{host: "localhost", port: 28015}
+	if err := cfg.resolve(func(string) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.host != "envhost" || cfg.port != 29015 {
+		t.Errorf("got host %q port %d", cfg.host, cfg.port)
+	}
+}
+
+func TestResolveProfileUnknown(t *testing.T) {
+	writeTestConfig(t, testConfigYAML)
+	cfg := &rootConfig{connProfile: "staging"}
+	err := cfg.resolveProfile(func(string) bool { return false })
+	if err == nil || !strings.Contains(err.Error(), `"staging"`) {
+		t.Errorf("expected profile not found error, got %v", err)
+	}
+}
+
+func TestRunConfigSet(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "sub", "config.yaml")
+	if err := runConfigSet(path, "dev", []string{"host=devhost", "port=29015", "insecure_skip_verify=true"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := runConfigSet(path, "dev", []string{"port=", "db=app"}, false); err != nil {
+		t.Fatal(err)
+	}
+	cf, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := cf.Profiles["dev"]
+	if cf.Default != "dev" || p == nil || p.Host != "devhost" || p.Port != 0 || p.DB != "app" || !p.InsecureSkipVerify {
+		t.Errorf("got default %q profile %+v", cf.Default, p)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestRunConfigSetErrors(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cases := [][]string{{"host"}, {"colour=red"}, {"port=abc"}, {"port=70000"}, {"insecure_skip_verify=maybe"}}
+	for _, pairs := range cases {
+		if err := runConfigSet(path, "dev", pairs, false); err == nil {
+			t.Errorf("%v: expected error", pairs)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("config file should not be written on error, stat err = %v", err)
+	}
+}
+
+func TestWriteProfiles(t *testing.T) {
+	t.Parallel()
+	cf := &configFile{Default: "dev", Profiles: map[string]*connectionProfile{
+		"prod": {Host: "prodhost", User: "ops", TLSCert: "/ca.pem"},
+		"dev":  {Host: "devhost", Port: 29015, DB: "devdb"},
+	}}
+	var buf bytes.Buffer
+	if err := writeProfiles(&buf, cf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines:\n%s", len(lines), buf.String())
+	}
+	if f := strings.Fields(lines[1]); strings.Join(f, " ") != "* dev devhost:29015 - devdb - no" {
+		t.Errorf("dev line = %q", lines[1])
+	}
+	if f := strings.Fields(lines[2]); strings.Join(f, " ") != "prod prodhost:- ops - - yes" {
+		t.Errorf("prod line = %q", lines[2])
+	}
+}
+
+func TestConfigCmdSkipsProfileResolve(t *testing.T) {
+	writeTestConfig(t, testConfigYAML)
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{"--conn-profile", "missing", "config", "list"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("config list should not resolve the profile: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// resolveURL applies --url, or RETHINKDB_URL when --url is not given, to the
+// connection settings whose flags were not set on the command line.
+func (c *rootConfig) resolveURL(changed func(string) bool) error {
+	raw, src := c.url, "--url"
+	if raw == "" {
+		raw, src = os.Getenv("RETHINKDB_URL"), "RETHINKDB_URL"
+	}
+	if raw == "" {
+		return nil
+	}
+	if err := c.applyURL(raw, changed); err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	return nil
+}
+
+// applyURL parses rethinkdb://[user[:password]@]host[:port][/db][?params].
+// Parameters: tls, tls_cert, tls_client_cert, tls_key, insecure_skip_verify.
+func (c *rootConfig) applyURL(raw string, changed func(string) bool) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		// url.Error repeats the URL, which may hold a password
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "rethinkdb" {
+		return fmt.Errorf("invalid URL %q: scheme must be rethinkdb://", u.Redacted())
+	}
+	if err := applyURLAuthority(u, c, changed); err != nil {
+		return fmt.Errorf("invalid URL %q: %w", u.Redacted(), err)
+	}
+	db := strings.TrimPrefix(u.Path, "/")
+	if strings.Contains(db, "/") {
+		return fmt.Errorf("invalid URL %q: path must be a database name", u.Redacted())
+	}
+	if db != "" && !changed("db") {
+		c.database = db
+	}
+	if err := c.applyURLQuery(u.Query(), changed); err != nil {
+		return fmt.Errorf("invalid URL %q: %w", u.Redacted(), err)
+	}
+	return nil
+}
+
+// applyURLAuthority copies the host, port and user info of u into c, except
+// for the parts whose flag skip reports as set.
+func applyURLAuthority(u *url.URL, c *rootConfig, skip func(string) bool) error {
+	if h := u.Hostname(); h != "" && !skip("host") {
+		c.host = h
+	}
+	if p := u.Port(); p != "" && !skip("port") {
+		port, err := strconv.Atoi(p)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("bad port %q", p)
+		}
+		c.port = port
+	}
+	if u.User != nil {
+		if !skip("user") {
+			c.user = u.User.Username()
+		}
+		if !skip("password") {
+			c.password, _ = u.User.Password()
+		}
+	}
+	return nil
+}
+
+// applyURLQuery applies the TLS query parameters of a connection URL.
+func (c *rootConfig) applyURLQuery(q url.Values, changed func(string) bool) error {
+	paths := map[string]*string{
+		"tls_cert": &c.tlsCACert, "tls_client_cert": &c.tlsClientCert, "tls_key": &c.tlsKey,
+	}
+	for key := range q {
+		v := q.Get(key)
+		flag := strings.ReplaceAll(key, "_", "-")
+		if dst, ok := paths[key]; ok {
+			if !changed(flag) {
+				*dst = v
+			}
+			continue
+		}
+		if key != "tls" && key != "insecure_skip_verify" {
+			return fmt.Errorf("unknown parameter %q", key)
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %q is not a boolean", key, v)
+		}
+		if key == "tls" {
+			c.tlsEnabled = b
+		} else if !changed(flag) {
+			c.insecureSkipVerify = b
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+)
+
+type copyConfig struct {
+	insertConfig
+	from    string
+	to      string
+	indexes bool
+}
+
+// copyEndpoint is one side of a copy: connection settings plus the table.
+type copyEndpoint struct {
+	cfg rootConfig
+	ref exportRef
+}
+
+func newCopyCmd(cfg *rootConfig) *cobra.Command {
+	cc := &copyConfig{}
+	cmd := &cobra.Command{
+		Use:   "copy --from <url> --to <url>",
+		Short: "Stream a table from one server to another",
+		Long: "Stream all documents of a table to another table, possibly on another cluster.\n" +
+			"Endpoints are rethinkdb://[user[:password]@]host[:port]/db.table; omitted parts\n" +
+			"fall back to the global connection flags. The destination database and table\n" +
+			"are created when missing; --indexes also recreates the secondary indexes.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runCopy(cmd.Context(), cfg, cc, os.Stdout)
+		},
+	}
+	cc.registerWrite(cmd)
+	f := cmd.Flags()
+	f.StringVar(&cc.from, "from", "", "source table URL")
+	f.StringVar(&cc.to, "to", "", "destination table URL")
+	f.BoolVar(&cc.indexes, "indexes", false, "recreate secondary indexes on the destination")
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+// parseCopyURL parses a rethinkdb://[user[:password]@]host[:port]/db.table
+// endpoint; connection parts missing from the URL are taken from base.
+func parseCopyURL(raw string, base *rootConfig) (copyEndpoint, error) {
+	ep := copyEndpoint{cfg: *base}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ep, fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if u.Scheme != "rethinkdb" {
+		return ep, fmt.Errorf("invalid URL %q: scheme must be rethinkdb://", raw)
+	}
+	if err := applyURLAuthority(u, &ep.cfg, func(string) bool { return false }); err != nil {
+		return ep, fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	db, table, err := parseTableRef(strings.TrimPrefix(u.Path, "/"))
+	if err != nil {
+		return ep, fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	ep.ref = exportRef{db: db, table: table}
+	ep.cfg.database = db
+	return ep, nil
+}
+
+// runCopy copies the source table into the destination in --batch-size
+// batches, creating the destination table with the source primary key.
+func runCopy(ctx context.Context, cfg *rootConfig, cc *copyConfig, out io.Writer) error {
+	opts, err := cc.insertOpts()
+	if err != nil {
+		return err
+	}
+	src, err := parseCopyURL(cc.from, cfg)
+	if err != nil {
+		return fmt.Errorf("copy: --from: %w", err)
+	}
+	dst, err := parseCopyURL(cc.to, cfg)
+	if err != nil {
+		return fmt.Errorf("copy: --to: %w", err)
+	}
+
+	srcExec, srcCleanup, err := newExecutor(&src.cfg)
+	if err != nil {
+		return err
+	}
+	defer srcCleanup()
+	dstExec, dstCleanup, err := newExecutor(&dst.cfg)
+	if err != nil {
+		return err
+	}
+	defer dstCleanup()
+
+	schema, err := tableSchema(ctx, srcExec, &src.cfg, src.ref)
+	if err != nil {
+		return fmt.Errorf("copy: reading source: %w", err)
+	}
+	target := schema
+	target.DB, target.Table = dst.ref.db, dst.ref.table
+	if err := prepareCopyTarget(ctx, dstExec, &dst.cfg, &target); err != nil {
+		return fmt.Errorf("copy: preparing destination: %w", err)
+	}
+
+	var total insertResult
+	err = copyRows(ctx, srcExec, dstExec, &src, &dst, opts, cc.batchSize, &total)
+	if err == nil && cc.indexes {
+		err = ensureIndexes(ctx, dstExec, &dst.cfg, reql.DB(dst.ref.db).Table(dst.ref.table), target.Indexes)
+	}
+	data, _ := json.Marshal(total)
+	_, _ = fmt.Fprintf(out, "%s\n", data)
+	return err
+}
+
+// prepareCopyTarget creates the destination database and table when missing.
+func prepareCopyTarget(ctx context.Context, exec *query.Executor, cfg *rootConfig, t *exportTable) error {
+	if err := ensureDBs(ctx, exec, cfg, []exportTable{*t}); err != nil {
+		return err
+	}
+	return ensureTable(ctx, exec, cfg, t)
+}
+
+// copyRows reads the raw source documents and inserts them in batches.
+func copyRows(ctx context.Context, srcExec, dstExec *query.Executor, src, dst *copyEndpoint, opts reql.OptArgs, batchSize int, total *insertResult) error {
+	_, cur, err := srcExec.Run(ctx, reql.DB(src.ref.db).Table(src.ref.table), nil)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		return nil
+	}
+	defer func() { _ = cur.Close() }()
+
+	tbl := reql.DB(dst.ref.db).Table(dst.ref.table)
+	batch := make([]json.RawMessage, 0, batchSize)
+	err = cur.Each(ctx, func(row json.RawMessage) error {
+		batch = append(batch, row)
+		if len(batch) < batchSize {
+			return nil
+		}
+		if err := execInsertBatch(ctx, dstExec, &dst.cfg, tbl, opts, batch, total); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return execInsertBatch(ctx, dstExec, &dst.cfg, tbl, opts, batch, total)
+	}
+	return nil
+}
@@ -72,10 +72,16 @@ var errAborted = errors.New("aborted")
 // confirmDrop prompts the user to confirm a destructive drop operation.
 // When quiet is true, skips the prompt and returns errAborted (use --yes to proceed in quiet mode).
 func confirmDrop(kind, name string, r io.Reader, quiet bool) error {
+	return confirm(fmt.Sprintf("Drop %s %q?", kind, name), r, quiet)
+}
+
+// confirm asks question on stderr and succeeds only on a "y" or "yes" answer.
+// When quiet is true, skips the prompt and returns errAborted.
+func confirm(question string, r io.Reader, quiet bool) error {
 	if quiet {
 		return errAborted
 	}
-	fmt.Fprintf(os.Stderr, "Drop %s %q? [y/N] ", kind, name)
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
 	scanner := bufio.NewScanner(r)
 	if scanner.Scan() {
 		answer := strings.TrimSpace(strings.ToLower(scanner.Text()))
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// maxArchiveFileSize bounds a single extracted file so a corrupt or hostile
+// archive cannot fill the disk.
+const maxArchiveFileSize = 64 << 30
+
+func newDumpCmd(cfg *rootConfig) *cobra.Command {
+	ec := &exportConfig{}
+	var file string
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Export tables into a single .tar.gz archive",
+		Long: "Export tables like export and pack the result into one .tar.gz archive\n" +
+			"(default rethinkdb_dump_<timestamp>.tar.gz) that restore can load.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if file == "" {
+				file = defaultDumpName(time.Now())
+			}
+			return runDump(cmd.Context(), cfg, ec, file, os.Stdout)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&file, "file", "F", "", "archive path, - for stdout (default: rethinkdb_dump_<timestamp>.tar.gz)")
+	f.StringArrayVar(&ec.tables, "table", nil, "table to dump, as table (in --db) or db.table; repeatable")
+	f.IntVar(&ec.parallel, "parallel", 4, "number of tables exported concurrently")
+	return cmd
+}
+
+func newRestoreCmd(cfg *rootConfig) *cobra.Command {
+	ic := &importConfig{}
+	cmd := &cobra.Command{
+		Use:   "restore <archive.tar.gz>",
+		Short: "Import a dump archive, creating missing tables and indexes",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRestore(cmd.Context(), cfg, ic, args[0], os.Stdout)
+		},
+	}
+	ic.registerWrite(cmd)
+	f := cmd.Flags()
+	f.StringArrayVar(&ic.tables, "table", nil, "table to restore, as table (in --db) or db.table; repeatable")
+	f.IntVar(&ic.parallel, "parallel", 4, "number of tables imported concurrently")
+	return cmd
+}
+
+// defaultDumpName mirrors the archive name used by `rethinkdb dump`.
+func defaultDumpName(now time.Time) string {
+	return "rethinkdb_dump_" + now.Format("2006-01-02T15-04-05") + ".tar.gz"
+}
+
+// runDump exports into a temp dir and archives it under a directory named
+// after the archive, replacing file only when the archive is complete.
+func runDump(ctx context.Context, cfg *rootConfig, ec *exportConfig, file string, out io.Writer) error {
+	tmp, err := os.MkdirTemp("", "r-cli-dump-*")
+	if err != nil {
+		return fmt.Errorf("dump: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	ec.dir = tmp
+	res, err := exportDir(ctx, cfg, ec)
+	if err != nil {
+		return err
+	}
+	target, err := openOutputTarget(file, out)
+	if err != nil {
+		return err
+	}
+	prefix := strings.TrimSuffix(filepath.Base(file), ".tar.gz")
+	if file == "-" {
+		prefix = strings.TrimSuffix(defaultDumpName(time.Now()), ".tar.gz")
+	}
+	if err := target.finish(writeTarGz(target, tmp, prefix)); err != nil {
+		return fmt.Errorf("dump: %w", err)
+	}
+	if file == "-" {
+		// the archive itself went to stdout
+		return nil
+	}
+	res.Archive = file
+	data, _ := json.Marshal(res)
+	_, _ = fmt.Fprintf(out, "%s\n", data)
+	return nil
+}
+
+// runRestore extracts archive into a temp dir and imports it.
+func runRestore(ctx context.Context, cfg *rootConfig, ic *importConfig, archive string, out io.Writer) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	tmp, err := os.MkdirTemp("", "r-cli-restore-*")
+	if err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	if err := extractTarGz(f, tmp); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	dir, err := findExportDir(tmp)
+	if err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	ic.dir = dir
+	return runImport(ctx, cfg, ic, out)
+}
+
+// writeTarGz writes the regular files under dir to w as a gzipped tar, with
+// entry names prefixed by prefix.
+func writeTarGz(w io.Writer, dir, prefix string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return addTarFile(tw, path, filepath.ToSlash(filepath.Join(prefix, rel)))
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	return err
+}
+
+func addTarFile(tw *tar.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: name, Mode: 0o600, Size: st.Size(), ModTime: st.ModTime(), Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// extractTarGz unpacks the regular files of a gzipped tar into dir. Entries
+// that would land outside dir are rejected; other entry types are skipped.
+func extractTarGz(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = gz.Close() }()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := extractTarFile(tr, dir, hdr.Name); err != nil {
+			return err
+		}
+	}
+}
+
+func extractTarFile(r io.Reader, dir, name string) error {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return fmt.Errorf("archive entry %q escapes the target directory", name)
+	}
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, maxArchiveFileSize+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > maxArchiveFileSize {
+		err = fmt.Errorf("archive entry %q is too large", name)
+	}
+	return err
+}
+
+// findExportDir returns dir, or its single subdirectory, holding info.json.
+func findExportDir(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, exportInfoFile)); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		sub := filepath.Join(dir, entries[0].Name())
+		if _, err := os.Stat(filepath.Join(sub, exportInfoFile)); err == nil {
+			return sub, nil
+		}
+	}
+	return "", fmt.Errorf("archive has no %s", exportInfoFile)
+}
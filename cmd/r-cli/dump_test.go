@@ -0,0 +1,111 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDumpRestoreCmdsRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	found := map[string]bool{}
+	for _, sub := range root.Commands() {
+		found[sub.Name()] = true
+	}
+	for _, name := range []string{"dump", "restore"} {
+		if !found[name] {
+			t.Errorf("%s subcommand not registered on root command", name)
+		}
+	}
+}
+
+func TestRestoreFlags(t *testing.T) {
+	t.Parallel()
+	cmd := newRestoreCmd(&rootConfig{})
+	for _, name := range []string{"table", "parallel", "conflict", "batch-size", "durability"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("restore: missing --%s flag", name)
+		}
+	}
+	if cmd.Flags().Lookup("file") != nil {
+		t.Error("restore: unexpected --file flag")
+	}
+}
+
+func TestDefaultDumpName(t *testing.T) {
+	t.Parallel()
+	got := defaultDumpName(time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC))
+	if got != "rethinkdb_dump_2024-03-05T14-07-09.tar.gz" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTarGzRoundTrip(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	writeTestInfo(t, src, exportInfo{Format: "jsonl"})
+	if err := os.MkdirAll(filepath.Join(src, "app"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "app", "users.jsonl"), []byte(`{"id":1}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTarGz(&buf, src, "dump"); err != nil {
+		t.Fatal(err)
+	}
+	dst := t.TempDir()
+	if err := extractTarGz(&buf, dst); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := findExportDir(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != filepath.Join(dst, "dump") {
+		t.Errorf("export dir: got %q", dir)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "app", "users.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"id":1}`+"\n" {
+		t.Errorf("got %q", data)
+	}
+}
+
+func TestExtractTarGzRejectsEscape(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	body := []byte("x")
+	if err := tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0o600, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := extractTarGz(&buf, t.TempDir()); err == nil {
+		t.Error("expected error for entry outside the target directory")
+	}
+}
+
+func TestFindExportDirMissing(t *testing.T) {
+	t.Parallel()
+	if _, err := findExportDir(t.TempDir()); err == nil {
+		t.Error("expected error without info.json")
+	}
+}
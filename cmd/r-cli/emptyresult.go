@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"r-cli/internal/output"
+)
+
+// errEmptyResult is returned with --fail-on-empty when a query succeeded but
+// produced nothing; main exits with exitEmpty without printing an error.
+var errEmptyResult = errors.New("empty result")
+
+// emptyCheckIter records whether inner yields a non-empty result: at least one
+// row, other than a single null, false or [] value.
+type emptyCheckIter struct {
+	inner output.RowIterator
+	rows  int
+	blank bool // the only row seen so far is null, false or []
+}
+
+func (e *emptyCheckIter) Next() (json.RawMessage, error) {
+	row, err := e.inner.Next()
+	if err != nil {
+		return row, err
+	}
+	e.rows++
+	e.blank = e.rows == 1 && isBlankAtom(row)
+	return row, nil
+}
+
+func (e *emptyCheckIter) empty() bool {
+	return e.rows == 0 || e.blank
+}
+
+func isBlankAtom(row json.RawMessage) bool {
+	switch string(bytes.TrimSpace(row)) {
+	case "null", "false", "[]":
+		return true
+	}
+	return false
+}
+
+// withEmptyCheck wraps iter when --fail-on-empty is set; the returned func
+// reports errEmptyResult once the rows have been written. Both are no-ops
+// otherwise.
+func withEmptyCheck(iter output.RowIterator, cfg *rootConfig) (output.RowIterator, func() error) {
+	if !cfg.failOnEmpty {
+		return iter, func() error { return nil }
+	}
+	check := &emptyCheckIter{inner: iter}
+	return check, func() error {
+		if check.empty() {
+			return errEmptyResult
+		}
+		return nil
+	}
+}
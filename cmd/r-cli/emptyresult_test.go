@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestEmptyCheckIter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		rows  []string
+		empty bool
+	}{
+		{"no rows", nil, true},
+		{"null atom", []string{"null"}, true},
+		{"false atom", []string{"false"}, true},
+		{"empty array", []string{"[]"}, true},
+		{"true atom", []string{"true"}, false},
+		{"zero", []string{"0"}, false},
+		{"empty string", []string{`""`}, false},
+		{"document", []string{`{"id":1}`}, false},
+		{"null then row", []string{"null", "1"}, false},
+		{"several nulls", []string{"null", "null"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			inner := &stubIter{}
+			for _, r := range tt.rows {
+				inner.rows = append(inner.rows, json.RawMessage(r))
+			}
+			check := &emptyCheckIter{inner: inner}
+			for {
+				if _, err := check.Next(); errors.Is(err, io.EOF) {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := check.empty(); got != tt.empty {
+				t.Errorf("empty() = %v, want %v", got, tt.empty)
+			}
+		})
+	}
+}
+
+func TestWithEmptyCheckDisabled(t *testing.T) {
+	t.Parallel()
+	inner := &stubIter{}
+	iter, check := withEmptyCheck(inner, &rootConfig{})
+	if iter != inner {
+		t.Error("iter wrapped without --fail-on-empty")
+	}
+	if err := check(); err != nil {
+		t.Errorf("check: %v", err)
+	}
+}
+
+func TestWithEmptyCheckEnabled(t *testing.T) {
+	t.Parallel()
+	iter, check := withEmptyCheck(&stubIter{rows: []json.RawMessage{json.RawMessage("null")}}, &rootConfig{failOnEmpty: true})
+	if _, err := iter.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(); !errors.Is(err, errEmptyResult) {
+		t.Errorf("check: got %v, want errEmptyResult", err)
+	}
+}
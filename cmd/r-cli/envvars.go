@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envBinding ties an env var to the flag it provides a default for; field
+// returns a pointer to the rootConfig field (*string, *int, *bool or
+// *time.Duration).
+type envBinding struct {
+	key   string
+	flag  string
+	desc  string
+	field func(c *rootConfig) any
+}
+
+// envBindings lists the env vars applied by resolveEnvVars, in help order.
+// Connection settings use the RETHINKDB_ prefix, output settings RCLI_.
+var envBindings = []envBinding{
+	{"RETHINKDB_HOST", "host", "override default host", func(c *rootConfig) any { return &c.host }},
+	{"RETHINKDB_PORT", "port", "override default port", func(c *rootConfig) any { return &c.port }},
+	{"RETHINKDB_USER", "user", "override default user", func(c *rootConfig) any { return &c.user }},
+	{"RETHINKDB_PASSWORD", "password", "set password", func(c *rootConfig) any { return &c.password }},
+	{"RETHINKDB_PASSWORD_FILE", "password-file", "read password from file", func(c *rootConfig) any { return &c.passwordFile }},
+	{"RETHINKDB_DATABASE", "db", "set default database", func(c *rootConfig) any { return &c.database }},
+	{"RETHINKDB_TIMEOUT", "timeout", "connection timeout, e.g. 10s", func(c *rootConfig) any { return &c.timeout }},
+	{"RETHINKDB_TLS_CA", "tls-cert", "CA certificate PEM file", func(c *rootConfig) any { return &c.tlsCACert }},
+	{"RETHINKDB_TLS_CLIENT_CERT", "tls-client-cert", "client certificate PEM file", func(c *rootConfig) any { return &c.tlsClientCert }},
+	{"RETHINKDB_TLS_KEY", "tls-key", "client private key PEM file", func(c *rootConfig) any { return &c.tlsKey }},
+	{"RETHINKDB_INSECURE_SKIP_VERIFY", "insecure-skip-verify", "skip TLS certificate verification (true/false)", func(c *rootConfig) any { return &c.insecureSkipVerify }},
+	{"RETHINKDB_READ_MODE", "read-mode", "read mode: single, majority, outdated", func(c *rootConfig) any { return &c.readMode }},
+	{"RETHINKDB_DURABILITY", "durability", "write durability: hard, soft", func(c *rootConfig) any { return &c.durability }},
+	{"RCLI_FORMAT", "format", "output format", func(c *rootConfig) any { return &c.format }},
+	{"RCLI_COLOR", "color", "colorize JSON output: auto, always, never", func(c *rootConfig) any { return &c.color }},
+	{"RCLI_TIME_FORMAT", "time-format", "time format", func(c *rootConfig) any { return &c.timeFormat }},
+	{"RCLI_BINARY_FORMAT", "binary-format", "binary format", func(c *rootConfig) any { return &c.binaryFormat }},
+	{"RCLI_MAX_COL_WIDTH", "max-col-width", "table format: maximum column width", func(c *rootConfig) any { return &c.maxColWidth }},
+}
+
+// resolveEnvVars applies env var values for flags not explicitly set via CLI.
+func (c *rootConfig) resolveEnvVars(changed func(string) bool) error {
+	for _, b := range envBindings {
+		if changed(b.flag) {
+			continue
+		}
+		if v := os.Getenv(b.key); v != "" {
+			if err := b.apply(c, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// apply parses v according to the type of the bound field and stores it;
+// the field is left unchanged on error.
+func (b envBinding) apply(c *rootConfig, v string) error {
+	switch dst := b.field(c).(type) {
+	case *string:
+		*dst = v
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%s %q: not a valid number", b.key, v)
+		}
+		*dst = n
+	case *bool:
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%s %q: not a valid boolean", b.key, v)
+		}
+		*dst = on
+	case *time.Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%s %q: not a valid duration", b.key, v)
+		}
+		*dst = d
+	}
+	return nil
+}
+
+// envVarsSection is the template block injected into the root command's usage template.
+var envVarsSection = buildEnvVarsSection()
+
+func buildEnvVarsSection() string {
+	var sb strings.Builder
+	sb.WriteString("{{if not .HasParent}}\n\nEnvironment Variables:\n")
+	line := func(key, desc string) {
+		fmt.Fprintf(&sb, "  %-31s %s\n", key, desc)
+	}
+	for _, b := range envBindings {
+		line(b.key, b.desc)
+	}
+	line("RETHINKDB_URL", "connection URL, same as --url")
+	line("RCLI_CONFIG", "config file path (default ~/.config/r-cli/config.yaml)")
+	sb.WriteString("Flags override env vars; env vars override the --conn-profile profile.")
+	sb.WriteString("{{- end}}")
+	return sb.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvVarsExtended(t *testing.T) {
+	t.Setenv("RETHINKDB_TIMEOUT", "5s")
+	t.Setenv("RETHINKDB_TLS_CA", "/ca.pem")
+	t.Setenv("RETHINKDB_INSECURE_SKIP_VERIFY", "true")
+	t.Setenv("RETHINKDB_READ_MODE", "outdated")
+	t.Setenv("RCLI_FORMAT", "table")
+	t.Setenv("RCLI_MAX_COL_WIDTH", "20")
+	cfg := &rootConfig{timeout: 30 * time.Second}
+	if err := cfg.resolveEnvVars(func(string) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.timeout != 5*time.Second || cfg.tlsCACert != "/ca.pem" || !cfg.insecureSkipVerify ||
+		cfg.readMode != "outdated" || cfg.format != "table" || cfg.maxColWidth != 20 {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestEnvVarsFlagWins(t *testing.T) {
+	t.Setenv("RETHINKDB_TIMEOUT", "5s")
+	t.Setenv("RCLI_FORMAT", "table")
+	cfg := &rootConfig{timeout: time.Minute, format: "csv"}
+	if err := cfg.resolveEnvVars(func(name string) bool { return name == "timeout" || name == "format" }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.timeout != time.Minute || cfg.format != "csv" {
+		t.Errorf("got timeout %s format %q", cfg.timeout, cfg.format)
+	}
+}
+
+func TestEnvVarsInvalid(t *testing.T) {
+	for key, v := range map[string]string{
+		"RETHINKDB_TIMEOUT":              "soon",
+		"RETHINKDB_INSECURE_SKIP_VERIFY": "maybe",
+		"RCLI_MAX_COL_WIDTH":             "wide",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, v)
+			err := (&rootConfig{}).resolveEnvVars(func(string) bool { return false })
+			if err == nil || !strings.Contains(err.Error(), key) {
+				t.Errorf("expected error naming %s, got %v", key, err)
+			}
+		})
+	}
+}
+
+func TestEnvBindingsMatchFlags(t *testing.T) {
+	t.Parallel()
+	cmd := newRootCmd()
+	cfg := &rootConfig{}
+	for _, b := range envBindings {
+		if cmd.PersistentFlags().Lookup(b.flag) == nil {
+			t.Errorf("%s: no flag --%s", b.key, b.flag)
+		}
+		switch b.field(cfg).(type) {
+		case *string, *int, *bool, *time.Duration:
+		default:
+			t.Errorf("%s: unsupported field type %T", b.key, b.field(cfg))
+		}
+	}
+}
+
+func TestHelpListsAllEnvVars(t *testing.T) {
+	t.Parallel()
+	out := cmdHelpOutput(t, "")
+	for _, b := range envBindings {
+		if !strings.Contains(out, b.key) {
+			t.Errorf("help output missing %s", b.key)
+		}
+	}
+}
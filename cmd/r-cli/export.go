@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/cursor"
+	"r-cli/internal/output"
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+)
+
+// exportInfoFile is the name of the manifest written next to the table files.
+const exportInfoFile = "info.json"
+
+type exportConfig struct {
+	dir      string
+	tables   []string
+	parallel int
+}
+
+// exportRef names one table to export.
+type exportRef struct {
+	db    string
+	table string
+}
+
+// exportIndex is a secondary index definition as reported by indexStatus();
+// Function is the BINARY pseudo-type accepted by indexCreate.
+type exportIndex struct {
+	Index    string          `json:"index"`
+	Function json.RawMessage `json:"function"`
+	Geo      bool            `json:"geo"`
+	Multi    bool            `json:"multi"`
+}
+
+// exportTable is the info.json entry for one exported table.
+type exportTable struct {
+	DB         string        `json:"db"`
+	Table      string        `json:"table"`
+	PrimaryKey string        `json:"primary_key"`
+	Indexes    []exportIndex `json:"indexes"`
+	File       string        `json:"file"`
+	Rows       int           `json:"rows"`
+}
+
+// exportInfo is the info.json manifest.
+type exportInfo struct {
+	Format string        `json:"format"`
+	Tables []exportTable `json:"tables"`
+}
+
+type exportResult struct {
+	Tables  int    `json:"tables"`
+	Rows    int    `json:"rows"`
+	Archive string `json:"archive,omitempty"`
+}
+
+func newExportCmd(cfg *rootConfig) *cobra.Command {
+	ec := &exportConfig{}
+	cmd := &cobra.Command{
+		Use:   "export --dir <dir>",
+		Short: "Export tables to NDJSON, CSV or Parquet files",
+		Long: "Export tables to <dir>/<db>/<table>.jsonl (or .csv or .parquet with --format) plus\n" +
+			"an info.json manifest with primary keys and secondary index definitions.\n" +
+			"Exports every table of --db, or of all databases when --db is not set;\n" +
+			"--table (repeatable, table or db.table) narrows the selection.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runExport(cmd.Context(), cfg, ec, os.Stdout)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&ec.dir, "dir", "", "output directory")
+	f.StringArrayVar(&ec.tables, "table", nil, "table to export, as table (in --db) or db.table; repeatable")
+	_ = cmd.RegisterFlagCompletionFunc("table", completeTableRef(cfg))
+	f.IntVar(&ec.parallel, "parallel", 4, "number of tables exported concurrently")
+	_ = cmd.MarkFlagRequired("dir")
+	return cmd
+}
+
+// exportFormat maps --format to the file format; only jsonl (default), csv and parquet are supported.
+func exportFormat(flagFormat string) (string, error) {
+	switch flagFormat {
+	case "", "jsonl":
+		return "jsonl", nil
+	case "csv", "parquet":
+		return flagFormat, nil
+	}
+	return "", fmt.Errorf("export: unsupported format %q: use jsonl, csv or parquet", flagFormat)
+}
+
+// runExport exports the selected tables and prints the totals.
+func runExport(ctx context.Context, cfg *rootConfig, ec *exportConfig, out io.Writer) error {
+	res, err := exportDir(ctx, cfg, ec)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(res)
+	_, _ = fmt.Fprintf(out, "%s\n", data)
+	return nil
+}
+
+// exportDir exports the selected tables concurrently into ec.dir and writes
+// info.json last, so a directory with a manifest always holds a complete export.
+func exportDir(ctx context.Context, cfg *rootConfig, ec *exportConfig) (exportResult, error) {
+	var res exportResult
+	format, err := exportFormat(cfg.format)
+	if err != nil {
+		return res, err
+	}
+	if ec.parallel < 1 {
+		return res, fmt.Errorf("--parallel must be >= 1")
+	}
+
+	exec, cleanup, err := newPoolExecutor(cfg, max(cfg.poolSize, ec.parallel))
+	if err != nil {
+		return res, err
+	}
+	defer cleanup()
+
+	refs, err := exportRefs(ctx, exec, cfg, ec.tables)
+	if err != nil {
+		return res, err
+	}
+	tables, err := exportAll(ctx, exec, cfg, refs, ec, format)
+	if err != nil {
+		return res, err
+	}
+	if err := writeExportInfo(ec.dir, exportInfo{Format: format, Tables: tables}); err != nil {
+		return res, err
+	}
+	res.Tables = len(tables)
+	for _, t := range tables {
+		res.Rows += t.Rows
+	}
+	return res, nil
+}
+
+// exportRefs resolves --table values, or lists every table of --db or of all
+// user databases (the rethinkdb system database is skipped).
+func exportRefs(ctx context.Context, exec *query.Executor, cfg *rootConfig, tables []string) ([]exportRef, error) {
+	if len(tables) > 0 {
+		refs, err := parseTableRefs(cfg.database, tables)
+		if err != nil {
+			return nil, fmt.Errorf("export: %w", err)
+		}
+		return refs, nil
+	}
+	dbs := []string{cfg.database}
+	if cfg.database == "" {
+		all, err := fetchNames(ctx, exec, cfg, reql.DBList())
+		if err != nil {
+			return nil, err
+		}
+		dbs = dbs[:0]
+		for _, db := range all {
+			if db != "rethinkdb" {
+				dbs = append(dbs, db)
+			}
+		}
+	}
+	var refs []exportRef
+	for _, db := range dbs {
+		names, err := fetchNames(ctx, exec, cfg, reql.DB(db).TableList())
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			refs = append(refs, exportRef{db: db, table: name})
+		}
+	}
+	return refs, nil
+}
+
+// parseTableRefs resolves "db.table" or bare table names in defaultDB.
+func parseTableRefs(defaultDB string, tables []string) ([]exportRef, error) {
+	refs := make([]exportRef, 0, len(tables))
+	for _, t := range tables {
+		if strings.Contains(t, ".") {
+			db, table, err := parseTableRef(t)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, exportRef{db: db, table: table})
+			continue
+		}
+		if defaultDB == "" {
+			return nil, fmt.Errorf("table %q needs a database: use db.table or --db", t)
+		}
+		refs = append(refs, exportRef{db: defaultDB, table: t})
+	}
+	return refs, nil
+}
+
+// exportAll runs up to ec.parallel table exports at once; the first failure
+// cancels the rest. Results keep the order of refs.
+func exportAll(ctx context.Context, exec *query.Executor, cfg *rootConfig, refs []exportRef, ec *exportConfig, format string) ([]exportTable, error) {
+	tables := make([]exportTable, len(refs))
+	err := runParallel(ctx, ec.parallel, len(refs), func(ctx context.Context, i int) error {
+		var err error
+		tables[i], err = exportOne(ctx, exec, cfg, refs[i], ec.dir, format)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// exportOne writes one table's documents to <dir>/<db>/<table>.<format> and
+// returns its manifest entry.
+func exportOne(ctx context.Context, exec *query.Executor, cfg *rootConfig, ref exportRef, dir, format string) (exportTable, error) {
+	tbl := reql.DB(ref.db).Table(ref.table)
+	entry, err := tableSchema(ctx, exec, cfg, ref)
+	if err != nil {
+		return entry, fmt.Errorf("export %s.%s: %w", ref.db, ref.table, err)
+	}
+	entry.File = filepath.Join(ref.db, ref.table+"."+format)
+
+	n, err := exportRows(ctx, exec, cfg, tbl, filepath.Join(dir, entry.File), format)
+	if err != nil {
+		return entry, fmt.Errorf("export %s.%s: %w", ref.db, ref.table, err)
+	}
+	entry.Rows = n
+	cfg.log().Info("exported", "table", ref.db+"."+ref.table, "rows", n)
+	return entry, nil
+}
+
+// tableSchema reads the primary key (info()) and secondary index definitions
+// (indexStatus()) of a table.
+func tableSchema(ctx context.Context, exec *query.Executor, cfg *rootConfig, ref exportRef) (exportTable, error) {
+	tbl := reql.DB(ref.db).Table(ref.table)
+	entry := exportTable{DB: ref.db, Table: ref.table, Indexes: []exportIndex{}}
+	var info struct {
+		PrimaryKey string `json:"primary_key"`
+	}
+	if err := fetchValue(ctx, exec, cfg, tbl.Info(), &info); err != nil {
+		return entry, err
+	}
+	entry.PrimaryKey = info.PrimaryKey
+	if err := fetchValue(ctx, exec, cfg, tbl.IndexStatus(), &entry.Indexes); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// exportRows streams the raw documents of tbl into path; pseudo-types are kept
+// in their wire form so the file can be inserted back unchanged.
+func exportRows(ctx context.Context, exec *query.Executor, cfg *rootConfig, tbl reql.Term, path, format string) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+	_, cur, err := exec.Run(ctx, tbl, nil)
+	if err != nil {
+		return 0, err
+	}
+	if cur == nil {
+		return 0, nil
+	}
+	defer func() { _ = cur.Close() }()
+
+	out, err := openOutputTarget(path, nil)
+	if err != nil {
+		return 0, err
+	}
+	rows := &countingIter{inner: cur}
+	switch format {
+	case "csv":
+		err = output.CSV(out, rows)
+	case "parquet":
+		err = output.Parquet(out, rows)
+	default:
+		err = output.JSONL(out, rows)
+	}
+	return rows.n, out.finish(err)
+}
+
+// fetchValue runs term and decodes its single result into v.
+func fetchValue(ctx context.Context, exec *query.Executor, cfg *rootConfig, term reql.Term, v interface{}) error {
+	_, cur, err := runQuery(ctx, exec, cfg, term)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		return fmt.Errorf("empty response")
+	}
+	defer func() { _ = cur.Close() }()
+	rows, err := cur.All()
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("empty response")
+	}
+	return json.Unmarshal(rows[0], v)
+}
+
+// fetchDecoded is fetchValue with TIME and BINARY pseudo-types decoded
+// into time.Time and []byte fields.
+func fetchDecoded(ctx context.Context, exec *query.Executor, cfg *rootConfig, term reql.Term, v interface{}) error {
+	_, cur, err := runQuery(ctx, exec, cfg, term)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		return fmt.Errorf("empty response")
+	}
+	err = cursor.DecodeOne(cur, v)
+	if errors.Is(err, cursor.ErrNoRows) {
+		return fmt.Errorf("empty response")
+	}
+	return err
+}
+
+// fetchNames runs a listing term (dbList, tableList) and returns the names sorted.
+func fetchNames(ctx context.Context, exec *query.Executor, cfg *rootConfig, term reql.Term) ([]string, error) {
+	var names []string
+	if err := fetchValue(ctx, exec, cfg, term, &names); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// writeExportInfo writes the manifest to <dir>/info.json.
+func writeExportInfo(dir string, info exportInfo) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	out, err := openOutputTarget(filepath.Join(dir, exportInfoFile), nil)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(append(data, '\n'))
+	return out.finish(err)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportCmdRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	for _, sub := range root.Commands() {
+		if sub.Name() == "export" {
+			for _, name := range []string{"dir", "table", "parallel"} {
+				if sub.Flags().Lookup(name) == nil {
+					t.Errorf("export: missing --%s flag", name)
+				}
+			}
+			return
+		}
+	}
+	t.Error("export subcommand not registered on root command")
+}
+
+func TestExportRequiresDir(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	root.SetArgs([]string{"export"})
+	if err := root.Execute(); err == nil {
+		t.Error("expected error without --dir")
+	}
+}
+
+func TestExportFormat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		flag    string
+		want    string
+		wantErr bool
+	}{
+		{"", "jsonl", false},
+		{"jsonl", "jsonl", false},
+		{"csv", "csv", false},
+		{"parquet", "parquet", false},
+		{"table", "", true},
+	}
+	for _, tc := range tests {
+		got, err := exportFormat(tc.flag)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%q: err=%v, wantErr=%v", tc.flag, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.flag, got, tc.want)
+		}
+	}
+}
+
+func TestParseTableRefs(t *testing.T) {
+	t.Parallel()
+	refs, err := parseTableRefs("app", []string{"users", "logs.events"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []exportRef{{db: "app", table: "users"}, {db: "logs", table: "events"}}
+	if len(refs) != len(want) {
+		t.Fatalf("got %v, want %v", refs, want)
+	}
+	for i := range want {
+		if refs[i] != want[i] {
+			t.Errorf("[%d]: got %v, want %v", i, refs[i], want[i])
+		}
+	}
+	if _, err := parseTableRefs("", []string{"users"}); err == nil {
+		t.Error("expected error for bare table without --db")
+	}
+	if _, err := parseTableRefs("", []string{".users"}); err == nil {
+		t.Error("expected error for malformed db.table")
+	}
+}
+
+func TestWriteExportInfo(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "out")
+	info := exportInfo{Format: "jsonl", Tables: []exportTable{{
+		DB: "app", Table: "users", PrimaryKey: "id", File: "app/users.jsonl", Rows: 3,
+		Indexes: []exportIndex{{Index: "email", Function: json.RawMessage(`{"$reql_type$":"BINARY","data":"AA=="}`)}},
+	}}}
+	if err := writeExportInfo(dir, info); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, exportInfoFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got exportInfo
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Format != "jsonl" || len(got.Tables) != 1 || got.Tables[0].PrimaryKey != "id" || got.Tables[0].Indexes[0].Index != "email" {
+		t.Errorf("unexpected manifest: %s", data)
+	}
+}
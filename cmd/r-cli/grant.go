@@ -14,19 +14,24 @@ func newGrantCmd(cfg *rootConfig) *cobra.Command {
 		tableName string
 		read      bool
 		write     bool
+		config    bool
+		connect   bool
 	)
 	c := &cobra.Command{
 		Use:   "grant <user>",
 		Short: "Grant or revoke permissions for a user",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			perms := buildGrantPerms(cmd, read, write)
+			perms := buildGrantPerms(cmd, read, write, config, connect)
 			if len(perms) == 0 {
-				return fmt.Errorf("at least one permission flag required (--read, --write)")
+				return fmt.Errorf("at least one permission flag required (--read, --write, --config, --connect)")
 			}
 			if tableName != "" && cfg.database == "" {
 				return fmt.Errorf("--table requires --db")
 			}
+			if _, ok := perms["connect"]; ok && cfg.database != "" {
+				return fmt.Errorf("--connect can only be granted globally (without --db)")
+			}
 			user := args[0]
 			term := grantTerm(cfg.database, tableName, user, perms)
 			return execTerm(cmd.Context(), cfg, term, os.Stdout)
@@ -35,16 +40,17 @@ func newGrantCmd(cfg *rootConfig) *cobra.Command {
 	c.Flags().StringVar(&tableName, "table", "", "target table (requires --db)")
 	c.Flags().BoolVar(&read, "read", false, "read permission")
 	c.Flags().BoolVar(&write, "write", false, "write permission")
+	c.Flags().BoolVar(&config, "config", false, "config permission (create/drop/reconfigure tables and indexes)")
+	c.Flags().BoolVar(&connect, "connect", false, "connect permission (r.http and outgoing connections; global only)")
 	return c
 }
 
-func buildGrantPerms(cmd *cobra.Command, read, write bool) map[string]interface{} {
+func buildGrantPerms(cmd *cobra.Command, read, write, config, connect bool) map[string]interface{} {
 	perms := map[string]interface{}{}
-	if cmd.Flags().Changed("read") {
-		perms["read"] = read
-	}
-	if cmd.Flags().Changed("write") {
-		perms["write"] = write
+	for name, v := range map[string]bool{"read": read, "write": write, "config": config, "connect": connect} {
+		if cmd.Flags().Changed(name) {
+			perms[name] = v
+		}
 	}
 	return perms
 }
@@ -107,7 +107,7 @@ func TestBuildGrantPermsReadOnly(t *testing.T) {
 	if err := cmd.ParseFlags([]string{"--read"}); err != nil {
 		t.Fatal(err)
 	}
-	perms := buildGrantPerms(cmd, true, false)
+	perms := buildGrantPerms(cmd, true, false, false, false)
 	if v, ok := perms["read"]; !ok || v != true {
 		t.Errorf("buildGrantPerms: expected read=true, got %v", perms)
 	}
@@ -124,7 +124,7 @@ func TestBuildGrantPermsRevoke(t *testing.T) {
 		t.Fatal(err)
 	}
 	read, _ := cmd.Flags().GetBool("read")
-	perms := buildGrantPerms(cmd, read, false)
+	perms := buildGrantPerms(cmd, read, false, false, false)
 	if v, ok := perms["read"]; !ok || v != false {
 		t.Errorf("buildGrantPerms: expected read=false, got %v", perms)
 	}
@@ -195,7 +195,7 @@ func TestBuildGrantPermsBothFlags(t *testing.T) {
 	}
 	read, _ := cmd.Flags().GetBool("read")
 	write, _ := cmd.Flags().GetBool("write")
-	perms := buildGrantPerms(cmd, read, write)
+	perms := buildGrantPerms(cmd, read, write, false, false)
 	if v, ok := perms["read"]; !ok || v != true {
 		t.Errorf("buildGrantPerms both: expected read=true, got %v", perms)
 	}
@@ -222,3 +222,27 @@ func TestGrantTableRequiresDB(t *testing.T) {
 		t.Errorf("grant --table without --db: unexpected error: %v", err)
 	}
 }
+
+func TestBuildGrantPermsConfigConnect(t *testing.T) {
+	t.Parallel()
+	cmd := newGrantCmd(&rootConfig{})
+	if err := cmd.ParseFlags([]string{"--config", "--connect=false"}); err != nil {
+		t.Fatal(err)
+	}
+	perms := buildGrantPerms(cmd, false, false, true, false)
+	if len(perms) != 2 || perms["config"] != true || perms["connect"] != false {
+		t.Errorf("buildGrantPerms config/connect: got %v", perms)
+	}
+}
+
+func TestGrantConnectRequiresGlobalScope(t *testing.T) {
+	t.Parallel()
+	cmd := newGrantCmd(&rootConfig{database: "app"})
+	if err := cmd.ParseFlags([]string{"--connect"}); err != nil {
+		t.Fatal(err)
+	}
+	err := cmd.RunE(cmd, []string{"alice"})
+	if err == nil || !strings.Contains(err.Error(), "globally") {
+		t.Errorf("grant --connect with --db: unexpected error: %v", err)
+	}
+}
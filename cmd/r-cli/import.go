@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+)
+
+type importConfig struct {
+	insertConfig
+	dir      string
+	tables   []string
+	parallel int
+}
+
+type importResult struct {
+	Tables int `json:"tables"`
+	insertResult
+}
+
+func newImportCmd(cfg *rootConfig) *cobra.Command {
+	ic := &importConfig{}
+	cmd := &cobra.Command{
+		Use:   "import (--dir <dir> | --file <file> --table <table|db.table>)",
+		Short: "Import an export directory or a single file, creating missing tables",
+		Long: "Import the tables listed in <dir>/info.json (written by export), or the\n" +
+			"documents of a single JSON/NDJSON --file into --table. Missing databases and\n" +
+			"tables are created; with --dir the primary key and secondary indexes are\n" +
+			"recreated too. --table also selects which tables of --dir to import.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runImport(cmd.Context(), cfg, ic, os.Stdout)
+		},
+	}
+	ic.register(cmd)
+	f := cmd.Flags()
+	f.StringVar(&ic.dir, "dir", "", "export directory containing info.json")
+	f.StringArrayVar(&ic.tables, "table", nil, "target table for --file, or table to import from --dir (table or db.table); repeatable")
+	f.IntVar(&ic.parallel, "parallel", 4, "number of tables imported concurrently")
+	cmd.MarkFlagsOneRequired("dir", "file")
+	cmd.MarkFlagsMutuallyExclusive("dir", "file")
+	return cmd
+}
+
+// runImport resolves the tables to import and imports them, printing the
+// combined write summary.
+func runImport(ctx context.Context, cfg *rootConfig, ic *importConfig, out io.Writer) error {
+	opts, err := ic.insertOpts()
+	if err != nil {
+		return err
+	}
+	if ic.parallel < 1 {
+		return fmt.Errorf("--parallel must be >= 1")
+	}
+	tables, err := importTables(cfg, ic)
+	if err != nil {
+		return err
+	}
+
+	exec, cleanup, err := newPoolExecutor(cfg, max(cfg.poolSize, ic.parallel))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := ensureDBs(ctx, exec, cfg, tables); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	results := make([]insertResult, len(tables))
+	err = runParallel(ctx, ic.parallel, len(tables), func(ctx context.Context, i int) error {
+		return importOne(ctx, exec, cfg, &tables[i], opts, ic, &results[i])
+	})
+	res := importResult{Tables: len(tables)}
+	for _, r := range results {
+		res.Inserted += r.Inserted
+		res.Replaced += r.Replaced
+		res.Errors += r.Errors
+	}
+	data, _ := json.Marshal(res)
+	_, _ = fmt.Fprintf(out, "%s\n", data)
+	return err
+}
+
+// importTables returns the manifest entries to import. For --file it is a
+// single entry for --table; for --dir the entries of info.json, filtered by
+// --table when given. File paths are resolved relative to the directory.
+func importTables(cfg *rootConfig, ic *importConfig) ([]exportTable, error) {
+	refs, err := parseTableRefs(cfg.database, ic.tables)
+	if err != nil {
+		return nil, fmt.Errorf("import: %w", err)
+	}
+	if ic.file != "" {
+		if len(refs) != 1 {
+			return nil, fmt.Errorf("import: --file requires exactly one --table")
+		}
+		return []exportTable{{DB: refs[0].db, Table: refs[0].table, File: ic.file}}, nil
+	}
+	info, err := readExportInfo(ic.dir)
+	if err != nil {
+		return nil, err
+	}
+	if info.Format == "csv" || info.Format == "parquet" {
+		return nil, fmt.Errorf("import: %s exports cannot be imported: column types are not preserved", info.Format)
+	}
+	var tables []exportTable
+	for _, t := range info.Tables {
+		if len(refs) > 0 && !slices.Contains(refs, exportRef{db: t.DB, table: t.Table}) {
+			continue
+		}
+		// a manifest, possibly from a restored archive, only names files
+		// inside the export directory
+		if !filepath.IsLocal(t.File) {
+			return nil, fmt.Errorf("import: %s: file %q of %s.%s is outside the export directory", exportInfoFile, t.File, t.DB, t.Table)
+		}
+		t.File = filepath.Join(ic.dir, t.File)
+		tables = append(tables, t)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("import: no matching tables in %s", filepath.Join(ic.dir, exportInfoFile))
+	}
+	return tables, nil
+}
+
+// readExportInfo loads <dir>/info.json.
+func readExportInfo(dir string) (*exportInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, exportInfoFile))
+	if err != nil {
+		return nil, fmt.Errorf("import: %w", err)
+	}
+	var info exportInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("import: parsing %s: %w", exportInfoFile, err)
+	}
+	return &info, nil
+}
+
+// ensureDBs creates the databases of tables that do not exist yet. It runs
+// before the parallel phase so two tables never race to create one database.
+func ensureDBs(ctx context.Context, exec *query.Executor, cfg *rootConfig, tables []exportTable) error {
+	existing, err := fetchNames(ctx, exec, cfg, reql.DBList())
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		if slices.Contains(existing, t.DB) {
+			continue
+		}
+		if err := runWrite(ctx, exec, cfg, reql.DBCreate(t.DB)); err != nil {
+			return fmt.Errorf("creating database %s: %w", t.DB, err)
+		}
+		existing = append(existing, t.DB)
+	}
+	return nil
+}
+
+// importOne creates the table if needed, inserts its documents, then creates
+// missing secondary indexes (after the data, which is faster than indexing
+// every insert) and waits for them.
+func importOne(ctx context.Context, exec *query.Executor, cfg *rootConfig, t *exportTable, opts reql.OptArgs, ic *importConfig, res *insertResult) error {
+	name := t.DB + "." + t.Table
+	tbl := reql.DB(t.DB).Table(t.Table)
+	if err := ensureTable(ctx, exec, cfg, t); err != nil {
+		return fmt.Errorf("import %s: %w", name, err)
+	}
+	f, err := os.Open(t.File)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", name, err)
+	}
+	defer func() { _ = f.Close() }()
+	if err := insertStream(ctx, exec, cfg, tbl, opts, ic.batchSize, t.File, f, res); err != nil {
+		return fmt.Errorf("import %s: %w", name, err)
+	}
+	if err := ensureIndexes(ctx, exec, cfg, tbl, t.Indexes); err != nil {
+		return fmt.Errorf("import %s: %w", name, err)
+	}
+	if !cfg.quiet {
+		_, _ = fmt.Fprintf(os.Stderr, "imported %s: %d inserted, %d replaced, %d errors\n", name, res.Inserted, res.Replaced, res.Errors)
+	}
+	return nil
+}
+
+// ensureTable creates t with its primary key unless it already exists.
+func ensureTable(ctx context.Context, exec *query.Executor, cfg *rootConfig, t *exportTable) error {
+	names, err := fetchNames(ctx, exec, cfg, reql.DB(t.DB).TableList())
+	if err != nil {
+		return err
+	}
+	if slices.Contains(names, t.Table) {
+		return nil
+	}
+	opts := reql.OptArgs{}
+	if t.PrimaryKey != "" {
+		opts["primary_key"] = t.PrimaryKey
+	}
+	return runWrite(ctx, exec, cfg, reql.DB(t.DB).TableCreate(t.Table, opts))
+}
+
+// ensureIndexes creates the indexes missing from tbl and waits until they are ready.
+func ensureIndexes(ctx context.Context, exec *query.Executor, cfg *rootConfig, tbl reql.Term, indexes []exportIndex) error {
+	if len(indexes) == 0 {
+		return nil
+	}
+	existing, err := fetchNames(ctx, exec, cfg, tbl.IndexList())
+	if err != nil {
+		return err
+	}
+	for _, idx := range indexes {
+		if slices.Contains(existing, idx.Index) {
+			continue
+		}
+		opts := reql.OptArgs{}
+		if idx.Geo {
+			opts["geo"] = true
+		}
+		if idx.Multi {
+			opts["multi"] = true
+		}
+		term := tbl.IndexCreate(idx.Index, opts)
+		if len(idx.Function) > 0 && string(idx.Function) != "null" {
+			term = tbl.IndexCreateFunc(idx.Index, reql.Datum(idx.Function), opts)
+		}
+		if err := runWrite(ctx, exec, cfg, term); err != nil {
+			return fmt.Errorf("creating index %s: %w", idx.Index, err)
+		}
+	}
+	return runWrite(ctx, exec, cfg, tbl.IndexWait())
+}
+
+// runWrite runs term and discards its result.
+func runWrite(ctx context.Context, exec *query.Executor, cfg *rootConfig, term reql.Term) error {
+	_, cur, err := exec.Run(ctx, term, nil)
+	if err != nil {
+		return err
+	}
+	if cur != nil {
+		defer func() { _ = cur.Close() }()
+		_, err = cur.All()
+	}
+	return err
+}
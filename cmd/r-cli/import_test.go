@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportCmdRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	for _, sub := range root.Commands() {
+		if sub.Name() == "import" {
+			for _, name := range []string{"dir", "file", "table", "parallel", "conflict", "batch-size", "durability"} {
+				if sub.Flags().Lookup(name) == nil {
+					t.Errorf("import: missing --%s flag", name)
+				}
+			}
+			return
+		}
+	}
+	t.Error("import subcommand not registered on root command")
+}
+
+func TestImportDirFileFlags(t *testing.T) {
+	t.Parallel()
+	for _, args := range [][]string{
+		{"import"},
+		{"import", "--dir", "out", "--file", "x.json"},
+	} {
+		root := newRootCmd()
+		root.SetArgs(args)
+		if err := root.Execute(); err == nil {
+			t.Errorf("%v: expected error", args)
+		}
+	}
+}
+
+func writeTestInfo(t *testing.T, dir string, info exportInfo) {
+	t.Helper()
+	if err := writeExportInfo(dir, info); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportTablesFromDir(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeTestInfo(t, dir, exportInfo{Format: "jsonl", Tables: []exportTable{
+		{DB: "app", Table: "users", PrimaryKey: "id", File: "app/users.jsonl"},
+		{DB: "app", Table: "logs", PrimaryKey: "id", File: "app/logs.jsonl"},
+	}})
+
+	all, err := importTables(&rootConfig{}, &importConfig{dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].File != filepath.Join(dir, "app", "users.jsonl") {
+		t.Errorf("unexpected tables: %+v", all)
+	}
+
+	some, err := importTables(&rootConfig{database: "app"}, &importConfig{dir: dir, tables: []string{"logs"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(some) != 1 || some[0].Table != "logs" {
+		t.Errorf("filtered: got %+v", some)
+	}
+
+	if _, err := importTables(&rootConfig{}, &importConfig{dir: dir, tables: []string{"other.x"}}); err == nil {
+		t.Error("expected error when no table matches")
+	}
+}
+
+func TestImportTablesCSVRejected(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeTestInfo(t, dir, exportInfo{Format: "csv"})
+	_, err := importTables(&rootConfig{}, &importConfig{dir: dir})
+	if err == nil || !strings.Contains(err.Error(), "csv") {
+		t.Errorf("expected csv error, got %v", err)
+	}
+}
+
+func TestImportTablesParquetRejected(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeTestInfo(t, dir, exportInfo{Format: "parquet"})
+	_, err := importTables(&rootConfig{}, &importConfig{dir: dir})
+	if err == nil || !strings.Contains(err.Error(), "parquet") {
+		t.Errorf("expected parquet error, got %v", err)
+	}
+}
+
+func TestImportTablesRejectsNonLocalFiles(t *testing.T) {
+	t.Parallel()
+	for _, file := range []string{"../../home/u/secret.json", "/etc/passwd", "app/../../x.jsonl", ""} {
+		dir := t.TempDir()
+		writeTestInfo(t, dir, exportInfo{Format: "jsonl", Tables: []exportTable{
+			{DB: "app", Table: "users", PrimaryKey: "id", File: file},
+		}})
+		_, err := importTables(&rootConfig{}, &importConfig{dir: dir})
+		if err == nil || !strings.Contains(err.Error(), "outside the export directory") {
+			t.Errorf("file %q: got %v, want an outside the export directory error", file, err)
+		}
+	}
+}
+
+func TestImportTablesFromFile(t *testing.T) {
+	t.Parallel()
+	ic := &importConfig{insertConfig: insertConfig{file: "docs.json"}, tables: []string{"app.users"}}
+	tables, err := importTables(&rootConfig{}, ic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || tables[0].DB != "app" || tables[0].Table != "users" || tables[0].File != "docs.json" {
+		t.Errorf("got %+v", tables)
+	}
+	ic.tables = nil
+	if _, err := importTables(&rootConfig{}, ic); err == nil {
+		t.Error("expected error for --file without --table")
+	}
+}
+
+func TestReadExportInfoMissing(t *testing.T) {
+	t.Parallel()
+	if _, err := readExportInfo(t.TempDir()); err == nil {
+		t.Error("expected error for missing info.json")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, exportInfoFile), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readExportInfo(dir); err == nil {
+		t.Error("expected error for malformed info.json")
+	}
+}
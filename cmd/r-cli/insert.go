@@ -18,24 +18,29 @@ import (
 )
 
 type insertConfig struct {
-	file      string
-	batchSize int
-	conflict  string
+	file       string
+	batchSize  int
+	conflict   string
+	durability string
 }
 
 type insertResult struct {
 	Inserted int64 `json:"inserted"`
+	Replaced int64 `json:"replaced"`
 	Errors   int64 `json:"errors"`
 }
 
 func newInsertCmd(cfg *rootConfig) *cobra.Command {
 	ic := &insertConfig{}
 	cmd := &cobra.Command{
-		Use:   "insert <db.table>",
-		Short: "Bulk insert documents into a table",
-		Args:  cobra.ExactArgs(1),
+		Use:               "insert <table|db.table>",
+		ValidArgsFunction: firstArg(completeTableRef(cfg)),
+		Short:             "Bulk insert documents into a table",
+		Long: "Bulk insert documents read from stdin or --file. Input is a JSON array or\n" +
+			"newline-delimited JSON; a .json file or input starting with '[' is read as an array.",
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			dbName, tableName, err := parseTableRef(args[0])
+			tbl, err := listTable(cfg, args[0])
 			if err != nil {
 				return err
 			}
@@ -44,15 +49,27 @@ func newInsertCmd(cfg *rootConfig) *cobra.Command {
 				return err
 			}
 			defer closer()
-			return runInsert(cmd.Context(), cfg, ic, dbName, tableName, src, os.Stdout)
+			return runInsert(cmd.Context(), cfg, ic, tbl, src, os.Stdout)
 		},
 	}
-	cmd.Flags().StringVarP(&ic.file, "file", "F", "", "input file (default: stdin)")
-	cmd.Flags().IntVar(&ic.batchSize, "batch-size", 200, "documents per insert batch")
-	cmd.Flags().StringVar(&ic.conflict, "conflict", "error", "conflict strategy: error, replace, update")
+	ic.register(cmd)
 	return cmd
 }
 
+// register defines the input and write flags shared by insert and import.
+func (ic *insertConfig) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&ic.file, "file", "F", "", "input file (default: stdin)")
+	ic.registerWrite(cmd)
+}
+
+// registerWrite defines the batching and write option flags.
+func (ic *insertConfig) registerWrite(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.IntVar(&ic.batchSize, "batch-size", 200, "documents per insert batch")
+	f.StringVar(&ic.conflict, "conflict", "error", "conflict strategy: error, replace, update")
+	f.StringVar(&ic.durability, "durability", "", "write durability for inserts: hard, soft (default: table setting)")
+}
+
 // parseTableRef splits "db.table" into db and table names.
 func parseTableRef(ref string) (db, table string, err error) {
 	parts := strings.SplitN(ref, ".", 2)
@@ -85,26 +102,13 @@ func detectInputFormat(file, flagFormat string) string {
 	return "jsonl"
 }
 
-// runInsert reads documents from r and bulk-inserts them into db.table.
-func runInsert(ctx context.Context, cfg *rootConfig, ic *insertConfig, dbName, tableName string, r io.Reader, out io.Writer) error {
-	if ic.batchSize < 1 {
-		return fmt.Errorf("--batch-size must be >= 1")
-	}
-	switch ic.conflict {
-	case "error", "replace", "update":
-	default:
-		return fmt.Errorf("--conflict: invalid value %q, must be error, replace, or update", ic.conflict)
-	}
-	if cfg.timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
-		defer cancel()
+// runInsert reads documents from r and bulk-inserts them into tbl.
+func runInsert(ctx context.Context, cfg *rootConfig, ic *insertConfig, tbl reql.Term, r io.Reader, out io.Writer) error {
+	opts, err := ic.insertOpts()
+	if err != nil {
+		return err
 	}
 
-	format := detectInputFormat(ic.file, cfg.format)
-	opts := reql.OptArgs{"conflict": ic.conflict}
-	tbl := reql.DB(dbName).Table(tableName)
-
 	exec, cleanup, err := newExecutor(cfg)
 	if err != nil {
 		return err
@@ -112,27 +116,92 @@ func runInsert(ctx context.Context, cfg *rootConfig, ic *insertConfig, dbName, t
 	defer cleanup()
 
 	var total insertResult
-	if format == "json" {
-		err = insertJSON(ctx, exec, cfg, tbl, opts, ic.batchSize, r, &total)
-	} else {
-		err = insertJSONL(ctx, exec, cfg, tbl, opts, ic.batchSize, r, &total)
-	}
+	err = insertStream(ctx, exec, cfg, tbl, opts, ic.batchSize, ic.file, r, &total)
 	data, _ := json.Marshal(total)
 	_, _ = fmt.Fprintf(out, "%s\n", data)
 	return err
 }
 
+// insertStream inserts the documents read from r, detecting a JSON array or
+// NDJSON from the first bytes of r, or from --format jsonl.
+func insertStream(ctx context.Context, exec *query.Executor, cfg *rootConfig, tbl reql.Term, opts reql.OptArgs, batchSize int, file string, r io.Reader, total *insertResult) error {
+	br := bufio.NewReader(r)
+	format := sniffInputFormat(br, detectInputFormat(file, cfg.format), cfg.format)
+	if format == "json" {
+		return insertJSON(ctx, exec, cfg, tbl, opts, batchSize, br, total)
+	}
+	return insertJSONL(ctx, exec, cfg, tbl, opts, batchSize, br, total)
+}
+
+// insertOpts validates the insert flags and returns the Insert optargs.
+func (ic *insertConfig) insertOpts() (reql.OptArgs, error) {
+	if ic.batchSize < 1 {
+		return nil, fmt.Errorf("--batch-size must be >= 1")
+	}
+	switch ic.conflict {
+	case "error", "replace", "update":
+	default:
+		return nil, fmt.Errorf("--conflict: invalid value %q, must be error, replace, or update", ic.conflict)
+	}
+	opts := reql.OptArgs{"conflict": ic.conflict}
+	switch ic.durability {
+	case "":
+	case "hard", "soft":
+		opts["durability"] = ic.durability
+	default:
+		return nil, fmt.Errorf("--durability: invalid value %q, must be hard or soft", ic.durability)
+	}
+	return opts, nil
+}
+
+// sniffInputFormat refines format, detected from the flag or the file
+// extension, by the first bytes of br: a leading '[' is a JSON array and
+// anything else NDJSON, so NDJSON exports named .json or read with
+// --format json insert as they are. --format jsonl and empty input keep
+// format.
+func sniffInputFormat(br *bufio.Reader, format, flagFormat string) string {
+	if flagFormat == "jsonl" {
+		return format
+	}
+	if startsWithArray(br) {
+		return "json"
+	}
+	if _, err := br.Peek(1); err != nil {
+		return format
+	}
+	return "jsonl"
+}
+
+// startsWithArray reports whether the first non-space byte of br is '['.
+func startsWithArray(br *bufio.Reader) bool {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return false
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			_ = br.UnreadByte()
+			return b == '['
+		}
+	}
+}
+
 // insertJSONL reads JSONL (one doc per line) and bulk-inserts in batches.
 func insertJSONL(ctx context.Context, exec *query.Executor, cfg *rootConfig, tbl reql.Term, opts reql.OptArgs, batchSize int, r io.Reader, total *insertResult) error {
 	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
 
 	var batch []json.RawMessage
+	lineNo := 0
 	for scanner.Scan() {
+		lineNo++
 		line := bytes.TrimSpace(scanner.Bytes())
 		if len(line) == 0 {
 			continue
 		}
+		if !json.Valid(line) {
+			return fmt.Errorf("parsing input: line %d: invalid JSON", lineNo)
+		}
 		batch = append(batch, json.RawMessage(string(line)))
 		if len(batch) >= batchSize {
 			if err := execInsertBatch(ctx, exec, cfg, tbl, opts, batch, total); err != nil {
@@ -174,13 +243,10 @@ func insertJSON(ctx context.Context, exec *query.Executor, cfg *rootConfig, tbl
 
 // execInsertBatch runs a single batch insert and accumulates totals.
 func execInsertBatch(ctx context.Context, exec *query.Executor, cfg *rootConfig, tbl reql.Term, opts reql.OptArgs, batch []json.RawMessage, total *insertResult) error {
-	items := make([]interface{}, len(batch))
-	for i, d := range batch {
-		items[i] = d
-	}
-	// wrap in MAKE_ARRAY so RethinkDB treats it as a datum array, not a ReQL term array
-	term := tbl.Insert(reql.Array(items...), opts)
-	_, cur, err := exec.Run(ctx, term, buildQueryOpts(cfg))
+	// send the batch through r.json() so arrays nested in documents are not
+	// interpreted as ReQL terms
+	term := tbl.Insert(reql.JSON(string(joinJSONArray(batch))), opts)
+	_, cur, err := runQuery(ctx, exec, cfg, term)
 	if err != nil {
 		return err
 	}
@@ -194,15 +260,27 @@ func execInsertBatch(ctx context.Context, exec *query.Executor, cfg *rootConfig,
 		return err
 	}
 	if len(rows) > 0 {
-		var res struct {
-			Inserted int64 `json:"inserted"`
-			Errors   int64 `json:"errors"`
-		}
+		var res insertResult
 		if err := json.Unmarshal(rows[0], &res); err != nil {
 			return fmt.Errorf("parsing insert response: %w", err)
 		}
 		total.Inserted += res.Inserted
+		total.Replaced += res.Replaced
 		total.Errors += res.Errors
 	}
 	return nil
 }
+
+// joinJSONArray concatenates docs into a single JSON array.
+func joinJSONArray(docs []json.RawMessage) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, d := range docs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(d)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
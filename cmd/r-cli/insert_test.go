@@ -8,6 +8,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"r-cli/internal/reql"
 )
 
 func TestInsertCmdRegistered(t *testing.T) {
@@ -223,7 +225,7 @@ func TestOpenInputSourceMissingFile(t *testing.T) {
 
 func TestInsertResultJSONMarshal(t *testing.T) {
 	t.Parallel()
-	res := insertResult{Inserted: 10, Errors: 2}
+	res := insertResult{Inserted: 10, Replaced: 3, Errors: 2}
 	data, err := json.Marshal(res)
 	if err != nil {
 		t.Fatal(err)
@@ -235,6 +237,9 @@ func TestInsertResultJSONMarshal(t *testing.T) {
 	if !strings.Contains(got, `"errors":2`) {
 		t.Errorf("insertResult JSON: missing errors field in %q", got)
 	}
+	if !strings.Contains(got, `"replaced":3`) {
+		t.Errorf("insertResult JSON: missing replaced field in %q", got)
+	}
 }
 
 func TestInsertJSONLReadsDocuments(t *testing.T) {
@@ -270,8 +275,124 @@ func TestInsertCmdUse(t *testing.T) {
 	t.Parallel()
 	cfg := &rootConfig{}
 	cmd := newInsertCmd(cfg)
-	if cmd.Use != "insert <db.table>" {
-		t.Errorf("Use: got %q, want %q", cmd.Use, "insert <db.table>")
+	if cmd.Use != "insert <table|db.table>" {
+		t.Errorf("Use: got %q, want %q", cmd.Use, "insert <table|db.table>")
+	}
+}
+
+func TestInsertOpts(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		ic      insertConfig
+		want    reql.OptArgs
+		wantErr bool
+	}{
+		{"defaults", insertConfig{batchSize: 200, conflict: "error"}, reql.OptArgs{"conflict": "error"}, false},
+		{"durability soft", insertConfig{batchSize: 1, conflict: "replace", durability: "soft"}, reql.OptArgs{"conflict": "replace", "durability": "soft"}, false},
+		{"bad durability", insertConfig{batchSize: 1, conflict: "error", durability: "fast"}, nil, true},
+		{"bad conflict", insertConfig{batchSize: 1, conflict: "skip"}, nil, true},
+		{"zero batch", insertConfig{batchSize: 0, conflict: "error"}, nil, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tc.ic.insertOpts()
+			if tc.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for k, v := range tc.want {
+				if got[k] != v {
+					t.Errorf("opts[%q]: got %v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestStartsWithArray(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{`[{"id":1}]`, true},
+		{"  \n\t[{\"id\":1}]", true},
+		{`{"id":1}`, false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		br := bufio.NewReader(strings.NewReader(tc.input))
+		if got := startsWithArray(br); got != tc.want {
+			t.Errorf("startsWithArray(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+		// the sniffed byte must still be readable
+		rest, _ := io.ReadAll(br)
+		if strings.TrimSpace(string(rest)) != strings.TrimSpace(tc.input) {
+			t.Errorf("startsWithArray(%q) consumed content: rest %q", tc.input, rest)
+		}
+	}
+}
+
+func TestSniffInputFormat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		input  string
+		format string
+		flag   string
+		want   string
+	}{
+		{"array in jsonl file", `[{"id":1}]`, "jsonl", "", "json"},
+		{"ndjson in json file", "{\"id\":1}\n{\"id\":2}\n", "json", "", "jsonl"},
+		{"ndjson with --format json", `{"id":1}`, "json", "json", "jsonl"},
+		{"array with --format json", " \n[{\"id\":1}]", "json", "json", "json"},
+		{"--format jsonl kept", `[{"id":1}]`, "jsonl", "jsonl", "jsonl"},
+		{"empty input", "  \n", "json", "", "json"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			br := bufio.NewReader(strings.NewReader(tc.input))
+			if got := sniffInputFormat(br, tc.format, tc.flag); got != tc.want {
+				t.Errorf("sniffInputFormat(%q, %q, %q) = %q, want %q", tc.input, tc.format, tc.flag, got, tc.want)
+			}
+			rest, _ := io.ReadAll(br)
+			if strings.TrimSpace(string(rest)) != strings.TrimSpace(tc.input) {
+				t.Errorf("sniffInputFormat(%q) consumed content: rest %q", tc.input, rest)
+			}
+		})
+	}
+}
+
+func TestJoinJSONArray(t *testing.T) {
+	t.Parallel()
+	got := joinJSONArray([]json.RawMessage{json.RawMessage(`{"id":1,"tags":["a"]}`), json.RawMessage(`{"id":2}`)})
+	if string(got) != `[{"id":1,"tags":["a"]},{"id":2}]` {
+		t.Errorf("got %s", got)
+	}
+	if got := joinJSONArray(nil); string(got) != `[]` {
+		t.Errorf("empty: got %s", got)
+	}
+}
+
+func TestInsertDurabilityFlag(t *testing.T) {
+	t.Parallel()
+	cmd := newInsertCmd(&rootConfig{})
+	if err := cmd.ParseFlags([]string{"--durability", "soft"}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := cmd.Flags().GetString("durability"); v != "soft" {
+		t.Errorf("--durability: got %q, want soft", v)
 	}
 }
 
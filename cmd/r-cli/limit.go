@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"r-cli/internal/output"
+)
+
+// limitIter yields at most limit rows from inner. When the limit is reached
+// it closes inner (if it is an io.Closer) so a streaming cursor sends STOP
+// instead of fetching further batches.
+type limitIter struct {
+	inner output.RowIterator
+	limit int
+	n     int
+}
+
+func (l *limitIter) Next() (json.RawMessage, error) {
+	if l.n >= l.limit {
+		if c, ok := l.inner.(io.Closer); ok {
+			_ = c.Close()
+		}
+		return nil, io.EOF
+	}
+	row, err := l.inner.Next()
+	if err != nil {
+		return nil, err
+	}
+	l.n++
+	return row, nil
+}
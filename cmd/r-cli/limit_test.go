@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+type closingStubIter struct {
+	stubIter
+	closed bool
+}
+
+func (c *closingStubIter) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestLimitIterStopsAtLimit(t *testing.T) {
+	t.Parallel()
+	inner := &closingStubIter{stubIter: stubIter{rows: []json.RawMessage{
+		json.RawMessage(`1`), json.RawMessage(`2`), json.RawMessage(`3`),
+	}}}
+	iter := &limitIter{inner: inner, limit: 2}
+	for i := range 2 {
+		if _, err := iter.Next(); err != nil {
+			t.Fatalf("row %d: %v", i, err)
+		}
+	}
+	if inner.closed {
+		t.Error("inner closed before the limit was exceeded")
+	}
+	if _, err := iter.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+	if !inner.closed {
+		t.Error("inner not closed after reaching the limit")
+	}
+}
+
+func TestLimitIterShorterResult(t *testing.T) {
+	t.Parallel()
+	iter := &limitIter{inner: &stubIter{rows: []json.RawMessage{json.RawMessage(`1`)}}, limit: 5}
+	if _, err := iter.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := iter.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
+
+func TestMakeIterAppliesLimit(t *testing.T) {
+	t.Parallel()
+	rows := []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`2`), json.RawMessage(`3`)}
+	iter := makeIter(&stubIter{rows: rows}, &rootConfig{limit: 1})
+	if _, err := iter.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := iter.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/output"
+	"r-cli/internal/reql"
+)
+
+func newDBsCmd(cfg *rootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "dbs",
+		Short: "List databases, one per line",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runList(cmd.Context(), cfg, reql.DBList(), os.Stdout)
+		},
+	}
+}
+
+func newTablesCmd(cfg *rootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:               "tables [db]",
+		ValidArgsFunction: firstArg(completeDBs(cfg)),
+		Short:             "List tables in a database (default --db), one per line",
+		Args:              cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := cfg.database
+			if len(args) == 1 {
+				name = args[0]
+			}
+			if name == "" {
+				return fmt.Errorf("tables: database required: pass it as an argument or use --db")
+			}
+			return runList(cmd.Context(), cfg, reql.DB(name).TableList(), os.Stdout)
+		},
+	}
+}
+
+func newIndexesCmd(cfg *rootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:               "indexes <table|db.table>",
+		ValidArgsFunction: firstArg(completeTableRef(cfg)),
+		Short:             "List secondary indexes of a table, one per line",
+		Args:              cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tbl, err := listTable(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			return runList(cmd.Context(), cfg, tbl.IndexList(), os.Stdout)
+		},
+	}
+}
+
+// listTable resolves "db.table", or a bare table name in --db.
+func listTable(cfg *rootConfig, ref string) (reql.Term, error) {
+	if strings.Contains(ref, ".") {
+		db, table, err := parseTableRef(ref)
+		if err != nil {
+			return reql.Term{}, err
+		}
+		return reql.DB(db).Table(table), nil
+	}
+	return indexTable(cfg, ref)
+}
+
+// runList executes term and prints each element of the resulting array on its
+// own line; an explicit --format or --template still applies per element.
+func runList(ctx context.Context, cfg *rootConfig, term reql.Term, w io.Writer) error {
+	localCfg := *cfg
+	if localCfg.format == "" && localCfg.template == "" {
+		localCfg.format = "raw"
+	}
+	return execTermWith(ctx, &localCfg, term, w, func(it output.RowIterator) output.RowIterator {
+		return &unrollIter{inner: it}
+	})
+}
+
+// unrollIter yields the elements of array rows one at a time; other rows pass through.
+type unrollIter struct {
+	inner   output.RowIterator
+	pending []json.RawMessage
+}
+
+func (u *unrollIter) Next() (json.RawMessage, error) {
+	for len(u.pending) == 0 {
+		row, err := u.inner.Next()
+		if err != nil {
+			return nil, err
+		}
+		if trimmed := bytes.TrimSpace(row); len(trimmed) == 0 || trimmed[0] != '[' {
+			return row, nil
+		}
+		var items []json.RawMessage
+		if json.Unmarshal(row, &items) != nil {
+			return row, nil
+		}
+		u.pending = items
+	}
+	row := u.pending[0]
+	u.pending = u.pending[1:]
+	return row, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestListCmdsRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	want := map[string]bool{"dbs": false, "tables": false, "indexes": false}
+	for _, sub := range root.Commands() {
+		if _, ok := want[sub.Name()]; ok {
+			want[sub.Name()] = true
+		}
+	}
+	for name, found := range want {
+		if !found {
+			t.Errorf("%s subcommand not registered on root command", name)
+		}
+	}
+}
+
+func TestListCmdArgs(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{}
+	if cmd := newDBsCmd(cfg); cmd.Args(cmd, []string{"x"}) == nil {
+		t.Error("dbs: expected error for extra arg")
+	}
+	if cmd := newTablesCmd(cfg); cmd.Args(cmd, []string{"a", "b"}) == nil {
+		t.Error("tables: expected error for two args")
+	}
+	if cmd := newIndexesCmd(cfg); cmd.Args(cmd, []string{}) == nil {
+		t.Error("indexes: expected error without table")
+	}
+}
+
+func TestTablesRequiresDB(t *testing.T) {
+	t.Parallel()
+	cmd := newTablesCmd(&rootConfig{})
+	cmd.SetContext(context.Background())
+	err := cmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "database required") {
+		t.Errorf("got %v, want database required error", err)
+	}
+}
+
+func TestListTable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		cfg     rootConfig
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"qualified", rootConfig{}, "app.users", `[15,[[14,["app"]],"users"]]`, false},
+		{"bare with --db", rootConfig{database: "app"}, "users", `[15,[[14,["app"]],"users"]]`, false},
+		{"bare without --db", rootConfig{}, "users", "", true},
+		{"empty table", rootConfig{}, "app.", "", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			term, err := listTable(&tc.cfg, tc.ref)
+			if tc.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(term)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnrollIter(t *testing.T) {
+	t.Parallel()
+	iter := &unrollIter{inner: &stubIter{rows: []json.RawMessage{
+		json.RawMessage(`["a","b"]`),
+		json.RawMessage(`[]`),
+		json.RawMessage(`"c"`),
+		json.RawMessage(`["d"]`),
+	}}}
+	var got []string
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(row))
+	}
+	want := `"a","b","c","d"`
+	if strings.Join(got, ",") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
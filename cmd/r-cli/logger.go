@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// newLogger builds the stderr logger: errors only with --quiet, warnings by
+// default, connection lifecycle and query timing with -v and wire frames with
+// -vv. --log-json writes one JSON object per record, otherwise records are
+// plain "msg key=value" lines.
+func newLogger(w io.Writer, verbose int, quiet, asJSON bool) *slog.Logger {
+	level := slog.LevelWarn
+	switch {
+	case quiet:
+		level = slog.LevelError
+	case verbose >= 2:
+		level = slog.LevelDebug
+	case verbose == 1:
+		level = slog.LevelInfo
+	}
+	if asJSON {
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+	}
+	return slog.New(&lineHandler{w: w, mu: &sync.Mutex{}, level: level})
+}
+
+// log returns the logger set up by resolve, or a discarding one for configs
+// built without it.
+func (c *rootConfig) log() *slog.Logger {
+	if c.logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return c.logger
+}
+
+// lineHandler writes records as "msg key=value ..." lines without time or
+// level, matching the tone of the CLI's other stderr output. Warnings and
+// errors are prefixed with their level.
+type lineHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	level  slog.Level
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *lineHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level
+}
+
+func (h *lineHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	if r.Level >= slog.LevelWarn {
+		sb.WriteString(strings.ToLower(r.Level.String()) + ": ")
+	}
+	sb.WriteString(r.Message)
+	for _, a := range h.attrs {
+		writeAttr(&sb, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&sb, h.prefix, a)
+		return true
+	})
+	sb.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *lineHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	nh.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		a.Key = h.prefix + a.Key
+		nh.attrs = append(nh.attrs, a)
+	}
+	return &nh
+}
+
+func (h *lineHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	nh := *h
+	nh.prefix = h.prefix + name + "."
+	return &nh
+}
+
+// writeAttr appends " key=value", flattening groups into dotted keys and
+// quoting values that contain spaces or quotes.
+func writeAttr(sb *strings.Builder, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		for _, ga := range v.Group() {
+			writeAttr(sb, prefix+a.Key+".", ga)
+		}
+		return
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	var s string
+	switch v.Kind() {
+	case slog.KindDuration:
+		s = v.Duration().Round(time.Microsecond).String()
+	case slog.KindString:
+		s = v.String()
+	default:
+		s = fmt.Sprint(v.Any())
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		s = strconv.Quote(s)
+	}
+	sb.WriteString(" " + prefix + a.Key + "=" + s)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLoggerLevels(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		verbose int
+		quiet   bool
+		want    slog.Level
+	}{
+		{"default", 0, false, slog.LevelWarn},
+		{"quiet", 0, true, slog.LevelError},
+		{"quiet wins", 2, true, slog.LevelError},
+		{"-v", 1, false, slog.LevelInfo},
+		{"-vv", 2, false, slog.LevelDebug},
+		{"-vvv", 3, false, slog.LevelDebug},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			l := newLogger(&strings.Builder{}, tt.verbose, tt.quiet, false)
+			if !l.Enabled(t.Context(), tt.want) {
+				t.Errorf("level %s not enabled", tt.want)
+			}
+			if l.Enabled(t.Context(), tt.want-1) {
+				t.Errorf("level below %s enabled", tt.want)
+			}
+		})
+	}
+}
+
+func TestLineHandlerFormat(t *testing.T) {
+	t.Parallel()
+	var buf strings.Builder
+	l := newLogger(&buf, 1, false, false)
+	l.Info("connected", "addr", "localhost:28015", "duration", 1234567*time.Nanosecond)
+	l.Warn("feed lost", "error", errors.New("read: connection reset"))
+	l.With("table", "test.users").WithGroup("rows").Info("exported", "n", 3, "note", "")
+	l.Debug("frame out")
+
+	want := "connected addr=localhost:28015 duration=1.235ms\n" +
+		"warn: feed lost error=\"read: connection reset\"\n" +
+		"exported table=test.users rows.n=3 rows.note=\"\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLineHandlerGroupAttr(t *testing.T) {
+	t.Parallel()
+	var buf strings.Builder
+	l := newLogger(&buf, 0, false, false)
+	l.Error("failed", slog.Group("query", "token", 7, "kind", "a=b"))
+	if got, want := buf.String(), "error: failed query.token=7 query.kind=\"a=b\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewLoggerJSON(t *testing.T) {
+	t.Parallel()
+	var buf strings.Builder
+	l := newLogger(&buf, 1, false, true)
+	l.Info("query done", "duration", time.Second)
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(buf.String()), &rec); err != nil {
+		t.Fatalf("not JSON: %q: %v", buf.String(), err)
+	}
+	if rec["msg"] != "query done" || rec["level"] != "INFO" || rec["duration"] != float64(time.Second) {
+		t.Errorf("got %v", rec)
+	}
+}
+
+func TestRootConfigLogDiscardsWithoutLogger(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{}
+	if cfg.log().Enabled(t.Context(), slog.LevelError) {
+		t.Error("log() without a logger should discard")
+	}
+}
@@ -18,6 +18,7 @@ func main() {
 
 	parselog.SetVersion(version)
 	cmd := newRootCmd()
+	cmd.SetArgs(rewritePasswordPrompt(os.Args[1:]))
 	err := cmd.ExecuteContext(ctx)
 
 	ctxErr := ctx.Err()
@@ -27,6 +28,9 @@ func main() {
 		if errors.Is(err, errAborted) {
 			os.Exit(exitOK)
 		}
+		if errors.Is(err, errEmptyResult) {
+			os.Exit(exitEmpty)
+		}
 		if ctxErr != nil {
 			os.Exit(exitINT)
 		}
@@ -8,7 +8,8 @@ import (
 )
 
 // testLogDir is the parselog directory used by all tests in this package.
-// Set once in TestMain to a temp directory to avoid writing to ~/.r-cli.
+// Set once in TestMain to a temp directory to avoid writing to ~/.r-cli;
+// RCLI_CONFIG points into it as well.
 var testLogDir string
 
 func TestMain(m *testing.M) {
@@ -18,6 +19,10 @@ func TestMain(m *testing.M) {
 		panic("setup test parselog dir: " + err.Error())
 	}
 	parselog.SetDir(testLogDir)
+	// keep the developer's own config file (and its default profile) out of tests
+	if err := os.Setenv("RCLI_CONFIG", testLogDir+"/config.yaml"); err != nil {
+		panic("setup test config path: " + err.Error())
+	}
 	code := m.Run()
 	_ = os.RemoveAll(testLogDir)
 	os.Exit(code)
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/reql"
+)
+
+// selector holds the --key/--filter flags shared by delete and update.
+type selector struct {
+	key    string
+	filter string
+}
+
+func (s *selector) register(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.StringVar(&s.key, "key", "", "select a single document by primary key")
+	f.StringVar(&s.filter, "filter", "", "select documents matching a JSON object")
+	cmd.MarkFlagsMutuallyExclusive("key", "filter")
+}
+
+// term narrows tbl to the selected documents; with neither flag set the whole table is selected.
+func (s *selector) term(tbl reql.Term) (reql.Term, error) {
+	switch {
+	case s.key != "":
+		return tbl.Get(parseKeyArg(s.key)), nil
+	case s.filter != "":
+		return filterTerm(tbl, s.filter)
+	}
+	return tbl, nil
+}
+
+// describe renders the selection for the confirmation prompt.
+func (s *selector) describe(ref string) string {
+	switch {
+	case s.key != "":
+		return fmt.Sprintf("document %s in %s", s.key, ref)
+	case s.filter != "":
+		return fmt.Sprintf("documents in %s matching %s", ref, s.filter)
+	}
+	return "ALL documents in " + ref
+}
+
+func newDeleteCmd(cfg *rootConfig) *cobra.Command {
+	var sel selector
+	var yes bool
+	cmd := &cobra.Command{
+		Use:               "delete <table|db.table>",
+		ValidArgsFunction: firstArg(completeTableRef(cfg)),
+		Short:             "Delete documents selected by --key or --filter (whole table if neither)",
+		Args:              cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tbl, err := listTable(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			target, err := sel.term(tbl)
+			if err != nil {
+				return err
+			}
+			if !yes {
+				if err := confirm("Delete "+sel.describe(args[0])+"?", os.Stdin, cfg.quiet); err != nil {
+					return err
+				}
+			}
+			return execTerm(cmd.Context(), cfg, target.Delete(), os.Stdout)
+		},
+	}
+	sel.register(cmd)
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation prompt")
+	return cmd
+}
+
+func newUpdateCmd(cfg *rootConfig) *cobra.Command {
+	var sel selector
+	var set string
+	var yes bool
+	cmd := &cobra.Command{
+		Use:               "update <table|db.table> --set <json>",
+		ValidArgsFunction: firstArg(completeTableRef(cfg)),
+		Short:             "Merge a JSON object into documents selected by --key or --filter (whole table if neither)",
+		Args:              cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tbl, err := listTable(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			target, err := sel.term(tbl)
+			if err != nil {
+				return err
+			}
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(set), &obj); err != nil {
+				return fmt.Errorf("set must be a JSON object: %w", err)
+			}
+			if !yes {
+				if err := confirm("Update "+sel.describe(args[0])+"?", os.Stdin, cfg.quiet); err != nil {
+					return err
+				}
+			}
+			return execTerm(cmd.Context(), cfg, target.Update(reql.JSON(set)), os.Stdout)
+		},
+	}
+	sel.register(cmd)
+	f := cmd.Flags()
+	f.StringVar(&set, "set", "", "JSON object to merge into each selected document")
+	f.BoolVarP(&yes, "yes", "y", false, "skip confirmation prompt")
+	_ = cmd.MarkFlagRequired("set")
+	return cmd
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"r-cli/internal/reql"
+)
+
+func TestDeleteUpdateCmdsRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	found := map[string]bool{}
+	for _, sub := range root.Commands() {
+		found[sub.Name()] = true
+	}
+	for _, name := range []string{"delete", "update"} {
+		if !found[name] {
+			t.Errorf("%s subcommand not registered on root command", name)
+		}
+	}
+}
+
+func TestDeleteUpdateFlags(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{}
+	del := newDeleteCmd(cfg)
+	upd := newUpdateCmd(cfg)
+	for _, name := range []string{"key", "filter", "yes"} {
+		if del.Flags().Lookup(name) == nil {
+			t.Errorf("delete: missing --%s flag", name)
+		}
+		if upd.Flags().Lookup(name) == nil {
+			t.Errorf("update: missing --%s flag", name)
+		}
+	}
+	if upd.Flags().Lookup("set") == nil {
+		t.Error("update: missing --set flag")
+	}
+}
+
+func TestUpdateRequiresSet(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	root.SetArgs([]string{"update", "users", "--yes"})
+	if err := root.Execute(); err == nil {
+		t.Error("expected error without --set")
+	}
+}
+
+func TestDeleteKeyFilterExclusive(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	root.SetArgs([]string{"delete", "users", "--key", "1", "--filter", "{}", "--yes"})
+	if err := root.Execute(); err == nil {
+		t.Error("expected error for --key with --filter")
+	}
+}
+
+func TestSelectorTerm(t *testing.T) {
+	t.Parallel()
+	tbl := reql.Table("users")
+	tests := []struct {
+		sel  selector
+		want string
+	}{
+		{selector{}, `[15,["users"]]`},
+		{selector{key: "abc"}, `[16,[[15,["users"]],"abc"]]`},
+		{selector{filter: `{"a":[1]}`}, `[39,[[15,["users"]],[98,["{\"a\":[1]}"]]]]`},
+	}
+	for _, tc := range tests {
+		term, err := tc.sel.term(tbl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(term)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("%+v: got %s, want %s", tc.sel, got, tc.want)
+		}
+	}
+	if _, err := (&selector{filter: "[1]"}).term(tbl); err == nil {
+		t.Error("expected error for non-object filter")
+	}
+}
+
+func TestSelectorDescribe(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		sel  selector
+		want string
+	}{
+		{selector{}, "ALL documents in users"},
+		{selector{key: "42"}, "document 42 in users"},
+		{selector{filter: `{"a":1}`}, `documents in users matching {"a":1}`},
+	}
+	for _, tc := range tests {
+		if got := tc.sel.describe("users"); got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
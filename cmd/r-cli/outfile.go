@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"r-cli/internal/output"
+)
+
+// outputTarget is the destination for formatted results: either the command's
+// writer, or a temp file that is renamed over the target path on success so
+// that a failed query never leaves a partial file behind.
+type outputTarget struct {
+	io.Writer
+	tmp  *os.File
+	path string
+
+	// --format sqlite: the sqlite3 shell the Writer feeds, and its table
+	shell *sqliteProc
+	table *output.SQLTable
+}
+
+// openOutputTarget returns a target writing to w when path is empty or "-",
+// otherwise a temp file created next to path.
+func openOutputTarget(path string, w io.Writer) (*outputTarget, error) {
+	if path == "" || path == "-" {
+		return &outputTarget{Writer: w}, nil
+	}
+	tmp, err := createOutputTemp(path)
+	if err != nil {
+		return nil, fmt.Errorf("output: %w", err)
+	}
+	return &outputTarget{Writer: tmp, tmp: tmp, path: path}, nil
+}
+
+// format resolves the output format; files are treated like pipes for auto-detection.
+func (o *outputTarget) format(flagFormat string) string {
+	if o.tmp != nil {
+		return output.DetectFormat(o.tmp, flagFormat)
+	}
+	return output.DetectFormat(os.Stdout, flagFormat)
+}
+
+// finish renames the temp file into place when err is nil and removes it otherwise.
+// Returns err, or the first error encountered while committing the file.
+func (o *outputTarget) finish(err error) error {
+	if o.shell != nil {
+		return o.shell.wait(err)
+	}
+	if o.tmp == nil {
+		return err
+	}
+	name := o.tmp.Name()
+	if err != nil {
+		_ = o.tmp.Close()
+		_ = os.Remove(name)
+		return err
+	}
+	// a replaced file keeps its mode
+	if fi, err := os.Stat(o.path); err == nil {
+		if err := o.tmp.Chmod(fi.Mode().Perm()); err != nil {
+			_ = o.tmp.Close()
+			_ = os.Remove(name)
+			return fmt.Errorf("output: %w", err)
+		}
+	}
+	if err := o.tmp.Close(); err != nil {
+		_ = os.Remove(name)
+		return fmt.Errorf("output: %w", err)
+	}
+	if err := os.Rename(name, o.path); err != nil {
+		_ = os.Remove(name)
+		return fmt.Errorf("output: %w", err)
+	}
+	return nil
+}
+
+// createOutputTemp creates a temp file next to path. Unlike os.CreateTemp,
+// which uses 0600, it asks for 0666 so the umask gives the file the mode
+// os.Create would.
+func createOutputTemp(path string) (*os.File, error) {
+	prefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	for {
+		name := prefix + strconv.FormatUint(rand.Uint64(), 36)
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666) //nolint:gosec // output files are not secret
+		if !errors.Is(err, os.ErrExist) {
+			return f, err
+		}
+	}
+}
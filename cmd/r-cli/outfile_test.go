@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenOutputTargetStdout(t *testing.T) {
+	t.Parallel()
+	for _, path := range []string{"", "-"} {
+		var buf bytes.Buffer
+		o, err := openOutputTarget(path, &buf)
+		if err != nil {
+			t.Fatalf("path %q: %v", path, err)
+		}
+		_, _ = fmt.Fprint(o, "hello")
+		if err := o.finish(nil); err != nil {
+			t.Fatalf("path %q: finish: %v", path, err)
+		}
+		if buf.String() != "hello" {
+			t.Errorf("path %q: got %q, want %q", path, buf.String(), "hello")
+		}
+	}
+}
+
+func TestOpenOutputTargetFileCommit(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.jsonl")
+	o, err := openOutputTarget(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fmt.Fprintln(o, `{"a":1}`)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("target file must not exist before finish, stat err: %v", err)
+	}
+	if err := o.finish(nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\"a\":1}\n" {
+		t.Errorf("got %q", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the output file in dir, got %d entries", len(entries))
+	}
+}
+
+func TestOpenOutputTargetFileAbortKeepsOriginal(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.json")
+	if err := os.WriteFile(path, []byte("original"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	o, err := openOutputTarget(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fmt.Fprint(o, "partial")
+	errQuery := errors.New("query failed")
+	if err := o.finish(errQuery); !errors.Is(err, errQuery) {
+		t.Errorf("finish: got %v, want %v", err, errQuery)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "original" {
+		t.Errorf("original file modified: %q", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp file left behind: %d entries", len(entries))
+	}
+}
+
+func TestOpenOutputTargetFileMode(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	write := func(path string) os.FileMode {
+		t.Helper()
+		o, err := openOutputTarget(path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = fmt.Fprint(o, "x")
+		if err := o.finish(nil); err != nil {
+			t.Fatal(err)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Mode().Perm()
+	}
+	// a new file gets what os.Create would give it
+	created := filepath.Join(dir, "created")
+	f, err := os.Create(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+	fi, err := os.Stat(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := write(filepath.Join(dir, "new.jsonl")); got != fi.Mode().Perm() {
+		t.Errorf("new file mode = %v, want %v", got, fi.Mode().Perm())
+	}
+	// a replaced file keeps its mode
+	existing := filepath.Join(dir, "existing.jsonl")
+	if err := os.WriteFile(existing, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(existing, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if got := write(existing); got != 0o640 {
+		t.Errorf("replaced file mode = %v, want 0640", got)
+	}
+}
+
+func TestOpenOutputTargetMissingDir(t *testing.T) {
+	t.Parallel()
+	if _, err := openOutputTarget("/nonexistent/dir/out.json", nil); err == nil {
+		t.Error("expected error for missing directory, got nil")
+	}
+}
+
+func TestOutputTargetFileFormatAutoDetect(t *testing.T) {
+	t.Parallel()
+	o, err := openOutputTarget(filepath.Join(t.TempDir(), "out"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = o.finish(errors.New("discard")) }()
+	if got := o.format(""); got != "jsonl" {
+		t.Errorf("auto format for file: got %q, want jsonl", got)
+	}
+	if got := o.format("table"); got != "table" {
+		t.Errorf("explicit format: got %q, want table", got)
+	}
+}
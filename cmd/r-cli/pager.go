@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// defaultPager runs when $PAGER is unset; -R passes the JSON colors through.
+const defaultPager = "less -R"
+
+// errPagerQuit is returned by pagerWriter.Write once the pager has exited,
+// e.g. because the user quit it before the end of the result.
+var errPagerQuit = errors.New("pager quit")
+
+// terminalSize reports the columns and rows of the terminal f is, if any;
+// replaced in tests.
+var terminalSize = func(f *os.File) (cols, rows int, ok bool) {
+	if !term.IsTerminal(int(f.Fd())) { //nolint:gosec
+		return 0, 0, false
+	}
+	cols, rows, err := term.GetSize(int(f.Fd())) //nolint:gosec
+	return cols, rows, err == nil && cols > 0 && rows > 0
+}
+
+// pagerWriter holds back what is written to a terminal until it would
+// scroll the screen, then starts the pager and streams the rest through it.
+type pagerWriter struct {
+	out     io.Writer // the terminal
+	command []string
+	rows    int // screen rows that fit before paging
+	cols    int
+
+	buf  bytes.Buffer
+	used int  // screen rows taken by buf
+	col  int  // column of the last line in buf
+	esc  bool // inside an ANSI escape sequence
+
+	cmd  *exec.Cmd
+	pipe io.WriteCloser
+}
+
+// newReplPager returns a pagerWriter for w when w is a terminal and the
+// pager is not turned off, otherwise nil.
+func newReplPager(w io.Writer, cfg *rootConfig) *pagerWriter {
+	f, ok := w.(*os.File)
+	if cfg.pagerOff || !ok {
+		return nil
+	}
+	cols, rows, ok := terminalSize(f)
+	if !ok {
+		return nil
+	}
+	command := strings.Fields(os.Getenv("PAGER"))
+	if len(command) == 0 {
+		command = strings.Fields(defaultPager)
+	}
+	// the last row stays free for the prompt
+	return &pagerWriter{out: w, command: command, rows: rows - 1, cols: cols}
+}
+
+func (p *pagerWriter) Write(b []byte) (int, error) {
+	if p.pipe != nil {
+		if _, err := p.pipe.Write(b); err != nil {
+			return 0, errPagerQuit
+		}
+		return len(b), nil
+	}
+	p.buf.Write(b)
+	p.count(b)
+	if p.used <= p.rows {
+		return len(b), nil
+	}
+	if err := p.startPager(); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// count adds the screen rows b takes to used, wrapping long lines and
+// skipping ANSI color sequences.
+func (p *pagerWriter) count(b []byte) {
+	for _, r := range string(b) {
+		switch {
+		case p.esc:
+			p.esc = r < '@' || r > '~' || r == '['
+		case r == '\x1b':
+			p.esc = true
+		case r == '\n':
+			p.used++
+			p.col = 0
+		default:
+			if p.col == p.cols {
+				p.used++
+				p.col = 0
+			}
+			p.col++
+		}
+	}
+}
+
+// startPager runs the pager and hands it the buffered output. When the
+// pager cannot start, the output goes to the terminal as is.
+func (p *pagerWriter) startPager() error {
+	cmd := exec.Command(p.command[0], p.command[1:]...) //nolint:gosec // $PAGER is the user's choice
+	cmd.Stdout = p.out
+	cmd.Stderr = os.Stderr
+	pipe, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "pager %q: %v\n", strings.Join(p.command, " "), err)
+		p.pipe = nopWriteCloser{p.out}
+	} else {
+		p.cmd, p.pipe = cmd, pipe
+	}
+	_, err = p.Write(p.buf.Bytes())
+	p.buf.Reset()
+	return err
+}
+
+// finish flushes output that fit on the screen, or waits for the pager to
+// exit. A pager quit by the user is not an error.
+func (p *pagerWriter) finish(err error) error {
+	if p.pipe == nil {
+		if _, werr := p.out.Write(p.buf.Bytes()); err == nil {
+			err = werr
+		}
+		return err
+	}
+	_ = p.pipe.Close()
+	if p.cmd != nil {
+		_ = p.cmd.Wait() // less exits non-zero on some terminals; nothing to report
+	}
+	if errors.Is(err, errPagerQuit) {
+		return nil
+	}
+	return err
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
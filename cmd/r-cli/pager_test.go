@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestPager(command ...string) (*pagerWriter, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &pagerWriter{out: &out, command: command, rows: 3, cols: 10}, &out
+}
+
+func TestPagerWriterShortOutput(t *testing.T) {
+	t.Parallel()
+	p, out := newTestPager("sed", "s/^/paged:/")
+	_, _ = p.Write([]byte("1\n2\n"))
+	_, _ = p.Write([]byte("3\n"))
+	if out.Len() != 0 {
+		t.Errorf("wrote %q before finish", out.String())
+	}
+	if err := p.finish(nil); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if out.String() != "1\n2\n3\n" {
+		t.Errorf("out = %q", out.String())
+	}
+}
+
+func TestPagerWriterPages(t *testing.T) {
+	t.Parallel()
+	p, out := newTestPager("sed", "s/^/paged:/")
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n", "5\n"} {
+		if _, err := p.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := p.finish(nil); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if want := "paged:1\npaged:2\npaged:3\npaged:4\npaged:5\n"; out.String() != want {
+		t.Errorf("out = %q, want %q", out.String(), want)
+	}
+}
+
+func TestPagerWriterQuit(t *testing.T) {
+	t.Parallel()
+	p, out := newTestPager("head", "-n", "1")
+	var err error
+	for i := 0; i < 100000 && err == nil; i++ {
+		_, err = p.Write([]byte("row\n"))
+	}
+	if !errors.Is(err, errPagerQuit) {
+		t.Fatalf("Write = %v, want errPagerQuit", err)
+	}
+	if err := p.finish(err); err != nil {
+		t.Errorf("finish = %v, want nil after the pager quit", err)
+	}
+	if out.String() != "row\n" {
+		t.Errorf("out = %q", out.String())
+	}
+}
+
+func TestPagerWriterMissingPager(t *testing.T) {
+	t.Parallel()
+	p, out := newTestPager(filepath.Join(t.TempDir(), "no-such-pager"))
+	_, _ = p.Write([]byte("1\n2\n3\n4\n"))
+	_, _ = p.Write([]byte("5\n"))
+	if err := p.finish(nil); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if out.String() != "1\n2\n3\n4\n5\n" {
+		t.Errorf("out = %q", out.String())
+	}
+}
+
+func TestPagerWriterCount(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		in   string
+		used int
+		col  int
+	}{
+		{"abc\n", 1, 0},
+		{"0123456789", 0, 10},
+		{"0123456789x", 1, 1},
+		{"0123456789\n", 1, 0},
+		{"\x1b[1;34m\"key\"\x1b[0m\n", 1, 0},
+		{"\x1b[32m0123456789\x1b[0m", 0, 10},
+	}
+	for _, tc := range cases {
+		p := &pagerWriter{cols: 10}
+		p.count([]byte(tc.in))
+		if p.used != tc.used || p.col != tc.col {
+			t.Errorf("count(%q): used=%d col=%d, want %d %d", tc.in, p.used, p.col, tc.used, tc.col)
+		}
+	}
+}
+
+func TestNewReplPager(t *testing.T) {
+	oldSize := terminalSize
+	t.Cleanup(func() { terminalSize = oldSize })
+	terminalSize = func(*os.File) (int, int, bool) { return 80, 24, true }
+	t.Setenv("PAGER", "more -s")
+	if p := newReplPager(&bytes.Buffer{}, &rootConfig{}); p != nil {
+		t.Error("pager for a non-file writer")
+	}
+	if p := newReplPager(os.Stdout, &rootConfig{pagerOff: true}); p != nil {
+		t.Error("pager after .pager off")
+	}
+	p := newReplPager(os.Stdout, &rootConfig{})
+	if p == nil || p.rows != 23 || p.cols != 80 || strings.Join(p.command, " ") != "more -s" {
+		t.Fatalf("pager = %+v", p)
+	}
+	t.Setenv("PAGER", "")
+	if p := newReplPager(os.Stdout, &rootConfig{}); p == nil || strings.Join(p.command, " ") != defaultPager {
+		t.Errorf("pager without $PAGER = %+v", p)
+	}
+	terminalSize = func(*os.File) (int, int, bool) { return 0, 0, false }
+	if p := newReplPager(os.Stdout, &rootConfig{}); p != nil {
+		t.Error("pager when stdout is not a terminal")
+	}
+}
+
+func TestColorEnabledThroughPager(t *testing.T) {
+	t.Parallel()
+	if !colorEnabled(&pagerWriter{out: &bytes.Buffer{}}, "always") {
+		t.Error("color off through the pager with --color always")
+	}
+	if colorEnabled(&pagerWriter{out: &bytes.Buffer{}}, "auto") {
+		t.Error("color on for a pager over a buffer")
+	}
+}
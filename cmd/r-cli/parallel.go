@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// runParallel calls fn for indexes 0..count-1 with at most n calls in flight.
+// The first failure cancels the context passed to the remaining calls and is
+// returned; cancellations caused by it are not reported separately.
+func runParallel(ctx context.Context, n, count int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, count)
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			if errs[i] = fn(ctx, i); errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
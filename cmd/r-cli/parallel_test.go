@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunParallelAll(t *testing.T) {
+	t.Parallel()
+	var calls, inFlight, peak atomic.Int32
+	err := runParallel(context.Background(), 2, 10, func(_ context.Context, _ int) error {
+		calls.Add(1)
+		cur := inFlight.Add(1)
+		for {
+			old := peak.Load()
+			if cur <= old || peak.CompareAndSwap(old, cur) {
+				break
+			}
+		}
+		inFlight.Add(-1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 10 {
+		t.Errorf("calls: got %d, want 10", calls.Load())
+	}
+	if peak.Load() > 2 {
+		t.Errorf("peak concurrency: got %d, want <= 2", peak.Load())
+	}
+}
+
+func TestRunParallelFirstError(t *testing.T) {
+	t.Parallel()
+	boom := errors.New("boom")
+	err := runParallel(context.Background(), 1, 5, func(ctx context.Context, i int) error {
+		if i == 0 {
+			return boom
+		}
+		return ctx.Err()
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("got %v, want boom", err)
+	}
+}
+
+func TestRunParallelEmpty(t *testing.T) {
+	t.Parallel()
+	if err := runParallel(context.Background(), 4, 0, nil); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
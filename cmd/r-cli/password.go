@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// passwordPromptFlag is the hidden flag that -p/--password without a value
+// is rewritten to, since pflag cannot tell a missing value from the next arg.
+const passwordPromptFlag = "password-prompt"
+
+// rewritePasswordPrompt replaces a -p or --password that is the last argument,
+// or is followed by another flag, with --password-prompt. Arguments after
+// "--" are left alone.
+func rewritePasswordPrompt(args []string) []string {
+	out := make([]string, len(args))
+	copy(out, args)
+	for i, a := range out {
+		if a == "--" {
+			break
+		}
+		if a != "-p" && a != "--password" {
+			continue
+		}
+		if i == len(out)-1 || (len(out[i+1]) > 1 && strings.HasPrefix(out[i+1], "-")) {
+			out[i] = "--" + passwordPromptFlag
+		}
+	}
+	return out
+}
+
+// checkPasswordSources rejects --password-stdin and the -p prompt combined
+// with each other or with --password / --password-file.
+func (c *rootConfig) checkPasswordSources(changed func(string) bool) error {
+	if !c.passwordStdin && !c.passwordPrompt {
+		return nil
+	}
+	if c.passwordStdin && c.passwordPrompt {
+		return fmt.Errorf("--password-stdin cannot be used with -p without a value")
+	}
+	if changed("password") || changed("password-file") {
+		return fmt.Errorf("--password-stdin and -p without a value cannot be used with --password or --password-file")
+	}
+	return nil
+}
+
+// readPasswordStdin reads the whole of r as the password, without the
+// trailing newline.
+func readPasswordStdin(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("reading password from stdin: %w", err)
+	}
+	pwd := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if pwd == "" {
+		return "", fmt.Errorf("--password-stdin: password cannot be empty")
+	}
+	return pwd, nil
+}
+
+// promptLoginPassword asks for the connection password with echo disabled.
+func promptLoginPassword() (string, error) {
+	if !stdinIsTTY() {
+		return "", fmt.Errorf("-p without a value needs a terminal; use --password-stdin or --password-file")
+	}
+	return promptPassword(os.Stderr, os.Stdin)
+}
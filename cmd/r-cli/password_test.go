@@ -0,0 +1,90 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRewritePasswordPrompt(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-p"}, []string{"--password-prompt"}},
+		{[]string{"--password", "--db", "app", "tables"}, []string{"--password-prompt", "--db", "app", "tables"}},
+		{[]string{"-p", "secret", "dbs"}, []string{"-p", "secret", "dbs"}},
+		{[]string{"-p", "", "dbs"}, []string{"-p", "", "dbs"}},
+		{[]string{"-p", "-", "dbs"}, []string{"-p", "-", "dbs"}},
+		{[]string{"query", "--", "-p"}, []string{"query", "--", "-p"}},
+	}
+	for _, tc := range tests {
+		if got := rewritePasswordPrompt(tc.args); !slices.Equal(got, tc.want) {
+			t.Errorf("rewritePasswordPrompt(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestRewritePasswordPromptParses(t *testing.T) {
+	t.Parallel()
+	cmd := newRootCmd()
+	if err := cmd.ParseFlags(rewritePasswordPrompt([]string{"-u", "bob", "-p"})); err != nil {
+		t.Fatal(err)
+	}
+	if on, _ := cmd.PersistentFlags().GetBool("password-prompt"); !on {
+		t.Error("expected --password-prompt to be set")
+	}
+	if pwd, _ := cmd.PersistentFlags().GetString("password"); pwd != "" {
+		t.Errorf("password: got %q, want empty", pwd)
+	}
+}
+
+func TestReadPasswordStdin(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[string]string{"pass\n": "pass", "pass\r\n": "pass", "pass": "pass", " sp ace \n": " sp ace "} {
+		got, err := readPasswordStdin(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%q: got %q, want %q", in, got, want)
+		}
+	}
+	if _, err := readPasswordStdin(strings.NewReader("\n")); err == nil {
+		t.Error("expected error for empty password")
+	}
+}
+
+func TestCheckPasswordSources(t *testing.T) {
+	t.Parallel()
+	none := func(string) bool { return false }
+	flag := func(name string) bool { return name == "password-file" }
+	tests := []struct {
+		name    string
+		cfg     rootConfig
+		changed func(string) bool
+		wantErr bool
+	}{
+		{"nothing", rootConfig{}, flag, false},
+		{"stdin", rootConfig{passwordStdin: true}, none, false},
+		{"stdin and prompt", rootConfig{passwordStdin: true, passwordPrompt: true}, none, true},
+		{"stdin and file", rootConfig{passwordStdin: true}, flag, true},
+		{"prompt and file", rootConfig{passwordPrompt: true}, flag, true},
+		{"stdin and profile file", rootConfig{passwordStdin: true, passwordFile: "/p"}, none, false},
+	}
+	for _, tc := range tests {
+		if err := tc.cfg.checkPasswordSources(tc.changed); (err != nil) != tc.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestPromptLoginPasswordNoTTY(t *testing.T) {
+	orig := stdinIsTTY
+	stdinIsTTY = func() bool { return false }
+	t.Cleanup(func() { stdinIsTTY = orig })
+	if _, err := promptLoginPassword(); err == nil || !strings.Contains(err.Error(), "--password-stdin") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/reql"
+)
+
+func newGetCmd(cfg *rootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:               "get <table|db.table> <key>",
+		ValidArgsFunction: firstArg(completeTableRef(cfg)),
+		Short:             "Fetch a document by primary key",
+		Long: "Fetch a document by primary key. A key that is valid JSON (number, array,\n" +
+			`quoted string) is sent as that value; anything else is sent as a string.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tbl, err := listTable(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			return execTerm(cmd.Context(), cfg, tbl.Get(parseKeyArg(args[1])), os.Stdout)
+		},
+	}
+}
+
+func newCountCmd(cfg *rootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:               "count <table|db.table> [filter-json]",
+		ValidArgsFunction: firstArg(completeTableRef(cfg)),
+		Short:             "Count documents, optionally matching a JSON filter object",
+		Args:              cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tbl, err := listTable(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			if len(args) == 2 {
+				if tbl, err = filterTerm(tbl, args[1]); err != nil {
+					return err
+				}
+			}
+			return execTerm(cmd.Context(), cfg, tbl.Count(), os.Stdout)
+		},
+	}
+}
+
+// parseKeyArg returns an r.json() term for keys that are valid JSON so numbers
+// and compound (array) keys keep their type, and a plain string otherwise.
+func parseKeyArg(s string) interface{} {
+	if json.Valid([]byte(s)) {
+		return reql.JSON(s)
+	}
+	return s
+}
+
+// filterTerm applies a JSON object filter to seq; the object is sent through
+// r.json() so nested arrays are not mistaken for ReQL terms.
+func filterTerm(seq reql.Term, filterJSON string) (reql.Term, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(filterJSON), &obj); err != nil {
+		return reql.Term{}, fmt.Errorf("filter must be a JSON object: %w", err)
+	}
+	return seq.Filter(reql.JSON(filterJSON)), nil
+}
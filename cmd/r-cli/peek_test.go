@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"r-cli/internal/reql"
+)
+
+func TestGetCountCmdsRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	found := map[string]bool{}
+	for _, sub := range root.Commands() {
+		found[sub.Name()] = true
+	}
+	for _, name := range []string{"get", "count"} {
+		if !found[name] {
+			t.Errorf("%s subcommand not registered on root command", name)
+		}
+	}
+}
+
+func TestGetCountArgs(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{}
+	if cmd := newGetCmd(cfg); cmd.Args(cmd, []string{"users"}) == nil {
+		t.Error("get: expected error without key")
+	}
+	if cmd := newCountCmd(cfg); cmd.Args(cmd, []string{}) == nil {
+		t.Error("count: expected error without table")
+	}
+	if cmd := newCountCmd(cfg); cmd.Args(cmd, []string{"a", "{}", "x"}) == nil {
+		t.Error("count: expected error for three args")
+	}
+}
+
+func TestParseKeyArg(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"abc-123", `"abc-123"`},
+		{"42", `[98,["42"]]`},
+		{`["a",1]`, `[98,["[\"a\",1]"]]`},
+		{`"42"`, `[98,["\"42\""]]`},
+	}
+	for _, tc := range tests {
+		got, err := json.Marshal(reql.Datum(nil).Get(parseKeyArg(tc.in)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `[16,[null,` + tc.want + `]]`
+		if string(got) != want {
+			t.Errorf("%s: got %s, want %s", tc.in, got, want)
+		}
+	}
+}
+
+func TestFilterTerm(t *testing.T) {
+	t.Parallel()
+	tbl := reql.DB("app").Table("users")
+	term, err := filterTerm(tbl, `{"tags":["a"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(term)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[39,[[15,[[14,["app"]],"users"]],[98,["{\"tags\":[\"a\"]}"]]]]`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if _, err := filterTerm(tbl, `[1]`); err == nil {
+		t.Error("expected error for non-object filter")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newPingCmd(cfg *rootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "ping",
+		Short: "Check connectivity with a handshake and an r.expr(1) round trip",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runPing(cmd.Context(), cfg, os.Stdout)
+		},
+	}
+}
+
+// pingInfo is the JSON output of the ping command.
+type pingInfo struct {
+	Host          string  `json:"host"`
+	Port          int     `json:"port"`
+	ServerVersion string  `json:"server_version"`
+	ConnectMs     float64 `json:"connect_ms"`
+	RoundTripMs   float64 `json:"round_trip_ms"`
+	Status        string  `json:"status"`
+}
+
+func runPing(ctx context.Context, cfg *rootConfig, w io.Writer) error {
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	res, err := exec.Ping(ctx)
+	if err != nil {
+		return err
+	}
+
+	pi := pingInfo{
+		Host:          cfg.host,
+		Port:          cfg.port,
+		ServerVersion: res.ServerVersion,
+		ConnectMs:     durationMs(res.Connect),
+		RoundTripMs:   durationMs(res.RoundTrip),
+		Status:        "ok",
+	}
+	out, err := openOutputTarget(cfg.output, w)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return out.finish(enc.Encode(pi))
+}
+
+// durationMs converts d to fractional milliseconds with microsecond precision.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPingCmdRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	for _, sub := range root.Commands() {
+		if sub.Name() == "ping" {
+			return
+		}
+	}
+	t.Error("ping subcommand not registered on root command")
+}
+
+func TestPingCmdNoArgs(t *testing.T) {
+	t.Parallel()
+	cmd := newPingCmd(&rootConfig{})
+	if err := cmd.Args(cmd, []string{"extra"}); err == nil {
+		t.Error("ping: expected error for extra arg, got nil")
+	}
+	if err := cmd.Args(cmd, []string{}); err != nil {
+		t.Errorf("ping: expected no error for zero args, got %v", err)
+	}
+}
+
+func TestRunPingConnectionRefused(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+	_ = ln.Close()
+
+	var buf bytes.Buffer
+	cfg := &rootConfig{host: "127.0.0.1", port: port, timeout: time.Second}
+	if err := runPing(context.Background(), cfg, &buf); err == nil {
+		t.Fatal("expected connection error, got nil")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("no output expected on failure, got %q", buf.String())
+	}
+}
+
+func TestDurationMs(t *testing.T) {
+	t.Parallel()
+	if got := durationMs(1234567 * time.Nanosecond); got != 1.234 {
+		t.Errorf("got %v, want 1.234", got)
+	}
+}
@@ -1,7 +1,8 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -62,12 +63,17 @@ func readQueryExpr(args []string, stdin io.Reader) (string, error) {
 
 // runQueryExpr parses expr and executes it, writing results to cmd's output.
 func runQueryExpr(cmd *cobra.Command, cfg *rootConfig, expr string) error {
+	return execQueryExpr(cmd.Context(), cfg, expr, cmd.OutOrStdout())
+}
+
+// execQueryExpr parses expr and executes it, writing results to w.
+func execQueryExpr(ctx context.Context, cfg *rootConfig, expr string, w io.Writer) error {
 	term, err := parser.Parse(expr)
 	if err != nil {
 		parselog.Log(expr, err)
 		return &queryError{err: fmt.Errorf("query: %w", err)}
 	}
-	return execTerm(cmd.Context(), cfg, term, cmd.OutOrStdout())
+	return execTerm(ctx, cfg, term, w)
 }
 
 // runQueryFile reads queries from path, splits on "---", and executes each.
@@ -85,14 +91,45 @@ func runQueryFile(cmd *cobra.Command, cfg *rootConfig, path string, stopOnError
 		r = f
 	}
 
-	queries, err := splitQueries(r)
+	queries, err := parser.SplitQueries(r)
 	if err != nil {
 		return fmt.Errorf("query: reading file: %w", err)
 	}
 
-	var firstErr error
+	// all queries share one output target so --output collects every result
+	out, err := openResultTarget(cfg, cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	localCfg := *cfg
+	localCfg.output = ""
+	localCfg.format = out.format(cfg.format)
+	return finishQueries(out, execQueries(cmd, &localCfg, queries, stopOnError, out))
+}
+
+// finishQueries finishes out with the error of execQueries. An empty result
+// under --fail-on-empty is not a failure: the output of the other queries is
+// kept, and the empty result reported once it is in place.
+func finishQueries(out *outputTarget, err error) error {
+	if !errors.Is(err, errEmptyResult) {
+		return out.finish(err)
+	}
+	if ferr := out.finish(nil); ferr != nil {
+		return ferr
+	}
+	return err
+}
+
+// execQueries runs each query in turn, writing results to w. Empty results
+// under --fail-on-empty do not count as failures but are reported at the end.
+func execQueries(cmd *cobra.Command, cfg *rootConfig, queries []string, stopOnError bool, w io.Writer) error {
+	var firstErr, empty error
 	for _, q := range queries {
-		if err := runQueryExpr(cmd, cfg, q); err != nil {
+		if err := execQueryExpr(cmd.Context(), cfg, q, w); err != nil {
+			if errors.Is(err, errEmptyResult) {
+				empty = err
+				continue
+			}
 			if stopOnError {
 				return err
 			}
@@ -106,32 +143,5 @@ func runQueryFile(cmd *cobra.Command, cfg *rootConfig, path string, stopOnError
 		// individual errors already printed to stderr; return summary to signal non-zero exit
 		return &queryError{err: fmt.Errorf("query: one or more queries failed")}
 	}
-	return nil
-}
-
-// splitQueries reads r and splits on lines containing only "---".
-func splitQueries(r io.Reader) ([]string, error) {
-	var queries []string
-	var cur strings.Builder
-	sc := bufio.NewScanner(r)
-	sc.Buffer(make([]byte, bufio.MaxScanTokenSize), 64*1024*1024) // match max payload 64MB
-	for sc.Scan() {
-		line := sc.Text()
-		if strings.TrimSpace(line) == "---" {
-			if q := strings.TrimSpace(cur.String()); q != "" {
-				queries = append(queries, q)
-			}
-			cur.Reset()
-		} else {
-			cur.WriteString(line)
-			cur.WriteByte('\n')
-		}
-	}
-	if err := sc.Err(); err != nil {
-		return nil, err
-	}
-	if q := strings.TrimSpace(cur.String()); q != "" {
-		queries = append(queries, q)
-	}
-	return queries, nil
+	return empty
 }
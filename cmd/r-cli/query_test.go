@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -158,116 +159,6 @@ func TestRunQueryExprLogsParseError(t *testing.T) {
 	}
 }
 
-func TestSplitQueriesEmpty(t *testing.T) {
-	t.Parallel()
-	got, err := splitQueries(strings.NewReader(""))
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(got) != 0 {
-		t.Errorf("got %d queries, want 0", len(got))
-	}
-}
-
-func TestSplitQueriesSingle(t *testing.T) {
-	t.Parallel()
-	got, err := splitQueries(strings.NewReader(`r.table("users")`))
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(got) != 1 {
-		t.Fatalf("got %d queries, want 1", len(got))
-	}
-	if got[0] != `r.table("users")` {
-		t.Errorf("got %q, want %q", got[0], `r.table("users")`)
-	}
-}
-
-func TestSplitQueriesMultiple(t *testing.T) {
-	t.Parallel()
-	input := "r.dbList()\n---\nr.tableList()\n---\nr.now()"
-	got, err := splitQueries(strings.NewReader(input))
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(got) != 3 {
-		t.Fatalf("got %d queries, want 3: %v", len(got), got)
-	}
-	want := []string{"r.dbList()", "r.tableList()", "r.now()"}
-	for i, w := range want {
-		if got[i] != w {
-			t.Errorf("query[%d]: got %q, want %q", i, got[i], w)
-		}
-	}
-}
-
-func TestSplitQueriesTrailingSeparator(t *testing.T) {
-	t.Parallel()
-	input := "r.now()\n---\n"
-	got, err := splitQueries(strings.NewReader(input))
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(got) != 1 {
-		t.Fatalf("got %d queries, want 1: %v", len(got), got)
-	}
-	if got[0] != "r.now()" {
-		t.Errorf("got %q, want %q", got[0], "r.now()")
-	}
-}
-
-func TestSplitQueriesLeadingSeparator(t *testing.T) {
-	t.Parallel()
-	input := "---\nr.now()"
-	got, err := splitQueries(strings.NewReader(input))
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(got) != 1 {
-		t.Fatalf("got %d queries, want 1: %v", len(got), got)
-	}
-	if got[0] != "r.now()" {
-		t.Errorf("got %q, want %q", got[0], "r.now()")
-	}
-}
-
-func TestSplitQueriesWhitespaceSeparator(t *testing.T) {
-	t.Parallel()
-	input := "r.now()\n  ---  \nr.dbList()"
-	got, err := splitQueries(strings.NewReader(input))
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(got) != 2 {
-		t.Fatalf("got %d queries, want 2: %v", len(got), got)
-	}
-	if got[0] != "r.now()" {
-		t.Errorf("query[0]: got %q, want %q", got[0], "r.now()")
-	}
-	if got[1] != "r.dbList()" {
-		t.Errorf("query[1]: got %q, want %q", got[1], "r.dbList()")
-	}
-}
-
-func TestSplitQueriesMultilineQuery(t *testing.T) {
-	t.Parallel()
-	input := "r.db(\"test\")\n  .table(\"users\")\n---\nr.now()"
-	got, err := splitQueries(strings.NewReader(input))
-	if err != nil {
-		t.Fatal(err)
-	}
-	if len(got) != 2 {
-		t.Fatalf("got %d queries, want 2: %v", len(got), got)
-	}
-	want0 := "r.db(\"test\")\n  .table(\"users\")"
-	if got[0] != want0 {
-		t.Errorf("query[0]: got %q, want %q", got[0], want0)
-	}
-	if got[1] != "r.now()" {
-		t.Errorf("query[1]: got %q, want %q", got[1], "r.now()")
-	}
-}
-
 func TestRunQueryFileNotFound(t *testing.T) {
 	t.Parallel()
 	cmd := &cobra.Command{}
@@ -330,6 +221,44 @@ func TestRunQueryFileContinueOnError(t *testing.T) {
 	}
 }
 
+func TestFinishQueriesKeepsFileOnEmptyResult(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	out, err := openOutputTarget(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := out.Write([]byte("{\"id\":1}\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := finishQueries(out, errEmptyResult); !errors.Is(err, errEmptyResult) {
+		t.Errorf("err = %v, want %v", err, errEmptyResult)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("output file not kept: %v", err)
+	}
+	if string(data) != "{\"id\":1}\n" {
+		t.Errorf("file = %q", data)
+	}
+}
+
+func TestFinishQueriesRemovesFileOnFailure(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	out, err := openOutputTarget(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errQuery := errors.New("query failed")
+	if err := finishQueries(out, errQuery); !errors.Is(err, errQuery) {
+		t.Errorf("err = %v, want %v", err, errQuery)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("output file written on failure: %v", err)
+	}
+}
+
 func TestRunQueryFileNoQueries(t *testing.T) {
 	t.Parallel()
 	dir := t.TempDir()
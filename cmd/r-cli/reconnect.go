@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/query"
+)
+
+// reconnectPolicy re-dials a dropped connection a few times with a short
+// backoff, logging every attempt. Commands only hit it when the connection
+// dies between queries of one run (watch, import, wait).
+func reconnectPolicy(log *slog.Logger) conn.Reconnect {
+	return conn.Reconnect{
+		MaxAttempts: 5,
+		Backoff:     100 * time.Millisecond,
+		MaxBackoff:  2 * time.Second,
+		Notify: func(ev conn.ReconnectEvent) {
+			switch {
+			case ev.Err == nil:
+				log.Info("reconnected", "attempt", ev.Attempt)
+			case ev.Backoff > 0:
+				log.Warn("reconnect failed, retrying", "attempt", ev.Attempt, "error", ev.Err, "backoff", ev.Backoff)
+			default:
+				log.Warn("reconnect failed", "attempt", ev.Attempt, "error", ev.Err)
+			}
+		},
+	}
+}
+
+// replReconnectPolicy keeps retrying for about half a minute, long enough
+// for a server restart, and reports each attempt on w so the user sees why
+// the prompt is waiting. The default database survives the reconnect because
+// the REPL sends it with every query.
+func replReconnectPolicy(w io.Writer) conn.Reconnect {
+	return conn.Reconnect{
+		MaxAttempts: 10,
+		Backoff:     250 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		Notify: func(ev conn.ReconnectEvent) {
+			switch {
+			case ev.Err == nil && ev.Attempt == 1:
+				_, _ = fmt.Fprintln(w, "connection lost, reconnected")
+			case ev.Err == nil:
+				_, _ = fmt.Fprintf(w, "reconnected after %d attempts\n", ev.Attempt)
+			case ev.Backoff > 0:
+				_, _ = fmt.Fprintf(w, "connection lost, reconnecting in %s (attempt %d): %v\n", ev.Backoff, ev.Attempt, ev.Err)
+			default:
+				_, _ = fmt.Fprintf(w, "reconnect failed after %d attempts: %v\n", ev.Attempt, ev.Err)
+			}
+		},
+	}
+}
+
+// closeCursorsOnReconnect extends rc so a successful reconnect drops the
+// cursors of exec that were opened on the lost connection.
+func closeCursorsOnReconnect(rc conn.Reconnect, exec *query.Executor) conn.Reconnect {
+	notify := rc.Notify
+	rc.Notify = func(ev conn.ReconnectEvent) {
+		if ev.Err == nil {
+			exec.CloseCursors()
+		}
+		if notify != nil {
+			notify(ev)
+		}
+	}
+	return rc
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/query"
+)
+
+func TestReplReconnectPolicyMessages(t *testing.T) {
+	t.Parallel()
+	errRefused := errors.New("connection refused")
+	tests := []struct {
+		ev   conn.ReconnectEvent
+		want string
+	}{
+		{conn.ReconnectEvent{Attempt: 1}, "connection lost, reconnected\n"},
+		{conn.ReconnectEvent{Attempt: 3}, "reconnected after 3 attempts\n"},
+		{conn.ReconnectEvent{Attempt: 2, Err: errRefused, Backoff: 500 * time.Millisecond},
+			"connection lost, reconnecting in 500ms (attempt 2): connection refused\n"},
+		{conn.ReconnectEvent{Attempt: 10, Err: errRefused}, "reconnect failed after 10 attempts: connection refused\n"},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		replReconnectPolicy(&buf).Notify(tc.ev)
+		if buf.String() != tc.want {
+			t.Errorf("event %+v: got %q, want %q", tc.ev, buf.String(), tc.want)
+		}
+	}
+}
+
+func TestReconnectPolicyLogs(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	p := reconnectPolicy(newLogger(&buf, 1, false, false))
+	if p.MaxAttempts < 2 || p.Backoff <= 0 {
+		t.Errorf("policy does not retry: %+v", p)
+	}
+	p.Notify(conn.ReconnectEvent{Attempt: 1, Err: errors.New("refused"), Backoff: time.Second})
+	p.Notify(conn.ReconnectEvent{Attempt: 2})
+	out := buf.String()
+	for _, want := range []string{"warn: reconnect failed, retrying attempt=1 error=refused backoff=1s", "reconnected attempt=2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q missing %q", out, want)
+		}
+	}
+}
+
+func TestCloseCursorsOnReconnect(t *testing.T) {
+	t.Parallel()
+	var got []conn.ReconnectEvent
+	rc := closeCursorsOnReconnect(conn.Reconnect{
+		MaxAttempts: 3,
+		Notify:      func(ev conn.ReconnectEvent) { got = append(got, ev) },
+	}, query.New(nil))
+	if rc.MaxAttempts != 3 {
+		t.Errorf("MaxAttempts = %d, want 3", rc.MaxAttempts)
+	}
+	rc.Notify(conn.ReconnectEvent{Attempt: 1, Err: errors.New("refused"), Backoff: time.Second})
+	rc.Notify(conn.ReconnectEvent{Attempt: 2})
+	if len(got) != 2 || got[1].Attempt != 2 {
+		t.Errorf("events = %+v", got)
+	}
+	closeCursorsOnReconnect(conn.Reconnect{}, query.New(nil)).Notify(conn.ReconnectEvent{Attempt: 1})
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/signal"
@@ -10,52 +12,111 @@ import (
 	"path/filepath"
 	"sync"
 	"syscall"
-	"time"
 
 	"github.com/spf13/cobra"
 
+	"r-cli/internal/conn"
+	"r-cli/internal/connmgr"
 	"r-cli/internal/output"
-	"r-cli/internal/parselog"
 	"r-cli/internal/query"
 	"r-cli/internal/repl"
 	"r-cli/internal/reql"
-	"r-cli/internal/reql/parser"
 )
 
 // replStart is the function used to launch the REPL; replaced in tests.
 var replStart = runREPL
 
+// maxAuthAttempts is how many passwords the REPL tries before giving up.
+const maxAuthAttempts = 3
+
+// replPasswordPrompt asks for a new password after a rejected login; replaced in tests.
+var replPasswordPrompt = func(w io.Writer) (string, error) {
+	return promptPassword(w, os.Stdin)
+}
+
 // replShowHintHook is called with the resolved ShowHint value before executor creation; replaced in tests.
 var replShowHintHook func(bool)
 
 func newReplCmd(cfg *rootConfig) *cobra.Command {
-	return &cobra.Command{
+	var force, continueOnError bool
+	cmd := &cobra.Command{
 		Use:   "repl",
 		Short: "Start an interactive REPL",
-		Args:  cobra.NoArgs,
+		Long: "Start an interactive REPL.\n\n" +
+			"Queries that delete documents or drop a table, database or index ask\n" +
+			"\"Really run? [y/N]\" first; --force or .set confirm off skips the question.\n\n" +
+			"When stdin is not a terminal (r-cli repl < script.reql), the script runs\n" +
+			"without prompts and stops at the first failing query, exiting with its\n" +
+			"exit code; --continue-on-error runs the rest and exits with 2 at the end.",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			if force {
+				cfg.replConfirm = "off"
+			}
+			cfg.replContinueOnError = continueOnError
 			return replStart(cmd.Context(), cfg, cmd.OutOrStdout(), cmd.ErrOrStderr())
 		},
 	}
+	cmd.Flags().BoolVar(&force, "force", false, "run deleting and dropping queries without asking")
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "when stdin is not a terminal, keep running the script after a failing query")
+	return cmd
 }
 
-// runREPL creates a readline reader, connects to RethinkDB, and runs the REPL loop.
+// runREPL creates a readline reader, connects to RethinkDB, and runs the REPL
+// loop; when stdin is not a terminal it runs stdin as a script instead, see
+// runReplBatch.
 func runREPL(ctx context.Context, cfg *rootConfig, out, errOut io.Writer) error {
 	if replShowHintHook != nil {
 		replShowHintHook(!cfg.quiet)
 	}
-	exec, cleanup, err := newExecutor(cfg)
+	mgr, cleanup, err := connectREPL(ctx, cfg, errOut, newConnManager)
 	if err != nil {
 		return err
 	}
-	defer cleanup()
+	exec := query.New(mgr)
+	exec.SetDefaults(buildQueryOpts(cfg))
+	exec.SetCursorOptions(cfg.outputCursorOptions())
 
 	localCfg := *cfg
-	completer := &repl.Completer{
-		FetchDBs:    makeFetchDBs(exec),
-		FetchTables: makeFetchTables(exec, &localCfg),
+	completer := newReplCompleter(exec, &localCfg)
+	server := &replServer{exec: exec, cfg: &localCfg, errOut: errOut, completer: completer, cleanup: cleanup, newManager: newConnManager}
+	mgr.SetReconnect(server.reconnectPolicy())
+	// .exit and EOF stop the queries of interrupted iterations
+	defer server.close()
+
+	vars := newReplVars()
+	aliases, saveAlias := replAliases(errOut)
+	rcfg := &repl.Config{
+		Exec:       makeReplExec(exec, &localCfg, vars),
+		Let:        makeReplLet(exec, &localCfg, vars),
+		Watch:      makeReplWatch(exec, &localCfg, vars),
+		Confirm:    makeReplConfirm(&localCfg, vars),
+		Aliases:    aliases,
+		SaveAlias:  saveAlias,
+		Out:        out,
+		ErrOut:     errOut,
+		PromptFunc: server.prompt,
+		ShowHint:   !cfg.quiet,
+		Color:      output.UseColor(cfg.color, errOut),
+		OnUseDB: func(db string) {
+			localCfg.database = db
+			exec.SetDefaults(buildQueryOpts(&localCfg))
+			completer.SetCurrentDB(db)
+			completer.Refresh()
+		},
+		OnFormat:    func(format string) { localCfg.format = format },
+		OnPager:     func(on bool) { localCfg.pagerOff = !on },
+		OnRefresh:   completer.Refresh,
+		OnReset:     vars.reset,
+		List:        makeReplLister(exec, &localCfg),
+		TableInfo:   makeTableInfo(exec, &localCfg),
+		TableSchema: makeTableSchema(exec, &localCfg),
+		Status:      server.status,
+		Connect:     server.switchTo,
+	}
+	if !stdinIsTTY() {
+		return runReplBatch(ctx, rcfg, exec, &localCfg, os.Stdin)
 	}
-	completer.SetCurrentDB(cfg.database)
 
 	historyFile := replHistoryFile()
 	interruptCh := make(chan struct{}, 1)
@@ -65,10 +126,14 @@ func runREPL(ctx context.Context, cfg *rootConfig, out, errOut io.Writer) error
 		default:
 		}
 	}
-	reader, err := repl.NewReadlineReader("r> ", historyFile, out, errOut, notifyInterrupt, completer)
+	reader, err := repl.NewReadlineReader(server.prompt(), historyFile, out, errOut, notifyInterrupt, output.UseColor(cfg.color, out), completer)
 	if err != nil {
 		return err
 	}
+	keys, _ := reader.(repl.KeymapReader)
+	if keys != nil && localCfg.replKeymap == repl.KeymapVi {
+		_ = keys.SetKeymap(repl.KeymapVi)
+	}
 
 	var once sync.Once
 	closeReader := func() { once.Do(func() { _ = reader.Close() }) }
@@ -91,22 +156,60 @@ func runREPL(ctx context.Context, cfg *rootConfig, out, errOut io.Writer) error
 
 	sigTermFired := startSignalRouter(replCtx, sigIntCh, sigTermCh, notifyInterrupt, closeReader)
 
-	r := repl.New(&repl.Config{
-		Reader:      reader,
-		Exec:        makeReplExec(exec, &localCfg),
-		Out:         out,
-		ErrOut:      errOut,
-		InterruptCh: interruptCh,
-		ShowHint:    !cfg.quiet,
-		OnUseDB: func(db string) {
-			localCfg.database = db
-			completer.SetCurrentDB(db)
-		},
-		OnFormat: func(format string) {
-			localCfg.format = format
-		},
-	})
-	return runReplAndCheckExit(ctx, replCtx, r, sigTermFired)
+	rcfg.Reader = reader
+	rcfg.InterruptCh = interruptCh
+	rcfg.Options = makeReplOptions(exec, &localCfg, keys)
+	return runReplAndCheckExit(ctx, replCtx, repl.New(rcfg), sigTermFired)
+}
+
+// runReplBatch runs the REPL over script, the stdin that is not a
+// terminal: no prompts or hint, all rows of table scans, no pager, and the
+// first failing query ends the run with its exit code unless
+// --continue-on-error is set. Interrupts cancel the whole run.
+func runReplBatch(ctx context.Context, rcfg *repl.Config, exec *query.Executor, cfg *rootConfig, script io.Reader) error {
+	cfg.replAutoLimit = 0
+	cfg.pagerOff = true
+	rcfg.Reader = repl.NewBatchReader(script)
+	rcfg.Options = makeReplOptions(exec, cfg, nil)
+	rcfg.Batch = true
+	rcfg.ContinueOnError = cfg.replContinueOnError
+	rcfg.ShowHint = false
+	err := repl.New(rcfg).Run(ctx)
+	if errors.Is(err, repl.ErrBatchFailed) {
+		return &queryError{err: err}
+	}
+	return err
+}
+
+// connectREPL opens the first connection before the prompt appears. A
+// rejected login on a terminal asks for the password again, up to
+// maxAuthAttempts in total; a network failure is only reported, since the
+// first query dials again.
+func connectREPL(ctx context.Context, cfg *rootConfig, errOut io.Writer, connect func(*rootConfig, int) (*connmgr.ConnManager, func(), error)) (*connmgr.ConnManager, func(), error) {
+	for attempt := 1; ; attempt++ {
+		mgr, cleanup, err := connect(cfg, cfg.poolSize)
+		if err != nil {
+			return nil, func() {}, err
+		}
+		_, err = mgr.Get(ctx)
+		if err == nil {
+			return mgr, cleanup, nil
+		}
+		if !errors.Is(err, conn.ErrReqlAuth) {
+			_, _ = fmt.Fprintf(errOut, "cannot reach %s:%d: %v; queries will retry the connection\n", cfg.host, cfg.port, err)
+			return mgr, cleanup, nil
+		}
+		cleanup()
+		if attempt >= maxAuthAttempts || !stdinIsTTY() {
+			return nil, func() {}, fmt.Errorf("login as %q rejected: %w", cfg.user, err)
+		}
+		_, _ = fmt.Fprintf(errOut, "login as %q rejected (wrong user or password), attempt %d of %d\n", cfg.user, attempt, maxAuthAttempts)
+		pwd, err := replPasswordPrompt(errOut)
+		if err != nil {
+			return nil, func() {}, err
+		}
+		cfg.password = pwd
+	}
 }
 
 // startSignalRouter routes OS signals during REPL: SIGINT -> notifyInterrupt, SIGTERM -> closeReader.
@@ -146,31 +249,37 @@ func runReplAndCheckExit(ctx, replCtx context.Context, r *repl.Repl, sigTermFire
 	return errAborted
 }
 
-// makeReplExec returns an ExecFunc that parses and executes a ReQL expression.
-func makeReplExec(exec *query.Executor, cfg *rootConfig) repl.ExecFunc {
+// makeReplExec returns an ExecFunc that parses and executes a ReQL
+// expression; its result becomes _.
+func makeReplExec(exec *query.Executor, cfg *rootConfig, vars *replVars) repl.ExecFunc {
 	return func(ctx context.Context, expr string, w io.Writer) error {
-		term, err := parser.Parse(expr)
-		if err != nil {
-			parselog.Log(expr, err)
-			return err
-		}
-		start := time.Now()
-		profile, cur, err := exec.Run(ctx, term, buildQueryOpts(cfg))
-		if err != nil {
-			return err
-		}
-		writeQueryMeta(cfg, profile, time.Since(start))
-		if cur == nil {
-			return nil
-		}
-		defer func() { _ = cur.Close() }()
-		return writeOutput(w, output.DetectFormat(os.Stdout, cfg.format), makeIter(cur, cfg))
+		return runReplQuery(ctx, exec, cfg, vars, expr, "", w)
 	}
 }
 
+// replOutputFile is the file the default format is detected for: the .output
+// file results are redirected to, otherwise stdout.
+func replOutputFile(w io.Writer) *os.File {
+	if f, ok := w.(*os.File); ok {
+		return f
+	}
+	return os.Stdout
+}
+
+// newReplCompleter completes names of cfg's server, starting in cfg.database.
+func newReplCompleter(exec *query.Executor, cfg *rootConfig) *repl.Completer {
+	c := &repl.Completer{
+		FetchDBs:     makeFetchDBs(exec),
+		FetchTables:  makeFetchTables(exec, cfg),
+		FetchIndexes: makeFetchIndexes(exec, cfg),
+	}
+	c.SetCurrentDB(cfg.database)
+	return c
+}
+
 func makeFetchDBs(exec *query.Executor) func(context.Context) ([]string, error) {
 	return func(ctx context.Context) ([]string, error) {
-		_, cur, err := exec.Run(ctx, reql.DBList(), reql.OptArgs{})
+		_, cur, err := exec.Run(ctx, reql.DBList(), nil)
 		if err != nil {
 			return nil, err
 		}
@@ -191,7 +300,7 @@ func makeFetchTables(exec *query.Executor, cfg *rootConfig) func(context.Context
 		if db == "" {
 			return nil, nil
 		}
-		_, cur, err := exec.Run(ctx, reql.DB(db).TableList(), reql.OptArgs{})
+		_, cur, err := exec.Run(ctx, reql.DB(db).TableList(), nil)
 		if err != nil {
 			return nil, err
 		}
@@ -204,6 +313,44 @@ func makeFetchTables(exec *query.Executor, cfg *rootConfig) func(context.Context
 	}
 }
 
+func makeFetchIndexes(exec *query.Executor, cfg *rootConfig) func(context.Context, string, string) ([]string, error) {
+	return func(ctx context.Context, db, table string) ([]string, error) {
+		if db == "" {
+			db = cfg.database
+		}
+		if db == "" {
+			return nil, nil
+		}
+		return fetchNames(ctx, exec, cfg, reql.DB(db).Table(table).IndexList())
+	}
+}
+
+// errNoDB is returned by .tables and .indexes when neither --db nor .use
+// selected a database.
+var errNoDB = errors.New("no database selected; use .use <db> or pass --db")
+
+// makeReplLister backs .dbs, .tables and .indexes with the completion
+// fetchers, which are not cached; a missing database is an error here.
+func makeReplLister(exec *query.Executor, cfg *rootConfig) repl.Lister {
+	tables := makeFetchTables(exec, cfg)
+	indexes := makeFetchIndexes(exec, cfg)
+	return repl.Lister{
+		DBs: makeFetchDBs(exec),
+		Tables: func(ctx context.Context, db string) ([]string, error) {
+			if db == "" && cfg.database == "" {
+				return nil, errNoDB
+			}
+			return tables(ctx, db)
+		},
+		Indexes: func(ctx context.Context, db, table string) ([]string, error) {
+			if db == "" && cfg.database == "" {
+				return nil, errNoDB
+			}
+			return indexes(ctx, db, table)
+		},
+	}
+}
+
 // jsonRowsToStrings unmarshals each JSON row as a string, skipping failures.
 func jsonRowsToStrings(rows []json.RawMessage) []string {
 	var names []string
@@ -3,8 +3,11 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,7 +15,11 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"r-cli/internal/conn"
+	"r-cli/internal/output"
 	"r-cli/internal/parselog"
+	"r-cli/internal/query"
+	"r-cli/internal/repl"
 )
 
 func TestReplCmdRegistered(t *testing.T) {
@@ -152,7 +159,7 @@ func TestMakeReplExecParseError(t *testing.T) {
 	t.Parallel()
 	// makeReplExec should propagate parser errors without attempting connection
 	cfg := &rootConfig{}
-	execFn := makeReplExec(nil, cfg)
+	execFn := makeReplExec(nil, cfg, newReplVars())
 	err := execFn(context.Background(), "!!!invalid!!!", io.Discard)
 	if err == nil {
 		t.Error("expected parse error for invalid expression, got nil")
@@ -164,7 +171,7 @@ func TestMakeReplExecLogsParseError(t *testing.T) {
 	parselog.SetDir(dir)
 	t.Cleanup(func() { parselog.SetDir(testLogDir) })
 
-	execFn := makeReplExec(nil, &rootConfig{})
+	execFn := makeReplExec(nil, &rootConfig{}, newReplVars())
 	_ = execFn(context.Background(), "!!!invalid!!!", io.Discard)
 
 	data, err := os.ReadFile(filepath.Join(dir, "parser-errors.log"))
@@ -198,7 +205,7 @@ func TestShowHintWiringQuietFalse(t *testing.T) {
 	}
 	defer func() { replShowHintHook = oldHook }()
 
-	// runREPL will fail at newExecutor (no RethinkDB), but the hook fires before that.
+	// runREPL will fail at the first query (no RethinkDB), but the hook fires before that.
 	_ = runREPL(context.Background(), &rootConfig{quiet: false}, io.Discard, io.Discard)
 
 	if !hookCalled {
@@ -231,6 +238,27 @@ func TestShowHintWiringQuietTrue(t *testing.T) {
 	}
 }
 
+func TestMakeFetchIndexesNoDB(t *testing.T) {
+	t.Parallel()
+	// no --db and no .use: nothing to complete, and no query is sent
+	fetch := makeFetchIndexes(query.New(nil), &rootConfig{})
+	names, err := fetch(context.Background(), "", "users")
+	if err != nil || names != nil {
+		t.Errorf("fetch = %v, %v; want nil, nil", names, err)
+	}
+}
+
+func TestMakeReplListerNoDB(t *testing.T) {
+	t.Parallel()
+	list := makeReplLister(query.New(nil), &rootConfig{})
+	if _, err := list.Tables(context.Background(), ""); !errors.Is(err, errNoDB) {
+		t.Errorf("Tables = %v, want errNoDB", err)
+	}
+	if _, err := list.Indexes(context.Background(), "", "users"); !errors.Is(err, errNoDB) {
+		t.Errorf("Indexes = %v, want errNoDB", err)
+	}
+}
+
 func TestJsonRowsToStrings(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -261,3 +289,175 @@ func TestJsonRowsToStrings(t *testing.T) {
 		})
 	}
 }
+
+// startAuthKeyServer accepts V0_4 handshakes and answers SUCCESS only for
+// key, keeping accepted connections open until the test ends.
+func startAuthKeyServer(t *testing.T, key string) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() { _ = ln.Close(); <-done })
+	go func() {
+		defer close(done)
+		var conns []net.Conn
+		defer func() {
+			for _, nc := range conns {
+				_ = nc.Close()
+			}
+		}()
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, nc)
+			var hdr [8]byte
+			if _, err := io.ReadFull(nc, hdr[:]); err != nil {
+				continue
+			}
+			buf := make([]byte, binary.LittleEndian.Uint32(hdr[4:])+4)
+			if _, err := io.ReadFull(nc, buf); err != nil {
+				continue
+			}
+			reply := "SUCCESS\x00"
+			if string(buf[:len(buf)-4]) != key {
+				reply = "ERROR: Incorrect authorization key.\n\x00"
+			}
+			_, _ = nc.Write([]byte(reply))
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+}
+
+func stubReplPrompt(t *testing.T, tty bool, passwords ...string) *int {
+	t.Helper()
+	oldTTY, oldPrompt := stdinIsTTY, replPasswordPrompt
+	t.Cleanup(func() { stdinIsTTY, replPasswordPrompt = oldTTY, oldPrompt })
+	stdinIsTTY = func() bool { return tty }
+	asked := new(int)
+	replPasswordPrompt = func(io.Writer) (string, error) {
+		*asked++
+		if *asked > len(passwords) {
+			return "", errors.New("no more passwords")
+		}
+		return passwords[*asked-1], nil
+	}
+	return asked
+}
+
+func TestConnectREPLReprompts(t *testing.T) {
+	port := startAuthKeyServer(t, "right")
+	asked := stubReplPrompt(t, true, "wrong", "right")
+	cfg := &rootConfig{host: "127.0.0.1", port: port, user: "admin", password: "bad", protocol: "v0_4", poolSize: 1}
+	var errOut bytes.Buffer
+	mgr, cleanup, err := connectREPL(context.Background(), cfg, &errOut, newConnManager)
+	if err != nil {
+		t.Fatalf("connectREPL: %v", err)
+	}
+	defer cleanup()
+	if len(mgr.Conns()) != 1 || *asked != 2 || cfg.password != "right" {
+		t.Errorf("conns=%d asked=%d password=%q", len(mgr.Conns()), *asked, cfg.password)
+	}
+	if got := strings.Count(errOut.String(), "wrong user or password"); got != 2 {
+		t.Errorf("stderr has %d rejection notes, want 2:\n%s", got, errOut.String())
+	}
+	if !strings.Contains(errOut.String(), "attempt 2 of 3") {
+		t.Errorf("stderr = %q", errOut.String())
+	}
+}
+
+func TestConnectREPLGivesUp(t *testing.T) {
+	port := startAuthKeyServer(t, "right")
+	asked := stubReplPrompt(t, true, "x", "y", "z")
+	cfg := &rootConfig{host: "127.0.0.1", port: port, user: "admin", protocol: "v0_4", poolSize: 1}
+	_, _, err := connectREPL(context.Background(), cfg, io.Discard, newConnManager)
+	if !errors.Is(err, conn.ErrReqlAuth) || exitCode(err) != exitAuth {
+		t.Fatalf("err = %v, want auth error", err)
+	}
+	if *asked != maxAuthAttempts-1 {
+		t.Errorf("prompted %d times, want %d", *asked, maxAuthAttempts-1)
+	}
+}
+
+func TestConnectREPLNoPromptWithoutTTY(t *testing.T) {
+	port := startAuthKeyServer(t, "right")
+	asked := stubReplPrompt(t, false)
+	cfg := &rootConfig{host: "127.0.0.1", port: port, user: "admin", protocol: "v0_4", poolSize: 1}
+	if _, _, err := connectREPL(context.Background(), cfg, io.Discard, newConnManager); !errors.Is(err, conn.ErrReqlAuth) {
+		t.Fatalf("err = %v, want auth error", err)
+	}
+	if *asked != 0 {
+		t.Errorf("prompted %d times without a terminal", *asked)
+	}
+}
+
+func TestConnectREPLNetworkError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+	_ = ln.Close()
+	asked := stubReplPrompt(t, true)
+	cfg := &rootConfig{host: "127.0.0.1", port: port, user: "admin", poolSize: 1}
+	var errOut bytes.Buffer
+	_, cleanup, err := connectREPL(context.Background(), cfg, &errOut, newConnManager)
+	if err != nil {
+		t.Fatalf("connectREPL: %v", err)
+	}
+	cleanup()
+	if *asked != 0 || !strings.Contains(errOut.String(), "cannot reach 127.0.0.1:") || strings.Contains(errOut.String(), "password") {
+		t.Errorf("asked=%d stderr=%q", *asked, errOut.String())
+	}
+}
+
+func TestReplOutputFile(t *testing.T) {
+	t.Parallel()
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	if got := replOutputFile(f); got != f {
+		t.Errorf("replOutputFile(file) = %v, want the file", got)
+	}
+	if got := replOutputFile(&bytes.Buffer{}); got != os.Stdout {
+		t.Errorf("replOutputFile(buffer) = %v, want stdout", got)
+	}
+	if got := output.DetectFormat(replOutputFile(f), ""); got != "jsonl" {
+		t.Errorf("format for a file = %q, want jsonl", got)
+	}
+}
+
+func TestRunReplBatch(t *testing.T) {
+	t.Parallel()
+	errQuery := &queryError{err: errors.New("bad query")}
+	for _, keepGoing := range []bool{false, true} {
+		var ran []string
+		rcfg := &repl.Config{
+			Exec: func(_ context.Context, expr string, _ io.Writer) error {
+				ran = append(ran, expr)
+				if expr == "bad" {
+					return errQuery
+				}
+				return nil
+			},
+			ErrOut:   io.Discard,
+			ShowHint: true,
+		}
+		cfg := &rootConfig{replAutoLimit: 40, replContinueOnError: keepGoing}
+		err := runReplBatch(context.Background(), rcfg, query.New(nil), cfg, strings.NewReader("r.now()\nbad\nr.dbList()\n"))
+		if exitCode(err) != exitQuery {
+			t.Errorf("keepGoing=%v: err = %v, want a query error", keepGoing, err)
+		}
+		if want := map[bool]int{false: 2, true: 3}[keepGoing]; len(ran) != want {
+			t.Errorf("keepGoing=%v: ran %q, want %d queries", keepGoing, ran, want)
+		}
+		if cfg.replAutoLimit != 0 || !cfg.pagerOff {
+			t.Errorf("keepGoing=%v: auto-limit %d, pager off %v; want both off", keepGoing, cfg.replAutoLimit, cfg.pagerOff)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// replAliases loads the .alias macros from the config file and returns
+// them with the function that saves a changed one back. A config file that
+// cannot be read is reported and leaves the session without aliases.
+func replAliases(errOut io.Writer) (map[string]string, func(name, text string) error) {
+	path, err := configPath()
+	if err == nil {
+		var cf *configFile
+		if cf, err = loadConfigFile(path); err == nil {
+			return cf.Aliases, func(name, text string) error { return saveAlias(path, name, text) }
+		}
+	}
+	_, _ = fmt.Fprintf(errOut, "warning: aliases not loaded: %v\n", err)
+	return nil, func(string, string) error { return err }
+}
+
+// saveAlias sets alias name to text in the config file at path, keeping
+// the rest of the file; an empty text removes the alias.
+func saveAlias(path, name, text string) error {
+	cf, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		delete(cf.Aliases, name)
+	} else {
+		if cf.Aliases == nil {
+			cf.Aliases = map[string]string{}
+		}
+		cf.Aliases[name] = text
+	}
+	return saveConfigFile(path, cf)
+}
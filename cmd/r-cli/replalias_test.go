@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReplAliases(t *testing.T) {
+	path := writeTestConfig(t, testConfigYAML+"aliases:\n  users: r.db(\"app\").table(\"users\")\n")
+	var errOut bytes.Buffer
+	aliases, save := replAliases(&errOut)
+	if errOut.Len() != 0 {
+		t.Fatalf("errOut = %q", errOut.String())
+	}
+	if aliases["users"] != `r.db("app").table("users")` {
+		t.Fatalf("aliases = %v", aliases)
+	}
+	if err := save("n", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := save("users", ""); err != nil {
+		t.Fatal(err)
+	}
+	cf, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cf.Aliases) != 1 || cf.Aliases["n"] != "10" {
+		t.Errorf("saved aliases = %v", cf.Aliases)
+	}
+	if cf.Default != "dev" || cf.Profiles["prod"].Host != "prodhost" {
+		t.Errorf("profiles not kept: %+v", cf)
+	}
+}
+
+func TestReplAliasesBadConfig(t *testing.T) {
+	writeTestConfig(t, "aliases: [\n")
+	var errOut bytes.Buffer
+	aliases, save := replAliases(&errOut)
+	if aliases != nil {
+		t.Errorf("aliases = %v, want none", aliases)
+	}
+	if !strings.Contains(errOut.String(), "warning: aliases not loaded") {
+		t.Errorf("errOut = %q", errOut.String())
+	}
+	if err := save("n", "10"); err == nil {
+		t.Error("save: expected the load error")
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import "r-cli/internal/reql"
+
+// autoLimitNotice follows a REPL result that --auto-limit may have cut short.
+const autoLimitNotice = "showing first %d rows; use .set limit 0 to disable\n"
+
+// autoLimit appends .limit(--auto-limit) to a REPL query that scans a table,
+// or filters one, with nothing bounding the result, so a stray
+// r.table("events") neither floods the terminal nor reads the whole table.
+// It returns the limit it added, or 0 when term is left as it is: the query
+// is not a scan, auto-limit is off, or .set limit already caps the rows.
+func autoLimit(term reql.Term, cfg *rootConfig) (reql.Term, int) {
+	if cfg.replAutoLimit <= 0 || cfg.limit > 0 || !reql.IsTableScan(term) {
+		return term, 0
+	}
+	return term.Limit(cfg.replAutoLimit), cfg.replAutoLimit
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"r-cli/internal/reql/parser"
+)
+
+func TestAutoLimit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		expr      string
+		autoLimit int
+		limit     int
+		want      string
+		added     int
+	}{
+		{`r.table("users")`, 40, 0, `[71,[[15,["users"]],40]]`, 40},
+		{`r.db("app").table("users").filter({active: true})`, 5, 0, `[71,[[39,[[15,[[14,["app"]],"users"]],{"active":true}]],5]]`, 5},
+		{`r.table("users").limit(100)`, 40, 0, `[71,[[15,["users"]],100]]`, 0},
+		{`r.table("users").count()`, 40, 0, `[43,[[15,["users"]]]]`, 0},
+		{`r.table("users")`, 0, 0, `[15,["users"]]`, 0},
+		{`r.table("users")`, 40, 10, `[15,["users"]]`, 0},
+	}
+	for _, tt := range tests {
+		term, err := parser.Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("parse %s: %v", tt.expr, err)
+		}
+		got, added := autoLimit(term, &rootConfig{replAutoLimit: tt.autoLimit, limit: tt.limit})
+		if data := mustMarshal(t, got); data != tt.want || added != tt.added {
+			t.Errorf("autoLimit(%s) = %s, %d; want %s, %d", tt.expr, data, added, tt.want, tt.added)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"r-cli/internal/proto"
+	"r-cli/internal/reql"
+	"r-cli/internal/reql/parser"
+)
+
+// destructiveTerms are the terms a REPL query is confirmed for, with the
+// name the question gives them.
+var destructiveTerms = map[proto.TermType]string{
+	proto.TermDelete:    "delete",
+	proto.TermTableDrop: "tableDrop",
+	proto.TermDBDrop:    "dbDrop",
+	proto.TermIndexDrop: "indexDrop",
+}
+
+// makeReplConfirm asks before REPL queries that contain a destructive term
+// while confirm is on. It returns nil, asking nothing, when stdin is not a
+// terminal. A query that does not parse is not asked about; running it
+// reports the error.
+func makeReplConfirm(cfg *rootConfig, vars *replVars) func(string) string {
+	if !stdinIsTTY() {
+		return nil
+	}
+	types := make([]proto.TermType, 0, len(destructiveTerms))
+	for tt := range destructiveTerms {
+		types = append(types, tt)
+	}
+	return func(expr string) string {
+		if cfg.replConfirm == "off" {
+			return ""
+		}
+		term, err := parser.ParseWith(expr, vars.terms)
+		if err != nil {
+			return ""
+		}
+		tt, ok := reql.Find(term, types...)
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("The query calls %s on %s. Really run?", destructiveTerms[tt], serverAddr(cfg))
+	}
+}
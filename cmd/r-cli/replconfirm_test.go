@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestReplConfirm(t *testing.T) {
+	orig := stdinIsTTY
+	t.Cleanup(func() { stdinIsTTY = orig })
+	stdinIsTTY = func() bool { return true }
+
+	cfg := &rootConfig{host: "db1", port: 28015}
+	vars := newReplVars()
+	vars.bind(json.RawMessage(`{"id":1}`), lastResultVar)
+	confirm := makeReplConfirm(cfg, vars)
+	tests := map[string]string{
+		`r.table("users").delete()`:                 "The query calls delete on db1:28015. Really run?",
+		`r.table("users").get(_("id")).delete()`:    "The query calls delete on db1:28015. Really run?",
+		`r.db("app").tableDrop("users")`:            "The query calls tableDrop on db1:28015. Really run?",
+		`r.expr([1]).forEach(x => r.dbDrop("app"))`: "The query calls dbDrop on db1:28015. Really run?",
+		`r.table("users").indexDrop("email")`:       "The query calls indexDrop on db1:28015. Really run?",
+		`r.table("users").filter({name: "delete"})`: "",
+		`r.table("users").insert({id: 2})`:          "",
+		`r.table("users").delete(`:                  "",
+		`r.expr(54).add(1)`:                         "",
+		`r.expr([54, 56, 60])`:                      "",
+	}
+	for expr, want := range tests {
+		if got := confirm(expr); got != want {
+			t.Errorf("confirm(%s) = %q, want %q", expr, got, want)
+		}
+	}
+	cfg.replConfirm = "off"
+	if got := confirm(`r.dbDrop("app")`); got != "" {
+		t.Errorf("confirm with .set confirm off = %q", got)
+	}
+
+	stdinIsTTY = func() bool { return false }
+	if makeReplConfirm(cfg, vars) != nil {
+		t.Error("confirmation set up without a terminal")
+	}
+}
+
+func TestReplCmdForce(t *testing.T) {
+	oldStart := replStart
+	t.Cleanup(func() { replStart = oldStart })
+	var confirm string
+	replStart = func(_ context.Context, cfg *rootConfig, _, _ io.Writer) error {
+		confirm = cfg.replConfirm
+		return nil
+	}
+	root := buildRootCmd(&rootConfig{})
+	root.SetArgs([]string{"repl", "--force"})
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if confirm != "off" {
+		t.Errorf("replConfirm = %q with --force, want off", confirm)
+	}
+}
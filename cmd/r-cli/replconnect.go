@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/connmgr"
+	"r-cli/internal/query"
+	"r-cli/internal/repl"
+)
+
+// replServer owns the connection manager behind the REPL's executor, which
+// .connect replaces while the completer and dot-commands keep using exec.
+type replServer struct {
+	exec      *query.Executor
+	cfg       *rootConfig // the REPL's local copy of the flags
+	errOut    io.Writer
+	completer *repl.Completer
+	cleanup   func() // closes the current manager
+	switched  bool   // .connect succeeded at least once
+	// newManager is newConnManager; replaced in tests.
+	newManager func(*rootConfig, int) (*connmgr.ConnManager, func(), error)
+
+	mu   sync.Mutex
+	lost error // why the last reconnect gave up; nil once one succeeds
+}
+
+// switchTo logs in to t with the other connection settings (TLS, SSH, pool
+// size, the current database) unchanged. Only a successful login replaces
+// the manager; the open cursors of the old server are closed first.
+func (s *replServer) switchTo(ctx context.Context, t repl.Target) (string, error) {
+	next := *s.cfg
+	next.host, next.port, next.password = t.Host, t.Port, t.Password
+	if next.port == 0 {
+		next.port = 28015
+	}
+	if t.User != "" {
+		next.user = t.User
+	}
+	mgr, cleanup, err := s.newManager(&next, next.poolSize)
+	if err != nil {
+		return "", err
+	}
+	if _, err := mgr.Get(ctx); err != nil {
+		cleanup()
+		return "", fmt.Errorf("connect to %s as %q: %w", serverAddr(&next), next.user, err)
+	}
+	mgr.SetReconnect(s.reconnectPolicy())
+	s.exec.CloseCursors()
+	s.exec.SetManager(mgr)
+	s.cleanup()
+	s.cleanup = cleanup
+	*s.cfg = next
+	s.switched = true
+	s.setLost(nil)
+	if s.completer != nil {
+		s.completer.Refresh()
+	}
+	return s.prompt(), nil
+}
+
+// reconnectPolicy is replReconnectPolicy for the current executor, noting
+// the outcome of each reconnect for .status.
+func (s *replServer) reconnectPolicy() conn.Reconnect {
+	rc := closeCursorsOnReconnect(replReconnectPolicy(s.errOut), s.exec)
+	notify := rc.Notify
+	rc.Notify = func(ev conn.ReconnectEvent) {
+		switch {
+		case ev.Err == nil:
+			s.setLost(nil)
+		case ev.Backoff == 0:
+			s.setLost(ev.Err)
+		}
+		notify(ev)
+	}
+	return rc
+}
+
+func (s *replServer) setLost(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lost = err
+}
+
+// status backs .status, e.g. "connected to localhost:28015 (server 2.4.4,
+// 1 connection); open changefeeds 1, cursors 2". Without an open
+// connection it says why: a failed reconnect, or none made yet.
+func (s *replServer) status() string {
+	s.mu.Lock()
+	lost := s.lost
+	s.mu.Unlock()
+	h := s.exec.Health()
+	addr := serverAddr(s.cfg)
+	var state string
+	switch {
+	case h.Conns > 0:
+		conns := "1 connection"
+		if h.Conns > 1 {
+			conns = fmt.Sprintf("%d connections", h.Conns)
+		}
+		if h.ServerVersion != "" { // the V0_4 handshake reports none
+			conns = "server " + h.ServerVersion + ", " + conns
+		}
+		state = fmt.Sprintf("connected to %s (%s)", addr, conns)
+	case lost != nil:
+		state = fmt.Sprintf("disconnected from %s, reconnect failed: %v", addr, lost)
+	default:
+		state = fmt.Sprintf("not connected to %s (connects on the next query)", addr)
+	}
+	return fmt.Sprintf("%s; open changefeeds %d, cursors %d", state, h.OpenFeeds, h.OpenCursors)
+}
+
+// close stops the open queries and closes the current manager.
+func (s *replServer) close() {
+	s.exec.CloseCursors()
+	s.cleanup()
+}
+
+// prompt renders the --prompt template for the current server and
+// database. Without one the prompt is "r> ", which names the server once
+// .connect has switched away from the first one.
+func (s *replServer) prompt() string {
+	switch {
+	case s.cfg.replPrompt != "":
+		return renderPrompt(s.cfg.replPrompt, s.cfg)
+	case s.switched:
+		return "r@" + serverAddr(s.cfg) + "> "
+	default:
+		return "r> "
+	}
+}
+
+// renderPrompt replaces the {user}, {host}, {port} and {db} placeholders
+// of tmpl; an unset database renders as "-". A space is added after a
+// prompt that does not end in one, as .set drops trailing blanks.
+func renderPrompt(tmpl string, cfg *rootConfig) string {
+	db := cfg.database
+	if db == "" {
+		db = "-"
+	}
+	p := strings.NewReplacer(
+		"{user}", cfg.user,
+		"{host}", cfg.host,
+		"{port}", strconv.Itoa(cfg.port),
+		"{db}", db,
+	).Replace(tmpl)
+	if !strings.HasSuffix(p, " ") {
+		p += " "
+	}
+	return p
+}
+
+func serverAddr(cfg *rootConfig) string {
+	return net.JoinHostPort(cfg.host, strconv.Itoa(cfg.port))
+}
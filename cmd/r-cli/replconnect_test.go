@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/connmgr"
+	"r-cli/internal/query"
+	"r-cli/internal/repl"
+)
+
+// newTestReplServer returns a replServer on a manager that is never dialed;
+// closed counts the cleanups of managers it replaced or closed.
+func newTestReplServer(t *testing.T, cfg *rootConfig) (s *replServer, closed *int) {
+	t.Helper()
+	closed = new(int)
+	mgr, cleanup, err := newConnManager(cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = &replServer{
+		exec:    query.New(mgr),
+		cfg:     cfg,
+		errOut:  io.Discard,
+		cleanup: func() { *closed++; cleanup() },
+		newManager: func(cfg *rootConfig, size int) (*connmgr.ConnManager, func(), error) {
+			mgr, cleanup, err := newConnManager(cfg, size)
+			return mgr, func() { *closed++; cleanup() }, err
+		},
+	}
+	t.Cleanup(s.close)
+	return s, closed
+}
+
+func TestReplServerSwitchTo(t *testing.T) {
+	port := startAuthKeyServer(t, "right")
+	cfg := &rootConfig{host: "old", port: 1, user: "admin", database: "app", protocol: "v0_4", poolSize: 1}
+	s, closed := newTestReplServer(t, cfg)
+	prompt, err := s.switchTo(context.Background(), repl.Target{Host: "127.0.0.1", Port: port, User: "bob", Password: "right"})
+	if err != nil {
+		t.Fatalf("switchTo: %v", err)
+	}
+	if want := "r@127.0.0.1:" + strconv.Itoa(port) + "> "; prompt != want {
+		t.Errorf("prompt = %q, want %q", prompt, want)
+	}
+	if *closed != 1 {
+		t.Errorf("old manager closed %d times, want 1", *closed)
+	}
+	if cfg.host != "127.0.0.1" || cfg.port != port || cfg.user != "bob" || cfg.password != "right" || cfg.database != "app" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+func TestReplServerSwitchToRejected(t *testing.T) {
+	port := startAuthKeyServer(t, "right")
+	cfg := &rootConfig{host: "old", port: 1, user: "admin", password: "kept", protocol: "v0_4", poolSize: 1}
+	s, closed := newTestReplServer(t, cfg)
+	_, err := s.switchTo(context.Background(), repl.Target{Host: "127.0.0.1", Port: port, Password: "wrong"})
+	if !errors.Is(err, conn.ErrReqlAuth) {
+		t.Fatalf("switchTo = %v, want auth error", err)
+	}
+	if *closed != 1 {
+		t.Errorf("cleanups = %d, want only the new manager's", *closed)
+	}
+	if cfg.host != "old" || cfg.port != 1 || cfg.password != "kept" {
+		t.Errorf("cfg changed after a failed connect: %+v", cfg)
+	}
+}
+
+func TestReplServerPrompt(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		cfg      rootConfig
+		switched bool
+		want     string
+	}{
+		{rootConfig{host: "db2", port: 28015}, false, "r> "},
+		{rootConfig{host: "db2", port: 28015}, true, "r@db2:28015> "},
+		{rootConfig{host: "::1", port: 28015}, true, "r@[::1]:28015> "},
+		{rootConfig{host: "db2", port: 28016, user: "bob", database: "app", replPrompt: "{user}@{host}:{port}/{db}> "}, true, "bob@db2:28016/app> "},
+		{rootConfig{host: "db2", user: "bob", replPrompt: "{user}@{host}/{db}>"}, false, "bob@db2/-> "},
+		{rootConfig{replPrompt: "prod {unknown}> "}, false, "prod {unknown}> "},
+	} {
+		s := &replServer{cfg: &tc.cfg, switched: tc.switched}
+		if got := s.prompt(); got != tc.want {
+			t.Errorf("prompt(%+v) = %q, want %q", tc.cfg, got, tc.want)
+		}
+	}
+}
+
+func TestReplServerStatus(t *testing.T) {
+	t.Parallel()
+	s, _ := newTestReplServer(t, &rootConfig{host: "db", port: 28015, poolSize: 1})
+	want := "not connected to db:28015 (connects on the next query); open changefeeds 0, cursors 0"
+	if got := s.status(); got != want {
+		t.Errorf("status = %q, want %q", got, want)
+	}
+	rc := s.reconnectPolicy()
+	rc.Notify(conn.ReconnectEvent{Attempt: 1, Err: errors.New("refused"), Backoff: 1})
+	if got := s.status(); got != want {
+		t.Errorf("status while retrying = %q", got)
+	}
+	rc.Notify(conn.ReconnectEvent{Attempt: 10, Err: errors.New("refused")})
+	want = "disconnected from db:28015, reconnect failed: refused; open changefeeds 0, cursors 0"
+	if got := s.status(); got != want {
+		t.Errorf("status = %q, want %q", got, want)
+	}
+	rc.Notify(conn.ReconnectEvent{Attempt: 1})
+	if s.lost != nil {
+		t.Errorf("lost = %v after a reconnect", s.lost)
+	}
+}
+
+func TestReplServerStatusConnected(t *testing.T) {
+	port := startAuthKeyServer(t, "right")
+	cfg := &rootConfig{host: "old", port: 1, user: "admin", protocol: "v0_4", poolSize: 1}
+	s, _ := newTestReplServer(t, cfg)
+	if _, err := s.switchTo(context.Background(), repl.Target{Host: "127.0.0.1", Port: port, Password: "right"}); err != nil {
+		t.Fatalf("switchTo: %v", err)
+	}
+	want := "connected to 127.0.0.1:" + strconv.Itoa(port) + " (1 connection); open changefeeds 0, cursors 0"
+	if got := s.status(); got != want {
+		t.Errorf("status = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+
+	"r-cli/internal/reql"
+	"r-cli/internal/reql/parser"
+	"r-cli/internal/response"
+)
+
+// sourceError is a server error located in the text of a REPL query by
+// its backtrace; the REPL underlines the runes [start, end).
+type sourceError struct {
+	err        error
+	start, end int
+}
+
+// Error leaves out the raw backtrace frames, which the span replaces.
+func (e *sourceError) Error() string {
+	msg, _, _ := strings.Cut(e.err.Error(), "\nBacktrace: ")
+	return msg
+}
+
+func (e *sourceError) Unwrap() error { return e.err }
+
+// Span returns the runes of the query the error is about.
+func (e *sourceError) Span() (start, end int) { return e.start, e.end }
+
+// locateError points err, the result of running term, at the
+// sub-expression of the query text its backtrace names; other errors are
+// returned as they are.
+func locateError(err error, term reql.Term) error {
+	frames, ok := response.BacktraceOf(err)
+	if !ok {
+		return err
+	}
+	start, end, ok := parser.Locate(term, frames)
+	if !ok {
+		return err
+	}
+	return &sourceError{err: err, start: start, end: end}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"r-cli/internal/proto"
+	"r-cli/internal/reql"
+	"r-cli/internal/reql/parser"
+	"r-cli/internal/response"
+)
+
+func TestLocateError(t *testing.T) {
+	t.Parallel()
+	expr := `r.table("a").map(d => d("n").add("x"))`
+	term, err := parser.Parse(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverErr := response.MapError(&response.Response{
+		Type:      proto.ResponseRuntimeError,
+		Results:   []json.RawMessage{json.RawMessage(`"Expected type NUMBER but found STRING."`)},
+		Backtrace: []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`1`)},
+	})
+	located := locateError(fmt.Errorf("wrapped: %w", serverErr), term)
+	var se *sourceError
+	if !errors.As(located, &se) {
+		t.Fatalf("locateError = %T, want *sourceError", located)
+	}
+	start, end := se.Span()
+	if got := expr[start:end]; got != `.add("x")` {
+		t.Errorf("span = %q, want .add(\"x\")", got)
+	}
+	if se.Error() != "wrapped: Expected type NUMBER but found STRING." {
+		t.Errorf("Error() = %q", se.Error())
+	}
+	if !errors.Is(located, serverErr) {
+		t.Error("located error does not wrap the server error")
+	}
+
+	plain := errors.New("connection reset")
+	if got := locateError(plain, term); got != plain {
+		t.Errorf("non-server error changed: %v", got)
+	}
+	if got := locateError(serverErr, reql.Table("a")); got != serverErr {
+		t.Errorf("term without source: got %v, want the error as is", got)
+	}
+}
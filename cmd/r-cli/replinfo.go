@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+)
+
+// tableSummary is what the REPL's .info prints about a table, read from
+// info(), config() and status().
+type tableSummary struct {
+	DB, Table  string
+	PrimaryKey string
+	Docs       int64 // sum of the per-shard estimates
+	Durability string
+	WriteAcks  string
+	Ready      tableReadiness
+	Shards     []shardStatus
+	Indexes    []string
+}
+
+// tableReadiness is the status field of a table's status().
+type tableReadiness struct {
+	AllReplicasReady      bool `json:"all_replicas_ready"`
+	ReadyForWrites        bool `json:"ready_for_writes"`
+	ReadyForReads         bool `json:"ready_for_reads"`
+	ReadyForOutdatedReads bool `json:"ready_for_outdated_reads"`
+}
+
+// shardStatus is one entry of the shards of a table's status().
+type shardStatus struct {
+	PrimaryReplicas []string `json:"primary_replicas"`
+	Replicas        []struct {
+		Server string `json:"server"`
+		State  string `json:"state"`
+	} `json:"replicas"`
+}
+
+// makeTableInfo backs the REPL's .info command.
+func makeTableInfo(exec *query.Executor, cfg *rootConfig) func(context.Context, string, string, io.Writer) error {
+	return func(ctx context.Context, db, table string, w io.Writer) error {
+		if db == "" {
+			db = cfg.database
+		}
+		if db == "" {
+			return errNoDB
+		}
+		s, err := readTableSummary(ctx, exec, cfg, db, table)
+		if err != nil {
+			return err
+		}
+		return s.write(w)
+	}
+}
+
+func readTableSummary(ctx context.Context, exec *query.Executor, cfg *rootConfig, db, table string) (tableSummary, error) {
+	tbl := reql.DB(db).Table(table)
+	s := tableSummary{DB: db, Table: table}
+	var info struct {
+		PrimaryKey string   `json:"primary_key"`
+		DocCounts  []int64  `json:"doc_count_estimates"`
+		Indexes    []string `json:"indexes"`
+	}
+	if err := fetchValue(ctx, exec, cfg, tbl.Info(), &info); err != nil {
+		return s, err
+	}
+	s.PrimaryKey, s.Indexes = info.PrimaryKey, info.Indexes
+	for _, n := range info.DocCounts {
+		s.Docs += n
+	}
+	var conf struct {
+		Durability string `json:"durability"`
+		WriteAcks  string `json:"write_acks"`
+	}
+	if err := fetchValue(ctx, exec, cfg, tbl.Config(), &conf); err != nil {
+		return s, err
+	}
+	s.Durability, s.WriteAcks = conf.Durability, conf.WriteAcks
+	var status struct {
+		Shards []shardStatus  `json:"shards"`
+		Status tableReadiness `json:"status"`
+	}
+	if err := fetchValue(ctx, exec, cfg, tbl.Status(), &status); err != nil {
+		return s, err
+	}
+	s.Shards, s.Ready = status.Shards, status.Status
+	return s, nil
+}
+
+// write prints the summary as aligned "label  value" lines.
+func (s tableSummary) write(w io.Writer) error {
+	indexes := strings.Join(s.Indexes, ", ")
+	if indexes == "" {
+		indexes = "(none)"
+	}
+	lines := [][2]string{
+		{"table", s.DB + "." + s.Table},
+		{"primary key", s.PrimaryKey},
+		{"documents", fmt.Sprintf("~%d", s.Docs)},
+		{"durability", s.Durability + ", write acks " + s.WriteAcks},
+		{"status", s.Ready.String()},
+	}
+	for i, sh := range s.Shards {
+		lines = append(lines, [2]string{fmt.Sprintf("shard %d", i+1), sh.String()})
+	}
+	lines = append(lines, [2]string{"indexes", indexes})
+	for _, l := range lines {
+		if _, err := fmt.Fprintf(w, "%-12s %s\n", l[0], l[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String names the highest availability level the table reached.
+func (r tableReadiness) String() string {
+	switch {
+	case r.AllReplicasReady:
+		return "all replicas ready"
+	case r.ReadyForWrites:
+		return "ready for writes"
+	case r.ReadyForReads:
+		return "ready for reads"
+	case r.ReadyForOutdatedReads:
+		return "ready for outdated reads"
+	}
+	return "unavailable"
+}
+
+// String lists the primary and the state of every replica, e.g.
+// "primary a; replicas a (ready), b (backfilling)".
+func (s shardStatus) String() string {
+	replicas := make([]string, len(s.Replicas))
+	for i, r := range s.Replicas {
+		replicas[i] = r.Server + " (" + r.State + ")"
+	}
+	primary := strings.Join(s.PrimaryReplicas, ", ")
+	if primary == "" {
+		primary = "none"
+	}
+	return "primary " + primary + "; replicas " + strings.Join(replicas, ", ")
+}
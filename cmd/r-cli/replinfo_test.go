@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"r-cli/internal/query"
+)
+
+func TestTableSummaryWrite(t *testing.T) {
+	t.Parallel()
+	var s tableSummary
+	if err := json.Unmarshal([]byte(`{
+		"DB": "app", "Table": "users", "PrimaryKey": "id", "Docs": 1234,
+		"Durability": "hard", "WriteAcks": "majority",
+		"Ready": {"ready_for_writes": true, "ready_for_reads": true},
+		"Shards": [
+			{"primary_replicas": ["a"], "replicas": [{"server": "a", "state": "ready"}, {"server": "b", "state": "backfilling"}]},
+			{"primary_replicas": [], "replicas": [{"server": "c", "state": "disconnected"}]}
+		],
+		"Indexes": ["email", "name"]
+	}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := s.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `table        app.users
+primary key  id
+documents    ~1234
+durability   hard, write acks majority
+status       ready for writes
+shard 1      primary a; replicas a (ready), b (backfilling)
+shard 2      primary none; replicas c (disconnected)
+indexes      email, name
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestTableReadiness(t *testing.T) {
+	t.Parallel()
+	tests := map[string]tableReadiness{
+		"all replicas ready":       {AllReplicasReady: true, ReadyForWrites: true},
+		"ready for reads":          {ReadyForReads: true, ReadyForOutdatedReads: true},
+		"ready for outdated reads": {ReadyForOutdatedReads: true},
+		"unavailable":              {},
+	}
+	for want, r := range tests {
+		if got := r.String(); got != want {
+			t.Errorf("%+v: got %q, want %q", r, got, want)
+		}
+	}
+}
+
+func TestMakeTableInfoNoDB(t *testing.T) {
+	t.Parallel()
+	info := makeTableInfo(query.New(nil), &rootConfig{})
+	if err := info(context.Background(), "", "users", &bytes.Buffer{}); !errors.Is(err, errNoDB) {
+		t.Errorf("err = %v, want errNoDB", err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+)
+
+// schemaNode gathers what the sampled values at one path of a table looked
+// like: the types seen there and, for objects and arrays, the fields and
+// elements below it.
+type schemaNode struct {
+	seen    int            // values present at this path
+	types   map[string]int // values per type name
+	objects int            // values that were objects, the base of the fields' optionality
+	fields  map[string]*schemaNode
+	elems   *schemaNode // the elements of arrays
+}
+
+// makeTableSchema backs the REPL's .schema command.
+func makeTableSchema(exec *query.Executor, cfg *rootConfig) func(context.Context, string, string, int, io.Writer) error {
+	return func(ctx context.Context, db, table string, sample int, w io.Writer) error {
+		if db == "" {
+			db = cfg.database
+		}
+		if db == "" {
+			return errNoDB
+		}
+		var docs []interface{}
+		if err := fetchValue(ctx, exec, cfg, reql.DB(db).Table(table).Sample(sample), &docs); err != nil {
+			return err
+		}
+		return writeSchema(w, db+"."+table, docs)
+	}
+}
+
+// writeSchema prints the fields of docs as a tree headed by name.
+func writeSchema(w io.Writer, name string, docs []interface{}) error {
+	if len(docs) == 0 {
+		_, err := fmt.Fprintf(w, "%s: no documents\n", name)
+		return err
+	}
+	var root schemaNode
+	for _, d := range docs {
+		root.add(d)
+	}
+	var rows [][3]string
+	root.rows("", &rows)
+	var nameWidth, typeWidth int
+	for _, r := range rows {
+		nameWidth, typeWidth = max(nameWidth, len(r[0])), max(typeWidth, len(r[1]))
+	}
+	if _, err := fmt.Fprintf(w, "%s: %d documents sampled\n", name, len(docs)); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		line := strings.TrimRight(fmt.Sprintf("%-*s  %-*s  %s", nameWidth, r[0], typeWidth, r[1], r[2]), " ")
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add records v, and what it holds, at n.
+func (n *schemaNode) add(v interface{}) {
+	n.seen++
+	t := schemaType(v)
+	if n.types == nil {
+		n.types = map[string]int{}
+	}
+	n.types[t]++
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if t != "object" {
+			return
+		}
+		n.objects++
+		if n.fields == nil {
+			n.fields = map[string]*schemaNode{}
+		}
+		for k, fv := range v {
+			f := n.fields[k]
+			if f == nil {
+				f = &schemaNode{}
+				n.fields[k] = f
+			}
+			f.add(fv)
+		}
+	case []interface{}:
+		for _, e := range v {
+			if n.elems == nil {
+				n.elems = &schemaNode{}
+			}
+			n.elems.add(e)
+		}
+	}
+}
+
+// rows appends a "name, types, optionality" row for each field of n, in
+// name order, each followed by the rows below it indented by two spaces.
+func (n *schemaNode) rows(indent string, rows *[][3]string) {
+	for _, name := range slices.Sorted(maps.Keys(n.fields)) {
+		f := n.fields[name]
+		note := ""
+		if f.seen < n.objects {
+			note = fmt.Sprintf("optional (%d of %d)", f.seen, n.objects)
+		}
+		*rows = append(*rows, [3]string{indent + name, f.typeList(), note})
+		f.children(indent+"  ", rows)
+	}
+}
+
+// children appends the rows of the fields and array elements below n.
+func (n *schemaNode) children(indent string, rows *[][3]string) {
+	n.rows(indent, rows)
+	if n.elems != nil {
+		*rows = append(*rows, [3]string{indent + "[]", n.elems.typeList(), ""})
+		n.elems.children(indent+"  ", rows)
+	}
+}
+
+// typeList joins the types seen at n, the most frequent first.
+func (n *schemaNode) typeList() string {
+	types := slices.SortedFunc(maps.Keys(n.types), func(a, b string) int {
+		return cmp.Or(cmp.Compare(n.types[b], n.types[a]), cmp.Compare(a, b))
+	})
+	return strings.Join(types, " | ")
+}
+
+// schemaType names the type of a decoded JSON value; the TIME, BINARY and
+// GEOMETRY pseudo-types are named after their $reql_type$ rather than
+// object.
+func schemaType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		if t, ok := v["$reql_type$"].(string); ok {
+			return strings.ToLower(t)
+		}
+	}
+	return "object"
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"r-cli/internal/query"
+)
+
+func TestWriteSchema(t *testing.T) {
+	t.Parallel()
+	var docs []interface{}
+	if err := json.Unmarshal([]byte(`[
+		{"id": "a", "name": "Ann", "age": 31, "tags": ["x", "y"], "address": {"city": "Oslo", "zip": "0150"},
+		 "created": {"$reql_type$": "TIME", "epoch_time": 1, "timezone": "+00:00"}},
+		{"id": "b", "name": null, "tags": [], "address": {"city": "Rome"}},
+		{"id": "c", "name": "Cy", "age": "old", "tags": [{"k": 1}]}
+	]`), &docs); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeSchema(&buf, "app.users", docs); err != nil {
+		t.Fatal(err)
+	}
+	want := `app.users: 3 documents sampled
+address  object           optional (2 of 3)
+  city   string
+  zip    string           optional (1 of 2)
+age      number | string  optional (2 of 3)
+created  time             optional (1 of 3)
+id       string
+name     string | null
+tags     array
+  []     string | object
+    k    number
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteSchemaEmpty(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := writeSchema(&buf, "app.users", nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "app.users: no documents\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestMakeTableSchemaNoDB(t *testing.T) {
+	t.Parallel()
+	schema := makeTableSchema(query.New(nil), &rootConfig{})
+	if err := schema(context.Background(), "", "users", 10, &bytes.Buffer{}); !errors.Is(err, errNoDB) {
+		t.Errorf("err = %v, want errNoDB", err)
+	}
+}
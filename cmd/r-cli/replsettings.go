@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"r-cli/internal/query"
+	"r-cli/internal/repl"
+)
+
+// replSetting is a session setting of .set and .show, named like its flag.
+type replSetting struct {
+	name string
+	get  func(c *rootConfig) string
+	set  func(c *rootConfig, value string) error
+}
+
+// replSettings lists the settings in .show order.
+var replSettings = []replSetting{
+	{"timeout", getReplTimeout, setReplTimeout},
+	choiceSetting("read-mode", func(c *rootConfig) *string { return &c.readMode }, "default", "single", "majority", "outdated"),
+	choiceSetting("durability", func(c *rootConfig) *string { return &c.durability }, "default", "hard", "soft"),
+	countSetting("array-limit", func(c *rootConfig) *int { return &c.arrayLimit }, nil),
+	countSetting("first-batch-scaledown", func(c *rootConfig) *int { return &c.firstBatchScaledown }, nil),
+	countSetting("max-batch-rows", func(c *rootConfig) *int { return &c.maxBatchRows }, checkReplMaxBatchRows),
+	choiceSetting("format", func(c *rootConfig) *string { return &c.format }, "auto", "json", "jsonl", "raw", "table", "tsv", "csv", "template"),
+	choiceSetting("time-format", func(c *rootConfig) *string { return &c.timeFormat }, "", "native", "local", "relative", "unix-ms", "raw"),
+	{"limit", getReplLimit, setReplLimit},
+	choiceSetting("color", func(c *rootConfig) *string { return &c.color }, "", "auto", "always", "never"),
+	choiceSetting("confirm", func(c *rootConfig) *string { return &c.replConfirm }, "on", "off"),
+	{"prompt", getReplPrompt, setReplPrompt},
+	choiceSetting("keymap", func(c *rootConfig) *string { return &c.replKeymap }, repl.KeymapEmacs, repl.KeymapVi),
+}
+
+// choiceSetting is a setting holding one of choices; empty, if not "",
+// names the zero value that leaves the choice to the server or the terminal.
+func choiceSetting(name string, field func(*rootConfig) *string, empty string, choices ...string) replSetting {
+	return replSetting{
+		name: name,
+		get: func(c *rootConfig) string {
+			if v := *field(c); v != "" {
+				return v
+			}
+			return empty
+		},
+		set: func(c *rootConfig, value string) error {
+			switch {
+			case empty != "" && value == empty:
+				*field(c) = ""
+			case slices.Contains(choices, value):
+				*field(c) = value
+			default:
+				valid := choices
+				if empty != "" {
+					valid = append([]string{empty}, choices...)
+				}
+				return fmt.Errorf("invalid value %q, must be %s", value, strings.Join(valid, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+// countSetting is a setting holding a number >= 0, checked like its flag
+// and, if check is not nil, by check, which can reject the new value; 0,
+// shown and set as default, leaves the value to the server.
+func countSetting(name string, field func(*rootConfig) *int, check func(*rootConfig) error) replSetting {
+	return replSetting{
+		name: name,
+		get: func(c *rootConfig) string {
+			if v := *field(c); v > 0 {
+				return strconv.Itoa(v)
+			}
+			return "default"
+		},
+		set: func(c *rootConfig, value string) error {
+			n := 0
+			if value != "default" {
+				var err error
+				n, err = strconv.Atoi(value)
+				if err != nil || n < 0 {
+					return fmt.Errorf("invalid value %q, must be a number >= 0 or default", value)
+				}
+			}
+			old := *field(c)
+			*field(c) = n
+			if check != nil {
+				if err := check(c); err != nil {
+					*field(c) = old
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// checkReplMaxBatchRows rejects max-batch-rows with --page-size, which is
+// sent as max_batch_rows too, as the flags do.
+func checkReplMaxBatchRows(c *rootConfig) error {
+	if c.maxBatchRows > 0 && c.pageSize > 0 {
+		return fmt.Errorf("cannot be set with --page-size, which is sent as max_batch_rows")
+	}
+	return nil
+}
+
+func getReplTimeout(c *rootConfig) string {
+	if c.replTimeout == 0 {
+		return "none"
+	}
+	return c.replTimeout.String()
+}
+
+func setReplTimeout(c *rootConfig, value string) error {
+	if value == "none" || value == "0" {
+		c.replTimeout = 0
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return fmt.Errorf("invalid duration %q, e.g. 30s or none", value)
+	}
+	c.replTimeout = d
+	return nil
+}
+
+func getReplLimit(c *rootConfig) string {
+	switch {
+	case c.limit > 0:
+		return strconv.Itoa(c.limit)
+	case c.replAutoLimit > 0:
+		return strconv.Itoa(c.replAutoLimit) + " on table scans"
+	default:
+		return "none"
+	}
+}
+
+// setReplLimit caps the rows of every query; any value, 0 and none
+// included, also turns off --auto-limit.
+func setReplLimit(c *rootConfig, value string) error {
+	n := 0
+	if value != "none" {
+		var err error
+		n, err = strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid limit %q, must be a number >= 0 or none", value)
+		}
+	}
+	c.limit, c.replAutoLimit = n, 0
+	return nil
+}
+
+func getReplPrompt(c *rootConfig) string {
+	if c.replPrompt == "" {
+		return "default"
+	}
+	return c.replPrompt
+}
+
+func setReplPrompt(c *rootConfig, value string) error {
+	if value == "default" {
+		value = ""
+	}
+	c.replPrompt = value
+	return nil
+}
+
+// makeReplOptions backs .set and .show with replSettings on cfg. A change
+// is pushed to exec, which sends the optargs and converts the rows of the
+// queries that follow, and a keymap to keys, if not nil; names may use _
+// for -, as in read_mode.
+func makeReplOptions(exec *query.Executor, cfg *rootConfig, keys repl.KeymapReader) repl.Options {
+	return repl.Options{
+		Set: func(name, value string) error {
+			key := strings.ReplaceAll(strings.ToLower(name), "_", "-")
+			i := slices.IndexFunc(replSettings, func(s replSetting) bool { return s.name == key })
+			if i < 0 {
+				return fmt.Errorf("unknown setting %q; .show lists them", name)
+			}
+			if err := replSettings[i].set(cfg, value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			exec.SetDefaults(buildQueryOpts(cfg))
+			exec.SetCursorOptions(cfg.outputCursorOptions())
+			if key == "keymap" && keys != nil {
+				return keys.SetKeymap(replSettings[i].get(cfg))
+			}
+			return nil
+		},
+		List: func() []repl.Setting {
+			out := make([]repl.Setting, len(replSettings))
+			for i, s := range replSettings {
+				out[i] = repl.Setting{Name: s.name, Value: s.get(cfg)}
+			}
+			return out
+		},
+	}
+}
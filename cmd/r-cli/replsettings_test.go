@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"r-cli/internal/query"
+)
+
+func TestReplOptionsSet(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{timeFormat: "native", color: "auto"}
+	opts := makeReplOptions(query.New(nil), cfg, nil)
+	for _, kv := range [][2]string{
+		{"timeout", "5s"}, {"read_mode", "majority"}, {"Durability", "soft"}, {"format", "table"},
+		{"time-format", "unix-ms"}, {"limit", "10"}, {"color", "never"}, {"confirm", "off"},
+		{"prompt", "{host}/{db}>"}, {"keymap", "vi"}, {"array_limit", "200000"}, {"first-batch-scaledown", "2"},
+		{"max-batch-rows", "50"},
+	} {
+		if err := opts.Set(kv[0], kv[1]); err != nil {
+			t.Fatalf("Set(%s, %s): %v", kv[0], kv[1], err)
+		}
+	}
+	if cfg.replTimeout != 5*time.Second || cfg.readMode != "majority" || cfg.durability != "soft" || cfg.format != "table" ||
+		cfg.timeFormat != "unix-ms" || cfg.limit != 10 || cfg.color != "never" || cfg.replConfirm != "off" ||
+		cfg.replPrompt != "{host}/{db}>" || cfg.replKeymap != "vi" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if opts := buildQueryOpts(cfg); opts["read_mode"] != "majority" || opts["durability"] != "soft" ||
+		opts["array_limit"] != 200000 || opts["first_batch_scaledown_factor"] != 2 || opts["max_batch_rows"] != 50 {
+		t.Errorf("optargs = %v", opts)
+	}
+	got := fmt.Sprint(opts.List())
+	if want := "[{timeout 5s} {read-mode majority} {durability soft} {array-limit 200000} {first-batch-scaledown 2} {max-batch-rows 50} {format table} {time-format unix-ms} {limit 10} {color never} {confirm off} {prompt {host}/{db}>} {keymap vi}]"; got != want {
+		t.Errorf("List = %s, want %s", got, want)
+	}
+
+	for _, kv := range [][2]string{{"timeout", "none"}, {"read-mode", "default"}, {"format", "auto"}, {"limit", "none"}, {"prompt", "default"}, {"array-limit", "default"}, {"max-batch-rows", "0"}} {
+		if err := opts.Set(kv[0], kv[1]); err != nil {
+			t.Fatalf("Set(%s, %s): %v", kv[0], kv[1], err)
+		}
+	}
+	if cfg.replTimeout != 0 || cfg.readMode != "" || cfg.format != "" || cfg.limit != 0 || cfg.replPrompt != "" || cfg.arrayLimit != 0 || cfg.maxBatchRows != 0 {
+		t.Errorf("cfg after reset = %+v", cfg)
+	}
+	if got := fmt.Sprint(opts.List()[:7]); got != "[{timeout none} {read-mode default} {durability soft} {array-limit default} {first-batch-scaledown 2} {max-batch-rows default} {format auto}]" {
+		t.Errorf("List after reset = %s", got)
+	}
+}
+
+func TestReplOptionsLimitDisablesAutoLimit(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{replAutoLimit: 40}
+	opts := makeReplOptions(query.New(nil), cfg, nil)
+	if got := getReplLimit(cfg); got != "40 on table scans" {
+		t.Errorf("limit = %q", got)
+	}
+	if err := opts.Set("limit", "0"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.replAutoLimit != 0 || cfg.limit != 0 || getReplLimit(cfg) != "none" {
+		t.Errorf("cfg after .set limit 0 = %+v", cfg)
+	}
+}
+
+func TestReplOptionsMaxBatchRowsWithPageSize(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{pageSize: 25}
+	opts := makeReplOptions(query.New(nil), cfg, nil)
+	if err := opts.Set("max-batch-rows", "50"); err == nil || !strings.Contains(err.Error(), "--page-size") {
+		t.Errorf("Set(max-batch-rows, 50) with --page-size = %v", err)
+	}
+	if cfg.maxBatchRows != 0 || buildQueryOpts(cfg)["max_batch_rows"] != 25 {
+		t.Errorf("rejected value kept: cfg.maxBatchRows = %d", cfg.maxBatchRows)
+	}
+	if err := opts.Set("max-batch-rows", "default"); err != nil {
+		t.Errorf("Set(max-batch-rows, default) with --page-size = %v", err)
+	}
+}
+
+type fakeKeymapReader struct{ keymaps []string }
+
+func (f *fakeKeymapReader) SetKeymap(keymap string) error {
+	f.keymaps = append(f.keymaps, keymap)
+	return nil
+}
+
+func TestReplOptionsSetKeymap(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{replKeymap: "emacs"}
+	keys := &fakeKeymapReader{}
+	opts := makeReplOptions(query.New(nil), cfg, keys)
+	for _, v := range []string{"vi", "emacs"} {
+		if err := opts.Set("keymap", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := opts.Set("keymap", "nano"); err == nil {
+		t.Error("Set(keymap, nano) succeeded")
+	}
+	if err := opts.Set("limit", "5"); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(keys.keymaps) != "[vi emacs]" {
+		t.Errorf("keymaps = %q", keys.keymaps)
+	}
+}
+
+func TestReplOptionsSetErrors(t *testing.T) {
+	t.Parallel()
+	opts := makeReplOptions(query.New(nil), &rootConfig{}, nil)
+	cases := map[[2]string]string{
+		{"nope", "1"}:              `unknown setting "nope"`,
+		{"timeout", "soon"}:        `timeout: invalid duration "soon"`,
+		{"timeout", "-1s"}:         `timeout: invalid duration "-1s"`,
+		{"limit", "-1"}:            `limit: invalid limit "-1"`,
+		{"read-mode", "fast"}:      `read-mode: invalid value "fast", must be default, single, majority, outdated`,
+		{"color", "default"}:       `color: invalid value "default", must be auto, always, never`,
+		{"durability", "medium"}:   `durability: invalid value "medium", must be default, hard, soft`,
+		{"time-format", "iso8601"}: `time-format: invalid value "iso8601"`,
+		{"array-limit", "-1"}:      `array-limit: invalid value "-1", must be a number >= 0 or default`,
+		{"max-batch-rows", "many"}: `max-batch-rows: invalid value "many"`,
+	}
+	for kv, want := range cases {
+		err := opts.Set(kv[0], kv[1])
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Set(%s, %s) = %v, want %q", kv[0], kv[1], err, want)
+		}
+	}
+	// the list of choices is not extended by earlier errors
+	if err := opts.Set("read-mode", "fast"); err == nil || strings.Count(err.Error(), "default") != 1 {
+		t.Errorf("repeated error = %v", err)
+	}
+}
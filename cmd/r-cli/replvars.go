@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"r-cli/internal/cursor"
+	"r-cli/internal/output"
+	"r-cli/internal/parselog"
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+	"r-cli/internal/reql/parser"
+)
+
+// lastResultVar names the result of the last REPL query in later queries.
+const lastResultVar = "_"
+
+// maxBoundRows caps the rows kept for a variable; a longer result is
+// printed but leaves the variable unchanged.
+const maxBoundRows = 10000
+
+// replVars holds the variables REPL queries may use: _ and those of .let.
+type replVars struct {
+	terms map[string]reql.Term
+}
+
+func newReplVars() *replVars {
+	return &replVars{terms: map[string]reql.Term{}}
+}
+
+// reset forgets every variable, for the REPL's .reset.
+func (v *replVars) reset() {
+	clear(v.terms)
+}
+
+func (v *replVars) bind(value json.RawMessage, names ...string) {
+	for _, name := range names {
+		v.terms[name] = reql.JSON(string(value))
+	}
+}
+
+// captureIter keeps the rows read through it, up to maxBoundRows.
+type captureIter struct {
+	inner output.RowIterator
+	rows  []json.RawMessage
+	count int  // rows read
+	over  bool // more than maxBoundRows rows
+	done  bool // inner reached io.EOF
+}
+
+func (c *captureIter) Next() (json.RawMessage, error) {
+	row, err := c.inner.Next()
+	switch {
+	case errors.Is(err, io.EOF):
+		c.done = true
+	case err != nil:
+	default:
+		c.count++
+	}
+	switch {
+	case err != nil:
+	case len(c.rows) == maxBoundRows:
+		c.over = true
+		c.rows = nil
+	case !c.over:
+		c.rows = append(c.rows, row)
+	}
+	return row, err
+}
+
+// value returns the captured result as JSON: the row of a single value,
+// otherwise an array of the rows. It reports false unless every row was
+// read and kept.
+func (c *captureIter) value(atom bool) (json.RawMessage, bool) {
+	if !c.done || c.over {
+		return nil, false
+	}
+	if atom && len(c.rows) == 1 {
+		return c.rows[0], true
+	}
+	rows := c.rows
+	if rows == nil {
+		rows = []json.RawMessage{}
+	}
+	value, err := json.Marshal(rows)
+	return value, err == nil
+}
+
+// runReplQuery parses expr with the variables in vars, runs it and writes
+// the result to w. A result printed in full becomes _, and name too when
+// set; it holds the rows as printed, so --select, --limit and the time
+// format apply. A query without a name that scans a table is limited to
+// --auto-limit rows, see autoLimit.
+func runReplQuery(ctx context.Context, exec *query.Executor, cfg *rootConfig, vars *replVars, expr, name string, w io.Writer) error {
+	term, err := parser.ParseWith(expr, vars.terms)
+	if err != nil {
+		parselog.Log(expr, err)
+		return &queryError{err: err}
+	}
+	limited := 0
+	if name == "" {
+		term, limited = autoLimit(term, cfg)
+	}
+	if cfg.replTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.replTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	profile, cur, err := exec.Run(ctx, term, nil)
+	if err != nil {
+		return locateError(err, term)
+	}
+	writeQueryMeta(cfg, profile, time.Since(start))
+	if cur == nil {
+		return nil
+	}
+	defer func() { _ = cur.Close() }()
+	iter := &captureIter{inner: makeIter(cur, cfg)}
+	format := output.DetectFormat(replOutputFile(w), cfg.format)
+	if p := newReplPager(w, cfg); p != nil {
+		err = p.finish(writeOutput(p, format, iter, cfg))
+	} else {
+		err = writeOutput(w, format, iter, cfg)
+	}
+	if value, ok := iter.value(cursor.IsAtom(cur)); ok && err == nil {
+		names := []string{lastResultVar}
+		if name != "" {
+			names = append(names, name)
+		}
+		vars.bind(value, names...)
+	}
+	if limited > 0 && iter.count == limited && err == nil && !cfg.quiet {
+		_, _ = fmt.Fprintf(os.Stderr, autoLimitNotice, limited)
+	}
+	if err != nil {
+		return locateError(err, term)
+	}
+	return nil
+}
+
+// makeReplLet backs .let: it runs expr like a query and binds its result
+// to name as well as _.
+func makeReplLet(exec *query.Executor, cfg *rootConfig, vars *replVars) func(context.Context, string, string, io.Writer) error {
+	return func(ctx context.Context, name, expr string, w io.Writer) error {
+		return runReplQuery(ctx, exec, cfg, vars, expr, name, w)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// drain reads iter to the end, as writeOutput does.
+func drain(t *testing.T, iter *captureIter) {
+	t.Helper()
+	for {
+		if _, err := iter.Next(); errors.Is(err, io.EOF) {
+			return
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCaptureIterValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		rows []string
+		atom bool
+		want string
+	}{
+		{"atom", []string{`{"generated_keys":["k1"]}`}, true, `{"generated_keys":["k1"]}`},
+		{"split atom", []string{`{"group":1}`, `{"group":2}`}, true, `[{"group":1},{"group":2}]`},
+		{"single row sequence", []string{`{"id":1}`}, false, `[{"id":1}]`},
+		{"empty sequence", nil, false, `[]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stub := &stubIter{}
+			for _, r := range tt.rows {
+				stub.rows = append(stub.rows, json.RawMessage(r))
+			}
+			iter := &captureIter{inner: stub}
+			drain(t, iter)
+			got, ok := iter.value(tt.atom)
+			if !ok || string(got) != tt.want {
+				t.Errorf("value = %s, %v; want %s", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptureIterIncomplete(t *testing.T) {
+	t.Parallel()
+	stub := &stubIter{rows: []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`2`)}}
+	iter := &captureIter{inner: stub}
+	if _, err := iter.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := iter.value(false); ok {
+		t.Error("value of a partly read result is bound")
+	}
+
+	stub = &stubIter{}
+	for range maxBoundRows + 1 {
+		stub.rows = append(stub.rows, json.RawMessage(`1`))
+	}
+	iter = &captureIter{inner: stub}
+	drain(t, iter)
+	if _, ok := iter.value(false); ok || iter.rows != nil {
+		t.Errorf("value of %d rows is bound", maxBoundRows+1)
+	}
+}
+
+func TestReplVarsParse(t *testing.T) {
+	t.Parallel()
+	vars := newReplVars()
+	exec := makeReplExec(nil, &rootConfig{}, vars)
+	if err := exec(context.Background(), "_", io.Discard); err == nil || !strings.Contains(err.Error(), "_") {
+		t.Fatalf("_ before any result: %v", err)
+	}
+	vars.bind(json.RawMessage(`["k1"]`), lastResultVar, "ids")
+	for _, name := range []string{lastResultVar, "ids"} {
+		if got, want := vars.terms[name], `[98,["[\"k1\"]"]]`; mustMarshal(t, got) != want {
+			t.Errorf("%s = %s, want %s", name, mustMarshal(t, got), want)
+		}
+	}
+}
+
+func TestReplVarsReset(t *testing.T) {
+	t.Parallel()
+	vars := newReplVars()
+	vars.bind(json.RawMessage(`1`), lastResultVar, "n")
+	vars.reset()
+	if len(vars.terms) != 0 {
+		t.Errorf("terms after reset = %v", vars.terms)
+	}
+	exec := makeReplExec(nil, &rootConfig{}, vars)
+	if err := exec(context.Background(), "n", io.Discard); err == nil || !strings.Contains(err.Error(), "n") {
+		t.Errorf("n after reset: %v", err)
+	}
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"r-cli/internal/parselog"
+	"r-cli/internal/proto"
+	"r-cli/internal/query"
+	"r-cli/internal/repl"
+	"r-cli/internal/reql"
+	"r-cli/internal/reql/parser"
+)
+
+// replWatchMaxBackoff bounds the reopen delay of a lost .watch feed, as
+// --max-backoff does for watch.
+const replWatchMaxBackoff = 30 * time.Second
+
+// makeReplWatch backs .watch: the changes are printed as watch prints them,
+// one stamped JSON line each, and a lost feed is reopened, until ctx is
+// cancelled by Ctrl+C.
+func makeReplWatch(exec *query.Executor, cfg *rootConfig, vars *replVars) repl.ExecFunc {
+	return func(ctx context.Context, expr string, w io.Writer) error {
+		term, err := replWatchTerm(cfg, vars, expr)
+		if err != nil {
+			return err
+		}
+		wc := &watchConfig{maxBackoff: replWatchMaxBackoff}
+		emit := watchEmitter(ctx, w, &wc.actions, time.Now)
+		keepWaiting := func() (bool, error) { return true, nil }
+		_, err = watchOnce(ctx, exec, term, watchResume(cfg, wc, exec.Reopen(term, nil)), emit.emit, keepWaiting)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return locateError(err, term)
+		}
+		return nil
+	}
+}
+
+// replWatchTerm builds the feed of a .watch argument: a table, or a query
+// with the REPL variables, gets .changes() unless it already calls it.
+func replWatchTerm(cfg *rootConfig, vars *replVars, expr string) (reql.Term, error) {
+	var term reql.Term
+	var err error
+	if isWatchExpr(expr) {
+		term, err = parser.ParseWith(expr, vars.terms)
+		if err != nil {
+			parselog.Log(expr, err)
+		}
+	} else {
+		term, err = listTable(cfg, expr)
+	}
+	if err != nil {
+		return reql.Term{}, err
+	}
+	if _, ok := reql.Find(term, proto.TermChanges); !ok {
+		term = term.Changes()
+	}
+	return term, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReplWatchTerm(t *testing.T) {
+	t.Parallel()
+	vars := newReplVars()
+	vars.bind(json.RawMessage(`"u1"`), "key")
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"users", `[152,[[15,[[14,["test"]],"users"]]]]`},
+		{"app.users", `[152,[[15,[[14,["app"]],"users"]]]]`},
+		{`r.table("users").filter({a: 1})`, `[152,[[39,[[15,["users"]],{"a":1}]]]]`},
+		{`r.table("users").changes({squash: true})`, `[152,[[15,["users"]]],{"squash":true}]`},
+		{`r.table("users").get(key)`, `[152,[[16,[[15,["users"]],[98,["\"u1\""]]]]]]`},
+	}
+	for _, tc := range tests {
+		term, err := replWatchTerm(&rootConfig{database: "test"}, vars, tc.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := mustMarshal(t, term); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestReplWatchTermErrors(t *testing.T) {
+	t.Parallel()
+	for _, expr := range []string{`r.table(`, "users"} {
+		if term, err := replWatchTerm(&rootConfig{}, newReplVars(), expr); err == nil {
+			t.Errorf("%s: got %v, want an error", expr, term)
+		}
+	}
+}
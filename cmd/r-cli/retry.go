@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/cursor"
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+	"r-cli/internal/response"
+)
+
+// maxRetryBackoff caps the delay between two query attempts.
+const maxRetryBackoff = 30 * time.Second
+
+// runQuery runs term like exec.Run, retrying up to --retry times on
+// connection and availability errors. Queries that write are retried only
+// with --retry-writes. Only the initial response is retried; rows already
+// streamed to the output are never fetched again.
+func runQuery(ctx context.Context, exec *query.Executor, cfg *rootConfig, term reql.Term) (json.RawMessage, cursor.Cursor, error) {
+	retries := cfg.retry
+	writes := retries > 0 && !cfg.retryWrites && reql.IsWrite(term)
+	if writes {
+		retries = 0
+	}
+	for attempt := 1; ; attempt++ {
+		profile, cur, err := exec.Run(ctx, term, nil)
+		if err == nil || !retryableQueryError(ctx, err) {
+			return profile, cur, err
+		}
+		if attempt > retries {
+			if writes {
+				cfg.log().Warn("not retrying a write query; use --retry-writes to allow it")
+			}
+			return nil, nil, err
+		}
+		backoff := retryBackoff(cfg.retryBackoff, attempt)
+		cfg.log().Warn("query failed, retrying", "attempt", attempt, "of", retries+1, "error", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return nil, nil, err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// retryableQueryError reports whether err may succeed on another attempt: a
+// lost or refused connection, or a cluster availability error. Query logic
+// and auth errors, and a cancelled or expired ctx, are final.
+func retryableQueryError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var ae *response.ReqlAvailabilityError
+	var ne net.Error
+	return errors.As(err, &ae) || errors.As(err, &ne) || errors.Is(err, conn.ErrClosed) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryBackoff returns the delay before retry n (1-based): base doubled for
+// each earlier retry, capped at maxRetryBackoff, with the upper half jittered
+// so parallel clients do not retry in lockstep.
+func retryBackoff(base time.Duration, n int) time.Duration {
+	d := base
+	for i := 1; i < n && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxRetryBackoff)
+	half := d / 2
+	return half + rand.N(half+1) //nolint:gosec // jitter needs no crypto randomness
+}
+
+// validateRetry checks --retry and --retry-backoff.
+func (c *rootConfig) validateRetry() error {
+	if c.retry < 0 {
+		return fmt.Errorf("--retry: must be >= 0, got %d", c.retry)
+	}
+	if c.retry > 0 && c.retryBackoff <= 0 {
+		return fmt.Errorf("--retry-backoff: must be > 0, got %s", c.retryBackoff)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/connmgr"
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+	"r-cli/internal/response"
+)
+
+// refusingExecutor returns an executor whose every dial fails with a
+// connection refused error, counting the attempts in *dials.
+func refusingExecutor(dials *int) *query.Executor {
+	return query.New(connmgr.New(func(context.Context) (*conn.Conn, error) {
+		*dials++
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}))
+}
+
+func TestRunQueryRetriesReads(t *testing.T) {
+	t.Parallel()
+	var dials int
+	cfg := &rootConfig{retry: 2, retryBackoff: time.Millisecond}
+	_, _, err := runQuery(context.Background(), refusingExecutor(&dials), cfg, reql.DBList())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if dials != 3 {
+		t.Errorf("got %d attempts, want 3", dials)
+	}
+}
+
+func TestRunQueryRetriesReadsWithWriteIDLiterals(t *testing.T) {
+	t.Parallel()
+	// 56 is INSERT and 54 DELETE, here only as numbers
+	read := reql.Array(56, 1).Add(reql.Array(54))
+	var dials int
+	cfg := &rootConfig{retry: 2, retryBackoff: time.Millisecond}
+	_, _, _ = runQuery(context.Background(), refusingExecutor(&dials), cfg, read)
+	if dials != 3 {
+		t.Errorf("got %d attempts, want 3", dials)
+	}
+}
+
+func TestRunQueryNoRetryByDefault(t *testing.T) {
+	t.Parallel()
+	var dials int
+	_, _, _ = runQuery(context.Background(), refusingExecutor(&dials), &rootConfig{}, reql.DBList())
+	if dials != 1 {
+		t.Errorf("got %d attempts, want 1", dials)
+	}
+}
+
+func TestRunQueryWritesNeedOptIn(t *testing.T) {
+	t.Parallel()
+	write := reql.DB("test").Table("t").Insert(map[string]interface{}{"id": 1})
+
+	var dials int
+	cfg := &rootConfig{retry: 2, retryBackoff: time.Millisecond}
+	_, _, _ = runQuery(context.Background(), refusingExecutor(&dials), cfg, write)
+	if dials != 1 {
+		t.Errorf("write without --retry-writes: got %d attempts, want 1", dials)
+	}
+
+	dials = 0
+	cfg.retryWrites = true
+	_, _, _ = runQuery(context.Background(), refusingExecutor(&dials), cfg, write)
+	if dials != 3 {
+		t.Errorf("write with --retry-writes: got %d attempts, want 3", dials)
+	}
+}
+
+func TestRunQueryStopsWhenContextDone(t *testing.T) {
+	t.Parallel()
+	var dials int
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	cfg := &rootConfig{retry: 5, retryBackoff: time.Hour}
+	start := time.Now()
+	if _, _, err := runQuery(ctx, refusingExecutor(&dials), cfg, reql.DBList()); err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("backoff not interrupted by ctx: took %s", elapsed)
+	}
+	if dials != 1 {
+		t.Errorf("got %d attempts, want 1", dials)
+	}
+}
+
+func TestRetryableQueryError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"availability", &response.ReqlAvailabilityError{Msg: "primary replica not available"}, true},
+		{"net error", fmt.Errorf("dial: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), true},
+		{"closed", fmt.Errorf("query: send: %w", conn.ErrClosed), true},
+		{"eof", fmt.Errorf("readLoop: %w", io.EOF), true},
+		{"runtime", &response.ReqlRuntimeError{Msg: "No attribute `x`"}, false},
+		{"auth", fmt.Errorf("dial: %w", conn.ErrReqlAuth), false},
+		{"other", errors.New("query: build: bad term"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := retryableQueryError(context.Background(), tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if retryableQueryError(ctx, conn.ErrClosed) {
+		t.Error("retryable after ctx was cancelled")
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	t.Parallel()
+	base := 500 * time.Millisecond
+	for n, want := range map[int]time.Duration{1: base, 2: 2 * base, 3: 4 * base, 20: maxRetryBackoff} {
+		for range 20 {
+			d := retryBackoff(base, n)
+			if d < want/2 || d > want {
+				t.Fatalf("retryBackoff(%s, %d) = %s, want in [%s, %s]", base, n, d, want/2, want)
+			}
+		}
+	}
+}
+
+func TestValidateRetry(t *testing.T) {
+	t.Parallel()
+	if err := (&rootConfig{retry: -1}).validateRetry(); err == nil {
+		t.Error("negative --retry accepted")
+	}
+	if err := (&rootConfig{retry: 1}).validateRetry(); err == nil {
+		t.Error("zero --retry-backoff accepted with --retry")
+	}
+	if err := (&rootConfig{retry: 1, retryBackoff: time.Second}).validateRetry(); err != nil {
+		t.Error(err)
+	}
+}
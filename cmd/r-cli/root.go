@@ -5,8 +5,8 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -14,7 +14,11 @@ import (
 	"golang.org/x/term"
 
 	"r-cli/internal/conn"
+	"r-cli/internal/output"
+	"r-cli/internal/proto"
 	"r-cli/internal/response"
+	"r-cli/internal/sshtunnel"
+	"r-cli/internal/wire"
 )
 
 // exit codes
@@ -23,27 +27,93 @@ const (
 	exitConnection = 1
 	exitQuery      = 2
 	exitAuth       = 3
+	exitEmpty      = 4 // --fail-on-empty and the query returned nothing
 	exitINT        = 130
 )
 
 type rootConfig struct {
-	host               string
-	port               int
-	database           string
-	user               string
-	password           string
-	passwordFile       string
-	timeout            time.Duration
-	format             string
-	profile            bool
-	timeFormat         string
-	binaryFormat       string
-	quiet              bool
-	verbose            bool
-	tlsCACert          string
-	tlsClientCert      string
-	tlsKey             string
-	insecureSkipVerify bool
+	host                string
+	port                int
+	database            string
+	user                string
+	password            string
+	passwordFile        string
+	passwordStdin       bool
+	passwordPrompt      bool
+	timeout             time.Duration
+	poolSize            int
+	discover            bool
+	discoverInterval    time.Duration
+	keepAlive           time.Duration
+	tcpNoDelay          bool
+	sourceAddr          string
+	protocol            string
+	maxInFlight         int
+	handshakeTimeout    time.Duration
+	maxResponseMB       int
+	prefetch            int
+	maxBufferedRows     int
+	ssh                 string
+	noreply             bool
+	trace               bool
+	sshKey              string
+	sshKnownHosts       string
+	format              string
+	template            string
+	output              string
+	selectSpec          string
+	selectPaths         []selectPath
+	limit               int
+	pageSize            int
+	tableColumns        string
+	maxColWidth         int
+	noTruncate          bool
+	sortBy              string
+	sortPath            selectPath
+	sortDesc            bool
+	unique              string
+	uniquePath          selectPath
+	flattenDepth        int
+	sqliteTable         string
+	color               string
+	pagerOff            bool          // REPL .pager off
+	replTimeout         time.Duration // REPL .set timeout; 0 = none
+	replConfirm         string        // REPL .set confirm: "" asks before destructive queries, "off" does not
+	replPrompt          string        // REPL prompt template with {user}, {host}, {port} and {db}
+	replAutoLimit       int           // REPL: rows a bare table scan is limited to; 0 = off
+	replKeymap          string        // REPL line editing keys: "emacs" or "vi"
+	replContinueOnError bool          // REPL batch run: keep going after a failing query (repl --continue-on-error)
+	compact             bool
+	pretty              bool
+	profile             bool
+	stats               bool
+	failOnEmpty         bool
+	retry               int
+	retryBackoff        time.Duration
+	retryWrites         bool
+	statsJSON           bool
+	timeFormat          string
+	binaryFormat        string
+	binaryDir           string
+	quiet               bool
+	verbose             int
+	logJSON             bool
+	logger              *slog.Logger
+	tlsCACert           string
+	tlsClientCert       string
+	tlsKey              string
+	tlsPins             []string
+	insecureSkipVerify  bool
+	connProfile         string
+	url                 string
+	tlsEnabled          bool // tls=true in the connection URL: TLS with system roots
+
+	// global query optargs; zero values are not sent
+	readMode            string
+	durability          string
+	arrayLimit          int
+	firstBatchScaledown int
+	maxBatchRows        int
 }
 
 // stdinIsTTY reports whether stdin is connected to a terminal; replaceable in tests.
@@ -82,14 +152,11 @@ func buildRootCmd(cfg *rootConfig) *cobra.Command {
 			if p := cmd.Parent(); p != nil && p.Name() == "completion" {
 				return nil
 			}
-			if err := cfg.resolveEnvVars(cmd.Flags().Changed); err != nil {
-				return err
-			}
-			// -p/--password flag takes precedence over --password-file
-			if cmd.Flags().Changed("password") {
+			// config subcommands edit the profiles and must work when one is broken
+			if p := cmd.Parent(); p != nil && p.Name() == "config" && p.Parent() == cmd.Root() {
 				return nil
 			}
-			return cfg.resolvePassword()
+			return cfg.resolve(cmd.Flags().Changed)
 		},
 	}
 	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
@@ -102,8 +169,37 @@ func buildRootCmd(cfg *rootConfig) *cobra.Command {
 	cmd.AddCommand(newUserCmd(cfg))
 	cmd.AddCommand(newGrantCmd(cfg))
 	cmd.AddCommand(newInsertCmd(cfg))
+	cmd.AddCommand(newSeedCmd(cfg))
 	cmd.AddCommand(newStatusCmd(cfg))
+	cmd.AddCommand(newPingCmd(cfg))
+	cmd.AddCommand(newWaitCmd(cfg))
+	cmd.AddCommand(newServerInfoCmd(cfg))
+	cmd.AddCommand(newDBsCmd(cfg))
+	cmd.AddCommand(newTablesCmd(cfg))
+	cmd.AddCommand(newIndexesCmd(cfg))
+	cmd.AddCommand(newGetCmd(cfg))
+	cmd.AddCommand(newCountCmd(cfg))
+	cmd.AddCommand(newDeleteCmd(cfg))
+	cmd.AddCommand(newUpdateCmd(cfg))
+	cmd.AddCommand(newExportCmd(cfg))
+	cmd.AddCommand(newImportCmd(cfg))
+	cmd.AddCommand(newDumpCmd(cfg))
+	cmd.AddCommand(newRestoreCmd(cfg))
+	cmd.AddCommand(newCopyCmd(cfg))
+	cmd.AddCommand(newSchemaCmd(cfg))
+	cmd.AddCommand(newWatchCmd(cfg))
+	cmd.AddCommand(newAdminCmd(cfg))
+	cmd.AddCommand(newClusterStatsCmd(cfg))
+	cmd.AddCommand(newConfigCmd())
+	registerGlobalFlags(cmd, cfg)
+	registerCompletions(cmd, cfg)
+
+	cmd.SetUsageTemplate(withEnvVarsTemplate(cmd))
+	return cmd
+}
 
+// registerGlobalFlags defines the persistent flags shared by all subcommands.
+func registerGlobalFlags(cmd *cobra.Command, cfg *rootConfig) {
 	f := cmd.PersistentFlags()
 	f.StringVarP(&cfg.host, "host", "H", "localhost", "RethinkDB host")
 	f.IntVarP(&cfg.port, "port", "P", 28015, "RethinkDB port")
@@ -111,33 +207,73 @@ func buildRootCmd(cfg *rootConfig) *cobra.Command {
 	f.StringVarP(&cfg.user, "user", "u", "admin", "RethinkDB user")
 	f.StringVarP(&cfg.password, "password", "p", "", "RethinkDB password")
 	f.StringVar(&cfg.passwordFile, "password-file", "", "read password from file")
+	f.BoolVar(&cfg.passwordStdin, "password-stdin", false, "read password from stdin (query must be given as an argument)")
+	f.BoolVar(&cfg.passwordPrompt, passwordPromptFlag, false, "prompt for the password (set by -p without a value)")
+	_ = f.MarkHidden(passwordPromptFlag)
 	f.DurationVarP(&cfg.timeout, "timeout", "t", 30*time.Second, "connection timeout")
-	f.StringVarP(&cfg.format, "format", "f", "", "output format: json, jsonl, raw, table (default: json on TTY, jsonl when piped)")
+	f.IntVar(&cfg.poolSize, "pool-size", 1, "maximum connections per command (export and import use at least --parallel)")
+	f.BoolVar(&cfg.discover, "discover", false, "fail over to cluster members read from rethinkdb.server_status")
+	f.DurationVar(&cfg.discoverInterval, "discover-interval", time.Minute, "how often --discover refreshes the member list (0 = only on connect)")
+	f.DurationVar(&cfg.keepAlive, "keepalive", 0, "TCP keepalive probe interval (0 = 15s, negative disables)")
+	f.BoolVar(&cfg.tcpNoDelay, "tcp-nodelay", true, "set TCP_NODELAY; false leaves Nagle's algorithm on")
+	f.StringVar(&cfg.sourceAddr, "source-addr", "", "local IP (optionally ip:port) to connect from")
+	f.DurationVar(&cfg.handshakeTimeout, "handshake-timeout", 10*time.Second, "limit for each handshake round trip, so an unresponsive server fails before --timeout (0 = only --timeout)")
+	f.IntVar(&cfg.maxResponseMB, "max-response-mb", 64, "largest response frame accepted, in MiB; a bigger result fails its query (1-4095)")
+	f.IntVar(&cfg.prefetch, "prefetch", 1, "result batches requested ahead of the output, overlapping the round trip with formatting (0 = fetch only when needed)")
+	f.IntVar(&cfg.maxBufferedRows, "max-buffered-rows", 0, "stop prefetching while this many result rows wait for output, so a slow consumer delays the server (0 = only --prefetch limits it)")
+	f.IntVar(&cfg.maxInFlight, "max-inflight", 0, "max queries awaiting a response per connection; more wait for a free slot (0 = unlimited)")
+	f.StringVar(&cfg.protocol, "protocol", "auto", "handshake protocol: auto (v1_0, falling back to v0_4 for servers before 2.3 when no password is set), v1_0 or v0_4 (password sent in plaintext as the auth key)")
+	f.StringVar(&cfg.ssh, "ssh", "", "connect through an SSH tunnel: [user@]bastion[:port]")
+	f.StringVar(&cfg.sshKey, "ssh-key", "", "private key for --ssh (default: ssh-agent, then ~/.ssh/id_*)")
+	f.StringVar(&cfg.sshKnownHosts, "ssh-known-hosts", "", "known_hosts file verifying the --ssh server (default ~/.ssh/known_hosts)")
+	f.StringVarP(&cfg.format, "format", "f", "", "output format: json, jsonl, raw, table, tsv, csv, parquet, msgpack, template, sqlite (default: json on TTY, jsonl when piped)")
+	f.StringVar(&cfg.template, "template", "", "Go text/template rendered per row (implies --format template)")
+	f.StringVar(&cfg.selectSpec, "select", "", "comma-separated paths to keep in each result, e.g. 'id,address.city,tags[0]'")
+	f.IntVar(&cfg.limit, "limit", 0, "stop after N rows, closing the cursor (0 = no limit)")
+	f.IntVar(&cfg.pageSize, "page-size", 0, "rows fetched per CONTINUE batch (sent as max_batch_rows)")
+	f.StringVar(&cfg.tableColumns, "columns", "", "table format: comma-separated columns to show, in order")
+	f.IntVar(&cfg.maxColWidth, "max-col-width", 0, "table format: maximum column width (default 50)")
+	f.BoolVar(&cfg.noTruncate, "no-truncate", false, "table format: never truncate values")
+	f.StringVar(&cfg.sortBy, "sort-by", "", "sort rows by a field path, field[:desc]; buffers the whole result")
+	f.StringVar(&cfg.unique, "unique", "", "keep only the first row for each value of a field path")
+	f.IntVar(&cfg.flattenDepth, "flatten-depth", 0, "table format: key levels of nested objects split into address.city columns; deeper objects show as JSON (0 = all, 1 = none)")
+	f.StringVar(&cfg.sqliteTable, "table", "results", "sqlite format: table of the --output database to create or append to")
+	f.StringVar(&cfg.color, "color", "auto", "colorize JSON output: auto, always, never (auto honors NO_COLOR)")
+	f.IntVar(&cfg.replAutoLimit, "auto-limit", 40, "REPL: append .limit(N) to queries that scan a table, or filter one, without a limit (0 = off)")
+	f.StringVar(&cfg.replKeymap, "keymap", "emacs", "REPL line editing keys: emacs, or vi for modal editing")
+	f.StringVar(&cfg.replPrompt, "prompt", "", "REPL prompt with {user}, {host}, {port} and {db} placeholders, e.g. '{user}@{host}/{db}> ' (default \"r> \")")
+	f.BoolVar(&cfg.compact, "compact", false, "print json output without indentation")
+	f.BoolVar(&cfg.pretty, "pretty", false, "print indented json output (selects json format when piped)")
+	f.StringVarP(&cfg.output, "output", "o", "", "write results to file (written atomically; - for stdout)")
 	f.BoolVar(&cfg.profile, "profile", false, "enable query profiling output")
-	f.StringVar(&cfg.timeFormat, "time-format", "native", "time format: native (convert pseudo-types), raw (pass-through)")
-	f.StringVar(&cfg.binaryFormat, "binary-format", "native", "binary format: native (convert pseudo-types), raw (pass-through)")
+	f.BoolVar(&cfg.stats, "stats", false, "print rows, batches, round trips and duration to stderr")
+	f.BoolVar(&cfg.statsJSON, "stats-json", false, "print query stats to stderr as a JSON object")
+	f.BoolVar(&cfg.failOnEmpty, "fail-on-empty", false, "exit with code 4 when the query returns no rows, null, false or []")
+	f.IntVar(&cfg.retry, "retry", 0, "retry a query up to N times on connection and availability errors")
+	f.DurationVar(&cfg.retryBackoff, "retry-backoff", 500*time.Millisecond, "delay before the first retry; doubles per retry (jittered, max 30s)")
+	f.BoolVar(&cfg.retryWrites, "retry-writes", false, "also retry queries that write (may apply a write twice)")
+	f.StringVar(&cfg.timeFormat, "time-format", "native", "time format: native, local, relative, unix-ms, raw (pass-through)")
+	f.StringVar(&cfg.binaryFormat, "binary-format", "native", "binary format: native (convert pseudo-types), files (write to --binary-dir), raw (pass-through)")
+	f.StringVar(&cfg.binaryDir, "binary-dir", "", "directory for BINARY values with --binary-format files")
 	f.BoolVar(&cfg.quiet, "quiet", false, "suppress non-data output to stderr")
-	f.BoolVar(&cfg.verbose, "verbose", false, "show connection info and query timing to stderr")
+	f.CountVarP(&cfg.verbose, "verbose", "v", "log connection lifecycle and query timing to stderr; -vv adds wire frames")
+	f.BoolVar(&cfg.logJSON, "log-json", false, "write stderr logs as JSON objects, one per line")
+	f.BoolVar(&cfg.trace, "trace", false, "print every wire frame (token, type, size, round-trip time, truncated payload) to stderr")
+	f.BoolVar(&cfg.noreply, "noreply", false, "send the query without waiting for its result; exits once the server has applied it")
 	f.StringVar(&cfg.tlsCACert, "tls-cert", "", "path to CA certificate PEM file")
 	f.StringVar(&cfg.tlsClientCert, "tls-client-cert", "", "path to client certificate PEM file")
 	f.StringVar(&cfg.tlsKey, "tls-key", "", "path to client private key PEM file")
+	f.StringArrayVar(&cfg.tlsPins, "tls-pin", nil, "accept only a server certificate whose public key hash matches sha256:<base64|hex>; repeatable")
 	f.BoolVar(&cfg.insecureSkipVerify, "insecure-skip-verify", false, "skip TLS certificate verification (insecure)")
-
-	cmd.SetUsageTemplate(withEnvVarsTemplate(cmd))
-	return cmd
+	f.StringVar(&cfg.url, "url", "", "connection URL rethinkdb://[user[:password]@]host[:port][/db][?tls=true]")
+	f.StringVar(&cfg.connProfile, "conn-profile", "", "connection profile from the config file (see r-cli config)")
+	f.StringVar(&cfg.readMode, "read-mode", "", "read mode: single, majority, outdated (default: server default)")
+	f.StringVar(&cfg.durability, "durability", "", "write durability: hard, soft (default: server default)")
+	f.IntVar(&cfg.arrayLimit, "array-limit", 0, "maximum array size (default: server default)")
+	f.IntVar(&cfg.firstBatchScaledown, "first-batch-scaledown", 0, "first batch scaledown factor (default: server default)")
+	f.IntVar(&cfg.maxBatchRows, "max-batch-rows", 0, "maximum rows per batch (default: server default)")
 }
 
-// envVarsSection is the template block injected into the root command's usage template.
-const envVarsSection = `{{if not .HasParent}}
-
-Environment Variables:
-  RETHINKDB_HOST      override default host
-  RETHINKDB_PORT      override default port
-  RETHINKDB_USER      override default user
-  RETHINKDB_PASSWORD  set password
-  RETHINKDB_DATABASE  set default database
-{{- end}}`
-
 // withEnvVarsTemplate returns a usage template with an env vars section injected
 // before the trailing "Use ... --help" line, visible only on the root command.
 func withEnvVarsTemplate(cmd *cobra.Command) string {
@@ -156,6 +292,9 @@ func exitCode(err error) int {
 	if err == nil {
 		return exitOK
 	}
+	if errors.Is(err, errEmptyResult) {
+		return exitEmpty
+	}
 	if errors.Is(err, conn.ErrReqlAuth) {
 		return exitAuth
 	}
@@ -172,40 +311,243 @@ func isQueryError(err error) bool {
 	var cl *response.ReqlClientError
 	var ne *response.ReqlNonExistenceError
 	var pe *response.ReqlPermissionError
+	var ae *response.ReqlAvailabilityError
+	var ft *wire.FrameTooLargeError
 	return errors.As(err, &qe) || errors.As(err, &c) || errors.As(err, &r) || errors.As(err, &cl) ||
-		errors.As(err, &ne) || errors.As(err, &pe)
+		errors.As(err, &ne) || errors.As(err, &pe) || errors.As(err, &ae) || errors.As(err, &ft)
 }
 
-// resolveEnvVars applies env var values for flags not explicitly set via CLI.
-func (c *rootConfig) resolveEnvVars(changed func(string) bool) error {
-	applyEnvStr(&c.host, changed("host"), "RETHINKDB_HOST")
-	applyEnvStr(&c.user, changed("user"), "RETHINKDB_USER")
-	applyEnvStr(&c.password, changed("password"), "RETHINKDB_PASSWORD")
-	applyEnvStr(&c.database, changed("db"), "RETHINKDB_DATABASE")
-	if !changed("port") {
-		if v := os.Getenv("RETHINKDB_PORT"); v != "" {
-			n, err := strconv.Atoi(v)
-			if err != nil {
-				return fmt.Errorf("RETHINKDB_PORT %q: not a valid port number", v)
-			}
-			c.port = n
+// resolve applies the connection profile, env vars and connection URL, validates flag values and loads the password;
+// changed reports whether a flag was set explicitly on the command line.
+func (c *rootConfig) resolve(changed func(string) bool) error {
+	c.logger = newLogger(os.Stderr, c.verbose, c.quiet, c.logJSON)
+	if err := c.resolveProfile(changed); err != nil {
+		return err
+	}
+	if err := c.resolveEnvVars(changed); err != nil {
+		return err
+	}
+	if err := c.resolveURL(changed); err != nil {
+		return err
+	}
+	if err := c.validateGlobalOptArgs(); err != nil {
+		return err
+	}
+	if err := c.validateRetry(); err != nil {
+		return err
+	}
+	if err := c.validateConnOpts(changed); err != nil {
+		return err
+	}
+	if err := c.validatePseudoFormats(); err != nil {
+		return err
+	}
+	if err := c.validateREPL(); err != nil {
+		return err
+	}
+	if err := c.resolveFormat(); err != nil {
+		return err
+	}
+	if c.selectSpec != "" {
+		paths, err := parseSelectPaths(c.selectSpec)
+		if err != nil {
+			return err
+		}
+		c.selectPaths = paths
+	}
+	if c.sortBy != "" {
+		path, desc, err := parseSortSpec(c.sortBy)
+		if err != nil {
+			return err
+		}
+		c.sortPath, c.sortDesc = path, desc
+	}
+	if c.unique != "" {
+		path, err := parseFieldPath(c.unique)
+		if err != nil {
+			return fmt.Errorf("--unique: %w", err)
 		}
+		c.uniquePath = path
+	}
+	if err := c.checkPasswordSources(changed); err != nil {
+		return err
+	}
+	// -p/--password flag takes precedence over --password-file
+	if changed("password") {
+		return nil
+	}
+	return c.resolvePassword()
+}
+
+// validateConnLimits checks the pool size, the per-connection limits and the
+// cursor buffer flags.
+func (c *rootConfig) validateConnLimits(changed func(string) bool) error {
+	if changed("pool-size") && c.poolSize < 1 {
+		return fmt.Errorf("--pool-size must be >= 1")
+	}
+	if c.maxInFlight < 0 {
+		return fmt.Errorf("--max-inflight must be >= 0")
+	}
+	if c.prefetch < 0 || c.maxBufferedRows < 0 {
+		return fmt.Errorf("--prefetch and --max-buffered-rows must be >= 0")
+	}
+	if changed("max-response-mb") && (c.maxResponseMB < 1 || c.maxResponseMB > maxResponseMB) {
+		return fmt.Errorf("--max-response-mb must be between 1 and %d", maxResponseMB)
 	}
 	return nil
 }
 
-// applyEnvStr sets *dst to the env var value when the flag was not explicitly set.
-func applyEnvStr(dst *string, flagChanged bool, key string) {
-	if flagChanged {
-		return
+// validateConnOpts checks the connection limits, --protocol and the --ssh flags.
+func (c *rootConfig) validateConnOpts(changed func(string) bool) error {
+	if err := c.validateConnLimits(changed); err != nil {
+		return err
 	}
-	if v := os.Getenv(key); v != "" {
-		*dst = v
+	if _, ok := protocolVersions[c.protocol]; !ok && c.protocol != "" {
+		return fmt.Errorf("--protocol: invalid value %q, must be auto, v1_0, or v0_4", c.protocol)
 	}
+	if c.ssh == "" {
+		if c.sshKey != "" || c.sshKnownHosts != "" {
+			return fmt.Errorf("--ssh-key and --ssh-known-hosts require --ssh")
+		}
+		return nil
+	}
+	if _, _, err := sshtunnel.ParseTarget(c.ssh); err != nil {
+		return fmt.Errorf("--ssh: %w", err)
+	}
+	return nil
 }
 
-// resolvePassword loads the password from --password-file if set.
+// maxResponseMB is the largest --max-response-mb that fits the 32-bit
+// frame length.
+const maxResponseMB = 4095
+
+// protocolVersions maps --protocol values to conn.Config.Protocol; auto is
+// zero, which falls back from V1_0 to V0_4.
+var protocolVersions = map[string]proto.Version{"auto": 0, "v1_0": proto.V1_0, "v0_4": proto.V0_4}
+
+// validateGlobalOptArgs checks the values of the global optarg flags.
+func (c *rootConfig) validateGlobalOptArgs() error {
+	switch c.readMode {
+	case "", "single", "majority", "outdated":
+	default:
+		return fmt.Errorf("--read-mode: invalid value %q, must be single, majority, or outdated", c.readMode)
+	}
+	switch c.durability {
+	case "", "hard", "soft":
+	default:
+		return fmt.Errorf("--durability: invalid value %q, must be hard or soft", c.durability)
+	}
+	if c.arrayLimit < 0 || c.firstBatchScaledown < 0 || c.maxBatchRows < 0 {
+		return fmt.Errorf("--array-limit, --first-batch-scaledown and --max-batch-rows must be >= 0")
+	}
+	if c.limit < 0 || c.pageSize < 0 {
+		return fmt.Errorf("--limit and --page-size must be >= 0")
+	}
+	if c.pageSize > 0 && c.maxBatchRows > 0 {
+		return fmt.Errorf("--page-size and --max-batch-rows are mutually exclusive")
+	}
+	return nil
+}
+
+// validatePseudoFormats checks --time-format and --binary-format.
+func (c *rootConfig) validatePseudoFormats() error {
+	switch c.timeFormat {
+	case "", "native", "local", "relative", "unix-ms", "raw":
+	default:
+		return fmt.Errorf("--time-format: invalid value %q, must be native, local, relative, unix-ms, or raw", c.timeFormat)
+	}
+	switch c.binaryFormat {
+	case "", "native", "raw":
+	case "files":
+		if c.binaryDir == "" {
+			return fmt.Errorf("--binary-format files requires --binary-dir")
+		}
+	default:
+		return fmt.Errorf("--binary-format: invalid value %q, must be native, files, or raw", c.binaryFormat)
+	}
+	if c.binaryDir != "" && c.binaryFormat != "files" {
+		return fmt.Errorf("--binary-dir requires --binary-format files")
+	}
+	return nil
+}
+
+// validateREPL checks --keymap and --auto-limit.
+func (c *rootConfig) validateREPL() error {
+	switch c.replKeymap {
+	case "", "emacs", "vi":
+	default:
+		return fmt.Errorf("--keymap: invalid value %q, must be emacs or vi", c.replKeymap)
+	}
+	if c.replAutoLimit < 0 {
+		return fmt.Errorf("--auto-limit must be >= 0")
+	}
+	return nil
+}
+
+// resolveFormat validates format-specific flags; --template alone selects the
+// template format and --pretty alone selects json.
+func (c *rootConfig) resolveFormat() error {
+	switch c.color {
+	case "", "auto", "always", "never":
+	default:
+		return fmt.Errorf("--color: invalid value %q, must be auto, always, or never", c.color)
+	}
+	if c.maxColWidth < 0 {
+		return fmt.Errorf("--max-col-width must be >= 0")
+	}
+	if c.flattenDepth < 0 {
+		return fmt.Errorf("--flatten-depth must be >= 0")
+	}
+	if c.compact && c.pretty {
+		return fmt.Errorf("--compact and --pretty are mutually exclusive")
+	}
+	if c.format == "sqlite" {
+		if c.output == "" || c.output == "-" {
+			return fmt.Errorf("--format sqlite requires --output <file>")
+		}
+		if c.sqliteTable == "" {
+			return fmt.Errorf("--table must not be empty")
+		}
+	}
+	if c.pretty && c.format == "" && c.template == "" {
+		c.format = "json"
+	}
+	return c.resolveTemplate()
+}
+
+// resolveTemplate validates --template and applies it as the output format.
+func (c *rootConfig) resolveTemplate() error {
+	if c.template == "" {
+		if c.format == "template" {
+			return fmt.Errorf("--format template requires --template")
+		}
+		return nil
+	}
+	if c.format == "" {
+		c.format = "template"
+	}
+	if c.format != "template" {
+		return fmt.Errorf("--template cannot be used with --format %s", c.format)
+	}
+	_, err := output.ParseTemplate(c.template)
+	return err
+}
+
+// resolvePassword loads the password from stdin, a terminal prompt or
+// --password-file, whichever was requested.
 func (c *rootConfig) resolvePassword() error {
+	if c.passwordStdin || c.passwordPrompt {
+		read := promptLoginPassword
+		if c.passwordStdin {
+			read = func() (string, error) { return readPasswordStdin(os.Stdin) }
+		}
+		pwd, err := read()
+		if err != nil {
+			return err
+		}
+		c.password = pwd
+		return nil
+	}
 	if c.passwordFile == "" {
 		return nil
 	}
@@ -219,12 +561,21 @@ func (c *rootConfig) resolvePassword() error {
 
 // buildTLSConfig returns a *tls.Config built from TLS flags, or nil for plain TCP.
 func (c *rootConfig) buildTLSConfig() (*tls.Config, error) {
-	if c.tlsCACert == "" && c.tlsClientCert == "" && c.tlsKey == "" && !c.insecureSkipVerify {
+	if !c.tlsEnabled && c.tlsCACert == "" && c.tlsClientCert == "" && c.tlsKey == "" && !c.insecureSkipVerify && len(c.tlsPins) == 0 {
 		return nil, nil
 	}
 	tlsCfg := &tls.Config{
 		InsecureSkipVerify: c.insecureSkipVerify, //nolint:gosec
 	}
+	if len(c.tlsPins) > 0 {
+		pins, err := parseTLSPins(c.tlsPins)
+		if err != nil {
+			return nil, err
+		}
+		// a pin replaces CA verification unless a CA is given as well
+		tlsCfg.InsecureSkipVerify = tlsCfg.InsecureSkipVerify || c.tlsCACert == "" //nolint:gosec
+		tlsCfg.VerifyPeerCertificate = verifyTLSPins(pins)
+	}
 	if c.tlsCACert != "" {
 		pool, err := loadCACert(c.tlsCACert)
 		if err != nil {
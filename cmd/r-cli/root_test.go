@@ -18,7 +18,9 @@ import (
 	"time"
 
 	"r-cli/internal/conn"
+	"r-cli/internal/proto"
 	"r-cli/internal/response"
+	"r-cli/internal/wire"
 )
 
 func TestRootHostDefault(t *testing.T) {
@@ -412,12 +414,116 @@ func TestQuietFlagDefault(t *testing.T) {
 func TestVerboseFlagDefault(t *testing.T) {
 	t.Parallel()
 	cmd := newRootCmd()
-	v, err := cmd.PersistentFlags().GetBool("verbose")
+	v, err := cmd.PersistentFlags().GetCount("verbose")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if v {
-		t.Error("verbose flag: expected false by default")
+	if v != 0 {
+		t.Errorf("verbose flag: got %d, want 0 by default", v)
+	}
+}
+
+func TestDiscoverFlagDefaults(t *testing.T) {
+	t.Parallel()
+	f := newRootCmd().PersistentFlags()
+	if on, _ := f.GetBool("discover"); on {
+		t.Error("--discover should be off by default")
+	}
+	if d, _ := f.GetDuration("discover-interval"); d != time.Minute {
+		t.Errorf("--discover-interval: got %v, want 1m", d)
+	}
+}
+
+func TestDialFlagDefaults(t *testing.T) {
+	t.Parallel()
+	f := newRootCmd().PersistentFlags()
+	if d, _ := f.GetDuration("keepalive"); d != 0 {
+		t.Errorf("--keepalive: got %v, want 0 (Go default)", d)
+	}
+	if on, _ := f.GetBool("tcp-nodelay"); !on {
+		t.Error("--tcp-nodelay should be on by default")
+	}
+	if s, _ := f.GetString("source-addr"); s != "" {
+		t.Errorf("--source-addr: got %q, want empty", s)
+	}
+	if d, _ := f.GetDuration("handshake-timeout"); d != 10*time.Second {
+		t.Errorf("--handshake-timeout: got %v, want 10s", d)
+	}
+	if n, _ := f.GetInt("prefetch"); n != 1 {
+		t.Errorf("--prefetch: got %d, want 1", n)
+	}
+	if n, _ := f.GetInt("max-buffered-rows"); n != 0 {
+		t.Errorf("--max-buffered-rows: got %d, want 0", n)
+	}
+}
+
+func TestNoreplyFlag(t *testing.T) {
+	t.Parallel()
+	cmd := newRootCmd()
+	if on, _ := cmd.PersistentFlags().GetBool("noreply"); on {
+		t.Error("--noreply should be off by default")
+	}
+	if err := cmd.PersistentFlags().Parse([]string{"--noreply"}); err != nil {
+		t.Fatal(err)
+	}
+	if on, _ := cmd.PersistentFlags().GetBool("noreply"); !on {
+		t.Error("--noreply not set")
+	}
+}
+
+func TestResolveConnFlags(t *testing.T) {
+	t.Parallel()
+	never := func(string) bool { return false }
+	for _, p := range []string{"", "auto", "v1_0", "v0_4"} {
+		if err := (&rootConfig{protocol: p}).resolve(never); err != nil {
+			t.Errorf("--protocol %q: %v", p, err)
+		}
+	}
+	if err := (&rootConfig{protocol: "v0_3"}).resolve(never); err == nil || !strings.Contains(err.Error(), `--protocol: invalid value "v0_3"`) {
+		t.Errorf("--protocol v0_3: got %v", err)
+	}
+	if err := (&rootConfig{prefetch: -1}).resolve(never); err == nil || !strings.Contains(err.Error(), "--prefetch") {
+		t.Errorf("--prefetch -1: got %v", err)
+	}
+	if err := (&rootConfig{maxBufferedRows: -1}).resolve(never); err == nil || !strings.Contains(err.Error(), "--max-buffered-rows") {
+		t.Errorf("--max-buffered-rows -1: got %v", err)
+	}
+	if err := (&rootConfig{maxInFlight: -1}).resolve(never); err == nil || !strings.Contains(err.Error(), "--max-inflight") {
+		t.Errorf("--max-inflight -1: got %v", err)
+	}
+	maxResp := func(name string) bool { return name == "max-response-mb" }
+	for _, mb := range []int{0, 4096} {
+		if err := (&rootConfig{maxResponseMB: mb}).resolve(maxResp); err == nil || !strings.Contains(err.Error(), "--max-response-mb") {
+			t.Errorf("--max-response-mb %d: got %v", mb, err)
+		}
+	}
+	if v := protocolVersions["v0_4"]; v != proto.V0_4 {
+		t.Errorf("v0_4 maps to 0x%08x", uint32(v))
+	}
+}
+
+func TestResolveSSHFlags(t *testing.T) {
+	t.Parallel()
+	never := func(string) bool { return false }
+	if err := (&rootConfig{sshKey: "id"}).resolve(never); err == nil || !strings.Contains(err.Error(), "require --ssh") {
+		t.Errorf("--ssh-key without --ssh: got %v", err)
+	}
+	if err := (&rootConfig{ssh: "ops@"}).resolve(never); err == nil || !strings.Contains(err.Error(), `--ssh: missing host in "ops@"`) {
+		t.Errorf("bad --ssh: got %v", err)
+	}
+	if err := (&rootConfig{ssh: "ops@bastion:2222", sshKey: "id"}).resolve(never); err != nil {
+		t.Errorf("valid --ssh: %v", err)
+	}
+}
+
+func TestResolvePoolSize(t *testing.T) {
+	t.Parallel()
+	changed := func(name string) bool { return name == "pool-size" }
+	if err := (&rootConfig{poolSize: 0}).resolve(changed); err == nil {
+		t.Error("--pool-size 0 accepted")
+	}
+	if err := (&rootConfig{poolSize: 4}).resolve(changed); err != nil {
+		t.Errorf("--pool-size 4: %v", err)
 	}
 }
 
@@ -447,6 +553,7 @@ func TestExitCodeQuery(t *testing.T) {
 		{"client", &response.ReqlClientError{Msg: "client error"}},
 		{"nonexistence", &response.ReqlNonExistenceError{Msg: "not found"}},
 		{"permission", &response.ReqlPermissionError{Msg: "permission denied"}},
+		{"too large", fmt.Errorf("query: send: %w", &wire.FrameTooLargeError{Token: 1, Size: 1 << 27, Limit: 1 << 26})},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -466,6 +573,14 @@ func TestExitCodeAuth(t *testing.T) {
 	}
 }
 
+func TestExitCodeEmpty(t *testing.T) {
+	t.Parallel()
+	err := fmt.Errorf("wrapped: %w", errEmptyResult)
+	if code := exitCode(err); code != exitEmpty {
+		t.Errorf("exitCode(empty): got %d, want %d", code, exitEmpty)
+	}
+}
+
 func TestSIGINTExitConstant(t *testing.T) {
 	t.Parallel()
 	if exitINT != 130 {
@@ -767,3 +882,207 @@ func TestPasswordFlagUsageNoEnvMention(t *testing.T) {
 		t.Errorf("--password flag usage should not mention RETHINKDB_PASSWORD, got: %q", f.Usage)
 	}
 }
+
+func TestValidateGlobalOptArgs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		cfg     rootConfig
+		wantErr bool
+	}{
+		{"empty", rootConfig{}, false},
+		{"read mode single", rootConfig{readMode: "single"}, false},
+		{"read mode majority", rootConfig{readMode: "majority"}, false},
+		{"read mode outdated", rootConfig{readMode: "outdated"}, false},
+		{"read mode invalid", rootConfig{readMode: "fast"}, true},
+		{"durability hard", rootConfig{durability: "hard"}, false},
+		{"durability soft", rootConfig{durability: "soft"}, false},
+		{"durability invalid", rootConfig{durability: "medium"}, true},
+		{"negative array limit", rootConfig{arrayLimit: -1}, true},
+		{"negative scaledown", rootConfig{firstBatchScaledown: -1}, true},
+		{"negative batch rows", rootConfig{maxBatchRows: -1}, true},
+		{"limit and page size", rootConfig{limit: 10, pageSize: 5}, false},
+		{"negative limit", rootConfig{limit: -1}, true},
+		{"negative page size", rootConfig{pageSize: -1}, true},
+		{"page size with max batch rows", rootConfig{pageSize: 5, maxBatchRows: 5}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.cfg.validateGlobalOptArgs()
+			if tc.wantErr && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestGlobalOptArgFlags(t *testing.T) {
+	t.Parallel()
+	cmd := newRootCmd()
+	args := []string{
+		"--read-mode", "outdated", "--durability", "soft", "--array-limit", "1000",
+		"--first-batch-scaledown", "4", "--max-batch-rows", "50",
+	}
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatal(err)
+	}
+	f := cmd.PersistentFlags()
+	if v, _ := f.GetString("read-mode"); v != "outdated" {
+		t.Errorf("read-mode: got %q, want %q", v, "outdated")
+	}
+	if v, _ := f.GetString("durability"); v != "soft" {
+		t.Errorf("durability: got %q, want %q", v, "soft")
+	}
+	if v, _ := f.GetInt("array-limit"); v != 1000 {
+		t.Errorf("array-limit: got %d, want 1000", v)
+	}
+	if v, _ := f.GetInt("first-batch-scaledown"); v != 4 {
+		t.Errorf("first-batch-scaledown: got %d, want 4", v)
+	}
+	if v, _ := f.GetInt("max-batch-rows"); v != 50 {
+		t.Errorf("max-batch-rows: got %d, want 50", v)
+	}
+}
+
+func TestOutputFlagShorthand(t *testing.T) {
+	t.Parallel()
+	cmd := newRootCmd()
+	if v, _ := cmd.PersistentFlags().GetString("output"); v != "" {
+		t.Errorf("output default: got %q, want empty", v)
+	}
+	if err := cmd.ParseFlags([]string{"-o", "out.json"}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := cmd.PersistentFlags().GetString("output"); v != "out.json" {
+		t.Errorf("-o: got %q, want %q", v, "out.json")
+	}
+}
+
+func TestResolveFormat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		format     string
+		template   string
+		wantFormat string
+		wantErr    bool
+	}{
+		{"no template", "json", "", "json", false},
+		{"template implies format", "", "{{.id}}", "template", false},
+		{"explicit template format", "template", "{{.id}}", "template", false},
+		{"template format without template", "template", "", "", true},
+		{"template with other format", "json", "{{.id}}", "", true},
+		{"invalid template", "", "{{.id", "", true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &rootConfig{format: tc.format, template: tc.template}
+			err := cfg.resolveFormat()
+			if tc.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.format != tc.wantFormat {
+				t.Errorf("format: got %q, want %q", cfg.format, tc.wantFormat)
+			}
+		})
+	}
+}
+
+func TestResolveFormatColorAndPretty(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		cfg        rootConfig
+		wantFormat string
+		wantErr    bool
+	}{
+		{"color auto", rootConfig{color: "auto"}, "", false},
+		{"color always", rootConfig{color: "always"}, "", false},
+		{"color never", rootConfig{color: "never"}, "", false},
+		{"color invalid", rootConfig{color: "sometimes"}, "", true},
+		{"compact and pretty", rootConfig{compact: true, pretty: true}, "", true},
+		{"pretty selects json", rootConfig{pretty: true}, "json", false},
+		{"pretty keeps explicit format", rootConfig{pretty: true, format: "jsonl"}, "jsonl", false},
+		{"compact keeps auto format", rootConfig{compact: true}, "", false},
+		{"negative max col width", rootConfig{maxColWidth: -1}, "", true},
+		{"negative flatten depth", rootConfig{flattenDepth: -1}, "", true},
+		{"sqlite needs output", rootConfig{format: "sqlite", sqliteTable: "results"}, "", true},
+		{"sqlite to stdout", rootConfig{format: "sqlite", output: "-", sqliteTable: "results"}, "", true},
+		{"sqlite empty table", rootConfig{format: "sqlite", output: "data.db"}, "", true},
+		{"sqlite to file", rootConfig{format: "sqlite", output: "data.db", sqliteTable: "results"}, "sqlite", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := tc.cfg
+			err := cfg.resolveFormat()
+			if tc.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.format != tc.wantFormat {
+				t.Errorf("format: got %q, want %q", cfg.format, tc.wantFormat)
+			}
+		})
+	}
+}
+
+func TestValidatePseudoFormats(t *testing.T) {
+	t.Parallel()
+	for _, tf := range []string{"", "native", "local", "relative", "unix-ms", "raw"} {
+		cfg := rootConfig{timeFormat: tf, binaryFormat: "native"}
+		if err := cfg.validatePseudoFormats(); err != nil {
+			t.Errorf("--time-format %q: unexpected error: %v", tf, err)
+		}
+	}
+	if err := (&rootConfig{timeFormat: "iso"}).validatePseudoFormats(); err == nil {
+		t.Error("--time-format iso: expected error, got nil")
+	}
+	if err := (&rootConfig{binaryFormat: "hex"}).validatePseudoFormats(); err == nil {
+		t.Error("--binary-format hex: expected error, got nil")
+	}
+}
+
+func TestValidateREPL(t *testing.T) {
+	t.Parallel()
+	for _, km := range []string{"", "emacs", "vi"} {
+		if err := (&rootConfig{replKeymap: km}).validateREPL(); err != nil {
+			t.Errorf("--keymap %q: unexpected error: %v", km, err)
+		}
+	}
+	if err := (&rootConfig{replKeymap: "nano"}).validateREPL(); err == nil {
+		t.Error("--keymap nano: expected error, got nil")
+	}
+	if err := (&rootConfig{replAutoLimit: -1}).validateREPL(); err == nil {
+		t.Error("--auto-limit -1: expected error, got nil")
+	}
+}
+
+func TestValidateBinaryDir(t *testing.T) {
+	t.Parallel()
+	if err := (&rootConfig{binaryFormat: "files", binaryDir: "blobs"}).validatePseudoFormats(); err != nil {
+		t.Errorf("files with dir: unexpected error: %v", err)
+	}
+	if err := (&rootConfig{binaryFormat: "files"}).validatePseudoFormats(); err == nil {
+		t.Error("files without --binary-dir: expected error, got nil")
+	}
+	if err := (&rootConfig{binaryFormat: "native", binaryDir: "blobs"}).validatePseudoFormats(); err == nil {
+		t.Error("--binary-dir without files: expected error, got nil")
+	}
+}
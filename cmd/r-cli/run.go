@@ -7,16 +7,18 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"r-cli/internal/conn"
 	"r-cli/internal/connmgr"
+	"r-cli/internal/cursor"
 	"r-cli/internal/output"
 	"r-cli/internal/query"
 	"r-cli/internal/reql"
-	"r-cli/internal/response"
+	"r-cli/internal/sshtunnel"
 )
 
 func newRunCmd(cfg *rootConfig) *cobra.Command {
@@ -56,41 +58,127 @@ func readTerm(args []string, stdin io.Reader) ([]byte, error) {
 }
 
 // newExecutor creates a connection manager and query executor from the given config.
-// The returned cleanup func must be called to close the manager.
+// The returned cleanup func must be called to stop any cursor still open
+// and close the manager.
 func newExecutor(cfg *rootConfig) (*query.Executor, func(), error) {
+	return newPoolExecutor(cfg, cfg.poolSize)
+}
+
+// newPoolExecutor is newExecutor with a pool of up to size connections, for
+// commands that run queries in parallel.
+func newPoolExecutor(cfg *rootConfig, size int) (*query.Executor, func(), error) {
+	mgr, cleanup, err := newConnManager(cfg, size)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	exec := query.New(mgr)
+	exec.SetDefaults(buildQueryOpts(cfg))
+	exec.SetCursorOptions(cfg.cursorOptions())
+	return exec, func() { exec.CloseCursors(); cleanup() }, nil
+}
+
+// cursorOptions returns how result cursors prefetch and buffer batches.
+func (c *rootConfig) cursorOptions() cursor.Options {
+	return cursor.Options{Prefetch: c.prefetch, MaxBufferedRows: c.maxBufferedRows}
+}
+
+// outputCursorOptions are cursorOptions for queries whose rows are printed:
+// pseudo-types are converted as --time-format and --binary-format select.
+// Commands that decode rows themselves, and the msgpack format, which
+// encodes them as extension types, keep the server's pseudo-types.
+func (c *rootConfig) outputCursorOptions() cursor.Options {
+	opts := c.cursorOptions()
+	if c.format == "msgpack" {
+		return opts
+	}
+	opts.Convert = cursor.Conversion{Time: c.timeFormat, Binary: c.binaryFormat, BinaryDir: c.binaryDir}
+	return opts
+}
+
+// commandContext bounds ctx by --timeout, for commands that do a bounded
+// amount of work: queries, admin and status commands, ping and wait. The
+// bulk commands insert, export, import, copy and seed, and watch, run for as long
+// as the data or the feed takes and use ctx as is. Every command connects
+// within --timeout, which newConnManager sets as conn.Config.ConnectTimeout.
+func (c *rootConfig) commandContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// newConnManager builds a manager of up to size connections that re-dials
+// dropped connections with the logging reconnect policy. With --ssh every
+// connection is dialed through one SSH tunnel. The returned cleanup func
+// closes the manager and the tunnel.
+func newConnManager(cfg *rootConfig, size int) (*connmgr.ConnManager, func(), error) {
 	tlsCfg, err := cfg.buildTLSConfig()
 	if err != nil {
 		return nil, func() {}, err
 	}
-	mgr := connmgr.NewFromConfig(conn.Config{
-		Host:     cfg.host,
-		Port:     cfg.port,
-		User:     cfg.user,
-		Password: cfg.password,
-	}, tlsCfg)
-	return query.New(mgr), func() { _ = mgr.Close() }, nil
+	opts := conn.DialOptions{
+		KeepAlive: cfg.keepAlive,
+		Nagle:     !cfg.tcpNoDelay,
+		LocalAddr: cfg.sourceAddr,
+	}
+	closeTunnel := func() {}
+	if cfg.ssh != "" {
+		tun, err := sshtunnel.New(sshtunnel.Config{
+			Target:     cfg.ssh,
+			KeyFile:    cfg.sshKey,
+			KnownHosts: cfg.sshKnownHosts,
+			Logger:     cfg.logger,
+		})
+		if err != nil {
+			return nil, func() {}, err
+		}
+		opts.Dial = tun.DialContext
+		closeTunnel = func() { _ = tun.Close() }
+	}
+	mgr := connmgr.NewPoolFromConfig(conn.Config{
+		Host:                 cfg.host,
+		Port:                 cfg.port,
+		User:                 cfg.user,
+		Password:             cfg.password,
+		Logger:               cfg.logger,
+		Tracer:               cfg.tracer(os.Stderr),
+		Protocol:             protocolVersions[cfg.protocol],
+		MaxInFlight:          cfg.maxInFlight,
+		MaxResponseSize:      uint32(cfg.maxResponseMB) << 20, //nolint:gosec // validated to 0..4095
+		HandshakeStepTimeout: cfg.handshakeTimeout,
+		ConnectTimeout:       cfg.timeout,
+		DialOptions:          opts,
+	}, tlsCfg, size)
+	mgr.SetReconnect(reconnectPolicy(cfg.log()))
+	if cfg.discover {
+		mgr.EnableDiscovery(cfg.discoverInterval)
+	}
+	return mgr, func() { _ = mgr.Close(); closeTunnel() }, nil
 }
 
 // execTerm builds a connection, runs the given ReQL term, and writes output.
 func execTerm(ctx context.Context, cfg *rootConfig, term reql.Term, w io.Writer) error {
-	if cfg.timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
-		defer cancel()
-	}
+	return execTermWith(ctx, cfg, term, w, nil)
+}
 
-	if cfg.verbose && !cfg.quiet {
-		_, _ = fmt.Fprintf(os.Stderr, "connecting to %s:%d\n", cfg.host, cfg.port)
-	}
+// execTermWith is execTerm with an optional wrap applied to the cursor rows
+// before --limit and --select.
+func execTermWith(ctx context.Context, cfg *rootConfig, term reql.Term, w io.Writer, wrap func(output.RowIterator) output.RowIterator) error {
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
 
 	exec, cleanup, err := newExecutor(cfg)
 	if err != nil {
 		return err
 	}
 	defer cleanup()
+	exec.SetCursorOptions(cfg.outputCursorOptions())
+	if cfg.noreply {
+		return runNoreply(ctx, exec, term)
+	}
 
 	start := time.Now()
-	profile, cur, err := exec.Run(ctx, term, buildQueryOpts(cfg))
+	profile, cur, err := runQuery(ctx, exec, cfg, term)
 	if err != nil {
 		return err
 	}
@@ -100,10 +188,36 @@ func execTerm(ctx context.Context, cfg *rootConfig, term reql.Term, w io.Writer)
 	}
 	defer func() { _ = cur.Close() }()
 
-	return writeOutput(w, output.DetectFormat(os.Stdout, cfg.format), makeIter(cur, cfg))
+	out, err := openResultTarget(cfg, w)
+	if err != nil {
+		return err
+	}
+	var rows output.RowIterator = cur
+	if wrap != nil {
+		rows = wrap(rows)
+	}
+	iter, checkEmpty := withEmptyCheck(makeIter(rows, cfg), cfg)
+	iter, reportStats := withStats(iter, cur, cfg, start)
+	err = out.finish(writeOutput(out, out.format(cfg.format), iter, cfg))
+	reportStats()
+	if err != nil {
+		return err
+	}
+	return checkEmpty()
+}
+
+// runNoreply sends term without asking for a response, then blocks on
+// NOREPLY_WAIT so the process does not exit before the server has applied
+// it. Errors in the query itself are not reported.
+func runNoreply(ctx context.Context, exec *query.Executor, term reql.Term) error {
+	if _, _, err := exec.Run(ctx, term, reql.OptArgs{"noreply": true}); err != nil {
+		return err
+	}
+	return exec.NoreplyWait(ctx)
 }
 
-// buildQueryOpts constructs the ReQL query options from the root config.
+// buildQueryOpts constructs the global optargs set as executor defaults, and
+// so sent with every query.
 func buildQueryOpts(cfg *rootConfig) reql.OptArgs {
 	opts := reql.OptArgs{}
 	if cfg.database != "" {
@@ -112,114 +226,132 @@ func buildQueryOpts(cfg *rootConfig) reql.OptArgs {
 	if cfg.profile {
 		opts["profile"] = true
 	}
+	addGlobalOptArgs(opts, cfg)
 	return opts
 }
 
-// writeQueryMeta writes verbose timing and profile data to stderr.
-func writeQueryMeta(cfg *rootConfig, profile json.RawMessage, elapsed time.Duration) {
-	if cfg.verbose && !cfg.quiet {
-		_, _ = fmt.Fprintf(os.Stderr, "query time: %v\n", elapsed)
+// addGlobalOptArgs copies the global optarg flags that were set into opts.
+func addGlobalOptArgs(opts reql.OptArgs, cfg *rootConfig) {
+	if cfg.readMode != "" {
+		opts["read_mode"] = cfg.readMode
 	}
-	if cfg.profile && !cfg.quiet && len(profile) > 0 {
-		_, _ = fmt.Fprintf(os.Stderr, "profile: %s\n", profile)
+	if cfg.durability != "" {
+		opts["durability"] = cfg.durability
 	}
-}
-
-// makeIter wraps cur in a convertingIter when pseudo-type conversion is requested.
-func makeIter(cur output.RowIterator, cfg *rootConfig) output.RowIterator {
-	if cfg.timeFormat == "native" || cfg.binaryFormat == "native" {
-		return &convertingIter{
-			inner:         cur,
-			convertTime:   cfg.timeFormat == "native",
-			convertBinary: cfg.binaryFormat == "native",
-		}
+	if cfg.arrayLimit > 0 {
+		opts["array_limit"] = cfg.arrayLimit
+	}
+	if cfg.firstBatchScaledown > 0 {
+		opts["first_batch_scaledown_factor"] = cfg.firstBatchScaledown
+	}
+	if cfg.maxBatchRows > 0 {
+		opts["max_batch_rows"] = cfg.maxBatchRows
+	}
+	if cfg.pageSize > 0 {
+		opts["max_batch_rows"] = cfg.pageSize
 	}
-	return cur
-}
-
-// convertingIter wraps a RowIterator, applying selective pseudo-type conversion to each row.
-type convertingIter struct {
-	inner         output.RowIterator
-	convertTime   bool
-	convertBinary bool
 }
 
-func (c *convertingIter) Next() (json.RawMessage, error) {
-	raw, err := c.inner.Next()
-	if err != nil {
-		return nil, err
+// writeQueryMeta logs the query time and writes profile data to stderr.
+func writeQueryMeta(cfg *rootConfig, profile json.RawMessage, elapsed time.Duration) {
+	cfg.log().Info("query done", "duration", elapsed)
+	if cfg.profile && !cfg.quiet && len(profile) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "profile: %s\n", profile)
 	}
-	return convertRow(raw, c.convertTime, c.convertBinary), nil
 }
 
-// convertRow applies selective pseudo-type conversion to raw JSON.
-// Returns raw unchanged on any error or when no conversion is needed.
-func convertRow(raw json.RawMessage, convertTime, convertBinary bool) json.RawMessage {
-	if !convertTime && !convertBinary {
-		return raw
+// makeIter wraps cur in a sortIter for --sort-by, a uniqueIter for
+// --unique, a limitIter when --limit is set and a selectIter when --select
+// paths are set, in that order, so --limit counts sorted, distinct rows.
+// Pseudo-types are converted by the cursor, see
+// rootConfig.outputCursorOptions.
+func makeIter(cur output.RowIterator, cfg *rootConfig) output.RowIterator {
+	iter := cur
+	if cfg.sortBy != "" {
+		iter = &sortIter{inner: iter, path: cfg.sortPath, desc: cfg.sortDesc}
 	}
-	var v interface{}
-	if json.Unmarshal(raw, &v) != nil {
-		return raw
+	if cfg.unique != "" {
+		iter = &uniqueIter{inner: iter, path: cfg.uniquePath}
 	}
-	out, err := json.Marshal(selectiveConvert(v, convertTime, convertBinary))
-	if err != nil {
-		return raw
+	if cfg.limit > 0 {
+		iter = &limitIter{inner: iter, limit: cfg.limit}
+	}
+	if len(cfg.selectPaths) > 0 {
+		iter = &selectIter{inner: iter, paths: cfg.selectPaths}
 	}
-	return out
+	return iter
 }
 
-// selectiveConvert recursively converts TIME and/or BINARY pseudo-types based on flags.
-func selectiveConvert(v interface{}, convertTime, convertBinary bool) interface{} {
-	if convertTime && convertBinary {
-		return response.ConvertPseudoTypes(v)
+// tableOptions builds the table layout from the table format flags.
+func (c *rootConfig) tableOptions() output.TableOptions {
+	opts := output.TableOptions{
+		MaxColWidth: c.maxColWidth,
+		NoTruncate:  c.noTruncate,
+		Depth:       c.flattenDepth,
 	}
-	switch val := v.(type) {
-	case map[string]interface{}:
-		return convertMap(val, convertTime, convertBinary)
-	case []interface{}:
-		out := make([]interface{}, len(val))
-		for i, item := range val {
-			out[i] = selectiveConvert(item, convertTime, convertBinary)
+	for _, col := range strings.Split(c.tableColumns, ",") {
+		if col = strings.TrimSpace(col); col != "" {
+			opts.Columns = append(opts.Columns, col)
 		}
-		return out
 	}
-	return v
+	return opts
 }
 
-// convertMap handles pseudo-type detection and selective conversion for map values.
-func convertMap(m map[string]interface{}, convertTime, convertBinary bool) interface{} {
-	reqlType, isReql := m["$reql_type$"].(string)
-	if isReql {
-		switch reqlType {
-		case "TIME":
-			if convertTime {
-				return response.ConvertPseudoTypes(m)
-			}
-			return m
-		case "BINARY":
-			if convertBinary {
-				return response.ConvertPseudoTypes(m)
-			}
-			return m
+// writeOutput renders iter in the given format; cfg supplies format-specific options.
+func writeOutput(w io.Writer, format string, iter output.RowIterator, cfg *rootConfig) error {
+	switch format {
+	case "", "json", "jsonl":
+		return writeJSONOutput(w, format, iter, cfg)
+	case "raw":
+		return output.Raw(w, iter)
+	case "table":
+		return output.TableWithOptions(w, iter, cfg.tableOptions())
+	case "tsv":
+		return output.TSV(w, iter)
+	case "csv":
+		return output.CSV(w, iter)
+	case "parquet":
+		return output.Parquet(w, iter)
+	case "msgpack":
+		return output.MsgPack(w, iter)
+	case "sqlite":
+		table, ok := sqliteTableOf(w)
+		if !ok {
+			return fmt.Errorf("--format sqlite requires --output <file>")
 		}
+		return output.SQLite(w, iter, table)
+	case "template":
+		if cfg.template == "" {
+			return fmt.Errorf("template format requires --template")
+		}
+		return output.Template(w, iter, cfg.template)
+	default:
+		return writeJSONOutput(w, "json", iter, cfg)
 	}
-	out := make(map[string]interface{}, len(m))
-	for k, item := range m {
-		out[k] = selectiveConvert(item, convertTime, convertBinary)
-	}
-	return out
 }
 
-func writeOutput(w io.Writer, format string, iter output.RowIterator) error {
-	switch format {
-	case "jsonl":
+// writeJSONOutput writes json or jsonl output, colorized when --color allows it.
+func writeJSONOutput(w io.Writer, format string, iter output.RowIterator, cfg *rootConfig) error {
+	if colorEnabled(w, cfg.color) {
+		w = output.NewColorWriter(w)
+	}
+	switch {
+	case format == "jsonl":
 		return output.JSONL(w, iter)
-	case "raw":
-		return output.Raw(w, iter)
-	case "table":
-		return output.Table(w, iter)
+	case cfg.compact:
+		return output.JSONCompact(w, iter)
 	default:
 		return output.JSON(w, iter)
 	}
 }
+
+// colorEnabled resolves the --color mode against the underlying destination of w.
+func colorEnabled(w io.Writer, mode string) bool {
+	switch o := w.(type) {
+	case *outputTarget:
+		w = o.Writer
+	case *pagerWriter:
+		w = o.out
+	}
+	return output.UseColor(mode, w)
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"io"
 	"strings"
 	"testing"
 	"time"
+
+	"r-cli/internal/cursor"
 )
 
 // stubIter is a minimal RowIterator for testing writeOutput.
@@ -35,6 +37,8 @@ func TestWriteOutput(t *testing.T) {
 		{"json", func(s string) bool { return strings.Contains(s, `"key"`) }},
 		{"jsonl", func(s string) bool { return strings.TrimSpace(s) == `{"key":"val"}` }},
 		{"raw", func(s string) bool { return strings.Contains(s, "val") }},
+		{"tsv", func(s string) bool { return s == "key\nval\n" }},
+		{"template", func(s string) bool { return s == "val!\n" }},
 		{"unknown", func(s string) bool { return strings.Contains(s, `"key"`) }}, // falls to default JSON
 		{"", func(s string) bool { return strings.Contains(s, `"key"`) }},        // falls to default JSON
 	}
@@ -43,7 +47,7 @@ func TestWriteOutput(t *testing.T) {
 			t.Parallel()
 			var buf bytes.Buffer
 			iter := &stubIter{rows: []json.RawMessage{row}}
-			if err := writeOutput(&buf, tc.format, iter); err != nil {
+			if err := writeOutput(&buf, tc.format, iter, &rootConfig{template: "{{.key}}!"}); err != nil {
 				t.Fatalf("writeOutput(%q): %v", tc.format, err)
 			}
 			if !tc.check(buf.String()) {
@@ -130,93 +134,179 @@ func TestRunCmdUsage(t *testing.T) {
 	t.Error("run subcommand not found")
 }
 
-func TestConvertingIterTimePseudoType(t *testing.T) {
+func TestOutputCursorOptions(t *testing.T) {
 	t.Parallel()
-	raw := json.RawMessage(`{"$reql_type$":"TIME","epoch_time":0,"timezone":"+00:00"}`)
-	iter := &convertingIter{inner: &stubIter{rows: []json.RawMessage{raw}}, convertTime: true, convertBinary: true}
-	got, err := iter.Next()
-	if err != nil {
-		t.Fatal(err)
+	cfg := &rootConfig{prefetch: 2, timeFormat: "relative", binaryFormat: "files", binaryDir: "blobs"}
+	opts := cfg.outputCursorOptions()
+	want := cursor.Conversion{Time: "relative", Binary: "files", BinaryDir: "blobs"}
+	if opts.Prefetch != 2 || opts.Convert.Time != want.Time || opts.Convert.Binary != want.Binary || opts.Convert.BinaryDir != want.BinaryDir {
+		t.Errorf("outputCursorOptions = %+v", opts)
 	}
-	var parsed time.Time
-	if jsonErr := json.Unmarshal(got, &parsed); jsonErr != nil {
-		t.Fatalf("expected time.Time JSON, got %q: %v", got, jsonErr)
+	if conv := cfg.cursorOptions().Convert; conv.Time != "" || conv.Binary != "" {
+		t.Errorf("cursorOptions converts pseudo-types: %+v", conv)
 	}
-	if parsed.UTC() != time.Unix(0, 0).UTC() {
-		t.Errorf("got %v, want unix epoch", parsed)
+	cfg.format = "msgpack"
+	if conv := cfg.outputCursorOptions().Convert; conv.Time != "" || conv.Binary != "" {
+		t.Errorf("msgpack converts pseudo-types: %+v", conv)
 	}
 }
 
-func TestConvertingIterBinaryPseudoType(t *testing.T) {
+func TestBuildQueryOptsGlobalOptArgs(t *testing.T) {
 	t.Parallel()
-	// "aGVsbG8=" is base64 for "hello"
-	raw := json.RawMessage(`{"$reql_type$":"BINARY","data":"aGVsbG8="}`)
-	iter := &convertingIter{inner: &stubIter{rows: []json.RawMessage{raw}}, convertTime: true, convertBinary: true}
-	got, err := iter.Next()
-	if err != nil {
-		t.Fatal(err)
+	cfg := &rootConfig{
+		database:            "test",
+		readMode:            "majority",
+		durability:          "soft",
+		arrayLimit:          500000,
+		firstBatchScaledown: 2,
+		maxBatchRows:        100,
+	}
+	opts := buildQueryOpts(cfg)
+	want := map[string]interface{}{
+		"db":                           "test",
+		"read_mode":                    "majority",
+		"durability":                   "soft",
+		"array_limit":                  500000,
+		"first_batch_scaledown_factor": 2,
+		"max_batch_rows":               100,
 	}
-	// []byte marshals to base64 JSON string
-	if string(got) != `"aGVsbG8="` {
-		t.Errorf("got %q, want base64 string", got)
+	if len(opts) != len(want) {
+		t.Fatalf("got %d opts, want %d: %v", len(opts), len(want), opts)
+	}
+	for k, v := range want {
+		if opts[k] != v {
+			t.Errorf("opts[%q]: got %v, want %v", k, opts[k], v)
+		}
+	}
+}
+
+func TestBuildQueryOptsOmitsUnsetOptArgs(t *testing.T) {
+	t.Parallel()
+	opts := buildQueryOpts(&rootConfig{})
+	if len(opts) != 0 {
+		t.Errorf("expected no opts for zero config, got %v", opts)
 	}
 }
 
-func TestConvertingIterPassthrough(t *testing.T) {
+func TestMakeIterAppliesSelect(t *testing.T) {
 	t.Parallel()
-	raw := json.RawMessage(`{"key":"value"}`)
-	iter := &convertingIter{inner: &stubIter{rows: []json.RawMessage{raw}}, convertTime: true, convertBinary: true}
-	got, err := iter.Next()
+	paths, err := parseSelectPaths("id,address.city")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if string(got) != `{"key":"value"}` {
-		t.Errorf("got %q, want original JSON", got)
+	cfg := &rootConfig{timeFormat: "raw", binaryFormat: "raw", selectPaths: paths}
+	inner := &stubIter{rows: []json.RawMessage{json.RawMessage(`{"id":1,"address":{"city":"NYC","zip":"1"},"x":true}`)}}
+	row, err := makeIter(inner, cfg).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(row) != `{"id":1,"address.city":"NYC"}` {
+		t.Errorf("got %s", row)
 	}
 }
 
-func TestConvertingIterTimeRawPassthrough(t *testing.T) {
+func TestWriteOutputCompactJSON(t *testing.T) {
 	t.Parallel()
-	// time-format raw: TIME pseudo-type should not be converted
-	raw := json.RawMessage(`{"$reql_type$":"TIME","epoch_time":0,"timezone":"+00:00"}`)
-	iter := &convertingIter{inner: &stubIter{rows: []json.RawMessage{raw}}, convertTime: false, convertBinary: true}
-	got, err := iter.Next()
-	if err != nil {
+	var buf bytes.Buffer
+	iter := &stubIter{rows: []json.RawMessage{json.RawMessage(`{"key": "val"}`)}}
+	if err := writeOutput(&buf, "json", iter, &rootConfig{compact: true}); err != nil {
 		t.Fatal(err)
 	}
-	// should remain as raw JSON object, not a time string
-	var m map[string]interface{}
-	if jsonErr := json.Unmarshal(got, &m); jsonErr != nil {
-		t.Fatalf("expected raw object, got %q: %v", got, jsonErr)
+	if buf.String() != "{\"key\":\"val\"}\n" {
+		t.Errorf("got %q", buf.String())
 	}
-	if m["$reql_type$"] != "TIME" {
-		t.Errorf("TIME pseudo-type should pass through when convertTime=false, got %q", got)
+}
+
+func TestWriteOutputColor(t *testing.T) {
+	t.Parallel()
+	row := json.RawMessage(`{"key":"val"}`)
+	for _, format := range []string{"json", "jsonl"} {
+		var always, never bytes.Buffer
+		if err := writeOutput(&always, format, &stubIter{rows: []json.RawMessage{row}}, &rootConfig{color: "always"}); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(always.String(), "\x1b[") {
+			t.Errorf("%s with --color always: expected ANSI codes, got %q", format, always.String())
+		}
+		if err := writeOutput(&never, format, &stubIter{rows: []json.RawMessage{row}}, &rootConfig{color: "never"}); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(never.String(), "\x1b[") {
+			t.Errorf("%s with --color never: unexpected ANSI codes: %q", format, never.String())
+		}
 	}
 }
 
-func TestConvertingIterBinaryRawPassthrough(t *testing.T) {
+func TestWriteOutputColorSkipsNonJSONFormats(t *testing.T) {
 	t.Parallel()
-	// binary-format raw: BINARY pseudo-type should not be converted
-	raw := json.RawMessage(`{"$reql_type$":"BINARY","data":"aGVsbG8="}`)
-	iter := &convertingIter{inner: &stubIter{rows: []json.RawMessage{raw}}, convertTime: true, convertBinary: false}
-	got, err := iter.Next()
-	if err != nil {
+	var buf bytes.Buffer
+	iter := &stubIter{rows: []json.RawMessage{json.RawMessage(`"val"`)}}
+	if err := writeOutput(&buf, "raw", iter, &rootConfig{color: "always"}); err != nil {
 		t.Fatal(err)
 	}
-	var m map[string]interface{}
-	if jsonErr := json.Unmarshal(got, &m); jsonErr != nil {
-		t.Fatalf("expected raw object, got %q: %v", got, jsonErr)
+	if buf.String() != "val\n" {
+		t.Errorf("got %q, want %q", buf.String(), "val\n")
+	}
+}
+
+func TestBuildQueryOptsPageSize(t *testing.T) {
+	t.Parallel()
+	opts := buildQueryOpts(&rootConfig{pageSize: 25})
+	if opts["max_batch_rows"] != 25 {
+		t.Errorf("max_batch_rows: got %v, want 25", opts["max_batch_rows"])
+	}
+}
+
+func TestCommandContext(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := (&rootConfig{timeout: time.Minute}).commandContext(context.Background())
+	defer cancel()
+	if d, ok := ctx.Deadline(); !ok || time.Until(d) > time.Minute {
+		t.Errorf("deadline = %v, %v; want within a minute", d, ok)
+	}
+	ctx, cancel = (&rootConfig{}).commandContext(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("deadline set without --timeout")
+	}
+}
+
+func TestTableOptions(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{tableColumns: "name, age,,", maxColWidth: 10, noTruncate: true, flattenDepth: 2}
+	got := cfg.tableOptions()
+	if strings.Join(got.Columns, ",") != "name,age" {
+		t.Errorf("columns: got %v, want [name age]", got.Columns)
+	}
+	if got.MaxColWidth != 10 || !got.NoTruncate || got.Depth != 2 {
+		t.Errorf("got %+v", got)
+	}
+	if cols := (&rootConfig{}).tableOptions().Columns; cols != nil {
+		t.Errorf("empty --columns: got %v, want nil", cols)
+	}
+}
+
+func TestWriteOutputTableColumns(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	iter := &stubIter{rows: []json.RawMessage{json.RawMessage(`{"a":1,"b":2}`)}}
+	if err := writeOutput(&buf, "table", iter, &rootConfig{tableColumns: "b"}); err != nil {
+		t.Fatal(err)
 	}
-	if m["$reql_type$"] != "BINARY" {
-		t.Errorf("BINARY pseudo-type should pass through when convertBinary=false, got %q", got)
+	if strings.Contains(buf.String(), "a") {
+		t.Errorf("column a should be hidden: %q", buf.String())
 	}
 }
 
-func TestConvertingIterEOF(t *testing.T) {
+func TestNewConnManagerPassesDialOptions(t *testing.T) {
 	t.Parallel()
-	iter := &convertingIter{inner: &stubIter{rows: nil}}
-	_, err := iter.Next()
-	if !errors.Is(err, io.EOF) {
-		t.Errorf("got %v, want io.EOF", err)
+	cfg := &rootConfig{host: "127.0.0.1", port: 1, sourceAddr: "bad host.invalid", tcpNoDelay: true}
+	mgr, cleanup, err := newConnManager(cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if _, err := mgr.Get(context.Background()); err == nil || !strings.Contains(err.Error(), `source address "bad host.invalid"`) {
+		t.Errorf("got %v, want source address error", err)
 	}
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+)
+
+// schemaDoc is the declarative description written by schema export and read
+// by schema apply.
+type schemaDoc struct {
+	Databases []schemaDB `json:"databases" yaml:"databases"`
+}
+
+type schemaDB struct {
+	Name   string        `json:"name" yaml:"name"`
+	Tables []schemaTable `json:"tables" yaml:"tables"`
+}
+
+type schemaTable struct {
+	Name       string        `json:"name" yaml:"name"`
+	PrimaryKey string        `json:"primary_key" yaml:"primary_key"`
+	Durability string        `json:"durability,omitempty" yaml:"durability,omitempty"`
+	Indexes    []schemaIndex `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+}
+
+// schemaIndex is a secondary index. Function is the base64 index function
+// from indexStatus(); Query is its readable form and is informational only.
+type schemaIndex struct {
+	Name     string `json:"name" yaml:"name"`
+	Function string `json:"function" yaml:"function"`
+	Geo      bool   `json:"geo,omitempty" yaml:"geo,omitempty"`
+	Multi    bool   `json:"multi,omitempty" yaml:"multi,omitempty"`
+	Query    string `json:"query,omitempty" yaml:"query,omitempty"`
+}
+
+func newSchemaCmd(cfg *rootConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Export or apply database, table and index definitions",
+	}
+	cmd.AddCommand(
+		newSchemaExportCmd(cfg),
+		newSchemaApplyCmd(cfg),
+	)
+	return cmd
+}
+
+func newSchemaExportCmd(cfg *rootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export",
+		Short: "Print the schema of --db, or of all databases, as YAML (or JSON with -f json)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runSchemaExport(cmd.Context(), cfg, os.Stdout)
+		},
+	}
+}
+
+// schemaFormat maps --format to yaml (default) or json.
+func schemaFormat(flagFormat string) (string, error) {
+	switch flagFormat {
+	case "", "yaml":
+		return "yaml", nil
+	case "json":
+		return "json", nil
+	}
+	return "", fmt.Errorf("schema: unsupported format %q: use yaml or json", flagFormat)
+}
+
+func runSchemaExport(ctx context.Context, cfg *rootConfig, w io.Writer) error {
+	format, err := schemaFormat(cfg.format)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var only []string
+	if cfg.database != "" {
+		only = []string{cfg.database}
+	}
+	doc, err := readClusterSchema(ctx, exec, cfg, only)
+	if err != nil {
+		return fmt.Errorf("schema: %w", err)
+	}
+	data, err := marshalSchema(doc, format)
+	if err != nil {
+		return err
+	}
+	out, err := openOutputTarget(cfg.output, w)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return out.finish(err)
+}
+
+// marshalSchema renders doc as two-space indented YAML or JSON.
+func marshalSchema(doc *schemaDoc, format string) ([]byte, error) {
+	if format == "json" {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		return append(data, '\n'), err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+// parseSchema reads a YAML or JSON schema document (JSON is valid YAML).
+func parseSchema(data []byte) (*schemaDoc, error) {
+	var doc schemaDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("schema: parsing: %w", err)
+	}
+	return &doc, nil
+}
+
+// readClusterSchema describes the databases named in only, or all databases
+// except the rethinkdb system database when only is nil. Databases in only
+// that do not exist are left out.
+func readClusterSchema(ctx context.Context, exec *query.Executor, cfg *rootConfig, only []string) (*schemaDoc, error) {
+	names, err := fetchNames(ctx, exec, cfg, reql.DBList())
+	if err != nil {
+		return nil, err
+	}
+	doc := &schemaDoc{Databases: []schemaDB{}}
+	for _, name := range names {
+		if name == "rethinkdb" || (only != nil && !slices.Contains(only, name)) {
+			continue
+		}
+		db := schemaDB{Name: name, Tables: []schemaTable{}}
+		tables, err := fetchNames(ctx, exec, cfg, reql.DB(name).TableList())
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range tables {
+			t, err := readTableSchema(ctx, exec, cfg, name, table)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", name, table, err)
+			}
+			db.Tables = append(db.Tables, t)
+		}
+		doc.Databases = append(doc.Databases, db)
+	}
+	return doc, nil
+}
+
+// readTableSchema reads primary key and durability from config() and index
+// definitions from indexStatus().
+func readTableSchema(ctx context.Context, exec *query.Executor, cfg *rootConfig, db, table string) (schemaTable, error) {
+	tbl := reql.DB(db).Table(table)
+	t := schemaTable{Name: table}
+	var conf struct {
+		PrimaryKey string `json:"primary_key"`
+		Durability string `json:"durability"`
+	}
+	if err := fetchValue(ctx, exec, cfg, tbl.Config(), &conf); err != nil {
+		return t, err
+	}
+	t.PrimaryKey, t.Durability = conf.PrimaryKey, conf.Durability
+
+	var status []struct {
+		Index    string `json:"index"`
+		Geo      bool   `json:"geo"`
+		Multi    bool   `json:"multi"`
+		Query    string `json:"query"`
+		Function struct {
+			Data string `json:"data"`
+		} `json:"function"`
+	}
+	if err := fetchValue(ctx, exec, cfg, tbl.IndexStatus(), &status); err != nil {
+		return t, err
+	}
+	for _, s := range status {
+		t.Indexes = append(t.Indexes, schemaIndex{
+			Name: s.Index, Function: s.Function.Data, Geo: s.Geo, Multi: s.Multi, Query: s.Query,
+		})
+	}
+	slices.SortFunc(t.Indexes, func(a, b schemaIndex) int { return strings.Compare(a.Name, b.Name) })
+	return t, nil
+}
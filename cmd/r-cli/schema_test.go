@@ -0,0 +1,155 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaCmdSubcommands(t *testing.T) {
+	t.Parallel()
+	cmd := newSchemaCmd(&rootConfig{})
+	found := map[string]bool{}
+	for _, sub := range cmd.Commands() {
+		found[sub.Name()] = true
+	}
+	for _, name := range []string{"export", "apply"} {
+		if !found[name] {
+			t.Errorf("schema %s subcommand not registered", name)
+		}
+	}
+}
+
+func TestSchemaFormat(t *testing.T) {
+	t.Parallel()
+	for flag, want := range map[string]string{"": "yaml", "yaml": "yaml", "json": "json"} {
+		got, err := schemaFormat(flag)
+		if err != nil || got != want {
+			t.Errorf("%q: got %q, %v; want %q", flag, got, err, want)
+		}
+	}
+	if _, err := schemaFormat("table"); err == nil {
+		t.Error("expected error for table format")
+	}
+}
+
+func testSchemaDoc() *schemaDoc {
+	return &schemaDoc{Databases: []schemaDB{{
+		Name: "app",
+		Tables: []schemaTable{{
+			Name: "users", PrimaryKey: "id", Durability: "hard",
+			Indexes: []schemaIndex{{Name: "tags", Function: "AAEC", Multi: true}},
+		}},
+	}}}
+}
+
+func TestMarshalParseSchemaRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, format := range []string{"yaml", "json"} {
+		data, err := marshalSchema(testSchemaDoc(), format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, err := parseSchema(data)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", format, err, data)
+		}
+		idx := doc.Databases[0].Tables[0].Indexes[0]
+		if idx.Name != "tags" || idx.Function != "AAEC" || !idx.Multi {
+			t.Errorf("%s: round trip lost data: %+v", format, doc)
+		}
+	}
+}
+
+func TestMarshalSchemaYAMLIndent(t *testing.T) {
+	t.Parallel()
+	data, err := marshalSchema(testSchemaDoc(), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "databases:\n  - name: app\n") {
+		t.Errorf("unexpected YAML:\n%s", data)
+	}
+}
+
+func planDescs(changes []schemaChange) []string {
+	descs := make([]string, len(changes))
+	for i, c := range changes {
+		descs[i] = c.desc
+	}
+	return descs
+}
+
+func TestDiffSchemaCreatesMissing(t *testing.T) {
+	t.Parallel()
+	changes, err := diffSchema(testSchemaDoc(), &schemaDoc{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"+ database app", "+ table app.users (primary key id)", "+ index app.users.tags"}
+	if got := planDescs(changes); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if hasDrops(changes) {
+		t.Error("create-only plan should have no drops")
+	}
+}
+
+func TestDiffSchemaUpToDate(t *testing.T) {
+	t.Parallel()
+	changes, err := diffSchema(testSchemaDoc(), testSchemaDoc(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %q", planDescs(changes))
+	}
+}
+
+func TestDiffSchemaModifies(t *testing.T) {
+	t.Parallel()
+	have := testSchemaDoc()
+	tbl := &have.Databases[0].Tables[0]
+	tbl.Durability = "soft"
+	tbl.Indexes = []schemaIndex{{Name: "tags", Function: "OLD"}, {Name: "stale", Function: "AA"}}
+	have.Databases[0].Tables = append(have.Databases[0].Tables, schemaTable{Name: "extra", PrimaryKey: "id"})
+
+	changes, err := diffSchema(testSchemaDoc(), have, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"~ table app.users durability soft -> hard",
+		"~ index app.users.tags (drop)",
+		"~ index app.users.tags (create)",
+		"- index app.users.stale",
+		"- table app.extra",
+	}
+	if got := planDescs(changes); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !hasDrops(changes) {
+		t.Error("expected drops in plan")
+	}
+}
+
+func TestDiffSchemaNoPruneKeepsTables(t *testing.T) {
+	t.Parallel()
+	have := testSchemaDoc()
+	have.Databases[0].Tables = append(have.Databases[0].Tables, schemaTable{Name: "extra", PrimaryKey: "id"})
+	changes, err := diffSchema(testSchemaDoc(), have, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes without --prune, got %q", planDescs(changes))
+	}
+}
+
+func TestDiffSchemaPrimaryKeyMismatch(t *testing.T) {
+	t.Parallel()
+	have := testSchemaDoc()
+	have.Databases[0].Tables[0].PrimaryKey = "email"
+	if _, err := diffSchema(testSchemaDoc(), have, false); err == nil {
+		t.Error("expected error for primary key change")
+	}
+}
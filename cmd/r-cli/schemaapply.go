@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/reql"
+)
+
+type schemaApplyConfig struct {
+	dryRun bool
+	prune  bool
+	yes    bool
+}
+
+// schemaChange is one step of an apply plan. desc is printed as the plan
+// line: "+" creates, "-" drops, "~" modifies.
+type schemaChange struct {
+	desc string
+	drop bool
+	term reql.Term
+}
+
+func newSchemaApplyCmd(cfg *rootConfig) *cobra.Command {
+	sa := &schemaApplyConfig{}
+	cmd := &cobra.Command{
+		Use:   "apply <file|->",
+		Short: "Create, modify and drop objects so the cluster matches a schema file",
+		Long: "Compare a YAML or JSON schema (from schema export) with the cluster and apply\n" +
+			"the difference: missing databases, tables and indexes are created, durability\n" +
+			"is updated, changed indexes are recreated and unlisted indexes of listed tables\n" +
+			"are dropped. With --prune, unlisted tables of listed databases are dropped too.\n" +
+			"Plans containing drops ask for confirmation unless --yes.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src, closer, err := openSchemaSource(args[0], os.Stdin)
+			if err != nil {
+				return err
+			}
+			defer closer()
+			return runSchemaApply(cmd.Context(), cfg, sa, src, os.Stdout)
+		},
+	}
+	f := cmd.Flags()
+	f.BoolVar(&sa.dryRun, "dry-run", false, "print the plan without applying it")
+	f.BoolVar(&sa.prune, "prune", false, "drop tables of listed databases that the schema does not list")
+	f.BoolVarP(&sa.yes, "yes", "y", false, "skip confirmation prompt for drops")
+	return cmd
+}
+
+// openSchemaSource opens path, or stdin for "-".
+func openSchemaSource(path string, stdin io.Reader) (io.Reader, func(), error) {
+	if path == "-" {
+		return stdin, func() {}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("schema: %w", err)
+	}
+	return f, func() { _ = f.Close() }, nil
+}
+
+func runSchemaApply(ctx context.Context, cfg *rootConfig, sa *schemaApplyConfig, r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("schema: reading: %w", err)
+	}
+	want, err := parseSchema(data)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	only := make([]string, 0, len(want.Databases))
+	for _, db := range want.Databases {
+		only = append(only, db.Name)
+	}
+	have, err := readClusterSchema(ctx, exec, cfg, only)
+	if err != nil {
+		return fmt.Errorf("schema: %w", err)
+	}
+	changes, err := diffSchema(want, have, sa.prune)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(w, "schema is up to date")
+		return nil
+	}
+	for _, c := range changes {
+		_, _ = fmt.Fprintln(w, c.desc)
+	}
+	if sa.dryRun {
+		return nil
+	}
+	if hasDrops(changes) && !sa.yes {
+		if err := confirm(fmt.Sprintf("Apply %d changes including drops?", len(changes)), os.Stdin, cfg.quiet); err != nil {
+			return err
+		}
+	}
+	for _, c := range changes {
+		if err := runWrite(ctx, exec, cfg, c.term); err != nil {
+			return fmt.Errorf("schema: %s: %w", c.desc, err)
+		}
+	}
+	return nil
+}
+
+func hasDrops(changes []schemaChange) bool {
+	for _, c := range changes {
+		if c.drop {
+			return true
+		}
+	}
+	return false
+}
+
+// diffSchema returns the changes that turn have into want. Primary keys cannot
+// be changed in place, so a mismatch is an error rather than a plan step.
+func diffSchema(want, have *schemaDoc, prune bool) ([]schemaChange, error) {
+	haveDBs := make(map[string]schemaDB, len(have.Databases))
+	for _, db := range have.Databases {
+		haveDBs[db.Name] = db
+	}
+	var changes []schemaChange
+	for _, db := range want.Databases {
+		cur, exists := haveDBs[db.Name]
+		if !exists {
+			changes = append(changes, schemaChange{desc: "+ database " + db.Name, term: reql.DBCreate(db.Name)})
+		}
+		dbChanges, err := diffTables(db, cur, prune)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, dbChanges...)
+	}
+	return changes, nil
+}
+
+// diffTables compares the tables of one database.
+func diffTables(want, have schemaDB, prune bool) ([]schemaChange, error) {
+	haveTables := make(map[string]schemaTable, len(have.Tables))
+	for _, t := range have.Tables {
+		haveTables[t.Name] = t
+	}
+	var changes []schemaChange
+	listed := make(map[string]bool, len(want.Tables))
+	for _, t := range want.Tables {
+		listed[t.Name] = true
+		name := want.Name + "." + t.Name
+		cur, exists := haveTables[t.Name]
+		if !exists {
+			changes = append(changes, createTableChange(want.Name, t))
+			changes = append(changes, diffIndexes(name, reql.DB(want.Name).Table(t.Name), t.Indexes, nil)...)
+			continue
+		}
+		if t.PrimaryKey != "" && t.PrimaryKey != cur.PrimaryKey {
+			return nil, fmt.Errorf("schema: %s: primary key %q cannot be changed to %q", name, cur.PrimaryKey, t.PrimaryKey)
+		}
+		tbl := reql.DB(want.Name).Table(t.Name)
+		if t.Durability != "" && t.Durability != cur.Durability {
+			changes = append(changes, schemaChange{
+				desc: fmt.Sprintf("~ table %s durability %s -> %s", name, cur.Durability, t.Durability),
+				term: tbl.Config().Update(map[string]interface{}{"durability": t.Durability}),
+			})
+		}
+		changes = append(changes, diffIndexes(name, tbl, t.Indexes, cur.Indexes)...)
+	}
+	if prune {
+		for _, t := range have.Tables {
+			if !listed[t.Name] {
+				changes = append(changes, schemaChange{
+					desc: "- table " + want.Name + "." + t.Name,
+					drop: true,
+					term: reql.DB(want.Name).TableDrop(t.Name),
+				})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func createTableChange(db string, t schemaTable) schemaChange {
+	opts := reql.OptArgs{}
+	desc := "+ table " + db + "." + t.Name
+	if t.PrimaryKey != "" {
+		opts["primary_key"] = t.PrimaryKey
+		desc += " (primary key " + t.PrimaryKey + ")"
+	}
+	if t.Durability != "" {
+		opts["durability"] = t.Durability
+	}
+	return schemaChange{desc: desc, term: reql.DB(db).TableCreate(t.Name, opts)}
+}
+
+// diffIndexes creates missing indexes, recreates indexes whose definition
+// differs and drops indexes that are not wanted.
+func diffIndexes(table string, tbl reql.Term, want, have []schemaIndex) []schemaChange {
+	haveIdx := make(map[string]schemaIndex, len(have))
+	for _, idx := range have {
+		haveIdx[idx.Name] = idx
+	}
+	var changes []schemaChange
+	wanted := make(map[string]bool, len(want))
+	for _, idx := range want {
+		wanted[idx.Name] = true
+		cur, exists := haveIdx[idx.Name]
+		switch {
+		case !exists:
+			changes = append(changes, createIndexChange("+", table, tbl, idx))
+		case cur.Function != idx.Function || cur.Geo != idx.Geo || cur.Multi != idx.Multi:
+			changes = append(changes,
+				schemaChange{desc: "~ index " + table + "." + idx.Name + " (drop)", drop: true, term: tbl.IndexDrop(idx.Name)},
+				createIndexChange("~", table, tbl, idx))
+		}
+	}
+	for _, idx := range have {
+		if !wanted[idx.Name] {
+			changes = append(changes, schemaChange{desc: "- index " + table + "." + idx.Name, drop: true, term: tbl.IndexDrop(idx.Name)})
+		}
+	}
+	return changes
+}
+
+func createIndexChange(mark, table string, tbl reql.Term, idx schemaIndex) schemaChange {
+	opts := reql.OptArgs{}
+	if idx.Geo {
+		opts["geo"] = true
+	}
+	if idx.Multi {
+		opts["multi"] = true
+	}
+	desc := mark + " index " + table + "." + idx.Name
+	if mark == "~" {
+		desc += " (create)"
+	}
+	term := tbl.IndexCreate(idx.Name, opts)
+	if idx.Function != "" {
+		fn := map[string]interface{}{"$reql_type$": "BINARY", "data": idx.Function}
+		term = tbl.IndexCreateFunc(idx.Name, fn, opts)
+	}
+	return schemaChange{desc: desc, term: term}
+}
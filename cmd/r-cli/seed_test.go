@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"r-cli/internal/reql"
+)
+
+func TestSeedCmdFlags(t *testing.T) {
+	t.Parallel()
+	cmd := newSeedCmd(&rootConfig{})
+	for _, name := range []string{"count", "template", "seed", "dry-run", "batch-size", "conflict", "durability"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("missing flag --%s", name)
+		}
+	}
+}
+
+func TestDocGeneratorReproducible(t *testing.T) {
+	t.Parallel()
+	const tmpl = `{"n":{{.}},"name":"{{name}}","id":"{{uuid}}","age":{{int 18 80}}}`
+	render := func() []string {
+		g, err := newDocGenerator(tmpl, 42)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for range 5 {
+			doc, err := g.next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, string(doc))
+		}
+		return out
+	}
+	a, b := render(), render()
+	if strings.Join(a, "\n") != strings.Join(b, "\n") {
+		t.Errorf("same seed gave different documents:\n%v\n%v", a, b)
+	}
+	var doc struct {
+		N   int    `json:"n"`
+		ID  string `json:"id"`
+		Age int    `json:"age"`
+	}
+	if err := json.Unmarshal([]byte(a[4]), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.N != 5 || doc.Age < 18 || doc.Age > 80 {
+		t.Errorf("got %+v", doc)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(doc.ID) {
+		t.Errorf("uuid %q is not a v4 UUID", doc.ID)
+	}
+}
+
+func TestDocGeneratorErrors(t *testing.T) {
+	t.Parallel()
+	if _, err := newDocGenerator(`{"a":{{int 1}`, 1); err == nil {
+		t.Error("parse error not reported")
+	}
+	tests := map[string]string{
+		"invalid JSON":  `{"name":{{name}}}`,
+		"bad range":     `{"a":{{int 5 1}}}`,
+		"missing arity": `{"a":{{int 1}}}`,
+	}
+	for name, tmpl := range tests {
+		g, err := newDocGenerator(tmpl, 1)
+		if err != nil {
+			t.Errorf("%s: parse: %v", name, err)
+			continue
+		}
+		if _, err := g.next(); err == nil || !strings.Contains(err.Error(), "document 1") {
+			t.Errorf("%s: got %v, want an error for document 1", name, err)
+		}
+	}
+}
+
+func TestFakeFuncs(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tmpl := template.Must(template.New("").Funcs(fakeFuncs(rand.New(rand.NewPCG(1, 1)), now)).Parse(
+		`{{hex 7}}|{{pick "x"}}|{{words 3}}|{{date}}|{{email}}|{{float 2 3}}|{{sentence}}`))
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(sb.String(), "|")
+	if len(parts[0]) != 7 || parts[1] != "x" || len(strings.Fields(parts[2])) != 3 {
+		t.Errorf("hex/pick/words: %q", parts[:3])
+	}
+	d, err := time.Parse(time.RFC3339, parts[3])
+	if err != nil || d.After(now) || d.Before(now.AddDate(-5, 0, -2)) {
+		t.Errorf("date %q out of range (%v)", parts[3], err)
+	}
+	if !strings.HasSuffix(parts[4], "@example.com") {
+		t.Errorf("email %q", parts[4])
+	}
+	if parts[5] < "2" || parts[5] >= "3" {
+		t.Errorf("float %q out of range", parts[5])
+	}
+	if !strings.HasSuffix(parts[6], ".") || parts[6][:1] != strings.ToUpper(parts[6][:1]) {
+		t.Errorf("sentence %q", parts[6])
+	}
+}
+
+func TestRunSeedDryRun(t *testing.T) {
+	t.Parallel()
+	sc := &seedConfig{insertConfig: insertConfig{batchSize: 2, conflict: "error"}, count: 3, template: `{"n":{{.}}}`, dryRun: true}
+	var out strings.Builder
+	if err := runSeed(t.Context(), &rootConfig{}, sc, reql.DB("test").Table("t"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "{\"n\":1}\n{\"n\":2}\n{\"n\":3}\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRunSeedValidation(t *testing.T) {
+	t.Parallel()
+	sc := &seedConfig{insertConfig: insertConfig{batchSize: 10, conflict: "error"}, count: 0, template: `{}`, dryRun: true}
+	if err := runSeed(t.Context(), &rootConfig{}, sc, reql.DB("test").Table("t"), &strings.Builder{}); err == nil {
+		t.Error("--count 0 accepted")
+	}
+	sc.count, sc.conflict = 1, "bogus"
+	if err := runSeed(t.Context(), &rootConfig{}, sc, reql.DB("test").Table("t"), &strings.Builder{}); err == nil {
+		t.Error("invalid --conflict accepted")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"r-cli/internal/output"
+)
+
+// pathStep is one step of a --select path: an object key or an array index.
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// selectPath is a parsed --select path such as "b.c" or "d[0]".
+type selectPath struct {
+	raw   string
+	steps []pathStep
+}
+
+// parseSelectPaths parses a comma-separated list of paths like "a,b.c,d[0]".
+// Negative indexes count from the end of the array.
+func parseSelectPaths(spec string) ([]selectPath, error) {
+	var paths []selectPath
+	for _, raw := range strings.Split(spec, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			return nil, fmt.Errorf("--select: empty path in %q", spec)
+		}
+		steps, err := parsePathSteps(raw)
+		if err != nil {
+			return nil, fmt.Errorf("--select: %w", err)
+		}
+		paths = append(paths, selectPath{raw: raw, steps: steps})
+	}
+	return paths, nil
+}
+
+func parsePathSteps(raw string) ([]pathStep, error) {
+	var steps []pathStep
+	for i, seg := range strings.Split(raw, ".") {
+		key, rest, _ := strings.Cut(seg, "[")
+		if key == "" && (i > 0 || rest == "") {
+			return nil, fmt.Errorf("invalid path %q", raw)
+		}
+		if key != "" {
+			steps = append(steps, pathStep{key: key})
+		}
+		if seg == key {
+			continue
+		}
+		idxSteps, err := parseIndexes("[" + rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", raw, err)
+		}
+		steps = append(steps, idxSteps...)
+	}
+	return steps, nil
+}
+
+// parseIndexes parses one or more "[n]" suffixes.
+func parseIndexes(s string) ([]pathStep, error) {
+	var steps []pathStep
+	for s != "" {
+		if s[0] != '[' {
+			return nil, fmt.Errorf("unexpected %q", s)
+		}
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("missing ]")
+		}
+		n, err := strconv.Atoi(s[1:end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q", s[1:end])
+		}
+		steps = append(steps, pathStep{index: n, isIndex: true})
+		s = s[end+1:]
+	}
+	return steps, nil
+}
+
+// lookup resolves the path against v; ok is false when any step is missing.
+func (p selectPath) lookup(v interface{}) (interface{}, bool) {
+	for _, st := range p.steps {
+		switch cur := v.(type) {
+		case map[string]interface{}:
+			if st.isIndex {
+				return nil, false
+			}
+			next, ok := cur[st.key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			if !st.isIndex {
+				return nil, false
+			}
+			i := st.index
+			if i < 0 {
+				i += len(cur)
+			}
+			if i < 0 || i >= len(cur) {
+				return nil, false
+			}
+			v = cur[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// selectIter projects each object or array row onto the selected paths.
+// The result is a flat object keyed by the path text, in --select order;
+// missing paths are omitted and scalar rows pass through unchanged.
+type selectIter struct {
+	inner output.RowIterator
+	paths []selectPath
+}
+
+func (s *selectIter) Next() (json.RawMessage, error) {
+	raw, err := s.inner.Next()
+	if err != nil {
+		return nil, err
+	}
+	return projectRow(raw, s.paths), nil
+}
+
+func projectRow(raw json.RawMessage, paths []selectPath) json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if dec.Decode(&v) != nil {
+		return raw
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return raw
+	}
+	// build the object manually to keep --select order
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	n := 0
+	for _, p := range paths {
+		val, ok := p.lookup(v)
+		if !ok {
+			continue
+		}
+		key, _ := json.Marshal(p.raw)
+		data, err := json.Marshal(val)
+		if err != nil {
+			return raw
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+		n++
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
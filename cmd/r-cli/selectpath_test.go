@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseSelectPaths(t *testing.T) {
+	t.Parallel()
+	paths, err := parseSelectPaths("a, b.c ,d[0],e[1][-1],[2].x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		raw   string
+		steps int
+	}{
+		{"a", 1}, {"b.c", 2}, {"d[0]", 2}, {"e[1][-1]", 3}, {"[2].x", 2},
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("got %d paths, want %d", len(paths), len(want))
+	}
+	for i, w := range want {
+		if paths[i].raw != w.raw || len(paths[i].steps) != w.steps {
+			t.Errorf("path %d: got %q (%d steps), want %q (%d steps)", i, paths[i].raw, len(paths[i].steps), w.raw, w.steps)
+		}
+	}
+}
+
+func TestParseSelectPathsInvalid(t *testing.T) {
+	t.Parallel()
+	for _, spec := range []string{"", "a,,b", "a.", ".a", "a[", "a[x]", "a[0]b", "a..b"} {
+		if _, err := parseSelectPaths(spec); err == nil {
+			t.Errorf("parseSelectPaths(%q): expected error, got nil", spec)
+		}
+	}
+}
+
+func TestProjectRow(t *testing.T) {
+	t.Parallel()
+	paths, err := parseSelectPaths("a,b.c,d[0],d[-1],missing,b.c.x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := json.RawMessage(`{"a":1,"b":{"c":"x","z":true},"d":[5,6,7],"e":12345678901234567890}`)
+	got := string(projectRow(row, paths))
+	want := `{"a":1,"b.c":"x","d[0]":5,"d[-1]":7}`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestProjectRowScalarPassthrough(t *testing.T) {
+	t.Parallel()
+	paths, _ := parseSelectPaths("a")
+	for _, in := range []string{`"str"`, `42`, `null`} {
+		if got := string(projectRow(json.RawMessage(in), paths)); got != in {
+			t.Errorf("projectRow(%s): got %s, want unchanged", in, got)
+		}
+	}
+}
+
+func TestProjectRowArrayRoot(t *testing.T) {
+	t.Parallel()
+	paths, _ := parseSelectPaths("[1].id")
+	got := string(projectRow(json.RawMessage(`[{"id":1},{"id":2}]`), paths))
+	if got != `{"[1].id":2}` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestSelectIter(t *testing.T) {
+	t.Parallel()
+	paths, _ := parseSelectPaths("id")
+	iter := &selectIter{
+		inner: &stubIter{rows: []json.RawMessage{json.RawMessage(`{"id":1,"x":2}`)}},
+		paths: paths,
+	}
+	row, err := iter.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(row) != `{"id":1}` {
+		t.Errorf("got %s", row)
+	}
+	if _, err := iter.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
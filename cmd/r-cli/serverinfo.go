@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newServerInfoCmd(cfg *rootConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "server-info",
+		Short: "Show server id, name, version and proxy status",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runServerInfo(cmd.Context(), cfg, os.Stdout)
+		},
+	}
+}
+
+// runServerInfo sends a SERVER_INFO query and prints the result as JSON.
+func runServerInfo(ctx context.Context, cfg *rootConfig, w io.Writer) error {
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	info, err := exec.ServerInfo(ctx)
+	if err != nil {
+		return err
+	}
+	out, err := openOutputTarget(cfg.output, w)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return out.finish(enc.Encode(info))
+}
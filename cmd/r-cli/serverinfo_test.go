@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerInfoCmdRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	for _, sub := range root.Commands() {
+		if sub.Name() == "server-info" {
+			return
+		}
+	}
+	t.Error("server-info subcommand not registered on root command")
+}
+
+func TestServerInfoCmdNoArgs(t *testing.T) {
+	t.Parallel()
+	cmd := newServerInfoCmd(&rootConfig{})
+	if err := cmd.Args(cmd, []string{"extra"}); err == nil {
+		t.Error("server-info: expected error for extra arg, got nil")
+	}
+	if err := cmd.Args(cmd, []string{}); err != nil {
+		t.Errorf("server-info: expected no error for zero args, got %v", err)
+	}
+}
+
+func TestRunServerInfoConnectionRefused(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+	_ = ln.Close()
+
+	var buf bytes.Buffer
+	cfg := &rootConfig{host: "127.0.0.1", port: port, timeout: time.Second}
+	if err := runServerInfo(context.Background(), cfg, &buf); err == nil {
+		t.Fatal("expected connection error, got nil")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("no output expected on failure, got %q", buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"r-cli/internal/output"
+)
+
+// parseSortSpec parses --sort-by field[:asc|:desc]; the field is a path as
+// in --select.
+func parseSortSpec(spec string) (path selectPath, desc bool, err error) {
+	field := spec
+	if i := strings.LastIndexByte(spec, ':'); i >= 0 {
+		switch spec[i+1:] {
+		case "asc":
+		case "desc":
+			desc = true
+		default:
+			return selectPath{}, false, fmt.Errorf("--sort-by: invalid order %q, must be asc or desc", spec[i+1:])
+		}
+		field = spec[:i]
+	}
+	path, err = parseFieldPath(field)
+	if err != nil {
+		return selectPath{}, false, fmt.Errorf("--sort-by: %w", err)
+	}
+	return path, desc, nil
+}
+
+// parseFieldPath parses a single path as in --select.
+func parseFieldPath(field string) (selectPath, error) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return selectPath{}, fmt.Errorf("empty field")
+	}
+	steps, err := parsePathSteps(field)
+	if err != nil {
+		return selectPath{}, err
+	}
+	return selectPath{raw: field, steps: steps}, nil
+}
+
+// fieldValue returns the value at path in row, or nil when row has none.
+func fieldValue(row json.RawMessage, path selectPath) json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(row))
+	dec.UseNumber()
+	var v interface{}
+	if dec.Decode(&v) != nil {
+		return nil
+	}
+	val, ok := path.lookup(v)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// sortIter reads all of inner on the first Next and yields its rows stably
+// ordered by the value at path, as output.LessValue orders them; rows
+// without the field come last in either direction.
+type sortIter struct {
+	inner  output.RowIterator
+	path   selectPath
+	desc   bool
+	rows   []json.RawMessage
+	err    error
+	loaded bool
+}
+
+func (s *sortIter) Next() (json.RawMessage, error) {
+	if !s.loaded {
+		s.loaded = true
+		s.load()
+	}
+	if len(s.rows) == 0 {
+		return nil, s.err
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return row, nil
+}
+
+// load drains inner and sorts its rows; a read error is returned once the
+// rows read before it have been yielded.
+func (s *sortIter) load() {
+	type keyed struct {
+		row, key json.RawMessage
+	}
+	var rows []keyed
+	for {
+		row, err := s.inner.Next()
+		if err != nil {
+			s.err = err
+			break
+		}
+		rows = append(rows, keyed{row, fieldValue(row, s.path)})
+	}
+	slices.SortStableFunc(rows, func(a, b keyed) int {
+		x, y := a.key, b.key
+		if s.desc && x != nil && y != nil {
+			x, y = y, x
+		}
+		switch {
+		case output.LessValue(x, y):
+			return -1
+		case output.LessValue(y, x):
+			return 1
+		}
+		return 0
+	})
+	s.rows = make([]json.RawMessage, len(rows))
+	for i, r := range rows {
+		s.rows[i] = r.row
+	}
+}
+
+// uniqueIter yields the first row for each value at path, comparing the
+// values as decoded JSON so 1 and 1.0 are the same; rows without the
+// field count as null. Close passes on to inner, so a --limit reached
+// still stops the cursor.
+type uniqueIter struct {
+	inner output.RowIterator
+	path  selectPath
+	seen  map[string]bool
+}
+
+func (u *uniqueIter) Next() (json.RawMessage, error) {
+	if u.seen == nil {
+		u.seen = map[string]bool{}
+	}
+	for {
+		row, err := u.inner.Next()
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		_ = json.Unmarshal(fieldValue(row, u.path), &v)
+		key, _ := json.Marshal(v)
+		if !u.seen[string(key)] {
+			u.seen[string(key)] = true
+			return row, nil
+		}
+	}
+}
+
+func (u *uniqueIter) Close() error {
+	if c, ok := u.inner.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
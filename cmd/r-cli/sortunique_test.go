@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"r-cli/internal/output"
+)
+
+// drainRows returns the rows of iter joined by spaces, and the error it ended
+// with other than io.EOF.
+func drainRows(t *testing.T, iter output.RowIterator) (string, error) {
+	t.Helper()
+	var rows []string
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			return strings.Join(rows, " "), nil
+		}
+		if err != nil {
+			return strings.Join(rows, " "), err
+		}
+		rows = append(rows, string(row))
+	}
+}
+
+func stubRows(rows ...string) *stubIter {
+	s := &stubIter{}
+	for _, r := range rows {
+		s.rows = append(s.rows, json.RawMessage(r))
+	}
+	return s
+}
+
+func TestParseSortSpec(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		spec string
+		raw  string
+		desc bool
+	}{
+		{"age", "age", false},
+		{"age:asc", "age", false},
+		{"address.city:desc", "address.city", true},
+		{"tags[0]:desc", "tags[0]", true},
+	}
+	for _, tt := range tests {
+		path, desc, err := parseSortSpec(tt.spec)
+		if err != nil {
+			t.Errorf("parseSortSpec(%q): %v", tt.spec, err)
+			continue
+		}
+		if path.raw != tt.raw || desc != tt.desc {
+			t.Errorf("parseSortSpec(%q) = %q %v, want %q %v", tt.spec, path.raw, desc, tt.raw, tt.desc)
+		}
+	}
+	for _, spec := range []string{"", ":desc", "age:down", "a..b"} {
+		if _, _, err := parseSortSpec(spec); err == nil || !strings.HasPrefix(err.Error(), "--sort-by:") {
+			t.Errorf("parseSortSpec(%q): got %v, want a --sort-by error", spec, err)
+		}
+	}
+}
+
+func TestSortIter(t *testing.T) {
+	t.Parallel()
+	rows := []string{`{"n":10,"id":1}`, `{"id":2}`, `{"n":"b","id":3}`, `{"n":9,"id":4}`, `{"n":10,"id":5}`, `7`}
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"n", `{"n":9,"id":4} {"n":10,"id":1} {"n":10,"id":5} {"n":"b","id":3} {"id":2} 7`},
+		{"n:desc", `{"n":"b","id":3} {"n":10,"id":1} {"n":10,"id":5} {"n":9,"id":4} {"id":2} 7`},
+	}
+	for _, tt := range tests {
+		path, desc, err := parseSortSpec(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := drainRows(t, &sortIter{inner: stubRows(rows...), path: path, desc: desc})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("--sort-by %s:\ngot  %s\nwant %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestSortIterNested(t *testing.T) {
+	t.Parallel()
+	path, _, _ := parseSortSpec("a.b[-1]")
+	got, err := drainRows(t, &sortIter{inner: stubRows(`{"a":{"b":[1,3]}}`, `{"a":{"b":[2]}}`), path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":{"b":[2]}} {"a":{"b":[1,3]}}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSortIterError(t *testing.T) {
+	t.Parallel()
+	errStream := errors.New("stream broken")
+	inner := &errAfterIter{rows: stubRows(`{"n":2}`, `{"n":1}`), err: errStream}
+	path, _, _ := parseSortSpec("n")
+	got, err := drainRows(t, &sortIter{inner: inner, path: path})
+	if !errors.Is(err, errStream) {
+		t.Errorf("err = %v, want %v", err, errStream)
+	}
+	if want := `{"n":1} {"n":2}`; got != want {
+		t.Errorf("rows before the error: got %s, want %s", got, want)
+	}
+}
+
+func TestUniqueIter(t *testing.T) {
+	t.Parallel()
+	path, err := parseFieldPath("k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := stubRows(`{"k":1,"i":1}`, `{"k":1.0,"i":2}`, `{"k":"1","i":3}`, `{"i":4}`, `{"k":null,"i":5}`, `{"k":{"x":1},"i":6}`, `{"k":{"x":1},"i":7}`)
+	got, err := drainRows(t, &uniqueIter{inner: inner, path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"k":1,"i":1} {"k":"1","i":3} {"i":4} {"k":{"x":1},"i":6}`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestMakeIterSortUniqueSelect(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{sortBy: "age:desc", unique: "team", selectSpec: "name"}
+	var err error
+	if cfg.sortPath, cfg.sortDesc, err = parseSortSpec(cfg.sortBy); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.uniquePath, err = parseFieldPath(cfg.unique); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.selectPaths, err = parseSelectPaths(cfg.selectSpec); err != nil {
+		t.Fatal(err)
+	}
+	inner := stubRows(
+		`{"name":"a","team":"x","age":30}`,
+		`{"name":"b","team":"y","age":40}`,
+		`{"name":"c","team":"x","age":50}`,
+	)
+	got, err := drainRows(t, makeIter(inner, cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the oldest of each team, by name only
+	if want := `{"name":"c"} {"name":"b"}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMakeIterLimitAfterSortAndUnique(t *testing.T) {
+	t.Parallel()
+	rows := []string{`{"n":3,"k":"a"}`, `{"n":1,"k":"a"}`, `{"n":2,"k":"b"}`, `{"n":0,"k":"c"}`}
+	sortCfg := &rootConfig{sortBy: "n", limit: 2}
+	var err error
+	if sortCfg.sortPath, sortCfg.sortDesc, err = parseSortSpec(sortCfg.sortBy); err != nil {
+		t.Fatal(err)
+	}
+	got, err := drainRows(t, makeIter(stubRows(rows...), sortCfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the two smallest of the whole result, not of its first two rows
+	if want := `{"n":0,"k":"c"} {"n":1,"k":"a"}`; got != want {
+		t.Errorf("--sort-by with --limit: got %s, want %s", got, want)
+	}
+
+	uniqueCfg := &rootConfig{unique: "k", limit: 3}
+	if uniqueCfg.uniquePath, err = parseFieldPath(uniqueCfg.unique); err != nil {
+		t.Fatal(err)
+	}
+	inner := &closingStubIter{stubIter: *stubRows(rows...)}
+	got, err = drainRows(t, makeIter(inner, uniqueCfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"n":3,"k":"a"} {"n":2,"k":"b"} {"n":0,"k":"c"}`; got != want {
+		t.Errorf("--unique with --limit: got %s, want %s", got, want)
+	}
+	if !inner.closed {
+		t.Error("cursor not closed once --limit was reached")
+	}
+}
+
+// errAfterIter yields the rows of rows, then err.
+type errAfterIter struct {
+	rows *stubIter
+	err  error
+}
+
+func (e *errAfterIter) Next() (json.RawMessage, error) {
+	row, err := e.rows.Next()
+	if errors.Is(err, io.EOF) {
+		return nil, e.err
+	}
+	return row, err
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"r-cli/internal/output"
+)
+
+// sqliteShell is the sqlite3 command-line shell that --format sqlite pipes
+// its SQL into; replaceable in tests.
+var sqliteShell = "sqlite3"
+
+// openResultTarget is openOutputTarget for query results: with --format
+// sqlite the results go to the sqlite3 shell on the --output database.
+func openResultTarget(cfg *rootConfig, w io.Writer) (*outputTarget, error) {
+	if cfg.format == "sqlite" && cfg.output != "" && cfg.output != "-" {
+		return openSQLiteTarget(cfg.output, cfg.sqliteTable)
+	}
+	return openOutputTarget(cfg.output, w)
+}
+
+// openSQLiteTarget starts the sqlite3 shell on the database at path and
+// returns a target writing to its input, for output.SQLite rows of table.
+// The shell stops at the first failing statement, so a transaction that
+// does not reach its COMMIT leaves the database unchanged.
+func openSQLiteTarget(path, table string) (*outputTarget, error) {
+	cols, err := sqliteColumns(path, table)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(sqliteShell, "-batch", "-bail", path) //nolint:gosec // path is the user's --output
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("output: --format sqlite: %w", err)
+	}
+	return &outputTarget{
+		Writer: stdin,
+		shell:  &sqliteProc{cmd: cmd, stdin: stdin, stderr: &stderr},
+		table:  &output.SQLTable{Name: table, Columns: cols},
+	}, nil
+}
+
+// sqliteColumns returns the columns of table in the database at path; none
+// when the database or the table does not exist yet.
+func sqliteColumns(path, table string) ([]string, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	query := "SELECT name FROM pragma_table_info('" + strings.ReplaceAll(table, "'", "''") + "')"
+	var stderr bytes.Buffer
+	cmd := exec.Command(sqliteShell, "-batch", "-noheader", "-readonly", path, query) //nolint:gosec // path is the user's --output
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("output: --format sqlite: %w", shellError(err, &stderr))
+	}
+	names := strings.TrimRight(string(data), "\r\n")
+	if names == "" {
+		return nil, nil
+	}
+	return strings.Split(names, "\n"), nil
+}
+
+// sqliteProc is a running sqlite3 shell.
+type sqliteProc struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *bytes.Buffer
+}
+
+// wait ends the shell's input and waits for it to exit. Its error, when it
+// failed, comes before err: writes fail once the shell has stopped.
+func (p *sqliteProc) wait(err error) error {
+	_ = p.stdin.Close()
+	if werr := p.cmd.Wait(); werr != nil {
+		return fmt.Errorf("output: sqlite: %w", shellError(werr, p.stderr))
+	}
+	return err
+}
+
+// shellError is err with the message the shell printed to stderr, if any.
+func shellError(err error, stderr *bytes.Buffer) error {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return errors.New(msg)
+	}
+	return err
+}
+
+// sqliteTableOf returns the table of the sqlite target that w writes to,
+// through the plain targets wrapped around it.
+func sqliteTableOf(w io.Writer) (*output.SQLTable, bool) {
+	for o, ok := w.(*outputTarget); ok; o, ok = o.Writer.(*outputTarget) {
+		if o.table != nil {
+			return o.table, true
+		}
+	}
+	return nil, false
+}
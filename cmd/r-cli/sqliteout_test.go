@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"r-cli/internal/output"
+)
+
+type sqliteRows struct {
+	rows []string
+	err  error // returned after the rows
+}
+
+func (s *sqliteRows) Next() (json.RawMessage, error) {
+	if len(s.rows) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return json.RawMessage(row), nil
+}
+
+// writeSQLite writes rows to table of the database at path as --format
+// sqlite does.
+func writeSQLite(t *testing.T, path, table string, rows *sqliteRows) error {
+	t.Helper()
+	cfg := &rootConfig{format: "sqlite", output: path, sqliteTable: table}
+	out, err := openResultTarget(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.finish(writeOutput(out, out.format(cfg.format), rows, cfg))
+}
+
+func sqliteQuery(t *testing.T, path, query string) string {
+	t.Helper()
+	data, err := exec.Command(sqliteShell, "-batch", path, query).CombinedOutput() //nolint:gosec
+	if err != nil {
+		t.Fatalf("sqlite3: %v: %s", err, data)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestSQLiteOutput(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath(sqliteShell); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	path := filepath.Join(t.TempDir(), "data.db")
+	first := &sqliteRows{rows: []string{`{"id":1,"name":"alice","address":{"city":"NYC"}}`, `{"id":2,"name":"bob"}`}}
+	if err := writeSQLite(t, path, "results", first); err != nil {
+		t.Fatal(err)
+	}
+	// a second run appends, adding a column for the new key
+	second := &sqliteRows{rows: []string{`{"id":3,"email":"c@x"}`}}
+	if err := writeSQLite(t, path, "results", second); err != nil {
+		t.Fatal(err)
+	}
+	got := sqliteQuery(t, path, `SELECT id, name, "address.city", email FROM results ORDER BY id`)
+	want := "1|alice|NYC|\n2|bob||\n3|||c@x"
+	if got != want {
+		t.Errorf("rows:\n%s\nwant\n%s", got, want)
+	}
+	if got := sqliteQuery(t, path, "SELECT typeof(id) FROM results LIMIT 1"); got != "integer" {
+		t.Errorf("typeof(id) = %q, want integer", got)
+	}
+
+	// a failing query leaves the database as it was
+	errBroken := errors.New("stream broken")
+	failing := &sqliteRows{rows: []string{`{"id":4,"extra":true}`}, err: errBroken}
+	if err := writeSQLite(t, path, "results", failing); !errors.Is(err, errBroken) {
+		t.Fatalf("err = %v, want %v", err, errBroken)
+	}
+	if got := sqliteQuery(t, path, "SELECT count(*) FROM results"); got != "3" {
+		t.Errorf("count after failed query = %s, want 3", got)
+	}
+	if got := sqliteQuery(t, path, "SELECT count(*) FROM pragma_table_info('results') WHERE name = 'extra'"); got != "0" {
+		t.Errorf("failed query added its column")
+	}
+}
+
+func TestSQLiteOutputShellError(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath(sqliteShell); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	path := filepath.Join(t.TempDir(), "data.db")
+	sqliteQuery(t, path, "CREATE VIEW results AS SELECT 1 AS id")
+	err := writeSQLite(t, path, "results", &sqliteRows{rows: []string{`{"id":2}`}})
+	if err == nil || !strings.Contains(err.Error(), "sqlite") {
+		t.Errorf("err = %v, want the shell's error", err)
+	}
+}
+
+func TestWriteOutputSQLiteNeedsTarget(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := writeOutput(&buf, "sqlite", &sqliteRows{}, &rootConfig{})
+	if err == nil || !strings.Contains(err.Error(), "--output") {
+		t.Errorf("err = %v, want --output required", err)
+	}
+	if _, ok := sqliteTableOf(&outputTarget{Writer: &outputTarget{table: &output.SQLTable{}}}); !ok {
+		t.Error("sqliteTableOf: table of a wrapped target not found")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"r-cli/internal/cursor"
+	"r-cli/internal/output"
+)
+
+// queryStats is the --stats footer printed after a query completes.
+type queryStats struct {
+	Rows       int     `json:"rows"`
+	Batches    int     `json:"batches"`
+	RoundTrips int     `json:"round_trips"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// countingIter counts the rows passed through to the formatter.
+type countingIter struct {
+	inner output.RowIterator
+	n     int
+}
+
+func (c *countingIter) Next() (json.RawMessage, error) {
+	row, err := c.inner.Next()
+	if err == nil {
+		c.n++
+	}
+	return row, err
+}
+
+// withStats wraps iter to count rows when --stats or --stats-json is set and
+// returns a func that prints the footer to stderr; both are no-ops otherwise.
+func withStats(iter output.RowIterator, cur cursor.Cursor, cfg *rootConfig, start time.Time) (output.RowIterator, func()) {
+	if !(cfg.stats || cfg.statsJSON) || cfg.quiet {
+		return iter, func() {}
+	}
+	counter := &countingIter{inner: iter}
+	return counter, func() {
+		s := collectStats(cur, counter.n, time.Since(start))
+		_ = writeStats(os.Stderr, s, cfg.statsJSON)
+	}
+}
+
+// collectStats builds the footer; single-response cursors count as one batch.
+// Round trips are the START query plus every CONTINUE/STOP the cursor sent.
+func collectStats(cur cursor.Cursor, rows int, elapsed time.Duration) queryStats {
+	s := queryStats{
+		Rows:       rows,
+		Batches:    1,
+		RoundTrips: 1,
+		DurationMs: durationMs(elapsed),
+	}
+	if sr, ok := cur.(cursor.StatsReporter); ok {
+		cs := sr.Stats()
+		s.Batches = cs.Batches
+		s.RoundTrips = 1 + cs.Requests
+	}
+	return s
+}
+
+// writeStats prints s as a human-readable line or, with asJSON, as one JSON object.
+func writeStats(w io.Writer, s queryStats, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(s)
+	}
+	_, err := fmt.Fprintf(w, "rows: %d, batches: %d, round trips: %d, time: %.3fms\n",
+		s.Rows, s.Batches, s.RoundTrips, s.DurationMs)
+	return err
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"r-cli/internal/cursor"
+)
+
+type statsStubCursor struct {
+	stubIter
+	stats cursor.Stats
+}
+
+func (s *statsStubCursor) All() ([]json.RawMessage, error) { return nil, nil }
+func (s *statsStubCursor) Each(context.Context, func(json.RawMessage) error) error {
+	return nil
+}
+func (s *statsStubCursor) Chan(context.Context) <-chan cursor.Row { return nil }
+func (s *statsStubCursor) Close() error                           { return nil }
+func (s *statsStubCursor) Stats() cursor.Stats                    { return s.stats }
+
+type plainStubCursor struct{ stubIter }
+
+func (s *plainStubCursor) All() ([]json.RawMessage, error) { return nil, nil }
+func (s *plainStubCursor) Each(context.Context, func(json.RawMessage) error) error {
+	return nil
+}
+func (s *plainStubCursor) Chan(context.Context) <-chan cursor.Row { return nil }
+func (s *plainStubCursor) Close() error                           { return nil }
+
+func TestCountingIter(t *testing.T) {
+	t.Parallel()
+	iter := &countingIter{inner: &stubIter{rows: []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`2`)}}}
+	for {
+		if _, err := iter.Next(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if iter.n != 2 {
+		t.Errorf("got %d rows, want 2", iter.n)
+	}
+}
+
+func TestCollectStatsBatchedCursor(t *testing.T) {
+	t.Parallel()
+	cur := &statsStubCursor{stats: cursor.Stats{Batches: 3, Requests: 2}}
+	got := collectStats(cur, 10, 1500*time.Microsecond)
+	want := queryStats{Rows: 10, Batches: 3, RoundTrips: 3, DurationMs: 1.5}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCollectStatsSingleResponse(t *testing.T) {
+	t.Parallel()
+	got := collectStats(&plainStubCursor{}, 1, 0)
+	if got.Batches != 1 || got.RoundTrips != 1 {
+		t.Errorf("got %+v, want 1 batch and 1 round trip", got)
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	t.Parallel()
+	s := queryStats{Rows: 2, Batches: 1, RoundTrips: 1, DurationMs: 4.25}
+	var text bytes.Buffer
+	if err := writeStats(&text, s, false); err != nil {
+		t.Fatal(err)
+	}
+	if text.String() != "rows: 2, batches: 1, round trips: 1, time: 4.250ms\n" {
+		t.Errorf("text: got %q", text.String())
+	}
+	var js bytes.Buffer
+	if err := writeStats(&js, s, true); err != nil {
+		t.Fatal(err)
+	}
+	var got queryStats
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", js.String(), err)
+	}
+	if got != s {
+		t.Errorf("json: got %+v, want %+v", got, s)
+	}
+	if !strings.Contains(js.String(), `"round_trips":1`) {
+		t.Errorf("json keys: got %q", js.String())
+	}
+}
+
+func TestWithStatsDisabled(t *testing.T) {
+	t.Parallel()
+	inner := &stubIter{}
+	iter, report := withStats(inner, &plainStubCursor{}, &rootConfig{}, time.Now())
+	if iter != inner {
+		t.Error("iterator should be returned unchanged when --stats is off")
+	}
+	report()
+}
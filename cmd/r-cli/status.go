@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"os"
 	"time"
@@ -32,15 +31,8 @@ type statusInfo struct {
 }
 
 func runStatus(ctx context.Context, cfg *rootConfig, w io.Writer) error {
-	if cfg.timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
-		defer cancel()
-	}
-
-	if cfg.verbose && !cfg.quiet {
-		_, _ = fmt.Fprintf(os.Stderr, "connecting to %s:%d\n", cfg.host, cfg.port)
-	}
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
 
 	exec, cleanup, err := newExecutor(cfg)
 	if err != nil {
@@ -53,9 +45,7 @@ func runStatus(ctx context.Context, cfg *rootConfig, w io.Writer) error {
 	if err != nil {
 		return err
 	}
-	if cfg.verbose && !cfg.quiet {
-		_, _ = fmt.Fprintf(os.Stderr, "query time: %v\n", time.Since(start))
-	}
+	cfg.log().Info("query done", "duration", time.Since(start))
 
 	si := statusInfo{
 		Host:     cfg.host,
@@ -64,7 +54,11 @@ func runStatus(ctx context.Context, cfg *rootConfig, w io.Writer) error {
 		Name:     info.Name,
 		Status:   "ok",
 	}
-	enc := json.NewEncoder(w)
+	out, err := openOutputTarget(cfg.output, w)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
 	enc.SetIndent("", "  ")
-	return enc.Encode(si)
+	return out.finish(enc.Encode(si))
 }
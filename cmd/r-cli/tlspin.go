@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// parseTLSPins decodes --tls-pin values of the form sha256:<hash>, where the
+// hash is the SHA-256 of the certificate's SubjectPublicKeyInfo in base64
+// (as printed by openssl) or hex, with or without colons.
+func parseTLSPins(values []string) ([][]byte, error) {
+	pins := make([][]byte, 0, len(values))
+	for _, v := range values {
+		algo, fp, ok := strings.Cut(v, ":")
+		if !ok || !strings.EqualFold(algo, "sha256") {
+			return nil, fmt.Errorf("--tls-pin %q: must be sha256:<fingerprint>", v)
+		}
+		pin, err := decodeFingerprint(fp)
+		if err != nil {
+			return nil, fmt.Errorf("--tls-pin %q: %w", v, err)
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+func decodeFingerprint(fp string) ([]byte, error) {
+	if h := strings.ReplaceAll(fp, ":", ""); len(h) == 2*sha256.Size {
+		if b, err := hex.DecodeString(h); err == nil {
+			return b, nil
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(fp)
+	if err != nil || len(b) != sha256.Size {
+		return nil, fmt.Errorf("fingerprint is not a SHA-256 hash in base64 or hex")
+	}
+	return b, nil
+}
+
+// spkiFingerprint returns the SHA-256 of cert's SubjectPublicKeyInfo.
+func spkiFingerprint(cert *x509.Certificate) []byte {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return sum[:]
+}
+
+// verifyTLSPins returns a VerifyPeerCertificate callback accepting the
+// connection only when the server's leaf certificate matches one of pins.
+// Only the leaf is checked, since without CA verification the rest of the
+// chain is whatever the server chose to send.
+func verifyTLSPins(pins [][]byte) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("tls: server sent no certificate")
+		}
+		leaf, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return fmt.Errorf("tls: parsing server certificate: %w", err)
+		}
+		got := spkiFingerprint(leaf)
+		for _, pin := range pins {
+			if bytes.Equal(got, pin) {
+				return nil
+			}
+		}
+		return fmt.Errorf("tls: server certificate sha256:%s does not match --tls-pin", base64.StdEncoding.EncodeToString(got))
+	}
+}
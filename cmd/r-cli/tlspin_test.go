@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testCertPin generates a self-signed certificate and returns the TLS
+// certificate, its PEM and its SPKI hash.
+func testCertPin(t *testing.T) (tls.Certificate, []byte, []byte) {
+	t.Helper()
+	certPEM, keyPEM := generateTestCert(t)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(certPEM)
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, certPEM, spkiFingerprint(parsed)
+}
+
+func TestParseTLSPins(t *testing.T) {
+	t.Parallel()
+	sum := sha256.Sum256([]byte("key"))
+	hexFP := hex.EncodeToString(sum[:])
+	var colons []string
+	for i := 0; i < len(hexFP); i += 2 {
+		colons = append(colons, strings.ToUpper(hexFP[i:i+2]))
+	}
+	for _, v := range []string{
+		"sha256:" + base64.StdEncoding.EncodeToString(sum[:]),
+		"sha256:" + hexFP,
+		"SHA256:" + strings.Join(colons, ":"),
+	} {
+		pins, err := parseTLSPins([]string{v})
+		if err != nil || len(pins) != 1 || string(pins[0]) != string(sum[:]) {
+			t.Errorf("parseTLSPins(%q) = %x, %v", v, pins, err)
+		}
+	}
+	for _, v := range []string{"md5:abcd", hexFP, "sha256:abcd", "sha256:" + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := parseTLSPins([]string{v}); err == nil {
+			t.Errorf("parseTLSPins(%q): expected error", v)
+		}
+	}
+}
+
+func TestVerifyTLSPins(t *testing.T) {
+	t.Parallel()
+	cert, _, pin := testCertPin(t)
+	other := sha256.Sum256([]byte("other"))
+
+	if err := verifyTLSPins([][]byte{other[:], pin})(cert.Certificate, nil); err != nil {
+		t.Errorf("matching pin rejected: %v", err)
+	}
+	err := verifyTLSPins([][]byte{other[:]})(cert.Certificate, nil)
+	want := "sha256:" + base64.StdEncoding.EncodeToString(pin) + " does not match --tls-pin"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v, want error containing %q", err, want)
+	}
+	if err := verifyTLSPins([][]byte{pin})(nil, nil); err == nil {
+		t.Error("empty chain accepted")
+	}
+}
+
+func TestBuildTLSConfigPin(t *testing.T) {
+	t.Parallel()
+	_, certPEM, pin := testCertPin(t)
+	pinFlag := "sha256:" + base64.StdEncoding.EncodeToString(pin)
+
+	cfg := &rootConfig{tlsPins: []string{pinFlag}}
+	tlsCfg, err := cfg.buildTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsCfg == nil || !tlsCfg.InsecureSkipVerify || tlsCfg.VerifyPeerCertificate == nil {
+		t.Fatalf("pin alone should replace CA verification: %+v", tlsCfg)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg = &rootConfig{tlsPins: []string{pinFlag}, tlsCACert: caFile}
+	tlsCfg, err = cfg.buildTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsCfg.InsecureSkipVerify || tlsCfg.VerifyPeerCertificate == nil {
+		t.Error("pin with --tls-cert should keep CA verification")
+	}
+
+	if _, err := (&rootConfig{tlsPins: []string{"sha1:x"}}).buildTLSConfig(); err == nil {
+		t.Error("invalid pin accepted")
+	}
+}
+
+func TestTLSPinHandshake(t *testing.T) {
+	t.Parallel()
+	cert, _, pin := testCertPin(t)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.(*tls.Conn).Handshake() //nolint:forcetypeassert
+			_ = c.Close()
+		}
+	}()
+
+	other := sha256.Sum256([]byte("other"))
+	for _, tc := range []struct {
+		pin    []byte
+		wantOK bool
+	}{{pin, true}, {other[:], false}} {
+		cfg := &rootConfig{tlsPins: []string{"sha256:" + hex.EncodeToString(tc.pin)}}
+		tlsCfg, err := cfg.buildTLSConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nc, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tls.Client(nc, tlsCfg).Handshake()
+		_ = nc.Close()
+		if (err == nil) != tc.wantOK {
+			t.Errorf("pin %x: handshake err = %v, want ok=%v", tc.pin, err, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"r-cli/internal/conn"
+)
+
+// maxTracePayload caps the payload text of a --trace line.
+const maxTracePayload = 200
+
+// frameTracer writes one --trace line per wire frame:
+//
+//	trace > token=1 START bytes=24 [1,[39,[]],{}]
+//	trace < token=1 SUCCESS_ATOM bytes=15 1.2ms {"t":1,"r":[1]}
+type frameTracer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (t *frameTracer) Trace(ev conn.FrameEvent) {
+	payload := ev.Payload
+	suffix := ""
+	if len(payload) > maxTracePayload {
+		payload, suffix = payload[:maxTracePayload], "..."
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ev.Out {
+		_, _ = fmt.Fprintf(t.w, "trace > token=%d %v bytes=%d %s%s\n", ev.Token, ev.QueryType, len(ev.Payload), payload, suffix)
+		return
+	}
+	_, _ = fmt.Fprintf(t.w, "trace < token=%d %v bytes=%d %v %s%s\n", ev.Token, ev.ResponseType, len(ev.Payload), ev.Elapsed.Round(time.Microsecond), payload, suffix)
+}
+
+// tracer returns the --trace frame tracer writing to w, or nil without --trace.
+func (c *rootConfig) tracer(w io.Writer) conn.Tracer {
+	if !c.trace {
+		return nil
+	}
+	return &frameTracer{w: w}
+}
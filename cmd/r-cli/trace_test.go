@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/proto"
+)
+
+func TestFrameTracer(t *testing.T) {
+	t.Parallel()
+	var buf strings.Builder
+	tr := &frameTracer{w: &buf}
+	tr.Trace(conn.FrameEvent{Out: true, Token: 1, QueryType: proto.QueryStart, Payload: []byte(`[1,[39,[]],{}]`)})
+	tr.Trace(conn.FrameEvent{Token: 1, ResponseType: proto.ResponseSuccessAtom, Payload: []byte(`{"t":1,"r":[1]}`), Elapsed: 1200 * time.Microsecond})
+	tr.Trace(conn.FrameEvent{Token: 2, ResponseType: proto.ResponseSuccessPartial, Payload: []byte(strings.Repeat("x", maxTracePayload+10))})
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		"trace > token=1 START bytes=14 [1,[39,[]],{}]",
+		`trace < token=1 SUCCESS_ATOM bytes=15 1.2ms {"t":1,"r":[1]}`,
+		"trace < token=2 SUCCESS_PARTIAL bytes=210 0s " + strings.Repeat("x", maxTracePayload) + "...",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines:\n%s", len(lines), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d:\n got %s\nwant %s", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestTracerOnlyWithFlag(t *testing.T) {
+	t.Parallel()
+	if (&rootConfig{}).tracer(nil) != nil {
+		t.Error("tracer set without --trace")
+	}
+	if (&rootConfig{trace: true}).tracer(nil) == nil {
+		t.Error("no tracer with --trace")
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+	"r-cli/internal/response"
+)
+
+type waitConfig struct {
+	tables   []string
+	waitFor  string
+	interval time.Duration
+}
+
+func newWaitCmd(cfg *rootConfig) *cobra.Command {
+	wc := &waitConfig{}
+	cmd := &cobra.Command{
+		Use:   "wait",
+		Short: "Block until the server accepts connections and tables are ready",
+		Long: "Poll until the server accepts connections and every --table reports the\n" +
+			"--wait-for state (via the ReQL wait term), then exit 0. Missing tables and\n" +
+			"refused connections are retried every --interval; --timeout bounds the\n" +
+			"whole wait (0 waits forever). Meant for docker-compose and CI start-up ordering:\n\n" +
+			"  r-cli wait --table app.users --table app.orders --timeout 60s",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runWait(cmd.Context(), cfg, wc)
+		},
+	}
+	f := cmd.Flags()
+	f.StringArrayVar(&wc.tables, "table", nil, "table (in --db, or db.table) to wait for; repeatable")
+	_ = cmd.RegisterFlagCompletionFunc("table", completeTableRef(cfg))
+	f.StringVar(&wc.waitFor, "wait-for", "ready_for_writes", "table state: ready_for_outdated_reads, ready_for_reads, ready_for_writes, all_replicas_ready")
+	f.DurationVar(&wc.interval, "interval", time.Second, "delay between attempts")
+	return cmd
+}
+
+func runWait(ctx context.Context, cfg *rootConfig, wc *waitConfig) error {
+	terms, err := wc.waitTerms(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := cfg.commandContext(ctx)
+	defer cancel()
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	start := time.Now()
+	for {
+		terms, err = waitReady(ctx, exec, cfg, terms)
+		if err == nil {
+			cfg.log().Info("ready", "duration", time.Since(start))
+			return nil
+		}
+		if !waitRetryable(ctx, err) {
+			if ctx.Err() != nil {
+				return fmt.Errorf("wait: not ready after %s: %w", time.Since(start).Round(time.Second), err)
+			}
+			return err
+		}
+		cfg.log().Info("not ready, retrying", "error", err, "interval", wc.interval)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait: not ready after %s: %w", time.Since(start).Round(time.Second), err)
+		case <-time.After(wc.interval):
+		}
+	}
+}
+
+// waitTerms validates the flags and builds one wait term per --table; with
+// no tables the list is a single r.expr(1) that only checks the connection.
+func (wc *waitConfig) waitTerms(cfg *rootConfig) ([]reql.Term, error) {
+	switch wc.waitFor {
+	case "ready_for_outdated_reads", "ready_for_reads", "ready_for_writes", "all_replicas_ready":
+	default:
+		return nil, fmt.Errorf("--wait-for: invalid value %q, must be ready_for_outdated_reads, ready_for_reads, ready_for_writes, or all_replicas_ready", wc.waitFor)
+	}
+	if wc.interval <= 0 {
+		return nil, fmt.Errorf("--interval must be > 0")
+	}
+	if len(wc.tables) == 0 {
+		return []reql.Term{reql.Datum(1)}, nil
+	}
+	terms := make([]reql.Term, 0, len(wc.tables))
+	for _, ref := range wc.tables {
+		tbl, err := listTable(cfg, ref)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, tbl.Wait(reql.OptArgs{"wait_for": wc.waitFor}))
+	}
+	return terms, nil
+}
+
+// waitReady runs the wait terms in order. On error it returns the terms not
+// yet satisfied, so tables already ready are not waited for again.
+func waitReady(ctx context.Context, exec *query.Executor, cfg *rootConfig, terms []reql.Term) ([]reql.Term, error) {
+	for i, term := range terms {
+		var res json.RawMessage
+		if err := fetchValue(ctx, exec, cfg, term, &res); err != nil {
+			return terms[i:], err
+		}
+	}
+	return nil, nil
+}
+
+// waitRetryable reports whether err means "not ready yet": the server is
+// unreachable, unavailable, or the table does not exist yet.
+func waitRetryable(ctx context.Context, err error) bool {
+	if retryableQueryError(ctx, err) {
+		return true
+	}
+	var ne *response.ReqlNonExistenceError
+	return ctx.Err() == nil && errors.As(err, &ne)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/response"
+)
+
+func TestWaitTerms(t *testing.T) {
+	t.Parallel()
+	wc := &waitConfig{tables: []string{"app.users", "orders"}, waitFor: "ready_for_reads", interval: time.Second}
+	terms, err := wc.waitTerms(&rootConfig{database: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, term := range terms {
+		data, err := json.Marshal(term)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(data))
+	}
+	want := []string{
+		`[177,[[15,[[14,["app"]],"users"]]],{"wait_for":"ready_for_reads"}]`,
+		`[177,[[15,[[14,["shop"]],"orders"]]],{"wait_for":"ready_for_reads"}]`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWaitTermsConnectionOnly(t *testing.T) {
+	t.Parallel()
+	terms, err := (&waitConfig{waitFor: "ready_for_writes", interval: time.Second}).waitTerms(&rootConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := json.Marshal(terms); string(data) != "[1]" {
+		t.Errorf("got %s, want [1]", data)
+	}
+}
+
+func TestWaitTermsInvalid(t *testing.T) {
+	t.Parallel()
+	tests := map[string]*waitConfig{
+		"wait-for": {waitFor: "soon", interval: time.Second},
+		"interval": {waitFor: "ready_for_writes"},
+		"no db":    {waitFor: "ready_for_writes", interval: time.Second, tables: []string{"users"}},
+	}
+	for name, wc := range tests {
+		if _, err := wc.waitTerms(&rootConfig{}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestWaitRetryable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, true},
+		{&response.ReqlAvailabilityError{Msg: "Table `app.users` does not exist."}, true},
+		{&response.ReqlNonExistenceError{Msg: "no such table"}, true},
+		{&response.ReqlPermissionError{Msg: "denied"}, false},
+		{fmt.Errorf("dial: %w", conn.ErrReqlAuth), false},
+	}
+	for _, tt := range tests {
+		if got := waitRetryable(context.Background(), tt.err); got != tt.want {
+			t.Errorf("waitRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRunWaitTimesOut(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close() // nothing listens: every dial is refused
+
+	cfg := &rootConfig{host: "127.0.0.1", port: port, timeout: 300 * time.Millisecond}
+	wc := &waitConfig{waitFor: "ready_for_writes", interval: 50 * time.Millisecond}
+	err = runWait(context.Background(), cfg, wc)
+	if err == nil || !strings.Contains(err.Error(), "not ready after") {
+		t.Errorf("got %v, want a not ready error", err)
+	}
+	if exitCode(err) != exitConnection {
+		t.Errorf("exit code %d, want %d", exitCode(err), exitConnection)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/cursor"
+	"r-cli/internal/parselog"
+	"r-cli/internal/query"
+	"r-cli/internal/reql"
+	"r-cli/internal/reql/parser"
+	"r-cli/internal/response"
+)
+
+// watchInitialBackoff is the first reconnect delay; it doubles up to --max-backoff.
+const watchInitialBackoff = time.Second
+
+// errWatchWrite marks output failures, which end the watch instead of reconnecting.
+var errWatchWrite = errors.New("watch: writing output")
+
+type watchConfig struct {
+	filter         string
+	includeInitial bool
+	includeStates  bool
+	includeTypes   bool
+	maxBackoff     time.Duration
+	idleTimeout    time.Duration
+	heartbeat      bool
+	actions        actionConfig
+}
+
+func newWatchCmd(cfg *rootConfig) *cobra.Command {
+	wc := &watchConfig{}
+	cmd := &cobra.Command{
+		Use:               "watch <table|db.table|expression>",
+		ValidArgsFunction: firstArg(completeTableRef(cfg)),
+		Short:             "Stream a changefeed as NDJSON, reconnecting on connection loss",
+		Long: "Open a changefeed and print every change as one JSON line with a leading \"ts\"\n" +
+			"field (UTC receive time). The argument is a table, which is watched with\n" +
+			"changes() (optionally narrowed by --filter), or a ReQL expression such as\n" +
+			"r.table('t').changes({squash: true}) that is run as given.\n" +
+			"The feed runs until interrupted; --timeout is ignored. When the connection\n" +
+			"is lost or the table becomes unavailable during a failover, the feed is\n" +
+			"reopened with exponential backoff up to --max-backoff, so changes made\n" +
+			"while disconnected are not seen. A reopened feed does not repeat\n" +
+			"--include-initial documents.\n" +
+			"--idle-timeout ends the watch when no change arrives for that long; with\n" +
+			"--heartbeat a {\"ts\":...,\"heartbeat\":true} line is printed instead and the\n" +
+			"watch goes on.\n" +
+			"--exec runs a shell command per change with the change JSON on stdin;\n" +
+			"--webhook POSTs the change JSON to a URL. Failed actions are retried\n" +
+			"--retries times and then reported on stderr; the feed keeps running.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(cmd.Context(), cfg, wc, args[0], os.Stdout)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVar(&wc.filter, "filter", "", "only watch documents matching a JSON object (table argument only)")
+	f.BoolVar(&wc.includeInitial, "include-initial", false, "emit the current documents before changes (table argument only)")
+	f.BoolVar(&wc.includeStates, "include-states", false, "emit feed state documents such as {\"state\":\"ready\"} (table argument only)")
+	f.BoolVar(&wc.includeTypes, "include-types", false, "add a type field (add, remove, change, initial) to changes (table argument only)")
+	f.DurationVar(&wc.maxBackoff, "max-backoff", 30*time.Second, "upper bound of the reconnect delay")
+	f.DurationVar(&wc.idleTimeout, "idle-timeout", 0, "exit when no change arrives for this long (0 = wait forever)")
+	f.BoolVar(&wc.heartbeat, "heartbeat", false, "with --idle-timeout, print a heartbeat line instead of exiting")
+	f.StringVar(&wc.actions.exec, "exec", "", "shell command run for each change, with the change JSON on stdin")
+	f.StringVar(&wc.actions.webhook, "webhook", "", "URL each change is POSTed to as JSON")
+	f.IntVar(&wc.actions.concurrency, "concurrency", 1, "maximum number of actions running at once (1 keeps feed order)")
+	f.IntVar(&wc.actions.retries, "retries", 3, "retries of a failed action")
+	f.DurationVar(&wc.actions.retryDelay, "retry-delay", time.Second, "delay before the first retry; doubles for each further retry")
+	f.DurationVar(&wc.actions.timeout, "action-timeout", 30*time.Second, "time limit of one action attempt (0 = none)")
+	cmd.MarkFlagsMutuallyExclusive("exec", "webhook")
+	return cmd
+}
+
+// isWatchExpr reports whether arg is a ReQL expression rather than a table name.
+func isWatchExpr(arg string) bool {
+	return strings.HasPrefix(arg, "r.") || strings.Contains(arg, "(")
+}
+
+// watchTerm builds the changefeed for arg: an expression is parsed and used
+// as is, a table is turned into table[.filter].changes(opts).
+func watchTerm(cfg *rootConfig, wc *watchConfig, arg string) (reql.Term, error) {
+	if isWatchExpr(arg) {
+		if wc.filter != "" || wc.includeInitial || wc.includeStates || wc.includeTypes {
+			return reql.Term{}, fmt.Errorf("watch: --filter and --include-* apply to a table argument; put them in the expression instead")
+		}
+		term, err := parser.Parse(arg)
+		if err != nil {
+			parselog.Log(arg, err)
+			return reql.Term{}, &queryError{err: fmt.Errorf("watch: %w", err)}
+		}
+		return term, nil
+	}
+	seq, err := listTable(cfg, arg)
+	if err != nil {
+		return reql.Term{}, err
+	}
+	if wc.filter != "" {
+		if seq, err = filterTerm(seq, wc.filter); err != nil {
+			return reql.Term{}, err
+		}
+	}
+	opts := reql.OptArgs{}
+	if wc.includeInitial {
+		opts["include_initial"] = true
+	}
+	if wc.includeStates {
+		opts["include_states"] = true
+	}
+	if wc.includeTypes {
+		opts["include_types"] = true
+	}
+	return seq.Changes(opts), nil
+}
+
+// runWatch keeps the feed open until ctx is done, the feed ends or the server
+// rejects the query. Connection errors reopen the feed after a backoff delay
+// that resets once a reopened feed delivers a change.
+func runWatch(ctx context.Context, cfg *rootConfig, wc *watchConfig, arg string, w io.Writer) error {
+	if err := wc.validate(); err != nil {
+		return err
+	}
+	term, err := watchTerm(cfg, wc, arg)
+	if err != nil {
+		return err
+	}
+	resumeTerm, err := watchResumeTerm(cfg, wc, arg, term)
+	if err != nil {
+		return err
+	}
+	emit := watchEmitter(ctx, w, &wc.actions, time.Now)
+	if emit.runner != nil {
+		defer emit.runner.wait()
+	}
+
+	exec, cleanup, err := newExecutor(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	copts := cfg.cursorOptions()
+	copts.IdleTimeout = wc.idleTimeout
+	exec.SetCursorOptions(copts)
+
+	resume := watchResume(cfg, wc, exec.Reopen(resumeTerm, nil))
+	onIdle := wc.onIdle(cfg, emit)
+	backoff := watchInitialBackoff
+	for {
+		n, err := watchOnce(ctx, exec, term, resume, emit.emit, onIdle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !watchRetryable(err) {
+			return err
+		}
+		if n > 0 {
+			backoff = watchInitialBackoff
+		}
+		cfg.log().Warn("watch: cannot open feed, retrying", "error", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, wc.maxBackoff)
+	}
+}
+
+// validate checks the watch flags.
+func (wc *watchConfig) validate() error {
+	if wc.maxBackoff <= 0 {
+		return fmt.Errorf("--max-backoff must be > 0")
+	}
+	if wc.idleTimeout < 0 {
+		return fmt.Errorf("--idle-timeout must be >= 0")
+	}
+	if wc.heartbeat && wc.idleTimeout == 0 {
+		return fmt.Errorf("--heartbeat requires --idle-timeout")
+	}
+	return wc.actions.validate()
+}
+
+// onIdle returns the watchOnce callback for a feed idle for --idle-timeout:
+// it prints a heartbeat with --heartbeat and otherwise ends the watch.
+func (wc *watchConfig) onIdle(cfg *rootConfig, e *changeEmitter) func() (bool, error) {
+	return func() (bool, error) {
+		if !wc.heartbeat {
+			cfg.log().Info("watch: no changes, exiting", "idle_timeout", wc.idleTimeout)
+			return false, nil
+		}
+		return true, e.heartbeat()
+	}
+}
+
+// watchResumeTerm returns the query that reopens a lost feed: term without
+// include_initial, so the current documents are not emitted again.
+func watchResumeTerm(cfg *rootConfig, wc *watchConfig, arg string, term reql.Term) (reql.Term, error) {
+	if !wc.includeInitial {
+		return term, nil
+	}
+	again := *wc
+	again.includeInitial = false
+	return watchTerm(cfg, &again, arg)
+}
+
+// watchResume makes the cursor reopen a lost feed with reopen, logging each
+// attempt and the include_states transitions.
+func watchResume(cfg *rootConfig, wc *watchConfig, reopen func(context.Context) (cursor.Feed, error)) cursor.Resume {
+	return cursor.Resume{
+		Reopen:     reopen,
+		Backoff:    watchInitialBackoff,
+		MaxBackoff: wc.maxBackoff,
+		Retryable:  watchRetryable,
+		Notify: func(ev cursor.ResumeEvent) {
+			if ev.Err == nil {
+				cfg.log().Info("watch: feed resumed", "attempt", ev.Attempt)
+				return
+			}
+			cfg.log().Warn("watch: feed lost, reconnecting", "error", ev.Cause, "attempt", ev.Attempt, "reopen_error", ev.Err, "backoff", ev.Backoff)
+		},
+		OnState: func(state string) { cfg.log().Info("watch: feed state", "state", state) },
+	}
+}
+
+// watchRetryable reports whether err is a lost connection or a failover worth
+// reopening the feed for; query and auth errors would fail the same way again.
+func watchRetryable(err error) bool {
+	if errors.Is(err, conn.ErrReqlAuth) || errors.Is(err, errWatchWrite) {
+		return false
+	}
+	var ae *response.ReqlAvailabilityError
+	return errors.As(err, &ae) || (err != nil && !isQueryError(err))
+}
+
+// changeEmitter writes each change to the output and hands it to the
+// configured action, if any.
+type changeEmitter struct {
+	ctx    context.Context
+	w      io.Writer
+	now    func() time.Time
+	runner *actionRunner
+}
+
+func watchEmitter(ctx context.Context, w io.Writer, ac *actionConfig, now func() time.Time) *changeEmitter {
+	e := &changeEmitter{ctx: ctx, w: w, now: now}
+	if action := ac.action(); action != nil {
+		e.runner = newActionRunner(ac, action, os.Stderr)
+	}
+	return e
+}
+
+func (e *changeEmitter) emit(row json.RawMessage) error {
+	line := stampChange(row, e.now())
+	if _, err := e.w.Write(line); err != nil {
+		return fmt.Errorf("%w: %w", errWatchWrite, err)
+	}
+	if e.runner != nil {
+		e.runner.dispatch(e.ctx, line)
+	}
+	return nil
+}
+
+// heartbeat writes a {"ts":...,"heartbeat":true} line; actions do not see it.
+func (e *changeEmitter) heartbeat() error {
+	if _, err := e.w.Write(stampChange(json.RawMessage(`{"heartbeat":true}`), e.now())); err != nil {
+		return fmt.Errorf("%w: %w", errWatchWrite, err)
+	}
+	return nil
+}
+
+// watchOnce runs term and passes its rows to emit until the cursor ends or
+// fails for good, returning the number of rows emitted. Lost feeds are
+// reopened by the cursor as configured by resume. When the cursor reports
+// cursor.ErrIdleTimeout, onIdle decides whether to keep waiting.
+func watchOnce(ctx context.Context, exec *query.Executor, term reql.Term, resume cursor.Resume, emit func(json.RawMessage) error, onIdle func() (bool, error)) (int, error) {
+	cur, err := exec.RunFeed(ctx, term, nil, resume)
+	if err != nil {
+		return 0, err
+	}
+	if cur == nil {
+		return 0, nil
+	}
+	defer func() { _ = cur.Close() }()
+	n := 0
+	for {
+		err = cur.Each(ctx, func(row json.RawMessage) error {
+			if err := emit(row); err != nil {
+				return err
+			}
+			n++
+			return nil
+		})
+		if !errors.Is(err, cursor.ErrIdleTimeout) {
+			return n, err
+		}
+		if more, err := onIdle(); !more || err != nil {
+			return n, err
+		}
+	}
+}
+
+// stampChange returns row as one NDJSON line with a leading "ts" field.
+// Non-object rows are wrapped as {"ts":...,"value":row}.
+func stampChange(row json.RawMessage, at time.Time) []byte {
+	ts, _ := json.Marshal(at.UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+	var buf bytes.Buffer
+	buf.WriteString(`{"ts":`)
+	buf.Write(ts)
+	body := bytes.TrimSpace(row)
+	switch {
+	case len(body) > 0 && body[0] == '{':
+		rest := bytes.TrimSpace(body[1:])
+		if len(rest) > 0 && rest[0] != '}' {
+			buf.WriteByte(',')
+		}
+		buf.Write(rest)
+	default:
+		buf.WriteString(`,"value":`)
+		buf.Write(body)
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
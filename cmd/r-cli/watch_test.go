@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/cursor"
+	"r-cli/internal/response"
+)
+
+func TestWatchCmdRegistered(t *testing.T) {
+	t.Parallel()
+	root := newRootCmd()
+	for _, sub := range root.Commands() {
+		if sub.Name() == "watch" {
+			return
+		}
+	}
+	t.Error("watch subcommand not registered on root command")
+}
+
+func TestWatchTerm(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		arg  string
+		wc   watchConfig
+		want string
+	}{
+		{"users", watchConfig{}, `[152,[[15,[[14,["test"]],"users"]]]]`},
+		{"app.users", watchConfig{includeStates: true}, `[152,[[15,[[14,["app"]],"users"]]],{"include_states":true}]`},
+		{"users", watchConfig{filter: `{"a":1}`}, `[152,[[39,[[15,[[14,["test"]],"users"]],[98,["{\"a\":1}"]]]]]]`},
+		{`r.table("users").changes({squash: true})`, watchConfig{}, `[152,[[15,["users"]]],{"squash":true}]`},
+	}
+	for _, tc := range tests {
+		term, err := watchTerm(&rootConfig{database: "test"}, &tc.wc, tc.arg)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.arg, err)
+		}
+		got, err := json.Marshal(term)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.arg, got, tc.want)
+		}
+	}
+}
+
+func TestWatchTermErrors(t *testing.T) {
+	t.Parallel()
+	if _, err := watchTerm(&rootConfig{}, &watchConfig{filter: "{}"}, `r.table("users").changes()`); err == nil {
+		t.Error("expected error for --filter with an expression")
+	}
+	_, err := watchTerm(&rootConfig{}, &watchConfig{}, `r.table(`)
+	if !isQueryError(err) {
+		t.Errorf("expected query error for bad expression, got %v", err)
+	}
+}
+
+func TestWatchRetryable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("cursor: connection closed"), true},
+		{conn.ErrClosed, true},
+		{&response.ReqlRuntimeError{Msg: "Table `x` does not exist."}, false},
+		{&response.ReqlAvailabilityError{Msg: "Primary replica for shard unavailable"}, true},
+		{fmt.Errorf("dial: %w", conn.ErrReqlAuth), false},
+		{fmt.Errorf("%w: broken pipe", errWatchWrite), false},
+	}
+	for _, tc := range tests {
+		if got := watchRetryable(tc.err); got != tc.want {
+			t.Errorf("watchRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestStampChange(t *testing.T) {
+	t.Parallel()
+	at := time.Date(2024, 5, 1, 12, 30, 0, 5e6, time.FixedZone("X", 3600))
+	tests := []struct {
+		row  string
+		want string
+	}{
+		{`{"new_val":{"id":1},"old_val":null}`, `{"ts":"2024-05-01T11:30:00.005Z","new_val":{"id":1},"old_val":null}`},
+		{` { "state" : "ready" } `, `{"ts":"2024-05-01T11:30:00.005Z","state" : "ready" }`},
+		{`{}`, `{"ts":"2024-05-01T11:30:00.005Z"}`},
+		{`42`, `{"ts":"2024-05-01T11:30:00.005Z","value":42}`},
+	}
+	for _, tc := range tests {
+		got := string(stampChange(json.RawMessage(tc.row), at))
+		if got != tc.want+"\n" {
+			t.Errorf("stampChange(%s) = %q, want %q", tc.row, got, tc.want+"\n")
+		}
+		if !json.Valid([]byte(got)) {
+			t.Errorf("stampChange(%s) is not valid JSON: %s", tc.row, got)
+		}
+	}
+}
+
+func TestRunWatchRejectsBadBackoff(t *testing.T) {
+	t.Parallel()
+	err := runWatch(t.Context(), &rootConfig{}, &watchConfig{}, "users", nil)
+	if err == nil {
+		t.Error("expected error for zero --max-backoff")
+	}
+}
+
+func TestChangeEmitterDispatchesAction(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	got := make(chan string, 1)
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	e := watchEmitter(t.Context(), &out, &actionConfig{}, func() time.Time { return at })
+	e.runner = newActionRunner(&actionConfig{concurrency: 1}, func(_ context.Context, change []byte) error {
+		got <- string(change)
+		return nil
+	}, io.Discard)
+	if err := e.emit(json.RawMessage(`{"new_val":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	e.runner.wait()
+	want := `{"ts":"2024-01-02T03:04:05.000Z","new_val":1}` + "\n"
+	if out.String() != want {
+		t.Errorf("output %q, want %q", out.String(), want)
+	}
+	if change := <-got; change != want {
+		t.Errorf("action got %q, want %q", change, want)
+	}
+}
+
+func TestWatchResume(t *testing.T) {
+	t.Parallel()
+	r := watchResume(&rootConfig{}, &watchConfig{maxBackoff: time.Minute}, nil)
+	if r.Backoff != watchInitialBackoff || r.MaxBackoff != time.Minute || r.MaxAttempts != 0 {
+		t.Errorf("resume = %+v", r)
+	}
+	if r.Retryable(fmt.Errorf("%w: broken pipe", errWatchWrite)) {
+		t.Error("output errors must not reopen the feed")
+	}
+	r.Notify(cursor.ResumeEvent{Attempt: 1, Cause: io.EOF, Err: io.EOF, Backoff: time.Second})
+	r.Notify(cursor.ResumeEvent{Attempt: 2, Cause: io.EOF})
+	r.OnState("ready")
+}
+
+func TestWatchResumeTerm(t *testing.T) {
+	t.Parallel()
+	cfg := &rootConfig{database: "test"}
+	wc := &watchConfig{includeInitial: true, includeTypes: true}
+	term, err := watchTerm(cfg, wc, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := watchResumeTerm(cfg, wc, "users", term)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.Marshal(again)
+	if want := `[152,[[15,[[14,["test"]],"users"]]],{"include_types":true}]`; string(got) != want {
+		t.Errorf("resume term = %s, want %s", got, want)
+	}
+	if !wc.includeInitial {
+		t.Error("watchResumeTerm modified the config")
+	}
+}
+
+func TestWatchConfigValidate(t *testing.T) {
+	t.Parallel()
+	ok := watchConfig{maxBackoff: time.Second, actions: actionConfig{concurrency: 1}}
+	tests := []struct {
+		name   string
+		modify func(*watchConfig)
+		want   string
+	}{
+		{"zero backoff", func(wc *watchConfig) { wc.maxBackoff = 0 }, "--max-backoff"},
+		{"negative idle", func(wc *watchConfig) { wc.idleTimeout = -time.Second }, "--idle-timeout"},
+		{"heartbeat alone", func(wc *watchConfig) { wc.heartbeat = true }, "--heartbeat requires --idle-timeout"},
+	}
+	for _, tc := range tests {
+		wc := ok
+		tc.modify(&wc)
+		if err := wc.validate(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: validate = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+	wc := ok
+	wc.idleTimeout, wc.heartbeat = time.Minute, true
+	if err := wc.validate(); err != nil {
+		t.Errorf("validate = %v", err)
+	}
+}
+
+func TestWatchOnIdle(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	e := watchEmitter(t.Context(), &out, &actionConfig{}, func() time.Time { return at })
+
+	more, err := (&watchConfig{idleTimeout: time.Minute}).onIdle(&rootConfig{}, e)()
+	if more || err != nil || out.Len() != 0 {
+		t.Errorf("without --heartbeat: more=%v err=%v output %q", more, err, out.String())
+	}
+	more, err = (&watchConfig{idleTimeout: time.Minute, heartbeat: true}).onIdle(&rootConfig{}, e)()
+	if !more || err != nil {
+		t.Fatalf("with --heartbeat: more=%v err=%v", more, err)
+	}
+	if want := `{"ts":"2024-01-02T03:04:05.000Z","heartbeat":true}` + "\n"; out.String() != want {
+		t.Errorf("heartbeat %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// watchAction handles one change line (the stamped JSON written to stdout).
+type watchAction func(ctx context.Context, change []byte) error
+
+type actionConfig struct {
+	exec        string
+	webhook     string
+	concurrency int
+	retries     int
+	retryDelay  time.Duration
+	timeout     time.Duration
+}
+
+// validate checks the action flags; it is a no-op when no action is set.
+func (ac *actionConfig) validate() error {
+	switch {
+	case ac.exec != "" && ac.webhook != "":
+		return fmt.Errorf("watch: --exec and --webhook are mutually exclusive")
+	case ac.concurrency < 1:
+		return fmt.Errorf("--concurrency must be >= 1")
+	case ac.retries < 0:
+		return fmt.Errorf("--retries must be >= 0")
+	case ac.retryDelay < 0 || ac.timeout < 0:
+		return fmt.Errorf("--retry-delay and --action-timeout must be >= 0")
+	}
+	return nil
+}
+
+// action returns the configured action, or nil when neither --exec nor
+// --webhook is set.
+func (ac *actionConfig) action() watchAction {
+	switch {
+	case ac.exec != "":
+		return execAction(ac.exec, os.Stderr)
+	case ac.webhook != "":
+		return webhookAction(ac.webhook, http.DefaultClient)
+	}
+	return nil
+}
+
+// execAction runs command through sh -c with the change on stdin. The
+// command's output goes to errOut so it cannot corrupt the NDJSON stream.
+func execAction(command string, errOut io.Writer) watchAction {
+	return func(ctx context.Context, change []byte) error {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec
+		cmd.Stdin = bytes.NewReader(change)
+		cmd.Stdout = errOut
+		cmd.Stderr = errOut
+		return cmd.Run()
+	}
+}
+
+// webhookAction POSTs the change as application/json; any non-2xx status is
+// an error.
+func webhookAction(url string, client *http.Client) watchAction {
+	return func(ctx context.Context, change []byte) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(change))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook returned %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// actionRunner runs an action for each change with at most concurrency
+// invocations in flight. With concurrency 1 changes are handled in feed order.
+type actionRunner struct {
+	action     watchAction
+	retries    int
+	retryDelay time.Duration
+	timeout    time.Duration
+	errOut     io.Writer
+
+	sem chan struct{}
+	wg  sync.WaitGroup
+	mu  sync.Mutex
+}
+
+func newActionRunner(ac *actionConfig, action watchAction, errOut io.Writer) *actionRunner {
+	return &actionRunner{
+		action:     action,
+		retries:    ac.retries,
+		retryDelay: ac.retryDelay,
+		timeout:    ac.timeout,
+		errOut:     errOut,
+		sem:        make(chan struct{}, ac.concurrency),
+	}
+}
+
+// dispatch starts the action for change, blocking while concurrency
+// invocations are already running so a slow action applies backpressure to
+// the feed instead of queueing without bound.
+func (r *actionRunner) dispatch(ctx context.Context, change []byte) {
+	select {
+	case r.sem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() { <-r.sem }()
+		if err := r.runWithRetry(ctx, change); err != nil && ctx.Err() == nil {
+			r.mu.Lock()
+			_, _ = fmt.Fprintf(r.errOut, "watch: action failed after %d attempts: %v\n", r.retries+1, err)
+			r.mu.Unlock()
+		}
+	}()
+}
+
+// wait blocks until every dispatched action has finished.
+func (r *actionRunner) wait() { r.wg.Wait() }
+
+// runWithRetry tries the action up to retries+1 times, doubling the delay
+// between attempts.
+func (r *actionRunner) runWithRetry(ctx context.Context, change []byte) error {
+	delay := r.retryDelay
+	var err error
+	for attempt := 0; attempt <= r.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		if err = r.attempt(ctx, change); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (r *actionRunner) attempt(ctx context.Context, change []byte) error {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	return r.action(ctx, change)
+}
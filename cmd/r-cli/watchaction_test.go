@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestActionConfigValidate(t *testing.T) {
+	t.Parallel()
+	ok := actionConfig{concurrency: 1}
+	if err := ok.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	bad := []actionConfig{
+		{exec: "true", webhook: "http://x", concurrency: 1},
+		{concurrency: 0},
+		{concurrency: 1, retries: -1},
+		{concurrency: 1, retryDelay: -time.Second},
+	}
+	for _, ac := range bad {
+		if err := ac.validate(); err == nil {
+			t.Errorf("%+v: expected error", ac)
+		}
+	}
+	if (&actionConfig{}).action() != nil {
+		t.Error("expected nil action without --exec or --webhook")
+	}
+}
+
+func TestExecAction(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	act := execAction("cat; echo", &out)
+	if err := act(t.Context(), []byte(`{"a":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "{\"a\":1}\n" {
+		t.Errorf("got %q", out.String())
+	}
+	if err := execAction("exit 3", io.Discard)(t.Context(), nil); err == nil {
+		t.Error("expected error for non-zero exit")
+	}
+}
+
+func TestWebhookAction(t *testing.T) {
+	t.Parallel()
+	var got atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "application/json" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got.Store(string(body))
+		if strings.Contains(string(body), "fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	act := webhookAction(srv.URL, srv.Client())
+	if err := act(t.Context(), []byte(`{"a":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got.Load() != `{"a":1}` {
+		t.Errorf("server got %v", got.Load())
+	}
+	if err := act(t.Context(), []byte(`{"fail":true}`)); err == nil {
+		t.Error("expected error for 500 response")
+	}
+}
+
+func TestActionRunnerRetries(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	act := func(context.Context, []byte) error {
+		if calls.Add(1) < 3 {
+			return errors.New("boom")
+		}
+		return nil
+	}
+	var errOut bytes.Buffer
+	r := newActionRunner(&actionConfig{concurrency: 1, retries: 2, retryDelay: time.Millisecond}, act, &errOut)
+	r.dispatch(t.Context(), []byte("{}"))
+	r.wait()
+	if calls.Load() != 3 {
+		t.Errorf("got %d calls, want 3", calls.Load())
+	}
+	if errOut.Len() != 0 {
+		t.Errorf("unexpected failure report: %s", errOut.String())
+	}
+}
+
+func TestActionRunnerReportsFailure(t *testing.T) {
+	t.Parallel()
+	act := func(context.Context, []byte) error { return errors.New("boom") }
+	var errOut bytes.Buffer
+	r := newActionRunner(&actionConfig{concurrency: 1, retries: 1}, act, &errOut)
+	r.dispatch(t.Context(), []byte("{}"))
+	r.wait()
+	if !strings.Contains(errOut.String(), "action failed after 2 attempts: boom") {
+		t.Errorf("got %q", errOut.String())
+	}
+}
+
+func TestActionRunnerConcurrency(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	running, peak := 0, 0
+	act := func(context.Context, []byte) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}
+	r := newActionRunner(&actionConfig{concurrency: 2}, act, io.Discard)
+	for range 8 {
+		r.dispatch(t.Context(), []byte("{}"))
+	}
+	r.wait()
+	if peak > 2 {
+		t.Errorf("peak concurrency %d, want <= 2", peak)
+	}
+}
+
+func TestActionRunnerTimeout(t *testing.T) {
+	t.Parallel()
+	act := func(ctx context.Context, _ []byte) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	var errOut bytes.Buffer
+	r := newActionRunner(&actionConfig{concurrency: 1, timeout: time.Millisecond}, act, &errOut)
+	r.dispatch(t.Context(), []byte("{}"))
+	r.wait()
+	if !strings.Contains(errOut.String(), "deadline exceeded") {
+		t.Errorf("got %q", errOut.String())
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"os"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"r-cli/internal/proto"
+	"r-cli/internal/response"
 	"r-cli/internal/wire"
 )
 
@@ -20,6 +25,20 @@ var ErrClosed = errors.New("conn: connection closed")
 // stopPayload is the wire payload for a STOP query (proto.QueryStop = 3).
 var stopPayload = []byte(`[3]`)
 
+// noreplyWaitPayload is the wire payload for NOREPLY_WAIT (proto.QueryNoreplyWait = 4).
+var noreplyWaitPayload = []byte(`[4]`)
+
+// serverInfoPayload is the wire payload for SERVER_INFO (proto.QueryServerInfo = 5).
+var serverInfoPayload = []byte(`[5]`)
+
+// ServerInfo describes the server at the other end of a connection.
+type ServerInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Proxy   bool   `json:"proxy"`
+	Version string `json:"version"` // from the handshake; not part of the SERVER_INFO response
+}
+
 // result carries the outcome of a dispatched response.
 type result struct {
 	payload []byte
@@ -32,6 +51,44 @@ type Config struct {
 	Port     int    `json:"port"`
 	User     string `json:"user"`
 	Password string `json:"-"`
+	// Logger receives every frame at debug level; nil disables frame logging.
+	Logger *slog.Logger `json:"-"`
+	// Tracer, if set, is called for every frame sent or received.
+	Tracer Tracer `json:"-"`
+	// HandshakeStepTimeout bounds each handshake round trip separately from
+	// the dial context; 0 leaves only the context.
+	HandshakeStepTimeout time.Duration `json:"handshake_step_timeout,omitempty"`
+	// ConnectTimeout bounds Dial as a whole, the TCP dial and the handshake
+	// including a protocol fallback; 0 leaves only the dial context. Queries
+	// on the connection are not bound by it.
+	ConnectTimeout time.Duration `json:"connect_timeout,omitempty"`
+	// MaxResponseSize caps the payload of one response frame; larger
+	// responses fail their query with a *wire.FrameTooLargeError. 0 means
+	// proto.MaxFrameSize (64MB).
+	MaxResponseSize uint32 `json:"max_response_size,omitempty"`
+	// MaxInFlight caps the queries waiting for a response on one
+	// connection; further Send calls block until one finishes. 0 is no limit.
+	MaxInFlight int `json:"max_in_flight,omitempty"`
+	// Protocol is the handshake version to speak: proto.V1_0 or proto.V0_4.
+	// Zero tries V1_0 and redials with V0_4 when the server rejects it.
+	Protocol proto.Version `json:"protocol,omitempty"`
+	DialOptions
+}
+
+// DialOptions tunes the TCP connection opened by Dial and DialTLS.
+type DialOptions struct {
+	// KeepAlive is the TCP keepalive probe interval; 0 uses the Go default
+	// (15s) and a negative value disables keepalive probes.
+	KeepAlive time.Duration `json:"keepalive,omitempty"`
+	// Nagle leaves Nagle's algorithm on; by default TCP_NODELAY is set so
+	// small query frames are sent immediately.
+	Nagle bool `json:"nagle,omitempty"`
+	// LocalAddr is the source IP to dial from, optionally with a port.
+	LocalAddr string `json:"local_addr,omitempty"`
+	// Dial, if set, opens the underlying connection instead of a direct TCP
+	// dial (e.g. through an SSH tunnel); KeepAlive and LocalAddr are then
+	// ignored. TLS is still layered on top.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error) `json:"-"`
 }
 
 // String returns Config without the password.
@@ -50,21 +107,56 @@ type Conn struct {
 	closed  bool
 	done    chan struct{}
 	debug   bool
+	log     *slog.Logger
+	version string
+	tracer  Tracer
+	sentAt  map[uint64]time.Time // guarded by mu; send time per token, kept only with a tracer
+	slots   chan struct{}        // in-flight semaphore; nil without MaxInFlight
+	maxResp uint32
 }
 
-// Dial connects to addr, performs the V1_0 handshake, and starts the readLoop.
-// tlsCfg may be nil for a plain TCP connection.
+// Dial connects to addr, performs the handshake of cfg.Protocol, and starts
+// the readLoop. tlsCfg may be nil for a plain TCP connection.
 func Dial(ctx context.Context, addr string, cfg Config, tlsCfg *tls.Config) (*Conn, error) {
-	nc, err := dialNet(ctx, addr, tlsCfg)
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
+	}
+	if cfg.Protocol != 0 {
+		return dialVersion(ctx, addr, cfg, tlsCfg, cfg.Protocol)
+	}
+	c, err := dialVersion(ctx, addr, cfg, tlsCfg, proto.V1_0)
+	if !errors.Is(err, ErrLegacyProtocol) {
+		return c, err
+	}
+	// V0_4 sends the password as a plaintext auth key, so a forged ERROR:
+	// reply must not be able to downgrade a SCRAM login to it
+	if cfg.Password != "" {
+		return nil, fmt.Errorf("%w; not retrying with V0_4, which sends the password in plaintext, unless the protocol is set to V0_4", err)
+	}
+	if cfg.Logger != nil {
+		cfg.Logger.Warn("server rejected protocol V1_0, retrying with V0_4", "addr", addr)
+	}
+	return dialVersion(ctx, addr, cfg, tlsCfg, proto.V0_4)
+}
+
+// dialVersion dials addr and performs the handshake of protocol v.
+func dialVersion(ctx context.Context, addr string, cfg Config, tlsCfg *tls.Config, v proto.Version) (*Conn, error) {
+	nc, err := dialNet(ctx, addr, tlsCfg, cfg.DialOptions)
 	if err != nil {
 		return nil, fmt.Errorf("dial %s: %w", addr, err)
 	}
 
 	// run handshake in a goroutine to respect context cancellation
-	type hsResult struct{ err error }
+	type hsResult struct {
+		version string
+		err     error
+	}
 	hsC := make(chan hsResult, 1)
 	go func() {
-		hsC <- hsResult{err: Handshake(nc, cfg.User, cfg.Password)}
+		version, err := handshakeVersion(nc, v, cfg.User, cfg.Password, cfg.HandshakeStepTimeout)
+		hsC <- hsResult{version: version, err: err}
 	}()
 
 	select {
@@ -77,17 +169,19 @@ func Dial(ctx context.Context, addr string, cfg Config, tlsCfg *tls.Config) (*Co
 			_ = nc.Close()
 			return nil, fmt.Errorf("dial %s: %w", addr, res.err)
 		}
+		c := newConn(nc, cfg)
+		c.version = res.version
+		return c, nil
 	}
-	return newConn(nc), nil
 }
 
 // DialTLS establishes a TLS TCP connection to addr using tlsCfg without performing
 // the RethinkDB handshake. If tlsCfg is nil, a default tls.Config is used.
-func DialTLS(ctx context.Context, addr string, tlsCfg *tls.Config) (net.Conn, error) {
+func DialTLS(ctx context.Context, addr string, tlsCfg *tls.Config, opts DialOptions) (net.Conn, error) {
 	if tlsCfg == nil {
 		tlsCfg = &tls.Config{} //nolint:gosec
 	}
-	nc, err := dialNet(ctx, addr, tlsCfg)
+	nc, err := dialNet(ctx, addr, tlsCfg, opts)
 	if err != nil {
 		return nil, fmt.Errorf("dial tls %s: %w", addr, err)
 	}
@@ -95,27 +189,110 @@ func DialTLS(ctx context.Context, addr string, tlsCfg *tls.Config) (net.Conn, er
 }
 
 // dialNet establishes a TCP or TLS connection.
-func dialNet(ctx context.Context, addr string, tlsCfg *tls.Config) (net.Conn, error) {
-	d := &net.Dialer{}
+func dialNet(ctx context.Context, addr string, tlsCfg *tls.Config, opts DialOptions) (net.Conn, error) {
+	if opts.Dial != nil {
+		return dialCustom(ctx, addr, tlsCfg, opts.Dial)
+	}
+	d, err := opts.dialer()
+	if err != nil {
+		return nil, err
+	}
+	var nc net.Conn
 	if tlsCfg != nil {
 		td := tls.Dialer{NetDialer: d, Config: tlsCfg}
-		return td.DialContext(ctx, "tcp", addr)
+		nc, err = td.DialContext(ctx, "tcp", addr)
+	} else {
+		nc, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil || !opts.Nagle {
+		return nc, err
+	}
+	if tc, ok := tcpConn(nc); ok {
+		if err := tc.SetNoDelay(false); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+	}
+	return nc, nil
+}
+
+// dialCustom opens the connection with dial and, when tlsCfg is set, runs
+// the TLS handshake over it, verifying the name in addr like tls.Dialer.
+func dialCustom(ctx context.Context, addr string, tlsCfg *tls.Config, dial func(ctx context.Context, network, addr string) (net.Conn, error)) (net.Conn, error) {
+	nc, err := dial(ctx, "tcp", addr)
+	if err != nil || tlsCfg == nil {
+		return nc, err
+	}
+	if tlsCfg.ServerName == "" {
+		host, _, _ := net.SplitHostPort(addr)
+		tlsCfg = tlsCfg.Clone()
+		tlsCfg.ServerName = host
+	}
+	tc := tls.Client(nc, tlsCfg)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+	return tc, nil
+}
+
+// dialer returns a net.Dialer with the keepalive interval and source address.
+func (o DialOptions) dialer() (*net.Dialer, error) {
+	d := &net.Dialer{KeepAlive: o.KeepAlive}
+	if o.LocalAddr == "" {
+		return d, nil
+	}
+	addr := o.LocalAddr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "0")
 	}
-	return d.DialContext(ctx, "tcp", addr)
+	local, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("source address %q: %w", o.LocalAddr, err)
+	}
+	d.LocalAddr = local
+	return d, nil
 }
 
-// newConn wraps nc in a Conn and starts the background readLoop.
-func newConn(nc net.Conn) *Conn {
+// tcpConn returns the TCP connection under nc, unwrapping TLS.
+func tcpConn(nc net.Conn) (*net.TCPConn, bool) {
+	if tc, ok := nc.(*tls.Conn); ok {
+		nc = tc.NetConn()
+	}
+	tcp, ok := nc.(*net.TCPConn)
+	return tcp, ok
+}
+
+// newConn wraps nc in a Conn configured by the Logger, Tracer and limit
+// fields of cfg and starts the background readLoop.
+func newConn(nc net.Conn, cfg Config) *Conn {
 	c := &Conn{
 		nc:      nc,
+		log:     cfg.Logger,
 		waiters: make(map[uint64]chan result),
 		done:    make(chan struct{}),
 		debug:   os.Getenv("RCLI_DEBUG") == "wire",
+		tracer:  cfg.Tracer,
+		maxResp: cfg.MaxResponseSize,
+	}
+	if c.maxResp == 0 {
+		c.maxResp = proto.MaxFrameSize
+	}
+	if c.tracer != nil {
+		c.sentAt = make(map[uint64]time.Time)
+	}
+	if cfg.MaxInFlight > 0 {
+		c.slots = make(chan struct{}, cfg.MaxInFlight)
 	}
 	go c.readLoop()
 	return c
 }
 
+// ServerVersion returns the server_version reported during the handshake.
+func (c *Conn) ServerVersion() string {
+	return c.version
+}
+
 // IsClosed reports whether the connection is closed.
 func (c *Conn) IsClosed() bool {
 	c.mu.Lock()
@@ -123,6 +300,13 @@ func (c *Conn) IsClosed() bool {
 	return c.closed
 }
 
+// Pending returns the number of queries waiting for a response.
+func (c *Conn) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
 // Close closes the underlying connection and waits for all pending Send calls to unblock.
 func (c *Conn) Close() error {
 	c.mu.Lock()
@@ -140,7 +324,13 @@ func (c *Conn) Close() error {
 
 // Send registers a waiter for token, writes the query frame, and waits for the response.
 // If ctx is cancelled, a STOP frame is sent and the waiter is cleaned up.
+// With MaxInFlight set, Send first waits for a free slot.
 func (c *Conn) Send(ctx context.Context, token uint64, payload []byte) ([]byte, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	ch := make(chan result, 1)
 
 	c.mu.Lock()
@@ -155,6 +345,8 @@ func (c *Conn) Send(ctx context.Context, token uint64, payload []byte) ([]byte,
 	if c.debug {
 		_, _ = fmt.Fprintf(os.Stderr, "wire out: token=%d len=%d\n%s", token, len(payload), hex.Dump(payload))
 	}
+	c.logFrame("frame out", token, payload)
+	c.traceOut(token, payload)
 	werr := wire.WriteQuery(c.nc, token, payload)
 	c.writeMu.Unlock()
 
@@ -177,13 +369,78 @@ func (c *Conn) Send(ctx context.Context, token uint64, payload []byte) ([]byte,
 	}
 }
 
+// acquire takes an in-flight slot, waiting while all are in use; the
+// returned func gives it back.
+func (c *Conn) acquire(ctx context.Context) (func(), error) {
+	if c.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, ErrClosed
+	}
+}
+
 // sendStop sends a STOP query for the given token; write errors are silently ignored.
 func (c *Conn) sendStop(token uint64) {
 	c.writeMu.Lock()
+	c.traceOut(token, stopPayload)
 	_ = wire.WriteQuery(c.nc, token, stopPayload)
 	c.writeMu.Unlock()
 }
 
+// NoreplyWait blocks until the server has processed every noreply query
+// sent on this connection before the call.
+func (c *Conn) NoreplyWait(ctx context.Context) error {
+	raw, err := c.Send(ctx, c.NextToken(), noreplyWaitPayload)
+	if err != nil {
+		return fmt.Errorf("noreply wait: %w", err)
+	}
+	resp, err := response.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("noreply wait: %w", err)
+	}
+	if err := response.MapError(resp); err != nil {
+		return err
+	}
+	if resp.Type != proto.ResponseWaitComplete {
+		return fmt.Errorf("noreply wait: unexpected response type %d", resp.Type)
+	}
+	return nil
+}
+
+// ServerInfo sends SERVER_INFO and returns the server's id, name and proxy
+// flag, with Version filled in from the handshake.
+func (c *Conn) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	raw, err := c.Send(ctx, c.NextToken(), serverInfoPayload)
+	if err != nil {
+		return nil, fmt.Errorf("server info: %w", err)
+	}
+	resp, err := response.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("server info: %w", err)
+	}
+	if err := response.MapError(resp); err != nil {
+		return nil, err
+	}
+	if resp.Type != proto.ResponseServerInfo {
+		return nil, fmt.Errorf("server info: unexpected response type %d", resp.Type)
+	}
+	if len(resp.Results) == 0 {
+		return nil, errors.New("server info: empty response")
+	}
+	var info ServerInfo
+	if err := json.Unmarshal(resp.Results[0], &info); err != nil {
+		return nil, fmt.Errorf("server info: %w", err)
+	}
+	info.Version = c.ServerVersion()
+	return &info, nil
+}
+
 // NextToken returns the next unique query token, incrementing atomically.
 func (c *Conn) NextToken() uint64 {
 	return c.token.Add(1)
@@ -199,6 +456,8 @@ func (c *Conn) WriteFrame(token uint64, payload []byte) error {
 	}
 	c.mu.Unlock()
 	c.writeMu.Lock()
+	c.logFrame("frame out", token, payload)
+	c.traceOut(token, payload)
 	err := wire.WriteQuery(c.nc, token, payload)
 	c.writeMu.Unlock()
 	return err
@@ -208,7 +467,12 @@ func (c *Conn) WriteFrame(token uint64, payload []byte) error {
 func (c *Conn) readLoop() {
 	defer close(c.done)
 	for {
-		token, payload, err := wire.ReadResponse(c.nc)
+		token, payload, err := wire.ReadResponseLimit(c.nc, c.maxResp)
+		var tooLarge *wire.FrameTooLargeError
+		if errors.As(err, &tooLarge) {
+			c.fail(token, err)
+			continue
+		}
 		if err != nil {
 			// close nc to release the fd when the connection dies unexpectedly
 			// (user's Close() won't call nc.Close() once closed=true is set)
@@ -219,10 +483,27 @@ func (c *Conn) readLoop() {
 		if c.debug {
 			_, _ = fmt.Fprintf(os.Stderr, "wire in: token=%d len=%d\n%s", token, len(payload), hex.Dump(payload))
 		}
+		c.logFrame("frame in", token, payload)
+		c.traceIn(token, payload)
 		c.dispatch(token, payload)
 	}
 }
 
+// maxLoggedPayload caps the payload text of a logged frame.
+const maxLoggedPayload = 512
+
+// logFrame records a frame at debug level, with the payload cut to maxLoggedPayload bytes.
+func (c *Conn) logFrame(msg string, token uint64, payload []byte) {
+	if c.log == nil || !c.log.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	text := payload
+	if len(text) > maxLoggedPayload {
+		text = text[:maxLoggedPayload]
+	}
+	c.log.Debug(msg, "token", token, "bytes", len(payload), "payload", string(text))
+}
+
 // dispatch sends payload to the waiter registered for token, if any.
 // Responses for unknown or removed tokens are silently discarded.
 func (c *Conn) dispatch(token uint64, payload []byte) {
@@ -243,6 +524,20 @@ func (c *Conn) dispatch(token uint64, payload []byte) {
 	}
 }
 
+// fail delivers err to the waiter registered for token, if any.
+func (c *Conn) fail(token uint64, err error) {
+	c.mu.Lock()
+	ch, ok := c.waiters[token]
+	delete(c.waiters, token)
+	c.mu.Unlock()
+	if ok {
+		select {
+		case ch <- result{err: err}:
+		default:
+		}
+	}
+}
+
 // closeWaiters delivers err to all pending waiters and marks the connection closed.
 func (c *Conn) closeWaiters(err error) {
 	c.mu.Lock()
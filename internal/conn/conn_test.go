@@ -9,16 +9,22 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"math/big"
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"r-cli/internal/proto"
 	"r-cli/internal/wire"
 )
 
@@ -83,6 +89,16 @@ func TestConfigStringNoPassword(t *testing.T) {
 // setupConn creates a client *Conn over net.Pipe, performing the V1_0 handshake.
 // Returns the client Conn (ready for wire queries) and the raw server-side net.Conn.
 func setupConn(t *testing.T) (clientConn *Conn, serverNC net.Conn) {
+	t.Helper()
+	return setupConnWithLogger(t, nil)
+}
+
+func setupConnWithLogger(t *testing.T, log *slog.Logger) (clientConn *Conn, serverNC net.Conn) {
+	t.Helper()
+	return setupConnWithConfig(t, Config{Logger: log})
+}
+
+func setupConnWithConfig(t *testing.T, cfg Config) (clientConn *Conn, serverNC net.Conn) {
 	t.Helper()
 	client, srvNC := net.Pipe()
 	t.Cleanup(func() {
@@ -97,7 +113,7 @@ func setupConn(t *testing.T) (clientConn *Conn, serverNC net.Conn) {
 	if err := Handshake(client, user, pass); err != nil {
 		t.Fatalf("setupConn: Handshake: %v", err)
 	}
-	c := newConn(client)
+	c := newConn(client, cfg)
 	t.Cleanup(func() { _ = c.Close() })
 	return c, srvNC
 }
@@ -437,6 +453,42 @@ func TestConnContextCancellationSendsStop(t *testing.T) { //nolint:cyclop
 	}
 }
 
+func TestDialConnectTimeout(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	done := make(chan struct{})
+	t.Cleanup(func() { _ = ln.Close(); <-done })
+	// accept connections but never answer the handshake
+	go func() {
+		defer close(done)
+		var conns []net.Conn
+		defer func() {
+			for _, nc := range conns {
+				_ = nc.Close()
+			}
+		}()
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, nc)
+		}
+	}()
+
+	start := time.Now()
+	cfg := Config{User: "admin", ConnectTimeout: 50 * time.Millisecond}
+	if _, err := Dial(context.Background(), ln.Addr().String(), cfg, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("timed out after %v", d)
+	}
+}
+
 func TestDialContextCancellationNoGoroutineLeak(t *testing.T) {
 	t.Parallel()
 
@@ -585,7 +637,7 @@ func TestDialTLSValidCACert(t *testing.T) {
 		t.Fatal("AppendCertsFromPEM: no valid certificate found")
 	}
 
-	nc, err := DialTLS(context.Background(), addr, &tls.Config{RootCAs: pool})
+	nc, err := DialTLS(context.Background(), addr, &tls.Config{RootCAs: pool}, DialOptions{})
 	if err != nil {
 		t.Fatalf("DialTLS: %v", err)
 	}
@@ -620,7 +672,7 @@ func TestDialTLSWrongCACert(t *testing.T) {
 		t.Fatal("AppendCertsFromPEM: no valid certificate found")
 	}
 
-	_, err = DialTLS(context.Background(), addr, &tls.Config{RootCAs: wrongPool})
+	_, err = DialTLS(context.Background(), addr, &tls.Config{RootCAs: wrongPool}, DialOptions{})
 	if err == nil {
 		t.Fatal("expected TLS verification error, got nil")
 	}
@@ -630,11 +682,206 @@ func TestDialTLSInsecureSkipVerify(t *testing.T) {
 	t.Parallel()
 	addr, _ := testTLSServer(t)
 
-	nc, err := DialTLS(context.Background(), addr, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec
+	nc, err := DialTLS(context.Background(), addr, &tls.Config{InsecureSkipVerify: true}, DialOptions{}) //nolint:gosec
+	if err != nil {
+		t.Fatalf("DialTLS: %v", err)
+	}
+	_ = nc.Close()
+}
+
+func TestDialTLSSourceAddrAndNagle(t *testing.T) {
+	t.Parallel()
+	addr, _ := testTLSServer(t)
+
+	// reserve a free port to dial from
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := ln.Addr().String()
+	_ = ln.Close()
+
+	opts := DialOptions{KeepAlive: time.Second, Nagle: true, LocalAddr: src}
+	nc, err := DialTLS(context.Background(), addr, &tls.Config{InsecureSkipVerify: true}, opts) //nolint:gosec
+	if err != nil {
+		t.Fatalf("DialTLS: %v", err)
+	}
+	defer func() { _ = nc.Close() }()
+	if got := nc.LocalAddr().String(); got != src {
+		t.Errorf("local addr = %s, want %s", got, src)
+	}
+}
+
+func TestConnNoreplyWait(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		resp    string
+		wantErr string
+	}{
+		{`{"t":4,"r":[]}`, ""},
+		{`{"t":1,"r":[1]}`, "unexpected response type 1"},
+		{`{"t":16,"r":["bad query"]}`, "bad query"},
+	} {
+		c, server := setupConn(t)
+		go func() {
+			tok, payload, err := wire.ReadResponse(server)
+			if err != nil || string(payload) != "[4]" {
+				t.Errorf("server read %q, %v; want [4]", payload, err)
+				return
+			}
+			_ = wire.WriteQuery(server, tok, []byte(tc.resp))
+		}()
+		err := c.NoreplyWait(context.Background())
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: %v", tc.resp, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: got %v, want error containing %q", tc.resp, err, tc.wantErr)
+		}
+	}
+}
+
+func TestConnServerInfo(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		resp    string
+		wantErr string
+	}{
+		{`{"t":5,"r":[{"id":"4e1b","name":"rdb_a","proxy":true}]}`, ""},
+		{`{"t":5,"r":[]}`, "empty response"},
+		{`{"t":1,"r":[1]}`, "unexpected response type 1"},
+		{`{"t":16,"r":["bad query"]}`, "bad query"},
+	} {
+		c, server := setupConn(t)
+		go func() {
+			tok, payload, err := wire.ReadResponse(server)
+			if err != nil || string(payload) != "[5]" {
+				t.Errorf("server read %q, %v; want [5]", payload, err)
+				return
+			}
+			_ = wire.WriteQuery(server, tok, []byte(tc.resp))
+		}()
+		info, err := c.ServerInfo(context.Background())
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("%s: got %v, want error containing %q", tc.resp, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.resp, err)
+		}
+		if info.ID != "4e1b" || info.Name != "rdb_a" || !info.Proxy || info.Version != c.ServerVersion() {
+			t.Errorf("info = %+v", info)
+		}
+	}
+}
+
+func TestConnMaxInFlight(t *testing.T) {
+	t.Parallel()
+	c, server := setupConnWithConfig(t, Config{MaxInFlight: 1})
+
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := c.Send(context.Background(), c.NextToken(), []byte(`[1,1,{}]`))
+			errs <- err
+		}()
+	}
+	tok, _, err := wire.ReadResponse(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = server.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, _, err := wire.ReadResponse(server); err == nil {
+		t.Fatal("second query sent while the first was in flight")
+	}
+	_ = server.SetReadDeadline(time.Time{})
+	_ = wire.WriteQuery(server, tok, []byte(`{"t":1,"r":[1]}`))
+	tok, _, err = wire.ReadResponse(server)
+	if err != nil {
+		t.Fatalf("second query not sent after the first finished: %v", err)
+	}
+	_ = wire.WriteQuery(server, tok, []byte(`{"t":1,"r":[2]}`))
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Errorf("Send: %v", err)
+		}
+	}
+
+	// a full connection honors the caller's context while waiting
+	c.slots <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Send(ctx, c.NextToken(), []byte(`[1,1,{}]`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiting for a slot: got %v, want deadline exceeded", err)
+	}
+}
+
+func TestConnResponseTooLarge(t *testing.T) {
+	t.Parallel()
+	c, server := setupConnWithConfig(t, Config{MaxResponseSize: 16})
+	go func() {
+		for range 2 {
+			tok, _, err := wire.ReadResponse(server)
+			if err != nil {
+				return
+			}
+			resp := `{"t":1,"r":[1]}`
+			if tok == 1 {
+				resp = `{"t":1,"r":["` + strings.Repeat("x", 64) + `"]}`
+			}
+			_ = wire.WriteQuery(server, tok, []byte(resp))
+		}
+	}()
+	_, err := c.Send(context.Background(), c.NextToken(), []byte(`[1,1,{}]`))
+	var tooLarge *wire.FrameTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 16 {
+		t.Fatalf("got %v, want FrameTooLargeError with limit 16", err)
+	}
+	// the oversized frame was skipped; the connection keeps working
+	raw, err := c.Send(context.Background(), c.NextToken(), []byte(`[1,1,{}]`))
+	if err != nil || string(raw) != `{"t":1,"r":[1]}` {
+		t.Errorf("next query: %s, %v", raw, err)
+	}
+}
+
+func TestDialTLSCustomDial(t *testing.T) {
+	t.Parallel()
+	addr, certPEM := testTLSServer(t)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+
+	var dialed atomic.Int32
+	opts := DialOptions{Dial: func(ctx context.Context, network, a string) (net.Conn, error) {
+		dialed.Add(1)
+		var d net.Dialer
+		return d.DialContext(ctx, network, a)
+	}}
+	nc, err := DialTLS(context.Background(), addr, &tls.Config{RootCAs: pool}, opts)
 	if err != nil {
 		t.Fatalf("DialTLS: %v", err)
 	}
 	_ = nc.Close()
+	if dialed.Load() != 1 {
+		t.Errorf("custom dial called %d times, want 1", dialed.Load())
+	}
+}
+
+func TestDialOptionsDialer(t *testing.T) {
+	t.Parallel()
+	d, err := DialOptions{KeepAlive: -1}.dialer()
+	if err != nil || d.KeepAlive != -1 || d.LocalAddr != nil {
+		t.Errorf("keepalive only: %+v, %v", d, err)
+	}
+	d, err = DialOptions{LocalAddr: "127.0.0.1"}.dialer()
+	if err != nil || d.LocalAddr.String() != "127.0.0.1:0" {
+		t.Errorf("bare IP: %v, %v", d.LocalAddr, err)
+	}
+	if _, err := (DialOptions{LocalAddr: "no such host.invalid"}).dialer(); err == nil ||
+		!strings.Contains(err.Error(), `source address "no such host.invalid"`) {
+		t.Errorf("bad source address: got %v", err)
+	}
 }
 
 func TestConnStopWithLatePartialNoDeadlock(t *testing.T) {
@@ -682,3 +929,175 @@ func TestConnStopWithLatePartialNoDeadlock(t *testing.T) {
 		t.Fatal("server deadlocked - late partial caused blocking")
 	}
 }
+
+func TestDialRecordsServerVersion(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		nc, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = nc.Close() }()
+		srv := &mockSCRAMServer{password: "pass"}
+		srv.serve(t, nc)
+		// keep the connection open until the client closes it
+		_, _ = io.Copy(io.Discard, nc)
+	}()
+
+	c, err := Dial(context.Background(), ln.Addr().String(), Config{User: "admin", Password: "pass"}, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+	if got := c.ServerVersion(); got != "2.3.0" {
+		t.Errorf("ServerVersion: got %q, want 2.3.0", got)
+	}
+}
+
+// startLegacyServer serves the handshake of a pre-2.3 server: V1_0 is
+// rejected and V0_4 accepted with key. It counts the V0_4 handshakes in *v04.
+func startLegacyServer(t *testing.T, key string, v04 *atomic.Int32) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = nc.Close() }()
+				var magic [4]byte
+				if _, err := io.ReadFull(nc, magic[:]); err != nil {
+					return
+				}
+				rw := struct {
+					io.Reader
+					io.Writer
+				}{io.MultiReader(bytes.NewReader(magic[:]), nc), nc}
+				if binary.LittleEndian.Uint32(magic[:]) == uint32(proto.V1_0) {
+					rejectV10(rw)
+					return
+				}
+				v04.Add(1)
+				if _, _, _, err := serveV04(rw, key); err == nil {
+					_, _ = io.Copy(io.Discard, nc)
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestDialFallsBackToV04(t *testing.T) {
+	t.Parallel()
+	var v04 atomic.Int32
+	addr := startLegacyServer(t, "", &v04)
+	var buf syncBuffer
+	cfg := Config{User: "admin", Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+	c, err := Dial(context.Background(), addr, cfg, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	_ = c.Close()
+	if !strings.Contains(buf.String(), "level=WARN") || !strings.Contains(buf.String(), "retrying with V0_4") {
+		t.Errorf("fallback not warned about:\n%s", buf.String())
+	}
+
+	cfg.Protocol = proto.V1_0
+	if _, err := Dial(context.Background(), addr, cfg, nil); !errors.Is(err, ErrLegacyProtocol) {
+		t.Errorf("explicit V1_0: got %v, want ErrLegacyProtocol", err)
+	}
+}
+
+func TestDialNoDowngradeWithPassword(t *testing.T) {
+	t.Parallel()
+	var v04 atomic.Int32
+	addr := startLegacyServer(t, "key", &v04)
+	cfg := Config{User: "admin", Password: "key"}
+	if _, err := Dial(context.Background(), addr, cfg, nil); !errors.Is(err, ErrLegacyProtocol) {
+		t.Fatalf("auto with a password: got %v, want ErrLegacyProtocol", err)
+	}
+	if n := v04.Load(); n != 0 {
+		t.Fatalf("auto with a password sent %d V0_4 handshakes", n)
+	}
+
+	cfg.Protocol = proto.V0_4
+	c, err := Dial(context.Background(), addr, cfg, nil)
+	if err != nil {
+		t.Fatalf("explicit V0_4: %v", err)
+	}
+	_ = c.Close()
+}
+
+func TestConnLogsFramesAtDebug(t *testing.T) {
+	t.Parallel()
+	var buf syncBuffer
+	c, server := setupConnWithLogger(t, slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	tok := c.NextToken()
+	big := bytes.Repeat([]byte("x"), maxLoggedPayload+100)
+	go func() {
+		if _, _, err := wire.ReadResponse(server); err != nil {
+			t.Errorf("server read: %v", err)
+			return
+		}
+		if err := wire.WriteQuery(server, tok, []byte(`{"t":1,"r":[1]}`)); err != nil {
+			t.Errorf("server write: %v", err)
+		}
+	}()
+	if _, err := c.Send(context.Background(), tok, big); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	type frameRecord struct {
+		Msg     string `json:"msg"`
+		Token   uint64 `json:"token"`
+		Bytes   int    `json:"bytes"`
+		Payload string `json:"payload"`
+	}
+	var records []frameRecord
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r frameRecord
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 2 || records[0].Msg != "frame out" || records[1].Msg != "frame in" {
+		t.Fatalf("got records %+v", records)
+	}
+	if records[0].Token != tok || records[0].Bytes != len(big) || len(records[0].Payload) != maxLoggedPayload {
+		t.Errorf("frame out: token %d bytes %d payload len %d", records[0].Token, records[0].Bytes, len(records[0].Payload))
+	}
+	if records[1].Payload != `{"t":1,"r":[1]}` {
+		t.Errorf("frame in payload %q", records[1].Payload)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of Send and readLoop.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -1,11 +1,15 @@
 package conn
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"strings"
+	"time"
 
 	"r-cli/internal/proto"
 	"r-cli/internal/scram"
@@ -14,6 +18,10 @@ import (
 // ErrReqlAuth indicates an authentication error (error_code 10-20) during handshake.
 var ErrReqlAuth = errors.New("reql: authentication error")
 
+// ErrLegacyProtocol is returned by the V1_0 handshake when the server only
+// speaks the pre-2.3 V0_4 protocol.
+var ErrLegacyProtocol = errors.New("conn: server does not support protocol V1_0")
+
 type step3Request struct {
 	ProtocolVersion      int    `json:"protocol_version"`
 	AuthenticationMethod string `json:"authentication_method"`
@@ -122,26 +130,111 @@ func parseStep6(data []byte) (string, error) {
 // Handshake performs the RethinkDB V1_0 handshake over rw, authenticating as user with password.
 // Steps 1 and 3 are pipelined (sent together) to save one round trip.
 func Handshake(rw io.ReadWriter, user, password string) error {
+	_, err := handshake(rw, user, password)
+	return err
+}
+
+// stepDeadlines gives every handshake step its own deadline, so a server
+// that stops answering mid-handshake fails after stepTimeout rather than at
+// the caller's overall timeout.
+type stepDeadlines struct {
+	io.ReadWriter
+	conn    interface{ SetDeadline(t time.Time) error }
+	timeout time.Duration
+	step    int
+}
+
+// beginStep starts handshake step n on rw, resetting the deadline when rw
+// carries step deadlines.
+func beginStep(rw io.Reader, n int) {
+	if s, ok := rw.(*stepDeadlines); ok {
+		s.step = n
+		_ = s.conn.SetDeadline(time.Now().Add(s.timeout))
+	}
+}
+
+// handshakeVersion performs the handshake of protocol v and returns the
+// server_version; V0_4 authenticates with password as the auth key, ignores
+// user and reports no version. With stepTimeout > 0 and a net.Conn, each
+// step must complete within stepTimeout; the deadline is cleared afterwards.
+func handshakeVersion(rw io.ReadWriter, v proto.Version, user, password string, stepTimeout time.Duration) (string, error) {
+	d, ok := rw.(interface{ SetDeadline(t time.Time) error })
+	if !ok || stepTimeout <= 0 {
+		return runHandshake(rw, v, user, password)
+	}
+	s := &stepDeadlines{ReadWriter: rw, conn: d, timeout: stepTimeout}
+	version, err := runHandshake(s, v, user, password)
+	_ = d.SetDeadline(time.Time{})
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return "", fmt.Errorf("handshake step %d timed out after %v", s.step, stepTimeout)
+	}
+	return version, err
+}
+
+func runHandshake(rw io.ReadWriter, v proto.Version, user, password string) (string, error) {
+	switch v {
+	case proto.V1_0:
+		return handshake(rw, user, password)
+	case proto.V0_4:
+		return "", handshakeV04(rw, password)
+	default:
+		return "", fmt.Errorf("handshake: unsupported protocol version 0x%08x", uint32(v))
+	}
+}
+
+// handshakeV04 sends the V0_4 magic, the length-prefixed auth key and the
+// JSON protocol magic in one write; the server answers "SUCCESS" or an
+// error line.
+func handshakeV04(rw io.ReadWriter, authKey string) error {
+	b := make([]byte, 0, 12+len(authKey))
+	b = binary.LittleEndian.AppendUint32(b, uint32(proto.V0_4))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(authKey))) //nolint:gosec // bounded by the flag/env value
+	b = append(b, authKey...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(proto.ProtocolJSON))
+	beginStep(rw, 1)
+	if _, err := rw.Write(b); err != nil {
+		return fmt.Errorf("handshake: write: %w", err)
+	}
+	beginStep(rw, 2)
+	data, err := readNullTerminated(rw)
+	if err != nil {
+		return fmt.Errorf("handshake: read: %w", err)
+	}
+	msg := strings.TrimSpace(string(data))
+	switch {
+	case msg == "SUCCESS":
+		return nil
+	case strings.Contains(msg, "authorization key"):
+		return fmt.Errorf("%w: %s", ErrReqlAuth, msg)
+	default:
+		return fmt.Errorf("handshake: %s", msg)
+	}
+}
+
+// handshake performs the handshake and returns the server_version reported in step 2.
+func handshake(rw io.ReadWriter, user, password string) (string, error) {
 	conv := scram.NewConversation(user, password)
+	beginStep(rw, 1)
 	if err := writePipelined(rw, conv.ClientFirst()); err != nil {
-		return err
+		return "", err
 	}
-	serverFirstMsg, err := exchangeInitial(rw)
+	serverFirstMsg, version, err := exchangeInitial(rw)
 	if err != nil {
-		return err
+		return "", err
 	}
 	clientFinal, err := conv.ServerFirst(serverFirstMsg)
 	if err != nil {
-		return fmt.Errorf("handshake: %w", err)
+		return "", fmt.Errorf("handshake: %w", err)
 	}
 	serverFinalMsg, err := exchangeFinal(rw, clientFinal)
 	if err != nil {
-		return err
+		return "", err
 	}
 	if err := conv.ServerFinal(serverFinalMsg); err != nil {
-		return fmt.Errorf("handshake: %w", err)
+		return "", fmt.Errorf("handshake: %w", err)
 	}
-	return nil
+	return version, nil
 }
 
 // writePipelined writes step 1 (magic) and step 3 (client-first-message) in a single call.
@@ -160,29 +253,36 @@ func writePipelined(w io.Writer, clientFirstMsg string) error {
 	return nil
 }
 
-// exchangeInitial reads step 2 (server info) and step 4 (server-first-message).
-func exchangeInitial(r io.Reader) (string, error) {
+// exchangeInitial reads step 2 (server info) and step 4 (server-first-message),
+// returning the server-first-message and the server version.
+func exchangeInitial(r io.Reader) (string, string, error) {
+	beginStep(r, 2)
 	data, err := readNullTerminated(r)
 	if err != nil {
-		return "", fmt.Errorf("handshake: read step 2: %w", err)
+		return "", "", fmt.Errorf("handshake: read step 2: %w", err)
+	}
+	// pre-2.3 servers reject the V1_0 magic with a plain "ERROR: ..." line
+	if bytes.HasPrefix(data, []byte("ERROR:")) {
+		return "", "", fmt.Errorf("handshake: %w: %s", ErrLegacyProtocol, strings.TrimSpace(string(data)))
 	}
 	step2Resp, err := parseStep2(data)
 	if err != nil {
-		return "", fmt.Errorf("handshake: %w", err)
+		return "", "", fmt.Errorf("handshake: %w", err)
 	}
 	if step2Resp.MinProtocolVersion > 0 {
-		return "", fmt.Errorf("handshake: server requires min protocol_version=%d, client supports 0",
+		return "", "", fmt.Errorf("handshake: server requires min protocol_version=%d, client supports 0",
 			step2Resp.MinProtocolVersion)
 	}
+	beginStep(r, 4)
 	data, err = readNullTerminated(r)
 	if err != nil {
-		return "", fmt.Errorf("handshake: read step 4: %w", err)
+		return "", "", fmt.Errorf("handshake: read step 4: %w", err)
 	}
 	msg, err := parseStep4(data)
 	if err != nil {
-		return "", fmt.Errorf("handshake: %w", err)
+		return "", "", fmt.Errorf("handshake: %w", err)
 	}
-	return msg, nil
+	return msg, step2Resp.ServerVersion, nil
 }
 
 // exchangeFinal writes step 5 (client-final-message) and reads step 6 (server-final-message).
@@ -191,9 +291,11 @@ func exchangeFinal(rw io.ReadWriter, clientFinal string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("handshake: %w", err)
 	}
+	beginStep(rw, 5)
 	if _, err := rw.Write(step5); err != nil {
 		return "", fmt.Errorf("handshake: write step 5: %w", err)
 	}
+	beginStep(rw, 6)
 	data, err := readNullTerminated(rw)
 	if err != nil {
 		return "", fmt.Errorf("handshake: read step 6: %w", err)
@@ -478,3 +478,167 @@ func TestHandshakePipelined(t *testing.T) {
 	}
 	<-serverDone
 }
+
+func TestHandshakeReturnsServerVersion(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	defer func() { _ = client.Close() }()
+
+	srv := &mockSCRAMServer{password: "testpass"}
+	go func() {
+		defer func() { _ = server.Close() }()
+		srv.serve(t, server)
+	}()
+
+	version, err := handshake(client, "testuser", "testpass")
+	if err != nil {
+		t.Fatalf("handshake error: %v", err)
+	}
+	if version != "2.3.0" {
+		t.Errorf("server version: got %q, want 2.3.0", version)
+	}
+}
+
+// serveV04 answers a V0_4 handshake on rw, accepting only authKey, and
+// reports the magic numbers and key it read.
+func serveV04(rw io.ReadWriter, authKey string) (magic, protocol uint32, key string, err error) {
+	var hdr [8]byte
+	if _, err = io.ReadFull(rw, hdr[:]); err != nil {
+		return 0, 0, "", err
+	}
+	magic = binary.LittleEndian.Uint32(hdr[:4])
+	buf := make([]byte, binary.LittleEndian.Uint32(hdr[4:])+4)
+	if _, err = io.ReadFull(rw, buf); err != nil {
+		return 0, 0, "", err
+	}
+	key = string(buf[:len(buf)-4])
+	protocol = binary.LittleEndian.Uint32(buf[len(buf)-4:])
+	reply := "SUCCESS\x00"
+	if key != authKey {
+		reply = "ERROR: Incorrect authorization key.\n\x00"
+	}
+	_, err = rw.Write([]byte(reply))
+	return magic, protocol, key, err
+}
+
+// rejectV10 answers a V1_0 handshake the way a pre-2.3 server does.
+func rejectV10(rw io.ReadWriter) {
+	var magic [4]byte
+	if _, err := io.ReadFull(rw, magic[:]); err != nil {
+		return
+	}
+	if _, err := readNullTerminated(rw); err != nil {
+		return
+	}
+	_, _ = rw.Write([]byte("ERROR: Received an unsupported protocol version. This port is for RethinkDB queries.\n\x00"))
+}
+
+func TestHandshakeV04(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		key      string
+		wantAuth bool
+	}{
+		{"s3cret", false},
+		{"wrong", true},
+	} {
+		client, server := net.Pipe()
+		type read struct {
+			magic, protocol uint32
+			key             string
+		}
+		got := make(chan read, 1)
+		go func() {
+			defer func() { _ = server.Close() }()
+			magic, protocol, key, _ := serveV04(server, "s3cret")
+			got <- read{magic, protocol, key}
+		}()
+		version, err := handshakeVersion(client, proto.V0_4, "ignored", tc.key, 0)
+		_ = client.Close()
+		if tc.wantAuth != errors.Is(err, ErrReqlAuth) || (!tc.wantAuth && err != nil) {
+			t.Errorf("key %q: got %v", tc.key, err)
+		}
+		if version != "" {
+			t.Errorf("version = %q, want none for V0_4", version)
+		}
+		r := <-got
+		if r.magic != uint32(proto.V0_4) || r.protocol != uint32(proto.ProtocolJSON) || r.key != tc.key {
+			t.Errorf("server read magic 0x%08x protocol 0x%08x key %q", r.magic, r.protocol, r.key)
+		}
+	}
+}
+
+func TestHandshakeLegacyServer(t *testing.T) {
+	t.Parallel()
+	client, server := net.Pipe()
+	defer func() { _ = client.Close() }()
+	go func() {
+		defer func() { _ = server.Close() }()
+		rejectV10(server)
+	}()
+	err := Handshake(client, "admin", "")
+	if !errors.Is(err, ErrLegacyProtocol) || !strings.Contains(err.Error(), "unsupported protocol version") {
+		t.Errorf("got %v, want ErrLegacyProtocol with the server message", err)
+	}
+}
+
+func TestHandshakeVersionUnsupported(t *testing.T) {
+	t.Parallel()
+	if _, err := handshakeVersion(&bytes.Buffer{}, proto.V0_3, "", "", 0); err == nil || !strings.Contains(err.Error(), "unsupported protocol version") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestHandshakeStepTimeout(t *testing.T) {
+	t.Parallel()
+	client, server := net.Pipe()
+	defer func() { _ = client.Close() }()
+	defer func() { _ = server.Close() }()
+	go func() {
+		// answer step 2, then go silent before step 4
+		var magic [4]byte
+		if _, err := io.ReadFull(server, magic[:]); err != nil {
+			return
+		}
+		if _, err := readNullTerminated(server); err != nil {
+			return
+		}
+		_, _ = server.Write([]byte(`{"success":true,"min_protocol_version":0,"max_protocol_version":0,"server_version":"2.4.0"}` + "\x00"))
+	}()
+
+	start := time.Now()
+	_, err := handshakeVersion(client, proto.V1_0, "admin", "", 50*time.Millisecond)
+	if err == nil || err.Error() != "handshake step 4 timed out after 50ms" {
+		t.Fatalf("got %v, want step 4 timeout", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("timed out after %v", d)
+	}
+}
+
+func TestHandshakeStepDeadlineCleared(t *testing.T) {
+	t.Parallel()
+	client, server := net.Pipe()
+	defer func() { _ = client.Close() }()
+	go func() {
+		defer func() { _ = server.Close() }()
+		srv := &mockSCRAMServer{password: "pass"}
+		srv.serve(t, server)
+		buf := make([]byte, 4)
+		if _, err := io.ReadFull(server, buf); err == nil {
+			_, _ = server.Write(buf)
+		}
+	}()
+	if _, err := handshakeVersion(client, proto.V1_0, "admin", "pass", 500*time.Millisecond); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	time.Sleep(600 * time.Millisecond) // past the last step deadline
+	if _, err := client.Write([]byte("ping")); err != nil {
+		t.Fatalf("write after handshake: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(client, buf); err != nil {
+		t.Errorf("read after handshake: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by Pool.Get after Close.
+var ErrPoolClosed = errors.New("conn: pool closed")
+
+// Pool maintains up to size connections, dialed lazily. Get hands out the
+// connection with the fewest queries in flight and opens another one only
+// when every open connection is busy. Connections whose read loop has ended
+// (dropped by the server or the network) fail the health check in Get and
+// are replaced by a fresh dial, retried as set by SetReconnect.
+type Pool struct {
+	dial  func(ctx context.Context) (*Conn, error)
+	slots []poolSlot
+
+	mu        sync.Mutex // guards slot conns, closed and reconnect
+	closed    bool
+	reconnect Reconnect
+}
+
+// Reconnect controls how Get replaces a connection that died. The first dial
+// of a slot is attempted once; a reconnect is attempted up to MaxAttempts
+// times, waiting Backoff after the first failure and doubling up to
+// MaxBackoff. Authentication failures are not retried.
+type Reconnect struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	// Notify, if set, is called after every reconnect attempt.
+	Notify func(ReconnectEvent)
+}
+
+// ReconnectEvent describes one reconnect attempt.
+type ReconnectEvent struct {
+	Attempt int           // 1-based
+	Err     error         // nil when the attempt succeeded
+	Backoff time.Duration // wait before the next attempt; 0 when giving up
+}
+
+// poolSlot holds one connection; dialMu serializes dials into the slot so
+// concurrent Gets do not open two connections for it.
+type poolSlot struct {
+	dialMu sync.Mutex
+	c      *Conn
+}
+
+// NewPool creates a pool of at most size connections (at least 1) opened by dial.
+func NewPool(size int, dial func(ctx context.Context) (*Conn, error)) *Pool {
+	return &Pool{dial: dial, slots: make([]poolSlot, max(size, 1))}
+}
+
+// SetReconnect sets the reconnect policy; the zero value dials once.
+func (p *Pool) SetReconnect(r Reconnect) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reconnect = r
+}
+
+// Size returns the maximum number of connections.
+func (p *Pool) Size() int {
+	return len(p.slots)
+}
+
+// Get returns a healthy connection for one query.
+func (p *Pool) Get(ctx context.Context) (*Conn, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	best, empty := p.pick()
+	p.mu.Unlock()
+	if best != nil && (empty < 0 || best.Pending() == 0) {
+		return best, nil
+	}
+	return p.fill(ctx, empty)
+}
+
+// pick returns the open connection with the fewest pending queries and the
+// index of the first slot without a healthy connection (-1 if none).
+// Callers hold p.mu.
+func (p *Pool) pick() (*Conn, int) {
+	var best *Conn
+	empty := -1
+	for i := range p.slots {
+		c := p.slots[i].c
+		if c == nil || c.IsClosed() {
+			if empty < 0 {
+				empty = i
+			}
+			continue
+		}
+		if best == nil || c.Pending() < best.Pending() {
+			best = c
+		}
+	}
+	return best, empty
+}
+
+// fill dials a connection into slot i, unless another Get already did.
+func (p *Pool) fill(ctx context.Context, i int) (*Conn, error) {
+	s := &p.slots[i]
+	s.dialMu.Lock()
+	defer s.dialMu.Unlock()
+
+	p.mu.Lock()
+	if c := s.c; c != nil && !c.IsClosed() {
+		p.mu.Unlock()
+		return c, nil
+	}
+	lost := s.c != nil
+	r := p.reconnect
+	s.c = nil
+	p.mu.Unlock()
+
+	dial := p.dial
+	if lost {
+		dial = r.dialer(p.dial)
+	}
+	c, err := dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		_ = c.Close()
+		return nil, ErrPoolClosed
+	}
+	s.c = c
+	return c, nil
+}
+
+// Conns returns the currently open connections.
+func (p *Pool) Conns() []*Conn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []*Conn
+	for i := range p.slots {
+		if c := p.slots[i].c; c != nil && !c.IsClosed() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Close closes every open connection; later Gets fail with ErrPoolClosed.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	conns := make([]*Conn, 0, len(p.slots))
+	for i := range p.slots {
+		if c := p.slots[i].c; c != nil {
+			conns = append(conns, c)
+			p.slots[i].c = nil
+		}
+	}
+	p.mu.Unlock()
+	var errs []error
+	for _, c := range conns {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dialer wraps dial with the reconnect retries.
+func (r Reconnect) dialer(dial func(ctx context.Context) (*Conn, error)) func(ctx context.Context) (*Conn, error) {
+	return func(ctx context.Context) (*Conn, error) {
+		backoff := r.Backoff
+		for attempt := 1; ; attempt++ {
+			c, err := dial(ctx)
+			if err == nil {
+				r.notify(ReconnectEvent{Attempt: attempt})
+				return c, nil
+			}
+			if attempt >= r.MaxAttempts || errors.Is(err, ErrReqlAuth) || ctx.Err() != nil {
+				r.notify(ReconnectEvent{Attempt: attempt, Err: err})
+				return nil, err
+			}
+			r.notify(ReconnectEvent{Attempt: attempt, Err: err, Backoff: backoff})
+			select {
+			case <-ctx.Done():
+				return nil, err
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, max(r.MaxBackoff, r.Backoff))
+		}
+	}
+}
+
+func (r Reconnect) notify(ev ReconnectEvent) {
+	if r.Notify != nil {
+		r.Notify(ev)
+	}
+}
@@ -0,0 +1,315 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"r-cli/internal/wire"
+)
+
+// pipeDialer dials connections over net.Pipe; the server side reads queries
+// and never answers, so a Send stays pending until the pipe is closed.
+type pipeDialer struct {
+	t       *testing.T
+	dials   atomic.Int32
+	mu      sync.Mutex
+	servers []net.Conn
+}
+
+func (d *pipeDialer) dial(context.Context) (*Conn, error) {
+	d.dials.Add(1)
+	client, server := net.Pipe()
+	d.mu.Lock()
+	d.servers = append(d.servers, server)
+	d.mu.Unlock()
+	d.t.Cleanup(func() { _ = server.Close() })
+	go func() {
+		for {
+			if _, _, err := wire.ReadResponse(server); err != nil {
+				return
+			}
+		}
+	}()
+	return newConn(client, Config{}), nil
+}
+
+func (d *pipeDialer) server(i int) net.Conn {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.servers[i]
+}
+
+// busy starts a query on c that stays pending, and waits until it is registered.
+func busy(t *testing.T, c *Conn) {
+	t.Helper()
+	go func() { _, _ = c.Send(context.Background(), c.NextToken(), []byte(`[1,[1],{}]`)) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for c.Pending() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("query not pending after 2s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// drop closes the server side of c and waits until c notices.
+func drop(t *testing.T, server net.Conn, c *Conn) {
+	t.Helper()
+	_ = server.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for !c.IsClosed() {
+		if time.Now().After(deadline) {
+			t.Fatal("connection not marked closed after 2s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolReusesIdleConnection(t *testing.T) {
+	t.Parallel()
+	d := &pipeDialer{t: t}
+	p := NewPool(3, d.dial)
+	defer func() { _ = p.Close() }()
+
+	c1, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1 != c2 || d.dials.Load() != 1 {
+		t.Errorf("idle connection not reused: same=%v dials=%d", c1 == c2, d.dials.Load())
+	}
+}
+
+func TestPoolDialsWhenBusy(t *testing.T) {
+	t.Parallel()
+	d := &pipeDialer{t: t}
+	p := NewPool(2, d.dial)
+	defer func() { _ = p.Close() }()
+
+	c1, _ := p.Get(context.Background())
+	busy(t, c1)
+	c2, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c2 == c1 || d.dials.Load() != 2 {
+		t.Fatalf("busy connection handed out: same=%v dials=%d", c1 == c2, d.dials.Load())
+	}
+
+	// pool full: the least busy connection is shared
+	busy(t, c2)
+	busy(t, c2)
+	c3, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c3 != c1 || d.dials.Load() != 2 {
+		t.Errorf("full pool: got c1=%v dials=%d, want c1 and 2 dials", c3 == c1, d.dials.Load())
+	}
+}
+
+func TestPoolReplacesDeadConnection(t *testing.T) {
+	t.Parallel()
+	d := &pipeDialer{t: t}
+	p := NewPool(1, d.dial)
+	defer func() { _ = p.Close() }()
+
+	c1, _ := p.Get(context.Background())
+	drop(t, d.server(0), c1)
+	c2, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c2 == c1 || c2.IsClosed() || d.dials.Load() != 2 {
+		t.Errorf("dead connection not replaced: same=%v dials=%d", c1 == c2, d.dials.Load())
+	}
+}
+
+// flakyDialer fails the dials listed in fail (1-based) with err and
+// delegates the rest to a pipeDialer.
+type flakyDialer struct {
+	pipeDialer
+	n    atomic.Int32
+	fail map[int32]bool
+	err  error
+}
+
+func (d *flakyDialer) dial(ctx context.Context) (*Conn, error) {
+	if d.fail[d.n.Add(1)] {
+		return nil, d.err
+	}
+	return d.pipeDialer.dial(ctx)
+}
+
+func TestPoolReconnectRetries(t *testing.T) {
+	t.Parallel()
+	d := &flakyDialer{pipeDialer: pipeDialer{t: t}, fail: map[int32]bool{2: true, 3: true}, err: errors.New("refused")}
+	p := NewPool(1, d.dial)
+	defer func() { _ = p.Close() }()
+	var events []ReconnectEvent
+	p.SetReconnect(Reconnect{MaxAttempts: 5, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond,
+		Notify: func(ev ReconnectEvent) { events = append(events, ev) }})
+
+	c1, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	drop(t, d.server(0), c1)
+	c2, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("reconnect: %v", err)
+	}
+	if c2 == c1 || c2.IsClosed() {
+		t.Error("dead connection not replaced")
+	}
+	want := []ReconnectEvent{
+		{Attempt: 1, Err: d.err, Backoff: time.Millisecond},
+		{Attempt: 2, Err: d.err, Backoff: 2 * time.Millisecond},
+		{Attempt: 3},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %+v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestPoolReconnectGivesUp(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"max attempts", errors.New("refused"), 3},
+		{"auth", ErrReqlAuth, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			d := &flakyDialer{pipeDialer: pipeDialer{t: t}, fail: map[int32]bool{2: true, 3: true, 4: true}, err: tc.err}
+			p := NewPool(1, d.dial)
+			defer func() { _ = p.Close() }()
+			var last ReconnectEvent
+			p.SetReconnect(Reconnect{MaxAttempts: 3, Backoff: time.Millisecond,
+				Notify: func(ev ReconnectEvent) { last = ev }})
+
+			c, _ := p.Get(context.Background())
+			drop(t, d.server(0), c)
+			if _, err := p.Get(context.Background()); !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+			if last.Attempt != tc.attempts || last.Backoff != 0 {
+				t.Errorf("last event = %+v, want attempt %d with no backoff", last, tc.attempts)
+			}
+		})
+	}
+}
+
+func TestPoolFirstDialNotRetried(t *testing.T) {
+	t.Parallel()
+	var dials atomic.Int32
+	p := NewPool(1, func(context.Context) (*Conn, error) {
+		dials.Add(1)
+		return nil, errors.New("refused")
+	})
+	p.SetReconnect(Reconnect{MaxAttempts: 5, Backoff: time.Millisecond})
+	if _, err := p.Get(context.Background()); err == nil {
+		t.Fatal("expected dial error")
+	}
+	if n := dials.Load(); n != 1 {
+		t.Errorf("dials = %d, want 1", n)
+	}
+}
+
+func TestPoolConns(t *testing.T) {
+	t.Parallel()
+	d := &pipeDialer{t: t}
+	p := NewPool(2, d.dial)
+	defer func() { _ = p.Close() }()
+	if n := len(p.Conns()); n != 0 {
+		t.Errorf("Conns before Get = %d, want 0", n)
+	}
+	c, _ := p.Get(context.Background())
+	if got := p.Conns(); len(got) != 1 || got[0] != c {
+		t.Errorf("Conns = %v, want [%p]", got, c)
+	}
+	drop(t, d.server(0), c)
+	if n := len(p.Conns()); n != 0 {
+		t.Errorf("Conns after drop = %d, want 0", n)
+	}
+}
+
+func TestPoolDialError(t *testing.T) {
+	t.Parallel()
+	errDial := errors.New("refused")
+	p := NewPool(2, func(context.Context) (*Conn, error) { return nil, errDial })
+	if _, err := p.Get(context.Background()); !errors.Is(err, errDial) {
+		t.Errorf("got %v, want dial error", err)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	t.Parallel()
+	d := &pipeDialer{t: t}
+	p := NewPool(2, d.dial)
+	c1, _ := p.Get(context.Background())
+	busy(t, c1)
+	c2, _ := p.Get(context.Background())
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !c1.IsClosed() || !c2.IsClosed() {
+		t.Error("connections still open after Close")
+	}
+	if _, err := p.Get(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Get after Close: got %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestPoolConcurrentGet(t *testing.T) {
+	t.Parallel()
+	d := &pipeDialer{t: t}
+	p := NewPool(4, d.dial)
+	defer func() { _ = p.Close() }()
+
+	var wg sync.WaitGroup
+	for range 32 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := p.Get(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_ = c.WriteFrame(c.NextToken(), []byte(`[1,[1],{}]`))
+		}()
+	}
+	wg.Wait()
+	if n := d.dials.Load(); n < 1 || n > 4 {
+		t.Errorf("dials = %d, want 1..4", n)
+	}
+}
+
+func TestPoolSize(t *testing.T) {
+	t.Parallel()
+	if got := NewPool(0, nil).Size(); got != 1 {
+		t.Errorf("Size() = %d, want 1", got)
+	}
+	if got := NewPool(8, nil).Size(); got != 8 {
+		t.Errorf("Size() = %d, want 8", got)
+	}
+}
@@ -0,0 +1,59 @@
+package conn
+
+import (
+	"context"
+	"fmt"
+
+	"r-cli/internal/proto"
+	"r-cli/internal/reql"
+)
+
+// Session wraps a Conn and attaches default global optargs (db, read_mode,
+// durability, profile, ...) to every START query sent through it.
+type Session struct {
+	conn     *Conn
+	defaults reql.OptArgs
+}
+
+// NewSession returns a Session sending queries on c with defaults; nil
+// defaults send each query's own optargs only.
+func NewSession(c *Conn, defaults reql.OptArgs) *Session {
+	return &Session{conn: c, defaults: defaults}
+}
+
+// Conn returns the wrapped connection.
+func (s *Session) Conn() *Conn {
+	return s.conn
+}
+
+// OptArgs returns the session defaults overlaid with opts; opts win.
+func (s *Session) OptArgs(opts reql.OptArgs) reql.OptArgs {
+	merged := make(reql.OptArgs, len(s.defaults)+len(opts))
+	for k, v := range s.defaults {
+		merged[k] = v
+	}
+	for k, v := range opts {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Start sends term as a START query and returns its token and the raw first
+// response. With "noreply": true among the optargs the frame is only
+// written and the response is nil.
+func (s *Session) Start(ctx context.Context, term reql.Term, opts reql.OptArgs) (uint64, []byte, error) {
+	opts = s.OptArgs(opts)
+	payload, err := reql.BuildQuery(proto.QueryStart, term, opts)
+	if err != nil {
+		return 0, nil, fmt.Errorf("build: %w", err)
+	}
+	token := s.conn.NextToken()
+	if v, _ := opts["noreply"].(bool); v {
+		return token, nil, s.conn.WriteFrame(token, payload)
+	}
+	raw, err := s.conn.Send(ctx, token, payload)
+	if err != nil {
+		return 0, nil, fmt.Errorf("send: %w", err)
+	}
+	return token, raw, nil
+}
@@ -0,0 +1,72 @@
+package conn
+
+import (
+	"context"
+	"testing"
+
+	"r-cli/internal/reql"
+	"r-cli/internal/wire"
+)
+
+func TestSessionOptArgs(t *testing.T) {
+	t.Parallel()
+	s := NewSession(nil, reql.OptArgs{"db": "app", "read_mode": "majority"})
+	got := s.OptArgs(reql.OptArgs{"db": "other", "noreply": true})
+	if got["db"] != "other" || got["read_mode"] != "majority" || got["noreply"] != true || len(got) != 3 {
+		t.Errorf("OptArgs = %v", got)
+	}
+	if len(s.defaults) != 2 || s.defaults["db"] != "app" {
+		t.Errorf("defaults modified: %v", s.defaults)
+	}
+	if got := NewSession(nil, nil).OptArgs(nil); len(got) != 0 {
+		t.Errorf("empty OptArgs = %v", got)
+	}
+}
+
+func TestSessionStart(t *testing.T) {
+	t.Parallel()
+	c, server := setupConn(t)
+	got := make(chan string, 1)
+	go func() {
+		tok, payload, err := wire.ReadResponse(server)
+		if err != nil {
+			t.Errorf("server read: %v", err)
+			return
+		}
+		got <- string(payload)
+		_ = wire.WriteQuery(server, tok, []byte(`{"t":1,"r":[1]}`))
+	}()
+	s := NewSession(c, reql.OptArgs{"db": "app", "durability": "soft"})
+	_, raw, err := s.Start(context.Background(), reql.Datum(1), reql.OptArgs{"durability": "hard"})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if string(raw) != `{"t":1,"r":[1]}` {
+		t.Errorf("response = %s", raw)
+	}
+	if want := `[1,1,{"db":[14,["app"]],"durability":"hard"}]`; <-got != want {
+		t.Errorf("payload differs, want %s", want)
+	}
+}
+
+func TestSessionStartNoreply(t *testing.T) {
+	t.Parallel()
+	c, server := setupConn(t)
+	got := make(chan string, 1)
+	go func() {
+		_, payload, err := wire.ReadResponse(server)
+		if err != nil {
+			t.Errorf("server read: %v", err)
+			return
+		}
+		got <- string(payload)
+	}()
+	s := NewSession(c, reql.OptArgs{"noreply": true})
+	_, raw, err := s.Start(context.Background(), reql.Datum(1), nil)
+	if err != nil || raw != nil {
+		t.Fatalf("Start = %s, %v; want no response", raw, err)
+	}
+	if want := `[1,1,{"noreply":true}]`; <-got != want {
+		t.Errorf("payload differs, want %s", want)
+	}
+}
@@ -0,0 +1,90 @@
+package conn
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"r-cli/internal/proto"
+)
+
+// Tracer receives every frame a Conn writes or reads. Trace is called from
+// the writing goroutine and the readLoop, so it must be safe for concurrent
+// use and should return quickly.
+type Tracer interface {
+	Trace(ev FrameEvent)
+}
+
+// FrameEvent describes one traced frame.
+type FrameEvent struct {
+	// Out is true for frames sent to the server.
+	Out   bool
+	Token uint64
+	// QueryType is set for outgoing frames, ResponseType for incoming ones;
+	// zero when the payload does not decode.
+	QueryType    proto.QueryType
+	ResponseType proto.ResponseType
+	// Payload is the frame body; it is only valid during Trace.
+	Payload []byte
+	// Elapsed is, for incoming frames, the time since the last frame with
+	// the same token was sent.
+	Elapsed time.Duration
+}
+
+// traceOut reports an outgoing frame and records when it was sent.
+func (c *Conn) traceOut(token uint64, payload []byte) {
+	if c.tracer == nil {
+		return
+	}
+	c.mu.Lock()
+	c.sentAt[token] = time.Now()
+	c.mu.Unlock()
+	c.tracer.Trace(FrameEvent{Out: true, Token: token, QueryType: frameQueryType(payload), Payload: payload})
+}
+
+// traceIn reports an incoming frame with the time since its token was sent.
+// The send time is kept only while more batches may follow.
+func (c *Conn) traceIn(token uint64, payload []byte) {
+	if c.tracer == nil {
+		return
+	}
+	ev := FrameEvent{Token: token, ResponseType: frameResponseType(payload), Payload: payload}
+	c.mu.Lock()
+	if at, ok := c.sentAt[token]; ok {
+		ev.Elapsed = time.Since(at)
+		if ev.ResponseType != proto.ResponseSuccessPartial {
+			delete(c.sentAt, token)
+		}
+	}
+	c.mu.Unlock()
+	c.tracer.Trace(ev)
+}
+
+// frameQueryType reads the leading type of a `[type, ...]` query payload.
+func frameQueryType(payload []byte) proto.QueryType {
+	rest, ok := bytes.CutPrefix(payload, []byte("["))
+	if !ok {
+		return 0
+	}
+	end := bytes.IndexAny(rest, ",]")
+	if end < 0 {
+		return 0
+	}
+	n, err := strconv.Atoi(string(bytes.TrimSpace(rest[:end])))
+	if err != nil {
+		return 0
+	}
+	return proto.QueryType(n)
+}
+
+// frameResponseType reads the "t" field of a response payload.
+func frameResponseType(payload []byte) proto.ResponseType {
+	var resp struct {
+		T proto.ResponseType `json:"t"`
+	}
+	if json.Unmarshal(payload, &resp) != nil {
+		return 0
+	}
+	return resp.T
+}
@@ -0,0 +1,87 @@
+package conn
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"r-cli/internal/proto"
+	"r-cli/internal/wire"
+)
+
+// recordTracer keeps a copy of every traced event.
+type recordTracer struct {
+	mu     sync.Mutex
+	events []FrameEvent
+}
+
+func (r *recordTracer) Trace(ev FrameEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ev.Payload = append([]byte(nil), ev.Payload...)
+	r.events = append(r.events, ev)
+}
+
+func (r *recordTracer) list() []FrameEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]FrameEvent(nil), r.events...)
+}
+
+func TestConnTracesFrames(t *testing.T) {
+	t.Parallel()
+	tr := &recordTracer{}
+	c, server := setupConnWithConfig(t, Config{Tracer: tr})
+	go func() {
+		for range 2 {
+			tok, _, err := wire.ReadResponse(server)
+			if err != nil {
+				return
+			}
+			_ = wire.WriteQuery(server, tok, []byte(`{"t":1,"r":[1]}`))
+		}
+	}()
+	tok := c.NextToken()
+	if _, err := c.Send(context.Background(), tok, []byte(`[1,1,{}]`)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if _, err := c.ServerInfo(context.Background()); err == nil {
+		t.Fatal("ServerInfo accepted a SUCCESS_ATOM reply")
+	}
+
+	events := tr.list()
+	if len(events) != 4 {
+		t.Fatalf("traced %d frames, want 4: %+v", len(events), events)
+	}
+	out, in := events[0], events[1]
+	if !out.Out || out.Token != tok || out.QueryType != proto.QueryStart || string(out.Payload) != `[1,1,{}]` {
+		t.Errorf("out = %+v", out)
+	}
+	if in.Out || in.Token != tok || in.ResponseType != proto.ResponseSuccessAtom || in.Elapsed <= 0 {
+		t.Errorf("in = %+v", in)
+	}
+	if events[2].QueryType != proto.QueryServerInfo {
+		t.Errorf("second query type = %v, want SERVER_INFO", events[2].QueryType)
+	}
+	c.mu.Lock()
+	left := len(c.sentAt)
+	c.mu.Unlock()
+	if left != 0 {
+		t.Errorf("%d send times kept after final responses", left)
+	}
+}
+
+func TestFrameTypes(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[string]proto.QueryType{`[1,[39,[]],{}]`: proto.QueryStart, `[3]`: proto.QueryStop, ` [2]`: 0, `{}`: 0, `[x]`: 0} {
+		if got := frameQueryType([]byte(in)); got != want {
+			t.Errorf("frameQueryType(%s) = %v, want %v", in, got, want)
+		}
+	}
+	if got := frameResponseType([]byte(`{"t":3,"r":[]}`)); got != proto.ResponseSuccessPartial {
+		t.Errorf("frameResponseType = %v", got)
+	}
+	if got := frameResponseType([]byte(`not json`)); got != 0 {
+		t.Errorf("frameResponseType(invalid) = %v, want 0", got)
+	}
+}
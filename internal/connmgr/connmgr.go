@@ -3,8 +3,14 @@ package connmgr
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"r-cli/internal/conn"
 )
@@ -12,51 +18,136 @@ import (
 // DialFunc creates a new connection.
 type DialFunc func(ctx context.Context) (*conn.Conn, error)
 
-// ConnManager manages a single lazily-created connection.
+// ConnManager hands out lazily created connections from a conn.Pool.
 type ConnManager struct {
-	dial DialFunc
-	mu   sync.Mutex
-	c    *conn.Conn
+	pool  *conn.Pool
+	hosts *hostSet // failover list; nil when built with New or NewPool
+	log   *slog.Logger
+
+	ctx    context.Context // cancelled by Close; bounds background discovery
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	discoverMu    sync.Mutex
+	discover      bool // discovery enabled
+	discovered    bool // first discovery ran
+	discoverEvery time.Duration
 }
 
-// New creates a ConnManager using the provided dial function.
+// New creates a ConnManager with a single connection using the provided dial function.
 func New(dial DialFunc) *ConnManager {
-	return &ConnManager{dial: dial}
+	return NewPool(dial, 1)
+}
+
+// NewPool creates a ConnManager that keeps up to size connections.
+func NewPool(dial DialFunc, size int) *ConnManager {
+	m := newManager(nil)
+	m.pool = conn.NewPool(size, dial)
+	return m
 }
 
-// NewFromConfig creates a ConnManager that dials addr using the given config.
+func newManager(log *slog.Logger) *ConnManager {
+	if log == nil {
+		log = slog.New(slog.DiscardHandler)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ConnManager{log: log, ctx: ctx, cancel: cancel}
+}
+
+// NewFromConfig creates a single-connection ConnManager that dials addr using the given config.
 func NewFromConfig(cfg conn.Config, tlsCfg *tls.Config) *ConnManager {
-	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-	return New(func(ctx context.Context) (*conn.Conn, error) {
-		return conn.Dial(ctx, addr, cfg, tlsCfg)
+	return NewPoolFromConfig(cfg, tlsCfg, 1)
+}
+
+// NewPoolFromConfig creates a ConnManager of up to size connections dialed
+// with the given config. cfg.Host and cfg.Port seed the failover host list,
+// which EnableDiscovery can grow. Dials are logged at info level to
+// cfg.Logger when it is set.
+func NewPoolFromConfig(cfg conn.Config, tlsCfg *tls.Config, size int) *ConnManager {
+	m := newManager(cfg.Logger)
+	m.hosts = newHostSet(net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+	m.pool = conn.NewPool(size, func(ctx context.Context) (*conn.Conn, error) {
+		return m.dialHosts(ctx, cfg, tlsCfg)
 	})
+	return m
 }
 
-// Get returns the current connection, creating one lazily on first call.
-// If the existing connection is closed or errored, it re-dials automatically.
-func (m *ConnManager) Get(ctx context.Context) (*conn.Conn, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if m.c != nil && !m.c.IsClosed() {
-		return m.c, nil
+// dialHosts connects to the first reachable host, starting with the one that
+// accepted the last connection. Authentication failures are not retried on
+// other hosts, since users are cluster-wide.
+func (m *ConnManager) dialHosts(ctx context.Context, cfg conn.Config, tlsCfg *tls.Config) (*conn.Conn, error) {
+	addrs := m.hosts.order()
+	errs := make([]error, 0, len(addrs))
+	for _, addr := range addrs {
+		c, err := m.dialAddr(ctx, addr, cfg, tlsCfg)
+		if err == nil {
+			m.hosts.use(addr)
+			m.discoverOn(ctx, c)
+			return c, nil
+		}
+		if len(addrs) == 1 || errors.Is(err, conn.ErrReqlAuth) || ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("connmgr: no reachable host in %s: %w", strings.Join(addrs, ", "), errors.Join(errs...))
+}
+
+func (m *ConnManager) dialAddr(ctx context.Context, addr string, cfg conn.Config, tlsCfg *tls.Config) (*conn.Conn, error) {
+	if cfg.Logger == nil {
+		return conn.Dial(ctx, addr, cfg, tlsCfg)
 	}
-	m.c = nil
-	c, err := m.dial(ctx)
+	cfg.Logger.Info("connecting", "addr", addr, "user", cfg.User, "tls", tlsCfg != nil)
+	start := time.Now()
+	c, err := conn.Dial(ctx, addr, cfg, tlsCfg)
 	if err != nil {
+		cfg.Logger.Info("connect failed", "addr", addr, "error", err)
 		return nil, err
 	}
-	m.c = c
-	return m.c, nil
+	attrs := []any{"addr", addr, "server_version", c.ServerVersion(), "duration", time.Since(start)}
+	if cfg.Logger.Enabled(ctx, slog.LevelInfo) {
+		attrs = append(attrs, serverName(ctx, c)...)
+	}
+	cfg.Logger.Info("connected", attrs...)
+	return c, nil
 }
 
-// Close closes the managed connection if one exists.
-func (m *ConnManager) Close() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if m.c == nil {
+// serverInfoTimeout bounds the SERVER_INFO round trip made for the connect
+// log record.
+const serverInfoTimeout = 2 * time.Second
+
+// serverName asks the server for its name for the connect log record; a
+// failure or a reply slower than serverInfoTimeout only leaves the name out.
+func serverName(ctx context.Context, c *conn.Conn) []any {
+	ctx, cancel := context.WithTimeout(ctx, serverInfoTimeout)
+	defer cancel()
+	info, err := c.ServerInfo(ctx)
+	if err != nil {
 		return nil
 	}
-	err := m.c.Close()
-	m.c = nil
+	return []any{"server", info.Name}
+}
+
+// Get returns a connection for one query, dialing lazily on first use.
+// Connections that were closed or errored are re-dialed automatically.
+func (m *ConnManager) Get(ctx context.Context) (*conn.Conn, error) {
+	return m.pool.Get(ctx)
+}
+
+// Conns returns the open connections without dialing.
+func (m *ConnManager) Conns() []*conn.Conn {
+	return m.pool.Conns()
+}
+
+// SetReconnect sets how connections that died are re-dialed.
+func (m *ConnManager) SetReconnect(r conn.Reconnect) {
+	m.pool.SetReconnect(r)
+}
+
+// Close stops background discovery and closes all managed connections.
+func (m *ConnManager) Close() error {
+	m.cancel()
+	err := m.pool.Close()
+	m.wg.Wait()
 	return err
 }
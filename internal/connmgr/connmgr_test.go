@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"strconv"
 	"strings"
@@ -381,6 +382,59 @@ func TestReconnectPreservesConfig(t *testing.T) {
 	}
 }
 
+func TestNewFromConfigLogsDial(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	addr, stop := startStatusServer(t, pass, func() string { return statusReply() })
+	defer stop()
+
+	var buf strings.Builder
+	cfg := configFor(addr, pass, slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	mgr := NewFromConfig(cfg, nil)
+	defer func() { _ = mgr.Close() }()
+
+	if _, err := mgr.Get(context.Background()); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"msg=connecting", "addr=" + addr, "user=admin", "msg=connected", "duration=", "server=rdb_a"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestNewPoolFromConfigSharesIdleConnection(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	addr, stop := startTestServer(t, pass)
+	defer stop()
+
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+	mgr := NewPoolFromConfig(conn.Config{Host: host, Port: port, User: "admin", Password: pass}, nil, 4)
+	c1, err := mgr.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	c2, err := mgr.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if c1 != c2 {
+		t.Error("idle connection not reused")
+	}
+	if err := mgr.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !c1.IsClosed() {
+		t.Error("connection open after Close")
+	}
+	if _, err := mgr.Get(context.Background()); !errors.Is(err, conn.ErrPoolClosed) {
+		t.Errorf("Get after Close: got %v, want ErrPoolClosed", err)
+	}
+}
+
 func TestCloseClosesConnection(t *testing.T) {
 	t.Parallel()
 	const pass = "testpass"
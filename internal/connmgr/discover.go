@@ -0,0 +1,200 @@
+package connmgr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/proto"
+	"r-cli/internal/reql"
+	"r-cli/internal/response"
+)
+
+// discoverTimeout bounds one background refresh of the host list.
+const discoverTimeout = 10 * time.Second
+
+// hostSet is the failover list of ReQL "host:port" addresses. The seed host
+// comes first; discovered hosts are appended and never removed, so a member
+// that is down for a while is tried again once it returns.
+type hostSet struct {
+	mu    sync.Mutex
+	addrs []string
+	last  int // index of the host that accepted the last connection
+}
+
+func newHostSet(seed string) *hostSet {
+	return &hostSet{addrs: []string{seed}}
+}
+
+// order returns the hosts starting with the last one that accepted a connection.
+func (h *hostSet) order() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append(slices.Clone(h.addrs[h.last:]), h.addrs[:h.last]...)
+}
+
+func (h *hostSet) use(addr string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i := slices.Index(h.addrs, addr); i >= 0 {
+		h.last = i
+	}
+}
+
+// add appends the addresses not in the set yet and returns them.
+func (h *hostSet) add(addrs []string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var added []string
+	for _, a := range addrs {
+		if !slices.Contains(h.addrs, a) {
+			h.addrs = append(h.addrs, a)
+			added = append(added, a)
+		}
+	}
+	return added
+}
+
+func (h *hostSet) list() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.addrs)
+}
+
+// EnableDiscovery makes the manager read rethinkdb.server_status after its
+// first connection and add every cluster member's ReQL address to the hosts
+// it fails over to. With interval > 0 the list is refreshed in the background
+// until Close. Discovery failures (e.g. a user without access to the
+// rethinkdb database) are logged and leave the list unchanged. It has no
+// effect on managers built with New or NewPool.
+func (m *ConnManager) EnableDiscovery(interval time.Duration) {
+	m.discoverMu.Lock()
+	defer m.discoverMu.Unlock()
+	m.discover = m.hosts != nil
+	m.discoverEvery = interval
+}
+
+// discoverOn runs the first discovery on a freshly dialed connection and
+// starts the refresh loop.
+func (m *ConnManager) discoverOn(ctx context.Context, c *conn.Conn) {
+	m.discoverMu.Lock()
+	if !m.discover || m.discovered {
+		m.discoverMu.Unlock()
+		return
+	}
+	m.discovered = true
+	interval := m.discoverEvery
+	m.discoverMu.Unlock()
+
+	if err := m.refreshHosts(ctx, c); err != nil {
+		m.log.Warn("host discovery failed", "error", err)
+	}
+	if interval > 0 {
+		m.wg.Add(1)
+		go m.refreshLoop(interval)
+	}
+}
+
+func (m *ConnManager) refreshLoop(interval time.Duration) {
+	defer m.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-t.C:
+		}
+		ctx, cancel := context.WithTimeout(m.ctx, discoverTimeout)
+		c, err := m.pool.Get(ctx)
+		if err == nil {
+			err = m.refreshHosts(ctx, c)
+		}
+		cancel()
+		if err != nil && m.ctx.Err() == nil {
+			m.log.Warn("host discovery failed", "error", err)
+		}
+	}
+}
+
+// refreshHosts queries the cluster members over c and adds their addresses.
+func (m *ConnManager) refreshHosts(ctx context.Context, c *conn.Conn) error {
+	servers, err := serverStatus(ctx, c)
+	if err != nil {
+		return err
+	}
+	seed := m.hosts.list()[0]
+	if added := m.hosts.add(reqlAddrs(servers, seed)); len(added) > 0 {
+		m.log.Info("discovered hosts", "added", added, "hosts", m.hosts.list())
+	}
+	return nil
+}
+
+// serverNetwork is the part of a rethinkdb.server_status row discovery uses.
+type serverNetwork struct {
+	Network struct {
+		CanonicalAddresses []struct {
+			Host string `json:"host"`
+		} `json:"canonical_addresses"`
+		ReqlPort int `json:"reql_port"`
+	} `json:"network"`
+}
+
+func serverStatus(ctx context.Context, c *conn.Conn) ([]serverNetwork, error) {
+	term := reql.DB("rethinkdb").Table("server_status").Pluck("network").CoerceTo("array")
+	_, raw, err := conn.NewSession(c, nil).Start(ctx, term, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := response.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := response.MapError(resp); err != nil {
+		return nil, err
+	}
+	if resp.Type != proto.ResponseSuccessAtom || len(resp.Results) != 1 {
+		return nil, fmt.Errorf("connmgr: unexpected server_status response type %d", resp.Type)
+	}
+	var servers []serverNetwork
+	if err := json.Unmarshal(resp.Results[0], &servers); err != nil {
+		return nil, fmt.Errorf("connmgr: server_status: %w", err)
+	}
+	return servers, nil
+}
+
+// reqlAddrs returns the ReQL address of every canonical address of every
+// server. Loopback addresses are skipped unless the seed host is itself a
+// loopback address, as they would point a remote client at its own machine.
+func reqlAddrs(servers []serverNetwork, seed string) []string {
+	seedHost, _, _ := net.SplitHostPort(seed)
+	keepLoopback := isLoopback(seedHost)
+	var out []string
+	for _, s := range servers {
+		if s.Network.ReqlPort == 0 {
+			continue
+		}
+		port := strconv.Itoa(s.Network.ReqlPort)
+		for _, a := range s.Network.CanonicalAddresses {
+			if a.Host == "" || (!keepLoopback && isLoopback(a.Host)) {
+				continue
+			}
+			out = append(out, net.JoinHostPort(a.Host, port))
+		}
+	}
+	return out
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,230 @@
+package connmgr
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"r-cli/internal/conn"
+	"r-cli/internal/wire"
+)
+
+// startStatusServer is startTestServer answering SERVER_INFO with
+// testServerInfo and every other query with the response returned by reply,
+// e.g. a server_status listing.
+func startStatusServer(t *testing.T, password string, reply func() string) (addr string, stop func()) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveStatus(nc, password, reply)
+		}
+	}()
+	return ln.Addr().String(), func() { _ = ln.Close() }
+}
+
+func serveStatus(nc net.Conn, password string, reply func() string) {
+	defer func() { _ = nc.Close() }()
+	clientFirstMsg, err := readHandshakeInit(nc)
+	if err != nil {
+		return
+	}
+	if completeSCRAM(nc, clientFirstMsg, password) != nil {
+		return
+	}
+	for {
+		token, payload, err := wire.ReadResponse(nc)
+		if err != nil {
+			return
+		}
+		resp := testServerInfo
+		if string(payload) != "[5]" {
+			resp = reply()
+		}
+		if wire.WriteQuery(nc, token, []byte(resp)) != nil {
+			return
+		}
+	}
+}
+
+// testServerInfo is the SERVER_INFO response of startStatusServer.
+const testServerInfo = `{"t":5,"r":[{"id":"4e1b","name":"rdb_a","proxy":false}]}`
+
+// statusReply renders a server_status SUCCESS_ATOM listing one server per addr.
+func statusReply(addrs ...string) string {
+	rows := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		host, port, _ := net.SplitHostPort(a)
+		rows = append(rows, `{"network":{"canonical_addresses":[{"host":"`+host+`","port":29015}],"reql_port":`+port+`}}`)
+	}
+	return `{"t":1,"r":[[` + strings.Join(rows, ",") + `]]}`
+}
+
+func configFor(addr, password string, log *slog.Logger) conn.Config {
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+	return conn.Config{Host: host, Port: port, User: "admin", Password: password, Logger: log}
+}
+
+func TestHostSetOrder(t *testing.T) {
+	t.Parallel()
+	h := newHostSet("a:1")
+	if added := h.add([]string{"b:1", "a:1", "c:1", "b:1"}); !slices.Equal(added, []string{"b:1", "c:1"}) {
+		t.Errorf("added = %v", added)
+	}
+	h.use("c:1")
+	if got := h.order(); !slices.Equal(got, []string{"c:1", "a:1", "b:1"}) {
+		t.Errorf("order = %v, want last used first", got)
+	}
+	if got := h.list(); !slices.Equal(got, []string{"a:1", "b:1", "c:1"}) {
+		t.Errorf("list = %v", got)
+	}
+}
+
+func TestReqlAddrs(t *testing.T) {
+	t.Parallel()
+	var servers []serverNetwork
+	for _, hosts := range [][]string{{"10.0.0.1", "127.0.0.1"}, {"::1", "fd00::2"}} {
+		var s serverNetwork
+		s.Network.ReqlPort = 28015
+		for _, h := range hosts {
+			s.Network.CanonicalAddresses = append(s.Network.CanonicalAddresses, struct {
+				Host string `json:"host"`
+			}{h})
+		}
+		servers = append(servers, s)
+	}
+	servers = append(servers, serverNetwork{}) // no reql_port: skipped
+
+	got := reqlAddrs(servers, "db.example.com:28015")
+	if want := []string{"10.0.0.1:28015", "[fd00::2]:28015"}; !slices.Equal(got, want) {
+		t.Errorf("remote seed: got %v, want %v", got, want)
+	}
+	got = reqlAddrs(servers, "localhost:28015")
+	if want := []string{"10.0.0.1:28015", "127.0.0.1:28015", "[::1]:28015", "[fd00::2]:28015"}; !slices.Equal(got, want) {
+		t.Errorf("loopback seed: got %v, want %v", got, want)
+	}
+}
+
+func TestDiscoveryFailsOverToMember(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	member, stopMember := startTestServer(t, pass)
+	defer stopMember()
+	var seed string
+	seed, stopSeed := startStatusServer(t, pass, func() string { return statusReply(seed, member) })
+
+	mgr := NewFromConfig(configFor(seed, pass, nil), nil)
+	defer func() { _ = mgr.Close() }()
+	mgr.EnableDiscovery(0)
+
+	c1, err := mgr.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got := mgr.hosts.list(); !slices.Equal(got, []string{seed, member}) {
+		t.Fatalf("hosts = %v, want [%s %s]", got, seed, member)
+	}
+
+	stopSeed()
+	_ = c1.Close()
+	c2, err := mgr.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get after seed went down: %v", err)
+	}
+	if c2 == c1 {
+		t.Error("expected a new connection")
+	}
+	if got := mgr.hosts.order()[0]; got != member {
+		t.Errorf("connected to %s, want member %s", got, member)
+	}
+}
+
+func TestDiscoveryRefreshes(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	var mu sync.Mutex
+	members := []string{"127.0.0.1:1"}
+	seed, stop := startStatusServer(t, pass, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return statusReply(members...)
+	})
+	defer stop()
+
+	mgr := NewFromConfig(configFor(seed, pass, nil), nil)
+	mgr.EnableDiscovery(5 * time.Millisecond)
+	if _, err := mgr.Get(context.Background()); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	mu.Lock()
+	members = append(members, "127.0.0.1:2")
+	mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !slices.Contains(mgr.hosts.list(), "127.0.0.1:2") {
+		if time.Now().After(deadline) {
+			t.Fatalf("refresh did not add new member: %v", mgr.hosts.list())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := mgr.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestDiscoveryErrorKeepsConnection(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	var queries atomic.Int32
+	seed, stop := startStatusServer(t, pass, func() string {
+		queries.Add(1)
+		return `{"t":18,"e":4100000,"r":["User ` + "`app`" + ` does not have the required ` + "`read`" + ` permissions."]}`
+	})
+	defer stop()
+
+	var buf strings.Builder
+	log := slog.New(slog.NewTextHandler(&buf, nil))
+	mgr := NewFromConfig(configFor(seed, pass, log), nil)
+	defer func() { _ = mgr.Close() }()
+	mgr.EnableDiscovery(0)
+
+	if _, err := mgr.Get(context.Background()); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if queries.Load() != 1 {
+		t.Errorf("server_status queries = %d, want 1", queries.Load())
+	}
+	if !strings.Contains(buf.String(), "host discovery failed") {
+		t.Errorf("discovery error not logged:\n%s", buf.String())
+	}
+	if got := mgr.hosts.list(); !slices.Equal(got, []string{seed}) {
+		t.Errorf("hosts = %v, want only the seed", got)
+	}
+}
+
+func TestFailoverReportsAllHosts(t *testing.T) {
+	t.Parallel()
+	mgr := NewFromConfig(conn.Config{Host: "127.0.0.1", Port: 1, User: "admin"}, nil)
+	defer func() { _ = mgr.Close() }()
+	mgr.hosts.add([]string{"127.0.0.1:2"})
+
+	_, err := mgr.Get(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no reachable host in 127.0.0.1:1, 127.0.0.1:2") {
+		t.Errorf("got %v, want error naming both hosts", err)
+	}
+}
@@ -0,0 +1,107 @@
+package cursor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"r-cli/internal/response"
+)
+
+// binaryFiles implements Conversion.Binary "files": each BINARY pseudo-type is
+// written to its own file under dir and replaced in the row by the file path.
+// Files are named "<id>.<field path>.bin", using "row<N>" when the row has no id.
+type binaryFiles struct {
+	dir  string
+	rows int
+}
+
+// extractRow replaces BINARY pseudo-types in raw with file paths.
+// Rows that are not valid JSON are returned unchanged.
+func (b *binaryFiles) extractRow(raw json.RawMessage) (json.RawMessage, error) {
+	var v interface{}
+	if json.Unmarshal(raw, &v) != nil {
+		return raw, nil
+	}
+	b.rows++
+	base := "row" + strconv.Itoa(b.rows)
+	if obj, ok := v.(map[string]interface{}); ok {
+		if id, ok := obj["id"]; ok && !isPseudoType(id) {
+			base = fmt.Sprint(id)
+		}
+	}
+	out, err := b.walk(v, base, nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(out)
+}
+
+func (b *binaryFiles) walk(v interface{}, base string, path []string) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if val["$reql_type$"] == "BINARY" {
+			return b.write(val, base, path)
+		}
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			conv, err := b.walk(item, base, append(path, k))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = conv
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			conv, err := b.walk(item, base, append(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = conv
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// write stores one BINARY value and returns its path; malformed values pass through.
+func (b *binaryFiles) write(m map[string]interface{}, base string, path []string) (interface{}, error) {
+	data, ok := response.ConvertPseudoTypes(m).([]byte)
+	if !ok {
+		return m, nil
+	}
+	name := sanitizeFileName(strings.Join(append([]string{base}, path...), ".")) + ".bin"
+	if err := os.MkdirAll(b.dir, 0o750); err != nil {
+		return nil, fmt.Errorf("binary-dir: %w", err)
+	}
+	p := filepath.Join(b.dir, name)
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		return nil, fmt.Errorf("binary-dir: %w", err)
+	}
+	return p, nil
+}
+
+func isPseudoType(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = m["$reql_type$"]
+	return ok
+}
+
+// sanitizeFileName replaces characters unsafe in file names with '_'.
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,101 @@
+package cursor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"r-cli/internal/response"
+)
+
+func TestBinaryFilesWritesFiles(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "blobs")
+	b := &binaryFiles{dir: dir}
+	// "aGVsbG8=" is base64 for "hello"
+	raw := json.RawMessage(`{"id":"doc/1","avatar":{"$reql_type$":"BINARY","data":"aGVsbG8="},"files":[{"$reql_type$":"BINARY","data":"aGVsbG8="}]}`)
+	got, err := b.extractRow(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal(got, &row); err != nil {
+		t.Fatal(err)
+	}
+	wantAvatar := filepath.Join(dir, "doc_1.avatar.bin")
+	if row["avatar"] != wantAvatar {
+		t.Errorf("avatar: got %v, want %q", row["avatar"], wantAvatar)
+	}
+	files, _ := row["files"].([]interface{})
+	wantFile := filepath.Join(dir, "doc_1.files.0.bin")
+	if len(files) != 1 || files[0] != wantFile {
+		t.Errorf("files: got %v, want [%q]", row["files"], wantFile)
+	}
+	for _, p := range []string{wantAvatar, wantFile} {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "hello" {
+			t.Errorf("%s: got %q, want %q", p, data, "hello")
+		}
+	}
+}
+
+func TestBinaryFilesRowWithoutID(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	b := &binaryFiles{dir: dir}
+	raw := json.RawMessage(`{"$reql_type$":"BINARY","data":"aGVsbG8="}`)
+	if _, err := b.extractRow(json.RawMessage(`{"x":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.extractRow(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(filepath.Join(dir, "row2.bin"))
+	if string(got) != string(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestBinaryFilesPassthrough(t *testing.T) {
+	t.Parallel()
+	b := &binaryFiles{dir: t.TempDir()}
+	raw := json.RawMessage(`{"id":1,"bad":{"$reql_type$":"BINARY","data":"!!"}}`)
+	got, err := b.extractRow(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal(got, &row); err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := row["bad"].(map[string]interface{}); !ok || m["data"] != "!!" {
+		t.Errorf("malformed BINARY should pass through, got %v", row["bad"])
+	}
+}
+
+func TestConvertBinaryFiles(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	raw := json.RawMessage(`{"id":7,"b":{"$reql_type$":"BINARY","data":"aGVsbG8="}}`)
+	c := Convert(NewSequence(&response.Response{Results: []json.RawMessage{raw}}), Conversion{Time: "raw", Binary: "files", BinaryDir: dir})
+	got, err := c.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(map[string]interface{}{"b": filepath.Join(dir, "7.b.bin"), "id": 7})
+	if string(got) != string(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	t.Parallel()
+	if got := sanitizeFileName("a/b c:d.e-f_g"); got != "a_b_c_d.e-f_g" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,278 @@
+package cursor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"r-cli/internal/response"
+)
+
+// Conversion selects how a cursor renders RethinkDB pseudo-types in its rows.
+// The zero value leaves TIME and BINARY objects as the server sent them and
+// splits a GROUPED_DATA result into one {"group", "reduction"} row per group.
+type Conversion struct {
+	// Time is native (time.Time in the server-supplied timezone), local,
+	// relative (e.g. "3m ago"), unix-ms (milliseconds since the Unix epoch)
+	// or raw; "" is raw.
+	Time string
+	// Binary is native (the decoded bytes, which marshal as base64), files
+	// (each value written under BinaryDir and replaced by the file path) or
+	// raw; "" is raw.
+	Binary    string
+	BinaryDir string
+	// RawGroups keeps a GROUPED_DATA result as a single pseudo-type row.
+	RawGroups bool
+	// Now is the clock for relative times; nil means time.Now.
+	Now func() time.Time
+}
+
+func (c Conversion) convertTime() bool {
+	return c.Time != "" && c.Time != "raw"
+}
+
+func (c Conversion) convertBinary() bool {
+	return c.Binary == "native"
+}
+
+// rewrites reports whether rows are changed beyond splitting groups.
+func (c Conversion) rewrites() bool {
+	return c.convertTime() || c.convertBinary() || c.Binary == "files"
+}
+
+// Convert wraps c so its rows have pseudo-types rendered as conv selects.
+// It returns c itself when conv would not change any row.
+func Convert(c Cursor, conv Conversion) Cursor {
+	if _, atom := c.(*atomCursor); !conv.rewrites() && (conv.RawGroups || !atom) {
+		return c
+	}
+	cc := &convertCursor{inner: c, conv: conv}
+	if conv.Binary == "files" {
+		cc.files = &binaryFiles{dir: conv.BinaryDir}
+	}
+	if sr, ok := c.(StatsReporter); ok {
+		return &convertStatsCursor{convertCursor: cc, sr: sr}
+	}
+	return cc
+}
+
+// convertCursor applies a Conversion to the rows of another cursor.
+type convertCursor struct {
+	inner Cursor
+	conv  Conversion
+	files *binaryFiles // set for Binary "files"
+
+	mu      sync.Mutex
+	pending []json.RawMessage // group rows not yet returned
+}
+
+// convertStatsCursor is a convertCursor over a cursor that reports Stats.
+type convertStatsCursor struct {
+	*convertCursor
+	sr StatsReporter
+}
+
+func (c *convertStatsCursor) Stats() Stats { return c.sr.Stats() }
+
+func (c *convertCursor) Next() (json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.pending) == 0 {
+		raw, err := c.inner.Next()
+		if err != nil {
+			return nil, err
+		}
+		c.pending = c.split(raw)
+	}
+	row := c.pending[0]
+	c.pending = c.pending[1:]
+	return c.render(row)
+}
+
+func (c *convertCursor) All() ([]json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rest, err := c.inner.All()
+	if err != nil {
+		return nil, err
+	}
+	rows := c.pending
+	c.pending = nil
+	for _, raw := range rest {
+		rows = append(rows, c.split(raw)...)
+	}
+	out := make([]json.RawMessage, 0, len(rows))
+	for _, row := range rows {
+		conv, err := c.render(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, conv)
+	}
+	return out, nil
+}
+
+func (c *convertCursor) Each(ctx context.Context, fn func(json.RawMessage) error) error {
+	return each(ctx, c, fn)
+}
+
+func (c *convertCursor) Chan(ctx context.Context) <-chan Row { return rowChan(ctx, c) }
+
+func (c *convertCursor) Close() error { return c.inner.Close() }
+
+// split returns the rows raw stands for: one per group of a GROUPED_DATA
+// value unless RawGroups is set, otherwise raw itself.
+func (c *convertCursor) split(raw json.RawMessage) []json.RawMessage {
+	if !c.conv.RawGroups {
+		if groups, ok := response.Grouped(raw); ok {
+			return groupRows(groups)
+		}
+	}
+	return []json.RawMessage{raw}
+}
+
+// groupRows encodes each group as a {"group": ..., "reduction": ...} row.
+func groupRows(groups []response.Group) []json.RawMessage {
+	rows := make([]json.RawMessage, 0, len(groups))
+	for _, g := range groups {
+		row, err := json.Marshal(g)
+		if err != nil {
+			continue // the fields were decoded from valid JSON
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// render applies the TIME and BINARY conversions to one row. Rows that are
+// not valid JSON are returned unchanged.
+func (c *convertCursor) render(raw json.RawMessage) (json.RawMessage, error) {
+	if c.files != nil {
+		var err error
+		if raw, err = c.files.extractRow(raw); err != nil {
+			return nil, err
+		}
+	}
+	if !c.conv.convertTime() && !c.conv.convertBinary() {
+		return raw, nil
+	}
+	r := renderer{Conversion: c.conv}
+	if c.conv.Time == "relative" {
+		r.now = time.Now()
+		if c.conv.Now != nil {
+			r.now = c.conv.Now()
+		}
+	}
+	var v interface{}
+	if json.Unmarshal(raw, &v) != nil {
+		return raw, nil
+	}
+	out, err := json.Marshal(r.value(v))
+	if err != nil {
+		return raw, nil
+	}
+	return out, nil
+}
+
+// renderer converts the pseudo-types of one row.
+type renderer struct {
+	Conversion
+	now time.Time // reference time for relative times
+}
+
+// value recursively converts TIME and/or BINARY pseudo-types in v.
+func (r renderer) value(v interface{}) interface{} {
+	if r.Time == "native" && r.convertBinary() {
+		return response.ConvertPseudoTypes(v)
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return r.object(val)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.value(item)
+		}
+		return out
+	}
+	return v
+}
+
+func (r renderer) object(m map[string]interface{}) interface{} {
+	switch m["$reql_type$"] {
+	case "TIME":
+		if r.convertTime() {
+			return formatTime(m, r.Time, r.now)
+		}
+		return m
+	case "BINARY":
+		if r.convertBinary() {
+			return response.ConvertPseudoTypes(m)
+		}
+		return m
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, item := range m {
+		out[k] = r.value(item)
+	}
+	return out
+}
+
+// formatTime renders a TIME pseudo-type in the given format:
+//   - native: time.Time in the server-supplied timezone
+//   - local: time.Time in the local timezone
+//   - relative: human-readable offset from now, e.g. "3m ago"
+//   - unix-ms: integer milliseconds since the Unix epoch
+//
+// Malformed pseudo-types are returned unchanged.
+func formatTime(m map[string]interface{}, format string, now time.Time) interface{} {
+	t, ok := response.ConvertPseudoTypes(m).(time.Time)
+	if !ok {
+		return m
+	}
+	switch format {
+	case "local":
+		return t.Local()
+	case "relative":
+		return relativeTime(t, now)
+	case "unix-ms":
+		// epoch_time is a float; rounding avoids off-by-one ms from float error
+		epoch, _ := m["epoch_time"].(float64)
+		return int64(math.Round(epoch * 1000))
+	default:
+		return t
+	}
+}
+
+// relativeTime formats the distance between t and now in the largest whole unit,
+// e.g. "3m ago" or "in 2h"; distances under a second are "just now".
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	if d < time.Second {
+		return "just now"
+	}
+	var s string
+	switch {
+	case d < time.Minute:
+		s = fmt.Sprintf("%ds", int(d/time.Second))
+	case d < time.Hour:
+		s = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		s = fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 365*24*time.Hour:
+		s = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	default:
+		s = fmt.Sprintf("%dy", int(d/(365*24*time.Hour)))
+	}
+	if future {
+		return "in " + s
+	}
+	return s + " ago"
+}
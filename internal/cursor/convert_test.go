@@ -0,0 +1,219 @@
+package cursor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"r-cli/internal/proto"
+	"r-cli/internal/response"
+)
+
+func timePseudo(epoch float64, tz string) map[string]interface{} {
+	return map[string]interface{}{"$reql_type$": "TIME", "epoch_time": epoch, "timezone": tz}
+}
+
+func TestFormatTimeNative(t *testing.T) {
+	t.Parallel()
+	got, ok := formatTime(timePseudo(3600, "+01:00"), "native", time.Time{}).(time.Time)
+	if !ok {
+		t.Fatal("expected time.Time")
+	}
+	if _, off := got.Zone(); off != 3600 {
+		t.Errorf("zone offset: got %d, want 3600", off)
+	}
+	if !got.Equal(time.Unix(3600, 0)) {
+		t.Errorf("got %v, want %v", got, time.Unix(3600, 0))
+	}
+}
+
+func TestFormatTimeLocal(t *testing.T) {
+	t.Parallel()
+	got, ok := formatTime(timePseudo(3600, "+05:00"), "local", time.Time{}).(time.Time)
+	if !ok {
+		t.Fatal("expected time.Time")
+	}
+	if got.Location() != time.Local {
+		t.Errorf("location: got %v, want Local", got.Location())
+	}
+	if !got.Equal(time.Unix(3600, 0)) {
+		t.Errorf("got %v, want %v", got, time.Unix(3600, 0))
+	}
+}
+
+func TestFormatTimeUnixMs(t *testing.T) {
+	t.Parallel()
+	got := formatTime(timePseudo(1700000000.123, "+00:00"), "unix-ms", time.Time{})
+	if got != int64(1700000000123) {
+		t.Errorf("got %v, want 1700000000123", got)
+	}
+}
+
+func TestFormatTimeRelative(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1000000, 0)
+	got := formatTime(timePseudo(1000000-180, "+00:00"), "relative", now)
+	if got != "3m ago" {
+		t.Errorf("got %v, want %q", got, "3m ago")
+	}
+}
+
+func TestFormatTimeMalformed(t *testing.T) {
+	t.Parallel()
+	m := map[string]interface{}{"$reql_type$": "TIME", "epoch_time": "bad"}
+	got, ok := formatTime(m, "unix-ms", time.Time{}).(map[string]interface{})
+	if !ok || got["epoch_time"] != "bad" {
+		t.Errorf("malformed TIME should pass through, got %v", got)
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(100000000, 0)
+	tests := []struct {
+		offset time.Duration
+		want   string
+	}{
+		{0, "just now"},
+		{500 * time.Millisecond, "just now"},
+		{42 * time.Second, "42s ago"},
+		{3 * time.Minute, "3m ago"},
+		{5*time.Hour + 59*time.Minute, "5h ago"},
+		{3 * 24 * time.Hour, "3d ago"},
+		{800 * 24 * time.Hour, "2y ago"},
+		{-2 * time.Hour, "in 2h"},
+	}
+	for _, tc := range tests {
+		if got := relativeTime(now.Add(-tc.offset), now); got != tc.want {
+			t.Errorf("offset %v: got %q, want %q", tc.offset, got, tc.want)
+		}
+	}
+}
+
+func TestConvertTimeFormats(t *testing.T) {
+	t.Parallel()
+	raw := json.RawMessage(`{"at":{"$reql_type$":"TIME","epoch_time":60,"timezone":"+00:00"}}`)
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"unix-ms", `{"at":60000}`},
+		{"relative", `{"at":"1m ago"}`},
+		{"raw", string(raw)},
+	}
+	for _, tc := range tests {
+		c := Convert(NewSequence(&response.Response{Results: []json.RawMessage{raw}}), Conversion{
+			Time: tc.format,
+			Now:  func() time.Time { return time.Unix(120, 0) },
+		})
+		got, err := c.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.format, got, tc.want)
+		}
+	}
+}
+
+func TestConvertNative(t *testing.T) {
+	t.Parallel()
+	// "aGVsbG8=" is base64 for "hello"
+	rows := []json.RawMessage{
+		rawMsg(`{"$reql_type$":"TIME","epoch_time":0,"timezone":"+00:00"}`),
+		rawMsg(`{"$reql_type$":"BINARY","data":"aGVsbG8="}`),
+		rawMsg(`{"key":"value"}`),
+		rawMsg(`not json`),
+	}
+	got, err := Convert(NewSequence(&response.Response{Results: rows}), Conversion{Time: "native", Binary: "native"}).All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`"1970-01-01T00:00:00Z"`, `"aGVsbG8="`, `{"key":"value"}`, `not json`}
+	for i, row := range got {
+		if string(row) != want[i] {
+			t.Errorf("row %d = %s, want %s", i, row, want[i])
+		}
+	}
+}
+
+func TestConvertSelective(t *testing.T) {
+	t.Parallel()
+	raw := rawMsg(`{"t":{"$reql_type$":"TIME","epoch_time":0,"timezone":"+00:00"},"b":{"$reql_type$":"BINARY","data":"aGVsbG8="}}`)
+	tests := []struct {
+		conv Conversion
+		want string
+	}{
+		{Conversion{Time: "raw", Binary: "native"}, `{"b":"aGVsbG8=","t":{"$reql_type$":"TIME","epoch_time":0,"timezone":"+00:00"}}`},
+		{Conversion{Time: "unix-ms", Binary: "raw"}, `{"b":{"$reql_type$":"BINARY","data":"aGVsbG8="},"t":0}`},
+	}
+	for _, tc := range tests {
+		got, err := Convert(NewSequence(&response.Response{Results: []json.RawMessage{raw}}), tc.conv).Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("%+v: got %s, want %s", tc.conv, got, tc.want)
+		}
+	}
+}
+
+func TestConvertEOF(t *testing.T) {
+	t.Parallel()
+	c := Convert(NewSequence(&response.Response{}), Conversion{Time: "native"})
+	if _, err := c.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
+
+func TestConvertSplitsGroups(t *testing.T) {
+	t.Parallel()
+	resp := &response.Response{
+		Type:    proto.ResponseSuccessAtom,
+		Results: []json.RawMessage{rawMsg(`{"$reql_type$":"GROUPED_DATA","data":[["admin",2],[null,[{"id":1}]]]}`)},
+	}
+	rows, err := Convert(NewAtom(resp), Conversion{}).All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	want := []string{`{"group":"admin","reduction":2}`, `{"group":null,"reduction":[{"id":1}]}`}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %s", len(rows), len(want), rows)
+	}
+	for i, row := range rows {
+		if string(row) != want[i] {
+			t.Errorf("row %d = %s, want %s", i, row, want[i])
+		}
+	}
+	empty := &response.Response{Type: proto.ResponseSuccessAtom, Results: []json.RawMessage{rawMsg(`{"$reql_type$":"GROUPED_DATA","data":[]}`)}}
+	if _, err := Convert(NewAtom(empty), Conversion{}).Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("empty groups: Next = %v, want io.EOF", err)
+	}
+}
+
+func TestConvertRawGroups(t *testing.T) {
+	t.Parallel()
+	raw := `{"$reql_type$":"GROUPED_DATA","data":[["a",1]]}`
+	c := Convert(NewAtom(&response.Response{Type: proto.ResponseSuccessAtom, Results: []json.RawMessage{rawMsg(raw)}}), Conversion{RawGroups: true})
+	if row, err := c.Next(); err != nil || string(row) != raw {
+		t.Errorf("Next = %s, %v; want the pseudo-type", row, err)
+	}
+}
+
+func TestConvertPassesThrough(t *testing.T) {
+	t.Parallel()
+	seq := NewSequence(&response.Response{Results: []json.RawMessage{rawMsg(`1`)}})
+	if c := Convert(seq, Conversion{Time: "raw", Binary: "raw"}); c != seq {
+		t.Error("Convert wrapped a cursor it does not change")
+	}
+	stream := NewStream(context.Background(), &response.Response{Type: proto.ResponseSuccessSequence}, nil, func(proto.QueryType) error { return nil }, Options{})
+	if _, ok := Convert(stream, Conversion{Time: "native"}).(StatsReporter); !ok {
+		t.Error("converted stream cursor lost Stats")
+	}
+	if _, ok := Convert(NewAtom(&response.Response{}), Conversion{Time: "native"}).(StatsReporter); ok {
+		t.Error("converted atom cursor reports Stats")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"r-cli/internal/proto"
 	"r-cli/internal/response"
@@ -16,9 +18,141 @@ import (
 type Cursor interface {
 	Next() (json.RawMessage, error)
 	All() ([]json.RawMessage, error)
+	// Each calls fn for every remaining row until the cursor ends (nil), fn
+	// or the cursor fails, or ctx is cancelled; cancellation closes the
+	// cursor so a pending fetch returns.
+	Each(ctx context.Context, fn func(json.RawMessage) error) error
+	// Chan streams the remaining rows from a new goroutine for select-based
+	// consumers; the channel is closed when the cursor ends, after a last
+	// Row carrying the error if it failed. Cancelling ctx closes the cursor
+	// and the channel without an error Row.
+	Chan(ctx context.Context) <-chan Row
 	Close() error
 }
 
+// Row is one item received from Cursor.Chan: either Data or, as the last
+// value, a non-nil Err.
+type Row struct {
+	Data json.RawMessage
+	Err  error
+}
+
+// ErrNoRows is returned by DecodeOne when the cursor has no rows.
+var ErrNoRows = errors.New("cursor: no rows")
+
+// ErrIdleTimeout is returned by a changefeed cursor's Next when no row
+// arrived within Options.IdleTimeout. The cursor stays open: the next call
+// keeps waiting for the same batch.
+var ErrIdleTimeout = errors.New("cursor: no changes within the idle timeout")
+
+// DecodeNext decodes the next row into dest with response.Decode, which
+// turns TIME and BINARY pseudo-types into time.Time and []byte. It returns
+// io.EOF after the last row.
+func DecodeNext(c Cursor, dest interface{}) error {
+	row, err := c.Next()
+	if err != nil {
+		return err
+	}
+	return response.Decode(row, dest)
+}
+
+// DecodeOne decodes the first row into dest and closes the cursor.
+func DecodeOne(c Cursor, dest interface{}) error {
+	defer func() { _ = c.Close() }()
+	err := DecodeNext(c, dest)
+	if errors.Is(err, io.EOF) {
+		return ErrNoRows
+	}
+	return err
+}
+
+// DecodeAll appends every remaining row to *dest, decoded as T.
+func DecodeAll[T any](c Cursor, dest *[]T) error {
+	for {
+		var v T
+		err := DecodeNext(c, &v)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		*dest = append(*dest, v)
+	}
+}
+
+// Stats reports how a cursor fetched its results.
+type Stats struct {
+	Batches  int // responses received, including the initial one
+	Requests int // CONTINUE and STOP queries sent by the cursor
+}
+
+// StatsReporter is implemented by cursors that fetch results in batches.
+// Atom and sequence cursors arrive in a single response and do not implement it.
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// countingSend wraps send, counting each query sent into n.
+func countingSend(send func(proto.QueryType) error, n *atomic.Int64) func(proto.QueryType) error {
+	return func(qt proto.QueryType) error {
+		n.Add(1)
+		return send(qt)
+	}
+}
+
+// each drains c into fn; it implements Cursor.Each for every cursor type.
+func each(ctx context.Context, c Cursor, fn func(json.RawMessage) error) error {
+	stop := context.AfterFunc(ctx, func() { _ = c.Close() })
+	defer func() {
+		// wait for a Close already started by the AfterFunc
+		if !stop() {
+			_ = c.Close()
+		}
+	}()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		row, err := c.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+// rowChan implements Cursor.Chan on top of each.
+func rowChan(ctx context.Context, c Cursor) <-chan Row {
+	ch := make(chan Row)
+	go func() {
+		defer close(ch)
+		err := each(ctx, c, func(data json.RawMessage) error {
+			select {
+			case ch <- Row{Data: data}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			select {
+			case ch <- Row{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return ch
+}
+
 // atomCursor returns a single value from a SUCCESS_ATOM response.
 type atomCursor struct {
 	item    json.RawMessage
@@ -34,6 +168,16 @@ func NewAtom(resp *response.Response) Cursor {
 	return &atomCursor{}
 }
 
+// IsAtom reports whether c holds a single value rather than a sequence,
+// looking through Convert.
+func IsAtom(c Cursor) bool {
+	if cc, ok := c.(*convertCursor); ok {
+		c = cc.inner
+	}
+	_, ok := c.(*atomCursor)
+	return ok
+}
+
 func (c *atomCursor) Next() (json.RawMessage, error) {
 	if c.done || !c.hasItem {
 		return nil, io.EOF
@@ -50,6 +194,12 @@ func (c *atomCursor) All() ([]json.RawMessage, error) {
 	return []json.RawMessage{c.item}, nil
 }
 
+func (c *atomCursor) Each(ctx context.Context, fn func(json.RawMessage) error) error {
+	return each(ctx, c, fn)
+}
+
+func (c *atomCursor) Chan(ctx context.Context) <-chan Row { return rowChan(ctx, c) }
+
 func (c *atomCursor) Close() error { return nil }
 
 // seqCursor iterates over all items in a SUCCESS_SEQUENCE response.
@@ -78,24 +228,59 @@ func (c *seqCursor) All() ([]json.RawMessage, error) {
 	return remaining, nil
 }
 
+func (c *seqCursor) Each(ctx context.Context, fn func(json.RawMessage) error) error {
+	return each(ctx, c, fn)
+}
+
+func (c *seqCursor) Chan(ctx context.Context) <-chan Row { return rowChan(ctx, c) }
+
 func (c *seqCursor) Close() error { return nil }
 
+// Options tunes how stream and changefeed cursors fetch batches. Rows are
+// buffered only up to these bounds; a consumer slower than the server delays
+// the next CONTINUE instead.
+type Options struct {
+	// Convert selects how pseudo-types are rendered in the rows of the
+	// cursors a query executor returns, which it wraps with Convert.
+	Convert Conversion
+	// Prefetch is how many batches a cursor requests ahead of the consumer.
+	// Once Next starts on a batch, CONTINUE is sent for the next one while
+	// fewer than Prefetch batches are buffered, overlapping the round trip
+	// with the caller's work. 0 sends CONTINUE only when the buffered rows
+	// run out. At most one CONTINUE is outstanding.
+	Prefetch int
+	// MaxBufferedRows stops prefetching while this many received rows are
+	// waiting for Next; 0 means only Prefetch bounds the buffer.
+	MaxBufferedRows int
+	// IdleTimeout makes Next on a changefeed return ErrIdleTimeout when it
+	// has waited this long without a row; 0 waits forever. Other cursors
+	// ignore it.
+	IdleTimeout time.Duration
+}
+
 // streamCursor handles paginated SUCCESS_PARTIAL responses by sending CONTINUE.
+// With feed set it serves a changefeed, which never completes: only Close,
+// an error or a connection drop ends it.
 type streamCursor struct {
 	ch     <-chan *response.Response
 	send   func(qt proto.QueryType) error
 	ctx    context.Context
 	cancel context.CancelFunc
+	opts   Options
+	feed   bool
 
 	mu       sync.Mutex
 	cond     *sync.Cond
 	buf      []json.RawMessage
 	pos      int
-	partial  bool // last response was PARTIAL; CONTINUE needed when buf exhausted
-	done     bool
+	queue    []*response.Response // batches received ahead of buf
+	partial  bool                 // last response received was PARTIAL; more can be requested
+	inflight bool                 // a CONTINUE was sent and its response not yet received
 	err      error
 	fetching bool
+	batches  int
 
+	requests  atomic.Int64
 	closeOnce sync.Once
 	stopErr   error
 }
@@ -103,111 +288,200 @@ type streamCursor struct {
 // NewStream creates a streaming cursor for SUCCESS_PARTIAL responses.
 // initial is the first response; ch receives subsequent batches.
 // send transmits CONTINUE or STOP queries back to the server.
-func NewStream(ctx context.Context, initial *response.Response, ch <-chan *response.Response, send func(proto.QueryType) error) Cursor {
+func NewStream(ctx context.Context, initial *response.Response, ch <-chan *response.Response, send func(proto.QueryType) error, opts Options) Cursor {
+	return newStream(ctx, initial, ch, send, opts, false)
+}
+
+// NewChangefeed creates a cursor for infinite changefeed streams.
+// It sends CONTINUE after each batch and never terminates automatically;
+// All() returns an error.
+func NewChangefeed(ctx context.Context, initial *response.Response, ch <-chan *response.Response, send func(proto.QueryType) error, opts Options) Cursor {
+	return newStream(ctx, initial, ch, send, opts, true)
+}
+
+func newStream(ctx context.Context, initial *response.Response, ch <-chan *response.Response, send func(proto.QueryType) error, opts Options, feed bool) *streamCursor {
 	ctx2, cancel := context.WithCancel(ctx)
 	c := &streamCursor{
-		ch:     ch,
-		send:   send,
-		ctx:    ctx2,
-		cancel: cancel,
-		buf:    initial.Results,
-		pos:    0,
+		ch:      ch,
+		ctx:     ctx2,
+		cancel:  cancel,
+		opts:    opts,
+		feed:    feed,
+		buf:     initial.Results,
+		pos:     0,
+		partial: feed || initial.Type == proto.ResponseSuccessPartial,
+		batches: 1,
 	}
+	c.send = countingSend(send, &c.requests)
 	c.cond = sync.NewCond(&c.mu)
-	switch initial.Type {
-	case proto.ResponseSuccessSequence:
-		c.done = true
-	case proto.ResponseSuccessPartial:
-		c.partial = true
-	}
 	return c
 }
 
 func (c *streamCursor) Next() (json.RawMessage, error) {
+	var idle <-chan time.Time
+	if c.feed && c.opts.IdleTimeout > 0 {
+		t := time.NewTimer(c.opts.IdleTimeout)
+		defer t.Stop()
+		idle = t.C
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.poll()
 	for {
-		if c.err != nil {
-			return nil, c.err
-		}
+		// rows already received are returned before a later error
 		if c.pos < len(c.buf) {
 			item := c.buf[c.pos]
 			c.pos++
+			c.prefetchAhead()
 			return item, nil
 		}
-		if c.done {
+		if c.err != nil {
+			return nil, c.err
+		}
+		if len(c.queue) > 0 {
+			resp := c.queue[0]
+			c.queue = c.queue[1:]
+			c.install(resp)
+			c.prefetchAhead()
+			continue
+		}
+		if !c.partial && !c.inflight {
 			return nil, io.EOF
 		}
 		if c.fetching {
 			c.cond.Wait()
 			continue
 		}
-		if err := c.fetchBatch(); err != nil {
+		if err := c.fetchBatch(idle); err != nil {
 			return nil, err
 		}
 	}
 }
 
-// fetchBatch is called with mu held; it releases and reacquires mu around I/O.
-func (c *streamCursor) fetchBatch() error {
-	c.fetching = true
-	needContinue := c.partial
-	c.mu.Unlock()
+// poll is called with mu held. It takes an already delivered response off
+// ch without blocking and requests further batches up to the prefetch depth.
+func (c *streamCursor) poll() {
+	if c.inflight && !c.fetching {
+		select {
+		case resp, ok := <-c.ch:
+			c.receive(resp, ok)
+		default:
+		}
+	}
+	c.prefetchAhead()
+}
 
-	var fetchErr error
-	if needContinue {
-		fetchErr = c.send(proto.QueryContinue)
+// prefetchAhead is called with mu held; it sends CONTINUE while fewer than
+// Prefetch batches and MaxBufferedRows rows are buffered.
+func (c *streamCursor) prefetchAhead() {
+	if !c.partial || c.inflight || c.err != nil || len(c.queue) >= c.opts.Prefetch {
+		return
 	}
-	var resp *response.Response
-	if fetchErr == nil {
-		resp, fetchErr = c.waitForResponse()
+	if c.opts.MaxBufferedRows > 0 && c.buffered() >= c.opts.MaxBufferedRows {
+		return
 	}
+	c.requestBatch()
+}
 
-	c.mu.Lock()
-	c.fetching = false
-
-	if fetchErr != nil {
-		c.err = fetchErr
-		c.cond.Broadcast()
-		return fetchErr
+// buffered is called with mu held; it counts the received rows not yet
+// returned by Next.
+func (c *streamCursor) buffered() int {
+	n := len(c.buf) - c.pos
+	for _, resp := range c.queue {
+		n += len(resp.Results)
 	}
+	return n
+}
 
-	c.buf = resp.Results
-	c.pos = 0
+// requestBatch is called with mu held; send only starts the request.
+func (c *streamCursor) requestBatch() {
 	c.partial = false
+	c.inflight = true
+	if err := c.send(proto.QueryContinue); err != nil {
+		c.inflight = false
+		c.err = err
+	}
+}
+
+// receive is called with mu held; it queues a response read from ch, or
+// records the end of ch.
+func (c *streamCursor) receive(resp *response.Response, ok bool) {
+	c.inflight = false
+	if !ok {
+		c.err = io.EOF
+		if c.feed {
+			c.err = fmt.Errorf("cursor: connection closed")
+		}
+		return
+	}
+	c.queue = append(c.queue, resp)
+	c.partial = resp.Type == proto.ResponseSuccessPartial
+	c.batches++
+}
 
+// install is called with mu held; it makes resp the current batch.
+func (c *streamCursor) install(resp *response.Response) {
+	c.buf, c.pos = nil, 0
 	switch {
-	case resp.Type == proto.ResponseSuccessSequence:
-		c.done = true
-	case resp.Type == proto.ResponseSuccessPartial:
-		c.partial = true
+	case resp.Type == proto.ResponseSuccessPartial, resp.Type == proto.ResponseSuccessSequence && !c.feed:
+		c.buf = resp.Results
 	case resp.Type.IsError():
 		c.err = response.MapError(resp)
 	default:
 		c.err = fmt.Errorf("cursor: unexpected response type %d", resp.Type)
 	}
-	c.cond.Broadcast()
-	return c.err
 }
 
-func (c *streamCursor) waitForResponse() (*response.Response, error) {
+// fetchBatch is called with mu held; it requests a batch unless one is
+// already on the way and releases mu while waiting for it. When idle fires
+// first it returns ErrIdleTimeout and the request stays outstanding.
+func (c *streamCursor) fetchBatch(idle <-chan time.Time) error {
+	if !c.inflight {
+		c.requestBatch()
+		if c.err != nil {
+			return nil
+		}
+	}
+	c.fetching = true
+	c.mu.Unlock()
+
+	resp, ok, err := c.waitForResponse(idle)
+
+	c.mu.Lock()
+	c.fetching = false
+	defer c.cond.Broadcast()
+	switch {
+	case errors.Is(err, ErrIdleTimeout):
+		return err
+	case err != nil:
+		c.inflight = false
+		c.err = err
+	default:
+		c.receive(resp, ok)
+	}
+	return nil
+}
+
+func (c *streamCursor) waitForResponse(idle <-chan time.Time) (*response.Response, bool, error) {
 	select {
 	case resp, ok := <-c.ch:
-		if !ok {
-			return nil, io.EOF
-		}
-		return resp, nil
+		return resp, ok, nil
+	case <-idle:
+		return nil, false, ErrIdleTimeout
 	case <-c.ctx.Done():
 		// send STOP exactly once (guards against concurrent Close())
 		c.closeOnce.Do(func() {
 			c.stopErr = c.send(proto.QueryStop)
 		})
-		return nil, c.ctx.Err()
+		return nil, false, c.ctx.Err()
 	}
 }
 
 func (c *streamCursor) All() ([]json.RawMessage, error) {
+	if c.feed {
+		return nil, fmt.Errorf("cursor: All() not supported for changefeed; use Next()")
+	}
 	var all []json.RawMessage
 	for {
 		item, err := c.Next()
@@ -221,132 +495,23 @@ func (c *streamCursor) All() ([]json.RawMessage, error) {
 	}
 }
 
-func (c *streamCursor) Close() error {
-	c.closeOnce.Do(func() {
-		c.mu.Lock()
-		needStop := !c.done && c.err == nil
-		c.mu.Unlock()
-		c.cancel()
-		if needStop {
-			c.stopErr = c.send(proto.QueryStop)
-		}
-	})
-	return c.stopErr
-}
-
-// changefeedCursor handles infinite SUCCESS_PARTIAL streams (changefeeds).
-// It never auto-completes; only Close() or a connection drop terminates it.
-type changefeedCursor struct {
-	ch     <-chan *response.Response
-	send   func(qt proto.QueryType) error
-	ctx    context.Context
-	cancel context.CancelFunc
-
-	mu       sync.Mutex
-	cond     *sync.Cond
-	buf      []json.RawMessage
-	pos      int
-	err      error
-	fetching bool
-
-	closeOnce sync.Once
-	stopErr   error
+func (c *streamCursor) Each(ctx context.Context, fn func(json.RawMessage) error) error {
+	return each(ctx, c, fn)
 }
 
-// NewChangefeed creates a cursor for infinite changefeed streams.
-// It always sends CONTINUE after each batch and never terminates automatically.
-func NewChangefeed(ctx context.Context, initial *response.Response, ch <-chan *response.Response, send func(proto.QueryType) error) Cursor {
-	ctx2, cancel := context.WithCancel(ctx)
-	c := &changefeedCursor{
-		ch:     ch,
-		send:   send,
-		ctx:    ctx2,
-		cancel: cancel,
-		buf:    initial.Results,
-		pos:    0,
-	}
-	c.cond = sync.NewCond(&c.mu)
-	return c
-}
+func (c *streamCursor) Chan(ctx context.Context) <-chan Row { return rowChan(ctx, c) }
 
-func (c *changefeedCursor) Next() (json.RawMessage, error) {
+// Stats reports the batches received and queries sent so far.
+func (c *streamCursor) Stats() Stats {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-
-	for {
-		if c.err != nil {
-			return nil, c.err
-		}
-		if c.pos < len(c.buf) {
-			item := c.buf[c.pos]
-			c.pos++
-			return item, nil
-		}
-		if c.fetching {
-			c.cond.Wait()
-			continue
-		}
-		if err := c.fetchNextBatch(); err != nil {
-			return nil, err
-		}
-	}
+	return Stats{Batches: c.batches, Requests: int(c.requests.Load())}
 }
 
-// fetchNextBatch is called with mu held; releases and reacquires mu around I/O.
-func (c *changefeedCursor) fetchNextBatch() error {
-	c.fetching = true
-	c.mu.Unlock()
-
-	fetchErr := c.send(proto.QueryContinue)
-	var resp *response.Response
-	if fetchErr == nil {
-		resp, fetchErr = c.waitForChangefeedResponse()
-	}
-
-	c.mu.Lock()
-	c.fetching = false
-
-	if fetchErr != nil {
-		c.err = fetchErr
-		c.cond.Broadcast()
-		return fetchErr
-	}
-
-	c.buf = resp.Results
-	c.pos = 0
-
-	if resp.Type.IsError() {
-		c.err = response.MapError(resp)
-	} else if resp.Type != proto.ResponseSuccessPartial {
-		c.err = fmt.Errorf("cursor: unexpected response type %d", resp.Type)
-	}
-	c.cond.Broadcast()
-	return c.err
-}
-
-func (c *changefeedCursor) waitForChangefeedResponse() (*response.Response, error) {
-	select {
-	case resp, ok := <-c.ch:
-		if !ok {
-			return nil, fmt.Errorf("cursor: connection closed")
-		}
-		return resp, nil
-	case <-c.ctx.Done():
-		c.closeOnce.Do(func() {
-			c.stopErr = c.send(proto.QueryStop)
-		})
-		return nil, c.ctx.Err()
-	}
-}
-
-func (c *changefeedCursor) All() ([]json.RawMessage, error) {
-	return nil, fmt.Errorf("cursor: All() not supported for changefeed; use Next()")
-}
-
-func (c *changefeedCursor) Close() error {
+func (c *streamCursor) Close() error {
 	c.closeOnce.Do(func() {
 		c.mu.Lock()
-		needStop := c.err == nil
+		needStop := (c.partial || c.inflight) && c.err == nil
 		c.mu.Unlock()
 		c.cancel()
 		if needStop {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -72,6 +73,18 @@ func TestAtomCursor_EOF_Immediately_When_Empty(t *testing.T) {
 	}
 }
 
+func TestIsAtom(t *testing.T) {
+	t.Parallel()
+	atom := NewAtom(&response.Response{Results: []json.RawMessage{rawMsg(`[1,2]`)}})
+	seq := NewSequence(&response.Response{Results: []json.RawMessage{rawMsg(`1`)}})
+	if !IsAtom(atom) || !IsAtom(Convert(atom, Conversion{Time: "raw"})) {
+		t.Error("IsAtom(atom) = false")
+	}
+	if IsAtom(seq) || IsAtom(Convert(seq, Conversion{Time: "native"})) {
+		t.Error("IsAtom(sequence) = true")
+	}
+}
+
 func TestSeqCursor_IterateAll(t *testing.T) {
 	t.Parallel()
 	resp := &response.Response{
@@ -168,7 +181,7 @@ func TestStreamCursor_PartialThenSequence(t *testing.T) {
 		Type:    proto.ResponseSuccessPartial,
 		Results: []json.RawMessage{rawMsg(`1`), rawMsg(`2`)},
 	}
-	c := NewStream(context.Background(), initial, ch, send)
+	c := NewStream(context.Background(), initial, ch, send, Options{})
 
 	for i := 1; i <= 4; i++ {
 		item, err := c.Next()
@@ -207,7 +220,7 @@ func TestStreamCursor_Close_SendsStop(t *testing.T) {
 		Type:    proto.ResponseSuccessPartial,
 		Results: []json.RawMessage{rawMsg(`1`)},
 	}
-	c := NewStream(context.Background(), initial, ch, send)
+	c := NewStream(context.Background(), initial, ch, send, Options{})
 
 	item, err := c.Next()
 	if err != nil {
@@ -252,7 +265,7 @@ func TestStreamCursor_ContextCancel_SendsStop(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	c := NewStream(ctx, initial, ch, send)
+	c := NewStream(ctx, initial, ch, send, Options{})
 
 	errCh := make(chan error, 1)
 	go func() {
@@ -316,7 +329,7 @@ func TestChangefeedCursor_InfiniteStream(t *testing.T) {
 		Type:    proto.ResponseSuccessPartial,
 		Results: []json.RawMessage{rawMsg(`1`)},
 	}
-	c := NewChangefeed(context.Background(), initial, ch, send)
+	c := NewChangefeed(context.Background(), initial, ch, send, Options{})
 
 	for i := 1; i <= 4; i++ {
 		item, err := c.Next()
@@ -341,6 +354,26 @@ func TestChangefeedCursor_InfiniteStream(t *testing.T) {
 	}
 }
 
+func TestChangefeedCursor_IdleTimeout(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response, 1)
+	send := func(proto.QueryType) error { return nil }
+	initial := &response.Response{Type: proto.ResponseSuccessPartial}
+	c := NewChangefeed(context.Background(), initial, ch, send, Options{IdleTimeout: 20 * time.Millisecond})
+	defer func() { _ = c.Close() }()
+
+	if _, err := c.Next(); !errors.Is(err, ErrIdleTimeout) {
+		t.Fatalf("Next = %v, want ErrIdleTimeout", err)
+	}
+	ch <- &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(`1`)}}
+	if row, err := c.Next(); err != nil || string(row) != "1" {
+		t.Fatalf("Next after idle = %s, %v", row, err)
+	}
+	if st := c.(StatsReporter).Stats(); st.Requests != 1 { //nolint:forcetypeassert
+		t.Errorf("requests = %d, want the one CONTINUE kept across the timeout", st.Requests)
+	}
+}
+
 func TestChangefeedCursor_Close_SendsStop(t *testing.T) {
 	t.Parallel()
 	ch := make(chan *response.Response) // never receives
@@ -358,7 +391,7 @@ func TestChangefeedCursor_Close_SendsStop(t *testing.T) {
 		Type:    proto.ResponseSuccessPartial,
 		Results: []json.RawMessage{rawMsg(`1`)},
 	}
-	c := NewChangefeed(context.Background(), initial, ch, send)
+	c := NewChangefeed(context.Background(), initial, ch, send, Options{})
 
 	item, err := c.Next()
 	if err != nil {
@@ -389,7 +422,7 @@ func TestChangefeedCursor_ConnectionDrop(t *testing.T) {
 		Type:    proto.ResponseSuccessPartial,
 		Results: nil,
 	}
-	c := NewChangefeed(context.Background(), initial, ch, send)
+	c := NewChangefeed(context.Background(), initial, ch, send, Options{})
 
 	errCh := make(chan error, 1)
 	go func() {
@@ -439,7 +472,7 @@ func TestChangefeedCursor_All_ReturnsError(t *testing.T) {
 		Type:    proto.ResponseSuccessPartial,
 		Results: nil,
 	}
-	c := NewChangefeed(context.Background(), initial, make(chan *response.Response), func(proto.QueryType) error { return nil })
+	c := NewChangefeed(context.Background(), initial, make(chan *response.Response), func(proto.QueryType) error { return nil }, Options{})
 	_, err := c.All()
 	if err == nil {
 		t.Fatal("expected error from changefeed All(), got nil")
@@ -462,7 +495,7 @@ func TestStreamCursor_ServerError(t *testing.T) {
 		Type:    proto.ResponseSuccessPartial,
 		Results: []json.RawMessage{rawMsg(`1`)},
 	}
-	c := NewStream(context.Background(), initial, ch, send)
+	c := NewStream(context.Background(), initial, ch, send, Options{})
 
 	_, err := c.Next() // consume initial item
 	if err != nil {
@@ -491,7 +524,7 @@ func TestStreamCursor_UnexpectedResponseType(t *testing.T) {
 		Type:    proto.ResponseSuccessPartial,
 		Results: []json.RawMessage{rawMsg(`1`)},
 	}
-	c := NewStream(context.Background(), initial, ch, send)
+	c := NewStream(context.Background(), initial, ch, send, Options{})
 
 	_, err := c.Next() // consume initial item
 	if err != nil {
@@ -523,7 +556,7 @@ func TestStreamCursor_ConcurrentNext(t *testing.T) {
 		Type:    proto.ResponseSuccessPartial,
 		Results: []json.RawMessage{rawMsg(`"a"`)},
 	}
-	c := NewStream(context.Background(), initial, ch, send)
+	c := NewStream(context.Background(), initial, ch, send, Options{})
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -557,3 +590,400 @@ func TestStreamCursor_ConcurrentNext(t *testing.T) {
 		seen[r] = true
 	}
 }
+
+func TestStreamCursor_Stats(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response, 1)
+	send := func(qt proto.QueryType) error {
+		if qt == proto.QueryContinue {
+			ch <- &response.Response{
+				Type:    proto.ResponseSuccessSequence,
+				Results: []json.RawMessage{rawMsg(`2`)},
+			}
+		}
+		return nil
+	}
+	initial := &response.Response{
+		Type:    proto.ResponseSuccessPartial,
+		Results: []json.RawMessage{rawMsg(`1`)},
+	}
+	c := NewStream(context.Background(), initial, ch, send, Options{})
+	sr, ok := c.(StatsReporter)
+	if !ok {
+		t.Fatal("stream cursor does not implement StatsReporter")
+	}
+	if got := sr.Stats(); got != (Stats{Batches: 1}) {
+		t.Errorf("before fetching: got %+v, want 1 batch, 0 requests", got)
+	}
+	if _, err := c.All(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sr.Stats(); got != (Stats{Batches: 2, Requests: 1}) {
+		t.Errorf("after All: got %+v, want 2 batches, 1 request", got)
+	}
+}
+
+func TestChangefeedCursor_StatsCountsStop(t *testing.T) {
+	t.Parallel()
+	initial := &response.Response{
+		Type:    proto.ResponseSuccessPartial,
+		Results: []json.RawMessage{rawMsg(`1`)},
+	}
+	c := NewChangefeed(context.Background(), initial, make(chan *response.Response), func(proto.QueryType) error { return nil }, Options{})
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sr, ok := c.(StatsReporter)
+	if !ok {
+		t.Fatal("changefeed cursor does not implement StatsReporter")
+	}
+	if got := sr.Stats(); got != (Stats{Batches: 1, Requests: 1}) {
+		t.Errorf("got %+v, want 1 batch, 1 request", got)
+	}
+}
+
+func TestAtomCursor_NoStats(t *testing.T) {
+	t.Parallel()
+	c := NewAtom(&response.Response{Results: []json.RawMessage{rawMsg(`1`)}})
+	if _, ok := c.(StatsReporter); ok {
+		t.Error("atom cursor should not implement StatsReporter")
+	}
+}
+
+func TestEach_DrainsAndStopsOnError(t *testing.T) {
+	t.Parallel()
+	resp := &response.Response{
+		Type:    proto.ResponseSuccessSequence,
+		Results: []json.RawMessage{rawMsg(`1`), rawMsg(`2`), rawMsg(`3`)},
+	}
+	var got []string
+	err := NewSequence(resp).Each(context.Background(), func(row json.RawMessage) error {
+		got = append(got, string(row))
+		return nil
+	})
+	if err != nil || len(got) != 3 || got[2] != "3" {
+		t.Fatalf("Each = %v, rows %v", err, got)
+	}
+
+	errStop := errors.New("stop")
+	c := NewSequence(resp)
+	n := 0
+	err = c.Each(context.Background(), func(json.RawMessage) error {
+		n++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || n != 1 {
+		t.Fatalf("Each = %v after %d rows, want errStop after 1", err, n)
+	}
+	if item, err := c.Next(); err != nil || string(item) != "2" {
+		t.Errorf("Next after stop = %s, %v; want 2", item, err)
+	}
+
+	if err := NewAtom(&response.Response{Type: proto.ResponseSuccessAtom}).Each(context.Background(), func(json.RawMessage) error {
+		t.Error("fn called for an empty atom")
+		return nil
+	}); err != nil {
+		t.Errorf("Each on empty atom = %v", err)
+	}
+}
+
+func TestEach_ContextCancelClosesChangefeed(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response)
+	var mu sync.Mutex
+	stops := 0
+	send := func(qt proto.QueryType) error {
+		if qt == proto.QueryStop {
+			mu.Lock()
+			stops++
+			mu.Unlock()
+		}
+		return nil
+	}
+	initial := &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(`1`)}}
+	c := NewChangefeed(context.Background(), initial, ch, send, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Each(ctx, func(json.RawMessage) error {
+			cancel()
+			return nil
+		})
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Each = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Each did not return after cancel")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if stops != 1 {
+		t.Errorf("sent %d STOP queries, want 1", stops)
+	}
+}
+
+func TestEach_BlockedFetchReturnsOnCancel(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response)
+	initial := &response.Response{Type: proto.ResponseSuccessPartial}
+	c := NewStream(context.Background(), initial, ch, func(proto.QueryType) error { return nil }, Options{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Each(ctx, func(json.RawMessage) error { return nil }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Each = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestChan_RowsThenError(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response, 1)
+	ch <- &response.Response{Type: proto.ResponseRuntimeError, Results: []json.RawMessage{rawMsg(`"boom"`)}}
+	initial := &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(`1`), rawMsg(`2`)}}
+	c := NewStream(context.Background(), initial, ch, func(proto.QueryType) error { return nil }, Options{})
+
+	var rows []string
+	var last error
+	for row := range c.Chan(context.Background()) {
+		if row.Err != nil {
+			last = row.Err
+			continue
+		}
+		rows = append(rows, string(row.Data))
+	}
+	if len(rows) != 2 || rows[1] != "2" {
+		t.Errorf("rows = %v", rows)
+	}
+	var re *response.ReqlRuntimeError
+	if !errors.As(last, &re) {
+		t.Errorf("terminal error = %v, want ReqlRuntimeError", last)
+	}
+}
+
+func TestChan_EndsWithoutErrorRow(t *testing.T) {
+	t.Parallel()
+	resp := &response.Response{Type: proto.ResponseSuccessSequence, Results: []json.RawMessage{rawMsg(`1`)}}
+	n := 0
+	for row := range NewSequence(resp).Chan(context.Background()) {
+		if row.Err != nil {
+			t.Fatalf("unexpected error row: %v", row.Err)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Errorf("received %d rows, want 1", n)
+	}
+}
+
+func TestChan_MultiplexCancel(t *testing.T) {
+	t.Parallel()
+	feed := func(v string) Cursor {
+		ch := make(chan *response.Response)
+		initial := &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(v)}}
+		return NewChangefeed(context.Background(), initial, ch, func(proto.QueryType) error { return nil }, Options{})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a, b := feed(`"a"`).Chan(ctx), feed(`"b"`).Chan(ctx)
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		select {
+		case row := <-a:
+			seen[string(row.Data)] = true
+		case row := <-b:
+			seen[string(row.Data)] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("saw only %v", seen)
+		}
+	}
+	cancel()
+	for _, ch := range []<-chan Row{a, b} {
+		select {
+		case row, ok := <-ch:
+			if ok {
+				t.Errorf("row after cancel: %+v", row)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("channel not closed after cancel")
+		}
+	}
+}
+
+func TestDecodeHelpers(t *testing.T) {
+	t.Parallel()
+	seq := func(rows ...string) Cursor {
+		resp := &response.Response{Type: proto.ResponseSuccessSequence}
+		for _, r := range rows {
+			resp.Results = append(resp.Results, rawMsg(r))
+		}
+		return NewSequence(resp)
+	}
+	type doc struct {
+		ID int       `json:"id"`
+		At time.Time `json:"at"`
+	}
+	rows := []string{
+		`{"id":1,"at":{"$reql_type$":"TIME","epoch_time":1700000000,"timezone":"+00:00"}}`,
+		`{"id":2}`,
+	}
+
+	var docs []doc
+	if err := DecodeAll(seq(rows...), &docs); err != nil {
+		t.Fatalf("DecodeAll: %v", err)
+	}
+	if len(docs) != 2 || docs[0].At.Unix() != 1700000000 || docs[1].ID != 2 || !docs[1].At.IsZero() {
+		t.Errorf("docs = %+v", docs)
+	}
+
+	var one doc
+	if err := DecodeOne(seq(rows...), &one); err != nil || one.ID != 1 {
+		t.Errorf("DecodeOne = %+v, %v", one, err)
+	}
+	if err := DecodeOne(seq(), &one); !errors.Is(err, ErrNoRows) {
+		t.Errorf("DecodeOne on empty cursor = %v, want ErrNoRows", err)
+	}
+
+	c := seq(`"a"`)
+	var s string
+	if err := DecodeNext(c, &s); err != nil || s != "a" {
+		t.Errorf("DecodeNext = %q, %v", s, err)
+	}
+	if err := DecodeNext(c, &s); !errors.Is(err, io.EOF) {
+		t.Errorf("DecodeNext at end = %v, want io.EOF", err)
+	}
+	var ns []int
+	if err := DecodeAll(seq(`1`, `"x"`), &ns); err == nil || len(ns) != 1 {
+		t.Errorf("DecodeAll = %v, %v; want an error after one row", ns, err)
+	}
+}
+
+func TestStreamCursor_PrefetchDepth(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response, 1)
+	var mu sync.Mutex
+	sent := 0
+	next := 2
+	send := func(qt proto.QueryType) error {
+		if qt != proto.QueryContinue {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		sent++
+		typ := proto.ResponseSuccessPartial
+		if next == 4 {
+			typ = proto.ResponseSuccessSequence
+		}
+		ch <- &response.Response{Type: typ, Results: []json.RawMessage{rawMsg(string(rune('0' + next)))}}
+		next++
+		return nil
+	}
+	sentCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return sent
+	}
+	initial := &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(`1`)}}
+	c := NewStream(context.Background(), initial, ch, send, Options{Prefetch: 2})
+
+	if item, err := c.Next(); err != nil || string(item) != "1" {
+		t.Fatalf("Next = %s, %v", item, err)
+	}
+	if got := sentCount(); got != 1 {
+		t.Fatalf("CONTINUE sent %d times after the first row, want 1", got)
+	}
+	// the prefetched batch is picked up and a second one requested, still
+	// without the consumer needing it
+	if item, err := c.Next(); err != nil || string(item) != "2" {
+		t.Fatalf("Next = %s, %v", item, err)
+	}
+	rest, err := c.All()
+	if err != nil || len(rest) != 2 || string(rest[1]) != "4" {
+		t.Fatalf("All = %s, %v", rest, err)
+	}
+	if got := sentCount(); got != 3 {
+		t.Errorf("CONTINUE sent %d times, want 3", got)
+	}
+	if st := c.(StatsReporter).Stats(); st.Batches != 4 { //nolint:forcetypeassert
+		t.Errorf("batches = %d, want 4", st.Batches)
+	}
+}
+
+func TestStreamCursor_PrefetchKeepsRowsBeforeError(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response, 1)
+	errSend := errors.New("send failed")
+	initial := &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(`1`), rawMsg(`2`)}}
+	c := NewStream(context.Background(), initial, ch, func(proto.QueryType) error { return errSend }, Options{Prefetch: 1})
+	all, err := c.All()
+	if !errors.Is(err, errSend) || len(all) != 2 {
+		t.Fatalf("All = %s, %v; want both rows then the send error", all, err)
+	}
+}
+
+func TestStreamCursor_MaxBufferedRowsDelaysContinue(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response, 1)
+	var mu sync.Mutex
+	sent := 0
+	send := func(qt proto.QueryType) error {
+		if qt == proto.QueryContinue {
+			mu.Lock()
+			sent++
+			mu.Unlock()
+			ch <- &response.Response{Type: proto.ResponseSuccessSequence, Results: []json.RawMessage{rawMsg(`4`)}}
+		}
+		return nil
+	}
+	sentCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return sent
+	}
+	initial := &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(`1`), rawMsg(`2`), rawMsg(`3`)}}
+	c := NewStream(context.Background(), initial, ch, send, Options{Prefetch: 3, MaxBufferedRows: 2})
+
+	if _, err := c.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sentCount(); got != 0 {
+		t.Fatalf("CONTINUE sent with 3 rows buffered")
+	}
+	if _, err := c.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sentCount(); got != 1 {
+		t.Fatalf("CONTINUE sent %d times once the buffer dropped below the limit, want 1", got)
+	}
+	rest, err := c.All()
+	if err != nil || len(rest) != 2 || string(rest[1]) != "4" {
+		t.Fatalf("All = %s, %v", rest, err)
+	}
+}
+
+func TestChangefeedCursor_Prefetch(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response, 1)
+	continues := make(chan struct{}, 4)
+	send := func(qt proto.QueryType) error {
+		if qt == proto.QueryContinue {
+			continues <- struct{}{}
+		}
+		return nil
+	}
+	initial := &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(`1`)}}
+	c := NewChangefeed(context.Background(), initial, ch, send, Options{Prefetch: 1})
+	if item, err := c.Next(); err != nil || string(item) != "1" {
+		t.Fatalf("Next = %s, %v", item, err)
+	}
+	if len(continues) != 1 {
+		t.Fatalf("CONTINUE sent %d times while the first change was returned, want 1", len(continues))
+	}
+	ch <- &response.Response{Type: proto.ResponseSuccessSequence}
+	if _, err := c.Next(); err == nil || !strings.Contains(err.Error(), "unexpected response type") {
+		t.Errorf("Next after a SEQUENCE on a feed = %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package cursor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// PeekCursor is a Cursor that can look at its next row without consuming it.
+type PeekCursor interface {
+	Cursor
+	// Peek returns the row the next Next call will return, or its error
+	// (io.EOF after the last row), without consuming it.
+	Peek() (json.RawMessage, error)
+	// HasNext reports whether Next will return a row or an error other
+	// than io.EOF.
+	HasNext() bool
+}
+
+// WithPeek wraps c so its next row can be inspected before it is consumed.
+// It returns c itself when c is already a PeekCursor.
+func WithPeek(c Cursor) PeekCursor {
+	if pc, ok := c.(PeekCursor); ok {
+		return pc
+	}
+	pc := &peekCursor{inner: c}
+	if sr, ok := c.(StatsReporter); ok {
+		return &peekStatsCursor{peekCursor: pc, sr: sr}
+	}
+	return pc
+}
+
+// peekCursor holds at most one row read ahead of its consumer.
+type peekCursor struct {
+	inner Cursor
+
+	mu     sync.Mutex
+	peeked bool // row and err hold the result of the read-ahead Next
+	row    json.RawMessage
+	err    error
+}
+
+// peekStatsCursor is a peekCursor over a cursor that reports Stats.
+type peekStatsCursor struct {
+	*peekCursor
+	sr StatsReporter
+}
+
+func (c *peekStatsCursor) Stats() Stats { return c.sr.Stats() }
+
+func (c *peekCursor) Peek() (json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.peeked {
+		c.row, c.err = c.inner.Next()
+		c.peeked = true
+	}
+	return c.row, c.err
+}
+
+func (c *peekCursor) HasNext() bool {
+	_, err := c.Peek()
+	return !errors.Is(err, io.EOF)
+}
+
+func (c *peekCursor) Next() (json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.peeked {
+		c.peeked = false
+		return c.row, c.err
+	}
+	return c.inner.Next()
+}
+
+func (c *peekCursor) All() ([]json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.peeked {
+		return c.inner.All()
+	}
+	c.peeked = false
+	if errors.Is(c.err, io.EOF) {
+		return nil, nil
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	rest, err := c.inner.All()
+	if err != nil {
+		return nil, err
+	}
+	return append([]json.RawMessage{c.row}, rest...), nil
+}
+
+func (c *peekCursor) Each(ctx context.Context, fn func(json.RawMessage) error) error {
+	return each(ctx, c, fn)
+}
+
+func (c *peekCursor) Chan(ctx context.Context) <-chan Row { return rowChan(ctx, c) }
+
+func (c *peekCursor) Close() error { return c.inner.Close() }
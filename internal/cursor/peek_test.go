@@ -0,0 +1,74 @@
+package cursor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"r-cli/internal/proto"
+	"r-cli/internal/response"
+)
+
+func TestWithPeek(t *testing.T) {
+	t.Parallel()
+	c := WithPeek(NewSequence(&response.Response{Results: []json.RawMessage{rawMsg(`1`), rawMsg(`2`)}}))
+	if WithPeek(c) != c {
+		t.Error("WithPeek wrapped a PeekCursor again")
+	}
+	for range 2 {
+		if row, err := c.Peek(); err != nil || string(row) != "1" {
+			t.Fatalf("Peek = %s, %v; want 1", row, err)
+		}
+	}
+	if row, err := c.Next(); err != nil || string(row) != "1" {
+		t.Fatalf("Next = %s, %v; want 1", row, err)
+	}
+	if !c.HasNext() {
+		t.Fatal("HasNext = false before the last row")
+	}
+	rows, err := c.All()
+	if err != nil || len(rows) != 1 || string(rows[0]) != "2" {
+		t.Fatalf("All = %s, %v", rows, err)
+	}
+	if c.HasNext() {
+		t.Error("HasNext = true after the last row")
+	}
+	if _, err := c.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next at end = %v, want io.EOF", err)
+	}
+}
+
+func TestWithPeekAtom(t *testing.T) {
+	t.Parallel()
+	c := WithPeek(NewAtom(&response.Response{Results: []json.RawMessage{rawMsg(`"x"`)}}))
+	if !c.HasNext() {
+		t.Fatal("HasNext = false for an atom")
+	}
+	var got []string
+	if err := c.Each(context.Background(), func(row json.RawMessage) error {
+		got = append(got, string(row))
+		return nil
+	}); err != nil || len(got) != 1 || got[0] != `"x"` {
+		t.Errorf("Each = %v, %v", got, err)
+	}
+}
+
+func TestWithPeekError(t *testing.T) {
+	t.Parallel()
+	ch := make(chan *response.Response, 1)
+	ch <- &response.Response{Type: proto.ResponseRuntimeError, Results: []json.RawMessage{rawMsg(`"boom"`)}}
+	initial := &response.Response{Type: proto.ResponseSuccessPartial}
+	c := WithPeek(NewStream(context.Background(), initial, ch, func(proto.QueryType) error { return nil }, Options{}))
+	defer func() { _ = c.Close() }()
+	if _, ok := c.(StatsReporter); !ok {
+		t.Error("peeking a stream cursor lost Stats")
+	}
+	if !c.HasNext() {
+		t.Fatal("HasNext = false before an error")
+	}
+	if _, err := c.All(); err == nil {
+		t.Error("All after a peeked error returned nil")
+	}
+}
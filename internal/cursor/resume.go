@@ -0,0 +1,213 @@
+package cursor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"r-cli/internal/proto"
+	"r-cli/internal/response"
+)
+
+// Feed is an open changefeed query: its first response, the channel later
+// batches arrive on and the func sending CONTINUE and STOP for its token.
+type Feed struct {
+	Initial *response.Response
+	Ch      <-chan *response.Response
+	Send    func(proto.QueryType) error
+	// Dropped, if set, reports whether the feed's connection is gone; any
+	// error the feed ends with is then resumable.
+	Dropped func() bool
+}
+
+// Resume makes a changefeed cursor reissue its query after the feed drops.
+type Resume struct {
+	// Reopen runs the changefeed query again; it may return a different
+	// query than the first one, e.g. without include_initial.
+	Reopen func(ctx context.Context) (Feed, error)
+	// MaxAttempts bounds the consecutive failed reopens; 0 means no limit.
+	MaxAttempts int
+	// Backoff is the wait before the first reopen; it doubles up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable reports whether an error ends the feed for good; nil uses
+	// Resumable.
+	Retryable func(error) bool
+	// Notify, if set, is called after every reopen attempt.
+	Notify func(ResumeEvent)
+	// OnState, if set, receives the state of each {"state": ...} row an
+	// include_states feed sends, such as "initializing" and "ready".
+	OnState func(state string)
+}
+
+// ResumeEvent describes one reopen attempt.
+type ResumeEvent struct {
+	Attempt int           // 1-based, counted since the feed last delivered a row
+	Cause   error         // the error that ended the previous feed
+	Err     error         // nil when the attempt succeeded
+	Backoff time.Duration // wait before the next attempt; 0 when giving up or resumed
+}
+
+// Resumable reports whether err may be cured by reissuing the query: a
+// lost connection or an unavailable table (a failover), but not a query
+// error, an idle timeout or a cancelled context.
+func Resumable(err error) bool {
+	if err == nil || errors.Is(err, ErrIdleTimeout) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var ae *response.ReqlAvailabilityError
+	if errors.As(err, &ae) {
+		return true
+	}
+	var (
+		c  *response.ReqlCompileError
+		r  *response.ReqlRuntimeError
+		cl *response.ReqlClientError
+		ne *response.ReqlNonExistenceError
+		pe *response.ReqlPermissionError
+	)
+	return !errors.As(err, &c) && !errors.As(err, &r) && !errors.As(err, &cl) && !errors.As(err, &ne) && !errors.As(err, &pe)
+}
+
+// resumeCursor is a changefeed cursor that reopens itself through Resume.
+type resumeCursor struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	r      Resume
+	opts   Options
+
+	mu       sync.Mutex // serializes Next and guards the fields below
+	cur      *streamCursor
+	dropped  func() bool // Dropped of the current feed
+	err      error
+	attempts int   // failed reopens since the last row
+	prev     Stats // totals of the feeds already replaced
+}
+
+// NewResumable creates a changefeed cursor over feed that reissues the query
+// with r.Reopen when the feed drops.
+func NewResumable(ctx context.Context, feed Feed, r Resume, opts Options) Cursor {
+	ctx2, cancel := context.WithCancel(ctx)
+	if r.Retryable == nil {
+		r.Retryable = Resumable
+	}
+	return &resumeCursor{
+		ctx:     ctx2,
+		cancel:  cancel,
+		r:       r,
+		opts:    opts,
+		cur:     newStream(ctx2, feed.Initial, feed.Ch, feed.Send, opts, true),
+		dropped: feed.Dropped,
+	}
+}
+
+func (c *resumeCursor) Next() (json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if c.err != nil {
+			return nil, c.err
+		}
+		row, err := c.cur.Next()
+		if err == nil {
+			c.attempts = 0
+			c.noteState(row)
+			return row, nil
+		}
+		if errors.Is(err, ErrIdleTimeout) {
+			return nil, err // the feed is still open
+		}
+		if c.ctx.Err() != nil || !c.retryable(err) || c.r.Reopen == nil {
+			c.err = err
+			continue
+		}
+		_ = c.cur.Close()
+		if err := c.reopen(err); err != nil {
+			c.err = err
+		}
+	}
+}
+
+// reopen is called with mu held; it retries r.Reopen with backoff until a
+// feed opens, the error is final or the attempts run out.
+func (c *resumeCursor) reopen(cause error) error {
+	backoff := c.r.Backoff
+	for {
+		select {
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		case <-time.After(backoff):
+		}
+		c.attempts++
+		feed, err := c.r.Reopen(c.ctx)
+		if err == nil {
+			c.notify(ResumeEvent{Attempt: c.attempts, Cause: cause})
+			st := c.cur.Stats()
+			c.prev.Batches += st.Batches
+			c.prev.Requests += st.Requests
+			c.cur = newStream(c.ctx, feed.Initial, feed.Ch, feed.Send, c.opts, true)
+			c.dropped = feed.Dropped
+			return nil
+		}
+		if c.ctx.Err() != nil || !c.r.Retryable(err) || (c.r.MaxAttempts > 0 && c.attempts >= c.r.MaxAttempts) {
+			c.notify(ResumeEvent{Attempt: c.attempts, Cause: cause, Err: err})
+			return fmt.Errorf("cursor: reopen changefeed: %w", err)
+		}
+		backoff = min(max(backoff*2, time.Millisecond), max(c.r.MaxBackoff, c.r.Backoff))
+		c.notify(ResumeEvent{Attempt: c.attempts, Cause: cause, Err: err, Backoff: backoff})
+	}
+}
+
+// retryable reports whether the error ending the current feed allows a reopen.
+func (c *resumeCursor) retryable(err error) bool {
+	return c.r.Retryable(err) || (c.dropped != nil && c.dropped())
+}
+
+func (c *resumeCursor) notify(ev ResumeEvent) {
+	if c.r.Notify != nil {
+		c.r.Notify(ev)
+	}
+}
+
+// noteState passes the state of a {"state": ...} row to r.OnState.
+func (c *resumeCursor) noteState(row json.RawMessage) {
+	if c.r.OnState == nil {
+		return
+	}
+	var doc struct {
+		State *string `json:"state"`
+	}
+	if json.Unmarshal(row, &doc) == nil && doc.State != nil {
+		c.r.OnState(*doc.State)
+	}
+}
+
+func (c *resumeCursor) All() ([]json.RawMessage, error) {
+	return nil, fmt.Errorf("cursor: All() not supported for changefeed; use Next()")
+}
+
+func (c *resumeCursor) Each(ctx context.Context, fn func(json.RawMessage) error) error {
+	return each(ctx, c, fn)
+}
+
+func (c *resumeCursor) Chan(ctx context.Context) <-chan Row { return rowChan(ctx, c) }
+
+// Stats reports the batches received and queries sent over all reopened feeds.
+func (c *resumeCursor) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.cur.Stats()
+	return Stats{Batches: c.prev.Batches + st.Batches, Requests: c.prev.Requests + st.Requests}
+}
+
+// Close stops the current feed; a Next waiting for a batch or a reopen
+// returns the context error.
+func (c *resumeCursor) Close() error {
+	c.cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cur.Close()
+}
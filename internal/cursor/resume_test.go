@@ -0,0 +1,202 @@
+package cursor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"r-cli/internal/proto"
+	"r-cli/internal/response"
+)
+
+// testFeed is a changefeed whose batches are pushed by the test.
+type testFeed struct {
+	ch chan *response.Response
+}
+
+func newTestFeed(rows ...string) (*testFeed, Feed) {
+	f := &testFeed{ch: make(chan *response.Response, 1)}
+	initial := &response.Response{Type: proto.ResponseSuccessPartial}
+	for _, r := range rows {
+		initial.Results = append(initial.Results, rawMsg(r))
+	}
+	return f, Feed{Initial: initial, Ch: f.ch, Send: func(proto.QueryType) error { return nil }}
+}
+
+func TestResumableReopensAfterDrop(t *testing.T) {
+	t.Parallel()
+	first, feed := newTestFeed(`{"state":"ready"}`, `1`)
+	second, reopened := newTestFeed(`{"state":"initializing"}`, `2`)
+	reopens := 0
+	var events []ResumeEvent
+	var states []string
+	c := NewResumable(context.Background(), feed, Resume{
+		Reopen: func(context.Context) (Feed, error) {
+			reopens++
+			if reopens == 1 {
+				return Feed{}, io.ErrUnexpectedEOF
+			}
+			return reopened, nil
+		},
+		Backoff: time.Millisecond,
+		Notify:  func(ev ResumeEvent) { events = append(events, ev) },
+		OnState: func(s string) { states = append(states, s) },
+	}, Options{})
+	defer func() { _ = c.Close() }()
+
+	var got []string
+	for range 2 {
+		row, err := c.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		got = append(got, string(row))
+	}
+	close(first.ch)
+	for range 2 {
+		row, err := c.Next()
+		if err != nil {
+			t.Fatalf("Next after drop: %v", err)
+		}
+		got = append(got, string(row))
+	}
+	if want := fmt.Sprint([]string{`{"state":"ready"}`, `1`, `{"state":"initializing"}`, `2`}); fmt.Sprint(got) != want {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if fmt.Sprint(states) != "[ready initializing]" {
+		t.Errorf("states = %v", states)
+	}
+	if len(events) != 2 || events[0].Err == nil || events[0].Backoff == 0 || events[1].Err != nil || events[1].Attempt != 2 || events[1].Cause == nil {
+		t.Errorf("events = %+v", events)
+	}
+	if st := c.(StatsReporter).Stats(); st.Batches != 2 { //nolint:forcetypeassert
+		t.Errorf("batches = %d, want 2", st.Batches)
+	}
+	second.ch <- &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(`3`)}}
+	if row, err := c.Next(); err != nil || string(row) != "3" {
+		t.Errorf("Next on reopened feed = %s, %v", row, err)
+	}
+}
+
+func TestResumableStopsOnQueryError(t *testing.T) {
+	t.Parallel()
+	f, feed := newTestFeed()
+	c := NewResumable(context.Background(), feed, Resume{
+		Reopen: func(context.Context) (Feed, error) {
+			t.Error("reopened after a query error")
+			return Feed{}, errors.New("unexpected")
+		},
+	}, Options{})
+	f.ch <- &response.Response{Type: proto.ResponseRuntimeError, ErrType: proto.ErrorQueryLogic, Results: []json.RawMessage{rawMsg(`"bad"`)}}
+	_, err := c.Next()
+	var re *response.ReqlRuntimeError
+	if !errors.As(err, &re) {
+		t.Fatalf("Next = %v, want runtime error", err)
+	}
+	if _, again := c.Next(); !errors.Is(again, err) {
+		t.Errorf("second Next = %v, want the same error", again)
+	}
+}
+
+func TestResumableDroppedConnection(t *testing.T) {
+	t.Parallel()
+	f, feed := newTestFeed()
+	feed.Dropped = func() bool { return true }
+	_, reopened := newTestFeed(`1`)
+	c := NewResumable(context.Background(), feed, Resume{
+		Reopen: func(context.Context) (Feed, error) { return reopened, nil },
+	}, Options{})
+	f.ch <- &response.Response{Type: proto.ResponseClientError, Results: []json.RawMessage{rawMsg(`"readLoop: EOF"`)}}
+	if row, err := c.Next(); err != nil || string(row) != "1" {
+		t.Fatalf("Next = %s, %v; want the reopened feed", row, err)
+	}
+}
+
+func TestResumableIdleTimeout(t *testing.T) {
+	t.Parallel()
+	f, feed := newTestFeed()
+	c := NewResumable(context.Background(), feed, Resume{
+		Reopen: func(context.Context) (Feed, error) {
+			t.Error("reopened after an idle timeout")
+			return Feed{}, errors.New("unexpected")
+		},
+	}, Options{IdleTimeout: 10 * time.Millisecond})
+	defer func() { _ = c.Close() }()
+	if _, err := c.Next(); !errors.Is(err, ErrIdleTimeout) {
+		t.Fatalf("Next = %v, want ErrIdleTimeout", err)
+	}
+	f.ch <- &response.Response{Type: proto.ResponseSuccessPartial, Results: []json.RawMessage{rawMsg(`1`)}}
+	if row, err := c.Next(); err != nil || string(row) != "1" {
+		t.Fatalf("Next after idle = %s, %v", row, err)
+	}
+}
+
+func TestResumableGivesUp(t *testing.T) {
+	t.Parallel()
+	f, feed := newTestFeed()
+	close(f.ch)
+	errDown := errors.New("connection refused")
+	var events []ResumeEvent
+	c := NewResumable(context.Background(), feed, Resume{
+		Reopen:      func(context.Context) (Feed, error) { return Feed{}, errDown },
+		MaxAttempts: 3,
+		Notify:      func(ev ResumeEvent) { events = append(events, ev) },
+	}, Options{})
+	if _, err := c.Next(); !errors.Is(err, errDown) {
+		t.Fatalf("Next = %v, want errDown", err)
+	}
+	if len(events) != 3 || events[2].Backoff != 0 || events[2].Attempt != 3 {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestResumableCloseDuringBackoff(t *testing.T) {
+	t.Parallel()
+	f, feed := newTestFeed()
+	c := NewResumable(context.Background(), feed, Resume{
+		Reopen:  func(context.Context) (Feed, error) { return Feed{}, errors.New("down") },
+		Backoff: time.Hour,
+	}, Options{})
+	close(f.ch)
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Next()
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	_ = c.Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Next = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Next still waiting after Close")
+	}
+}
+
+func TestResumable(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{ErrIdleTimeout, false},
+		{io.EOF, true},
+		{fmt.Errorf("cursor: connection closed"), true},
+		{&response.ReqlAvailabilityError{Msg: "table unavailable"}, true},
+		{&response.ReqlRuntimeError{Msg: "bad"}, false},
+		{&response.ReqlPermissionError{Msg: "denied"}, false},
+	}
+	for _, tc := range cases {
+		if got := Resumable(tc.err); got != tc.want {
+			t.Errorf("Resumable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
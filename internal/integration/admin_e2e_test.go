@@ -0,0 +1,203 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"r-cli/internal/reql"
+)
+
+func TestAdminStatusE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "users")
+
+	stdout, stderr, code := cliRun(t, "", cliArgs("admin", "status", "--json")...)
+	if code != 0 {
+		t.Fatalf("admin status: exit code %d, stderr: %s", code, stderr)
+	}
+	var ov struct {
+		Servers []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"servers"`
+		Tables []struct {
+			DB            string `json:"db"`
+			Name          string `json:"name"`
+			Shards        int    `json:"shards"`
+			ReadyReplicas int    `json:"ready_replicas"`
+		} `json:"tables"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &ov); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, stdout)
+	}
+	if len(ov.Servers) == 0 || ov.Servers[0].Version == "" {
+		t.Errorf("expected at least one server with a version, got %+v", ov.Servers)
+	}
+	found := false
+	for _, tbl := range ov.Tables {
+		if tbl.DB == dbName && tbl.Name == "users" {
+			found = tbl.Shards == 1 && tbl.ReadyReplicas == 1
+		}
+	}
+	if !found {
+		t.Errorf("%s.users missing or not ready in %+v", dbName, ov.Tables)
+	}
+
+	stdout, stderr, code = cliRun(t, "", cliArgs("admin", "status")...)
+	if code != 0 {
+		t.Fatalf("admin status: exit code %d, stderr: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "SERVERS") || !strings.Contains(stdout, dbName+".users") {
+		t.Errorf("unexpected text output:\n%s", stdout)
+	}
+}
+
+func TestAdminReconfigureE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "users")
+
+	stdout, stderr, code := cliRun(t, "", cliArgs("-d", dbName, "admin", "reconfigure", "users", "--shards", "2", "--dry-run")...)
+	if code != 0 {
+		t.Fatalf("dry run: exit code %d, stderr: %s", code, stderr)
+	}
+	if !strings.HasPrefix(stdout, "shards: 1 -> 2\n") || !strings.Contains(stdout, "+ shard 2:") {
+		t.Errorf("unexpected plan:\n%s", stdout)
+	}
+
+	stdout, stderr, code = cliRun(t, "", cliArgs("-d", dbName, "admin", "reconfigure", "users", "--shards", "2", "--yes")...)
+	if code != 0 {
+		t.Fatalf("apply: exit code %d, stderr: %s", code, stderr)
+	}
+	if !strings.HasSuffix(stdout, "{\"reconfigured\":1}\n") {
+		t.Errorf("unexpected output:\n%s", stdout)
+	}
+
+	stdout, _, code = cliRun(t, "", cliArgs("-d", dbName, "admin", "reconfigure", "users", "--shards", "2", "--dry-run")...)
+	if code != 0 || stdout != "no changes\n" {
+		t.Errorf("after apply: exit code %d, output %q", code, stdout)
+	}
+}
+
+func TestAdminJobsKillE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "events")
+
+	// an open changefeed is a long-running query job
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, cur, err := qexec.Run(ctx, reql.DB(dbName).Table("events").Changes(), nil)
+	if err != nil {
+		t.Fatalf("start changefeed: %v", err)
+	}
+	defer closeCursor(cur)
+
+	stdout, stderr, code := cliRun(t, "", cliArgs("admin", "jobs", "--json")...)
+	if code != 0 {
+		t.Fatalf("admin jobs: exit code %d, stderr: %s", code, stderr)
+	}
+	var jobs []struct {
+		ID   []string               `json:"id"`
+		Type string                 `json:"type"`
+		Info map[string]interface{} `json:"info"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &jobs); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, stdout)
+	}
+	var feedID string
+	for _, j := range jobs {
+		if q, _ := j.Info["query"].(string); j.Type == "query" && strings.Contains(q, "changes") && strings.Contains(q, dbName) {
+			feedID = j.ID[1]
+		}
+	}
+	if feedID == "" {
+		t.Fatalf("changefeed job not listed: %s", stdout)
+	}
+
+	stdout, stderr, code = cliRun(t, "", cliArgs("admin", "jobs", "kill", feedID)...)
+	if code != 0 {
+		t.Fatalf("kill: exit code %d, stderr: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != `{"deleted":1}` {
+		t.Errorf("kill output: %q", stdout)
+	}
+	if _, err := cur.Next(); err == nil {
+		t.Error("expected the killed changefeed to fail")
+	}
+
+	_, _, code = cliRun(t, "", cliArgs("admin", "jobs", "kill", feedID)...)
+	if code != 2 {
+		t.Errorf("killing a finished job: exit code %d, want 2", code)
+	}
+}
+
+func TestAdminUserE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	username := sanitizeID(t.Name() + "user")
+	setupTestDB(t, qexec, dbName)
+
+	_, stderr, code := cliRun(t, "", cliArgs("admin", "user", "create", username, "--new-password", "pw1")...)
+	if code != 0 {
+		t.Fatalf("admin user create: exit code %d, stderr: %s", code, stderr)
+	}
+	t.Cleanup(func() {
+		cliRun(t, "", cliArgs("admin", "user", "delete", username, "-y")...)
+	})
+	_, stderr, code = cliRun(t, "pw2\n", cliArgs("admin", "user", "passwd", username)...)
+	if code != 0 {
+		t.Fatalf("admin user passwd: exit code %d, stderr: %s", code, stderr)
+	}
+	_, stderr, code = cliRun(t, "", cliArgs("-d", dbName, "admin", "user", "grant", username, "--read", "--config")...)
+	if code != 0 {
+		t.Fatalf("admin user grant: exit code %d, stderr: %s", code, stderr)
+	}
+
+	stdout, stderr, code := cliRun(t, "", cliArgs("admin", "user", "list", "--json")...)
+	if code != 0 {
+		t.Fatalf("admin user list: exit code %d, stderr: %s", code, stderr)
+	}
+	var accounts []struct {
+		User        string `json:"user"`
+		Password    bool   `json:"password"`
+		Permissions []struct {
+			Scope       string          `json:"scope"`
+			Permissions map[string]bool `json:"permissions"`
+		} `json:"permissions"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &accounts); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, stdout)
+	}
+	for _, a := range accounts {
+		if a.User != username {
+			continue
+		}
+		if !a.Password || len(a.Permissions) != 1 || a.Permissions[0].Scope != dbName ||
+			!a.Permissions[0].Permissions["read"] || !a.Permissions[0].Permissions["config"] {
+			t.Errorf("unexpected account: %+v", a)
+		}
+		// the new password works
+		userExec := execAs(t, containerHost, containerPort, username, "pw2")
+		_, cur, err := userExec.Run(context.Background(), reql.DB(dbName).TableList(), nil)
+		closeCursor(cur)
+		if err != nil {
+			t.Errorf("connect with new password: %v", err)
+		}
+		return
+	}
+	t.Errorf("user %s not listed:\n%s", username, stdout)
+}
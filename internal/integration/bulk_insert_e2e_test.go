@@ -90,14 +90,17 @@ func TestBulkInsertE2EConflictReplace(t *testing.T) {
 	}
 
 	// insert with conflict=replace reports replaced, not inserted
-	// the CLI aggregates only inserted+errors, so we check errors==0 and total docs == 2
 	var result struct {
 		Inserted int64 `json:"inserted"`
+		Replaced int64 `json:"replaced"`
 		Errors   int64 `json:"errors"`
 	}
 	if err := json.Unmarshal([]byte(strings.TrimSpace(stdout)), &result); err != nil {
 		t.Fatalf("parse output %q: %v", stdout, err)
 	}
+	if result.Replaced != 2 {
+		t.Errorf("replaced=%d, want 2", result.Replaced)
+	}
 	if result.Errors != 0 {
 		t.Errorf("errors=%d, want 0", result.Errors)
 	}
@@ -122,3 +125,42 @@ func TestBulkInsertE2EConflictReplace(t *testing.T) {
 		t.Errorf("doc 1 val=%d, want 99 (replace did not update)", doc.Val)
 	}
 }
+
+func TestBulkInsertE2EJSONArrayStdin(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	tableName := "bulk_array"
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, tableName)
+
+	// JSON array on stdin, bare table name resolved via --db, nested arrays in docs
+	input := `[{"id":"1","tags":["a","b"]},{"id":"2","tags":[]}]`
+	stdout, stderr, code := cliRun(t, input, cliArgs("-d", dbName, "insert", tableName)...)
+	if code != 0 {
+		t.Fatalf("insert: exit code %d, stderr: %s", code, stderr)
+	}
+	var result struct {
+		Inserted int64 `json:"inserted"`
+		Errors   int64 `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(stdout)), &result); err != nil {
+		t.Fatalf("parse output %q: %v", stdout, err)
+	}
+	if result.Inserted != 2 || result.Errors != 0 {
+		t.Errorf("got inserted=%d errors=%d, want 2 and 0", result.Inserted, result.Errors)
+	}
+
+	_, cur, err := qexec.Run(context.Background(), reql.DB(dbName).Table(tableName).Get("1").GetField("tags").Count(), nil)
+	if err != nil {
+		t.Fatalf("count tags: %v", err)
+	}
+	raw, err := cur.Next()
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("count tags next: %v", err)
+	}
+	if string(raw) != "2" {
+		t.Errorf("tags count=%s, want 2", raw)
+	}
+}
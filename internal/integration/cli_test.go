@@ -250,6 +250,34 @@ func TestCLIQueryFile(t *testing.T) {
 	}
 }
 
+func TestCLIFailOnEmpty(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"r.dbList()", 0},
+		{"r.expr([])", 4},
+		{"r.expr(null)", 4},
+		{"r.expr(false)", 4},
+		{"r.expr(0)", 0},
+		{"r.dbList().filter(function(d) { return d.eq('no-such-db') })", 4},
+	}
+	for _, tc := range tests {
+		_, stderr, code := cliRun(t, "", cliArgs("--fail-on-empty", tc.query)...)
+		if code != tc.code {
+			t.Errorf("%s: exit code %d, want %d (stderr %q)", tc.query, code, tc.code, stderr)
+		}
+		if code == 4 && stderr != "" {
+			t.Errorf("%s: unexpected stderr %q", tc.query, stderr)
+		}
+	}
+	// without the flag an empty result still succeeds
+	if _, _, code := cliRun(t, "", cliArgs("r.expr(null)")...); code != 0 {
+		t.Errorf("without --fail-on-empty: exit code %d, want 0", code)
+	}
+}
+
 func TestCLIDbRoundtrip(t *testing.T) {
 	t.Parallel()
 	dbName := sanitizeID(t.Name())
@@ -295,3 +323,26 @@ func TestCLITableRoundtrip(t *testing.T) {
 		t.Fatalf("table drop exit code %d", code)
 	}
 }
+
+func TestCLINoreply(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "docs")
+	stdout, _, code := cliRun(t, "", cliArgs("--noreply", "-d", dbName, `r.table('docs').insert({id: 1})`)...)
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	if strings.TrimSpace(stdout) != "" {
+		t.Errorf("noreply printed %q, want nothing", stdout)
+	}
+	// NOREPLY_WAIT ran before exit, so the write is visible at once
+	stdout, _, code = cliRun(t, "", cliArgs("-d", dbName, `r.table('docs').count()`)...)
+	if code != 0 {
+		t.Fatalf("count exit code %d", code)
+	}
+	if strings.TrimSpace(stdout) != "1" {
+		t.Errorf("count = %q, want 1", stdout)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build integration
+
+package integration
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompletionE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "users")
+
+	complete := func(args ...string) []string {
+		t.Helper()
+		stdout, stderr, code := cliRun(t, "", append([]string{"__complete"}, cliArgs(args...)...)...)
+		if code != 0 {
+			t.Fatalf("__complete %v: exit code %d, stderr: %s", args, code, stderr)
+		}
+		return strings.Split(strings.TrimSpace(stdout), "\n")
+	}
+
+	if got := complete("--db", ""); !slices.Contains(got, dbName) {
+		t.Errorf("--db candidates %q missing %s", got, dbName)
+	}
+	if got := complete("-d", dbName, "get", ""); !slices.Contains(got, "users") || !slices.Contains(got, dbName+".") {
+		t.Errorf("get candidates %q missing users and %s.", got, dbName)
+	}
+	if got := complete("count", dbName+"."); !slices.Contains(got, dbName+".users") {
+		t.Errorf("count candidates %q missing %s.users", got, dbName)
+	}
+}
@@ -15,10 +15,8 @@ import (
 
 	"r-cli/internal/conn"
 	"r-cli/internal/connmgr"
-	"r-cli/internal/proto"
 	"r-cli/internal/query"
 	"r-cli/internal/reql"
-	"r-cli/internal/response"
 )
 
 // TestConnManager50Concurrent sends 50 queries concurrently through a single
@@ -187,19 +185,7 @@ func sendNoreplyWait(ctx context.Context, mgr *connmgr.ConnManager) error {
 	if err != nil {
 		return fmt.Errorf("get conn: %w", err)
 	}
-	token := c.NextToken()
-	raw, err := c.Send(ctx, token, []byte(`[4]`))
-	if err != nil {
-		return fmt.Errorf("send noreply_wait: %w", err)
-	}
-	resp, err := response.Parse(raw)
-	if err != nil {
-		return fmt.Errorf("parse response: %w", err)
-	}
-	if resp.Type != proto.ResponseWaitComplete {
-		return fmt.Errorf("expected WAIT_COMPLETE (%d), got %d", proto.ResponseWaitComplete, resp.Type)
-	}
-	return nil
+	return c.NoreplyWait(ctx)
 }
 
 // TestNoreplyInsert verifies that inserting with noreply=true returns no cursor
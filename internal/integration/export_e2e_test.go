@@ -0,0 +1,221 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"r-cli/internal/reql"
+)
+
+func TestExportE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "users")
+	createTestTable(t, qexec, dbName, "empty")
+
+	ctx := context.Background()
+	tbl := reql.DB(dbName).Table("users")
+	_, cur, err := qexec.Run(ctx, tbl.Insert(reql.Array(
+		map[string]interface{}{"id": "1", "val": 10},
+		map[string]interface{}{"id": "2", "val": 20},
+	)), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	_, cur, err = qexec.Run(ctx, tbl.IndexCreate("val"), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("index create: %v", err)
+	}
+
+	dir := t.TempDir()
+	stdout, stderr, code := cliRun(t, "", cliArgs("-d", dbName, "export", "--dir", dir)...)
+	if code != 0 {
+		t.Fatalf("export: exit code %d, stderr: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != `{"tables":2,"rows":2}` {
+		t.Errorf("summary: got %q", stdout)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, dbName, "users.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Errorf("users.jsonl: got %d lines, want 2", len(lines))
+	}
+
+	var info struct {
+		Tables []struct {
+			Table      string `json:"table"`
+			PrimaryKey string `json:"primary_key"`
+			Indexes    []struct {
+				Index string `json:"index"`
+			} `json:"indexes"`
+		} `json:"tables"`
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "info.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(raw, &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Tables) != 2 {
+		t.Fatalf("info.json: got %d tables, want 2", len(info.Tables))
+	}
+	// tables are listed in name order: empty, users
+	users := info.Tables[1]
+	if users.Table != "users" || users.PrimaryKey != "id" || len(users.Indexes) != 1 || users.Indexes[0].Index != "val" {
+		t.Errorf("unexpected users entry: %+v", users)
+	}
+}
+
+func TestImportE2ERoundTrip(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	srcDB := sanitizeID(t.Name()) + "_src"
+	dstDB := sanitizeID(t.Name()) + "_dst"
+	setupTestDB(t, qexec, srcDB)
+	t.Cleanup(func() {
+		_, cur, _ := qexec.Run(context.Background(), reql.DBDrop(dstDB), nil)
+		closeCursor(cur)
+	})
+
+	ctx := context.Background()
+	_, cur, err := qexec.Run(ctx, reql.DB(srcDB).TableCreate("items", reql.OptArgs{"primary_key": "sku"}), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("table create: %v", err)
+	}
+	tbl := reql.DB(srcDB).Table("items")
+	_, cur, err = qexec.Run(ctx, tbl.Insert(reql.JSON(`[{"sku":"a","tags":["x","y"]},{"sku":"b","tags":["z"]}]`)), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	_, cur, err = qexec.Run(ctx, tbl.IndexCreate("tags", reql.OptArgs{"multi": true}), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("index create: %v", err)
+	}
+
+	dir := t.TempDir()
+	if _, stderr, code := cliRun(t, "", cliArgs("-d", srcDB, "export", "--dir", dir)...); code != 0 {
+		t.Fatalf("export: exit code %d, stderr: %s", code, stderr)
+	}
+	// point the manifest at a fresh database to exercise table and index creation
+	infoPath := filepath.Join(dir, "info.json")
+	raw, err := os.ReadFile(infoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw = []byte(strings.ReplaceAll(string(raw), `"db": "`+srcDB+`"`, `"db": "`+dstDB+`"`))
+	if err := os.WriteFile(infoPath, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, code := cliRun(t, "", cliArgs("import", "--dir", dir)...)
+	if code != 0 {
+		t.Fatalf("import: exit code %d, stderr: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != `{"tables":1,"inserted":2,"replaced":0,"errors":0}` {
+		t.Errorf("summary: got %q", stdout)
+	}
+
+	out, stderr, code := cliRun(t, "", cliArgs("-f", "raw", "r.db('"+dstDB+"').table('items').getAll('z', {index: 'tags'})('sku')")...)
+	if code != 0 {
+		t.Fatalf("query: exit code %d, stderr: %s", code, stderr)
+	}
+	if strings.TrimSpace(out) != "b" {
+		t.Errorf("multi index lookup: got %q, want b", out)
+	}
+}
+
+func TestDumpRestoreE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "keep")
+	createTestTable(t, qexec, dbName, "skip")
+
+	ctx := context.Background()
+	for _, name := range []string{"keep", "skip"} {
+		_, cur, err := qexec.Run(ctx, reql.DB(dbName).Table(name).Insert(map[string]interface{}{"id": name}), nil)
+		closeCursor(cur)
+		if err != nil {
+			t.Fatalf("insert %s: %v", name, err)
+		}
+	}
+
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if _, stderr, code := cliRun(t, "", cliArgs("-d", dbName, "dump", "-F", archive)...); code != 0 {
+		t.Fatalf("dump: exit code %d, stderr: %s", code, stderr)
+	}
+	for _, name := range []string{"keep", "skip"} {
+		_, cur, err := qexec.Run(ctx, reql.DB(dbName).TableDrop(name), nil)
+		closeCursor(cur)
+		if err != nil {
+			t.Fatalf("drop %s: %v", name, err)
+		}
+	}
+
+	stdout, stderr, code := cliRun(t, "", cliArgs("restore", archive, "--table", dbName+".keep")...)
+	if code != 0 {
+		t.Fatalf("restore: exit code %d, stderr: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != `{"tables":1,"inserted":1,"replaced":0,"errors":0}` {
+		t.Errorf("summary: got %q", stdout)
+	}
+	out, _, code := cliRun(t, "", cliArgs("tables", dbName)...)
+	if code != 0 || strings.TrimSpace(out) != "keep" {
+		t.Errorf("tables after restore: got %q (exit %d), want keep", out, code)
+	}
+}
+
+func TestCopyE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "src")
+
+	ctx := context.Background()
+	src := reql.DB(dbName).Table("src")
+	_, cur, err := qexec.Run(ctx, src.Insert(reql.JSON(`[{"id":1,"tags":["a"]},{"id":2,"tags":["b"]},{"id":3,"tags":["c"]}]`)), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	_, cur, err = qexec.Run(ctx, src.IndexCreate("tags", reql.OptArgs{"multi": true}), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("index create: %v", err)
+	}
+
+	url := func(table string) string {
+		return fmt.Sprintf("rethinkdb://%s:%d/%s.%s", containerHost, containerPort, dbName, table)
+	}
+	stdout, stderr, code := cliRun(t, "", cliArgs("copy", "--from", url("src"), "--to", url("dst"), "--batch-size", "2", "--indexes")...)
+	if code != 0 {
+		t.Fatalf("copy: exit code %d, stderr: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != `{"inserted":3,"replaced":0,"errors":0}` {
+		t.Errorf("summary: got %q", stdout)
+	}
+	out, _, code := cliRun(t, "", cliArgs("indexes", dbName+".dst")...)
+	if code != 0 || strings.TrimSpace(out) != "tags" {
+		t.Errorf("indexes on copy: got %q (exit %d), want tags", out, code)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"r-cli/internal/cursor"
 	"r-cli/internal/reql"
 )
 
@@ -111,33 +112,21 @@ func TestGroupByField(t *testing.T) {
 	}
 	defer closeCursor(cur)
 
-	raw, err := cur.Next()
-	if err != nil {
-		t.Fatalf("cursor next: %v", err)
-	}
-
-	// GROUPED_DATA: {"$reql_type$": "GROUPED_DATA", "data": [["a", [doc1, doc2]], ["b", [doc3, doc4]]]}
-	var gd struct {
-		Data [][2]json.RawMessage `json:"data"`
+	// GROUPED_DATA is returned as one {"group", "reduction"} row per group
+	var groups []struct {
+		Group     string            `json:"group"`
+		Reduction []json.RawMessage `json:"reduction"`
 	}
-	if err := json.Unmarshal(raw, &gd); err != nil {
-		t.Fatalf("unmarshal grouped data: %v", err)
+	if err := cursor.DecodeAll(cur, &groups); err != nil {
+		t.Fatalf("decode groups: %v", err)
 	}
-	if len(gd.Data) != 2 {
-		t.Fatalf("got %d groups, want 2", len(gd.Data))
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
 	}
 
 	groupSizes := make(map[string]int)
-	for _, pair := range gd.Data {
-		var key string
-		if err := json.Unmarshal(pair[0], &key); err != nil {
-			t.Fatalf("unmarshal group key: %v", err)
-		}
-		var docs []json.RawMessage
-		if err := json.Unmarshal(pair[1], &docs); err != nil {
-			t.Fatalf("unmarshal group docs: %v", err)
-		}
-		groupSizes[key] = len(docs)
+	for _, g := range groups {
+		groupSizes[g.Group] = len(g.Reduction)
 	}
 	if groupSizes["a"] != 2 {
 		t.Errorf("group 'a' has %d docs, want 2", groupSizes["a"])
@@ -169,33 +158,20 @@ func TestGroupCount(t *testing.T) {
 	}
 	defer closeCursor(cur)
 
-	raw, err := cur.Next()
-	if err != nil {
-		t.Fatalf("cursor next: %v", err)
-	}
-
-	// GROUPED_DATA: {"$reql_type$": "GROUPED_DATA", "data": [["x", 2], ["y", 2], ["z", 1]]}
-	var gd struct {
-		Data [][2]json.RawMessage `json:"data"`
+	var groups []struct {
+		Group     string  `json:"group"`
+		Reduction float64 `json:"reduction"`
 	}
-	if err := json.Unmarshal(raw, &gd); err != nil {
-		t.Fatalf("unmarshal grouped data: %v", err)
+	if err := cursor.DecodeAll(cur, &groups); err != nil {
+		t.Fatalf("decode groups: %v", err)
 	}
-	if len(gd.Data) != 3 {
-		t.Fatalf("got %d groups, want 3", len(gd.Data))
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(groups))
 	}
 
 	counts := make(map[string]float64)
-	for _, pair := range gd.Data {
-		var key string
-		if err := json.Unmarshal(pair[0], &key); err != nil {
-			t.Fatalf("unmarshal group key: %v", err)
-		}
-		var count float64
-		if err := json.Unmarshal(pair[1], &count); err != nil {
-			t.Fatalf("unmarshal count: %v", err)
-		}
-		counts[key] = count
+	for _, g := range groups {
+		counts[g.Group] = g.Reduction
 	}
 	if counts["x"] != 2 {
 		t.Errorf("type 'x' count=%v, want 2", counts["x"])
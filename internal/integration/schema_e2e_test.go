@@ -0,0 +1,59 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"r-cli/internal/reql"
+)
+
+func TestSchemaExportApplyE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	srcDB := sanitizeID(t.Name()) + "_src"
+	dstDB := sanitizeID(t.Name()) + "_dst"
+	setupTestDB(t, qexec, srcDB)
+	t.Cleanup(func() {
+		_, cur, _ := qexec.Run(context.Background(), reql.DBDrop(dstDB), nil)
+		closeCursor(cur)
+	})
+
+	ctx := context.Background()
+	_, cur, err := qexec.Run(ctx, reql.DB(srcDB).TableCreate("users", reql.OptArgs{"primary_key": "email"}), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("table create: %v", err)
+	}
+	_, cur, err = qexec.Run(ctx, reql.DB(srcDB).Table("users").IndexCreate("age"), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("index create: %v", err)
+	}
+
+	schema, stderr, code := cliRun(t, "", cliArgs("-d", srcDB, "schema", "export")...)
+	if code != 0 {
+		t.Fatalf("schema export: exit code %d, stderr: %s", code, stderr)
+	}
+	if !strings.Contains(schema, "primary_key: email") || !strings.Contains(schema, "name: age") {
+		t.Fatalf("unexpected schema:\n%s", schema)
+	}
+
+	schema = strings.Replace(schema, "name: "+srcDB, "name: "+dstDB, 1)
+	plan, stderr, code := cliRun(t, schema, cliArgs("schema", "apply", "-")...)
+	if code != 0 {
+		t.Fatalf("schema apply: exit code %d, stderr: %s", code, stderr)
+	}
+	for _, want := range []string{"+ database " + dstDB, "+ table " + dstDB + ".users (primary key email)", "+ index " + dstDB + ".users.age"} {
+		if !strings.Contains(plan, want) {
+			t.Errorf("plan missing %q:\n%s", want, plan)
+		}
+	}
+
+	again, _, code := cliRun(t, schema, cliArgs("schema", "apply", "-")...)
+	if code != 0 || strings.TrimSpace(again) != "schema is up to date" {
+		t.Errorf("second apply: got %q (exit %d)", again, code)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"r-cli/internal/reql"
+)
+
+func TestSeedE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "people")
+
+	ref := fmt.Sprintf("%s.people", dbName)
+	tmpl := `{"n":{{.}},"name":"{{name}}","age":{{int 18 80}}}`
+	stdout, stderr, code := cliRun(t, "", cliArgs("seed", ref, "--count", "250", "--batch-size", "100", "--seed", "1", "--template", tmpl)...)
+	if code != 0 {
+		t.Fatalf("seed: exit code %d, stderr: %s", code, stderr)
+	}
+	var result struct {
+		Inserted int64 `json:"inserted"`
+		Errors   int64 `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(stdout)), &result); err != nil {
+		t.Fatalf("parse output %q: %v", stdout, err)
+	}
+	if result.Inserted != 250 || result.Errors != 0 {
+		t.Errorf("got %+v, want 250 inserted", result)
+	}
+
+	term := reql.DB(dbName).Table("people").Filter(reql.Row().GetField("age").Lt(18).Or(reql.Row().GetField("age").Gt(80))).Count()
+	_, cur, err := qexec.Run(context.Background(), term, nil)
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	raw, err := cur.Next()
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("count next: %v", err)
+	}
+	if string(raw) != "0" {
+		t.Errorf("documents with age outside 18..80: %s", raw)
+	}
+}
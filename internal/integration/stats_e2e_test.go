@@ -0,0 +1,41 @@
+//go:build integration
+
+package integration
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStatsE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "users")
+
+	stdout, stderr, code := cliRun(t, "", cliArgs("stats", "--json")...)
+	if code != 0 {
+		t.Fatalf("stats: exit code %d, stderr: %s", code, stderr)
+	}
+	var rows []struct {
+		Kind  string `json:"kind"`
+		DB    string `json:"db"`
+		Table string `json:"table"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &rows); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, stdout)
+	}
+	if len(rows) == 0 || rows[0].Kind != "cluster" {
+		t.Errorf("expected the cluster row first, got %+v", rows)
+	}
+
+	stdout, stderr, code = cliRun(t, "", cliArgs("-d", dbName, "stats", "--table", "users")...)
+	if code != 0 {
+		t.Fatalf("stats --table: exit code %d, stderr: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "table ") || !strings.Contains(stdout, dbName+".users") || strings.Contains(stdout, "cluster") {
+		t.Errorf("unexpected table stats:\n%s", stdout)
+	}
+}
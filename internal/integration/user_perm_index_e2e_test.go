@@ -99,6 +99,14 @@ func TestGrantE2E(t *testing.T) {
 	if code != 0 {
 		t.Errorf("user CLI query after db-level grant: exit code %d, stderr: %s", code, stderr)
 	}
+
+	// same query with the password read from stdin
+	stdinArgs := append([]string{}, userCLIArgs[:len(userCLIArgs)-2]...)
+	stdinArgs = append(stdinArgs, "--password-stdin", expr)
+	_, stderr, code = cliRun(t, "userpass\n", stdinArgs...)
+	if code != 0 {
+		t.Errorf("user CLI query with --password-stdin: exit code %d, stderr: %s", code, stderr)
+	}
 }
 
 func TestIndexE2E(t *testing.T) {
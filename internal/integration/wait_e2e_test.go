@@ -0,0 +1,34 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWaitE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "ready")
+
+	if _, stderr, code := cliRun(t, "", cliArgs("wait")...); code != 0 {
+		t.Fatalf("wait (connection only): exit code %d, stderr: %s", code, stderr)
+	}
+	ref := fmt.Sprintf("%s.ready", dbName)
+	if _, stderr, code := cliRun(t, "", cliArgs("wait", "--table", ref, "--wait-for", "all_replicas_ready")...); code != 0 {
+		t.Fatalf("wait --table: exit code %d, stderr: %s", code, stderr)
+	}
+
+	missing := fmt.Sprintf("%s.missing", dbName)
+	_, stderr, code := cliRun(t, "", cliArgs("--timeout", "2s", "wait", "--table", ref, "--table", missing, "--interval", "200ms")...)
+	if code != 1 {
+		t.Errorf("wait for a missing table: exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr, "not ready after") {
+		t.Errorf("stderr %q does not report the timeout", stderr)
+	}
+}
@@ -0,0 +1,144 @@
+//go:build integration
+
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"r-cli/internal/reql"
+)
+
+func TestWatchE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "events")
+
+	cmd, next := startWatch(t, "-d", dbName, "watch", "events", "--include-states")
+
+	waitWatchReady(t, next)
+	_, cur, err := qexec.Run(context.Background(), reql.DB(dbName).Table("events").Insert(
+		map[string]interface{}{"id": "e1"},
+	), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	change := next()
+	if change["ts"] == nil || change["new_val"] == nil {
+		t.Errorf("expected stamped change, got %v", change)
+	}
+
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	err = cmd.Wait()
+	if ee, ok := err.(*exec.ExitError); !ok || ee.ExitCode() != 130 {
+		t.Errorf("expected exit code 130 after interrupt, got %v", err)
+	}
+}
+
+func TestWatchExecE2E(t *testing.T) {
+	t.Parallel()
+	qexec := newExecutor(t)
+	dbName := sanitizeID(t.Name())
+	setupTestDB(t, qexec, dbName)
+	createTestTable(t, qexec, dbName, "events")
+
+	outFile := filepath.Join(t.TempDir(), "changes.jsonl")
+	cmd, next := startWatch(t, "-d", dbName, "watch", "events", "--include-states",
+		"--exec", "cat >> "+outFile)
+	waitWatchReady(t, next)
+	_, cur, err := qexec.Run(context.Background(), reql.DB(dbName).Table("events").Insert(
+		map[string]interface{}{"id": "e1"},
+	), nil)
+	closeCursor(cur)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	next()
+
+	// the action runs after the line is printed; poll for its output
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		data, _ := os.ReadFile(outFile)
+		if strings.Contains(string(data), `"new_val":{"id":"e1"}`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("exec action output: got %q", data)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	_ = cmd.Process.Signal(os.Interrupt)
+	_ = cmd.Wait()
+}
+
+// waitWatchReady skips to the ready state document so that writes made
+// afterwards are seen by the feed.
+func waitWatchReady(t *testing.T, next func() map[string]json.RawMessage) {
+	t.Helper()
+	for {
+		state := next()
+		if state["ts"] == nil {
+			t.Fatalf("expected stamped state document, got %v", state)
+		}
+		if string(state["state"]) == `"ready"` {
+			return
+		}
+	}
+}
+
+// startWatch starts r-cli with args and returns the process and a function
+// returning its next decoded output line.
+func startWatch(t *testing.T, args ...string) (*exec.Cmd, func() map[string]json.RawMessage) {
+	t.Helper()
+	bin, err := buildCLIBinary()
+	if err != nil {
+		t.Fatalf("build cli: %v", err)
+	}
+	cmd := exec.Command(bin, cliArgs(args...)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+	lines := make(chan map[string]json.RawMessage)
+	go func() {
+		sc := bufio.NewScanner(stdout)
+		for sc.Scan() {
+			var m map[string]json.RawMessage
+			if json.Unmarshal(sc.Bytes(), &m) == nil {
+				lines <- m
+			}
+		}
+		close(lines)
+	}()
+	next := func() map[string]json.RawMessage {
+		t.Helper()
+		select {
+		case m, ok := <-lines:
+			if !ok {
+				t.Fatal("watch exited early")
+			}
+			return m
+		case <-time.After(30 * time.Second):
+			t.Fatal("timed out waiting for watch output")
+		}
+		return nil
+	}
+	return cmd, next
+}
@@ -0,0 +1,131 @@
+package output
+
+import (
+	"io"
+	"os"
+)
+
+// ANSI escape sequences used for JSON highlighting.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiKey    = "\x1b[34;1m"
+	ansiString = "\x1b[32m"
+	ansiNumber = "\x1b[36m"
+	ansiBool   = "\x1b[33m"
+	ansiNull   = "\x1b[90m"
+)
+
+// UseColor resolves a --color mode (auto, always, never) for writer w.
+// In auto mode color is enabled only when w is a terminal and NO_COLOR is unset.
+func UseColor(mode string, w io.Writer) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && isattyFn(f)
+}
+
+// colorWriter highlights JSON text with ANSI colors as it is written.
+// Each Write must contain whole JSON tokens, which holds for the JSON and
+// JSONL formatters since they write complete values per call.
+type colorWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewColorWriter returns a writer that colorizes JSON keys, strings, numbers,
+// booleans and nulls before passing them to w.
+func NewColorWriter(w io.Writer) io.Writer {
+	return &colorWriter{w: w}
+}
+
+func (c *colorWriter) Write(p []byte) (int, error) {
+	c.buf = colorizeJSON(c.buf[:0], p)
+	if _, err := c.w.Write(c.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// colorizeJSON appends a highlighted copy of the JSON text src to dst.
+func colorizeJSON(dst, src []byte) []byte {
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case ch == '"':
+			end := stringEnd(src, i)
+			color := ansiString
+			if nextNonSpace(src, end) == ':' {
+				color = ansiKey
+			}
+			dst = appendColored(dst, color, src[i:end])
+			i = end
+		case ch == '-' || (ch >= '0' && ch <= '9'):
+			end := literalEnd(src, i)
+			dst = appendColored(dst, ansiNumber, src[i:end])
+			i = end
+		case ch == 't' || ch == 'f':
+			end := literalEnd(src, i)
+			dst = appendColored(dst, ansiBool, src[i:end])
+			i = end
+		case ch == 'n':
+			end := literalEnd(src, i)
+			dst = appendColored(dst, ansiNull, src[i:end])
+			i = end
+		default:
+			dst = append(dst, ch)
+			i++
+		}
+	}
+	return dst
+}
+
+func appendColored(dst []byte, color string, tok []byte) []byte {
+	dst = append(dst, color...)
+	dst = append(dst, tok...)
+	return append(dst, ansiReset...)
+}
+
+// stringEnd returns the index just past the closing quote of the string starting at i.
+func stringEnd(src []byte, i int) int {
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(src)
+}
+
+// literalEnd returns the index just past a number or keyword starting at i.
+func literalEnd(src []byte, i int) int {
+	j := i
+	for j < len(src) {
+		switch src[j] {
+		case ',', ']', '}', ':', ' ', '\t', '\n', '\r':
+			return j
+		}
+		j++
+	}
+	return j
+}
+
+// nextNonSpace returns the first non-whitespace byte at or after i, or 0.
+func nextNonSpace(src []byte, i int) byte {
+	for ; i < len(src); i++ {
+		switch src[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		return src[i]
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestColorizeJSON(t *testing.T) {
+	t.Parallel()
+	in := `{"name": "a:b", "n": -1.5e3, "ok": true, "x": null, "esc": "q\"}"}`
+	got := string(colorizeJSON(nil, []byte(in)))
+	want := "{" + ansiKey + `"name"` + ansiReset + ": " + ansiString + `"a:b"` + ansiReset +
+		", " + ansiKey + `"n"` + ansiReset + ": " + ansiNumber + "-1.5e3" + ansiReset +
+		", " + ansiKey + `"ok"` + ansiReset + ": " + ansiBool + "true" + ansiReset +
+		", " + ansiKey + `"x"` + ansiReset + ": " + ansiNull + "null" + ansiReset +
+		", " + ansiKey + `"esc"` + ansiReset + ": " + ansiString + `"q\"}"` + ansiReset + "}"
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestColorizeJSONArray(t *testing.T) {
+	t.Parallel()
+	got := string(colorizeJSON(nil, []byte(`[1,"a",false]`)))
+	want := "[" + ansiNumber + "1" + ansiReset + "," + ansiString + `"a"` + ansiReset + "," + ansiBool + "false" + ansiReset + "]"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestColorWriterWithJSONFormatter(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := JSON(NewColorWriter(&buf), newIter(`{"a":1}`, `{"b":"x"}`)); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{ansiKey + `"a"`, ansiNumber + "1", ansiString + `"x"`} {
+		if !bytes.Contains([]byte(got), []byte(want)) {
+			t.Errorf("output missing %q: %q", want, got)
+		}
+	}
+}
+
+func TestColorWriterReportsInputLength(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	n, err := fmt.Fprint(NewColorWriter(&buf), `"abc"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Errorf("got n=%d, want 5", n)
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	orig := isattyFn
+	defer func() { isattyFn = orig }()
+	isattyFn = func(*os.File) bool { return true }
+	t.Setenv("NO_COLOR", "")
+
+	if !UseColor("always", &bytes.Buffer{}) {
+		t.Error("always: expected true")
+	}
+	if UseColor("never", os.Stdout) {
+		t.Error("never: expected false")
+	}
+	if !UseColor("auto", os.Stdout) {
+		t.Error("auto on TTY: expected true")
+	}
+	if UseColor("auto", &bytes.Buffer{}) {
+		t.Error("auto on non-file writer: expected false")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if UseColor("auto", os.Stdout) {
+		t.Error("auto with NO_COLOR: expected false")
+	}
+	isattyFn = func(*os.File) bool { return false }
+	t.Setenv("NO_COLOR", "")
+	if UseColor("auto", os.Stdout) {
+		t.Error("auto on non-TTY: expected false")
+	}
+}
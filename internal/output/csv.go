@@ -0,0 +1,21 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// CSV formats results as RFC 4180 comma-separated values with a header row.
+// Rows are flattened exactly as for TSV; values containing commas, quotes or
+// newlines are quoted instead of escaped.
+func CSV(w io.Writer, iter RowIterator) error {
+	cw := csv.NewWriter(w)
+	err := writeRecords(iter, func(rec []string) error {
+		return cw.Write(rec)
+	})
+	cw.Flush()
+	if err != nil {
+		return err
+	}
+	return cw.Error()
+}
@@ -0,0 +1,58 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCSV_HeaderAndRows(t *testing.T) {
+	t.Parallel()
+	iter := newIter(
+		`{"name":"alice","address":{"city":"NYC"}}`,
+		`{"name":"bob","address":{"city":"LA"}}`,
+	)
+	var buf bytes.Buffer
+	if err := CSV(&buf, iter); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,address.city\nalice,NYC\nbob,LA\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestCSV_Quoting(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`{"text":"a,b","quote":"say \"hi\"","tags":["x","y"]}`)
+	var buf bytes.Buffer
+	if err := CSV(&buf, iter); err != nil {
+		t.Fatal(err)
+	}
+	want := "text,quote,tags\n\"a,b\",\"say \"\"hi\"\"\",\"[\"\"x\"\",\"\"y\"\"]\"\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestCSV_NonObjectRows(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`"users"`, `42`)
+	var buf bytes.Buffer
+	if err := CSV(&buf, iter); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "users\n42\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestCSV_Empty(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := CSV(&buf, newIter()); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected empty output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,125 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// flatField is a single leaf of a flattened JSON object.
+type flatField struct {
+	key   string
+	value json.RawMessage
+}
+
+// flattenObject flattens a JSON object into dot-delimited leaf fields in document order.
+// Nested objects are expanded (address.city); arrays and scalars are kept as raw JSON.
+// Returns an error if data is not a JSON object.
+func flattenObject(data json.RawMessage) ([]flatField, error) {
+	return flattenToDepth(data, 0)
+}
+
+// flattenToDepth is flattenObject that stops after depth levels of keys:
+// with depth 2 address.city is a field, but address.geo.lat is kept in the
+// raw JSON of address.geo. 0 expands every level.
+func flattenToDepth(data json.RawMessage, depth int) ([]flatField, error) {
+	var fields []flatField
+	if err := flattenInto(&fields, "", data, depth-1); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// flattenInto appends the fields of the object data, each key after
+// prefix; objects below are expanded while levels, the number of levels
+// left, is not 0 (negative is unlimited).
+func flattenInto(fields *[]flatField, prefix string, data json.RawMessage, levels int) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("not an object")
+	}
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected token type")
+		}
+		var val json.RawMessage
+		if err := dec.Decode(&val); err != nil {
+			return err
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if levels != 0 && isNonEmptyObject(val) {
+			if err := flattenInto(fields, key, val, levels-1); err != nil {
+				return err
+			}
+			continue
+		}
+		*fields = append(*fields, flatField{key: key, value: val})
+	}
+	return nil
+}
+
+// isNonEmptyObject reports whether raw is a JSON object with at least one key.
+func isNonEmptyObject(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return false
+	}
+	rest := bytes.TrimSpace(trimmed[1:])
+	return len(rest) > 0 && rest[0] != '}'
+}
+
+// flatRow returns the object row with its nested objects flattened to
+// depth levels of dot-delimited keys, as an object of the same key order;
+// other rows are returned as is.
+func flatRow(row json.RawMessage, depth int) json.RawMessage {
+	fields, err := flattenToDepth(row, depth)
+	if err != nil {
+		return row
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if json.Compact(&buf, f.value) != nil {
+			buf.Write(f.value)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// compactValue renders a raw JSON value for a flat cell: strings unquoted,
+// null as empty, everything else as compact JSON.
+func compactValue(raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, raw) != nil {
+		return string(raw)
+	}
+	if buf.String() == "null" {
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFlattenObject(t *testing.T) {
+	t.Parallel()
+	input := `{"id":1,"address":{"city":"NYC","geo":{"lat":1.5}},"tags":["a","b"],"empty":{},"n":null}`
+	fields, err := flattenObject(json.RawMessage(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ key, value string }{
+		{"id", "1"},
+		{"address.city", `"NYC"`},
+		{"address.geo.lat", "1.5"},
+		{"tags", `["a","b"]`},
+		{"empty", "{}"},
+		{"n", "null"},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("got %d fields, want %d: %v", len(fields), len(want), fields)
+	}
+	for i, w := range want {
+		if fields[i].key != w.key || string(fields[i].value) != w.value {
+			t.Errorf("field %d: got %s=%s, want %s=%s", i, fields[i].key, fields[i].value, w.key, w.value)
+		}
+	}
+}
+
+func TestFlattenObjectNotObject(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{`"str"`, `42`, `[1,2]`, `null`} {
+		if _, err := flattenObject(json.RawMessage(input)); err == nil {
+			t.Errorf("flattenObject(%s): expected error, got nil", input)
+		}
+	}
+}
+
+func TestCompactValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`"hello"`, "hello"},
+		{`42`, "42"},
+		{`true`, "true"},
+		{`null`, ""},
+		{`[1, 2, 3]`, "[1,2,3]"},
+		{`{ "a" : 1 }`, `{"a":1}`},
+	}
+	for _, tc := range tests {
+		if got := compactValue(json.RawMessage(tc.in)); got != tc.want {
+			t.Errorf("compactValue(%s): got %q, want %q", tc.in, got, tc.want)
+		}
+	}
+	if got := compactValue(nil); got != "" {
+		t.Errorf("compactValue(nil): got %q, want empty", got)
+	}
+}
@@ -9,10 +9,23 @@ import (
 )
 
 // JSON formats results as pretty-printed JSON.
-// A single row is printed directly; multiple rows are wrapped in an array.
+// A single row is printed directly; multiple rows are wrapped in an array,
+// streamed row by row once the second row has been read.
 // Empty results print as [].
 func JSON(w io.Writer, iter RowIterator) error {
-	first, err := iter.Next()
+	return jsonWriter(w, iter, "  ")
+}
+
+// JSONCompact formats results like JSON but without indentation: a single row
+// is printed as one compact line; multiple rows are wrapped in an array with
+// one compact element per line.
+func JSONCompact(w io.Writer, iter RowIterator) error {
+	return jsonWriter(w, iter, "")
+}
+
+func jsonWriter(w io.Writer, iter RowIterator, indent string) error {
+	p := peekable(iter)
+	first, err := p.Next()
 	if errors.Is(err, io.EOF) {
 		_, err = fmt.Fprintln(w, "[]")
 		return err
@@ -20,64 +33,56 @@ func JSON(w io.Writer, iter RowIterator) error {
 	if err != nil {
 		return err
 	}
-
-	second, err := iter.Next()
-	if errors.Is(err, io.EOF) {
-		return writeIndented(w, first)
-	}
-	if err != nil {
+	if _, err := p.Peek(); errors.Is(err, io.EOF) {
+		return writeIndented(w, first, "", indent)
+	} else if err != nil {
 		return err
 	}
-
-	return writeJSONArray(w, first, second, iter)
+	return writeJSONArray(w, first, p, indent)
 }
 
-func writeIndented(w io.Writer, data json.RawMessage) error {
+// formatJSON indents data with the given prefix and indent, or compacts it
+// when indent is empty. Invalid JSON is returned unchanged.
+func formatJSON(data json.RawMessage, prefix, indent string) string {
 	var buf bytes.Buffer
-	if err := json.Indent(&buf, data, "", "  "); err != nil {
-		buf.Reset()
-		_, _ = buf.Write(data)
+	var err error
+	if indent == "" {
+		err = json.Compact(&buf, data)
+	} else {
+		err = json.Indent(&buf, data, prefix, indent)
+	}
+	if err != nil {
+		return string(data)
 	}
-	_, err := fmt.Fprintln(w, buf.String())
+	return buf.String()
+}
+
+func writeIndented(w io.Writer, data json.RawMessage, prefix, indent string) error {
+	_, err := fmt.Fprintln(w, formatJSON(data, prefix, indent))
 	return err
 }
 
-func writeJSONArray(w io.Writer, first, second json.RawMessage, iter RowIterator) error {
-	if _, err := fmt.Fprintln(w, "["); err != nil {
+// writeJSONArray prints first and the remaining rows of iter as an array.
+// Each row is written as soon as it is read: the comma that separates it
+// from the previous row starts its line, so no row waits for the next one
+// and memory use does not grow with the result.
+func writeJSONArray(w io.Writer, first json.RawMessage, iter RowIterator, indent string) error {
+	if _, err := fmt.Fprintf(w, "[\n%s%s", indent, formatJSON(first, indent, indent)); err != nil {
 		return err
 	}
-
-	cur := first
-	peek := second
-	var peekErr error
-
 	for {
-		var buf bytes.Buffer
-		if err := json.Indent(&buf, cur, "  ", "  "); err != nil {
-			buf.Reset()
-			_, _ = buf.Write(cur)
-		}
-
-		suffix := ""
-		if peekErr == nil {
-			suffix = ","
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
 		}
-
-		if _, err := fmt.Fprintf(w, "  %s%s\n", buf.String(), suffix); err != nil {
+		if err != nil {
+			_, _ = fmt.Fprintln(w)
 			return err
 		}
-
-		if errors.Is(peekErr, io.EOF) {
-			break
-		}
-		if peekErr != nil {
-			return peekErr
+		if _, err := fmt.Fprintf(w, ",\n%s%s", indent, formatJSON(row, indent, indent)); err != nil {
+			return err
 		}
-
-		cur = peek
-		peek, peekErr = iter.Next()
 	}
-
-	_, err := fmt.Fprintln(w, "]")
+	_, err := fmt.Fprintln(w, "\n]")
 	return err
 }
@@ -124,3 +124,84 @@ func TestJSON_InvalidJSONFallback(t *testing.T) {
 		t.Errorf("expected raw fallback for invalid JSON, got: %q", got)
 	}
 }
+
+func TestJSONCompact_SingleDocument(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := JSONCompact(&buf, newIter(`{ "a" : 1, "b": [1, 2] }`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "{\"a\":1,\"b\":[1,2]}\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestJSONCompact_ArrayOfDocuments(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := JSONCompact(&buf, newIter(`{"a": 1}`, `{"b": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+	want := "[\n{\"a\":1},\n{\"b\":2}\n]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	var arr []interface{}
+	if err := json.Unmarshal(buf.Bytes(), &arr); err != nil {
+		t.Errorf("output is not valid JSON: %v", err)
+	}
+}
+
+func TestJSONCompact_Empty(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := JSONCompact(&buf, newIter()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Errorf("got %q, want []", got)
+	}
+}
+
+// streamCheckIter fails the test when a row is requested before the rows
+// already returned have been written to out.
+type streamCheckIter struct {
+	t    *testing.T
+	out  *bytes.Buffer
+	rows []string
+	pos  int
+}
+
+func (s *streamCheckIter) Next() (json.RawMessage, error) {
+	// the first two rows are read before anything is printed
+	if s.pos > 2 && !strings.Contains(s.out.String(), s.rows[s.pos-1]) {
+		s.t.Errorf("row %d requested before row %d was written", s.pos, s.pos-1)
+	}
+	if s.pos == len(s.rows) {
+		return nil, io.EOF
+	}
+	s.pos++
+	return json.RawMessage(s.rows[s.pos-1]), nil
+}
+
+func TestJSON_StreamsRows(t *testing.T) {
+	t.Parallel()
+	for _, format := range []func(io.Writer, RowIterator) error{JSON, JSONCompact} {
+		var buf bytes.Buffer
+		iter := &streamCheckIter{t: t, out: &buf, rows: []string{`1`, `2`, `3`, `4`}}
+		if err := format(&buf, iter); err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil || len(got) != 4 {
+			t.Errorf("output %q: %v", buf.String(), err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := JSON(&buf, newIter(`{"a":1}`, `2`)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[\n  {\n    \"a\": 1\n  },\n  2\n]\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
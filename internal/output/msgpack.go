@@ -0,0 +1,268 @@
+package output
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// MessagePack extension types used for RethinkDB pseudo-types.
+const (
+	msgpackTimestamp = -1 // the predefined timestamp extension
+	msgpackGeometry  = 1  // the GeoJSON object, MessagePack-encoded
+)
+
+// MsgPack writes each row as one MessagePack value, back to back. Objects
+// keep their key order; integers use the smallest integer encoding and
+// other numbers float 64. Rows are expected with their pseudo-types in
+// wire form: TIME becomes the timestamp extension (type -1, millisecond
+// precision; the timezone is dropped), BINARY a bin value, and GEOMETRY
+// extension type 1 holding the GeoJSON object without its $reql_type$.
+func MsgPack(w io.Writer, iter RowIterator) error {
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		buf, err := appendMsgPack(nil, row)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+}
+
+// appendMsgPack appends the MessagePack encoding of the JSON value raw.
+func appendMsgPack(buf []byte, raw json.RawMessage) ([]byte, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("msgpack: empty value")
+	}
+	switch raw[0] {
+	case 'n':
+		return append(buf, 0xc0), nil
+	case 't':
+		return append(buf, 0xc3), nil
+	case 'f':
+		return append(buf, 0xc2), nil
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("msgpack: %w", err)
+		}
+		return appendMsgPackString(buf, s), nil
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("msgpack: %w", err)
+		}
+		buf = appendMsgPackHeader(buf, len(items), 0x90, 0xdc)
+		for _, item := range items {
+			var err error
+			if buf, err = appendMsgPack(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case '{':
+		return appendMsgPackObject(buf, raw)
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return appendMsgPackNumber(buf, n)
+}
+
+// appendMsgPackObject appends an object as a map in key order, or as the
+// extension or bin value of its pseudo-type.
+func appendMsgPackObject(buf []byte, raw json.RawMessage) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	var keys []string
+	var values []json.RawMessage
+	pseudo := ""
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("msgpack: %w", err)
+		}
+		key, _ := tok.(string)
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("msgpack: %w", err)
+		}
+		if key == "$reql_type$" {
+			_ = json.Unmarshal(v, &pseudo)
+		}
+		keys = append(keys, key)
+		values = append(values, v)
+	}
+	field := func(name string) json.RawMessage {
+		for i, k := range keys {
+			if k == name {
+				return values[i]
+			}
+		}
+		return nil
+	}
+	switch pseudo {
+	case "TIME":
+		var epoch float64
+		if json.Unmarshal(field("epoch_time"), &epoch) == nil {
+			return appendMsgPackTime(buf, epoch), nil
+		}
+	case "BINARY":
+		var data string
+		if json.Unmarshal(field("data"), &data) == nil {
+			if b, err := base64.StdEncoding.DecodeString(data); err == nil {
+				return append(appendMsgPackHeader8(buf, len(b), 0xc4, 0xc5, 0xc6), b...), nil
+			}
+		}
+	}
+	var body []byte
+	count := 0
+	for i, k := range keys {
+		if pseudo == "GEOMETRY" && k == "$reql_type$" {
+			continue
+		}
+		body = appendMsgPackString(body, k)
+		var err error
+		if body, err = appendMsgPack(body, values[i]); err != nil {
+			return nil, err
+		}
+		count++
+	}
+	if pseudo == "GEOMETRY" {
+		body = append(appendMsgPackHeader(nil, count, 0x80, 0xde), body...)
+		return appendMsgPackExt(buf, msgpackGeometry, body), nil
+	}
+	buf = appendMsgPackHeader(buf, count, 0x80, 0xde)
+	return append(buf, body...), nil
+}
+
+// appendMsgPackNumber appends n as the smallest integer that holds it, or
+// as a float 64.
+func appendMsgPackNumber(buf []byte, n json.Number) ([]byte, error) {
+	if i, err := n.Int64(); err == nil {
+		return appendMsgPackInt(buf, i), nil
+	}
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), u), nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(f)), nil
+}
+
+func appendMsgPackInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i < 128:
+		return append(buf, byte(i))
+	case i >= -32 && i < 0:
+		return append(buf, byte(i)) //nolint:gosec // negative fixint is the two's complement byte
+	case i >= 0 && i <= math.MaxUint8:
+		return append(buf, 0xcc, byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(i))
+	case i >= 0:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), uint64(i))
+	case i >= math.MinInt8:
+		return append(buf, 0xd0, byte(i)) //nolint:gosec // two's complement
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(i)) //nolint:gosec
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(i)) //nolint:gosec
+	}
+	return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(i)) //nolint:gosec
+}
+
+func appendMsgPackString(buf []byte, s string) []byte {
+	if len(s) < 32 {
+		buf = append(buf, 0xa0|byte(len(s)))
+	} else {
+		buf = appendMsgPackHeader8(buf, len(s), 0xd9, 0xda, 0xdb)
+	}
+	return append(buf, s...)
+}
+
+// appendMsgPackHeader appends the header of an array or map of n entries:
+// fix, the fixarray or fixmap prefix, for up to 15, else the 16-bit code
+// or the 32-bit one that follows it.
+func appendMsgPackHeader(buf []byte, n int, fix, code16 byte) []byte {
+	switch {
+	case n < 16:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, code16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(buf, code16+1), uint32(n)) //nolint:gosec // rows are far below 4 GiB
+}
+
+// appendMsgPackHeader8 appends the header of a str, bin or ext value of n
+// bytes with an 8, 16 or 32-bit length.
+func appendMsgPackHeader8(buf []byte, n int, code8, code16, code32 byte) []byte {
+	switch {
+	case n <= math.MaxUint8:
+		return append(buf, code8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, code16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(buf, code32), uint32(n)) //nolint:gosec // rows are far below 4 GiB
+}
+
+// appendMsgPackExt appends an extension value of type typ.
+func appendMsgPackExt(buf []byte, typ int8, data []byte) []byte {
+	switch len(data) {
+	case 1:
+		buf = append(buf, 0xd4)
+	case 2:
+		buf = append(buf, 0xd5)
+	case 4:
+		buf = append(buf, 0xd6)
+	case 8:
+		buf = append(buf, 0xd7)
+	case 16:
+		buf = append(buf, 0xd8)
+	default:
+		buf = appendMsgPackHeader8(buf, len(data), 0xc7, 0xc8, 0xc9)
+	}
+	buf = append(buf, byte(typ)) //nolint:gosec // two's complement
+	return append(buf, data...)
+}
+
+// appendMsgPackTime appends epoch, seconds since the Unix epoch, as a
+// timestamp extension rounded to the millisecond: 32-bit when it is whole
+// seconds in range, else 64-bit, else 96-bit.
+func appendMsgPackTime(buf []byte, epoch float64) []byte {
+	ms := int64(math.Round(epoch * 1000))
+	sec, nsec := ms/1000, (ms%1000)*1e6
+	if nsec < 0 {
+		sec, nsec = sec-1, nsec+1e9
+	}
+	switch {
+	case nsec == 0 && sec >= 0 && sec <= math.MaxUint32:
+		return appendMsgPackExt(buf, msgpackTimestamp, binary.BigEndian.AppendUint32(nil, uint32(sec)))
+	case sec >= 0 && sec < 1<<34:
+		return appendMsgPackExt(buf, msgpackTimestamp, binary.BigEndian.AppendUint64(nil, uint64(nsec)<<34|uint64(sec)))
+	}
+	data := binary.BigEndian.AppendUint32(nil, uint32(nsec))
+	return appendMsgPackExt(buf, msgpackTimestamp, binary.BigEndian.AppendUint64(data, uint64(sec))) //nolint:gosec // two's complement
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMsgPack_Values(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		json string
+		want string // hex
+	}{
+		{"object in key order", `{"b":[true,null],"a":1,"s":"hi"}`, "83a16292c3c0a16101a173a26869"},
+		{"false", `false`, "c2"},
+		{"negative fixint", `-1`, "ff"},
+		{"int8", `-33`, "d0df"},
+		{"int16", `-129`, "d1ff7f"},
+		{"uint8", `200`, "ccc8"},
+		{"uint32", `70000`, "ce00011170"},
+		{"uint64", `18446744073709551615`, "cfffffffffffffffff"},
+		{"float", `1.5`, "cb3ff8000000000000"},
+		{"str8", `"` + strings.Repeat("x", 32) + `"`, "d920" + strings.Repeat("78", 32)},
+		{"time whole seconds", `{"$reql_type$":"TIME","epoch_time":1,"timezone":"+00:00"}`, "d6ff00000001"},
+		{"time with ms", `{"$reql_type$":"TIME","epoch_time":1.5,"timezone":"+02:00"}`, "d7ff7735940000000001"},
+		{"time before 1970", `{"$reql_type$":"TIME","epoch_time":-1.5,"timezone":"+00:00"}`, "c70cff1dcd6500fffffffffffffffe"},
+		{"binary", `{"$reql_type$":"BINARY","data":"aGk="}`, "c4026869"},
+		{
+			"geometry", `{"$reql_type$":"GEOMETRY","type":"Point","coordinates":[1,2]}`,
+			"c71b01" + "82a474797065a5506f696e74ab636f6f7264696e61746573920102",
+		},
+		{"unknown pseudo-type", `{"$reql_type$":"X"}`, "81ab247265716c5f7479706524a158"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			if err := MsgPack(&buf, newIter(tt.json)); err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgPack_Stream(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := MsgPack(&buf, newIter(`1`, `"a"`, `[]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(buf.Bytes()); got != "01a16190" {
+		t.Errorf("got %s, want rows back to back", got)
+	}
+}
+
+func TestMsgPack_Error(t *testing.T) {
+	t.Parallel()
+	errStream := errors.New("stream broken")
+	iter := &mockIter{items: []json.RawMessage{json.RawMessage(`1`)}, err: errStream}
+	var buf bytes.Buffer
+	if err := MsgPack(&buf, iter); !errors.Is(err, errStream) {
+		t.Errorf("err = %v, want %v", err, errStream)
+	}
+	if buf.Len() != 1 {
+		t.Errorf("rows before the error: wrote %d bytes, want 1", buf.Len())
+	}
+}
@@ -1,8 +1,61 @@
 package output
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
 
 // RowIterator streams rows from a query result.
 type RowIterator interface {
 	Next() (json.RawMessage, error)
 }
+
+// Peeker is a RowIterator that can look at its next row without consuming
+// it, such as a cursor.PeekCursor. Formatters use it to choose a layout from
+// the first rows before printing them.
+type Peeker interface {
+	RowIterator
+	// Peek returns what the next Next call will return.
+	Peek() (json.RawMessage, error)
+	// HasNext reports whether Next will return a row or an error other
+	// than io.EOF.
+	HasNext() bool
+}
+
+// peekable returns iter itself if it is a Peeker, otherwise a Peeker over it.
+func peekable(iter RowIterator) Peeker {
+	if p, ok := iter.(Peeker); ok {
+		return p
+	}
+	return &rowPeeker{iter: iter}
+}
+
+// rowPeeker holds at most one row read ahead of its consumer.
+type rowPeeker struct {
+	iter   RowIterator
+	peeked bool
+	row    json.RawMessage
+	err    error
+}
+
+func (p *rowPeeker) Peek() (json.RawMessage, error) {
+	if !p.peeked {
+		p.row, p.err = p.iter.Next()
+		p.peeked = true
+	}
+	return p.row, p.err
+}
+
+func (p *rowPeeker) HasNext() bool {
+	_, err := p.Peek()
+	return !errors.Is(err, io.EOF)
+}
+
+func (p *rowPeeker) Next() (json.RawMessage, error) {
+	if p.peeked {
+		p.peeked = false
+		return p.row, p.err
+	}
+	return p.iter.Next()
+}
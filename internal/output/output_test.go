@@ -0,0 +1,46 @@
+package output
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestPeekable(t *testing.T) {
+	t.Parallel()
+	p := peekable(newIter(`1`, `2`))
+	if peekable(p) != p {
+		t.Error("peekable wrapped a Peeker again")
+	}
+	for range 2 {
+		if row, err := p.Peek(); err != nil || string(row) != "1" {
+			t.Fatalf("Peek = %s, %v; want 1", row, err)
+		}
+	}
+	for _, want := range []string{"1", "2"} {
+		if !p.HasNext() {
+			t.Fatalf("HasNext = false before %s", want)
+		}
+		if row, err := p.Next(); err != nil || string(row) != want {
+			t.Fatalf("Next = %s, %v; want %s", row, err, want)
+		}
+	}
+	if p.HasNext() {
+		t.Error("HasNext = true after the last row")
+	}
+	if _, err := p.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next at end = %v, want io.EOF", err)
+	}
+}
+
+func TestPeekableError(t *testing.T) {
+	t.Parallel()
+	errBoom := errors.New("boom")
+	p := peekable(&mockIter{err: errBoom})
+	if !p.HasNext() {
+		t.Fatal("HasNext = false before an error")
+	}
+	if _, err := p.Next(); !errors.Is(err, errBoom) {
+		t.Errorf("Next = %v, want errBoom", err)
+	}
+}
@@ -0,0 +1,407 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	parquetSampleRows = 1000  // rows the column types are inferred from
+	parquetGroupRows  = 10000 // rows per row group
+)
+
+// Parquet physical types, and the values of the other enums used.
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional     = 1 // FieldRepetitionType
+	parquetUTF8         = 0 // ConvertedType
+	parquetPlain        = 0 // Encoding
+	parquetRLE          = 3 // Encoding
+	parquetDataPage     = 0 // PageType
+	parquetUncompressed = 0 // CompressionCodec
+)
+
+var parquetMagic = []byte("PAR1")
+
+// parquetColumn is a column of the file and the values of the row group
+// being built.
+type parquetColumn struct {
+	name    string
+	typ     int32
+	present []bool
+	values  []interface{} // bool, int64, float64 or string, one per present value
+	// offset and size of the column chunks written, for the footer
+	chunks []parquetChunk
+}
+
+type parquetChunk struct {
+	offset, size int64
+	values       int
+}
+
+// Parquet writes results as an uncompressed Parquet file. Object rows are
+// flattened to dot-delimited columns as for TSV; other rows go to a "value"
+// column. The columns and their types come from the first 1000 rows:
+// booleans, integers (int64), other numbers (double), and strings, with
+// any other or mixed values stored as JSON text. Every column is optional.
+// Rows are written in row groups of 10000 as they arrive. Keys first seen
+// after the sample, and values that do not fit their column's type, are
+// left out with a warning to stderr.
+func Parquet(w io.Writer, iter RowIterator) error {
+	return parquetWriter(w, os.Stderr, iter, parquetSampleRows, parquetGroupRows)
+}
+
+func parquetWriter(w, errOut io.Writer, iter RowIterator, sampleRows, groupRows int) error {
+	pw := &parquetFile{w: w}
+	var sample [][]flatField
+	for len(sample) < sampleRows {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		sample = append(sample, parquetFields(row))
+	}
+	pw.cols = inferParquetColumns(sample)
+	if err := pw.write(parquetMagic); err != nil {
+		return err
+	}
+	for _, fields := range sample {
+		if err := pw.add(fields, groupRows); err != nil {
+			return err
+		}
+	}
+	more := len(sample) == sampleRows
+	sample = nil
+	for more {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := pw.add(parquetFields(row), groupRows); err != nil {
+			return err
+		}
+	}
+	if err := pw.flush(); err != nil {
+		return err
+	}
+	if pw.dropped > 0 {
+		_, _ = fmt.Fprintf(errOut, "warning: parquet: %d values did not fit the columns inferred from the first %d rows and were left out\n", pw.dropped, sampleRows)
+	}
+	return pw.footer()
+}
+
+// parquetFields returns the flattened fields of an object row, or a
+// "value" field holding any other row.
+func parquetFields(row json.RawMessage) []flatField {
+	fields, err := flattenObject(row)
+	if err != nil {
+		return []flatField{{key: "value", value: row}}
+	}
+	return fields
+}
+
+// parquetKind classifies a JSON value for type inference; "" is null.
+func parquetKind(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return ""
+	}
+	switch raw[0] {
+	case 'n':
+		return ""
+	case 't', 'f':
+		return "bool"
+	case '"':
+		return "string"
+	case '[', '{':
+		return "json"
+	}
+	var n json.Number
+	if json.Unmarshal(raw, &n) != nil {
+		return "json"
+	}
+	if _, err := n.Int64(); err == nil {
+		return "int"
+	}
+	return "float"
+}
+
+// inferParquetColumns returns a column per key of the sampled rows, in
+// first-seen order, typed by the values seen under it.
+func inferParquetColumns(sample [][]flatField) []*parquetColumn {
+	var cols []*parquetColumn
+	kinds := map[string]map[string]bool{}
+	for _, fields := range sample {
+		for _, f := range fields {
+			if kinds[f.key] == nil {
+				kinds[f.key] = map[string]bool{}
+				cols = append(cols, &parquetColumn{name: f.key})
+			}
+			if k := parquetKind(f.value); k != "" {
+				kinds[f.key][k] = true
+			}
+		}
+	}
+	for _, c := range cols {
+		k := kinds[c.name]
+		switch {
+		case len(k) == 1 && k["bool"]:
+			c.typ = parquetBoolean
+		case len(k) == 1 && k["int"]:
+			c.typ = parquetInt64
+		case len(k) > 0 && len(k) <= 2 && !k["bool"] && !k["string"] && !k["json"]:
+			c.typ = parquetDouble
+		default:
+			c.typ = parquetByteArray
+		}
+	}
+	return cols
+}
+
+// parquetFile tracks the file written so far.
+type parquetFile struct {
+	w       io.Writer
+	offset  int64
+	cols    []*parquetColumn
+	rows    int // rows in the current row group
+	groups  []int
+	dropped int // values left out
+}
+
+func (p *parquetFile) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	return err
+}
+
+// add appends a row to the row group, writing the group once it is full.
+func (p *parquetFile) add(fields []flatField, groupRows int) error {
+	values := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if _, dup := values[f.key]; !dup {
+			values[f.key] = f.value
+		}
+	}
+	known := 0
+	for _, c := range p.cols {
+		raw, ok := values[c.name]
+		if ok {
+			known++
+		}
+		v, fits := parquetValue(c.typ, raw)
+		if !fits {
+			p.dropped++
+		}
+		c.present = append(c.present, v != nil)
+		if v != nil {
+			c.values = append(c.values, v)
+		}
+	}
+	p.dropped += len(values) - known
+	p.rows++
+	if p.rows == groupRows {
+		return p.flush()
+	}
+	return nil
+}
+
+// parquetValue converts raw to a value of a column of type typ: nil for
+// null or missing values, and, with fits false, for ones of another type.
+func parquetValue(typ int32, raw json.RawMessage) (v interface{}, fits bool) {
+	kind := parquetKind(raw)
+	if kind == "" {
+		return nil, true
+	}
+	switch typ {
+	case parquetBoolean:
+		var b bool
+		if kind != "bool" || json.Unmarshal(raw, &b) != nil {
+			return nil, false
+		}
+		return b, true
+	case parquetInt64:
+		var n json.Number
+		if kind != "int" || json.Unmarshal(raw, &n) != nil {
+			return nil, false
+		}
+		i, _ := n.Int64()
+		return i, true
+	case parquetDouble:
+		var f float64
+		if (kind != "int" && kind != "float") || json.Unmarshal(raw, &f) != nil {
+			return nil, false
+		}
+		return f, true
+	}
+	if kind == "string" {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return s, true
+		}
+	}
+	return compactValue(raw), true
+}
+
+// flush writes the buffered rows as a row group of one data page per
+// column.
+func (p *parquetFile) flush() error {
+	if p.rows == 0 {
+		return nil
+	}
+	for _, c := range p.cols {
+		page := c.page()
+		t := newThriftWriter()
+		t.i32(1, parquetDataPage)
+		t.i32(2, int32(len(page))) //nolint:gosec // pages are far below 2 GiB
+		t.i32(3, int32(len(page))) //nolint:gosec
+		t.begin(5)
+		t.i32(1, int32(p.rows)) //nolint:gosec
+		t.i32(2, parquetPlain)
+		t.i32(3, parquetRLE)
+		t.i32(4, parquetRLE)
+		t.end()
+		t.end()
+		chunk := parquetChunk{offset: p.offset, size: int64(len(t.buf) + len(page)), values: p.rows}
+		if err := p.write(t.buf); err != nil {
+			return err
+		}
+		if err := p.write(page); err != nil {
+			return err
+		}
+		c.chunks = append(c.chunks, chunk)
+		c.present, c.values = c.present[:0], c.values[:0]
+	}
+	p.groups = append(p.groups, p.rows)
+	p.rows = 0
+	return nil
+}
+
+// page returns the body of a data page holding the buffered values of c:
+// the definition levels, run-length encoded with their length in front,
+// then the present values, plain encoded.
+func (c *parquetColumn) page() []byte {
+	levels := bitPacked(c.present)
+	var buf []byte
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(levels))) //nolint:gosec // len of one page
+	buf = append(buf, levels...)
+	if c.typ == parquetBoolean {
+		bits := make([]bool, len(c.values))
+		for i, v := range c.values {
+			bits[i] = v.(bool)
+		}
+		return append(buf, packBits(bits)...)
+	}
+	for _, v := range c.values {
+		switch v := v.(type) {
+		case int64:
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(v)) //nolint:gosec // two's complement
+		case float64:
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+		case string:
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(v))) //nolint:gosec // len of one value
+			buf = append(buf, v...)
+		}
+	}
+	return buf
+}
+
+// bitPacked encodes 1-bit values as one bit-packed run of the
+// RLE/bit-packing hybrid encoding.
+func bitPacked(bits []bool) []byte {
+	groups := (len(bits) + 7) / 8
+	buf := binary.AppendUvarint(nil, uint64(groups)<<1|1) //nolint:gosec // groups >= 0
+	return append(buf, packBits(bits)...)
+}
+
+// packBits packs bits eight to a byte, the first in the lowest bit.
+func packBits(bits []bool) []byte {
+	buf := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			buf[i/8] |= 1 << (i % 8)
+		}
+	}
+	return buf
+}
+
+// footer writes the file metadata, its length and the closing magic.
+func (p *parquetFile) footer() error {
+	total := 0
+	for _, n := range p.groups {
+		total += n
+	}
+	t := newThriftWriter()
+	t.i32(1, 1)
+	t.list(2, thriftStruct, len(p.cols)+1)
+	t.elem()
+	t.string(4, "schema")
+	t.i32(5, int32(len(p.cols))) //nolint:gosec
+	t.end()
+	for _, c := range p.cols {
+		t.elem()
+		t.i32(1, c.typ)
+		t.i32(3, parquetOptional)
+		t.string(4, c.name)
+		if c.typ == parquetByteArray {
+			t.i32(6, parquetUTF8)
+			t.begin(10)
+			t.begin(1) // STRING
+			t.end()
+			t.end()
+		}
+		t.end()
+	}
+	t.i64(3, int64(total))
+	t.list(4, thriftStruct, len(p.groups))
+	for g, n := range p.groups {
+		t.elem()
+		t.list(1, thriftStruct, len(p.cols))
+		var size int64
+		for _, c := range p.cols {
+			ch := c.chunks[g]
+			size += ch.size
+			t.elem()
+			t.i64(2, ch.offset)
+			t.begin(3)
+			t.i32(1, c.typ)
+			t.list(2, thriftI32, 2)
+			t.listI32(parquetPlain)
+			t.listI32(parquetRLE)
+			t.list(3, thriftBinary, 1)
+			t.bytes(c.name)
+			t.i32(4, parquetUncompressed)
+			t.i64(5, int64(ch.values))
+			t.i64(6, ch.size)
+			t.i64(7, ch.size)
+			t.i64(9, ch.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, size)
+		t.i64(3, int64(n))
+		t.end()
+	}
+	t.string(6, "r-cli")
+	t.end()
+	meta := binary.LittleEndian.AppendUint32(t.buf, uint32(len(t.buf))) //nolint:gosec // footer size
+	if err := p.write(meta); err != nil {
+		return err
+	}
+	return p.write(parquetMagic)
+}
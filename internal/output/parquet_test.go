@@ -0,0 +1,273 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// thriftStructVal is a decoded Thrift compact struct: field id to value.
+type thriftStructVal map[int16]interface{}
+
+// readThrift decodes the struct at the start of b, returning it and the
+// bytes after it.
+func readThrift(t *testing.T, b []byte) (thriftStructVal, []byte) {
+	t.Helper()
+	uvarint := func() uint64 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("thrift: bad varint")
+		}
+		b = b[n:]
+		return v
+	}
+	zigzag := func() int64 {
+		v := uvarint()
+		return int64(v>>1) ^ -int64(v&1) //nolint:gosec
+	}
+	var value func(typ byte) interface{}
+	value = func(typ byte) interface{} {
+		switch typ {
+		case thriftI32, thriftI64:
+			return zigzag()
+		case thriftBinary:
+			n := uvarint()
+			s := string(b[:n])
+			b = b[n:]
+			return s
+		case thriftList:
+			h := b[0]
+			b = b[1:]
+			n := int(h >> 4)
+			if n == 15 {
+				n = int(uvarint()) //nolint:gosec
+			}
+			list := make([]interface{}, n)
+			for i := range list {
+				list[i] = value(h & 0x0f)
+			}
+			return list
+		case thriftStruct:
+			var s thriftStructVal
+			s, b = readThrift(t, b)
+			return s
+		}
+		t.Fatalf("thrift: unexpected type %d", typ)
+		return nil
+	}
+	s := thriftStructVal{}
+	var id int16
+	for {
+		h := b[0]
+		b = b[1:]
+		if h == 0 {
+			return s, b
+		}
+		if delta := int16(h >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(zigzag()) //nolint:gosec
+		}
+		s[id] = value(h & 0x0f)
+	}
+}
+
+// parquetTable reads back a file written by Parquet: the column names and
+// types, and the rows with nil for nulls.
+func parquetTable(t *testing.T, file []byte) (names []string, types []int64, rows [][]interface{}) {
+	t.Helper()
+	if !bytes.HasPrefix(file, parquetMagic) || !bytes.HasSuffix(file, parquetMagic) {
+		t.Fatalf("missing PAR1 magic")
+	}
+	size := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	meta, rest := readThrift(t, file[len(file)-8-size:len(file)-8])
+	if len(rest) != 0 {
+		t.Fatalf("footer: %d bytes after the metadata", len(rest))
+	}
+	schema := meta[2].([]interface{})
+	if got := schema[0].(thriftStructVal)[5].(int64); got != int64(len(schema)-1) {
+		t.Fatalf("root num_children = %d, want %d", got, len(schema)-1)
+	}
+	for _, e := range schema[1:] {
+		el := e.(thriftStructVal)
+		names = append(names, el[4].(string))
+		types = append(types, el[1].(int64))
+		if el[3].(int64) != parquetOptional {
+			t.Errorf("column %s is not optional", el[4])
+		}
+	}
+	for _, g := range meta[4].([]interface{}) {
+		group := g.(thriftStructVal)
+		n := int(group[3].(int64))
+		cols := make([][]interface{}, len(names))
+		for i, c := range group[1].([]interface{}) {
+			md := c.(thriftStructVal)[3].(thriftStructVal)
+			if md[3].([]interface{})[0] != names[i] || md[1].(int64) != types[i] {
+				t.Fatalf("column chunk %d does not match the schema", i)
+			}
+			header, page := readThrift(t, file[md[9].(int64):])
+			if header[2].(int64) != header[3].(int64) {
+				t.Fatalf("bad page header %v", header)
+			}
+			page = page[:header[2].(int64)]
+			if got := header[5].(thriftStructVal)[1].(int64); got != int64(n) {
+				t.Fatalf("page num_values = %d, want %d", got, n)
+			}
+			cols[i] = readPage(t, page, types[i], n)
+		}
+		for r := 0; r < n; r++ {
+			row := make([]interface{}, len(names))
+			for i := range names {
+				row[i] = cols[i][r]
+			}
+			rows = append(rows, row)
+		}
+		if int(meta[3].(int64)) < len(rows) {
+			t.Fatalf("num_rows %d < rows read %d", meta[3], len(rows))
+		}
+	}
+	return names, types, rows
+}
+
+// readPage decodes the n values of a data page of a column of type typ.
+func readPage(t *testing.T, page []byte, typ int64, n int) []interface{} {
+	t.Helper()
+	size := binary.LittleEndian.Uint32(page)
+	levels, values := page[4:4+size], page[4+size:]
+	groups, k := binary.Uvarint(levels)
+	if groups&1 != 1 || int(groups>>1) != (n+7)/8 { //nolint:gosec
+		t.Fatalf("definition levels: header %d for %d values", groups, n)
+	}
+	levels = levels[k:]
+	out := make([]interface{}, n)
+	bit := 0
+	for i := range out {
+		if levels[i/8]&(1<<(i%8)) == 0 {
+			continue
+		}
+		switch typ {
+		case parquetBoolean:
+			out[i] = values[bit/8]&(1<<(bit%8)) != 0
+			bit++
+		case parquetInt64:
+			out[i] = int64(binary.LittleEndian.Uint64(values)) //nolint:gosec
+			values = values[8:]
+		case parquetDouble:
+			out[i] = math.Float64frombits(binary.LittleEndian.Uint64(values))
+			values = values[8:]
+		case parquetByteArray:
+			l := binary.LittleEndian.Uint32(values)
+			out[i] = string(values[4 : 4+l])
+			values = values[4+l:]
+		}
+	}
+	return out
+}
+
+func TestParquet_Columns(t *testing.T) {
+	t.Parallel()
+	iter := newIter(
+		`{"id":1,"name":"alice","score":1.5,"admin":true,"address":{"city":"NYC"},"tags":["a"]}`,
+		`{"id":2,"score":2,"admin":false,"mixed":"x"}`,
+		`{"id":3,"name":null,"mixed":4}`,
+	)
+	var buf, errOut bytes.Buffer
+	if err := parquetWriter(&buf, &errOut, iter, 1000, 10000); err != nil {
+		t.Fatal(err)
+	}
+	names, types, rows := parquetTable(t, buf.Bytes())
+	wantNames := []string{"id", "name", "score", "admin", "address.city", "tags", "mixed"}
+	wantTypes := []int64{parquetInt64, parquetByteArray, parquetDouble, parquetBoolean, parquetByteArray, parquetByteArray, parquetByteArray}
+	if !reflect.DeepEqual(names, wantNames) || !reflect.DeepEqual(types, wantTypes) {
+		t.Fatalf("schema = %v %v, want %v %v", names, types, wantNames, wantTypes)
+	}
+	want := [][]interface{}{
+		{int64(1), "alice", 1.5, true, "NYC", `["a"]`, nil},
+		{int64(2), nil, 2.0, false, nil, nil, "x"},
+		{int64(3), nil, nil, nil, nil, nil, "4"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+	if errOut.Len() != 0 {
+		t.Errorf("unexpected warning %q", errOut.String())
+	}
+}
+
+func TestParquet_RowGroupsAndLateValues(t *testing.T) {
+	t.Parallel()
+	// the schema comes from the first two rows; row groups hold two rows
+	iter := newIter(`{"n":1}`, `{"n":2}`, `{"n":"three","extra":1}`, `{"n":4}`, `"plain"`)
+	var buf, errOut bytes.Buffer
+	if err := parquetWriter(&buf, &errOut, iter, 2, 2); err != nil {
+		t.Fatal(err)
+	}
+	names, _, rows := parquetTable(t, buf.Bytes())
+	if !reflect.DeepEqual(names, []string{"n"}) {
+		t.Fatalf("names = %v", names)
+	}
+	want := [][]interface{}{{int64(1)}, {int64(2)}, {nil}, {int64(4)}, {nil}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+	if !strings.Contains(errOut.String(), "3 values") {
+		t.Errorf("warning = %q, want 3 values left out", errOut.String())
+	}
+}
+
+func TestParquet_NonObjectRows(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := parquetWriter(&buf, &bytes.Buffer{}, newIter(`1`, `"x"`, `null`), 1000, 10000); err != nil {
+		t.Fatal(err)
+	}
+	names, types, rows := parquetTable(t, buf.Bytes())
+	if !reflect.DeepEqual(names, []string{"value"}) || types[0] != parquetByteArray {
+		t.Fatalf("schema = %v %v", names, types)
+	}
+	if want := [][]interface{}{{"1"}, {"x"}, {nil}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
+
+func TestParquet_Empty(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := Parquet(&buf, newIter()); err != nil {
+		t.Fatal(err)
+	}
+	names, _, rows := parquetTable(t, buf.Bytes())
+	if len(names) != 0 || len(rows) != 0 {
+		t.Errorf("got %v %v, want an empty file", names, rows)
+	}
+}
+
+func TestParquet_Error(t *testing.T) {
+	t.Parallel()
+	errStream := errors.New("stream broken")
+	iter := &mockIter{items: []json.RawMessage{json.RawMessage(`{"a":1}`)}, err: errStream}
+	if err := Parquet(&bytes.Buffer{}, iter); !errors.Is(err, errStream) {
+		t.Errorf("err = %v, want %v", err, errStream)
+	}
+}
+
+func TestThriftWriterLongFieldDelta(t *testing.T) {
+	t.Parallel()
+	w := newThriftWriter()
+	w.i32(1, -3)
+	w.i64(20, 300)
+	w.list(21, thriftI32, 16)
+	for i := range 16 {
+		w.listI32(int32(i)) //nolint:gosec
+	}
+	w.end()
+	s, rest := readThrift(t, w.buf)
+	if len(rest) != 0 || s[1] != int64(-3) || s[20] != int64(300) || len(s[21].([]interface{})) != 16 {
+		t.Errorf("decoded %v, rest %v", s, rest)
+	}
+}
@@ -0,0 +1,142 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+)
+
+// SQLTable is the SQLite table that SQLite inserts rows into. Columns are
+// the columns it already has; SQLite appends the ones it adds for new keys,
+// so one SQLTable can take the results of several queries.
+type SQLTable struct {
+	Name    string
+	Columns []string
+}
+
+// SQLite writes results as SQL for the sqlite3 shell, inserting them into t
+// in one transaction. Keys that differ only in the case of ASCII letters
+// share a column, the first one seen in a row giving its value. Object rows are flattened to dot-delimited columns as
+// for TSV; other rows go to a "value" column. A table without columns is
+// created from the first row, and a key without a column gets one with
+// ALTER TABLE. Numbers are stored as numbers, booleans as 1 and 0, null as
+// NULL, and arrays and empty objects as their JSON text. When iter fails
+// the transaction is rolled back and t keeps the columns it had.
+func SQLite(w io.Writer, iter RowIterator, t *SQLTable) error {
+	saved := slices.Clone(t.Columns)
+	if _, err := io.WriteString(w, "BEGIN;\n"); err != nil {
+		return err
+	}
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil {
+			_, err = io.WriteString(w, t.insert(row))
+		}
+		if err != nil {
+			t.Columns = saved
+			_, _ = io.WriteString(w, "ROLLBACK;\n")
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "COMMIT;\n")
+	return err
+}
+
+// insert returns the INSERT statement for row, after the CREATE TABLE or
+// ALTER TABLE statements that give its keys columns.
+func (t *SQLTable) insert(row json.RawMessage) string {
+	fields, err := flattenObject(row)
+	if err != nil || (len(fields) == 0 && len(t.Columns) == 0) {
+		fields = []flatField{{key: "value", value: row}}
+	}
+	var sb strings.Builder
+	var names, values []string
+	for _, f := range fields {
+		if sqlHasName(names, f.key) {
+			continue
+		}
+		names = append(names, f.key)
+		values = append(values, sqlValue(f.value))
+	}
+	if len(t.Columns) == 0 {
+		sb.WriteString("CREATE TABLE " + sqlName(t.Name) + " (" + sqlNames(names) + ");\n")
+		t.Columns = names
+	} else {
+		for _, n := range names {
+			if !sqlHasName(t.Columns, n) {
+				sb.WriteString("ALTER TABLE " + sqlName(t.Name) + " ADD COLUMN " + sqlName(n) + ";\n")
+				t.Columns = append(t.Columns, n)
+			}
+		}
+	}
+	if len(names) == 0 {
+		sb.WriteString("INSERT INTO " + sqlName(t.Name) + " DEFAULT VALUES;\n")
+		return sb.String()
+	}
+	sb.WriteString("INSERT INTO " + sqlName(t.Name) + " (" + sqlNames(names) + ") VALUES (" + strings.Join(values, ", ") + ");\n")
+	return sb.String()
+}
+
+// sqlHasName reports whether names holds n as SQLite compares identifiers:
+// ignoring the case of ASCII letters, so "Name" and "name" are one column.
+func sqlHasName(names []string, n string) bool {
+	n = sqlFold(n)
+	return slices.ContainsFunc(names, func(m string) bool { return sqlFold(m) == n })
+}
+
+func sqlFold(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+// sqlValue renders a raw JSON value as an SQL literal.
+func sqlValue(raw json.RawMessage) string {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if dec.Decode(&v) != nil {
+		return sqlString(string(raw))
+	}
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case json.Number:
+		return v.String()
+	case string:
+		return sqlString(v)
+	}
+	return sqlString(compactValue(raw))
+}
+
+// sqlString quotes s as an SQL string literal.
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// sqlName quotes s as an SQL identifier.
+func sqlName(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func sqlNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = sqlName(n)
+	}
+	return strings.Join(quoted, ", ")
+}
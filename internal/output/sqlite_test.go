@@ -0,0 +1,93 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSQLite_CreatesTable(t *testing.T) {
+	t.Parallel()
+	iter := newIter(
+		`{"id":1,"name":"o'brien","address":{"city":"NYC"},"tags":["a"],"admin":true}`,
+		`{"id":2.5,"name":null,"admin":false,"email":"b@x"}`,
+	)
+	tbl := &SQLTable{Name: "results"}
+	var buf bytes.Buffer
+	if err := SQLite(&buf, iter, tbl); err != nil {
+		t.Fatal(err)
+	}
+	want := "BEGIN;\n" +
+		`CREATE TABLE "results" ("id", "name", "address.city", "tags", "admin");` + "\n" +
+		`INSERT INTO "results" ("id", "name", "address.city", "tags", "admin") VALUES (1, 'o''brien', 'NYC', '["a"]', 1);` + "\n" +
+		`ALTER TABLE "results" ADD COLUMN "email";` + "\n" +
+		`INSERT INTO "results" ("id", "name", "admin", "email") VALUES (2.5, NULL, 0, 'b@x');` + "\n" +
+		"COMMIT;\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+	if !slices.Equal(tbl.Columns, []string{"id", "name", "address.city", "tags", "admin", "email"}) {
+		t.Errorf("columns = %q", tbl.Columns)
+	}
+}
+
+func TestSQLite_ExistingColumns(t *testing.T) {
+	t.Parallel()
+	tbl := &SQLTable{Name: `my"t`, Columns: []string{"id"}}
+	var buf bytes.Buffer
+	if err := SQLite(&buf, newIter(`{"id":1}`, `{}`, `"plain"`), tbl); err != nil {
+		t.Fatal(err)
+	}
+	want := "BEGIN;\n" +
+		`INSERT INTO "my""t" ("id") VALUES (1);` + "\n" +
+		`INSERT INTO "my""t" DEFAULT VALUES;` + "\n" +
+		`ALTER TABLE "my""t" ADD COLUMN "value";` + "\n" +
+		`INSERT INTO "my""t" ("value") VALUES ('plain');` + "\n" +
+		"COMMIT;\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestSQLite_CaseInsensitiveColumns(t *testing.T) {
+	t.Parallel()
+	tbl := &SQLTable{Name: "results"}
+	var buf bytes.Buffer
+	iter := newIter(`{"Name":"a","name":"b","a":{"B":1,"b":2}}`, `{"NAME":"c","A.b":3,"é":1,"É":2}`)
+	if err := SQLite(&buf, iter, tbl); err != nil {
+		t.Fatal(err)
+	}
+	want := "BEGIN;\n" +
+		`CREATE TABLE "results" ("Name", "a.B");` + "\n" +
+		`INSERT INTO "results" ("Name", "a.B") VALUES ('a', 1);` + "\n" +
+		`ALTER TABLE "results" ADD COLUMN "é";` + "\n" +
+		`ALTER TABLE "results" ADD COLUMN "É";` + "\n" +
+		`INSERT INTO "results" ("NAME", "A.b", "é", "É") VALUES ('c', 3, 1, 2);` + "\n" +
+		"COMMIT;\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestSQLite_ErrorRollsBack(t *testing.T) {
+	t.Parallel()
+	errStream := errors.New("stream broken")
+	iter := &mockIter{items: []json.RawMessage{json.RawMessage(`{"a":1}`)}, err: errStream}
+	tbl := &SQLTable{Name: "t"}
+	var buf bytes.Buffer
+	if err := SQLite(&buf, iter, tbl); !errors.Is(err, errStream) {
+		t.Fatalf("err = %v, want %v", err, errStream)
+	}
+	want := "BEGIN;\n" +
+		`CREATE TABLE "t" ("a");` + "\n" +
+		`INSERT INTO "t" ("a") VALUES (1);` + "\n" +
+		"ROLLBACK;\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+	if len(tbl.Columns) != 0 {
+		t.Errorf("columns after rollback = %q, want none", tbl.Columns)
+	}
+}
@@ -16,14 +16,33 @@ const (
 	maxColWidth  = 50
 )
 
+// TableOptions controls table layout; the zero value picks columns automatically
+// in first-seen order, flattens nested objects into address.city columns and
+// caps column width at 50 characters.
+type TableOptions struct {
+	Columns     []string // explicit column list and order
+	MaxColWidth int      // 0 means the default of 50
+	NoTruncate  bool     // never truncate values
+	// Depth caps the levels of keys in a column name: 1 keeps nested
+	// objects as JSON cells, 2 makes address.city but not address.geo.lat.
+	// 0 flattens every level.
+	Depth int
+}
+
 // Table formats results as an aligned ASCII table.
 // Buffers up to maxTableRows rows; if exceeded, truncates with warning to stderr.
+// Nested objects become dot-delimited columns and arrays compact JSON cells.
 // Non-object rows fall back to raw output.
 func Table(w io.Writer, iter RowIterator) error {
-	return tableWriter(w, os.Stderr, iter, maxTableRows)
+	return TableWithOptions(w, iter, TableOptions{})
+}
+
+// TableWithOptions formats results as an aligned ASCII table using opts.
+func TableWithOptions(w io.Writer, iter RowIterator, opts TableOptions) error {
+	return tableWriter(w, os.Stderr, iter, maxTableRows, opts)
 }
 
-func tableWriter(w, errOut io.Writer, iter RowIterator, maxRows int) error {
+func tableWriter(w, errOut io.Writer, iter RowIterator, maxRows int, opts TableOptions) error {
 	rows, truncated, err := collectRows(iter, maxRows)
 	if err != nil {
 		return err
@@ -41,9 +60,15 @@ func tableWriter(w, errOut io.Writer, iter RowIterator, maxRows int) error {
 	if json.Unmarshal(rows[0], &probe) != nil {
 		return rawSlice(w, rows)
 	}
+	for i, row := range rows {
+		rows[i] = flatRow(row, opts.Depth)
+	}
 
-	cols := extractColumns(rows)
-	widths := computeWidths(cols, rows)
+	cols := opts.Columns
+	if len(cols) == 0 {
+		cols = extractColumns(rows)
+	}
+	widths := computeWidths(cols, rows, opts.maxWidth())
 
 	if err := printTableHeader(w, cols, widths); err != nil {
 		return err
@@ -119,7 +144,38 @@ func objectKeysInOrder(data json.RawMessage) ([]string, error) {
 	return keys, nil
 }
 
-func computeWidths(cols []string, rows []json.RawMessage) []int {
+// maxWidth returns the column width cap; 0 means unlimited.
+func (o TableOptions) maxWidth() int {
+	switch {
+	case o.NoTruncate:
+		return 0
+	case o.MaxColWidth > 0:
+		return o.MaxColWidth
+	default:
+		return maxColWidth
+	}
+}
+
+// LessValue reports whether a sorts before b: numbers numerically before
+// other values, which compare by their text with strings unquoted; nil, a
+// missing value, sorts last.
+func LessValue(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a != nil && b == nil
+	}
+	var x, y float64
+	aNum, bNum := json.Unmarshal(a, &x) == nil, json.Unmarshal(b, &y) == nil
+	switch {
+	case aNum && bNum:
+		return x < y
+	case aNum != bNum:
+		return aNum
+	default:
+		return cellValue(a) < cellValue(b)
+	}
+}
+
+func computeWidths(cols []string, rows []json.RawMessage, maxWidth int) []int {
 	widths := make([]int, len(cols))
 	for i, col := range cols {
 		widths[i] = utf8.RuneCountInString(col)
@@ -137,8 +193,8 @@ func computeWidths(cols []string, rows []json.RawMessage) []int {
 		}
 	}
 	for i := range widths {
-		if widths[i] > maxColWidth {
-			widths[i] = maxColWidth
+		if maxWidth > 0 && widths[i] > maxWidth {
+			widths[i] = maxWidth
 		}
 	}
 	return widths
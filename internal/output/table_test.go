@@ -2,6 +2,7 @@ package output
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
 )
@@ -132,7 +133,7 @@ func TestTable_MaxRowsTruncation(t *testing.T) {
 	}
 	iter := newIter(items...)
 	var out, errOut bytes.Buffer
-	if err := tableWriter(&out, &errOut, iter, testMax); err != nil {
+	if err := tableWriter(&out, &errOut, iter, testMax, TableOptions{}); err != nil {
 		t.Fatal(err)
 	}
 	// check warning on stderr
@@ -147,3 +148,104 @@ func TestTable_MaxRowsTruncation(t *testing.T) {
 		t.Errorf("expected %d data rows, got %d:\n%s", testMax, dataLines, out.String())
 	}
 }
+
+func tableLines(t *testing.T, opts TableOptions, rows ...string) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := TableWithOptions(&buf, newIter(rows...), opts); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+func TestTable_Columns(t *testing.T) {
+	t.Parallel()
+	lines := tableLines(t, TableOptions{Columns: []string{"city", "name"}},
+		`{"name":"alice","age":30,"city":"NYC"}`,
+	)
+	if got := strings.Fields(lines[0]); strings.Join(got, " ") != "city | name" {
+		t.Errorf("header: got %q, want columns city, name", lines[0])
+	}
+	if strings.Contains(lines[2], "30") {
+		t.Errorf("unselected column rendered: %q", lines[2])
+	}
+}
+
+func TestTable_MaxColWidth(t *testing.T) {
+	t.Parallel()
+	lines := tableLines(t, TableOptions{MaxColWidth: 5}, `{"col":"abcdefghij"}`)
+	if got := strings.TrimSpace(lines[2]); got != "abcd~" {
+		t.Errorf("got %q, want %q", got, "abcd~")
+	}
+}
+
+func TestTable_NoTruncate(t *testing.T) {
+	t.Parallel()
+	longVal := strings.Repeat("x", maxColWidth+10)
+	lines := tableLines(t, TableOptions{NoTruncate: true, MaxColWidth: 5}, `{"col":"`+longVal+`"}`)
+	if got := strings.TrimSpace(lines[2]); got != longVal {
+		t.Errorf("value truncated: got %q", got)
+	}
+}
+
+func TestLessValue(t *testing.T) {
+	t.Parallel()
+	// in order: numbers numerically, then other values by their text, then missing
+	vals := []json.RawMessage{
+		json.RawMessage(`-1`), json.RawMessage(`9`), json.RawMessage(`10`),
+		json.RawMessage(`"Oslo"`), json.RawMessage(`"Rome"`), json.RawMessage(`true`), nil,
+	}
+	for i := range vals {
+		for j := range vals {
+			if got := LessValue(vals[i], vals[j]); got != (i < j) {
+				t.Errorf("LessValue(%s, %s) = %v, want %v", vals[i], vals[j], got, i < j)
+			}
+		}
+	}
+}
+
+func TestTable_Flatten(t *testing.T) {
+	t.Parallel()
+	rows := []string{
+		`{"id":1,"address":{"city":"Oslo","geo":{"lat":59.9}},"tags":["a", "b"]}`,
+		`{"id":2,"address":{"city":"Rome"},"tags":[]}`,
+	}
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{0, []string{
+			"id | address.city | address.geo.lat | tags",
+			"1  | Oslo         | 59.9            | [\"a\",\"b\"]",
+			"2  | Rome         |                 | []",
+		}},
+		{2, []string{
+			"id | address.city | address.geo  | tags",
+			"1  | Oslo         | {\"lat\":59.9} | [\"a\",\"b\"]",
+			"2  | Rome         |              | []",
+		}},
+		{1, []string{
+			"id | address                            | tags",
+			"1  | {\"city\":\"Oslo\",\"geo\":{\"lat\":59.9}} | [\"a\",\"b\"]",
+			"2  | {\"city\":\"Rome\"}                    | []",
+		}},
+	}
+	for _, tt := range tests {
+		lines := tableLines(t, TableOptions{Depth: tt.depth}, rows...)
+		got := []string{strings.TrimRight(lines[0], " "), strings.TrimRight(lines[2], " "), strings.TrimRight(lines[3], " ")}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("depth %d:\n%s\nwant:\n%s", tt.depth, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestTable_FlattenColumns(t *testing.T) {
+	t.Parallel()
+	lines := tableLines(t, TableOptions{Columns: []string{"address.city"}},
+		`{"address":{"city":"Rome"}}`,
+		`{"address":{"city":"Oslo"}}`,
+	)
+	if got := strings.TrimSpace(lines[2]) + "," + strings.TrimSpace(lines[3]); got != "Rome,Oslo" {
+		t.Errorf("got %q", got)
+	}
+}
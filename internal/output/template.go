@@ -0,0 +1,71 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+// templateFuncs are extra helpers available inside row templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// ParseTemplate parses a row template, making the template helper functions available.
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("row").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Template renders each row through a text/template followed by a newline.
+// Rows are decoded into generic Go values (objects become maps, numbers keep
+// their JSON text), so fields are accessed as {{.name}} or {{index . "a-b"}}.
+// A "json" helper renders any value as compact JSON.
+func Template(w io.Writer, iter RowIterator, text string) error {
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		v, err := decodeRow(row)
+		if err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+		buf.Reset()
+		if err := tmpl.Execute(&buf, v); err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+		buf.WriteByte('\n')
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeRow unmarshals a row into generic Go values, preserving number text.
+func decodeRow(row json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(row))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
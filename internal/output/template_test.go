@@ -0,0 +1,88 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestTemplate_RendersEachRow(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`{"id":1,"name":"alice"}`, `{"id":2,"name":"bob"}`)
+	var buf bytes.Buffer
+	if err := Template(&buf, iter, "{{.id}}: {{.name}}"); err != nil {
+		t.Fatal(err)
+	}
+	want := "1: alice\n2: bob\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTemplate_PreservesLargeNumbers(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`{"n":12345678901234567890}`)
+	var buf bytes.Buffer
+	if err := Template(&buf, iter, "{{.n}}"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "12345678901234567890\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestTemplate_JSONHelper(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`{"tags":["a","b"],"address":{"city":"NYC"}}`)
+	var buf bytes.Buffer
+	if err := Template(&buf, iter, `{{json .tags}} {{.address.city}}`); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[\"a\",\"b\"] NYC\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestTemplate_MissingKey(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`{"id":1}`)
+	var buf bytes.Buffer
+	if err := Template(&buf, iter, `{{.id}}-{{.missing}}`); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "1-<no value>\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestTemplate_ScalarRows(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`"users"`, `"posts"`)
+	var buf bytes.Buffer
+	if err := Template(&buf, iter, "table={{.}}"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "table=users\ntable=posts\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestTemplate_ParseError(t *testing.T) {
+	t.Parallel()
+	if err := Template(&bytes.Buffer{}, newIter(`{}`), "{{.id"); err == nil {
+		t.Error("expected parse error, got nil")
+	}
+	if _, err := ParseTemplate("{{.id"); err == nil {
+		t.Error("ParseTemplate: expected error, got nil")
+	}
+}
+
+func TestTemplate_IteratorError(t *testing.T) {
+	t.Parallel()
+	errStream := errors.New("stream error")
+	iter := &mockIter{items: []json.RawMessage{json.RawMessage(`{"a":1}`)}, err: errStream}
+	if err := Template(&bytes.Buffer{}, iter, "{{.a}}"); !errors.Is(err, errStream) {
+		t.Errorf("expected stream error, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package output
+
+import "encoding/binary"
+
+// Thrift compact protocol type codes.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs in the Thrift compact protocol, as Parquet
+// stores its page headers and file metadata. Fields must be written in
+// increasing id order within each struct.
+type thriftWriter struct {
+	buf  []byte
+	last []int16 // id of the last field written, per open struct
+}
+
+// newThriftWriter starts the encoding of a top-level struct.
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{last: []int16{0}}
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	top := len(t.last) - 1
+	if delta := id - t.last[top]; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	t.last[top] = id
+}
+
+// varint appends n zigzag-encoded.
+func (t *thriftWriter) varint(n int64) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(n<<1^n>>63)) //nolint:gosec // zigzag
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) string(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.bytes(s)
+}
+
+func (t *thriftWriter) bytes(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+// list starts a list field of n elements of type elem; i32 and string
+// elements follow with listI32 and bytes, structs with elem and end.
+func (t *thriftWriter) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elem)
+	t.buf = binary.AppendUvarint(t.buf, uint64(n))
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.varint(int64(v))
+}
+
+// begin starts a struct field; its fields follow, then end.
+func (t *thriftWriter) begin(id int16) {
+	t.field(id, thriftStruct)
+	t.elem()
+}
+
+// elem starts a struct element of a list; its fields follow, then end.
+func (t *thriftWriter) elem() {
+	t.last = append(t.last, 0)
+}
+
+// end closes the innermost struct.
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0)
+	t.last = t.last[:len(t.last)-1]
+}
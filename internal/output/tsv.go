@@ -0,0 +1,85 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// tsvEscaper escapes characters that would break tab-separated records.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// TSV formats results as tab-separated values with a header row.
+// Object rows are flattened to dot-delimited columns; columns are taken from
+// the first row so output streams without buffering. Non-object results are
+// printed one value per line without a header. Tabs, newlines and backslashes
+// inside values are backslash-escaped; no quoting is applied.
+func TSV(w io.Writer, iter RowIterator) error {
+	return writeRecords(iter, func(rec []string) error {
+		return writeTSVRecord(w, rec)
+	})
+}
+
+// writeRecords flattens rows into records for the delimited formats (TSV, CSV).
+// The first record is the header taken from the first row; non-object results
+// are emitted as single-value records without a header.
+func writeRecords(iter RowIterator, write func([]string) error) error {
+	p := peekable(iter)
+	first, err := p.Peek()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	record := func(row json.RawMessage) []string { return []string{compactValue(row)} }
+	if fields, err := flattenObject(first); err == nil {
+		cols := make([]string, len(fields))
+		for i, f := range fields {
+			cols[i] = f.key
+		}
+		if err := write(cols); err != nil {
+			return err
+		}
+		record = func(row json.RawMessage) []string { return flatRecord(cols, row) }
+	}
+	for {
+		row, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := write(record(row)); err != nil {
+			return err
+		}
+	}
+}
+
+// flatRecord returns the values of row for cols; non-object rows become a single value.
+func flatRecord(cols []string, row json.RawMessage) []string {
+	fields, err := flattenObject(row)
+	if err != nil {
+		return []string{compactValue(row)}
+	}
+	values := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		values[f.key] = f.value
+	}
+	rec := make([]string, len(cols))
+	for i, col := range cols {
+		rec[i] = compactValue(values[col])
+	}
+	return rec
+}
+
+func writeTSVRecord(w io.Writer, rec []string) error {
+	for i := range rec {
+		rec[i] = tsvEscaper.Replace(rec[i])
+	}
+	_, err := fmt.Fprintln(w, strings.Join(rec, "\t"))
+	return err
+}
@@ -0,0 +1,102 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTSV_HeaderAndRows(t *testing.T) {
+	t.Parallel()
+	iter := newIter(
+		`{"name":"alice","age":30}`,
+		`{"name":"bob","age":25}`,
+	)
+	var buf bytes.Buffer
+	if err := TSV(&buf, iter); err != nil {
+		t.Fatal(err)
+	}
+	want := "name\tage\nalice\t30\nbob\t25\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTSV_NestedFlattened(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`{"id":1,"address":{"city":"NYC"},"tags":["a","b"]}`)
+	var buf bytes.Buffer
+	if err := TSV(&buf, iter); err != nil {
+		t.Fatal(err)
+	}
+	want := "id\taddress.city\ttags\n1\tNYC\t[\"a\",\"b\"]\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTSV_Escaping(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`{"text":"a\tb\nc\\d"}`)
+	var buf bytes.Buffer
+	if err := TSV(&buf, iter); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	if lines[1] != `a\tb\nc\\d` {
+		t.Errorf("got %q, want %q", lines[1], `a\tb\nc\\d`)
+	}
+}
+
+func TestTSV_MissingAndExtraFields(t *testing.T) {
+	t.Parallel()
+	// columns come from the first row; missing values are empty, extra keys dropped
+	iter := newIter(`{"a":1,"b":2}`, `{"a":3,"c":4}`, `{"b":null}`)
+	var buf bytes.Buffer
+	if err := TSV(&buf, iter); err != nil {
+		t.Fatal(err)
+	}
+	want := "a\tb\n1\t2\n3\t\n\t\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTSV_NonObjectRows(t *testing.T) {
+	t.Parallel()
+	iter := newIter(`"users"`, `"posts"`, `42`)
+	var buf bytes.Buffer
+	if err := TSV(&buf, iter); err != nil {
+		t.Fatal(err)
+	}
+	want := "users\nposts\n42\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTSV_Empty(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := TSV(&buf, newIter()); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestTSV_IteratorError(t *testing.T) {
+	t.Parallel()
+	errStream := errors.New("stream error")
+	iter := &mockIter{items: []json.RawMessage{json.RawMessage(`{"a":1}`)}, err: errStream}
+	var buf bytes.Buffer
+	if err := TSV(&buf, iter); !errors.Is(err, errStream) {
+		t.Errorf("expected stream error, got %v", err)
+	}
+}
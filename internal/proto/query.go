@@ -1,5 +1,7 @@
 package proto
 
+import "strconv"
+
 // QueryType identifies the type of query sent to the server.
 type QueryType int
 
@@ -10,3 +12,19 @@ const (
 	QueryNoreplyWait QueryType = 4
 	QueryServerInfo  QueryType = 5
 )
+
+var queryTypeNames = map[QueryType]string{
+	QueryStart:       "START",
+	QueryContinue:    "CONTINUE",
+	QueryStop:        "STOP",
+	QueryNoreplyWait: "NOREPLY_WAIT",
+	QueryServerInfo:  "SERVER_INFO",
+}
+
+// String returns the protocol name of the query type, or its number.
+func (q QueryType) String() string {
+	if name, ok := queryTypeNames[q]; ok {
+		return name
+	}
+	return strconv.Itoa(int(q))
+}
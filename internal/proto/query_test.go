@@ -24,3 +24,13 @@ func TestQueryTypeConstants(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryTypeString(t *testing.T) {
+	t.Parallel()
+	if got := QueryNoreplyWait.String(); got != "NOREPLY_WAIT" {
+		t.Errorf("QueryNoreplyWait = %q", got)
+	}
+	if got := QueryType(9).String(); got != "9" {
+		t.Errorf("unknown type = %q, want 9", got)
+	}
+}
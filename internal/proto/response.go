@@ -1,5 +1,7 @@
 package proto
 
+import "strconv"
+
 // ResponseType identifies the type of response from the server.
 type ResponseType int
 
@@ -14,6 +16,25 @@ const (
 	ResponseRuntimeError    ResponseType = 18
 )
 
+var responseTypeNames = map[ResponseType]string{
+	ResponseSuccessAtom:     "SUCCESS_ATOM",
+	ResponseSuccessSequence: "SUCCESS_SEQUENCE",
+	ResponseSuccessPartial:  "SUCCESS_PARTIAL",
+	ResponseWaitComplete:    "WAIT_COMPLETE",
+	ResponseServerInfo:      "SERVER_INFO",
+	ResponseClientError:     "CLIENT_ERROR",
+	ResponseCompileError:    "COMPILE_ERROR",
+	ResponseRuntimeError:    "RUNTIME_ERROR",
+}
+
+// String returns the protocol name of the response type, or its number.
+func (r ResponseType) String() string {
+	if name, ok := responseTypeNames[r]; ok {
+		return name
+	}
+	return strconv.Itoa(int(r))
+}
+
 // IsError reports whether the response type represents an error condition (types >= 16).
 func (r ResponseType) IsError() bool {
 	return r >= ResponseClientError
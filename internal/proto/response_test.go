@@ -102,3 +102,13 @@ func TestResponseTypeIsError(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseTypeString(t *testing.T) {
+	t.Parallel()
+	if got := ResponseSuccessPartial.String(); got != "SUCCESS_PARTIAL" {
+		t.Errorf("ResponseSuccessPartial = %q", got)
+	}
+	if got := ResponseType(7).String(); got != "7" {
+		t.Errorf("unknown type = %q, want 7", got)
+	}
+}
@@ -7,7 +7,8 @@ type Version uint32
 const (
 	// V1_0 uses SCRAM-SHA-256 authentication (current).
 	V1_0 Version = 0x34c2bdc3
-	// V0_4 is a legacy protocol version.
+	// V0_4 authenticates with a plain auth key; servers before 2.3 speak
+	// only this version.
 	V0_4 Version = 0x400c2d20
 	// V0_3 is a legacy protocol version.
 	V0_3 Version = 0x5f75e83e
@@ -16,3 +17,10 @@ const (
 	// V0_1 is the initial protocol version.
 	V0_1 Version = 0x3f61ba36
 )
+
+// Protocol selects the query encoding after a V0_3/V0_4 handshake.
+// Sent as a 4-byte little-endian magic number after the auth key.
+type Protocol uint32
+
+// ProtocolJSON is the JSON query encoding, the only one r-cli speaks.
+const ProtocolJSON Protocol = 0x7e6970c7
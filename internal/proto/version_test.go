@@ -24,3 +24,10 @@ func TestVersionConstants(t *testing.T) {
 		})
 	}
 }
+
+func TestProtocolJSON(t *testing.T) {
+	t.Parallel()
+	if ProtocolJSON != 0x7e6970c7 {
+		t.Errorf("ProtocolJSON = 0x%08x, want 0x7e6970c7", ProtocolJSON)
+	}
+}
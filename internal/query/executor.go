@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"r-cli/internal/conn"
 	"r-cli/internal/connmgr"
@@ -15,7 +17,13 @@ import (
 
 // Executor executes ReQL queries via a managed connection.
 type Executor struct {
-	mgr *connmgr.ConnManager
+	mu         sync.RWMutex // guards mgr, defaults and cursorOpts
+	mgr        *connmgr.ConnManager
+	defaults   reql.OptArgs
+	cursorOpts cursor.Options
+
+	cursorsMu sync.Mutex
+	cursors   map[*trackedCursor]struct{} // open streams and changefeeds
 }
 
 // New creates an Executor backed by the given connection manager.
@@ -23,60 +31,171 @@ func New(mgr *connmgr.ConnManager) *Executor {
 	return &Executor{mgr: mgr}
 }
 
+// SetManager makes later queries use mgr. Cursors already open keep the
+// connection they were started on; the caller closes the old manager.
+func (e *Executor) SetManager(mgr *connmgr.ConnManager) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mgr = mgr
+}
+
+func (e *Executor) manager() *connmgr.ConnManager {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.mgr
+}
+
+// SetDefaults sets the global optargs sent with every query; optargs passed
+// to Run override them key by key.
+func (e *Executor) SetDefaults(opts reql.OptArgs) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.defaults = opts
+}
+
+// SetCursorOptions sets how the cursors returned by Run fetch batches and
+// render pseudo-types.
+func (e *Executor) SetCursorOptions(opts cursor.Options) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cursorOpts = opts
+}
+
+// session wraps c in a Session carrying the current defaults.
+func (e *Executor) session(c *conn.Conn) *conn.Session {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return conn.NewSession(c, e.defaults)
+}
+
 // Run executes a ReQL term and returns profile data, a cursor over the results, and any error.
 // Profile is non-nil only when the server returns profiling data (opts["profile"]=true).
 // If opts contains "noreply": true, the query is sent without waiting for a
 // response and Run returns (nil, nil, nil).
 func (e *Executor) Run(ctx context.Context, term reql.Term, opts reql.OptArgs) (json.RawMessage, cursor.Cursor, error) {
-	c, err := e.mgr.Get(ctx)
+	c, token, resp, err := e.start(ctx, term, opts)
+	if err != nil || resp == nil {
+		return nil, nil, err
+	}
+	cur, err := makeCursor(ctx, c, token, resp, e.cursorOptions())
 	if err != nil {
 		return nil, nil, err
 	}
-	if opts == nil {
-		opts = reql.OptArgs{}
+	return resp.Profile, e.track(cur, resp), nil
+}
+
+// RunFeed is Run for changefeeds: when the feed drops, the cursor reissues
+// the query as configured by r, whose Reopen defaults to Reopen(term, opts).
+// A result that is not a changefeed gets the cursor Run would return.
+func (e *Executor) RunFeed(ctx context.Context, term reql.Term, opts reql.OptArgs, r cursor.Resume) (cursor.Cursor, error) {
+	c, token, resp, err := e.start(ctx, term, opts)
+	if err != nil || resp == nil {
+		return nil, err
 	}
-	payload, err := reql.BuildQuery(proto.QueryStart, term, opts)
-	if err != nil {
-		return nil, nil, fmt.Errorf("query: build: %w", err)
+	copts := e.cursorOptions()
+	if resp.Type != proto.ResponseSuccessPartial || !isFeed(resp) {
+		cur, err := makeCursor(ctx, c, token, resp, copts)
+		if err != nil {
+			return nil, err
+		}
+		return e.track(cur, resp), nil
 	}
-	if v, _ := opts["noreply"].(bool); v {
-		return nil, nil, c.WriteFrame(c.NextToken(), payload)
+	if r.Reopen == nil {
+		r.Reopen = e.Reopen(term, opts)
+	}
+	cur := cursor.NewResumable(ctx, feedOf(ctx, c, token, resp), r, copts)
+	return e.track(cursor.Convert(cur, copts.Convert), resp), nil
+}
+
+// Reopen returns a cursor.Resume.Reopen func that runs term as a new
+// changefeed.
+func (e *Executor) Reopen(term reql.Term, opts reql.OptArgs) func(context.Context) (cursor.Feed, error) {
+	return func(ctx context.Context) (cursor.Feed, error) {
+		c, token, resp, err := e.start(ctx, term, opts)
+		if err != nil {
+			return cursor.Feed{}, err
+		}
+		if resp == nil || resp.Type != proto.ResponseSuccessPartial {
+			return cursor.Feed{}, fmt.Errorf("query: reopened query is not a changefeed")
+		}
+		return feedOf(ctx, c, token, resp), nil
 	}
-	token := c.NextToken()
-	raw, err := c.Send(ctx, token, payload)
+}
+
+// start sends term and returns the connection, token and first response;
+// the response is nil for a noreply query.
+func (e *Executor) start(ctx context.Context, term reql.Term, opts reql.OptArgs) (*conn.Conn, uint64, *response.Response, error) {
+	c, err := e.manager().Get(ctx)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	token, raw, err := e.session(c).Start(ctx, term, opts)
 	if err != nil {
-		return nil, nil, fmt.Errorf("query: send: %w", err)
+		return nil, 0, nil, fmt.Errorf("query: %w", err)
+	}
+	if raw == nil {
+		return nil, 0, nil, nil
 	}
 	resp, err := response.Parse(raw)
 	if err != nil {
-		return nil, nil, fmt.Errorf("query: response: %w", err)
+		return nil, 0, nil, fmt.Errorf("query: response: %w", err)
 	}
 	if err := response.MapError(resp); err != nil {
-		return nil, nil, err
+		return nil, 0, nil, err
+	}
+	return c, token, resp, nil
+}
+
+func (e *Executor) cursorOptions() cursor.Options {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.cursorOpts
+}
+
+// NoreplyWait waits until every open connection has processed the noreply
+// queries sent on it; with no open connection there is nothing to wait for.
+func (e *Executor) NoreplyWait(ctx context.Context) error {
+	for _, c := range e.manager().Conns() {
+		if err := c.NoreplyWait(ctx); err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+	}
+	return nil
+}
+
+// makeCursor selects the appropriate cursor type for the response and
+// applies opts.Convert to its rows.
+func makeCursor(ctx context.Context, c *conn.Conn, token uint64, resp *response.Response, opts cursor.Options) (cursor.Cursor, error) {
+	cur, err := newCursor(ctx, c, token, resp, opts)
+	if err != nil {
+		return nil, err
 	}
-	cur, err := makeCursor(ctx, c, token, resp)
-	return resp.Profile, cur, err
+	return cursor.Convert(cur, opts.Convert), nil
 }
 
-// makeCursor selects the appropriate cursor type for the response.
-func makeCursor(ctx context.Context, c *conn.Conn, token uint64, resp *response.Response) (cursor.Cursor, error) {
+func newCursor(ctx context.Context, c *conn.Conn, token uint64, resp *response.Response, opts cursor.Options) (cursor.Cursor, error) {
 	switch resp.Type {
 	case proto.ResponseSuccessAtom:
 		return cursor.NewAtom(resp), nil
 	case proto.ResponseSuccessSequence:
 		return cursor.NewSequence(resp), nil
 	case proto.ResponseSuccessPartial:
-		ch := make(chan *response.Response, 1)
-		send := makeSend(ctx, c, token, ch)
+		f := feedOf(ctx, c, token, resp)
 		if isFeed(resp) {
-			return cursor.NewChangefeed(ctx, resp, ch, send), nil
+			return cursor.NewChangefeed(ctx, f.Initial, f.Ch, f.Send, opts), nil
 		}
-		return cursor.NewStream(ctx, resp, ch, send), nil
+		return cursor.NewStream(ctx, f.Initial, f.Ch, f.Send, opts), nil
 	default:
 		return nil, fmt.Errorf("query: unexpected response type %d", resp.Type)
 	}
 }
 
+// feedOf wires the batches of token after resp to a channel for a cursor.
+func feedOf(ctx context.Context, c *conn.Conn, token uint64, resp *response.Response) cursor.Feed {
+	ch := make(chan *response.Response, 1)
+	return cursor.Feed{Initial: resp, Ch: ch, Send: makeSend(ctx, c, token, ch), Dropped: c.IsClosed}
+}
+
 // makeSend builds the send function for streaming cursors.
 // CONTINUE spawns a goroutine to fetch the next batch asynchronously.
 // STOP writes the stop frame without waiting for a response.
@@ -136,37 +255,79 @@ func isFeed(resp *response.Response) bool {
 }
 
 // ServerInfo holds information about the connected RethinkDB server.
-type ServerInfo struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-}
+type ServerInfo = conn.ServerInfo
 
 // ServerInfo returns information about the connected server.
 func (e *Executor) ServerInfo(ctx context.Context) (*ServerInfo, error) {
-	c, err := e.mgr.Get(ctx)
+	c, err := e.manager().Get(ctx)
 	if err != nil {
 		return nil, err
 	}
-	token := c.NextToken()
-	raw, err := c.Send(ctx, token, []byte(`[5]`))
+	info, err := c.ServerInfo(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("query: server info: %w", err)
+		return nil, fmt.Errorf("query: %w", err)
 	}
-	resp, err := response.Parse(raw)
+	return info, nil
+}
+
+// PingResult holds the outcome of a successful Ping.
+type PingResult struct {
+	ServerVersion string        // server_version reported during the handshake
+	Connect       time.Duration // connection setup including the handshake
+	RoundTrip     time.Duration // r.expr(1) query round trip
+}
+
+// Ping connects (if needed), runs r.expr(1) and verifies the server echoes 1.
+func (e *Executor) Ping(ctx context.Context) (*PingResult, error) {
+	start := time.Now()
+	c, err := e.manager().Get(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("query: server info response: %w", err)
+		return nil, err
 	}
-	if resp.Type != proto.ResponseServerInfo {
-		return nil, fmt.Errorf("query: unexpected response type %d", resp.Type)
+	res := &PingResult{ServerVersion: c.ServerVersion(), Connect: time.Since(start)}
+
+	start = time.Now()
+	_, cur, err := e.Run(ctx, reql.Datum(1), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cur.Close() }()
+	item, err := cur.Next()
+	if err != nil {
+		return nil, fmt.Errorf("query: ping: %w", err)
 	}
-	if len(resp.Results) == 0 {
-		return nil, fmt.Errorf("query: empty server info response")
+	res.RoundTrip = time.Since(start)
+	if string(item) != "1" {
+		return nil, fmt.Errorf("query: ping: unexpected result %s", item)
 	}
-	var info ServerInfo
-	if err := json.Unmarshal(resp.Results[0], &info); err != nil {
-		return nil, fmt.Errorf("query: parse server info: %w", err)
+	return res, nil
+}
+
+// Health is a snapshot of the executor's connections and open queries.
+type Health struct {
+	Conns         int    // open connections; none before the first query
+	ServerVersion string // server_version of the first open connection
+	OpenCursors   int    // streams and changefeeds still open
+	OpenFeeds     int    // the changefeeds among OpenCursors
+}
+
+// Health reports the state of the connections, without dialing.
+func (e *Executor) Health() Health {
+	h := Health{OpenCursors: e.OpenCursors(), OpenFeeds: e.openFeeds()}
+	mgr := e.manager()
+	if mgr == nil {
+		return h
+	}
+	for _, c := range mgr.Conns() {
+		if c.IsClosed() {
+			continue
+		}
+		if h.Conns == 0 {
+			h.ServerVersion = c.ServerVersion()
+		}
+		h.Conns++
 	}
-	return &info, nil
+	return h
 }
 
 // errResp wraps a transport error into a CLIENT_ERROR response so streaming
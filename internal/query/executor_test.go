@@ -10,11 +10,13 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"r-cli/internal/conn"
 	"r-cli/internal/connmgr"
+	"r-cli/internal/cursor"
 	"r-cli/internal/reql"
 	"r-cli/internal/scram"
 	"r-cli/internal/wire"
@@ -239,6 +241,36 @@ func TestExecutorRunWithDBOption(t *testing.T) {
 	}
 }
 
+func TestExecutorRunWithDefaults(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	received := make(chan []byte, 2)
+	handler := func(nc net.Conn, token uint64, payload []byte) {
+		received <- payload
+		sendResponse(nc, token, seqResp(nil))
+	}
+	addr, stop := startQueryServer(t, pass, handler)
+	defer stop()
+
+	ex := newTestExecutor(t, addr, pass)
+	ex.SetDefaults(reql.OptArgs{"db": "app", "read_mode": "majority"})
+	for _, opts := range []reql.OptArgs{nil, {"db": "other"}} {
+		if _, _, err := ex.Run(context.Background(), reql.Table("users"), opts); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	}
+	for _, want := range []string{`{"db":[14,["app"]],"read_mode":"majority"}`, `{"db":[14,["other"]],"read_mode":"majority"}`} {
+		select {
+		case payload := <-received:
+			if !strings.HasSuffix(string(payload), want+"]") {
+				t.Errorf("payload %s, want optargs %s", payload, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("server did not receive query within 1s")
+		}
+	}
+}
+
 func TestExecutorRunWithTimeout(t *testing.T) {
 	t.Parallel()
 	const pass = "testpass"
@@ -269,8 +301,9 @@ func TestExecutorServerInfo(t *testing.T) {
 			"t": 5, // ResponseServerInfo
 			"r": []interface{}{
 				map[string]interface{}{
-					"id":   "some-uuid-1234",
-					"name": "test-server",
+					"id":    "some-uuid-1234",
+					"name":  "test-server",
+					"proxy": true,
 				},
 			},
 		})
@@ -289,6 +322,12 @@ func TestExecutorServerInfo(t *testing.T) {
 	if info.Name != "test-server" {
 		t.Errorf("Name: got %q, want %q", info.Name, "test-server")
 	}
+	if !info.Proxy {
+		t.Error("Proxy: got false, want true")
+	}
+	if info.Version != "2.3.0" {
+		t.Errorf("Version: got %q, want %q", info.Version, "2.3.0")
+	}
 }
 
 func TestExecutorRunWithNoreply(t *testing.T) {
@@ -318,6 +357,40 @@ func TestExecutorRunWithNoreply(t *testing.T) {
 	}
 }
 
+func TestExecutorNoreplyWait(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	var mu sync.Mutex
+	var got []string
+	handler := func(nc net.Conn, token uint64, payload []byte) {
+		mu.Lock()
+		got = append(got, string(payload))
+		mu.Unlock()
+		if string(payload) == "[4]" {
+			sendResponse(nc, token, map[string]interface{}{"t": 4, "r": []interface{}{}})
+		}
+	}
+	addr, stop := startQueryServer(t, pass, handler)
+	defer stop()
+
+	ex := newTestExecutor(t, addr, pass)
+	// nothing connected yet: nothing to wait for
+	if err := ex.NoreplyWait(context.Background()); err != nil {
+		t.Fatalf("NoreplyWait without connection: %v", err)
+	}
+	if _, _, err := ex.Run(context.Background(), reql.Datum(1), reql.OptArgs{"noreply": true}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := ex.NoreplyWait(context.Background()); err != nil {
+		t.Fatalf("NoreplyWait: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 || got[1] != "[4]" {
+		t.Errorf("server saw %q, want the query then [4]", got)
+	}
+}
+
 func TestExecutorRunGetsAtom(t *testing.T) {
 	t.Parallel()
 	const pass = "testpass"
@@ -346,6 +419,31 @@ func TestExecutorRunGetsAtom(t *testing.T) {
 	}
 }
 
+func TestExecutorSetManager(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	serve := func(v int) queryHandler {
+		return func(nc net.Conn, token uint64, _ []byte) {
+			sendResponse(nc, token, map[string]interface{}{"t": 1, "r": []interface{}{v}})
+		}
+	}
+	first, stopFirst := startQueryServer(t, pass, serve(1))
+	defer stopFirst()
+	second, stopSecond := startQueryServer(t, pass, serve(2))
+	defer stopSecond()
+
+	ex := newTestExecutor(t, first, pass)
+	ex.SetManager(newTestExecutor(t, second, pass).manager())
+	_, cur, err := ex.Run(context.Background(), reql.Datum(0), nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	defer func() { _ = cur.Close() }()
+	if row, err := cur.Next(); err != nil || string(row) != "2" {
+		t.Errorf("Next = %s, %v; want the second server's 2", row, err)
+	}
+}
+
 func TestExecutorRunServerError(t *testing.T) {
 	t.Parallel()
 	const pass = "testpass"
@@ -388,3 +486,147 @@ func TestExecutorRunReturnsProfile(t *testing.T) {
 		t.Fatal("expected non-empty profile, got nil")
 	}
 }
+
+func TestExecutorPing(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	received := make(chan []byte, 1)
+	handler := func(nc net.Conn, token uint64, payload []byte) {
+		received <- payload
+		sendResponse(nc, token, map[string]interface{}{"t": 1, "r": []interface{}{1}})
+	}
+	addr, stop := startQueryServer(t, pass, handler)
+	defer stop()
+
+	ex := newTestExecutor(t, addr, pass)
+	res, err := ex.Ping(context.Background())
+	if err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if res.ServerVersion != "2.3.0" {
+		t.Errorf("ServerVersion: got %q, want 2.3.0", res.ServerVersion)
+	}
+	if got := string(<-received); got != `[1,1,{}]` {
+		t.Errorf("query payload: got %s, want [1,1,{}]", got)
+	}
+}
+
+func TestExecutorPingUnexpectedResult(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	handler := func(nc net.Conn, token uint64, _ []byte) {
+		sendResponse(nc, token, map[string]interface{}{"t": 1, "r": []interface{}{2}})
+	}
+	addr, stop := startQueryServer(t, pass, handler)
+	defer stop()
+
+	ex := newTestExecutor(t, addr, pass)
+	if _, err := ex.Ping(context.Background()); err == nil {
+		t.Fatal("expected error for unexpected ping result")
+	}
+}
+
+func TestExecutorRunPrefetch(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	continues := make(chan struct{}, 4)
+	var mu sync.Mutex
+	batch := 0
+	handler := func(nc net.Conn, token uint64, payload []byte) {
+		if string(payload) == "[2]" {
+			continues <- struct{}{}
+		}
+		mu.Lock()
+		batch++
+		n := batch
+		mu.Unlock()
+		typ := 3 // SUCCESS_PARTIAL
+		if n == 3 {
+			typ = 2
+		}
+		sendResponse(nc, token, map[string]interface{}{"t": typ, "r": []interface{}{n}})
+	}
+	addr, stop := startQueryServer(t, pass, handler)
+	defer stop()
+
+	ex := newTestExecutor(t, addr, pass)
+	ex.SetCursorOptions(cursor.Options{Prefetch: 1})
+	_, cur, err := ex.Run(context.Background(), reql.DB("test").Table("users"), nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	defer func() { _ = cur.Close() }()
+
+	if row, err := cur.Next(); err != nil || string(row) != "1" {
+		t.Fatalf("Next = %s, %v", row, err)
+	}
+	select {
+	case <-continues:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no CONTINUE sent while the first batch was being consumed")
+	}
+	rest, err := cur.All()
+	if err != nil || len(rest) != 2 || string(rest[1]) != "3" {
+		t.Fatalf("All = %s, %v", rest, err)
+	}
+}
+
+func TestExecutorRunFeedReopens(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	var mu sync.Mutex
+	starts := 0
+	handler := func(nc net.Conn, token uint64, payload []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasPrefix(string(payload), "[1,"):
+			starts++
+			sendResponse(nc, token, map[string]interface{}{"t": 3, "r": []interface{}{starts}, "n": []interface{}{1}})
+		case string(payload) == "[2]" && starts == 1:
+			_ = nc.Close() // drop the first feed
+		}
+	}
+	addr, stop := startQueryServer(t, pass, handler)
+	defer stop()
+
+	ex := newTestExecutor(t, addr, pass)
+	var events []cursor.ResumeEvent
+	cur, err := ex.RunFeed(context.Background(), reql.DB("test").Table("users").Changes(), nil, cursor.Resume{
+		Backoff: time.Millisecond,
+		Notify:  func(ev cursor.ResumeEvent) { events = append(events, ev) },
+	})
+	if err != nil {
+		t.Fatalf("RunFeed: %v", err)
+	}
+	defer func() { _ = cur.Close() }()
+
+	for _, want := range []string{"1", "2"} {
+		if row, err := cur.Next(); err != nil || string(row) != want {
+			t.Fatalf("Next = %s, %v; want %s", row, err, want)
+		}
+	}
+	if len(events) != 1 || events[0].Err != nil || events[0].Cause == nil {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestExecutorRunFeedNotFeed(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	handler := func(nc net.Conn, token uint64, _ []byte) {
+		sendResponse(nc, token, seqResp([]interface{}{1, 2}))
+	}
+	addr, stop := startQueryServer(t, pass, handler)
+	defer stop()
+
+	ex := newTestExecutor(t, addr, pass)
+	cur, err := ex.RunFeed(context.Background(), reql.DB("test").Table("users"), nil, cursor.Resume{})
+	if err != nil {
+		t.Fatalf("RunFeed: %v", err)
+	}
+	rows, err := cur.All()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("All = %s, %v", rows, err)
+	}
+}
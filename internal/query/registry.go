@@ -0,0 +1,85 @@
+package query
+
+import (
+	"r-cli/internal/cursor"
+	"r-cli/internal/proto"
+	"r-cli/internal/response"
+)
+
+// trackedCursor is a returned cursor whose query is still open on the
+// server; it stays in the executor's registry until closed.
+type trackedCursor struct {
+	cursor.Cursor
+	e    *Executor
+	feed bool // a changefeed rather than a stream
+}
+
+func (t *trackedCursor) Close() error {
+	t.e.untrack(t)
+	return t.Cursor.Close()
+}
+
+// Stats reports the stats of the wrapped stream or changefeed cursor.
+func (t *trackedCursor) Stats() cursor.Stats {
+	if sr, ok := t.Cursor.(cursor.StatsReporter); ok {
+		return sr.Stats()
+	}
+	return cursor.Stats{}
+}
+
+// track registers cur when resp leaves its query open on the server, i.e.
+// for streams and changefeeds; atoms and sequences are returned as is.
+func (e *Executor) track(cur cursor.Cursor, resp *response.Response) cursor.Cursor {
+	if resp.Type != proto.ResponseSuccessPartial {
+		return cur
+	}
+	t := &trackedCursor{Cursor: cur, e: e, feed: isFeed(resp)}
+	e.cursorsMu.Lock()
+	defer e.cursorsMu.Unlock()
+	if e.cursors == nil {
+		e.cursors = make(map[*trackedCursor]struct{})
+	}
+	e.cursors[t] = struct{}{}
+	return t
+}
+
+func (e *Executor) untrack(t *trackedCursor) {
+	e.cursorsMu.Lock()
+	defer e.cursorsMu.Unlock()
+	delete(e.cursors, t)
+}
+
+// OpenCursors reports how many returned cursors still hold a query open.
+func (e *Executor) OpenCursors() int {
+	e.cursorsMu.Lock()
+	defer e.cursorsMu.Unlock()
+	return len(e.cursors)
+}
+
+// openFeeds reports how many of the open cursors are changefeeds.
+func (e *Executor) openFeeds() int {
+	e.cursorsMu.Lock()
+	defer e.cursorsMu.Unlock()
+	n := 0
+	for t := range e.cursors {
+		if t.feed {
+			n++
+		}
+	}
+	return n
+}
+
+// CloseCursors closes every cursor that is still open, which sends STOP for
+// its query, and returns how many there were. Call it before closing the
+// connection manager so interrupted iterations do not leave queries running
+// on the server.
+func (e *Executor) CloseCursors() int {
+	e.cursorsMu.Lock()
+	open := e.cursors
+	e.cursors = nil
+	e.cursorsMu.Unlock()
+	for t := range open {
+		_ = t.Cursor.Close()
+	}
+	return len(open)
+}
@@ -0,0 +1,106 @@
+package query
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"r-cli/internal/cursor"
+	"r-cli/internal/reql"
+)
+
+func TestExecutorCloseCursors(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	stops := make(chan uint64, 4)
+	handler := func(nc net.Conn, token uint64, payload []byte) {
+		switch {
+		case string(payload) == "[3]":
+			stops <- token
+			sendResponse(nc, token, seqResp(nil))
+		case strings.Contains(string(payload), `"atom"`):
+			sendResponse(nc, token, map[string]interface{}{"t": 1, "r": []interface{}{1}})
+		default:
+			sendResponse(nc, token, map[string]interface{}{"t": 3, "r": []interface{}{1}})
+		}
+	}
+	addr, stop := startQueryServer(t, pass, handler)
+	defer stop()
+
+	ex := newTestExecutor(t, addr, pass)
+	ctx := context.Background()
+	var curs []cursor.Cursor
+	for range 2 {
+		_, cur, err := ex.Run(ctx, reql.DB("test").Table("users"), nil)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		curs = append(curs, cur)
+	}
+	if _, _, err := ex.Run(ctx, reql.Datum("atom"), nil); err != nil {
+		t.Fatalf("Run atom: %v", err)
+	}
+	if n := ex.OpenCursors(); n != 2 {
+		t.Fatalf("OpenCursors = %d, want 2 (atoms are not tracked)", n)
+	}
+	if _, ok := curs[0].(cursor.StatsReporter); !ok {
+		t.Error("tracked stream cursor does not report stats")
+	}
+
+	_ = curs[0].Close()
+	<-stops
+	if n := ex.OpenCursors(); n != 1 {
+		t.Fatalf("OpenCursors after Close = %d, want 1", n)
+	}
+	if n := ex.CloseCursors(); n != 1 {
+		t.Errorf("CloseCursors = %d, want 1", n)
+	}
+	select {
+	case <-stops:
+	case <-time.After(2 * time.Second):
+		t.Fatal("CloseCursors sent no STOP")
+	}
+	if n := ex.OpenCursors(); n != 0 {
+		t.Errorf("OpenCursors after CloseCursors = %d", n)
+	}
+	_ = curs[1].Close() // closing again is harmless
+}
+
+func TestExecutorHealth(t *testing.T) {
+	t.Parallel()
+	const pass = "testpass"
+	handler := func(nc net.Conn, token uint64, payload []byte) {
+		switch {
+		case strings.Contains(string(payload), `"feed"`):
+			sendResponse(nc, token, map[string]interface{}{"t": 3, "r": []interface{}{1}, "n": []interface{}{1}})
+		case strings.HasPrefix(string(payload), "[1,"):
+			sendResponse(nc, token, map[string]interface{}{"t": 3, "r": []interface{}{1}})
+		default:
+			sendResponse(nc, token, seqResp(nil))
+		}
+	}
+	addr, stop := startQueryServer(t, pass, handler)
+	defer stop()
+
+	ex := newTestExecutor(t, addr, pass)
+	if h := ex.Health(); h != (Health{}) {
+		t.Errorf("Health before any query = %+v", h)
+	}
+	ctx := context.Background()
+	for _, term := range []reql.Term{reql.Datum("feed"), reql.DB("test").Table("users")} {
+		_, cur, err := ex.Run(ctx, term, nil)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		defer func() { _ = cur.Close() }()
+	}
+	want := Health{Conns: 1, ServerVersion: "2.3.0", OpenCursors: 2, OpenFeeds: 1}
+	if h := ex.Health(); h != want {
+		t.Errorf("Health = %+v, want %+v", h, want)
+	}
+	if h := New(nil).Health(); h != (Health{}) {
+		t.Errorf("Health without a manager = %+v", h)
+	}
+}
@@ -0,0 +1,90 @@
+package repl
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"r-cli/internal/reql/parser"
+)
+
+// aliasCommand runs .alias: alone it lists the aliases, with a name it
+// shows one, and with a name and text it defines the name as a macro for
+// the text, expanded in later queries by expandAliases.
+func (r *Repl) aliasCommand(args string) {
+	name, text, _ := strings.Cut(args, " ")
+	name, text = strings.TrimSpace(name), strings.TrimSpace(text)
+	switch {
+	case name == "":
+		for _, n := range slices.Sorted(maps.Keys(r.aliases)) {
+			_, _ = fmt.Fprintf(r.errOut, "%s = %s\n", n, r.aliases[n])
+		}
+	case !varName.MatchString(name) || slices.Contains(reservedNames, name):
+		_, _ = fmt.Fprintf(r.errOut, ".alias: invalid name %q\n", name)
+	case text == "":
+		t, ok := r.aliases[name]
+		if !ok {
+			_, _ = fmt.Fprintf(r.errOut, ".alias: %s is not defined\n", name)
+			return
+		}
+		_, _ = fmt.Fprintf(r.errOut, "%s = %s\n", name, t)
+	default:
+		r.aliases[name] = text
+		r.persistAlias(name, text)
+	}
+}
+
+// unaliasCommand runs .unalias <name>.
+func (r *Repl) unaliasCommand(args []string) {
+	if len(args) != 1 {
+		_, _ = fmt.Fprintln(r.errOut, "usage: .unalias <name>")
+		return
+	}
+	if _, ok := r.aliases[args[0]]; !ok {
+		_, _ = fmt.Fprintf(r.errOut, ".unalias: %s is not defined\n", args[0])
+		return
+	}
+	delete(r.aliases, args[0])
+	r.persistAlias(args[0], "")
+}
+
+// persistAlias saves the alias for later sessions; an empty text removes it.
+// The alias stays in effect for this session when saving fails.
+func (r *Repl) persistAlias(name, text string) {
+	if r.saveAlias == nil {
+		return
+	}
+	if err := r.saveAlias(name, text); err != nil {
+		_, _ = fmt.Fprintf(r.errOut, "warning: alias not saved: %v\n", err)
+	}
+}
+
+// expandAliases replaces each identifier of expr that names an alias with
+// its text. Method names and string contents are left alone, and the
+// replaced text is not expanded again.
+func expandAliases(expr string, aliases map[string]string) string {
+	if len(aliases) == 0 {
+		return expr
+	}
+	rs := []rune(expr)
+	var sb strings.Builder
+	pos := 0
+	for _, sp := range parser.Scan(expr) {
+		if sp.Kind != parser.SpanIdent {
+			continue
+		}
+		text, ok := aliases[string(rs[sp.Start:sp.End])]
+		if !ok {
+			continue
+		}
+		sb.WriteString(string(rs[pos:sp.Start]))
+		sb.WriteString(text)
+		pos = sp.End
+	}
+	if pos == 0 {
+		return expr
+	}
+	sb.WriteString(string(rs[pos:]))
+	return sb.String()
+}
@@ -0,0 +1,105 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestExpandAliases(t *testing.T) {
+	t.Parallel()
+	aliases := map[string]string{"users": `r.db("app").table("users")`, "n": "10"}
+	cases := []struct{ in, want string }{
+		{"users", `r.db("app").table("users")`},
+		{"users.limit(n)", `r.db("app").table("users").limit(10)`},
+		{`r.table("users").count()`, `r.table("users").count()`},
+		{"r.table(\"a\").users()", "r.table(\"a\").users()"},
+		{"r.expr(usersx)", "r.expr(usersx)"},
+		{"r.expr([n, n])", "r.expr([10, 10])"},
+		{`r.expr("é").add(n)`, `r.expr("é").add(10)`},
+	}
+	for _, tc := range cases {
+		if got := expandAliases(tc.in, aliases); got != tc.want {
+			t.Errorf("expandAliases(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+	if got := expandAliases("users", nil); got != "users" {
+		t.Errorf("no aliases: got %q", got)
+	}
+}
+
+func TestReplAlias(t *testing.T) {
+	t.Parallel()
+	var errOut bytes.Buffer
+	var exprs, saved []string
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{
+			`.alias users r.db("app").table("users")`,
+			"users.count()",
+			".alias users",
+			".alias",
+			".unalias old",
+			"old",
+			".unalias old",
+		}},
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			exprs = append(exprs, expr)
+			return nil
+		},
+		Aliases: map[string]string{"old": "r.now()"},
+		SaveAlias: func(name, text string) error {
+			saved = append(saved, name+"="+text)
+			return nil
+		},
+		ErrOut: &errOut,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []string{`r.db("app").table("users").count()`, "old"}
+	if fmt.Sprint(exprs) != fmt.Sprint(want) {
+		t.Errorf("exprs = %q, want %q", exprs, want)
+	}
+	wantSaved := []string{`users=r.db("app").table("users")`, "old="}
+	if fmt.Sprint(saved) != fmt.Sprint(wantSaved) {
+		t.Errorf("saved = %q, want %q", saved, wantSaved)
+	}
+	wantErr := `users = r.db("app").table("users")` + "\n" +
+		"old = r.now()\n" + `users = r.db("app").table("users")` + "\n" +
+		".unalias: old is not defined\n"
+	if errOut.String() != wantErr {
+		t.Errorf("errOut = %q, want %q", errOut.String(), wantErr)
+	}
+	if r.last != "old" {
+		t.Errorf("last = %q, want the text as typed", r.last)
+	}
+}
+
+func TestReplAliasErrors(t *testing.T) {
+	t.Parallel()
+	cases := []struct{ line, want string }{
+		{".alias r r.now()", `invalid name "r"`},
+		{".alias a.b r.now()", `invalid name "a.b"`},
+		{".alias nope", "nope is not defined"},
+		{".unalias", "usage: .unalias"},
+		{".alias x r.now()", "warning: alias not saved: read-only"},
+	}
+	for _, tc := range cases {
+		var errOut bytes.Buffer
+		r := New(&Config{
+			Reader:    &fakeReader{lines: []string{tc.line}},
+			SaveAlias: func(string, string) error { return errors.New("read-only") },
+			ErrOut:    &errOut,
+		})
+		if err := r.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if !strings.Contains(errOut.String(), tc.want) {
+			t.Errorf("%s: errOut = %q, want %q", tc.line, errOut.String(), tc.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrBatchFailed is returned by Run in batch mode with ContinueOnError
+// when one or more queries failed; their errors have been printed.
+var ErrBatchFailed = errors.New("one or more queries failed")
+
+// batchReader reads a script for a Config.Batch run, one statement per
+// Readline: a dot-command is one line, and a query runs on until the line
+// that balances its brackets, or further while the next lines continue it
+// with a method call (".filter(...)"). Lines of "---", the separator of
+// query files, end a query.
+type batchReader struct {
+	sc     *bufio.Scanner
+	next   string // the line read ahead
+	peeked bool
+}
+
+// NewBatchReader creates a Reader that serves the statements of a script
+// read from r, for a REPL whose input is not a terminal. Prompts and
+// history are ignored.
+func NewBatchReader(r io.Reader) Reader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, bufio.MaxScanTokenSize), 64*1024*1024) // match max payload 64MB
+	return &batchReader{sc: sc}
+}
+
+func (b *batchReader) Readline() (string, error) {
+	var lines []string
+	for {
+		line, ok := b.peek()
+		if !ok {
+			if err := b.sc.Err(); err != nil {
+				return "", err
+			}
+			if len(lines) == 0 {
+				return "", io.EOF
+			}
+			return joinLines(lines), nil
+		}
+		trimmed := strings.TrimSpace(line)
+		if len(lines) > 0 && trimmed != "" && !chainLine.MatchString(trimmed) && isComplete(strings.Join(lines, "\n")) {
+			return joinLines(lines), nil
+		}
+		b.peeked = false
+		switch {
+		case trimmed == "---":
+			if len(lines) > 0 {
+				return joinLines(lines), nil
+			}
+		case len(lines) == 0 && trimmed == "":
+		case len(lines) == 0 && strings.HasPrefix(trimmed, ".") && !chainLine.MatchString(trimmed):
+			return trimmed, nil
+		default:
+			lines = append(lines, line)
+		}
+	}
+}
+
+// joinLines joins the lines of a statement, without the blank lines
+// after it.
+func joinLines(lines []string) string {
+	return strings.TrimRight(strings.Join(lines, "\n"), " \t\r\n")
+}
+
+// peek returns the next line without consuming it; false at the end of
+// the input.
+func (b *batchReader) peek() (string, bool) {
+	if !b.peeked {
+		if !b.sc.Scan() {
+			return "", false
+		}
+		b.next, b.peeked = b.sc.Text(), true
+	}
+	return b.next, true
+}
+
+func (b *batchReader) SetPrompt(string)        {}
+func (b *batchReader) AddHistory(string) error { return nil }
+func (b *batchReader) Close() error            { return nil }
@@ -0,0 +1,144 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readStatements(t *testing.T, script string) []string {
+	t.Helper()
+	br := NewBatchReader(strings.NewReader(script))
+	var stmts []string
+	for {
+		s, err := br.Readline()
+		if errors.Is(err, io.EOF) {
+			return stmts
+		}
+		if err != nil {
+			t.Fatalf("Readline: %v", err)
+		}
+		stmts = append(stmts, s)
+	}
+}
+
+func TestBatchReader(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"lines", "r.now()\n\nr.dbList()\n", []string{"r.now()", "r.dbList()"}},
+		{"chain", "r.table(\"a\")\n  .filter({x: 1})\n  .count()\nr.now()", []string{"r.table(\"a\")\n  .filter({x: 1})\n  .count()", "r.now()"}},
+		{"brackets", "r.table(\"a\").insert({\n  x: 1\n})\n", []string{"r.table(\"a\").insert({\n  x: 1\n})"}},
+		{"dot-commands", ".use test\n  r.table(\"a\")\n.format json\n", []string{".use test", "  r.table(\"a\")", ".format json"}},
+		{"separators", "r.table(\n---\nr.now()\n---\n", []string{"r.table(", "r.now()"}},
+		{"incomplete", "r.now()\nr.table(", []string{"r.now()", "r.table("}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := readStatements(t, tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func runBatch(t *testing.T, script string, keepGoing bool) ([]string, string, error) {
+	t.Helper()
+	var errOut bytes.Buffer
+	var exprs []string
+	r := New(&Config{
+		Reader: NewBatchReader(strings.NewReader(script)),
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			exprs = append(exprs, expr)
+			if strings.HasPrefix(expr, "bad") || strings.HasSuffix(expr, "(") {
+				return fmt.Errorf("cannot parse %s", expr)
+			}
+			return nil
+		},
+		ErrOut:          &errOut,
+		Batch:           true,
+		ContinueOnError: keepGoing,
+	})
+	err := r.Run(context.Background())
+	return exprs, errOut.String(), err
+}
+
+func TestReplBatchStopsOnError(t *testing.T) {
+	t.Parallel()
+	exprs, errOut, err := runBatch(t, "r.now()\nbad1\nr.dbList()\n", false)
+	if err == nil || err.Error() != "cannot parse bad1" {
+		t.Fatalf("err = %v, want the first query error", err)
+	}
+	if !reflect.DeepEqual(exprs, []string{"r.now()", "bad1"}) {
+		t.Errorf("exprs = %q", exprs)
+	}
+	if errOut != "" {
+		t.Errorf("errOut = %q, want the error left to the caller", errOut)
+	}
+}
+
+func TestReplBatchContinueOnError(t *testing.T) {
+	t.Parallel()
+	exprs, errOut, err := runBatch(t, "bad1\nr.now()\nbad2\n", true)
+	if !errors.Is(err, ErrBatchFailed) {
+		t.Fatalf("err = %v, want ErrBatchFailed", err)
+	}
+	if len(exprs) != 3 {
+		t.Errorf("exprs = %q, want all three", exprs)
+	}
+	if errOut != "cannot parse bad1\ncannot parse bad2\n" {
+		t.Errorf("errOut = %q", errOut)
+	}
+}
+
+func TestReplBatchIncompleteQuery(t *testing.T) {
+	t.Parallel()
+	exprs, _, err := runBatch(t, "r.now()\nr.table(\n", false)
+	if err == nil || !strings.Contains(err.Error(), "r.table(") {
+		t.Fatalf("err = %v, want the incomplete query to fail", err)
+	}
+	if len(exprs) != 2 {
+		t.Errorf("exprs = %q", exprs)
+	}
+}
+
+func TestReplBatchExit(t *testing.T) {
+	t.Parallel()
+	exprs, _, err := runBatch(t, "bad1\n.exit\nr.now()\n", true)
+	if !errors.Is(err, ErrBatchFailed) {
+		t.Fatalf("err = %v, want ErrBatchFailed", err)
+	}
+	if len(exprs) != 1 {
+		t.Errorf("exprs = %q, want nothing after .exit", exprs)
+	}
+}
+
+func TestReplBatchCancelled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	var exprs []string
+	r := New(&Config{
+		Reader: NewBatchReader(strings.NewReader("r.now()\nr.dbList()\n")),
+		Exec: func(ctx context.Context, expr string, _ io.Writer) error {
+			exprs = append(exprs, expr)
+			cancel()
+			return ctx.Err()
+		},
+		Batch: true,
+	})
+	if err := r.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if len(exprs) != 1 {
+		t.Errorf("exprs = %q", exprs)
+	}
+}
@@ -2,9 +2,12 @@ package repl
 
 import (
 	"context"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
+
+	"r-cli/internal/reql/parser"
 )
 
 // TabCompleter is implemented by types that provide readline tab completion.
@@ -13,12 +16,16 @@ type TabCompleter interface {
 }
 
 // Completer provides ReQL tab completion for the REPL.
-// FetchDBs and FetchTables are optional; if nil, dynamic completion is disabled.
+// FetchDBs, FetchTables and FetchIndexes are optional; if nil, the matching
+// dynamic completion is disabled. An empty db means the current database.
+// Names are fetched once and cached until Refresh.
 type Completer struct {
-	FetchDBs    func(ctx context.Context) ([]string, error)
-	FetchTables func(ctx context.Context, db string) ([]string, error)
-	mu          sync.RWMutex
-	currentDB   string
+	FetchDBs     func(ctx context.Context) ([]string, error)
+	FetchTables  func(ctx context.Context, db string) ([]string, error)
+	FetchIndexes func(ctx context.Context, db, table string) ([]string, error)
+	mu           sync.RWMutex
+	currentDB    string
+	cache        map[string][]string // fetched names by schemaKey
 }
 
 // SetCurrentDB updates the current database used for table name completion.
@@ -29,41 +36,37 @@ func (c *Completer) SetCurrentDB(db string) {
 	c.mu.Unlock()
 }
 
+// Refresh drops the cached database, table and index names so the next
+// completion fetches them again. Safe to call concurrently with tab completion.
+func (c *Completer) Refresh() {
+	c.mu.Lock()
+	c.cache = nil
+	c.mu.Unlock()
+}
+
 func (c *Completer) getDB() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.currentDB
 }
 
-// top-level r.* method names (from parser rBuilders map, sorted)
-var topLevelMethods = []string{
-	"args", "asc", "branch", "db", "dbCreate", "dbDrop", "dbList",
-	"desc", "epochTime", "error", "expr", "geoJSON", "iso8601",
-	"json", "literal", "maxval", "minval", "now", "point", "row",
-	"table", "uuid",
-}
-
-// chainable method names (from parser chainBuilders map, sorted)
-var chainMethods = []string{
-	"add", "and", "append", "avg", "between", "ceil", "changeAt",
-	"changes", "coerceTo", "concatMap", "config", "contains", "count",
-	"date", "day", "dayOfWeek", "dayOfYear", "default", "delete",
-	"deleteAt", "difference", "distinct", "distance", "div", "downcase",
-	"during", "eq", "eqJoin", "fill", "filter", "floor", "forEach",
-	"ge", "get", "getAll", "getField", "getIntersecting", "getNearest",
-	"grant", "group", "gt", "hasFields", "hours", "includes",
-	"indexCreate", "indexDrop", "indexList", "indexRename", "indexStatus",
-	"indexWait", "innerJoin", "insert", "insertAt", "intersects",
-	"inTimezone", "isEmpty", "keys", "le", "limit", "lt", "map",
-	"match", "max", "merge", "min", "minutes", "mod", "month", "mul",
-	"ne", "not", "nth", "or", "orderBy", "outerJoin", "pluck",
-	"polygonSub", "prepend", "rebalance", "reconfigure", "reduce",
-	"replace", "round", "seconds", "setDifference", "setInsert",
-	"setIntersection", "setUnion", "skip", "slice", "spliceAt", "split",
-	"status", "sub", "sum", "sync", "table", "tableCreate", "tableDrop",
-	"tableList", "timeOfDay", "timezone", "toEpochTime", "toGeoJSON",
-	"toISO8601", "toJSONString", "typeOf", "ungroup", "union", "update",
-	"upcase", "values", "wait", "withFields", "without", "year", "zip",
+// topLevelMethods and chainMethods are the r.* builders and chainable methods
+// the parser accepts, as completions: "name()" for methods without
+// arguments, "name(" for the rest.
+var (
+	topLevelMethods = methodCompletions(parser.RMethods())
+	chainMethods    = methodCompletions(parser.ChainMethods())
+)
+
+func methodCompletions(methods []parser.Method) []string {
+	out := make([]string, len(methods))
+	for i, m := range methods {
+		out[i] = m.Name + "("
+		if m.NoArgs {
+			out[i] += ")"
+		}
+	}
+	return out
 }
 
 // Do implements TabCompleter and readline.AutoCompleter.
@@ -72,11 +75,8 @@ func (c *Completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 	s := string(line[:pos])
 
 	// string-literal completions (checked before identifier completions)
-	if partial, ok := stringArg(s, "db"); ok {
-		return filterCompletions(c.fetchDBNames(), partial), len(partial)
-	}
-	if partial, ok := stringArg(s, "table"); ok {
-		return filterCompletions(c.fetchTableNames(), partial), len(partial)
+	if names, partial, ok := c.schemaArg(s); ok {
+		return filterCompletions(names, partial), len(partial)
 	}
 
 	// identifier completions
@@ -91,6 +91,52 @@ func (c *Completer) Do(line []rune, pos int) (newLine [][]rune, length int) {
 	return nil, 0
 }
 
+// schemaArg completes the open string literal at the end of s when it names
+// a database, a table or an index.
+func (c *Completer) schemaArg(s string) (names []string, partial string, ok bool) {
+	if partial, ok := stringArg(s, "db"); ok {
+		return c.fetchDBNames(), partial, true
+	}
+	if partial, ok := stringArg(s, "table"); ok {
+		db := ""
+		if m := dbTableArg.FindStringSubmatch(s); m != nil {
+			db = m[1]
+		}
+		return c.fetchTableNames(db), partial, true
+	}
+	if m := indexArg.FindStringSubmatch(s); m != nil {
+		db, table := lastTableRef(s)
+		if table == "" {
+			return nil, "", false
+		}
+		return c.fetchIndexNames(db, table), m[1], true
+	}
+	return nil, "", false
+}
+
+var (
+	// dbTableArg matches an open table name directly after db("name").
+	dbTableArg = regexp.MustCompile(`db\(\s*["']([^"']*)["']\s*\)\s*\.\s*table\(\s*["'][^"']*$`)
+	// indexArg matches an open index name: an index optarg (getAll, between,
+	// orderBy, eqJoin, getNearest, ...) or a string argument of indexDrop,
+	// indexRename, indexStatus and indexWait.
+	indexArg = regexp.MustCompile(`(?:(?:\bindex|["']index["'])\s*:\s*|\.\s*index(?:Drop|Rename|Status|Wait)\((?:\s*["'][^"']*["']\s*,)*\s*)["']([^"']*)$`)
+	// tableRef matches table("name"), optionally preceded by db("name").
+	tableRef = regexp.MustCompile(`(?:db\(\s*["']([^"']*)["']\s*\)\s*\.\s*)?table\(\s*["']([^"']*)["']`)
+)
+
+// lastTableRef returns the database and table of the last table("name") in
+// s, which is the table an index argument refers to; db is "" when the table
+// is not preceded by db("name").
+func lastTableRef(s string) (db, table string) {
+	refs := tableRef.FindAllStringSubmatch(s, -1)
+	if len(refs) == 0 {
+		return "", ""
+	}
+	last := refs[len(refs)-1]
+	return last[1], last[2]
+}
+
 // isTopLevelPrefix reports whether before ends with "r." not preceded by an ident char.
 func isTopLevelPrefix(before string) bool {
 	if !strings.HasSuffix(before, "r.") {
@@ -146,18 +192,58 @@ func (c *Completer) fetchDBNames() []string {
 	if c.FetchDBs == nil {
 		return nil
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-	names, _ := c.FetchDBs(ctx)
-	return names
+	return c.cached("dbs", c.FetchDBs)
 }
 
-func (c *Completer) fetchTableNames() []string {
+func (c *Completer) fetchTableNames(db string) []string {
 	if c.FetchTables == nil {
 		return nil
 	}
+	if db == "" {
+		db = c.getDB()
+	}
+	return c.cached(schemaKey("tables", db), func(ctx context.Context) ([]string, error) {
+		return c.FetchTables(ctx, db)
+	})
+}
+
+func (c *Completer) fetchIndexNames(db, table string) []string {
+	if c.FetchIndexes == nil {
+		return nil
+	}
+	if db == "" {
+		db = c.getDB()
+	}
+	return c.cached(schemaKey("indexes", db, table), func(ctx context.Context) ([]string, error) {
+		return c.FetchIndexes(ctx, db, table)
+	})
+}
+
+// schemaKey builds a cache key from a kind and the names it is scoped to.
+func schemaKey(kind string, names ...string) string {
+	return kind + "\x00" + strings.Join(names, "\x00")
+}
+
+// cached returns the names stored under key, calling fetch on a miss. Failed
+// fetches are not cached, so the next completion tries again.
+func (c *Completer) cached(key string, fetch func(ctx context.Context) ([]string, error)) []string {
+	c.mu.RLock()
+	names, ok := c.cache[key]
+	c.mu.RUnlock()
+	if ok {
+		return names
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	names, _ := c.FetchTables(ctx, c.getDB())
+	names, err := fetch(ctx)
+	if err != nil {
+		return nil
+	}
+	c.mu.Lock()
+	if c.cache == nil {
+		c.cache = make(map[string][]string)
+	}
+	c.cache[key] = names
+	c.mu.Unlock()
 	return names
 }
@@ -2,6 +2,8 @@ package repl
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -28,7 +30,7 @@ func TestCompleterTopLevelMethods(t *testing.T) {
 	if length != 2 {
 		t.Errorf("r.db: length = %d, want 2", length)
 	}
-	wantDB := []string{"", "Create", "Drop", "List"}
+	wantDB := []string{"(", "Create(", "Drop(", "List()"}
 	for _, w := range wantDB {
 		if !containsCompletion(got, w) {
 			t.Errorf("r.db: missing suffix %q in %v", w, toStringSlice(got))
@@ -38,14 +40,14 @@ func TestCompleterTopLevelMethods(t *testing.T) {
 		t.Errorf("r.db: got %d completions, want %d: %v", len(got), len(wantDB), toStringSlice(got))
 	}
 
-	// "r.now" -> exact match, returns empty suffix
+	// "r.now" -> exact match of a method without arguments, completes the parens
 	line = []rune("r.now")
 	got, length = c.Do(line, len(line))
 	if length != 3 {
 		t.Errorf("r.now: length = %d, want 3", length)
 	}
-	if len(got) != 1 || string(got[0]) != "" {
-		t.Errorf("r.now: got %v, want empty suffix", toStringSlice(got))
+	if len(got) != 1 || string(got[0]) != "()" {
+		t.Errorf("r.now: got %v, want [()]", toStringSlice(got))
 	}
 }
 
@@ -62,20 +64,22 @@ func TestCompleterChainMethods(t *testing.T) {
 	if len(got) != len(chainMethods) {
 		t.Errorf("chain dot: got %d completions, want %d", len(got), len(chainMethods))
 	}
-	for _, m := range []string{"filter", "get", "insert", "count", "orderBy"} {
+	for _, m := range []string{"filter(", "get(", "insert(", "count()", "orderBy("} {
 		if !containsCompletion(got, m) {
 			t.Errorf("chain dot: missing %q", m)
 		}
 	}
 
-	// "r.table(\"t\").fil" -> suffix for "filter" is "ter", length 3
+	// "r.table(\"t\").fil" -> "ter(" for filter and "l()" for fill, length 3
 	line = []rune(`r.table("t").fil`)
 	got, length = c.Do(line, len(line))
 	if length != 3 {
 		t.Errorf("chain fil: length = %d, want 3", length)
 	}
-	if !containsCompletion(got, "ter") {
-		t.Errorf("chain fil: missing suffix 'ter' (for 'filter') in %v", toStringSlice(got))
+	for _, w := range []string{"ter(", "l()"} {
+		if !containsCompletion(got, w) {
+			t.Errorf("chain fil: missing suffix %q in %v", w, toStringSlice(got))
+		}
 	}
 }
 
@@ -198,3 +202,81 @@ func toStringSlice(rss [][]rune) []string {
 	}
 	return ss
 }
+
+func TestCompleterIndexNames(t *testing.T) {
+	t.Parallel()
+	var asked []string
+	c := &Completer{
+		FetchIndexes: func(_ context.Context, db, table string) ([]string, error) {
+			asked = append(asked, db+"."+table)
+			return []string{"email", "name_" + table}, nil
+		},
+	}
+	c.SetCurrentDB("test")
+	tests := []struct {
+		line   string
+		table  string
+		want   []string
+		length int
+	}{
+		{`r.table("users").getAll("a", {index: "`, "test.users", []string{"email", "name_users"}, 0},
+		{`r.table("users").getAll("a", {index: 'em`, "test.users", []string{"ail"}, 2},
+		{`r.db("app").table("users").orderBy({"index": "na`, "app.users", []string{"me_users"}, 2},
+		{`r.table("a").eqJoin("x", r.table("b"), {index: "`, "test.b", []string{"email", "name_b"}, 0},
+		{`r.table("users").indexDrop("`, "test.users", []string{"email", "name_users"}, 0},
+		{`r.table("users").indexStatus("email", "n`, "test.users", []string{"ame_users"}, 1},
+	}
+	for _, tc := range tests {
+		asked = nil
+		got, length := c.Do([]rune(tc.line), len([]rune(tc.line)))
+		if length != tc.length || fmt.Sprint(toStringSlice(got)) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got %v, %d; want %v, %d", tc.line, toStringSlice(got), length, tc.want, tc.length)
+		}
+		if len(asked) > 1 || (len(asked) == 1 && asked[0] != tc.table) {
+			t.Errorf("%s: fetched %v, want %s", tc.line, asked, tc.table)
+		}
+	}
+	if got, _ := c.Do([]rune(`r.expr({index: "`), 16); len(got) != 0 {
+		t.Errorf("index optarg without a table: got %v", toStringSlice(got))
+	}
+}
+
+func TestCompleterCache(t *testing.T) {
+	t.Parallel()
+	fetches := 0
+	fail := true
+	c := &Completer{
+		FetchTables: func(_ context.Context, db string) ([]string, error) {
+			fetches++
+			if fail {
+				return nil, errors.New("down")
+			}
+			return []string{db + "_t"}, nil
+		},
+	}
+	c.SetCurrentDB("test")
+	complete := func(line string) []string {
+		got, _ := c.Do([]rune(line), len([]rune(line)))
+		return toStringSlice(got)
+	}
+	if got := complete(`r.table("`); len(got) != 0 {
+		t.Errorf("failed fetch: got %v", got)
+	}
+	fail = false
+	for range 2 {
+		if got := complete(`r.table("`); fmt.Sprint(got) != "[test_t]" {
+			t.Errorf("got %v, want [test_t]", got)
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("fetches = %d, want 2 (failures are not cached)", fetches)
+	}
+	if got := complete(`r.db("other").table("`); fmt.Sprint(got) != "[other_t]" {
+		t.Errorf("db(other): got %v, want [other_t]", got)
+	}
+	c.Refresh()
+	complete(`r.table("`)
+	if fetches != 4 {
+		t.Errorf("fetches after Refresh = %d, want 4", fetches)
+	}
+}
@@ -0,0 +1,33 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errNotConfirmed is returned by runQuery for a query the user chose not
+// to run.
+var errNotConfirmed = errors.New("not run")
+
+// confirmed asks the Confirm question for expr, if there is one, and
+// reports whether to run expr; only y or yes runs it, while Ctrl+C and EOF
+// answer no.
+func (r *Repl) confirmed(expr string) bool {
+	if r.confirm == nil {
+		return true
+	}
+	question := r.confirm(expr)
+	if question == "" {
+		return true
+	}
+	r.reader.SetPrompt(question + " [y/N] ")
+	answer, err := r.reader.Readline()
+	r.reader.SetPrompt(r.prompt)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if err == nil && (answer == "y" || answer == "yes") {
+		return true
+	}
+	_, _ = fmt.Fprintln(r.errOut, errNotConfirmed)
+	return false
+}
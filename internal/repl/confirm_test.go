@@ -0,0 +1,54 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReplConfirm(t *testing.T) {
+	t.Parallel()
+	var ran []string
+	var errOut bytes.Buffer
+	reader := &fakeReader{lines: []string{
+		"drop 1", "y",
+		"drop 2", "n",
+		"drop 3", "\x03",
+		"r.now()",
+		".let x = drop 4", "YES",
+		"drop 5",
+	}}
+	run := func(_ context.Context, expr string, _ io.Writer) error {
+		ran = append(ran, expr)
+		return nil
+	}
+	r := New(&Config{
+		Reader: reader,
+		Exec:   run,
+		Let: func(ctx context.Context, _, expr string, w io.Writer) error {
+			return run(ctx, expr, w)
+		},
+		ErrOut: &errOut,
+		Confirm: func(expr string) string {
+			if strings.HasPrefix(expr, "drop") {
+				return "Really run?"
+			}
+			return ""
+		},
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if want := "[drop 1 r.now() drop 4]"; fmt.Sprint(ran) != want {
+		t.Errorf("ran %v, want %s", ran, want)
+	}
+	if got := strings.Count(errOut.String(), "not run\n"); got != 3 {
+		t.Errorf("errOut = %q, want 3 queries not run", errOut.String())
+	}
+	if reader.prompts[2] != "Really run? [y/N] " || reader.prompt != "r> " {
+		t.Errorf("prompts = %q", reader.prompts)
+	}
+}
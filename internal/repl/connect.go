@@ -0,0 +1,118 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Target is the server a .connect command switches to.
+type Target struct {
+	Host     string
+	Port     int    // 0 means the default port
+	User     string // "" keeps the current user
+	Password string
+}
+
+// PasswordReader is implemented by Readers that can read a line without
+// echoing it.
+type PasswordReader interface {
+	ReadPassword(prompt string) (string, error)
+}
+
+const connectUsage = "usage: .connect <host[:port]> [--user <user>]"
+
+// connectCommand runs .connect <host[:port]> [--user u]: it asks for the
+// password and hands the target to Config.Connect, whose prompt replaces the
+// current one. A failed connect leaves the session on the old server.
+func (r *Repl) connectCommand(ctx context.Context, args []string) {
+	target, err := parseConnect(args)
+	if err != nil {
+		_, _ = fmt.Fprintln(r.errOut, err)
+		return
+	}
+	if r.connect == nil {
+		_, _ = fmt.Fprintln(r.errOut, ".connect: not available")
+		return
+	}
+	pr, ok := r.reader.(PasswordReader)
+	if !ok {
+		_, _ = fmt.Fprintln(r.errOut, ".connect: cannot prompt for a password")
+		return
+	}
+	target.Password, err = pr.ReadPassword("Password: ")
+	if err != nil {
+		if !errors.Is(err, ErrInterrupt) {
+			_, _ = fmt.Fprintln(r.errOut, err)
+		}
+		return
+	}
+	r.interruptible(ctx, func(ctx context.Context) error {
+		prompt, err := r.connect(ctx, target)
+		if err != nil {
+			return err
+		}
+		r.prompt = prompt
+		r.reader.SetPrompt(prompt)
+		return nil
+	})
+}
+
+// parseConnect parses the arguments of .connect: one host[:port], where an
+// IPv6 host with a port is bracketed, and an optional --user/-u.
+func parseConnect(args []string) (Target, error) {
+	var (
+		t    Target
+		addr string
+	)
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == "--user" || a == "-u":
+			if i+1 == len(args) {
+				return Target{}, errors.New(connectUsage)
+			}
+			i++
+			t.User = args[i]
+		case strings.HasPrefix(a, "--user="):
+			t.User = strings.TrimPrefix(a, "--user=")
+		case addr == "" && !strings.HasPrefix(a, "-"):
+			addr = a
+		default:
+			return Target{}, errors.New(connectUsage)
+		}
+	}
+	if addr == "" {
+		return Target{}, errors.New(connectUsage)
+	}
+	var err error
+	if t.Host, t.Port, err = splitHostPort(addr); err != nil {
+		return Target{}, err
+	}
+	if t.Host == "" {
+		return Target{}, errors.New(connectUsage)
+	}
+	return t, nil
+}
+
+// splitHostPort splits host[:port]; a bare IPv6 address or one in brackets
+// has no port.
+func splitHostPort(addr string) (string, int, error) {
+	if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		return addr[1 : len(addr)-1], 0, nil
+	}
+	if !strings.HasPrefix(addr, "[") && strings.Count(addr, ":") != 1 {
+		return addr, 0, nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, fmt.Errorf(".connect: %w", err)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return "", 0, fmt.Errorf(".connect: bad port %q", port)
+	}
+	return host, n, nil
+}
@@ -0,0 +1,144 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// passwordReader is a fakeReader that also answers password prompts.
+type passwordReader struct {
+	fakeReader
+	password string
+	asked    []string
+}
+
+func (p *passwordReader) ReadPassword(prompt string) (string, error) {
+	p.asked = append(p.asked, prompt)
+	if p.password == "\x03" {
+		return "", ErrInterrupt
+	}
+	return p.password, nil
+}
+
+func TestReplConnectCommand(t *testing.T) {
+	t.Parallel()
+	reader := &passwordReader{fakeReader: fakeReader{lines: []string{".connect db2:28016 --user bob", "r.now()"}}, password: "secret"}
+	var got []Target
+	var errOut bytes.Buffer
+	r := New(&Config{
+		Reader: reader,
+		Exec:   func(context.Context, string, io.Writer) error { return nil },
+		ErrOut: &errOut,
+		Connect: func(_ context.Context, t Target) (string, error) {
+			got = append(got, t)
+			return "r@" + t.Host + "> ", nil
+		},
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := Target{Host: "db2", Port: 28016, User: "bob", Password: "secret"}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("Connect got %+v, want %+v", got, want)
+	}
+	if len(reader.asked) != 1 || reader.asked[0] != "Password: " {
+		t.Errorf("password prompts = %q", reader.asked)
+	}
+	if reader.prompt != "r@db2> " {
+		t.Errorf("prompt = %q, want r@db2> ", reader.prompt)
+	}
+	if errOut.Len() != 0 {
+		t.Errorf("errOut = %q", errOut.String())
+	}
+}
+
+func TestReplConnectFailureKeepsPrompt(t *testing.T) {
+	t.Parallel()
+	reader := &passwordReader{fakeReader: fakeReader{lines: []string{".connect db2"}}}
+	var errOut bytes.Buffer
+	r := New(&Config{
+		Reader: reader,
+		ErrOut: &errOut,
+		Connect: func(context.Context, Target) (string, error) {
+			return "", errors.New("login rejected")
+		},
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(errOut.String(), "login rejected") {
+		t.Errorf("errOut = %q, want the connect error", errOut.String())
+	}
+	if reader.prompt != "r> " {
+		t.Errorf("prompt = %q, want r> ", reader.prompt)
+	}
+}
+
+func TestReplConnectErrors(t *testing.T) {
+	t.Parallel()
+	connect := func(context.Context, Target) (string, error) { return "x> ", nil }
+	cases := []struct {
+		name    string
+		reader  Reader
+		connect func(context.Context, Target) (string, error)
+		want    string
+	}{
+		{"usage", &passwordReader{fakeReader: fakeReader{lines: []string{".connect"}}}, connect, "usage: .connect"},
+		{"unavailable", &passwordReader{fakeReader: fakeReader{lines: []string{".connect db2"}}}, nil, "not available"},
+		{"no password prompt", &fakeReader{lines: []string{".connect db2"}}, connect, "cannot prompt for a password"},
+		{"interrupted", &passwordReader{fakeReader: fakeReader{lines: []string{".connect db2"}}, password: "\x03"}, connect, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var errOut bytes.Buffer
+			r := New(&Config{Reader: tc.reader, ErrOut: &errOut, Connect: tc.connect})
+			if err := r.Run(context.Background()); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got := errOut.String(); (tc.want == "") != (got == "") || !strings.Contains(got, tc.want) {
+				t.Errorf("errOut = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseConnect(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		args []string
+		want Target
+		err  string
+	}{
+		{[]string{"db2"}, Target{Host: "db2"}, ""},
+		{[]string{"db2:28016"}, Target{Host: "db2", Port: 28016}, ""},
+		{[]string{"-u", "bob", "db2"}, Target{Host: "db2", User: "bob"}, ""},
+		{[]string{"db2", "--user=bob"}, Target{Host: "db2", User: "bob"}, ""},
+		{[]string{"::1"}, Target{Host: "::1"}, ""},
+		{[]string{"[::1]"}, Target{Host: "::1"}, ""},
+		{[]string{"[::1]:28016"}, Target{Host: "::1", Port: 28016}, ""},
+		{nil, Target{}, "usage"},
+		{[]string{"db2", "db3"}, Target{}, "usage"},
+		{[]string{"db2", "--user"}, Target{}, "usage"},
+		{[]string{"db2", "--port", "1"}, Target{}, "usage"},
+		{[]string{":28016"}, Target{}, "usage"},
+		{[]string{"db2:x"}, Target{}, "bad port"},
+		{[]string{"db2:70000"}, Target{}, "bad port"},
+	}
+	for _, tc := range cases {
+		got, err := parseConnect(tc.args)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("parseConnect(%q) error = %v, want %q", tc.args, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("parseConnect(%q) = %+v, %v; want %+v", tc.args, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"r-cli/internal/reql/parser"
+)
+
+// defaultEditor runs when neither $VISUAL nor $EDITOR is set.
+const defaultEditor = "vi"
+
+// editCommand runs .edit [file]: it opens file, or a temporary file holding
+// the last query, in the editor and then runs the queries saved in it with
+// runScript. Each query is added to history. An editor that exits with an
+// error runs nothing.
+func (r *Repl) editCommand(ctx context.Context, args []string) {
+	if len(args) > 1 {
+		_, _ = fmt.Fprintln(r.errOut, "usage: .edit [file]")
+		return
+	}
+	queries, err := r.editQueries(args)
+	if err != nil {
+		_, _ = fmt.Fprintf(r.errOut, ".edit: %v\n", err)
+		return
+	}
+	for _, q := range queries {
+		_ = r.reader.AddHistory(q)
+	}
+	r.runScript(ctx, queries)
+}
+
+// editQueries edits the file named in args, or a temporary one, and returns
+// the queries it contains after the editor exits.
+func (r *Repl) editQueries(args []string) ([]string, error) {
+	var path string
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		f, err := os.CreateTemp("", "r-cli-*.reql")
+		if err != nil {
+			return nil, err
+		}
+		path = f.Name()
+		defer func() { _ = os.Remove(path) }()
+		_, err = f.WriteString(r.last)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := r.editor(path); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path) //nolint:gosec // the user's own file
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return parser.SplitQueries(f)
+}
+
+// runEditor opens path in $VISUAL, $EDITOR or vi on the REPL's terminal.
+func runEditor(path string) error {
+	command := strings.Fields(os.Getenv("VISUAL"))
+	if len(command) == 0 {
+		command = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(command) == 0 {
+		command = []string{defaultEditor}
+	}
+	cmd := exec.Command(command[0], append(command[1:], path)...) //nolint:gosec // $EDITOR is the user's choice
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return fmt.Errorf("%s exited with status %d; nothing run", command[0], ee.ExitCode())
+		}
+		return err
+	}
+	return nil
+}
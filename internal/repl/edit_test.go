@@ -0,0 +1,153 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scriptEditor returns an Editor that records the text it was opened with
+// and saves text in its place.
+func scriptEditor(opened *string, text string) func(string) error {
+	return func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		*opened = string(data)
+		return os.WriteFile(path, []byte(text), 0o600)
+	}
+}
+
+func TestReplEditRunsQueries(t *testing.T) {
+	t.Parallel()
+	var opened string
+	var ran []string
+	var out bytes.Buffer
+	reader := &fakeReader{lines: []string{"r.now()", ".edit"}}
+	r := New(&Config{
+		Reader: reader,
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			ran = append(ran, expr)
+			return nil
+		},
+		Out:    &out,
+		Editor: scriptEditor(&opened, "r.table(\"a\")\n  .count()\n---\nr.dbList()\n"),
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if opened != "r.now()" {
+		t.Errorf("editor opened with %q, want the last query", opened)
+	}
+	want := []string{"r.now()", "r.table(\"a\")\n  .count()", "r.dbList()"}
+	if fmt.Sprint(ran) != fmt.Sprint(want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+	if fmt.Sprint(reader.history) != fmt.Sprint(want) {
+		t.Errorf("history = %q, want %q", reader.history, want)
+	}
+	if !strings.Contains(out.String(), "r> r.dbList()\n") {
+		t.Errorf("out = %q, want the edited queries echoed", out.String())
+	}
+}
+
+func TestReplEditFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "q.reql")
+	if err := os.WriteFile(path, []byte("r.expr(1)"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var opened string
+	var ran []string
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{".edit " + path}},
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			ran = append(ran, expr)
+			return nil
+		},
+		Editor: scriptEditor(&opened, "r.expr(2)"),
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if opened != "r.expr(1)" || fmt.Sprint(ran) != "[r.expr(2)]" {
+		t.Errorf("opened %q, ran %q", opened, ran)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "r.expr(2)" {
+		t.Errorf("file = %q, want it kept", data)
+	}
+}
+
+func TestReplEditStopsOnInterrupt(t *testing.T) {
+	t.Parallel()
+	var opened string
+	var ran []string
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{".edit"}},
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			ran = append(ran, expr)
+			return context.Canceled
+		},
+		Editor: scriptEditor(&opened, "1\n---\n2"),
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if fmt.Sprint(ran) != "[1]" {
+		t.Errorf("ran %q after an interrupt, want only the first query", ran)
+	}
+}
+
+func TestReplEditErrors(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		line   string
+		editor func(string) error
+		want   string
+	}{
+		{".edit a b", nil, "usage: .edit"},
+		{".edit", func(string) error { return errors.New("vi exited with status 1; nothing run") }, "nothing run"},
+		{".edit " + filepath.Join(t.TempDir(), "never-saved"), func(string) error { return nil }, "no such file"},
+	}
+	for _, tc := range cases {
+		var errOut bytes.Buffer
+		r := New(&Config{
+			Reader: &fakeReader{lines: []string{tc.line}},
+			Exec: func(context.Context, string, io.Writer) error {
+				t.Errorf("%q: query run", tc.line)
+				return nil
+			},
+			ErrOut: &errOut,
+			Editor: tc.editor,
+		})
+		if err := r.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if !strings.Contains(errOut.String(), tc.want) {
+			t.Errorf("%q: errOut = %q, want %q", tc.line, errOut.String(), tc.want)
+		}
+	}
+}
+
+func TestRunEditor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q.reql")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "touch")
+	if err := runEditor(path); err != nil {
+		t.Fatalf("runEditor: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("$EDITOR not run: %v", err)
+	}
+	t.Setenv("VISUAL", "false")
+	if err := runEditor(path); err == nil || !strings.Contains(err.Error(), "false exited with status 1") {
+		t.Errorf("runEditor with a failing $VISUAL = %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ANSI escape sequences used for error reports.
+const (
+	ansiError = "\x1b[31;1m"
+	ansiCaret = "\x1b[31m"
+)
+
+// spanError is implemented by errors that point into the query text, such
+// as *parser.SyntaxError: Span returns the runes [start, end) at fault.
+type spanError interface {
+	error
+	Span() (start, end int)
+}
+
+// writeError writes err to w. When err points into expr, the line of expr
+// holding the span follows, with carets under the span. color paints the
+// message and the carets red.
+func writeError(w io.Writer, expr string, err error, color bool) {
+	msg := err.Error()
+	if color {
+		msg = ansiError + msg + ansiReset
+	}
+	_, _ = fmt.Fprintln(w, msg)
+	var se spanError
+	if expr == "" || !errors.As(err, &se) {
+		return
+	}
+	start, end := se.Span()
+	line, col, width := spanLine([]rune(expr), start, end)
+	carets := strings.Repeat("^", width)
+	if color {
+		carets = ansiCaret + carets + ansiReset
+	}
+	_, _ = fmt.Fprintf(w, "  %s\n  %s%s\n", string(line), indentTo(line, col), carets)
+}
+
+// spanLine returns the line of rs that holds rune start, the column of
+// start in it, and the width of [start, end) on that line, at least 1.
+func spanLine(rs []rune, start, end int) (line []rune, col, width int) {
+	start = max(0, min(start, len(rs)))
+	from := start
+	for from > 0 && rs[from-1] != '\n' {
+		from--
+	}
+	to := start
+	for to < len(rs) && rs[to] != '\n' {
+		to++
+	}
+	end = max(start, min(end, to))
+	return rs[from:to], start - from, max(1, end-start)
+}
+
+// indentTo returns the blanks that line the next text up with column col
+// of line, keeping its tabs.
+func indentTo(line []rune, col int) string {
+	var sb strings.Builder
+	for i := 0; i < col; i++ {
+		if i < len(line) && line[i] == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+	return sb.String()
+}
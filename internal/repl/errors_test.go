@@ -0,0 +1,75 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"r-cli/internal/reql/parser"
+)
+
+func TestWriteError(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		expr  string
+		err   error
+		color bool
+		want  string
+	}{
+		{"plain", `r.now()`, errors.New("boom"), false, "boom\n"},
+		{"no query", "", &parser.SyntaxError{Msg: "bad", Pos: 0, End: 1}, false, "bad\n"},
+		{
+			"span", `r.table("a").fliter(1)`, &parser.SyntaxError{Msg: "unknown method", Pos: 13, End: 19}, false,
+			"unknown method\n  r.table(\"a\").fliter(1)\n               ^^^^^^\n",
+		},
+		{
+			"end of input", `r.table("a"`, &parser.SyntaxError{Msg: "expected ')'", Pos: 11, End: 11}, false,
+			"expected ')'\n  r.table(\"a\"\n             ^\n",
+		},
+		{
+			"second line", "r.table(\"a\")\n\t.fliter(1)", &parser.SyntaxError{Msg: "unknown method", Pos: 15, End: 21}, false,
+			"unknown method\n  \t.fliter(1)\n  \t ^^^^^^\n",
+		},
+		{
+			"clipped to the line", "r.expr(\"a\nb\")", &parser.SyntaxError{Msg: "bad string", Pos: 7, End: 13}, false,
+			"bad string\n  r.expr(\"a\n         ^^\n",
+		},
+		{
+			"color", `r.x()`, &parser.SyntaxError{Msg: "unknown r.x", Pos: 2, End: 3}, true,
+			ansiError + "unknown r.x" + ansiReset + "\n  r.x()\n    " + ansiCaret + "^" + ansiReset + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			writeError(&buf, tt.expr, tt.err, tt.color)
+			if buf.String() != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReplErrorCaret(t *testing.T) {
+	t.Parallel()
+	var errOut bytes.Buffer
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{".alias t r.table(\"a\")", "t.fliter(1)"}},
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			_, err := parser.Parse(expr)
+			return err
+		},
+		ErrOut: &errOut,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := "unknown method .fliter at position 13\n  r.table(\"a\").fliter(1)\n               ^^^^^^\n"
+	if errOut.String() != want {
+		t.Errorf("errOut = %q, want %q", errOut.String(), want)
+	}
+}
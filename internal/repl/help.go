@@ -0,0 +1,54 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"r-cli/internal/reql/parser"
+)
+
+// rMethodDocs and chainMethodDocs are the docs of the methods listed for
+// completion, by name.
+var (
+	rMethodDocs     = methodDocs(parser.RMethods())
+	chainMethodDocs = methodDocs(parser.ChainMethods())
+)
+
+func methodDocs(methods []parser.Method) map[string]parser.MethodDoc {
+	docs := make(map[string]parser.MethodDoc, len(methods))
+	for _, m := range methods {
+		docs[m.Name] = m.Doc
+	}
+	return docs
+}
+
+// helpCommand runs .help [method]: without an argument it lists the
+// commands, otherwise it shows the signature, summary and example of a
+// method. "r.branch" names an r.* builder only; "table", which is both,
+// shows db.table and r.table.
+func (r *Repl) helpCommand(args []string) {
+	if len(args) == 0 {
+		printHelp(r.term)
+		return
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(args[0], ")"), "(")
+	var docs []parser.MethodDoc
+	if rest, ok := strings.CutPrefix(name, "r."); ok {
+		name = rest
+	} else if d, ok := chainMethodDocs[strings.TrimPrefix(name, ".")]; ok {
+		docs = append(docs, d)
+	}
+	if d, ok := rMethodDocs[name]; ok {
+		docs = append(docs, d)
+	}
+	if len(docs) == 0 {
+		_, _ = fmt.Fprintf(r.errOut, ".help: unknown method %q\n", args[0])
+		return
+	}
+	for i, d := range docs {
+		if i > 0 {
+			_, _ = fmt.Fprintln(r.term)
+		}
+		_, _ = fmt.Fprintf(r.term, "%s\n  %s\n  Example: %s\n", d.Signature, d.Summary, d.Example)
+	}
+}
@@ -0,0 +1,50 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestReplHelpMethod(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{".help filter", []string{"seq.filter(object | func)\n", "  Example: r.table(\"users\").filter({active: true})\n"}},
+		{".help .filter()", []string{"seq.filter(object | func)\n"}},
+		{".help r.branch", []string{"r.branch(test, trueValue"}},
+		{".help branch", []string{"r.branch(test, trueValue"}},
+		{".help table", []string{"db.table(name)\n", "\n\nr.table(name)\n"}},
+		{".help r.table", []string{"r.table(name)\n"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		r := New(&Config{Reader: &fakeReader{lines: []string{tt.line}}, Out: &out})
+		if err := r.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: out = %q, want %q in it", tt.line, out.String(), want)
+			}
+		}
+	}
+}
+
+func TestReplHelpUnknownMethod(t *testing.T) {
+	t.Parallel()
+	var out, errOut bytes.Buffer
+	r := New(&Config{Reader: &fakeReader{lines: []string{".help r.filter", ".help nope"}}, Out: &out, ErrOut: &errOut})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("out = %q", out.String())
+	}
+	if want := ".help: unknown method \"r.filter\"\n.help: unknown method \"nope\"\n"; errOut.String() != want {
+		t.Errorf("errOut = %q, want %q", errOut.String(), want)
+	}
+}
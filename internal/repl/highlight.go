@@ -0,0 +1,102 @@
+package repl
+
+import "r-cli/internal/reql/parser"
+
+// ANSI escape sequences used for input highlighting.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiKeyword = "\x1b[35;1m"
+	ansiMethod  = "\x1b[34m"
+	ansiString  = "\x1b[32m"
+	ansiNumber  = "\x1b[36m"
+	ansiLiteral = "\x1b[33m"
+	ansiInvalid = "\x1b[31m"
+	ansiMatch   = "\x1b[1;4m" // the bracket at the cursor and its partner
+)
+
+// spanColors maps the highlighted span kinds to their colors.
+var spanColors = map[parser.SpanKind]string{
+	parser.SpanKeyword: ansiKeyword,
+	parser.SpanMethod:  ansiMethod,
+	parser.SpanString:  ansiString,
+	parser.SpanNumber:  ansiNumber,
+	parser.SpanLiteral: ansiLiteral,
+	parser.SpanInvalid: ansiInvalid,
+}
+
+// Highlight returns line with ANSI colors for ReQL keywords, methods,
+// strings, numbers and literals, tokenized by parser.Scan. The bracket at or
+// just before pos and the bracket matching it are underlined.
+func Highlight(line []rune, pos int) []rune {
+	spans := parser.Scan(string(line))
+	match := matchingBrackets(line, spans, pos)
+	out := make([]rune, 0, len(line)*2)
+	at := 0
+	for i, sp := range spans {
+		out = append(out, line[at:sp.Start]...)
+		color := spanColors[sp.Kind]
+		if match[i] {
+			color = ansiMatch
+		}
+		if color == "" {
+			out = append(out, line[sp.Start:sp.End]...)
+		} else {
+			out = append(out, []rune(color)...)
+			out = append(out, line[sp.Start:sp.End]...)
+			out = append(out, []rune(ansiReset)...)
+		}
+		at = sp.End
+	}
+	return append(out, line[at:]...)
+}
+
+// matchingBrackets returns the indexes of the bracket span under the cursor
+// (at pos, else at pos-1) and of its partner; it is empty when there is no
+// bracket there or it has no partner.
+func matchingBrackets(line []rune, spans []parser.Span, pos int) map[int]bool {
+	partner := pairBrackets(line, spans)
+	for _, p := range []int{pos, pos - 1} {
+		for i, sp := range spans {
+			if sp.Kind != parser.SpanBracket || sp.Start != p {
+				continue
+			}
+			if j, ok := partner[i]; ok {
+				return map[int]bool{i: true, j: true}
+			}
+		}
+	}
+	return nil
+}
+
+// pairBrackets maps each bracket span index to the index of its partner.
+// Unbalanced and mismatched brackets are left out.
+func pairBrackets(line []rune, spans []parser.Span) map[int]int {
+	closer := map[rune]rune{'(': ')', '[': ']', '{': '}'}
+	partner := make(map[int]int)
+	var open []int
+	for i, sp := range spans {
+		if sp.Kind != parser.SpanBracket {
+			continue
+		}
+		ch := line[sp.Start]
+		if _, ok := closer[ch]; ok {
+			open = append(open, i)
+			continue
+		}
+		if len(open) == 0 {
+			continue
+		}
+		top := open[len(open)-1]
+		if closer[line[spans[top].Start]] != ch {
+			continue
+		}
+		open = open[:len(open)-1]
+		partner[i], partner[top] = top, i
+	}
+	return partner
+}
+
+// highlightPainter is a readline.Painter that calls Highlight.
+type highlightPainter struct{}
+
+func (highlightPainter) Paint(line []rune, pos int) []rune { return Highlight(line, pos) }
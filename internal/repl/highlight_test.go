@@ -0,0 +1,67 @@
+package repl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	t.Parallel()
+	line := `r.table("t").get(1)`
+	got := string(Highlight([]rune(line), 0))
+	for _, want := range []string{ansiKeyword + "r" + ansiReset, ansiMethod + "table" + ansiReset, ansiString + `"t"` + ansiReset, ansiNumber + "1" + ansiReset} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Highlight = %q, missing %q", got, want)
+		}
+	}
+	if stripped := strings.NewReplacer(ansiKeyword, "", ansiMethod, "", ansiString, "", ansiNumber, "", ansiReset, "").Replace(got); stripped != line {
+		t.Errorf("text changed: %q", stripped)
+	}
+}
+
+func TestHighlightPartialInput(t *testing.T) {
+	t.Parallel()
+	got := string(Highlight([]rune(`r.db("te`), 8))
+	if !strings.HasSuffix(got, ansiString+`"te`+ansiReset) {
+		t.Errorf("unterminated string not highlighted: %q", got)
+	}
+	got = string(Highlight([]rune(`  [null, false] # x`), 0))
+	if want := "  [" + ansiLiteral + "null" + ansiReset; !strings.HasPrefix(got, want) {
+		t.Errorf("Highlight = %q, want prefix %q", got, want)
+	}
+	if !strings.Contains(got, ansiInvalid+"#"+ansiReset+" x") {
+		t.Errorf("invalid character not marked: %q", got)
+	}
+}
+
+func TestHighlightMatchingBrackets(t *testing.T) {
+	t.Parallel()
+	line := []rune(`f({a: [1]})`)
+	tests := []struct {
+		pos  int
+		want []int // rune offsets of the underlined brackets
+	}{
+		{len(line), []int{1, 10}}, // just after the closing paren
+		{6, []int{6, 8}},          // on the opening square bracket
+		{4, nil},                  // not at a bracket
+		{3, []int{2, 9}},          // just after the brace
+		{2, []int{2, 9}},          // on the brace
+		{9, []int{9, 2}},          // on the closing brace
+		{len(line) + 5, nil},      // beyond the line
+	}
+	for _, tc := range tests {
+		got := string(Highlight(line, tc.pos))
+		if n := strings.Count(got, ansiMatch); n != len(tc.want) {
+			t.Errorf("pos %d: %d matches in %q, want %d", tc.pos, n, got, len(tc.want))
+			continue
+		}
+		for _, off := range tc.want {
+			if !strings.Contains(got, ansiMatch+string(line[off])+ansiReset) {
+				t.Errorf("pos %d: %q not underlined in %q", tc.pos, string(line[off]), got)
+			}
+		}
+	}
+	if got := string(Highlight([]rune(`(]`), 1)); strings.Contains(got, ansiMatch) {
+		t.Errorf("mismatched brackets underlined: %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package repl
+
+import (
+	"strings"
+	"unicode"
+)
+
+// historyEntry flattens a multiline expression into the single line the
+// history file stores per entry, so Ctrl+R and the arrow keys recall it
+// whole in later sessions. Line breaks between tokens, with the indentation
+// around them, become one space; raw line breaks inside string literals
+// become \n and \r escapes. The result parses to the same term.
+func historyEntry(expr string) string {
+	if !strings.ContainsAny(expr, "\r\n") {
+		return expr
+	}
+	var sb strings.Builder
+	rs := []rune(expr)
+	quote := rune(0)
+	for i := 0; i < len(rs); i++ {
+		ch := rs[i]
+		switch {
+		case quote != 0:
+			i = writeStringRune(&sb, rs, i, &quote)
+		case ch == '"' || ch == '\'':
+			quote = ch
+			sb.WriteRune(ch)
+		case unicode.IsSpace(ch):
+			j := i
+			for j < len(rs) && unicode.IsSpace(rs[j]) {
+				j++
+			}
+			if ws := string(rs[i:j]); strings.ContainsAny(ws, "\r\n") {
+				sb.WriteByte(' ')
+			} else {
+				sb.WriteString(ws)
+			}
+			i = j - 1
+		default:
+			sb.WriteRune(ch)
+		}
+	}
+	return sb.String()
+}
+
+// writeStringRune writes rs[i], which is inside a string literal opened by
+// *quote, and returns the index of the last rune consumed. An escape is
+// copied with the rune it escapes; the closing quote clears *quote.
+func writeStringRune(sb *strings.Builder, rs []rune, i int, quote *rune) int {
+	switch ch := rs[i]; ch {
+	case '\\':
+		sb.WriteRune(ch)
+		if i+1 < len(rs) {
+			i++
+			sb.WriteRune(rs[i])
+		}
+	case '\n':
+		sb.WriteString(`\n`)
+	case '\r':
+		sb.WriteString(`\r`)
+	case *quote:
+		*quote = 0
+		sb.WriteRune(ch)
+	default:
+		sb.WriteRune(ch)
+	}
+	return i
+}
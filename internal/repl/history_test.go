@@ -0,0 +1,43 @@
+package repl
+
+import (
+	"testing"
+
+	"r-cli/internal/reql/parser"
+)
+
+func TestHistoryEntry(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in, want string
+	}{
+		{`r.now()`, `r.now()`},
+		{"r.table(\"t\")\n  .filter({a:  1})\n  .count()", `r.table("t") .filter({a:  1}) .count()`},
+		{"r.expr(\"two\nlines\")", `r.expr("two\nlines")`},
+		{"r.expr('it\\'s\r\n')\r\n.count()", `r.expr('it\'s\r\n') .count()`},
+		{"r.expr(\"a\\\\\")\n", `r.expr("a\\") `},
+	}
+	for _, tc := range tests {
+		if got := historyEntry(tc.in); got != tc.want {
+			t.Errorf("historyEntry(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestHistoryEntrySameTerm(t *testing.T) {
+	t.Parallel()
+	in := "r.table(\"t\").insert({\n  note: \"line one\nline two\",\n  n: 1\n})"
+	want, err := parser.Parse(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parser.Parse(historyEntry(in))
+	if err != nil {
+		t.Fatalf("Parse(historyEntry) = %v", err)
+	}
+	wb, _ := want.MarshalJSON()
+	gb, _ := got.MarshalJSON()
+	if string(wb) != string(gb) {
+		t.Errorf("term changed: %s, want %s", gb, wb)
+	}
+}
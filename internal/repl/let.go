@@ -0,0 +1,45 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// varName matches the names .let accepts, which the parser reads as
+// identifiers.
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedNames are identifiers the parser gives a meaning of their own,
+// plus _, which always holds the last result.
+var reservedNames = []string{"r", "_", "true", "false", "null", "function", "return"}
+
+// letCommand runs .let <name> = <query>: the query runs like any other and
+// its result is bound to name, usable in later queries, as well as to _.
+func (r *Repl) letCommand(ctx context.Context, args string) {
+	name, expr, ok := strings.Cut(args, "=")
+	name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+	if !ok || name == "" || expr == "" {
+		_, _ = fmt.Fprintln(r.errOut, "usage: .let <name> = <query>")
+		return
+	}
+	if !varName.MatchString(name) || slices.Contains(reservedNames, name) {
+		_, _ = fmt.Fprintf(r.errOut, ".let: invalid name %q\n", name)
+		return
+	}
+	if r.let == nil {
+		_, _ = fmt.Fprintln(r.errOut, ".let: not available")
+		return
+	}
+	r.last = expr
+	expr = expandAliases(expr, r.aliases)
+	if !r.confirmed(expr) {
+		return
+	}
+	r.running = expr
+	r.interruptible(ctx, func(queryCtx context.Context) error {
+		return r.let(queryCtx, name, expr, r.out)
+	})
+}
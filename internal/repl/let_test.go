@@ -0,0 +1,74 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReplLet(t *testing.T) {
+	t.Parallel()
+	var out, errOut bytes.Buffer
+	var got []string
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{`.let ids = r.table("a").insert({"x": "a=b"})("generated_keys")`, ".let bad = oops"}},
+		Let: func(_ context.Context, name, expr string, w io.Writer) error {
+			got = append(got, name+" "+expr)
+			if expr == "oops" {
+				return errors.New("parse error")
+			}
+			_, err := fmt.Fprintln(w, `["k1"]`)
+			return err
+		},
+		Out:    &out,
+		ErrOut: &errOut,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []string{`ids r.table("a").insert({"x": "a=b"})("generated_keys")`, "bad oops"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Let calls = %q, want %q", got, want)
+	}
+	if out.String() != "[\"k1\"]\n" {
+		t.Errorf("out = %q", out.String())
+	}
+	if errOut.String() != "parse error\n" {
+		t.Errorf("errOut = %q", errOut.String())
+	}
+}
+
+func TestReplLetErrors(t *testing.T) {
+	t.Parallel()
+	cases := []struct{ line, want string }{
+		{".let", "usage: .let"},
+		{".let x", "usage: .let"},
+		{".let x =", "usage: .let"},
+		{".let = r.now()", "usage: .let"},
+		{".let _ = r.now()", `invalid name "_"`},
+		{".let r = r.now()", `invalid name "r"`},
+		{".let 1x = r.now()", `invalid name "1x"`},
+		{".let a.b = r.now()", `invalid name "a.b"`},
+	}
+	for _, tc := range cases {
+		var errOut bytes.Buffer
+		r := New(&Config{
+			Reader: &fakeReader{lines: []string{tc.line}},
+			Let: func(context.Context, string, string, io.Writer) error {
+				t.Errorf("%q: query run", tc.line)
+				return nil
+			},
+			ErrOut: &errOut,
+		})
+		if err := r.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if !strings.Contains(errOut.String(), tc.want) {
+			t.Errorf("%q: errOut = %q, want %q", tc.line, errOut.String(), tc.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Setting is the current value of a session setting shown by .show.
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// Options backs .set and .show. A nil func disables its command.
+type Options struct {
+	// Set validates value and applies it to the queries that follow.
+	Set func(name, value string) error
+	// List returns the settings in display order.
+	List func() []Setting
+}
+
+// pagerCommand runs .pager on|off.
+func (r *Repl) pagerCommand(args []string) {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		_, _ = fmt.Fprintln(r.errOut, "usage: .pager <on|off>")
+		return
+	}
+	r.onPager(args[0] == "on")
+}
+
+// setCommand runs .set <name> <value>; the value is the rest of the line.
+// Without arguments it shows the settings like .show.
+func (r *Repl) setCommand(args []string) {
+	switch {
+	case len(args) == 0:
+		r.showSettings()
+	case len(args) == 1:
+		_, _ = fmt.Fprintln(r.errOut, "usage: .set <name> <value>")
+	case r.options.Set == nil:
+		_, _ = fmt.Fprintln(r.errOut, ".set: not available")
+	default:
+		if err := r.options.Set(args[0], strings.Join(args[1:], " ")); err != nil {
+			_, _ = fmt.Fprintf(r.errOut, ".set: %v\n", err)
+		}
+	}
+}
+
+// showSettings prints one aligned "name value" line per setting.
+func (r *Repl) showSettings() {
+	if r.options.List == nil {
+		_, _ = fmt.Fprintln(r.errOut, ".show: not available")
+		return
+	}
+	settings := r.options.List()
+	width := 0
+	for _, s := range settings {
+		width = max(width, len(s.Name))
+	}
+	for _, s := range settings {
+		_, _ = fmt.Fprintf(r.term, "%-*s  %s\n", width, s.Name, s.Value)
+	}
+}
@@ -0,0 +1,53 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReplSetAndShow(t *testing.T) {
+	t.Parallel()
+	values := map[string]string{"timeout": "none", "read-mode": "default"}
+	var out, errOut bytes.Buffer
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{".set timeout 5s", ".set read-mode bogus", ".set format", ".show", ".set"}},
+		Out:    &out,
+		ErrOut: &errOut,
+		Options: Options{
+			Set: func(name, value string) error {
+				if value == "bogus" {
+					return errors.New("bad value")
+				}
+				values[name] = value
+				return nil
+			},
+			List: func() []Setting {
+				return []Setting{{"timeout", values["timeout"]}, {"read-mode", values["read-mode"]}}
+			},
+		},
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	show := "timeout    5s\nread-mode  default\n"
+	if out.String() != show+show {
+		t.Errorf("out = %q, want %q twice", out.String(), show)
+	}
+	if errOut.String() != ".set: bad value\nusage: .set <name> <value>\n" {
+		t.Errorf("errOut = %q", errOut.String())
+	}
+}
+
+func TestReplSetUnavailable(t *testing.T) {
+	t.Parallel()
+	var errOut bytes.Buffer
+	r := New(&Config{Reader: &fakeReader{lines: []string{".set limit 10", ".show"}}, ErrOut: &errOut})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := errOut.String(); got != ".set: not available\n.show: not available\n" {
+		t.Errorf("errOut = %q", got)
+	}
+}
@@ -0,0 +1,176 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Bracketed paste: once enablePaste is written to the terminal, it wraps
+// pasted text in pasteStart and pasteEnd.
+const (
+	enablePaste  = "\x1b[?2004h"
+	disablePaste = "\x1b[?2004l"
+	pasteStart   = "\x1b[200~"
+	pasteEnd     = "\x1b[201~"
+)
+
+// Runes that stand in for the line breaks and tabs of a paste while it sits
+// in the line editor, which would otherwise submit the line on a line break
+// and complete on a tab. Readline restores them.
+const (
+	pasteNewline = '↵'
+	pasteTab     = '⇥'
+)
+
+var pasteRestorer = strings.NewReplacer(string(pasteNewline), "\n", string(pasteTab), "\t")
+
+// restorePaste turns the stand-ins of a pasted line back into line breaks
+// and tabs.
+func restorePaste(line string) string {
+	return pasteRestorer.Replace(line)
+}
+
+// pasteReader strips the bracketed paste markers from the terminal input
+// and replaces the line breaks and tabs between them with pasteNewline and
+// pasteTab, so a multiline paste lands in the editor as one line. Line
+// breaks that end a paste submit it as a single Enter.
+type pasteReader struct {
+	r        io.ReadCloser
+	raw      []byte // input not translated yet: a marker split across reads
+	out      []byte // translated input not returned yet
+	pasting  bool
+	newlines int  // line breaks of the paste held back until text follows
+	cr       bool // the paste's last byte was \r, a \n after it is the same break
+}
+
+func newPasteReader(r io.ReadCloser) *pasteReader {
+	return &pasteReader{r: r}
+}
+
+func (p *pasteReader) Read(b []byte) (int, error) {
+	var buf [1024]byte
+	for len(p.out) == 0 {
+		n, err := p.r.Read(buf[:])
+		p.raw = append(p.raw, buf[:n]...)
+		p.translate(err != nil)
+		if err != nil && len(p.out) == 0 {
+			return 0, err
+		}
+	}
+	n := copy(b, p.out)
+	p.out = p.out[n:]
+	return n, nil
+}
+
+func (p *pasteReader) Close() error {
+	return p.r.Close()
+}
+
+// translate moves p.raw to p.out. Inside a paste, an escape that may begin
+// pasteEnd stays in p.raw until more input decides it, unless final is set.
+func (p *pasteReader) translate(final bool) {
+	i := 0
+	for i < len(p.raw) {
+		rest := p.raw[i:]
+		if rest[0] == 0x1b {
+			if bytes.HasPrefix(rest, []byte(pasteStart)) {
+				p.pasting, p.newlines, p.cr = true, 0, false
+				i += len(pasteStart)
+				continue
+			}
+			if bytes.HasPrefix(rest, []byte(pasteEnd)) {
+				p.endPaste()
+				i += len(pasteEnd)
+				continue
+			}
+			if p.pasting && !final && len(rest) < len(pasteEnd) && bytes.HasPrefix([]byte(pasteEnd), rest) {
+				break
+			}
+		}
+		if p.pasting {
+			p.pasteByte(rest[0])
+		} else {
+			p.out = append(p.out, rest[0])
+		}
+		i++
+	}
+	p.raw = append(p.raw[:0], p.raw[i:]...)
+}
+
+// pasteByte translates one byte of a paste.
+func (p *pasteReader) pasteByte(c byte) {
+	switch {
+	case c == '\r':
+		p.newlines++
+		p.cr = true
+		return
+	case c == '\n' && p.cr:
+		p.cr = false
+		return
+	case c == '\n':
+		p.newlines++
+		return
+	}
+	p.cr = false
+	for ; p.newlines > 0; p.newlines-- {
+		p.out = utf8.AppendRune(p.out, pasteNewline)
+	}
+	if c == '\t' {
+		p.out = utf8.AppendRune(p.out, pasteTab)
+		return
+	}
+	p.out = append(p.out, c)
+}
+
+// endPaste submits the paste if it ended with a line break.
+func (p *pasteReader) endPaste() {
+	if p.newlines > 0 {
+		p.out = append(p.out, '\r')
+	}
+	p.pasting, p.newlines, p.cr = false, 0, false
+}
+
+// chainLine matches a line that continues the previous query with a method
+// call, as opposed to a dot-command.
+var chainLine = regexp.MustCompile(`^\.\w+\(`)
+
+// splitPaste splits pasted text into the queries and dot-commands it holds.
+// A query ends at the first line that balances its brackets, unless the
+// next non-blank line continues it with a method call (".filter(...)").
+// A dot-command is one line. Blank lines between statements are dropped;
+// the last query may be incomplete.
+func splitPaste(text string) []string {
+	var stmts, cur []string
+	flush := func() {
+		if len(cur) > 0 {
+			stmts = append(stmts, strings.Join(cur, "\n"))
+			cur = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			if len(cur) > 0 {
+				cur = append(cur, line)
+			}
+			continue
+		}
+		chain := chainLine.MatchString(trimmed)
+		if len(cur) > 0 && !chain && isComplete(strings.Join(cur, "\n")) {
+			flush()
+		}
+		if len(cur) == 0 && strings.HasPrefix(trimmed, ".") && !chain {
+			stmts = append(stmts, trimmed)
+			continue
+		}
+		cur = append(cur, line)
+	}
+	flush()
+	for i, s := range stmts {
+		stmts[i] = strings.TrimRight(s, " \t\r\n")
+	}
+	return stmts
+}
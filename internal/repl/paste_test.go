@@ -0,0 +1,130 @@
+package repl
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// chunkReader returns its chunks one Read at a time.
+type chunkReader struct {
+	chunks []string
+}
+
+func (c *chunkReader) Read(b []byte) (int, error) {
+	if len(c.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, c.chunks[0])
+	c.chunks[0] = c.chunks[0][n:]
+	if c.chunks[0] == "" {
+		c.chunks = c.chunks[1:]
+	}
+	return n, nil
+}
+
+func (c *chunkReader) Close() error { return nil }
+
+func readPaste(t *testing.T, chunks ...string) string {
+	t.Helper()
+	got, err := io.ReadAll(newPasteReader(&chunkReader{chunks: chunks}))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return string(got)
+}
+
+func TestPasteReader(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{"typed", []string{"r.now()\r"}, "r.now()\r"},
+		{"arrow keys pass", []string{"\x1b[A\x1b"}, "\x1b[A\x1b"},
+		{"one line", []string{pasteStart + "r.now()" + pasteEnd}, "r.now()"},
+		{"trailing break submits", []string{pasteStart + "r.now()\r\r" + pasteEnd}, "r.now()\r"},
+		{"breaks and tabs", []string{pasteStart + "r.table(\"a\")\r\t.count()\r" + pasteEnd}, "r.table(\"a\")↵⇥.count()\r"},
+		{"crlf", []string{pasteStart + "a\r\nb\n" + pasteEnd}, "a↵b\r"},
+		{"end marker split", []string{pasteStart + "a\rb\x1b[20", "1~x"}, "a↵bx"},
+		{"escape inside paste", []string{pasteStart + "\x1b[20x" + pasteEnd}, "\x1b[20x"},
+		{"unterminated", []string{pasteStart + "a\rb\x1b[2"}, "a↵b\x1b[2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := readPaste(t, tt.chunks...); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestorePaste(t *testing.T) {
+	t.Parallel()
+	if got := restorePaste("a↵⇥b"); got != "a\n\tb" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSplitPaste(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"one query", "r.table(\"a\")\n  .filter({x: 1})\n  .count()", []string{"r.table(\"a\")\n  .filter({x: 1})\n  .count()"}},
+		{"two queries", "r.now()\n\nr.dbList()\n", []string{"r.now()", "r.dbList()"}},
+		{"open brackets", "r.table(\"a\").insert({\n  x: 1\n})\nr.now()", []string{"r.table(\"a\").insert({\n  x: 1\n})", "r.now()"}},
+		{"dot-commands", ".use test\nr.table(\"a\")\n.format json", []string{".use test", "r.table(\"a\")", ".format json"}},
+		{"incomplete last", "r.now()\nr.table(\n", []string{"r.now()", "r.table("}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := splitPaste(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplPaste(t *testing.T) {
+	t.Parallel()
+	var exprs []string
+	var db string
+	fr := &fakeReader{lines: []string{
+		".use test\nr.table(\"a\")\n  .count()\nr.table(\n",
+		"\"b\")",
+	}}
+	r := New(&Config{
+		Reader: fr,
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			exprs = append(exprs, expr)
+			return nil
+		},
+		OnUseDB: func(name string) { db = name },
+		Out:     io.Discard,
+		ErrOut:  io.Discard,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db != "test" {
+		t.Errorf("db = %q, want test", db)
+	}
+	want := []string{"r.table(\"a\")\n  .count()", "r.table(\n\"b\")"}
+	if !reflect.DeepEqual(exprs, want) {
+		t.Errorf("exprs = %q, want %q", exprs, want)
+	}
+	if !reflect.DeepEqual(fr.history, want) {
+		t.Errorf("history = %q, want %q", fr.history, want)
+	}
+	if !strings.Contains(strings.Join(fr.prompts, ","), contPrompt) {
+		t.Errorf("continuation prompt not seen; prompts: %v", fr.prompts)
+	}
+}
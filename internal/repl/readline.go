@@ -2,20 +2,39 @@ package repl
 
 import (
 	"errors"
+	"fmt"
 	"io"
 
 	"github.com/chzyer/readline"
 )
 
+// Key bindings of the line editor.
+const (
+	KeymapEmacs = "emacs"
+	KeymapVi    = "vi"
+)
+
+// KeymapReader is implemented by Readers whose key bindings can be switched
+// between KeymapEmacs and KeymapVi.
+type KeymapReader interface {
+	SetKeymap(keymap string) error
+}
+
 // readlineReader wraps *readline.Instance to implement Reader.
 type readlineReader struct {
-	rl *readline.Instance
+	rl    *readline.Instance
+	out   io.Writer
+	paste bool // bracketed paste is enabled on the terminal
 }
 
 // NewReadlineReader creates a Reader backed by github.com/chzyer/readline.
 // interruptHook is called (non-blocking) when Ctrl+C is pressed; pass nil to disable.
+// highlight colors the input line as it is edited, see Highlight.
 // An optional TabCompleter may be passed to enable tab completion.
-func NewReadlineReader(prompt, historyFile string, out, errOut io.Writer, interruptHook func(), completer ...TabCompleter) (Reader, error) {
+// On a terminal, bracketed paste is enabled: a multiline paste is edited as
+// one line, and Readline returns it with its line breaks (see pasteReader).
+// Word-wise movement and deletion step over ReQL units (see wordListener).
+func NewReadlineReader(prompt, historyFile string, out, errOut io.Writer, interruptHook func(), highlight bool, completer ...TabCompleter) (Reader, error) {
 	var ac readline.AutoCompleter
 	if len(completer) > 0 && completer[0] != nil {
 		ac = completer[0]
@@ -24,11 +43,20 @@ func NewReadlineReader(prompt, historyFile string, out, errOut io.Writer, interr
 		Prompt:                 prompt,
 		HistoryFile:            historyFile,
 		DisableAutoSaveHistory: true,
+		HistorySearchFold:      true, // case-insensitive Ctrl+R
 		InterruptPrompt:        "^C",
 		EOFPrompt:              "exit",
 		Stdout:                 out,
 		Stderr:                 errOut,
 		AutoComplete:           ac,
+		Listener:               &wordListener{},
+	}
+	paste := readline.DefaultIsTerminal()
+	if paste {
+		cfg.Stdin = newPasteReader(readline.NewCancelableStdin(readline.Stdin))
+	}
+	if highlight {
+		cfg.Painter = highlightPainter{}
 	}
 	if interruptHook != nil {
 		cfg.FuncFilterInputRune = func(r rune) (rune, bool) {
@@ -42,7 +70,10 @@ func NewReadlineReader(prompt, historyFile string, out, errOut io.Writer, interr
 	if err != nil {
 		return nil, err
 	}
-	return &readlineReader{rl: rl}, nil
+	if paste {
+		_, _ = io.WriteString(out, enablePaste)
+	}
+	return &readlineReader{rl: rl, out: out, paste: paste}, nil
 }
 
 func (r *readlineReader) Readline() (string, error) {
@@ -50,17 +81,44 @@ func (r *readlineReader) Readline() (string, error) {
 	if errors.Is(err, readline.ErrInterrupt) {
 		return "", ErrInterrupt
 	}
-	return line, err
+	return restorePaste(line), err
 }
 
 func (r *readlineReader) SetPrompt(prompt string) {
 	r.rl.SetPrompt(prompt)
 }
 
+// AddHistory saves line as one history entry; multiline expressions are
+// flattened by historyEntry.
 func (r *readlineReader) AddHistory(line string) error {
-	return r.rl.SaveHistory(line)
+	return r.rl.SaveHistory(historyEntry(line))
+}
+
+// ReadPassword reads a line without echoing it.
+func (r *readlineReader) ReadPassword(prompt string) (string, error) {
+	pwd, err := r.rl.ReadPassword(prompt)
+	if errors.Is(err, readline.ErrInterrupt) {
+		return "", ErrInterrupt
+	}
+	return string(pwd), err
+}
+
+// SetKeymap switches between emacs and vi (modal) editing of the line.
+func (r *readlineReader) SetKeymap(keymap string) error {
+	switch keymap {
+	case KeymapEmacs:
+		r.rl.SetVimMode(false)
+	case KeymapVi:
+		r.rl.SetVimMode(true)
+	default:
+		return fmt.Errorf("invalid keymap %q, must be %s or %s", keymap, KeymapEmacs, KeymapVi)
+	}
+	return nil
 }
 
 func (r *readlineReader) Close() error {
+	if r.paste {
+		_, _ = io.WriteString(r.out, disablePaste)
+	}
 	return r.rl.Close()
 }
@@ -17,7 +17,7 @@ import (
 // It provides a no-op stdin so readline doesn't try to set terminal raw mode.
 func newTestReadlineReader(t *testing.T, historyFile string) (Reader, bool) {
 	t.Helper()
-	r, err := NewReadlineReader("r> ", historyFile, io.Discard, io.Discard, nil)
+	r, err := NewReadlineReader("r> ", historyFile, io.Discard, io.Discard, nil, true)
 	if err != nil {
 		t.Logf("readline init failed (no TTY): %v", err)
 		return nil, false
@@ -131,6 +131,32 @@ func TestReadlineHistoryPersists(t *testing.T) {
 	}
 }
 
+// TestReadlineHistoryMultiline verifies that a multiline expression is stored
+// as one history line, so later sessions recall it whole.
+func TestReadlineHistoryMultiline(t *testing.T) {
+	t.Parallel()
+
+	histFile := histTempFile(t)
+	r, ok := newTestReadlineReader(t, histFile)
+	if !ok {
+		t.Skip("readline unavailable in this environment")
+	}
+	if err := r.AddHistory("r.table(\"t\")\n  .count()"); err != nil {
+		t.Fatalf("AddHistory: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(histFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "r.table(\"t\") .count()\n" {
+		t.Errorf("history file = %q, want one line", got)
+	}
+}
+
 func histTempFile(t *testing.T) string {
 	t.Helper()
 	f, err := os.CreateTemp(t.TempDir(), "hist")
@@ -142,3 +168,26 @@ func histTempFile(t *testing.T) string {
 	}
 	return f.Name()
 }
+
+func TestReadlineSetKeymap(t *testing.T) {
+	t.Parallel()
+	r, ok := newTestReadlineReader(t, "")
+	if !ok {
+		t.Skip("readline unavailable in this environment")
+	}
+	defer func() { _ = r.Close() }()
+	kr, ok := r.(KeymapReader)
+	if !ok {
+		t.Fatal("readline reader does not implement KeymapReader")
+	}
+	rl := r.(*readlineReader).rl
+	if err := kr.SetKeymap(KeymapVi); err != nil || !rl.IsVimMode() {
+		t.Errorf("SetKeymap(vi) = %v, vim mode %v", err, rl.IsVimMode())
+	}
+	if err := kr.SetKeymap(KeymapEmacs); err != nil || rl.IsVimMode() {
+		t.Errorf("SetKeymap(emacs) = %v, vim mode %v", err, rl.IsVimMode())
+	}
+	if err := kr.SetKeymap("nano"); err == nil {
+		t.Error("SetKeymap(nano) succeeded")
+	}
+}
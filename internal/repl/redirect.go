@@ -0,0 +1,39 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+)
+
+// outputCommand runs .output <file|->: results of later queries, listings
+// and .info go to file, truncated when opened, or back to Out for "-".
+// Errors and .help always go to the terminal.
+func (r *Repl) outputCommand(args []string) {
+	if len(args) != 1 {
+		_, _ = fmt.Fprintln(r.errOut, "usage: .output <file|->")
+		return
+	}
+	r.closeOutput()
+	if args[0] == "-" {
+		return
+	}
+	f, err := os.Create(args[0])
+	if err != nil {
+		_, _ = fmt.Fprintf(r.errOut, ".output: %v\n", err)
+		return
+	}
+	r.outFile = f
+	r.out = f
+}
+
+// closeOutput closes the .output file, if any, and restores Out.
+func (r *Repl) closeOutput() {
+	if r.outFile == nil {
+		return
+	}
+	if err := r.outFile.Close(); err != nil {
+		_, _ = fmt.Fprintf(r.errOut, ".output: %v\n", err)
+	}
+	r.outFile = nil
+	r.out = r.term
+}
@@ -0,0 +1,84 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplOutputRedirect(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "out.json")
+	var out, errOut bytes.Buffer
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{"1", ".output " + path, "2", "bad", ".help", ".output -", "3"}},
+		Exec: func(_ context.Context, expr string, w io.Writer) error {
+			if expr == "bad" {
+				return fmt.Errorf("query failed")
+			}
+			_, err := fmt.Fprintln(w, expr)
+			return err
+		},
+		Out:    &out,
+		ErrOut: &errOut,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "2\n" {
+		t.Errorf("file = %q, want %q", data, "2\n")
+	}
+	if !strings.HasPrefix(out.String(), "1\nAvailable commands:") || !strings.HasSuffix(out.String(), "\n3\n") {
+		t.Errorf("out = %q", out.String())
+	}
+	if errOut.String() != "query failed\n" {
+		t.Errorf("errOut = %q", errOut.String())
+	}
+}
+
+func TestReplOutputClosedOnExit(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "out.json")
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{".output " + path, "1", ".exit"}},
+		Exec: func(_ context.Context, expr string, w io.Writer) error {
+			_, err := fmt.Fprintln(w, expr)
+			return err
+		},
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if r.outFile != nil {
+		t.Error(".output file still open after .exit")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "1\n" {
+		t.Errorf("file = %q, %v", data, err)
+	}
+}
+
+func TestReplOutputErrors(t *testing.T) {
+	t.Parallel()
+	for _, line := range []string{".output", ".output a b", ".output " + filepath.Join(t.TempDir(), "missing", "out.json")} {
+		var errOut bytes.Buffer
+		r := New(&Config{Reader: &fakeReader{lines: []string{line}}, ErrOut: &errOut})
+		if err := r.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if errOut.Len() == 0 {
+			t.Errorf("%q: no error printed", line)
+		}
+		if r.outFile != nil {
+			t.Errorf("%q: output redirected", line)
+		}
+	}
+}
@@ -2,11 +2,16 @@
 package repl
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"os"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // ErrInterrupt is returned by Reader.Readline when the user presses Ctrl+C.
@@ -31,27 +36,101 @@ type Config struct {
 	ErrOut      io.Writer
 	InterruptCh <-chan struct{} // receives when user interrupts during query execution
 	Prompt      string
-	OnUseDB     func(db string)     // called when .use <db> is executed
-	OnFormat    func(format string) // called when .format <fmt> is executed
-	ShowHint    bool                // print available dot-commands to errOut on startup
+	// PromptFunc, when set, renders the prompt before each new query, so
+	// it can follow .use, .connect and .set; Prompt is then unused.
+	PromptFunc func() string
+	OnUseDB    func(db string)     // called when .use <db> is executed
+	OnFormat   func(format string) // called when .format <fmt> is executed
+	OnRefresh  func()              // called when .refresh is executed
+	OnReset    func()              // called when .reset is executed, to drop the variables
+	OnPager    func(on bool)       // called when .pager on|off is executed
+	Options    Options             // backs .set and .show
+	List       Lister              // backs .dbs, .tables and .indexes
+	// TableInfo writes the .info summary of a table; an empty db means the
+	// current database.
+	TableInfo func(ctx context.Context, db, table string, w io.Writer) error
+	// TableSchema writes the .schema tree inferred from up to sample
+	// documents of a table; an empty db means the current database.
+	TableSchema func(ctx context.Context, db, table string, sample int, w io.Writer) error
+	// Status describes the connection for .status: its state, the server
+	// version and the open changefeeds and cursors.
+	Status func() string
+	// Editor opens path in an editor and returns when it exits; nil runs
+	// $VISUAL or $EDITOR, falling back to vi.
+	Editor func(path string) error
+	// Connect switches the session to another server for .connect and
+	// returns the prompt to show from then on.
+	Connect func(ctx context.Context, t Target) (prompt string, err error)
+	// Let runs expr like Exec and binds its result to name for .let.
+	Let func(ctx context.Context, name, expr string, w io.Writer) error
+	// Watch streams the changefeed of expr to w for .watch until ctx is
+	// cancelled.
+	Watch ExecFunc
+	// Confirm returns the question to ask before running expr, such as
+	// for a query that deletes documents, or "" to run it right away.
+	Confirm func(expr string) string
+	// Aliases are the .alias macros defined in earlier sessions.
+	Aliases map[string]string
+	// SaveAlias persists an alias defined with .alias; an empty text
+	// removes it.
+	SaveAlias func(name, text string) error
+	// Batch runs a script rather than a session, see NewBatchReader: Run
+	// returns the error of the first failing query, unprinted, or with
+	// ContinueOnError prints every error and returns ErrBatchFailed at the
+	// end. A cancelled ctx stops the run.
+	Batch           bool
+	ContinueOnError bool
+	// Color paints error reports, see writeError.
+	Color    bool
+	ShowHint bool // print available dot-commands to errOut on startup
 }
 
 // Repl is the interactive REPL.
 type Repl struct {
 	reader      Reader
 	exec        ExecFunc
-	out         io.Writer
+	out         io.Writer // results: term or the .output file
+	term        io.Writer // Out
+	outFile     *os.File  // set while .output redirects results
 	errOut      io.Writer
 	interruptCh <-chan struct{}
 	prompt      string
+	promptFunc  func() string
 	onUseDB     func(db string)
 	onFormat    func(format string)
+	onRefresh   func()
+	onReset     func()
+	onPager     func(on bool)
+	options     Options
+	list        Lister
+	tableInfo   func(ctx context.Context, db, table string, w io.Writer) error
+	tableSchema func(ctx context.Context, db, table string, sample int, w io.Writer) error
+	status      func() string
+	connect     func(ctx context.Context, t Target) (string, error)
+	editor      func(path string) error
+	let         func(ctx context.Context, name, expr string, w io.Writer) error
+	watch       ExecFunc
+	confirm     func(expr string) string
+	aliases     map[string]string
+	saveAlias   func(name, text string) error
+	last        string        // the last query run, the starting text of .edit
+	running     string        // the query being run, with aliases expanded, for error reports
+	lastRun     time.Time     // when the last query finished, for .status
+	lastTook    time.Duration // how long it ran
+	now         func() time.Time
+	color       bool
 	showHint    bool
+	batch       bool
+	keepGoing   bool  // ContinueOnError
+	failed      error // the first error of a batch run
 }
 
 // New creates a Repl from Config.
 func New(cfg *Config) *Repl {
 	prompt := cfg.Prompt
+	if cfg.PromptFunc != nil {
+		prompt = cfg.PromptFunc()
+	}
 	if prompt == "" {
 		prompt = "r> "
 	}
@@ -71,16 +150,57 @@ func New(cfg *Config) *Repl {
 	if onFormat == nil {
 		onFormat = func(string) {}
 	}
+	onRefresh := cfg.OnRefresh
+	if onRefresh == nil {
+		onRefresh = func() {}
+	}
+	onReset := cfg.OnReset
+	if onReset == nil {
+		onReset = func() {}
+	}
+	editor := cfg.Editor
+	if editor == nil {
+		editor = runEditor
+	}
+	onPager := cfg.OnPager
+	if onPager == nil {
+		onPager = func(bool) {}
+	}
+	aliases := maps.Clone(cfg.Aliases)
+	if aliases == nil {
+		aliases = map[string]string{}
+	}
 	return &Repl{
 		reader:      cfg.Reader,
 		exec:        cfg.Exec,
 		out:         out,
+		term:        out,
 		errOut:      errOut,
 		interruptCh: cfg.InterruptCh,
 		prompt:      prompt,
+		promptFunc:  cfg.PromptFunc,
 		onUseDB:     onUseDB,
 		onFormat:    onFormat,
+		onRefresh:   onRefresh,
+		onReset:     onReset,
+		onPager:     onPager,
+		options:     cfg.Options,
+		list:        cfg.List,
+		tableInfo:   cfg.TableInfo,
+		tableSchema: cfg.TableSchema,
+		status:      cfg.Status,
+		now:         time.Now,
+		connect:     cfg.Connect,
+		editor:      editor,
+		let:         cfg.Let,
+		watch:       cfg.Watch,
+		confirm:     cfg.Confirm,
+		aliases:     aliases,
+		saveAlias:   cfg.SaveAlias,
 		showHint:    cfg.ShowHint,
+		color:       cfg.Color,
+		batch:       cfg.Batch,
+		keepGoing:   cfg.ContinueOnError,
 	}
 }
 
@@ -91,8 +211,28 @@ func printHelp(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "Available commands:")
 	_, _ = fmt.Fprintln(w, "  .exit, .quit          exit the REPL")
 	_, _ = fmt.Fprintln(w, "  .use <database>       change current database")
-	_, _ = fmt.Fprintln(w, "  .format <fmt>         set output format (json|jsonl|raw|table)")
-	_, _ = fmt.Fprintln(w, "  .help                 show this help")
+	_, _ = fmt.Fprintln(w, "  .format <fmt>         set output format (json|jsonl|raw|table|tsv)")
+	_, _ = fmt.Fprintln(w, "  .dbs                  list databases")
+	_, _ = fmt.Fprintln(w, "  .tables [database]    list tables of the current or given database")
+	_, _ = fmt.Fprintln(w, "  .indexes <table>      list secondary indexes of a table (db.table or table)")
+	_, _ = fmt.Fprintln(w, "  .info <table>         show primary key, shards, document count and indexes of a table")
+	_, _ = fmt.Fprintln(w, "  .schema <table>       infer fields, types and optionality from sampled documents (--sample N)")
+	_, _ = fmt.Fprintln(w, "  .connect <host>       switch server (host[:port] [--user <user>], asks for the password)")
+	_, _ = fmt.Fprintln(w, "  .edit [file]          compose queries in $EDITOR and run them (separate queries with ---)")
+	_, _ = fmt.Fprintln(w, "  .source <file>        run the queries of a file (separated by ---) in this session")
+	_, _ = fmt.Fprintln(w, "  .let <name> = <query>  run a query and name its result (the last result is always _)")
+	_, _ = fmt.Fprintln(w, "  .watch <table|query>  stream the changes of a table or query until Ctrl+C")
+	_, _ = fmt.Fprintln(w, "  .alias [name [text]]  list aliases, or make name expand to text in queries (saved in the config file)")
+	_, _ = fmt.Fprintln(w, "  .unalias <name>       remove an alias")
+	_, _ = fmt.Fprintln(w, "  .output <file|->      write results to a file, or back to the terminal with -")
+	_, _ = fmt.Fprintln(w, "  .pager <on|off>       page results taller than the terminal through $PAGER (default on)")
+	_, _ = fmt.Fprintln(w, "  .set <name> <value>   change a session setting for later queries (.set alone lists them)")
+	_, _ = fmt.Fprintln(w, "  .show                 show the session settings")
+	_, _ = fmt.Fprintln(w, "  .status               show the connection state, server version, open changefeeds and last query time")
+	_, _ = fmt.Fprintln(w, "  .clear                clear the screen")
+	_, _ = fmt.Fprintln(w, "  .reset                drop an unfinished multiline query and all variables (also mid-query)")
+	_, _ = fmt.Fprintln(w, "  .refresh              reload database, table and index names for completion")
+	_, _ = fmt.Fprintln(w, "  .help [method]        show this help, or the signature and an example of a method")
 }
 
 // readLine wraps Readline and normalizes errors.
@@ -117,11 +257,15 @@ func (r *Repl) Run(ctx context.Context) error {
 	if r.showHint {
 		printHelp(r.errOut)
 	}
+	defer r.closeOutput()
 	r.reader.SetPrompt(r.prompt)
 	var lines []string
 	for {
 		line, cont, err := r.readLine()
 		if !cont {
+			if err == nil && r.batch {
+				return r.finishBatch(ctx, lines)
+			}
 			return err
 		}
 		if errors.Is(err, ErrInterrupt) {
@@ -129,35 +273,101 @@ func (r *Repl) Run(ctx context.Context) error {
 			r.reader.SetPrompt(r.prompt)
 			continue
 		}
-
-		if len(lines) == 0 {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			if strings.HasPrefix(line, ".") {
-				if r.dotCommand(line) {
-					return nil
+		// a line break can only come from a paste, see pasteReader; unless
+		// it continues an unfinished query, run its statements one by one
+		stmts := []string{line}
+		if len(lines) == 0 && strings.Contains(line, "\n") {
+			stmts = splitPaste(line)
+		}
+		for _, stmt := range stmts {
+			if r.handleLine(ctx, stmt, &lines) {
+				if r.batch {
+					return r.finishBatch(ctx, nil)
 				}
-				continue
+				return nil
+			}
+			if r.batch && (ctx.Err() != nil || r.failed != nil && !r.keepGoing) {
+				return cmp.Or(ctx.Err(), r.failed)
 			}
 		}
+	}
+}
 
-		lines = append(lines, line)
-		input := strings.Join(lines, "\n")
+// finishBatch ends a batch run at .exit or the end of its input: a query
+// left incomplete still runs, so its parse error is reported.
+func (r *Repl) finishBatch(ctx context.Context, lines []string) error {
+	if len(lines) > 0 {
+		_ = r.runQuery(ctx, strings.TrimSpace(strings.Join(lines, "\n")))
+	}
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case r.failed != nil && r.keepGoing:
+		return ErrBatchFailed
+	}
+	return r.failed
+}
 
-		if !isComplete(input) {
-			r.reader.SetPrompt(contPrompt)
-			continue
+// handleLine adds line to the pending lines of a multiline query and runs
+// the query once it is complete; on a fresh line it runs dot-commands.
+// Returns true if the REPL should exit.
+func (r *Repl) handleLine(ctx context.Context, line string, lines *[]string) bool {
+	if strings.TrimSpace(line) == ".reset" {
+		r.resetCommand(lines)
+		return false
+	}
+	if len(*lines) == 0 {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return false
 		}
+		if strings.HasPrefix(line, ".") {
+			if r.dotCommand(ctx, line) {
+				return true
+			}
+			r.updatePrompt()
+			return false
+		}
+	}
+
+	*lines = append(*lines, line)
+	input := strings.Join(*lines, "\n")
+
+	if !isComplete(input) {
+		r.reader.SetPrompt(continuationPrompt(r.prompt))
+		return false
+	}
 
-		r.reader.SetPrompt(r.prompt)
-		lines = lines[:0]
+	r.reader.SetPrompt(r.prompt)
+	*lines = (*lines)[:0]
+
+	expr := strings.TrimSpace(input)
+	_ = r.reader.AddHistory(expr)
+	_ = r.runQuery(ctx, expr)
+	r.updatePrompt()
+	return false
+}
+
+// updatePrompt renders the prompt with PromptFunc, if set, and shows it
+// when it changed.
+func (r *Repl) updatePrompt() {
+	if r.promptFunc == nil {
+		return
+	}
+	if p := r.promptFunc(); p != r.prompt {
+		r.prompt = p
+		r.reader.SetPrompt(p)
+	}
+}
 
-		expr := strings.TrimSpace(input)
-		_ = r.reader.AddHistory(expr)
-		r.runQuery(ctx, expr)
+// continuationPrompt is contPrompt, right-aligned under prompt so the lines
+// of a multiline query start in the same column.
+func continuationPrompt(prompt string) string {
+	pad := utf8.RuneCountInString(prompt) - len(contPrompt)
+	if pad <= 0 {
+		return contPrompt
 	}
+	return strings.Repeat(" ", pad) + contPrompt
 }
 
 // isComplete returns true when all parentheses, braces, and brackets are balanced.
@@ -192,32 +402,92 @@ func isComplete(s string) bool {
 }
 
 // dotCommand dispatches a REPL dot-command. Returns true if the REPL should exit.
-func (r *Repl) dotCommand(line string) bool {
+func (r *Repl) dotCommand(ctx context.Context, line string) bool {
 	parts := strings.Fields(line)
 	switch parts[0] {
 	case ".exit", ".quit":
 		return true
+	case ".connect":
+		r.connectCommand(ctx, parts[1:])
+	case ".edit":
+		r.editCommand(ctx, parts[1:])
+	case ".source":
+		r.sourceCommand(ctx, parts[1:])
+	case ".let":
+		r.letCommand(ctx, strings.TrimSpace(strings.TrimPrefix(line, parts[0])))
+	case ".watch":
+		r.watchCommand(ctx, strings.TrimSpace(strings.TrimPrefix(line, parts[0])))
+	case ".alias":
+		r.aliasCommand(strings.TrimSpace(strings.TrimPrefix(line, parts[0])))
+	case ".unalias":
+		r.unaliasCommand(parts[1:])
+	case ".refresh":
+		r.onRefresh()
+	case ".status":
+		r.statusCommand()
+	case ".clear":
+		r.clearCommand()
+	case ".help":
+		r.helpCommand(parts[1:])
+	default:
+		if !r.settingCommand(parts) && !r.schemaCommand(ctx, parts) {
+			_, _ = fmt.Fprintf(r.errOut, "unknown command: %s\n", parts[0])
+		}
+	}
+	return false
+}
+
+// settingCommand runs the commands that change how later queries run or
+// print: .use, .format, .output, .pager, .set and .show. It reports false
+// for any other command.
+func (r *Repl) settingCommand(parts []string) bool {
+	switch parts[0] {
 	case ".use":
 		if len(parts) < 2 {
 			_, _ = fmt.Fprintln(r.errOut, "usage: .use <database>")
-			return false
+			return true
 		}
 		r.onUseDB(parts[1])
 	case ".format":
 		if len(parts) < 2 {
-			_, _ = fmt.Fprintln(r.errOut, "usage: .format <json|jsonl|raw|table>")
-			return false
+			_, _ = fmt.Fprintln(r.errOut, "usage: .format <json|jsonl|raw|table|tsv>")
+			return true
 		}
 		r.onFormat(parts[1])
-	case ".help":
-		printHelp(r.out)
+	case ".output":
+		r.outputCommand(parts[1:])
+	case ".pager":
+		r.pagerCommand(parts[1:])
+	case ".set":
+		r.setCommand(parts[1:])
+	case ".show":
+		r.showSettings()
 	default:
-		_, _ = fmt.Fprintf(r.errOut, "unknown command: %s\n", parts[0])
+		return false
 	}
-	return false
+	return true
+}
+
+// runQuery runs expr, once confirmed, and returns its error, which has
+// already been printed.
+func (r *Repl) runQuery(ctx context.Context, expr string) error {
+	r.last = expr
+	expr = expandAliases(expr, r.aliases)
+	if !r.confirmed(expr) {
+		return errNotConfirmed
+	}
+	r.running = expr
+	var err error
+	r.interruptible(ctx, func(queryCtx context.Context) error {
+		err = r.timed(func() error { return r.exec(queryCtx, expr, r.out) })
+		return err
+	})
+	return err
 }
 
-func (r *Repl) runQuery(ctx context.Context, expr string) {
+// interruptible runs fn with a context that an interrupt cancels and prints
+// the error fn returns, unless it was cancelled.
+func (r *Repl) interruptible(ctx context.Context, fn func(context.Context) error) {
 	// drain any stale interrupt signal queued while readline was waiting for input
 	for len(r.interruptCh) > 0 {
 		<-r.interruptCh
@@ -227,15 +497,25 @@ func (r *Repl) runQuery(ctx context.Context, expr string) {
 
 	done := r.watchInterrupt(queryCtx, cancel)
 
-	if err := r.exec(queryCtx, expr, r.out); err != nil {
-		if !errors.Is(err, context.Canceled) {
-			_, _ = fmt.Fprintln(r.errOut, err)
-		}
+	if err := fn(queryCtx); err != nil && !errors.Is(err, context.Canceled) {
+		r.fail(err)
 	}
+	r.running = ""
 	cancel() // unblock watchInterrupt goroutine via queryCtx.Done()
 	<-done
 }
 
+// fail reports the error of a query. A batch run keeps the first one and
+// leaves it to Run to return, unless it continues on errors.
+func (r *Repl) fail(err error) {
+	if r.batch && r.failed == nil {
+		r.failed = err
+	}
+	if !r.batch || r.keepGoing {
+		writeError(r.errOut, r.running, err, r.color)
+	}
+}
+
 // watchInterrupt starts a goroutine that cancels queryCtx on interrupt.
 // Returns a channel closed when the goroutine exits.
 // If interruptCh is nil, returns an already-closed channel.
@@ -342,6 +342,42 @@ func TestReplDotFormat(t *testing.T) {
 	}
 }
 
+func TestReplDotRefresh(t *testing.T) {
+	t.Parallel()
+	refreshes := 0
+	r := New(&Config{
+		Reader:    &fakeReader{lines: []string{".refresh", ".refresh"}},
+		Exec:      func(_ context.Context, _ string, _ io.Writer) error { return nil },
+		OnRefresh: func() { refreshes++ },
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refreshes != 2 {
+		t.Errorf("OnRefresh called %d times, want 2", refreshes)
+	}
+}
+
+func TestReplDotPager(t *testing.T) {
+	t.Parallel()
+	var got []bool
+	var errOut bytes.Buffer
+	r := New(&Config{
+		Reader:  &fakeReader{lines: []string{".pager off", ".pager on", ".pager", ".pager maybe"}},
+		ErrOut:  &errOut,
+		OnPager: func(on bool) { got = append(got, on) },
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(got) != "[false true]" {
+		t.Errorf("OnPager calls = %v, want [false true]", got)
+	}
+	if n := strings.Count(errOut.String(), "usage: .pager"); n != 2 {
+		t.Errorf("usage printed %d times, want 2: %q", n, errOut.String())
+	}
+}
+
 func TestReplDotHelp(t *testing.T) {
 	t.Parallel()
 	var out bytes.Buffer
@@ -355,7 +391,7 @@ func TestReplDotHelp(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	output := out.String()
-	for _, want := range []string{".exit", ".quit", ".use", ".format", ".help"} {
+	for _, want := range []string{".exit", ".quit", ".use", ".format", ".refresh", ".help"} {
 		if !strings.Contains(output, want) {
 			t.Errorf(".help output missing %q; got: %q", want, output)
 		}
@@ -562,7 +598,7 @@ func TestReplShowHintTrue(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	output := errOut.String()
-	for _, want := range []string{".exit", ".quit", ".use", ".format", ".help"} {
+	for _, want := range []string{".exit", ".quit", ".use", ".format", ".refresh", ".help"} {
 		if !strings.Contains(output, want) {
 			t.Errorf("startup hint missing %q; got: %q", want, output)
 		}
@@ -626,3 +662,36 @@ func TestReplCtrlCDuringExecution(t *testing.T) {
 		t.Fatal("REPL did not exit after interrupt")
 	}
 }
+
+func TestReplPromptFunc(t *testing.T) {
+	t.Parallel()
+	db := "app"
+	reader := &fakeReader{lines: []string{".use logs", "r.table(", `"a")`}}
+	r := New(&Config{
+		Reader:     reader,
+		Exec:       func(context.Context, string, io.Writer) error { return nil },
+		PromptFunc: func() string { return "admin@db1/" + db + "> " },
+		OnUseDB:    func(name string) { db = name },
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []string{"admin@db1/app> ", "admin@db1/logs> ", "            ... ", "admin@db1/logs> "}
+	if fmt.Sprint(reader.prompts) != fmt.Sprint(want) {
+		t.Errorf("prompts = %q, want %q", reader.prompts, want)
+	}
+}
+
+func TestContinuationPrompt(t *testing.T) {
+	t.Parallel()
+	for prompt, want := range map[string]string{
+		"r> ":           "... ",
+		"":              "... ",
+		"héllo> ":       "   ... ",
+		"r@db1:28015> ": "         ... ",
+	} {
+		if got := continuationPrompt(prompt); got != want {
+			t.Errorf("continuationPrompt(%q) = %q, want %q", prompt, got, want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+)
+
+// clearScreen moves the cursor home and erases the terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// clearCommand runs .clear: it erases the terminal, even while .output
+// sends results to a file. Batch runs have no screen and ignore it.
+func (r *Repl) clearCommand() {
+	if r.batch {
+		return
+	}
+	_, _ = io.WriteString(r.term, clearScreen)
+}
+
+// resetCommand runs .reset: it drops the lines of an unfinished query and
+// calls Config.OnReset to forget the variables of .let and _. It also runs
+// in the middle of a multiline query, so a bad paste can be thrown away.
+func (r *Repl) resetCommand(lines *[]string) {
+	dropped := len(*lines)
+	*lines = (*lines)[:0]
+	r.reader.SetPrompt(r.prompt)
+	r.onReset()
+	if dropped > 0 {
+		_, _ = fmt.Fprintf(r.errOut, "dropped %d unfinished lines; variables cleared\n", dropped)
+		return
+	}
+	_, _ = fmt.Fprintln(r.errOut, "variables cleared")
+}
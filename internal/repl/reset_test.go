@@ -0,0 +1,69 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestReplReset(t *testing.T) {
+	t.Parallel()
+	var errOut bytes.Buffer
+	var exprs []string
+	resets := 0
+	fr := &fakeReader{lines: []string{
+		`r.table("a").insert({`,
+		`  x: "broken`,
+		"  .reset",
+		"r.now()",
+		".reset",
+	}}
+	r := New(&Config{
+		Reader: fr,
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			exprs = append(exprs, expr)
+			return nil
+		},
+		OnReset: func() { resets++ },
+		ErrOut:  &errOut,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(exprs) != 1 || exprs[0] != "r.now()" {
+		t.Errorf("exprs = %q, want only r.now()", exprs)
+	}
+	if resets != 2 {
+		t.Errorf("OnReset called %d times, want 2", resets)
+	}
+	want := "dropped 2 unfinished lines; variables cleared\nvariables cleared\n"
+	if errOut.String() != want {
+		t.Errorf("errOut = %q, want %q", errOut.String(), want)
+	}
+	if fr.prompt != "r> " {
+		t.Errorf("prompt = %q, want the primary prompt", fr.prompt)
+	}
+}
+
+func TestReplClear(t *testing.T) {
+	t.Parallel()
+	for _, batch := range []bool{false, true} {
+		var out bytes.Buffer
+		r := New(&Config{
+			Reader: &fakeReader{lines: []string{".clear"}},
+			Out:    &out,
+			Batch:  batch,
+		})
+		if err := r.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		want := clearScreen
+		if batch {
+			want = ""
+		}
+		if out.String() != want {
+			t.Errorf("batch %v: out = %q, want %q", batch, out.String(), want)
+		}
+	}
+}
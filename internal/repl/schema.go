@@ -0,0 +1,147 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultSchemaSample is the number of documents .schema samples unless
+// --sample says otherwise.
+const defaultSchemaSample = 500
+
+// Lister fetches the names the .dbs, .tables and .indexes commands print.
+// An empty db means the current database. A nil func disables its command.
+type Lister struct {
+	DBs     func(ctx context.Context) ([]string, error)
+	Tables  func(ctx context.Context, db string) ([]string, error)
+	Indexes func(ctx context.Context, db, table string) ([]string, error)
+}
+
+// schemaCommand runs the commands that inspect the schema: .info, .schema
+// and the listings of listCommand. It reports false for any other command.
+func (r *Repl) schemaCommand(ctx context.Context, parts []string) bool {
+	if parts[0] == ".schema" {
+		r.inferSchema(ctx, parts)
+		return true
+	}
+	if parts[0] != ".info" {
+		return r.listCommand(ctx, parts)
+	}
+	switch {
+	case len(parts) < 2:
+		_, _ = fmt.Fprintln(r.errOut, "usage: .info <table|db.table>")
+	case r.tableInfo == nil:
+		_, _ = fmt.Fprintln(r.errOut, ".info: not available")
+	default:
+		db, table := splitTableRef(parts[1])
+		r.interruptible(ctx, func(ctx context.Context) error {
+			return r.tableInfo(ctx, db, table, r.out)
+		})
+	}
+	return true
+}
+
+// inferSchema runs .schema <[db.]table> [--sample N].
+func (r *Repl) inferSchema(ctx context.Context, parts []string) {
+	const usage = "usage: .schema <table|db.table> [--sample N]"
+	var ref string
+	sample := defaultSchemaSample
+	for i := 1; i < len(parts); i++ {
+		switch {
+		case parts[i] == "--sample" && i+1 < len(parts):
+			i++
+			n, err := strconv.Atoi(parts[i])
+			if err != nil || n < 1 {
+				_, _ = fmt.Fprintf(r.errOut, ".schema: invalid sample size %q\n", parts[i])
+				return
+			}
+			sample = n
+		case ref == "" && !strings.HasPrefix(parts[i], "-"):
+			ref = parts[i]
+		default:
+			_, _ = fmt.Fprintln(r.errOut, usage)
+			return
+		}
+	}
+	switch {
+	case ref == "":
+		_, _ = fmt.Fprintln(r.errOut, usage)
+	case r.tableSchema == nil:
+		_, _ = fmt.Fprintln(r.errOut, ".schema: not available")
+	default:
+		db, table := splitTableRef(ref)
+		r.interruptible(ctx, func(ctx context.Context) error {
+			return r.tableSchema(ctx, db, table, sample, r.out)
+		})
+	}
+}
+
+// listCommand runs .dbs, .tables [db] or .indexes <[db.]table> and prints
+// one name per line. It reports false for any other command.
+func (r *Repl) listCommand(ctx context.Context, parts []string) bool {
+	var (
+		fetch func(ctx context.Context) ([]string, error)
+		what  string
+	)
+	switch parts[0] {
+	case ".dbs":
+		fetch, what = r.list.DBs, "databases"
+	case ".tables":
+		db := ""
+		if len(parts) > 1 {
+			db = parts[1]
+		}
+		fetch, what = r.tablesOf(db), "tables"
+	case ".indexes":
+		if len(parts) < 2 {
+			_, _ = fmt.Fprintln(r.errOut, "usage: .indexes <table|db.table>")
+			return true
+		}
+		fetch, what = r.indexesOf(parts[1]), "indexes"
+	default:
+		return false
+	}
+	if fetch == nil {
+		_, _ = fmt.Fprintf(r.errOut, "%s: not available\n", parts[0])
+		return true
+	}
+	r.interruptible(ctx, func(ctx context.Context) error {
+		names, err := fetch(ctx)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			_, _ = fmt.Fprintf(r.errOut, "(no %s)\n", what)
+			return nil
+		}
+		_, err = fmt.Fprintln(r.out, strings.Join(names, "\n"))
+		return err
+	})
+	return true
+}
+
+func (r *Repl) tablesOf(db string) func(ctx context.Context) ([]string, error) {
+	if r.list.Tables == nil {
+		return nil
+	}
+	return func(ctx context.Context) ([]string, error) { return r.list.Tables(ctx, db) }
+}
+
+// indexesOf lists the indexes of ref, a table name or db.table.
+func (r *Repl) indexesOf(ref string) func(ctx context.Context) ([]string, error) {
+	if r.list.Indexes == nil {
+		return nil
+	}
+	db, table := splitTableRef(ref)
+	return func(ctx context.Context) ([]string, error) { return r.list.Indexes(ctx, db, table) }
+}
+
+// splitTableRef splits db.table; a plain table name has an empty db.
+func splitTableRef(ref string) (db, table string) {
+	if db, table, ok := strings.Cut(ref, "."); ok {
+		return db, table
+	}
+	return "", ref
+}
@@ -0,0 +1,130 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func runListCommands(t *testing.T, list Lister, lines ...string) (out, errOut string) {
+	t.Helper()
+	var o, e bytes.Buffer
+	r := New(&Config{
+		Reader: &fakeReader{lines: lines},
+		Exec:   func(_ context.Context, _ string, _ io.Writer) error { return nil },
+		Out:    &o,
+		ErrOut: &e,
+		List:   list,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return o.String(), e.String()
+}
+
+func TestReplListCommands(t *testing.T) {
+	t.Parallel()
+	var asked []string
+	list := Lister{
+		DBs: func(context.Context) ([]string, error) { return []string{"app", "test"}, nil },
+		Tables: func(_ context.Context, db string) ([]string, error) {
+			asked = append(asked, "tables "+db)
+			return []string{"users"}, nil
+		},
+		Indexes: func(_ context.Context, db, table string) ([]string, error) {
+			asked = append(asked, "indexes "+db+" "+table)
+			return nil, nil
+		},
+	}
+	out, errOut := runListCommands(t, list, ".dbs", ".tables", ".tables app", ".indexes users", ".indexes app.users", ".indexes")
+	if want := "app\ntest\nusers\nusers\n"; out != want {
+		t.Errorf("out = %q, want %q", out, want)
+	}
+	if want := "(no indexes)\n(no indexes)\nusage: .indexes <table|db.table>\n"; errOut != want {
+		t.Errorf("errOut = %q, want %q", errOut, want)
+	}
+	if want := "[tables  tables app indexes  users indexes app users]"; fmt.Sprint(asked) != want {
+		t.Errorf("asked = %s, want %s", fmt.Sprint(asked), want)
+	}
+}
+
+func TestReplListCommandErrors(t *testing.T) {
+	t.Parallel()
+	list := Lister{
+		DBs: func(context.Context) ([]string, error) { return nil, errors.New("connection refused") },
+	}
+	out, errOut := runListCommands(t, list, ".dbs", ".tables")
+	if out != "" {
+		t.Errorf("out = %q, want empty", out)
+	}
+	if want := "connection refused\n.tables: not available\n"; errOut != want {
+		t.Errorf("errOut = %q, want %q", errOut, want)
+	}
+}
+
+func TestReplInfoCommand(t *testing.T) {
+	t.Parallel()
+	var asked []string
+	var o, e bytes.Buffer
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{".info users", ".info app.users", ".info"}},
+		Exec:   func(_ context.Context, _ string, _ io.Writer) error { return nil },
+		Out:    &o,
+		ErrOut: &e,
+		TableInfo: func(_ context.Context, db, table string, w io.Writer) error {
+			asked = append(asked, db+"|"+table)
+			_, err := io.WriteString(w, "info\n")
+			return err
+		},
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if fmt.Sprint(asked) != "[|users app|users]" {
+		t.Errorf("asked = %v", asked)
+	}
+	if o.String() != "info\ninfo\n" || e.String() != "usage: .info <table|db.table>\n" {
+		t.Errorf("out = %q, errOut = %q", o.String(), e.String())
+	}
+	if _, errOut := runListCommands(t, Lister{}, ".info users"); errOut != ".info: not available\n" {
+		t.Errorf("without TableInfo: errOut = %q", errOut)
+	}
+}
+
+func TestReplSchemaCommand(t *testing.T) {
+	t.Parallel()
+	var asked []string
+	var o, e bytes.Buffer
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{
+			".schema users", ".schema app.users --sample 20", ".schema --sample 5 users",
+			".schema", ".schema users --sample 0", ".schema users extra",
+		}},
+		Exec:   func(_ context.Context, _ string, _ io.Writer) error { return nil },
+		Out:    &o,
+		ErrOut: &e,
+		TableSchema: func(_ context.Context, db, table string, sample int, w io.Writer) error {
+			asked = append(asked, fmt.Sprintf("%s|%s|%d", db, table, sample))
+			_, err := io.WriteString(w, "tree\n")
+			return err
+		},
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if fmt.Sprint(asked) != "[|users|500 app|users|20 |users|5]" {
+		t.Errorf("asked = %v", asked)
+	}
+	wantErr := "usage: .schema <table|db.table> [--sample N]\n" +
+		".schema: invalid sample size \"0\"\n" +
+		"usage: .schema <table|db.table> [--sample N]\n"
+	if o.String() != "tree\ntree\ntree\n" || e.String() != wantErr {
+		t.Errorf("out = %q, errOut = %q", o.String(), e.String())
+	}
+	if _, errOut := runListCommands(t, Lister{}, ".schema users"); errOut != ".schema: not available\n" {
+		t.Errorf("without TableSchema: errOut = %q", errOut)
+	}
+}
@@ -0,0 +1,53 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"r-cli/internal/reql/parser"
+)
+
+// sourceCommand runs .source <file>: the queries of file, split on "---"
+// lines like query -F, run in this session, so .use and the output
+// settings apply to them.
+func (r *Repl) sourceCommand(ctx context.Context, args []string) {
+	if len(args) != 1 {
+		_, _ = fmt.Fprintln(r.errOut, "usage: .source <file>")
+		return
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		_, _ = fmt.Fprintf(r.errOut, ".source: %v\n", err)
+		return
+	}
+	queries, err := parser.SplitQueries(f)
+	_ = f.Close()
+	if err != nil {
+		_, _ = fmt.Fprintf(r.errOut, ".source: %v\n", err)
+		return
+	}
+	r.runScript(ctx, queries)
+}
+
+// runScript echoes each query after the prompt and runs it; a failed query
+// prints its error and the script goes on, an interrupt skips the rest.
+// The number of failures is reported at the end.
+func (r *Repl) runScript(ctx context.Context, queries []string) {
+	failed := 0
+	for i, q := range queries {
+		_, _ = fmt.Fprintf(r.term, "%s%s\n", r.prompt, q)
+		err := r.runQuery(ctx, q)
+		if errors.Is(err, context.Canceled) {
+			_, _ = fmt.Fprintf(r.errOut, "interrupted; skipped %d of %d queries\n", len(queries)-i-1, len(queries))
+			return
+		}
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		_, _ = fmt.Fprintf(r.errOut, "%d of %d queries failed\n", failed, len(queries))
+	}
+}
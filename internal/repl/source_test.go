@@ -0,0 +1,97 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeScript(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "queries.reql")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReplSource(t *testing.T) {
+	t.Parallel()
+	path := writeScript(t, "r.table(\"a\")\n  .count()\n---\nbad\n---\nr.now()\n")
+	var out, errOut bytes.Buffer
+	var dbs []string
+	reader := &fakeReader{lines: []string{".use app", ".source " + path}}
+	r := New(&Config{
+		Reader: reader,
+		Exec: func(_ context.Context, expr string, w io.Writer) error {
+			if expr == "bad" {
+				return errors.New("parse error")
+			}
+			_, err := fmt.Fprintf(w, "result of %s in %s\n", expr, dbs[len(dbs)-1])
+			return err
+		},
+		Out:     &out,
+		ErrOut:  &errOut,
+		OnUseDB: func(db string) { dbs = append(dbs, db) },
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := "r> r.table(\"a\")\n  .count()\nresult of r.table(\"a\")\n  .count() in app\n" +
+		"r> bad\n" +
+		"r> r.now()\nresult of r.now() in app\n"
+	if out.String() != want {
+		t.Errorf("out = %q, want %q", out.String(), want)
+	}
+	if errOut.String() != "parse error\n1 of 3 queries failed\n" {
+		t.Errorf("errOut = %q", errOut.String())
+	}
+	if len(reader.history) != 0 {
+		t.Errorf("history = %q, want sourced queries left out", reader.history)
+	}
+}
+
+func TestReplSourceInterrupted(t *testing.T) {
+	t.Parallel()
+	path := writeScript(t, "1\n---\n2\n---\n3")
+	var ran []string
+	var errOut bytes.Buffer
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{".source " + path}},
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			ran = append(ran, expr)
+			return context.Canceled
+		},
+		ErrOut: &errOut,
+	})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if fmt.Sprint(ran) != "[1]" || errOut.String() != "interrupted; skipped 2 of 3 queries\n" {
+		t.Errorf("ran %q, errOut %q", ran, errOut.String())
+	}
+}
+
+func TestReplSourceErrors(t *testing.T) {
+	t.Parallel()
+	for line, want := range map[string]string{
+		".source":       "usage: .source",
+		".source a b":   "usage: .source",
+		".source /none": "no such file",
+	} {
+		var errOut bytes.Buffer
+		r := New(&Config{Reader: &fakeReader{lines: []string{line}}, ErrOut: &errOut})
+		if err := r.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if !strings.Contains(errOut.String(), want) {
+			t.Errorf("%q: errOut = %q, want %q", line, errOut.String(), want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package repl
+
+import (
+	"fmt"
+	"time"
+)
+
+// statusCommand runs .status: the connection summary of Config.Status,
+// then how long the last query took and how long ago it finished.
+func (r *Repl) statusCommand() {
+	if r.status == nil {
+		_, _ = fmt.Fprintln(r.errOut, ".status: not available")
+		return
+	}
+	last := "no queries yet"
+	if !r.lastRun.IsZero() {
+		ago := r.now().Sub(r.lastRun).Round(time.Second)
+		last = fmt.Sprintf("last query %s, %s ago", r.lastTook.Round(time.Millisecond), ago)
+	}
+	_, _ = fmt.Fprintf(r.term, "%s; %s\n", r.status(), last)
+}
+
+// timed runs fn, the execution of a query, and keeps its duration and
+// end for .status.
+func (r *Repl) timed(fn func() error) error {
+	start := r.now()
+	err := fn()
+	r.lastRun = r.now()
+	r.lastTook = r.lastRun.Sub(start)
+	return err
+}
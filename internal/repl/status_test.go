@@ -0,0 +1,43 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestReplStatus(t *testing.T) {
+	t.Parallel()
+	var out, errOut bytes.Buffer
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{".status", "r.now()", ".status"}},
+		Exec: func(_ context.Context, _ string, _ io.Writer) error {
+			clock = clock.Add(12 * time.Millisecond)
+			return nil
+		},
+		Status: func() string { return "connected to db:28015" },
+		Out:    &out,
+		ErrOut: &errOut,
+	})
+	r.now = func() time.Time { return clock }
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := "connected to db:28015; no queries yet\n" +
+		"connected to db:28015; last query 12ms, 0s ago\n"
+	if out.String() != want {
+		t.Errorf("out = %q, want %q", out.String(), want)
+	}
+
+	errOut.Reset()
+	r = New(&Config{Reader: &fakeReader{lines: []string{".status"}}, ErrOut: &errOut})
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if errOut.String() != ".status: not available\n" {
+		t.Errorf("without Status: errOut = %q", errOut.String())
+	}
+}
@@ -0,0 +1,25 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+)
+
+// watchCommand runs .watch <table|query>: the changes stream to the output
+// until Ctrl+C, which ends the watch but not the session.
+func (r *Repl) watchCommand(ctx context.Context, expr string) {
+	if expr == "" {
+		_, _ = fmt.Fprintln(r.errOut, "usage: .watch <table|query>")
+		return
+	}
+	if r.watch == nil {
+		_, _ = fmt.Fprintln(r.errOut, ".watch: not available")
+		return
+	}
+	expr = expandAliases(expr, r.aliases)
+	r.running = expr
+	_, _ = fmt.Fprintln(r.errOut, "watching for changes, press Ctrl+C to stop")
+	r.interruptible(ctx, func(queryCtx context.Context) error {
+		return r.watch(queryCtx, expr, r.out)
+	})
+}
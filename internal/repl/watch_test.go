@@ -0,0 +1,83 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplWatchUntilInterrupt(t *testing.T) {
+	t.Parallel()
+	intCh := make(chan struct{}, 1)
+	started := make(chan struct{})
+	var out, errOut bytes.Buffer
+	var watched, ran []string
+	r := New(&Config{
+		Reader: &fakeReader{lines: []string{`.watch r.table("a").changes()`, `r.table("a")`}},
+		Watch: func(ctx context.Context, expr string, w io.Writer) error {
+			watched = append(watched, expr)
+			_, _ = fmt.Fprintln(w, `{"new_val":{"id":1}}`)
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Exec: func(_ context.Context, expr string, _ io.Writer) error {
+			ran = append(ran, expr)
+			return nil
+		},
+		Out:         &out,
+		ErrOut:      &errOut,
+		InterruptCh: intCh,
+	})
+	done := make(chan error, 1)
+	go func() { done <- r.Run(context.Background()) }()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch did not start")
+	}
+	intCh <- struct{}{}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch was not stopped by the interrupt")
+	}
+	if fmt.Sprint(watched) != `[r.table("a").changes()]` || fmt.Sprint(ran) != `[r.table("a")]` {
+		t.Errorf("watched %q, ran %q", watched, ran)
+	}
+	if out.String() != "{\"new_val\":{\"id\":1}}\n" {
+		t.Errorf("out = %q", out.String())
+	}
+	if errOut.String() != "watching for changes, press Ctrl+C to stop\n" {
+		t.Errorf("errOut = %q", errOut.String())
+	}
+}
+
+func TestReplWatchErrors(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		line  string
+		watch ExecFunc
+		want  string
+	}{
+		{".watch", nil, "usage: .watch <table|query>"},
+		{".watch users", nil, ".watch: not available"},
+		{".watch users", func(context.Context, string, io.Writer) error { return fmt.Errorf("table not found") }, "table not found"},
+	} {
+		var errOut bytes.Buffer
+		r := New(&Config{Reader: &fakeReader{lines: []string{tc.line}}, Watch: tc.watch, ErrOut: &errOut})
+		if err := r.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if !strings.Contains(errOut.String(), tc.want) {
+			t.Errorf("%s: errOut = %q, want %q", tc.line, errOut.String(), tc.want)
+		}
+	}
+}
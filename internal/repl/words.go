@@ -0,0 +1,124 @@
+package repl
+
+import (
+	"slices"
+
+	"github.com/chzyer/readline"
+
+	"r-cli/internal/reql/parser"
+)
+
+// wordUnits splits line into the units that word-wise movement and
+// deletion step over, from the parser.Scan spans: a string literal, a
+// method with its dot and opening paren (".filter("), an identifier or a
+// number, or a run of adjacent brackets and punctuation ("})", "=>").
+func wordUnits(line []rune) [][2]int {
+	spans := parser.Scan(string(line))
+	var units [][2]int
+	for i := 0; i < len(spans); i++ {
+		u := [2]int{spans[i].Start, spans[i].End}
+		switch {
+		case methodAt(line, spans, i):
+			i++
+			u[1] = spans[i].End
+			if next := i + 1; next < len(spans) && spans[next].Start == u[1] && line[spans[next].Start] == '(' {
+				i = next
+				u[1] = spans[i].End
+			}
+		case isPunct(spans[i].Kind):
+			for next := i + 1; next < len(spans) && spans[next].Start == u[1] &&
+				isPunct(spans[next].Kind) && !methodAt(line, spans, next); next++ {
+				i = next
+				u[1] = spans[i].End
+			}
+		}
+		units = append(units, u)
+	}
+	return units
+}
+
+// methodAt reports whether spans[i] is the dot before a method name.
+func methodAt(line []rune, spans []parser.Span, i int) bool {
+	return spans[i].Kind == parser.SpanPunct && line[spans[i].Start] == '.' &&
+		i+1 < len(spans) && spans[i+1].Kind == parser.SpanMethod && spans[i+1].Start == spans[i].End
+}
+
+func isPunct(k parser.SpanKind) bool {
+	return k == parser.SpanBracket || k == parser.SpanPunct || k == parser.SpanInvalid
+}
+
+// prevUnitStart returns the start of the last unit that begins before pos,
+// or 0.
+func prevUnitStart(units [][2]int, pos int) int {
+	for _, u := range slices.Backward(units) {
+		if u[0] < pos {
+			return u[0]
+		}
+	}
+	return 0
+}
+
+// nextUnitEnd returns the end of the first unit that ends after pos, or
+// end, the length of the line.
+func nextUnitEnd(units [][2]int, pos, end int) int {
+	for _, u := range units {
+		if u[1] > pos {
+			return u[1]
+		}
+	}
+	return end
+}
+
+// wordEdit applies a word key to line with the cursor at pos: Alt+B and
+// Alt+F move to the start of the previous unit and the end of the next,
+// Ctrl+W and Alt+Backspace delete back to the start of the previous unit,
+// and Alt+D deletes up to the end of the next. ok is false for other keys.
+func wordEdit(line []rune, pos int, key rune) (newLine []rune, newPos int, ok bool) {
+	switch key {
+	case readline.MetaBackward:
+		return line, prevUnitStart(wordUnits(line), pos), true
+	case readline.MetaForward:
+		return line, nextUnitEnd(wordUnits(line), pos, len(line)), true
+	case readline.CharCtrlW, readline.MetaBackspace:
+		start := prevUnitStart(wordUnits(line), pos)
+		return slices.Concat(line[:start], line[pos:]), start, true
+	case readline.MetaDelete:
+		end := nextUnitEnd(wordUnits(line), pos, len(line))
+		return slices.Concat(line[:pos], line[end:]), pos, true
+	}
+	return line, pos, false
+}
+
+// wordListener is a readline.Listener that makes the word keys of
+// wordEdit step over ReQL units instead of readline's words. It sees each
+// key after readline handled it, so it keeps the line and cursor from
+// before the key and redoes a word key's edit on them.
+type wordListener struct {
+	line []rune
+	pos  int
+}
+
+func (w *wordListener) OnChange(line []rune, pos int, key rune) ([]rune, int, bool) {
+	newLine, newPos, ok := wordEdit(w.line, w.pos, key)
+	// readline's edit must have come from the kept line; after a new
+	// prompt or a history switch it is stale and readline's edit stands
+	if !ok || !trimmedFrom(w.line, line) {
+		newLine, newPos, ok = line, pos, false
+	}
+	w.line = slices.Clone(newLine)
+	w.pos = newPos
+	return newLine, newPos, ok
+}
+
+// trimmedFrom reports whether line is prev, or prev with one run of runes
+// cut out, as readline's word keys leave it.
+func trimmedFrom(prev, line []rune) bool {
+	if len(line) > len(prev) {
+		return false
+	}
+	p := 0
+	for p < len(line) && line[p] == prev[p] {
+		p++
+	}
+	return slices.Equal(line[p:], prev[len(prev)-(len(line)-p):])
+}
@@ -0,0 +1,96 @@
+package repl
+
+import (
+	"testing"
+
+	"github.com/chzyer/readline"
+)
+
+func TestWordUnits(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`r.table("users").filter({age: 30}).count()`, []string{
+			"r", ".table(", `"users"`, ")", ".filter(", "{", "age", ":", "30", "})", ".count(", ")",
+		}},
+		{`r.expr([1, 2]).map(x => x.mul(2))`, []string{
+			"r", ".expr(", "[", "1", ",", "2", "])", ".map(", "x", "=>", "x", ".mul(", "2", "))",
+		}},
+		{`  .pluck "a b`, []string{".pluck", `"a b`}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		line := []rune(tt.line)
+		var got []string
+		for _, u := range wordUnits(line) {
+			got = append(got, string(line[u[0]:u[1]]))
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.line, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %q, want %q", tt.line, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestWordEdit(t *testing.T) {
+	t.Parallel()
+	const line = `r.table("users").filter({age: 30})`
+	tests := []struct {
+		name     string
+		pos      int
+		key      rune
+		wantLine string
+		wantPos  int
+	}{
+		{"back over brackets", 34, readline.MetaBackward, line, 32},
+		{"back over method", 24, readline.MetaBackward, line, 16},
+		{"back over string", 15, readline.MetaBackward, line, 8},
+		{"back from middle of method", 19, readline.MetaBackward, line, 16},
+		{"back at start", 0, readline.MetaBackward, line, 0},
+		{"forward over method", 1, readline.MetaForward, line, 8},
+		{"forward over string", 8, readline.MetaForward, line, 15},
+		{"forward at end", 34, readline.MetaForward, line, 34},
+		{"delete back", 24, readline.CharCtrlW, `r.table("users"){age: 30})`, 16},
+		{"alt-backspace", 15, readline.MetaBackspace, `r.table().filter({age: 30})`, 8},
+		{"delete forward", 16, readline.MetaDelete, `r.table("users"){age: 30})`, 16},
+	}
+	for _, tt := range tests {
+		gotLine, gotPos, ok := wordEdit([]rune(line), tt.pos, tt.key)
+		if !ok || string(gotLine) != tt.wantLine || gotPos != tt.wantPos {
+			t.Errorf("%s: got %q at %d (%v), want %q at %d", tt.name, string(gotLine), gotPos, ok, tt.wantLine, tt.wantPos)
+		}
+	}
+	if _, _, ok := wordEdit([]rune(line), 3, 'a'); ok {
+		t.Error("wordEdit handled a plain key")
+	}
+}
+
+func TestWordListener(t *testing.T) {
+	t.Parallel()
+	var w wordListener
+	w.OnChange(nil, 0, 0)
+	line := []rune(`r.table("a").count()`)
+	w.OnChange(line, len(line), ')')
+	// readline's own Ctrl+W removed "count()"; the listener removes ")"
+	got, pos, ok := w.OnChange([]rune(`r.table("a").`), 13, readline.CharCtrlW)
+	if !ok || string(got) != `r.table("a").count(` || pos != 19 {
+		t.Errorf("Ctrl+W: got %q at %d (%v)", string(got), pos, ok)
+	}
+	got, pos, ok = w.OnChange(got, 12, readline.MetaBackward)
+	if !ok || string(got) != `r.table("a").count(` || pos != 12 {
+		t.Errorf("Alt+B: got %q at %d (%v)", string(got), pos, ok)
+	}
+	// a line that did not come from the kept one is left to readline
+	other := []rune("r.now()")
+	if got, pos, ok := w.OnChange(other, 2, readline.MetaBackward); ok || string(got) != "r.now()" || pos != 2 {
+		t.Errorf("stale line: got %q at %d (%v)", string(got), pos, ok)
+	}
+}
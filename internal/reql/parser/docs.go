@@ -0,0 +1,177 @@
+package parser
+
+// MethodDoc is the short reference of a method shown by the REPL's .help.
+type MethodDoc struct {
+	Signature string
+	Summary   string
+	Example   string
+}
+
+// rDocs documents the r.* builders of rBuilders.
+var rDocs = map[string]MethodDoc{
+	"args":      {"r.args(array)", "Splices the elements of array into the arguments of the enclosing call.", `r.table("users").getAll(r.args(["a", "b"]))`},
+	"asc":       {"r.asc(field)", "Sorts orderBy ascending by field; the default order.", `r.table("users").orderBy(r.asc("name"))`},
+	"binary":    {"r.binary(data)", "Wraps a base64 string as a BINARY value.", `r.binary("aGVsbG8=")`},
+	"branch":    {"r.branch(test, trueValue[, test2, value2, ...], falseValue)", "Returns the value of the first test that is true, like if/else if/else.", `r.branch(r.row("age").ge(18), "adult", "minor")`},
+	"circle":    {"r.circle(center, radius[, {numVertices, geoSystem, unit, fill}])", "Builds a polygon (or line with fill: false) approximating a circle.", `r.circle(r.point(-122.4, 37.7), 500, {unit: "m"})`},
+	"db":        {"r.db(name)", "Refers to a database.", `r.db("app").tableList()`},
+	"dbCreate":  {"r.dbCreate(name)", "Creates a database.", `r.dbCreate("app")`},
+	"dbDrop":    {"r.dbDrop(name)", "Drops a database and all of its tables.", `r.dbDrop("scratch")`},
+	"dbList":    {"r.dbList()", "Lists the database names.", `r.dbList()`},
+	"desc":      {"r.desc(field)", "Sorts orderBy descending by field.", `r.table("posts").orderBy(r.desc("date"))`},
+	"do":        {"r.do(arg, ..., func)", "Calls func with the args, binding them to its parameters.", `r.do(r.table("a").count(), (n) => n.mul(2))`},
+	"epochTime": {"r.epochTime(seconds)", "Builds a time from seconds since the Unix epoch.", `r.epochTime(1700000000)`},
+	"error":     {"r.error(message)", "Fails the query with message.", `r.branch(r.row("ok"), 1, r.error("not ok"))`},
+	"expr":      {"r.expr(value)", "Turns a JSON value into a ReQL term.", `r.expr([1, 2, 3]).count()`},
+	"geoJSON":   {"r.geoJSON(object)", "Converts a GeoJSON object to a geometry.", `r.geoJSON({type: "Point", coordinates: [-122.4, 37.7]})`},
+	"iso8601":   {"r.iso8601(string)", "Parses an ISO 8601 date and time.", `r.iso8601("2024-05-01T12:00:00Z")`},
+	"json":      {"r.json(string)", "Parses a JSON string.", `r.json("[1, 2, 3]")`},
+	"line":      {"r.line(point, point, ...)", "Builds a line from two or more points or [lon, lat] pairs.", `r.line([-122.4, 37.7], [-122.3, 37.8])`},
+	"literal":   {"r.literal(value)", "Replaces a nested object in update or merge instead of merging into it.", `r.table("users").get(1).update({prefs: r.literal({theme: "dark"})})`},
+	"maxval":    {"r.maxval", "The value greater than any other, for open-ended between ranges.", `r.table("users").between(10, r.maxval, {index: "age"})`},
+	"minval":    {"r.minval", "The value smaller than any other, for open-ended between ranges.", `r.table("users").between(r.minval, 18, {index: "age"})`},
+	"now":       {"r.now()", "The time the query started on the server.", `r.table("events").insert({at: r.now()})`},
+	"object":    {"r.object(key, value, ...)", "Builds an object from key/value pairs.", `r.object("id", 1, "name", "a")`},
+	"point":     {"r.point(longitude, latitude)", "Builds a geographic point.", `r.point(-122.4, 37.7)`},
+	"polygon":   {"r.polygon(point, point, point, ...)", "Builds a polygon from three or more points.", `r.polygon([0, 0], [0, 1], [1, 1])`},
+	"random":    {"r.random([min, ][max][, {float: true}])", "Returns a random integer in [min, max), or a float with float: true.", `r.random(1, 7)`},
+	"range":     {"r.range([[start, ]end])", "Streams the integers from start (0) up to end, or without end forever.", `r.range(5).map((i) => i.mul(i))`},
+	"row":       {"r.row", "The current document in filter, update and similar functions.", `r.table("users").filter(r.row("age").gt(30))`},
+	"table":     {"r.table(name)", "Refers to a table of the current database.", `r.table("users").count()`},
+	"time":      {"r.time(year, month, day[, hour, minute, second], timezone)", "Builds a time from its parts.", `r.time(2024, 5, 1, "Z")`},
+	"uuid":      {"r.uuid()", "Returns a random UUID.", `r.uuid()`},
+}
+
+// chainDocs documents the methods of chainBuilders.
+var chainDocs = map[string]MethodDoc{
+	"add":             {"value.add(value)", "Sums numbers, concatenates strings or arrays, or adds seconds to a time.", `r.expr(2).add(3)`},
+	"and":             {"bool.and(bool)", "Logical and.", `r.table("users").filter(r.row("active").and(r.row("admin")))`},
+	"append":          {"array.append(value)", "Adds value to the end of array.", `r.table("users").get(1).update({tags: r.row("tags").append("new")})`},
+	"avg":             {"seq.avg(field)", "Averages a field of the elements.", `r.table("orders").avg("total")`},
+	"between":         {"selection.between(lower, upper[, {index, leftBound, rightBound}])", "Selects documents with the primary key, or index, in [lower, upper).", `r.table("users").between(18, 30, {index: "age"})`},
+	"bitAnd":          {"number.bitAnd(number)", "Bitwise and.", `r.expr(6).bitAnd(3)`},
+	"bitNot":          {"number.bitNot()", "Bitwise not.", `r.expr(5).bitNot()`},
+	"bitOr":           {"number.bitOr(number)", "Bitwise or.", `r.expr(6).bitOr(3)`},
+	"bitSal":          {"number.bitSal(bits)", "Arithmetic shift left.", `r.expr(5).bitSal(2)`},
+	"bitSar":          {"number.bitSar(bits)", "Arithmetic shift right.", `r.expr(20).bitSar(2)`},
+	"bitXor":          {"number.bitXor(number)", "Bitwise exclusive or.", `r.expr(6).bitXor(3)`},
+	"ceil":            {"number.ceil()", "Rounds up to an integer.", `r.expr(1.2).ceil()`},
+	"changeAt":        {"array.changeAt(index, value)", "Replaces the element at index.", `r.expr(["a", "b"]).changeAt(1, "c")`},
+	"changes":         {"stream.changes([{squash, includeInitial, includeStates, includeTypes, changefeedQueueSize}])", "Opens a changefeed on a table, selection or single document.", `r.table("orders").changes({includeTypes: true})`},
+	"coerceTo":        {"value.coerceTo(type)", "Converts to another type: \"array\", \"object\", \"string\", \"number\" or \"binary\".", `r.table("users").coerceTo("array")`},
+	"concatMap":       {"seq.concatMap(func)", "Maps each element to an array and concatenates the arrays.", `r.table("users").concatMap(r.row("tags"))`},
+	"config":          {"table.config()", "The configuration document of a table or database, which update changes.", `r.table("users").config()`},
+	"contains":        {"seq.contains(value | func, ...)", "True when seq has every value, or elements matching every function.", `r.table("users").contains((u) => u("admin"))`},
+	"count":           {"seq.count()", "Counts the elements.", `r.table("users").count()`},
+	"date":            {"time.date()", "The time truncated to midnight of its day.", `r.now().date()`},
+	"day":             {"time.day()", "The day of the month, 1 to 31.", `r.now().day()`},
+	"dayOfWeek":       {"time.dayOfWeek()", "The day of the week, 1 (Monday) to 7.", `r.now().dayOfWeek()`},
+	"dayOfYear":       {"time.dayOfYear()", "The day of the year, 1 to 366.", `r.now().dayOfYear()`},
+	"default":         {"value.default(value)", "Replaces null or a missing field error with a default.", `r.table("users").map(r.row("nick").default("anonymous"))`},
+	"delete":          {"selection.delete([{durability, returnChanges}])", "Deletes the selected documents.", `r.table("sessions").filter({expired: true}).delete()`},
+	"deleteAt":        {"array.deleteAt(index)", "Removes the element at index.", `r.expr(["a", "b", "c"]).deleteAt(1)`},
+	"difference":      {"array.difference(array)", "Removes the elements found in the other array.", `r.expr([1, 2, 3]).difference([2])`},
+	"distance":        {"geometry.distance(geometry[, {geoSystem, unit}])", "The distance between two geometries, in meters by default.", `r.point(0, 0).distance(r.point(1, 1), {unit: "km"})`},
+	"distinct":        {"seq.distinct()", "Removes duplicate elements.", `r.table("users").pluck("city").distinct()`},
+	"div":             {"number.div(number)", "Divides.", `r.expr(10).div(4)`},
+	"do":              {"value.do(func)", "Calls func with value.", `r.table("users").get(1).do((u) => u("name"))`},
+	"downcase":        {"string.downcase()", "Lowercases a string.", `r.expr("ABC").downcase()`},
+	"during":          {"time.during(start, end)", "True when the time is in [start, end).", `r.table("events").filter(r.row("at").during(r.time(2024, 1, 1, "Z"), r.now()))`},
+	"eq":              {"value.eq(value)", "Equality.", `r.table("users").filter(r.row("role").eq("admin"))`},
+	"eqJoin":          {"seq.eqJoin(field, table[, {index, ordered}])", "Joins each element to the documents of table whose primary key, or index, equals the field.", `r.table("orders").eqJoin("userId", r.table("users")).zip()`},
+	"fill":            {"line.fill()", "Turns a closed line into a polygon.", `r.line([0, 0], [0, 1], [1, 1], [0, 0]).fill()`},
+	"filter":          {"seq.filter(object | func)", "Keeps the elements matching an object of field values or a predicate.", `r.table("users").filter({active: true})`},
+	"floor":           {"number.floor()", "Rounds down to an integer.", `r.expr(1.8).floor()`},
+	"fold":            {"seq.fold(base, func[, {emit, finalEmit}])", "Reduces seq in order from base; with emit it streams values as it goes.", `r.expr([1, 2, 3]).fold(0, (acc, x) => acc.add(x))`},
+	"forEach":         {"seq.forEach(func)", "Runs the write query func returns for each element.", `r.table("old").forEach((doc) => r.table("new").insert(doc))`},
+	"ge":              {"value.ge(value)", "Greater than or equal.", `r.table("users").filter(r.row("age").ge(18))`},
+	"get":             {"table.get(key)", "The document with this primary key, or null.", `r.table("users").get("u1")`},
+	"getAll":          {"table.getAll(key, ...[, {index}])", "The documents with any of the keys, by primary key or index.", `r.table("users").getAll("a@x.io", {index: "email"})`},
+	"getField":        {"object.getField(field)", "The value of a field; on a sequence, of each element.", `r.table("users").getField("name")`},
+	"getIntersecting": {"table.getIntersecting(geometry, {index})", "The documents whose geo index intersects geometry.", `r.table("places").getIntersecting(r.circle(r.point(0, 0), 100), {index: "loc"})`},
+	"getNearest":      {"table.getNearest(point, {index[, maxResults, maxDist, unit, geoSystem]})", "The documents nearest to point by a geo index, with their distance.", `r.table("places").getNearest(r.point(0, 0), {index: "loc", maxResults: 5})`},
+	"grant":           {"scope.grant(user, {read, write, connect, config})", "Sets the permissions of user on a database or a table.", `r.db("app").grant("bob", {read: true})`},
+	"group":           {"seq.group(field)", "Groups elements by a field; later methods apply to each group.", `r.table("orders").group("status").count()`},
+	"gt":              {"value.gt(value)", "Greater than.", `r.table("users").filter(r.row("age").gt(30))`},
+	"hasFields":       {"value.hasFields(field, ...)", "True when an object has every field; on a sequence, keeps those that do.", `r.table("users").hasFields("email")`},
+	"hours":           {"time.hours()", "The hour, 0 to 23.", `r.now().hours()`},
+	"inTimezone":      {"time.inTimezone(timezone)", "The same moment in another timezone, such as \"-08:00\".", `r.now().inTimezone("+02:00")`},
+	"includes":        {"geometry.includes(geometry)", "True when a polygon fully contains the other geometry.", `r.circle(r.point(0, 0), 1000).includes(r.point(0, 0.001))`},
+	"indexCreate":     {"table.indexCreate(name[, {multi, geo}])", "Creates a secondary index on the field name.", `r.table("users").indexCreate("email")`},
+	"indexDrop":       {"table.indexDrop(name)", "Drops a secondary index.", `r.table("users").indexDrop("email")`},
+	"indexList":       {"table.indexList()", "Lists the secondary indexes.", `r.table("users").indexList()`},
+	"indexRename":     {"table.indexRename(old, new)", "Renames a secondary index.", `r.table("users").indexRename("mail", "email")`},
+	"indexStatus":     {"table.indexStatus([name, ...])", "The build status of indexes.", `r.table("users").indexStatus("email")`},
+	"indexWait":       {"table.indexWait([name, ...])", "Waits until indexes are built.", `r.table("users").indexWait("email")`},
+	"info":            {"value.info()", "Information about a value, such as a table's primary key and indexes.", `r.table("users").info()`},
+	"innerJoin":       {"seq.innerJoin(seq, func)", "Pairs the elements of both sequences for which func is true.", `r.table("a").innerJoin(r.table("b"), (x, y) => x("id").eq(y("aId")))`},
+	"insert":          {"table.insert(object | array[, {durability, returnChanges, conflict}])", "Inserts documents; conflict is \"error\", \"replace\", \"update\" or a function.", `r.table("users").insert({name: "ann"})`},
+	"insertAt":        {"array.insertAt(index, value)", "Inserts value before index.", `r.expr(["a", "c"]).insertAt(1, "b")`},
+	"intersects":      {"geometry.intersects(geometry)", "True when two geometries intersect.", `r.point(0, 0).intersects(r.circle(r.point(0, 0), 10))`},
+	"isEmpty":         {"seq.isEmpty()", "True when seq has no elements.", `r.table("users").filter({admin: true}).isEmpty()`},
+	"keys":            {"object.keys()", "The field names of an object.", `r.table("users").get(1).keys()`},
+	"le":              {"value.le(value)", "Less than or equal.", `r.table("users").filter(r.row("age").le(65))`},
+	"limit":           {"seq.limit(n)", "The first n elements.", `r.table("users").limit(10)`},
+	"lt":              {"value.lt(value)", "Less than.", `r.table("users").filter(r.row("age").lt(18))`},
+	"map":             {"seq.map(func)", "Transforms each element.", `r.table("users").map(r.row("name"))`},
+	"match":           {"string.match(regexp)", "Matches a RE2 regular expression; null or the match with its groups.", `r.table("users").filter(r.row("email").match("@example\\.com$"))`},
+	"max":             {"seq.max(field)", "The element with the largest value of field.", `r.table("users").max("age")`},
+	"merge":           {"object.merge(object | func)", "Merges an object into this one, its fields winning; on a sequence, into each element.", `r.table("users").get(1).merge({seen: r.now()})`},
+	"min":             {"seq.min(field)", "The element with the smallest value of field.", `r.table("users").min("age")`},
+	"minutes":         {"time.minutes()", "The minute, 0 to 59.", `r.now().minutes()`},
+	"mod":             {"number.mod(number)", "The remainder of a division.", `r.expr(7).mod(3)`},
+	"month":           {"time.month()", "The month, 1 to 12.", `r.now().month()`},
+	"mul":             {"value.mul(value)", "Multiplies numbers, or repeats an array.", `r.expr(3).mul(4)`},
+	"ne":              {"value.ne(value)", "Inequality.", `r.table("users").filter(r.row("role").ne("guest"))`},
+	"not":             {"bool.not()", "Logical not.", `r.table("users").filter(r.row("active").not())`},
+	"nth":             {"seq.nth(index)", "The element at index; negative counts from the end.", `r.table("users").orderBy("name").nth(0)`},
+	"offsetsOf":       {"seq.offsetsOf(value | func)", "The indexes of the elements equal to value or matching func.", `r.expr(["a", "b", "a"]).offsetsOf("a")`},
+	"or":              {"bool.or(bool)", "Logical or.", `r.table("users").filter(r.row("admin").or(r.row("owner")))`},
+	"orderBy":         {"seq.orderBy(field | func | r.desc(...), ...[, {index}])", "Sorts by fields; a table sorted by an index streams.", `r.table("users").orderBy({index: r.desc("createdAt")})`},
+	"outerJoin":       {"seq.outerJoin(seq, func)", "Like innerJoin, also keeping left elements with no match.", `r.table("a").outerJoin(r.table("b"), (x, y) => x("id").eq(y("aId")))`},
+	"pluck":           {"value.pluck(field | object, ...)", "Keeps only the given fields, nested ones by object.", `r.table("users").pluck("id", "name")`},
+	"polygonSub":      {"polygon.polygonSub(polygon)", "Cuts a polygon inside it out of a polygon.", `r.circle(r.point(0, 0), 100).polygonSub(r.circle(r.point(0, 0), 50))`},
+	"prepend":         {"array.prepend(value)", "Adds value to the front of array.", `r.expr([2, 3]).prepend(1)`},
+	"rebalance":       {"table.rebalance()", "Moves data so the shards of a table are even.", `r.table("users").rebalance()`},
+	"reconfigure":     {"table.reconfigure({shards, replicas[, primaryReplicaTag, dryRun, emergencyRepair]})", "Changes the sharding and replication of a table.", `r.table("users").reconfigure({shards: 2, replicas: 3})`},
+	"reduce":          {"seq.reduce(func)", "Combines the elements pairwise into one value, in no set order.", `r.table("orders").map(r.row("total")).reduce((a, b) => a.add(b))`},
+	"replace":         {"selection.replace(object | func)", "Replaces the selected documents as a whole.", `r.table("users").get(1).replace({id: 1, name: "ann"})`},
+	"round":           {"number.round()", "Rounds to the nearest integer.", `r.expr(1.5).round()`},
+	"sample":          {"seq.sample(n)", "n elements picked at random.", `r.table("users").sample(3)`},
+	"seconds":         {"time.seconds()", "The seconds, with fractions, 0 to 59.999.", `r.now().seconds()`},
+	"setDifference":   {"array.setDifference(array)", "The distinct elements not in the other array.", `r.expr([1, 2, 2]).setDifference([2])`},
+	"setInsert":       {"array.setInsert(value)", "Adds value unless present, removing duplicates.", `r.table("users").get(1).update({tags: r.row("tags").setInsert("vip")})`},
+	"setIntersection": {"array.setIntersection(array)", "The distinct elements in both arrays.", `r.expr([1, 2]).setIntersection([2, 3])`},
+	"setUnion":        {"array.setUnion(array)", "The distinct elements in either array.", `r.expr([1, 2]).setUnion([2, 3])`},
+	"skip":            {"seq.skip(n)", "All but the first n elements.", `r.table("users").orderBy("name").skip(20).limit(10)`},
+	"slice":           {"seq.slice(start, end)", "The elements from start up to end.", `r.table("users").orderBy("name").slice(10, 20)`},
+	"spliceAt":        {"array.spliceAt(index, array)", "Inserts the elements of array before index.", `r.expr([1, 4]).spliceAt(1, [2, 3])`},
+	"split":           {"string.split([separator])", "Splits on whitespace, or on separator.", `r.expr("a,b,c").split(",")`},
+	"status":          {"table.status()", "The availability and shard states of a table.", `r.table("users").status()`},
+	"sub":             {"value.sub(value)", "Subtracts numbers, or seconds or another time from a time.", `r.now().sub(3600)`},
+	"sum":             {"seq.sum(field)", "Sums a field of the elements.", `r.table("orders").sum("total")`},
+	"sync":            {"table.sync()", "Flushes the soft durability writes of a table to disk.", `r.table("logs").sync()`},
+	"table":           {"db.table(name)", "Refers to a table of the database.", `r.db("app").table("users")`},
+	"tableCreate":     {"db.tableCreate(name[, {primaryKey, durability, shards, replicas}])", "Creates a table.", `r.db("app").tableCreate("users", {primaryKey: "email"})`},
+	"tableDrop":       {"db.tableDrop(name)", "Drops a table and its data.", `r.db("app").tableDrop("scratch")`},
+	"tableList":       {"db.tableList()", "Lists the table names of the database.", `r.db("app").tableList()`},
+	"timeOfDay":       {"time.timeOfDay()", "Seconds since midnight.", `r.now().timeOfDay()`},
+	"timezone":        {"time.timezone()", "The timezone, such as \"+02:00\".", `r.now().timezone()`},
+	"toEpochTime":     {"time.toEpochTime()", "Seconds since the Unix epoch.", `r.now().toEpochTime()`},
+	"toGeoJSON":       {"geometry.toGeoJSON()", "The GeoJSON object of a geometry.", `r.point(0, 0).toGeoJSON()`},
+	"toISO8601":       {"time.toISO8601()", "The time as an ISO 8601 string.", `r.now().toISO8601()`},
+	"toJSON":          {"value.toJSON()", "The value as a JSON string.", `r.expr({a: 1}).toJSON()`},
+	"toJSONString":    {"value.toJSONString()", "The value as a JSON string; same as toJSON.", `r.expr({a: 1}).toJSONString()`},
+	"toJsonString":    {"value.toJsonString()", "The value as a JSON string; same as toJSON.", `r.expr({a: 1}).toJsonString()`},
+	"typeOf":          {"value.typeOf()", "The type name, such as \"STRING\", \"TABLE\" or \"SELECTION<OBJECT>\".", `r.table("users").typeOf()`},
+	"ungroup":         {"grouped.ungroup()", "Turns groups into an array of {group, reduction} objects.", `r.table("orders").group("status").count().ungroup()`},
+	"union":           {"seq.union(seq, ...[, {interleave}])", "Concatenates sequences.", `r.table("a").union(r.table("b"))`},
+	"upcase":          {"string.upcase()", "Uppercases a string.", `r.expr("abc").upcase()`},
+	"update":          {"selection.update(object | func[, {durability, returnChanges, nonAtomic}])", "Merges fields into the selected documents.", `r.table("users").get(1).update({active: false})`},
+	"values":          {"object.values()", "The field values of an object.", `r.table("users").get(1).values()`},
+	"wait":            {"table.wait()", "Waits until a table, or every table of a database, is ready.", `r.table("users").wait()`},
+	"withFields":      {"seq.withFields(field, ...)", "Keeps the elements having every field, plucked to them.", `r.table("users").withFields("id", "email")`},
+	"without":         {"value.without(field | object, ...)", "Removes fields.", `r.table("users").without("password")`},
+	"year":            {"time.year()", "The year.", `r.now().year()`},
+	"zip":             {"seq.zip()", "Merges the right side of each eqJoin or innerJoin pair into the left.", `r.table("orders").eqJoin("userId", r.table("users")).zip()`},
+}
@@ -0,0 +1,26 @@
+package parser
+
+import "fmt"
+
+// SyntaxError is a parse error that points into the input: the runes
+// [Pos, End) hold the offending token, or the unterminated string. At the
+// end of the input Pos equals End.
+type SyntaxError struct {
+	Msg      string
+	Pos, End int
+}
+
+func (e *SyntaxError) Error() string { return e.Msg }
+
+// Span returns the runes of the input the error points at.
+func (e *SyntaxError) Span() (start, end int) { return e.Pos, e.End }
+
+// errorAt returns a SyntaxError at tok.
+func errorAt(tok token, format string, args ...any) error {
+	return &SyntaxError{Msg: fmt.Sprintf(format, args...), Pos: tok.Pos, End: tok.End}
+}
+
+// errorAt returns a SyntaxError at the runes [start, end) of the lexer input.
+func (l *lexer) errorAt(start, end int, format string, args ...any) error {
+	return &SyntaxError{Msg: fmt.Sprintf(format, args...), Pos: start, End: min(end, len(l.input))}
+}
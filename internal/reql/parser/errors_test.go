@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSyntaxErrorSpan(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input      string
+		start, end int
+	}{
+		{`r.table("a").fliter(1)`, 13, 19},
+		{`r.table("a"`, 11, 11},
+		{`r.expr("abc`, 7, 11},
+		{`r.expr(1) 2`, 10, 11},
+		{`r.expr(@)`, 7, 8},
+		{`r.nope()`, 2, 6},
+		{`r.expr("\q")`, 8, 10},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Parse(%q) = %v, want a SyntaxError", tt.input, err)
+			continue
+		}
+		if start, end := se.Span(); start != tt.start || end != tt.end {
+			t.Errorf("Parse(%q): span [%d, %d), want [%d, %d) (%v)", tt.input, start, end, tt.start, tt.end, err)
+		}
+	}
+}
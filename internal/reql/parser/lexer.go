@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"fmt"
 	"strings"
 	"unicode"
 )
@@ -30,10 +29,12 @@ const (
 )
 
 // token is a single lexical unit with its type, raw value, and rune index.
+// End is the rune index just past it in the input.
 type token struct {
 	Type  tokenType
 	Value string
 	Pos   int
+	End   int
 }
 
 // lexer converts a ReQL string into a flat token slice.
@@ -55,6 +56,7 @@ func (l *lexer) tokenize() ([]token, error) {
 		if err != nil {
 			return nil, err
 		}
+		tok.End = l.pos
 		tokens = append(tokens, tok)
 		if tok.Type == tokenEOF {
 			break
@@ -91,7 +93,7 @@ func (l *lexer) readArrow() (token, error) {
 		l.pos++ // consume '>'
 		return token{Type: tokenArrow, Value: "=>", Pos: start}, nil
 	}
-	return token{}, fmt.Errorf("unexpected character '=' at position %d", start)
+	return token{}, l.errorAt(start, start+1, "unexpected character '=' at position %d", start)
 }
 
 // punctTypes maps single-character punctuation to its token type.
@@ -129,7 +131,7 @@ func (l *lexer) readValue(ch rune) (token, error) {
 	case unicode.IsLetter(ch) || ch == '_':
 		return l.readIdent()
 	default:
-		return token{}, fmt.Errorf("unexpected character %q at position %d", string(ch), start)
+		return token{}, l.errorAt(start, start+1, "unexpected character %q at position %d", string(ch), start)
 	}
 }
 
@@ -143,12 +145,12 @@ func (l *lexer) readString(quote rune) (token, error) {
 		case '\\':
 			l.pos++
 			if l.pos >= len(l.input) {
-				return token{}, fmt.Errorf("unterminated string at position %d", start)
+				return token{}, l.errorAt(start, l.pos, "unterminated string at position %d", start)
 			}
 			esc := l.input[l.pos]
 			r, ok := unescapeChar(esc)
 			if !ok {
-				return token{}, fmt.Errorf("unknown escape sequence '\\%c' at position %d", esc, l.pos-1)
+				return token{}, l.errorAt(l.pos-1, l.pos+1, "unknown escape sequence '\\%c' at position %d", esc, l.pos-1)
 			}
 			sb.WriteRune(r)
 			l.pos++
@@ -160,7 +162,7 @@ func (l *lexer) readString(quote rune) (token, error) {
 			l.pos++
 		}
 	}
-	return token{}, fmt.Errorf("unterminated string at position %d", start)
+	return token{}, l.errorAt(start, l.pos, "unterminated string at position %d", start)
 }
 
 func unescapeChar(ch rune) (rune, bool) {
@@ -207,7 +209,7 @@ func (l *lexer) readNumber() (token, error) {
 	if l.input[l.pos] == '-' {
 		l.pos++
 		if l.pos >= len(l.input) || !unicode.IsDigit(l.input[l.pos]) {
-			return token{}, fmt.Errorf("unexpected character '-' at position %d", start)
+			return token{}, l.errorAt(start, start+1, "unexpected character '-' at position %d", start)
 		}
 	}
 	l.readDigits()
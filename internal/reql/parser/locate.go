@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"r-cli/internal/reql"
+	"r-cli/internal/response"
+)
+
+// Locate maps the backtrace of a server error back to the query text t
+// was parsed from: it follows path from t and returns the runes of the
+// deepest term on the way that Parse marked with its source. Arguments
+// parsed as plain values, such as the name in r.table("users"), have no
+// source of their own and fall back to the term they belong to. It
+// reports false when no term on the path has a source.
+func Locate(t reql.Term, path []response.Frame) (start, end int, ok bool) {
+	start, end, ok = t.Source()
+	for _, f := range path {
+		var next bool
+		if f.Opt != "" {
+			t, next = t.OptArg(f.Opt)
+		} else {
+			t, next = t.Arg(f.Arg)
+		}
+		if !next {
+			break
+		}
+		if s, e, found := t.Source(); found {
+			start, end, ok = s, e, true
+		}
+	}
+	return start, end, ok
+}
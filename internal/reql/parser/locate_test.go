@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"testing"
+
+	"r-cli/internal/response"
+)
+
+func TestLocate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		path  []response.Frame
+		want  string
+		ok    bool
+	}{
+		{"root", `r.table("a").count()`, nil, ".count()", true},
+		{"receiver", `r.table("a").count()`, []response.Frame{{Arg: 0}}, `r.table("a")`, true},
+		{"datum argument", `r.expr(1).add("x")`, []response.Frame{{Arg: 1}}, `"x"`, true},
+		{"expression argument", `r.expr(1).add(r.expr(2).mul("x"))`, []response.Frame{{Arg: 1}}, `.mul("x")`, true},
+		{"lambda body", `r.table("a").map(d => d("n").add(1))`, []response.Frame{{Arg: 1}, {Arg: 1}}, `.add(1)`, true},
+		{"bracket", `r.table("a").get(1)("n")`, []response.Frame{{Arg: 0}}, `.get(1)`, true},
+		{"optarg value", `r.table("a").getAll("x", {index: "i"})`, []response.Frame{{Opt: "index"}}, `.getAll("x", {index: "i"})`, true},
+		{"past the terms", `r.table("a")`, []response.Frame{{Arg: 0}, {Arg: 5}}, `r.table("a")`, true},
+		{"grouped", `(r.expr(1))`, nil, `(r.expr(1))`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			term := mustParse(t, tt.input)
+			start, end, ok := Locate(term, tt.path)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if got := string([]rune(tt.input)[start:end]); got != tt.want {
+				t.Errorf("Locate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"sort"
+
+	"r-cli/internal/reql"
+)
+
+// Method describes a method name the parser accepts.
+type Method struct {
+	Name string
+	// NoArgs is set for methods that take no arguments, such as count().
+	NoArgs bool
+	Doc    MethodDoc
+}
+
+// RMethods returns the r.* builders, sorted by name.
+func RMethods() []Method {
+	return methods(rBuilders, rDocs, func(fn rBuilderFn, p *parser) error {
+		_, err := fn(p)
+		return err
+	})
+}
+
+// ChainMethods returns the chainable methods, sorted by name.
+func ChainMethods() []Method {
+	return methods(chainBuilders, chainDocs, func(fn chainFn, p *parser) error {
+		_, err := fn(p, reql.Term{})
+		return err
+	})
+}
+
+// argProbes are argument lists covering the shapes builders accept: a
+// term, a string, a key/value pair and an optargs object.
+var argProbes = []string{`(1)`, `("a")`, `("a", 1)`, `({"a": 1})`}
+
+// methods lists the names registered in m with their docs. A builder that
+// parses "()" and rejects every argProbe takes no arguments.
+func methods[F any](m map[string]F, docs map[string]MethodDoc, call func(F, *parser) error) []Method {
+	out := make([]Method, 0, len(m))
+	for name, fn := range m {
+		noArgs := accepts(fn, call, "()")
+		for _, probe := range argProbes {
+			noArgs = noArgs && !accepts(fn, call, probe)
+		}
+		out = append(out, Method{Name: name, NoArgs: noArgs, Doc: docs[name]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// accepts reports whether fn parses all of src, the text after the method name.
+func accepts[F any](fn F, call func(F, *parser) error, src string) bool {
+	toks, err := newLexer(src).tokenize()
+	if err != nil {
+		return false
+	}
+	p := &parser{tokens: toks}
+	return call(fn, p) == nil && p.peek().Type == tokenEOF
+}
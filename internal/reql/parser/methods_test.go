@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"sort"
+	"testing"
+)
+
+func methodSet(methods []Method) map[string]bool {
+	set := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		set[m.Name] = m.NoArgs
+	}
+	return set
+}
+
+func TestRMethods(t *testing.T) {
+	t.Parallel()
+	methods := RMethods()
+	if len(methods) != len(rBuilders) {
+		t.Fatalf("got %d methods, want %d", len(methods), len(rBuilders))
+	}
+	if !sort.SliceIsSorted(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name }) {
+		t.Error("methods are not sorted")
+	}
+	set := methodSet(methods)
+	for name, want := range map[string]bool{"now": true, "dbList": true, "uuid": true, "db": false, "expr": false, "object": false, "row": false} {
+		if got, ok := set[name]; !ok || got != want {
+			t.Errorf("r.%s: NoArgs = %v (listed %v), want %v", name, got, ok, want)
+		}
+	}
+}
+
+func TestChainMethods(t *testing.T) {
+	t.Parallel()
+	set := methodSet(ChainMethods())
+	if len(set) != len(chainBuilders) {
+		t.Fatalf("got %d methods, want %d", len(set), len(chainBuilders))
+	}
+	for name, want := range map[string]bool{
+		"count": true, "distinct": true, "keys": true,
+		"filter": false, "pluck": false, "changes": false, "delete": false, "split": false,
+	} {
+		if got, ok := set[name]; !ok || got != want {
+			t.Errorf(".%s: NoArgs = %v (listed %v), want %v", name, got, ok, want)
+		}
+	}
+}
+
+func TestMethodDocs(t *testing.T) {
+	t.Parallel()
+	check := func(prefix string, methods []Method, docs map[string]MethodDoc) {
+		for _, m := range methods {
+			d := m.Doc
+			if d.Signature == "" || d.Summary == "" || d.Example == "" {
+				t.Errorf("%s%s: incomplete doc %+v", prefix, m.Name, d)
+				continue
+			}
+			if _, err := Parse(d.Example); err != nil {
+				t.Errorf("%s%s: example %s: %v", prefix, m.Name, d.Example, err)
+			}
+		}
+		if len(docs) != len(methods) {
+			t.Errorf("%d %s docs for %d methods", len(docs), prefix, len(methods))
+		}
+	}
+	check("r.", RMethods(), rDocs)
+	check(".", ChainMethods(), chainDocs)
+}
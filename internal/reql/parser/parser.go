@@ -10,18 +10,25 @@ import (
 
 // Parse tokenizes input and builds a reql.Term.
 func Parse(input string) (reql.Term, error) {
+	return ParseWith(input, nil)
+}
+
+// ParseWith is Parse with identifiers bound to terms, such as the REPL's
+// last result. A lambda parameter of the same name shadows a binding, and
+// "r" always starts an r.* expression.
+func ParseWith(input string, bindings map[string]reql.Term) (reql.Term, error) {
 	toks, err := newLexer(input).tokenize()
 	if err != nil {
 		return reql.Term{}, fmt.Errorf("parse: %w", err)
 	}
-	p := &parser{tokens: toks}
+	p := &parser{tokens: toks, bindings: bindings}
 	t, err := p.parseExpr()
 	if err != nil {
 		return reql.Term{}, err
 	}
 	if p.peek().Type != tokenEOF {
 		tok := p.peek()
-		return reql.Term{}, fmt.Errorf("unexpected token %q at position %d", tok.Value, tok.Pos)
+		return reql.Term{}, errorAt(tok, "unexpected token %q at position %d", tok.Value, tok.Pos)
 	}
 	return t, nil
 }
@@ -34,6 +41,7 @@ type parser struct {
 	depth       int
 	paramsStack []map[string]int
 	nextVarID   int
+	bindings    map[string]reql.Term
 }
 
 func (p *parser) inLambda() bool {
@@ -116,7 +124,7 @@ func (p *parser) expect(tt tokenType) (token, error) {
 	tok := p.peek()
 	if tok.Type != tt {
 		name := tokenNames[tt]
-		return token{}, fmt.Errorf("expected %s, got %q at position %d", name, tok.Value, tok.Pos)
+		return token{}, errorAt(tok, "expected %s, got %q at position %d", name, tok.Value, tok.Pos)
 	}
 	return p.advance(), nil
 }
@@ -127,11 +135,20 @@ func (p *parser) parseExpr() (reql.Term, error) {
 		return reql.Term{}, fmt.Errorf("expression too deeply nested (max depth %d)", maxDepth)
 	}
 	defer func() { p.depth-- }()
+	start := p.peek().Pos
 	t, err := p.parsePrimary()
 	if err != nil {
 		return reql.Term{}, err
 	}
-	return p.parseChain(t)
+	return p.parseChain(t.WithSource(start, p.lastEnd()))
+}
+
+// lastEnd returns the rune index just past the last token consumed.
+func (p *parser) lastEnd() int {
+	if p.pos == 0 {
+		return 0
+	}
+	return p.tokens[p.pos-1].End
 }
 
 func (p *parser) parsePrimary() (reql.Term, error) {
@@ -183,6 +200,10 @@ func (p *parser) parseIdentPrimary(tok token) (reql.Term, error) {
 		p.advance()
 		return p.parseRExpr()
 	}
+	if t, ok := p.bindings[tok.Value]; ok {
+		p.advance()
+		return t, nil
+	}
 	return p.parseDatumTerm()
 }
 
@@ -243,7 +264,7 @@ func (p *parser) parseRExpr() (reql.Term, error) {
 	}
 	fn, ok := rBuilders[method.Value]
 	if !ok {
-		return reql.Term{}, fmt.Errorf("unknown r.%s at position %d", method.Value, method.Pos)
+		return reql.Term{}, errorAt(method, "unknown r.%s at position %d", method.Value, method.Pos)
 	}
 	return fn(p)
 }
@@ -264,26 +285,30 @@ func (p *parser) parseChain(t reql.Term) (reql.Term, error) {
 	for {
 		switch p.peek().Type {
 		case tokenDot:
-			p.advance()
+			dot := p.advance()
 			method, err := p.expect(tokenIdent)
 			if err != nil {
 				return reql.Term{}, err
 			}
 			fn, ok := chainBuilders[method.Value]
 			if !ok {
-				return reql.Term{}, fmt.Errorf("unknown method .%s at position %d", method.Value, method.Pos)
+				return reql.Term{}, errorAt(method, "unknown method .%s at position %d", method.Value, method.Pos)
 			}
 			t, err = fn(p, t)
 			if err != nil {
 				return reql.Term{}, err
 			}
+			// a method call is marked from its dot, so errors point at it
+			t = t.WithSource(dot.Pos, p.lastEnd())
 		case tokenLParen:
 			// bracket notation: term("field") or term(0)
+			start := p.peek().Pos
 			var err error
 			t, err = p.parseBracketArg(t)
 			if err != nil {
 				return reql.Term{}, err
 			}
+			t = t.WithSource(start, p.lastEnd())
 		default:
 			return t, nil
 		}
@@ -376,7 +401,7 @@ func (p *parser) parseLambdaParams() ([]string, error) {
 		if p.peek().Type == tokenComma {
 			p.advance()
 			if p.peek().Type == tokenRParen {
-				return nil, fmt.Errorf("trailing comma in parameter list at position %d", p.peek().Pos)
+				return nil, errorAt(p.peek(), "trailing comma in parameter list at position %d", p.peek().Pos)
 			}
 		} else {
 			break
@@ -394,14 +419,14 @@ func (p *parser) parseLambdaParams() ([]string, error) {
 // validateLambdaParam checks that tok is a valid, non-duplicate parameter name.
 func validateLambdaParam(tok token, seen []string) error {
 	if tok.Type != tokenIdent {
-		return fmt.Errorf("expected identifier in lambda parameter, got %q at position %d", tok.Value, tok.Pos)
+		return errorAt(tok, "expected identifier in lambda parameter, got %q at position %d", tok.Value, tok.Pos)
 	}
 	if tok.Value == "return" || tok.Value == "function" {
-		return fmt.Errorf("reserved word %q cannot be used as parameter name at position %d", tok.Value, tok.Pos)
+		return errorAt(tok, "reserved word %q cannot be used as parameter name at position %d", tok.Value, tok.Pos)
 	}
 	for _, existing := range seen {
 		if existing == tok.Value {
-			return fmt.Errorf("duplicate parameter name %q at position %d", tok.Value, tok.Pos)
+			return errorAt(tok, "duplicate parameter name %q at position %d", tok.Value, tok.Pos)
 		}
 	}
 	return nil
@@ -713,7 +738,7 @@ func (p *parser) parseRandomArgs() ([]interface{}, error) {
 	case tokenRParen:
 		// no opts
 	default:
-		return nil, fmt.Errorf("r.random accepts 0, 1, or 2 arguments at position %d", p.peek().Pos)
+		return nil, errorAt(p.peek(), "r.random accepts 0, 1, or 2 arguments at position %d", p.peek().Pos)
 	}
 	return args, nil
 }
@@ -735,7 +760,7 @@ func (p *parser) parseRandomNumericArgs() ([]interface{}, error) {
 		}
 		p.advance()
 		if p.peek().Type == tokenRParen {
-			return nil, fmt.Errorf("trailing comma in argument list at position %d", p.peek().Pos)
+			return nil, errorAt(p.peek(), "trailing comma in argument list at position %d", p.peek().Pos)
 		}
 	}
 	return args, nil
@@ -850,7 +875,7 @@ func chainInsert(p *parser, t reql.Term) (reql.Term, error) {
 	if p.peek().Type == tokenComma {
 		p.advance()
 		if p.peek().Type != tokenLBrace {
-			return reql.Term{}, fmt.Errorf("insert: second argument must be an optargs object at position %d", p.peek().Pos)
+			return reql.Term{}, errorAt(p.peek(), "insert: second argument must be an optargs object at position %d", p.peek().Pos)
 		}
 		opts, err := p.parseOptArgs()
 		if err != nil {
@@ -878,7 +903,7 @@ func chainUpdate(p *parser, t reql.Term) (reql.Term, error) {
 	if p.peek().Type == tokenComma {
 		p.advance()
 		if p.peek().Type != tokenLBrace {
-			return reql.Term{}, fmt.Errorf("update: second argument must be an optargs object at position %d", p.peek().Pos)
+			return reql.Term{}, errorAt(p.peek(), "update: second argument must be an optargs object at position %d", p.peek().Pos)
 		}
 		opts, err := p.parseOptArgs()
 		if err != nil {
@@ -904,7 +929,7 @@ func chainDelete(p *parser, t reql.Term) (reql.Term, error) {
 		return t.Delete(), nil
 	}
 	if p.peek().Type != tokenLBrace {
-		return reql.Term{}, fmt.Errorf("delete: argument must be an optargs object at position %d", p.peek().Pos)
+		return reql.Term{}, errorAt(p.peek(), "delete: argument must be an optargs object at position %d", p.peek().Pos)
 	}
 	opts, err := p.parseOptArgs()
 	if err != nil {
@@ -957,7 +982,7 @@ func chainEqJoin(p *parser, t reql.Term) (reql.Term, error) {
 	if p.peek().Type == tokenComma {
 		p.advance()
 		if p.peek().Type != tokenLBrace {
-			return reql.Term{}, fmt.Errorf("eqJoin: third argument must be an optargs object at position %d", p.peek().Pos)
+			return reql.Term{}, errorAt(p.peek(), "eqJoin: third argument must be an optargs object at position %d", p.peek().Pos)
 		}
 		opts, err := p.parseOptArgs()
 		if err != nil {
@@ -992,7 +1017,7 @@ func chainBetween(p *parser, t reql.Term) (reql.Term, error) {
 	if p.peek().Type == tokenComma {
 		p.advance()
 		if p.peek().Type != tokenLBrace {
-			return reql.Term{}, fmt.Errorf("between: third argument must be an optargs object at position %d", p.peek().Pos)
+			return reql.Term{}, errorAt(p.peek(), "between: third argument must be an optargs object at position %d", p.peek().Pos)
 		}
 		opts, err := p.parseOptArgs()
 		if err != nil {
@@ -1353,7 +1378,7 @@ func noArgChainWithOpts(fn func(reql.Term, ...reql.OptArgs) reql.Term) chainFn {
 			return fn(t), nil
 		}
 		if p.peek().Type != tokenLBrace {
-			return reql.Term{}, fmt.Errorf("expected optargs object at position %d", p.peek().Pos)
+			return reql.Term{}, errorAt(p.peek(), "expected optargs object at position %d", p.peek().Pos)
 		}
 		opts, err := p.parseOptArgs()
 		if err != nil {
@@ -1379,7 +1404,7 @@ func oneArgChainWithOpts(fn func(reql.Term, reql.Term, ...reql.OptArgs) reql.Ter
 		if p.peek().Type == tokenComma {
 			p.advance()
 			if p.peek().Type != tokenLBrace {
-				return reql.Term{}, fmt.Errorf("expected optargs object at position %d", p.peek().Pos)
+				return reql.Term{}, errorAt(p.peek(), "expected optargs object at position %d", p.peek().Pos)
 			}
 			opts, err := p.parseOptArgs()
 			if err != nil {
@@ -1410,7 +1435,7 @@ func strArgChainWithOpts(fn func(reql.Term, string, ...reql.OptArgs) reql.Term)
 		if p.peek().Type == tokenComma {
 			p.advance()
 			if p.peek().Type != tokenLBrace {
-				return reql.Term{}, fmt.Errorf("expected optargs object at position %d", p.peek().Pos)
+				return reql.Term{}, errorAt(p.peek(), "expected optargs object at position %d", p.peek().Pos)
 			}
 			opts, err := p.parseOptArgs()
 			if err != nil {
@@ -1676,11 +1701,11 @@ func (p *parser) parseBracketArg(t reql.Term) (reql.Term, error) {
 		}
 		n, err := strconv.Atoi(tok.Value)
 		if err != nil {
-			return reql.Term{}, fmt.Errorf("bracket index must be an integer, got %q at position %d", tok.Value, tok.Pos)
+			return reql.Term{}, errorAt(tok, "bracket index must be an integer, got %q at position %d", tok.Value, tok.Pos)
 		}
 		return t.Nth(n), nil
 	default:
-		return reql.Term{}, fmt.Errorf("expected string or integer in bracket notation at position %d", tok.Pos)
+		return reql.Term{}, errorAt(tok, "expected string or integer in bracket notation at position %d", tok.Pos)
 	}
 }
 
@@ -1752,7 +1777,7 @@ func (p *parser) parseArgList() ([]reql.Term, error) {
 				return nil, err
 			}
 			if p.peek().Type == tokenRParen {
-				return nil, fmt.Errorf("trailing comma in argument list at position %d", p.peek().Pos)
+				return nil, errorAt(p.peek(), "trailing comma in argument list at position %d", p.peek().Pos)
 			}
 		}
 	}
@@ -1796,7 +1821,7 @@ func (p *parser) parseArgAndSep() (reql.Term, reql.OptArgs, bool, error) {
 		return reql.Term{}, nil, false, err
 	}
 	if p.peek().Type == tokenRParen {
-		return reql.Term{}, nil, false, fmt.Errorf("trailing comma in argument list at position %d", p.peek().Pos)
+		return reql.Term{}, nil, false, errorAt(p.peek(), "trailing comma in argument list at position %d", p.peek().Pos)
 	}
 	if opts, ok := p.tryTrailingOptArgs(); ok {
 		return arg, opts, true, nil
@@ -1888,7 +1913,7 @@ func (p *parser) parseObjectBody(valueParser func() (interface{}, error)) (reql.
 		if p.peek().Type == tokenComma {
 			p.advance()
 			if p.peek().Type == tokenRBrace {
-				return nil, fmt.Errorf("trailing comma in opts at position %d", p.peek().Pos)
+				return nil, errorAt(p.peek(), "trailing comma in opts at position %d", p.peek().Pos)
 			}
 		}
 	}
@@ -1920,7 +1945,7 @@ func (p *parser) parseOptArgValue() (interface{}, error) {
 		p.advance()
 		return nil, nil
 	}
-	return nil, fmt.Errorf("expected datum literal in optargs at position %d, got %q", tok.Pos, tok.Value)
+	return nil, errorAt(tok, "expected datum literal in optargs at position %d, got %q", tok.Pos, tok.Value)
 }
 
 // parseStringList parses ("s1", "s2", ...) and returns the string values.
@@ -1943,7 +1968,7 @@ func (p *parser) parseStringList() ([]string, error) {
 				return nil, err
 			}
 			if p.peek().Type == tokenRParen {
-				return nil, fmt.Errorf("trailing comma in argument list at position %d", p.peek().Pos)
+				return nil, errorAt(p.peek(), "trailing comma in argument list at position %d", p.peek().Pos)
 			}
 		}
 	}
@@ -1974,7 +1999,7 @@ func (p *parser) parseFieldSelectors() ([]interface{}, error) {
 				return nil, err
 			}
 			if p.peek().Type == tokenRParen {
-				return nil, fmt.Errorf("trailing comma in argument list at position %d", p.peek().Pos)
+				return nil, errorAt(p.peek(), "trailing comma in argument list at position %d", p.peek().Pos)
 			}
 		}
 	}
@@ -1993,7 +2018,7 @@ func (p *parser) parseOneFieldSelector() (interface{}, error) {
 	case tokenLBrace:
 		return p.parseDatumObject()
 	default:
-		return nil, fmt.Errorf("expected string or object in field selector at position %d, got %q", tok.Pos, tok.Value)
+		return nil, errorAt(tok, "expected string or object in field selector at position %d, got %q", tok.Pos, tok.Value)
 	}
 }
 
@@ -2029,7 +2054,7 @@ func (p *parser) parseDatumValue() (interface{}, error) {
 	case tokenLBrace:
 		return p.parseDatumObject()
 	default:
-		return nil, fmt.Errorf("expected datum value at position %d, got %q", tok.Pos, tok.Value)
+		return nil, errorAt(tok, "expected datum value at position %d, got %q", tok.Pos, tok.Value)
 	}
 }
 
@@ -2050,7 +2075,7 @@ func (p *parser) parseDatumArray() (reql.Term, error) {
 		if p.peek().Type == tokenComma {
 			p.advance()
 			if p.peek().Type == tokenRBracket {
-				return reql.Term{}, fmt.Errorf("trailing comma in array at position %d", p.peek().Pos)
+				return reql.Term{}, errorAt(p.peek(), "trailing comma in array at position %d", p.peek().Pos)
 			}
 		}
 	}
@@ -2083,7 +2108,7 @@ func (p *parser) parseDatumObject() (map[string]interface{}, error) {
 		if p.peek().Type == tokenComma {
 			p.advance()
 			if p.peek().Type == tokenRBrace {
-				return nil, fmt.Errorf("trailing comma in object at position %d", p.peek().Pos)
+				return nil, errorAt(p.peek(), "trailing comma in object at position %d", p.peek().Pos)
 			}
 		}
 	}
@@ -2258,7 +2283,7 @@ func (p *parser) parseObjectTerm() (reql.Term, error) {
 		if p.peek().Type == tokenComma {
 			p.advance()
 			if p.peek().Type == tokenRBrace {
-				return reql.Term{}, fmt.Errorf("trailing comma in object at position %d", p.peek().Pos)
+				return reql.Term{}, errorAt(p.peek(), "trailing comma in object at position %d", p.peek().Pos)
 			}
 		}
 	}
@@ -2274,7 +2299,7 @@ func (p *parser) parseObjectKey() (string, error) {
 		p.advance()
 		return tok.Value, nil
 	}
-	return "", fmt.Errorf("expected object key at position %d, got %q", tok.Pos, tok.Value)
+	return "", errorAt(tok, "expected object key at position %d, got %q", tok.Pos, tok.Value)
 }
 
 // parseArrayTerm parses [val, ...] and returns a MAKE_ARRAY term.
@@ -2292,7 +2317,7 @@ func (p *parser) parseArrayTerm() (reql.Term, error) {
 		if p.peek().Type == tokenComma {
 			p.advance()
 			if p.peek().Type == tokenRBracket {
-				return reql.Term{}, fmt.Errorf("trailing comma in array at position %d", p.peek().Pos)
+				return reql.Term{}, errorAt(p.peek(), "trailing comma in array at position %d", p.peek().Pos)
 			}
 		}
 	}
@@ -2322,7 +2347,7 @@ func (p *parser) parseDatumTerm() (reql.Term, error) {
 		p.advance()
 		return reql.Datum(nil), nil
 	default:
-		return reql.Term{}, fmt.Errorf("unexpected token %q at position %d", tok.Value, tok.Pos)
+		return reql.Term{}, errorAt(tok, "unexpected token %q at position %d", tok.Value, tok.Pos)
 	}
 }
 
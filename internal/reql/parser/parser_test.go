@@ -1755,3 +1755,28 @@ func TestCamelToSnake(t *testing.T) {
 		}
 	}
 }
+
+func TestParseWith_Bindings(t *testing.T) {
+	t.Parallel()
+	last := reql.JSON(`{"generated_keys":["k1"]}`)
+	bindings := map[string]reql.Term{"_": last, "ids": reql.JSON(`["a","b"]`)}
+	tests := []struct {
+		input string
+		want  reql.Term
+	}{
+		{`_("generated_keys")(0)`, last.Bracket("generated_keys").Nth(0)},
+		{`r.table("users").getAll(r.args(ids))`, reql.Table("users").GetAll(reql.Args(reql.JSON(`["a","b"]`)))},
+		// a lambda parameter shadows a binding
+		{`r.expr([1]).map(ids => ids)`, reql.Array(1).Map(reql.Func(reql.Var(1), 1))},
+	}
+	for _, tt := range tests {
+		got, err := ParseWith(tt.input, bindings)
+		if err != nil {
+			t.Fatalf("ParseWith(%q): %v", tt.input, err)
+		}
+		assertTermEqual(t, got, tt.want)
+	}
+	if _, err := Parse("ids"); err == nil {
+		t.Error("Parse(ids) without bindings succeeded")
+	}
+}
@@ -0,0 +1,75 @@
+package parser
+
+// SpanKind classifies a Span for syntax highlighting.
+type SpanKind int
+
+// Span kinds.
+const (
+	SpanInvalid SpanKind = iota // a character the lexer rejects
+	SpanKeyword                 // the r namespace
+	SpanMethod                  // an identifier after a dot
+	SpanIdent                   // any other identifier, e.g. an arrow function parameter
+	SpanString
+	SpanNumber
+	SpanLiteral // true, false and null
+	SpanBracket // ( ) [ ] { }
+	SpanPunct   // . , : ; =>
+)
+
+// Span is a token of the input, [Start, End) in runes.
+type Span struct {
+	Kind       SpanKind
+	Start, End int
+}
+
+// Scan splits input into spans with the lexer Parse uses. Unlike Parse it
+// accepts incomplete input: an unterminated string spans to the end and a
+// rejected character becomes a one-rune SpanInvalid. Whitespace is skipped.
+func Scan(input string) []Span {
+	l := newLexer(input)
+	var spans []Span
+	afterDot := false
+	for {
+		l.skipWhitespace()
+		start := l.pos
+		tok, err := l.next()
+		if err != nil {
+			if ch := l.input[start]; ch == '"' || ch == '\'' {
+				return append(spans, Span{Kind: SpanString, Start: start, End: len(l.input)})
+			}
+			spans = append(spans, Span{Kind: SpanInvalid, Start: start, End: start + 1})
+			l.pos = start + 1
+			afterDot = false
+			continue
+		}
+		if tok.Type == tokenEOF {
+			return spans
+		}
+		spans = append(spans, Span{Kind: spanKind(tok, afterDot), Start: start, End: l.pos})
+		afterDot = tok.Type == tokenDot
+	}
+}
+
+// spanKind maps a token to its SpanKind; afterDot is set when the previous
+// token was a dot.
+func spanKind(tok token, afterDot bool) SpanKind {
+	switch tok.Type {
+	case tokenIdent:
+		switch {
+		case afterDot:
+			return SpanMethod
+		case tok.Value == "r":
+			return SpanKeyword
+		}
+		return SpanIdent
+	case tokenString:
+		return SpanString
+	case tokenNumber:
+		return SpanNumber
+	case tokenBool, tokenNull:
+		return SpanLiteral
+	case tokenLParen, tokenRParen, tokenLBracket, tokenRBracket, tokenLBrace, tokenRBrace:
+		return SpanBracket
+	}
+	return SpanPunct
+}
@@ -0,0 +1,26 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`r.table("users").count()`, `[{1 0 1} {8 1 2} {2 2 7} {7 7 8} {4 8 15} {7 15 16} {8 16 17} {2 17 22} {7 22 23} {7 23 24}]`},
+		{`x => x("a") == 1`, `[{3 0 1} {8 2 4} {3 5 6} {7 6 7} {4 7 10} {7 10 11} {0 12 13} {0 13 14} {5 15 16}]`},
+		{`[true, null, -1.5e3]`, `[{7 0 1} {6 1 5} {8 5 6} {6 7 11} {8 11 12} {5 13 19} {7 19 20}]`},
+		{`r.db("te`, `[{1 0 1} {8 1 2} {2 2 4} {7 4 5} {4 5 8}]`},
+		{`"é" #`, `[{4 0 3} {0 4 5}]`},
+		{``, `[]`},
+	}
+	for _, tc := range tests {
+		if got := fmt.Sprint(Scan(tc.input)); got != tc.want {
+			t.Errorf("Scan(%q) = %s, want %s", tc.input, got, tc.want)
+		}
+	}
+}
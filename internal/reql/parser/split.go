@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// SplitQueries reads a script of queries separated by lines containing
+// only "---" and returns the non-empty ones, trimmed.
+func SplitQueries(r io.Reader) ([]string, error) {
+	var queries []string
+	var cur strings.Builder
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, bufio.MaxScanTokenSize), 64*1024*1024) // match max payload 64MB
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "---" {
+			if q := strings.TrimSpace(cur.String()); q != "" {
+				queries = append(queries, q)
+			}
+			cur.Reset()
+		} else {
+			cur.WriteString(line)
+			cur.WriteByte('\n')
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if q := strings.TrimSpace(cur.String()); q != "" {
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
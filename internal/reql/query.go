@@ -1,8 +1,10 @@
 package reql
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"r-cli/internal/proto"
 )
@@ -13,7 +15,7 @@ import (
 // STOP: [3]
 func BuildQuery(qt proto.QueryType, term Term, opts OptArgs) ([]byte, error) {
 	switch qt {
-	case proto.QueryContinue, proto.QueryStop:
+	case proto.QueryContinue, proto.QueryStop, proto.QueryServerInfo:
 		return json.Marshal([]interface{}{int(qt)})
 	case proto.QueryStart:
 		qOpts := make(map[string]interface{}, len(opts))
@@ -28,3 +30,94 @@ func BuildQuery(qt proto.QueryType, term Term, opts OptArgs) ([]byte, error) {
 		return nil, fmt.Errorf("reql: unsupported query type %d", qt)
 	}
 }
+
+// writeTermTypes are the terms that change documents, schema, permissions or
+// cluster configuration, plus r.http, whose requests may have side effects.
+var writeTermTypes = map[proto.TermType]bool{
+	proto.TermInsert: true, proto.TermUpdate: true, proto.TermDelete: true, proto.TermReplace: true,
+	proto.TermDBCreate: true, proto.TermDBDrop: true, proto.TermTableCreate: true, proto.TermTableDrop: true,
+	proto.TermIndexCreate: true, proto.TermIndexDrop: true, proto.TermIndexRename: true,
+	proto.TermReconfigure: true, proto.TermRebalance: true, proto.TermSync: true,
+	proto.TermGrant: true, proto.TermSetWriteHook: true, proto.TermHTTP: true,
+}
+
+// IsWrite reports whether term contains a term that may change server state,
+// so running it twice is not safe. The check walks the serialized term, which
+// also covers raw JSON terms; a term that fails to serialize counts as a write.
+func IsWrite(term Term) bool {
+	v, err := decodeWire(term)
+	if err != nil {
+		return true
+	}
+	_, found := findTerm(v, func(tt proto.TermType) bool { return writeTermTypes[tt] })
+	return found
+}
+
+// Find reports whether term contains a term of one of types, term itself
+// included, and returns its type. Like IsWrite it walks the serialized term;
+// a term that fails to serialize contains none.
+func Find(term Term, types ...proto.TermType) (proto.TermType, bool) {
+	v, err := decodeWire(term)
+	if err != nil {
+		return 0, false
+	}
+	return findTerm(v, func(tt proto.TermType) bool { return slices.Contains(types, tt) })
+}
+
+// decodeWire serializes term and decodes it back into generic JSON values.
+func decodeWire(term Term) (interface{}, error) {
+	data, err := json.Marshal(term)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	err = dec.Decode(&v)
+	return v, err
+}
+
+// findTerm walks a decoded wire term for a term whose type matches. A term
+// is [type, [args...], {optargs}?] with terms as its args and optarg values,
+// an object's values are terms, and any other value is a datum; the type is
+// read only from position 0 of a term, so numbers in args never count.
+func findTerm(v interface{}, match func(proto.TermType) bool) (proto.TermType, bool) {
+	switch x := v.(type) {
+	case []interface{}:
+		if len(x) == 0 {
+			return 0, false
+		}
+		if n, ok := x[0].(json.Number); ok {
+			if id, err := n.Int64(); err == nil && match(proto.TermType(id)) {
+				return proto.TermType(id), true
+			}
+		}
+		if len(x) > 1 {
+			args, _ := x[1].([]interface{})
+			for _, e := range args {
+				if tt, ok := findTerm(e, match); ok {
+					return tt, true
+				}
+			}
+		}
+		if len(x) > 2 {
+			return findTerm(x[2], match)
+		}
+	case map[string]interface{}:
+		for _, e := range x {
+			if tt, ok := findTerm(e, match); ok {
+				return tt, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// IsTableScan reports whether term is a table, or a chain of filters on
+// one, so its result is every matching document with nothing bounding it.
+func IsTableScan(term Term) bool {
+	for term.termType == proto.TermFilter && len(term.args) > 0 {
+		term = term.args[0]
+	}
+	return term.termType == proto.TermTable
+}
@@ -1,6 +1,7 @@
 package reql
 
 import (
+	"encoding/json"
 	"testing"
 
 	"r-cli/internal/proto"
@@ -58,6 +59,13 @@ func TestBuildQuery(t *testing.T) {
 			nil,
 			`[3]`,
 		},
+		{
+			"server_info",
+			proto.QueryServerInfo,
+			Term{},
+			nil,
+			`[5]`,
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -80,3 +88,89 @@ func TestBuildQueryUnsupported(t *testing.T) {
 		t.Error("expected error for unsupported query type, got nil")
 	}
 }
+
+func TestIsWrite(t *testing.T) {
+	t.Parallel()
+	users := DB("test").Table("users")
+	tests := []struct {
+		name string
+		term Term
+		want bool
+	}{
+		{"table read", users, false},
+		{"filter and count", users.Filter(map[string]interface{}{"active": true}).Count(), false},
+		{"db list", DBList(), false},
+		{"insert", users.Insert(map[string]interface{}{"id": 1}), true},
+		{"nested delete", Array(users.Get(1).Delete()), true},
+		{"table create", DB("test").TableCreate("t"), true},
+		{"string datum", Datum("[56,[]]"), false},
+		{"raw read", Datum(json.RawMessage(`[15,[[14,["test"]],"users"]]`)), false},
+		{"raw insert", Datum(json.RawMessage(`[56,[[15,["users"]],{"id":1}]]`)), true},
+		{"raw write in optarg", Datum(json.RawMessage(`[39,[],{"x":[54,[[15,["users"]]]]}]`)), true},
+		{"number args matching write ids", Datum(54).Add(56), false},
+		{"array literal matching write ids", Array(56, 1), false},
+		{"nested array literal", Array(Array(54, 56)), false},
+		{"raw number args", Datum(json.RawMessage(`[24,[54,1]]`)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := IsWrite(tt.term); got != tt.want {
+				t.Errorf("IsWrite() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+	drops := []proto.TermType{proto.TermDelete, proto.TermTableDrop, proto.TermDBDrop, proto.TermIndexDrop}
+	tests := []struct {
+		name string
+		term Term
+		want proto.TermType
+	}{
+		{"delete", Table("a").Get(1).Delete(), proto.TermDelete},
+		{"in an argument", Array(DB("app").TableDrop("a")), proto.TermTableDrop},
+		{"in an object", Datum(map[string]interface{}{"x": DBDrop("app")}), proto.TermDBDrop},
+		{"in an optarg", Table("a").Insert(1, OptArgs{"x": Table("b").IndexDrop("i")}), proto.TermIndexDrop},
+		{"read", Table("a").Filter(map[string]interface{}{"x": 1}).Count(), 0},
+		{"string datum", Datum("[54,[]]"), 0},
+		{"number args", Datum(54).Add(1), 0},
+		{"array literal", Array(56, 54, 1), 0},
+		{"object of array literals", Datum(map[string]interface{}{"x": Array(60, 1)}), 0},
+	}
+	for _, tt := range tests {
+		got, ok := Find(tt.term, drops...)
+		if got != tt.want || ok != (tt.want != 0) {
+			t.Errorf("%s: Find = %v, %v; want %v", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestIsTableScan(t *testing.T) {
+	t.Parallel()
+	users := DB("test").Table("users")
+	active := map[string]interface{}{"active": true}
+	tests := []struct {
+		name string
+		term Term
+		want bool
+	}{
+		{"table", users, true},
+		{"table without db", Table("users"), true},
+		{"filter", users.Filter(active), true},
+		{"filters", users.Filter(active).Filter(map[string]interface{}{"age": 3}), true},
+		{"limit", users.Filter(active).Limit(5), false},
+		{"count", users.Count(), false},
+		{"get", users.Get(1), false},
+		{"filter on an array", Array(1, 2).Filter(active), false},
+		{"table list", DB("test").TableList(), false},
+		{"raw table", Datum(json.RawMessage(`[15,["users"]]`)), false},
+	}
+	for _, tt := range tests {
+		if got := IsTableScan(tt.term); got != tt.want {
+			t.Errorf("%s: IsTableScan = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
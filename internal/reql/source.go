@@ -0,0 +1,32 @@
+package reql
+
+// WithSource returns t marked as parsed from the runes [start, end) of the
+// query text, so a server error pointing at t can be shown in the text.
+// The mark is not sent to the server.
+func (t Term) WithSource(start, end int) Term {
+	if end > start {
+		t.src = [2]int{start, end}
+	}
+	return t
+}
+
+// Source returns the runes of the query text t was parsed from; false when
+// t was not marked with WithSource.
+func (t Term) Source() (start, end int, ok bool) {
+	return t.src[0], t.src[1], t.src[1] > t.src[0]
+}
+
+// Arg returns the i-th argument of t as sent to the server. A datum has
+// none.
+func (t Term) Arg(i int) (Term, bool) {
+	if t.termType == 0 || i < 0 || i >= len(t.args) {
+		return Term{}, false
+	}
+	return t.args[i], true
+}
+
+// OptArg returns the optional argument key of t when it is a term.
+func (t Term) OptArg(key string) (Term, bool) {
+	v, ok := t.opts[key].(Term)
+	return v, ok
+}
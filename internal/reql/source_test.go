@@ -0,0 +1,49 @@
+package reql
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTermSource(t *testing.T) {
+	t.Parallel()
+	if _, _, ok := Table("a").Source(); ok {
+		t.Error("built term has a source")
+	}
+	term := Table("a").WithSource(2, 9)
+	if start, end, ok := term.Source(); !ok || start != 2 || end != 9 {
+		t.Errorf("Source = %d, %d, %v; want 2, 9, true", start, end, ok)
+	}
+	if _, _, ok := Table("a").WithSource(3, 3).Source(); ok {
+		t.Error("empty span kept")
+	}
+	got, err := json.Marshal(term)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `[15,["a"]]` {
+		t.Errorf("source changed the wire form: %s", got)
+	}
+}
+
+func TestTermArgs(t *testing.T) {
+	t.Parallel()
+	term := DB("d").Table("t").GetAll("x", OptArgs{"index": Datum("i").WithSource(1, 2)})
+	if arg, ok := term.Arg(0); !ok || arg.termType == 0 {
+		t.Errorf("Arg(0) = %v, %v", arg, ok)
+	}
+	if _, ok := term.Arg(9); ok {
+		t.Error("Arg(9) exists")
+	}
+	if _, ok := Datum("x").Arg(0); ok {
+		t.Error("datum has arguments")
+	}
+	if opt, ok := term.OptArg("index"); !ok {
+		t.Error("OptArg(index) missing")
+	} else if _, _, src := opt.Source(); !src {
+		t.Error("OptArg lost the source")
+	}
+	if _, ok := term.OptArg("left_bound"); ok {
+		t.Error("OptArg(left_bound) exists")
+	}
+}
@@ -15,6 +15,7 @@ type Term struct {
 	args     []Term
 	opts     map[string]interface{}
 	err      error
+	src      [2]int // runes [start, end) of the parsed text, see WithSource
 }
 
 // errTerm returns a Term that serializes as an error.
@@ -78,6 +79,7 @@ func replaceImplicit(t Term, inFunc bool) (Term, bool, error) {
 		args:     newArgs,
 		opts:     t.opts,
 		err:      t.err,
+		src:      t.src,
 	}, true, nil
 }
 
@@ -475,6 +477,17 @@ func (t Term) IndexCreate(name string, opts ...OptArgs) Term {
 	return term
 }
 
+// IndexCreateFunc creates an INDEX_CREATE term with an index function
+// ([75, [table, name, fn]], opts?). fn is a FUNC term or the BINARY function
+// reported by indexStatus().
+func (t Term) IndexCreateFunc(name string, fn interface{}, opts ...OptArgs) Term {
+	term := Term{termType: proto.TermIndexCreate, args: []Term{t, Datum(name), toTerm(fn)}}
+	if len(opts) > 0 {
+		term.opts = opts[0]
+	}
+	return term
+}
+
 // IndexDrop creates an INDEX_DROP term ([76, [table, name]]).
 func (t Term) IndexDrop(name string) Term {
 	return Term{termType: proto.TermIndexDrop, args: []Term{t, Datum(name)}}
@@ -1014,9 +1027,13 @@ func (t Term) Rebalance() Term {
 	return Term{termType: proto.TermRebalance, args: []Term{t}}
 }
 
-// Wait creates a WAIT term ([177, [table_term]]).
-func (t Term) Wait() Term {
-	return Term{termType: proto.TermWait, args: []Term{t}}
+// Wait creates a WAIT term ([177, [table_term]], opts?); opts take wait_for and timeout.
+func (t Term) Wait(opts ...OptArgs) Term {
+	term := Term{termType: proto.TermWait, args: []Term{t}}
+	if len(opts) > 0 {
+		term.opts = opts[0]
+	}
+	return term
 }
 
 // Args creates an ARGS term ([154, [array]]).
@@ -285,10 +285,12 @@ func TestIndexOperations(t *testing.T) {
 		want string
 	}{
 		{"index_create", table.IndexCreate("name"), `[75,[[15,[[14,["test"]],"users"]],"name"]]`},
+		{"index_create_func", table.IndexCreateFunc("name", Binary("AA==")), `[75,[[15,[[14,["test"]],"users"]],"name",[155,["AA=="]]]]`},
 		{"index_drop", table.IndexDrop("name"), `[76,[[15,[[14,["test"]],"users"]],"name"]]`},
 		{"index_list", table.IndexList(), `[77,[[15,[[14,["test"]],"users"]]]]`},
 		{"index_wait", table.IndexWait("name"), `[140,[[15,[[14,["test"]],"users"]],"name"]]`},
 		{"index_wait_all", table.IndexWait(), `[140,[[15,[[14,["test"]],"users"]]]]`},
+		{"wait_for", table.Wait(OptArgs{"wait_for": "ready_for_writes"}), `[177,[[15,[[14,["test"]],"users"]]],{"wait_for":"ready_for_writes"}]`},
 		{"index_status", table.IndexStatus("name"), `[139,[[15,[[14,["test"]],"users"]],"name"]]`},
 		{"index_status_all", table.IndexStatus(), `[139,[[15,[[14,["test"]],"users"]]]]`},
 		{"index_rename", table.IndexRename("old", "new"), `[156,[[15,[[14,["test"]],"users"]],"old","new"]]`},
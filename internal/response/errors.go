@@ -2,6 +2,7 @@ package response
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -16,6 +17,9 @@ type ReqlClientError struct {
 
 func (e *ReqlClientError) Error() string { return formatMsg(e.Msg, e.backtrace) }
 
+// Backtrace returns the path to the term the error is about.
+func (e *ReqlClientError) Backtrace() []Frame { return decodeBacktrace(e.backtrace) }
+
 // ReqlCompileError is returned when the server reports a COMPILE_ERROR (response type 17).
 type ReqlCompileError struct {
 	Msg       string
@@ -24,6 +28,9 @@ type ReqlCompileError struct {
 
 func (e *ReqlCompileError) Error() string { return formatMsg(e.Msg, e.backtrace) }
 
+// Backtrace returns the path to the term the error is about.
+func (e *ReqlCompileError) Backtrace() []Frame { return decodeBacktrace(e.backtrace) }
+
 // ReqlRuntimeError is returned for RUNTIME_ERROR (response type 18) with no specific subtype.
 type ReqlRuntimeError struct {
 	Msg       string
@@ -32,6 +39,9 @@ type ReqlRuntimeError struct {
 
 func (e *ReqlRuntimeError) Error() string { return formatMsg(e.Msg, e.backtrace) }
 
+// Backtrace returns the path to the term the error is about.
+func (e *ReqlRuntimeError) Backtrace() []Frame { return decodeBacktrace(e.backtrace) }
+
 // ReqlNonExistenceError is a RUNTIME_ERROR with ErrorType NON_EXISTENCE.
 type ReqlNonExistenceError struct {
 	Msg       string
@@ -40,6 +50,9 @@ type ReqlNonExistenceError struct {
 
 func (e *ReqlNonExistenceError) Error() string { return formatMsg(e.Msg, e.backtrace) }
 
+// Backtrace returns the path to the term the error is about.
+func (e *ReqlNonExistenceError) Backtrace() []Frame { return decodeBacktrace(e.backtrace) }
+
 // ReqlPermissionError is a RUNTIME_ERROR with ErrorType PERMISSION_ERROR.
 type ReqlPermissionError struct {
 	Msg       string
@@ -48,6 +61,61 @@ type ReqlPermissionError struct {
 
 func (e *ReqlPermissionError) Error() string { return formatMsg(e.Msg, e.backtrace) }
 
+// Backtrace returns the path to the term the error is about.
+func (e *ReqlPermissionError) Backtrace() []Frame { return decodeBacktrace(e.backtrace) }
+
+// ReqlAvailabilityError is a RUNTIME_ERROR with ErrorType OP_FAILED or
+// OP_INDETERMINATE: the cluster could not serve the query, e.g. a primary
+// replica was unavailable. Indeterminate is set when a write may have been
+// applied anyway.
+type ReqlAvailabilityError struct {
+	Msg           string
+	Indeterminate bool
+	backtrace     []json.RawMessage
+}
+
+func (e *ReqlAvailabilityError) Error() string { return formatMsg(e.Msg, e.backtrace) }
+
+// Backtrace returns the path to the term the error is about.
+func (e *ReqlAvailabilityError) Backtrace() []Frame { return decodeBacktrace(e.backtrace) }
+
+// Frame is one step of an error backtrace, from a term to one of its
+// arguments: the Arg-th positional one, or the optional argument Opt when
+// it is set.
+type Frame struct {
+	Arg int
+	Opt string
+}
+
+// BacktraceOf returns the backtrace of a server error in err's chain,
+// from the root term of the query to the term at fault; false when err
+// holds no server error or the server sent no backtrace.
+func BacktraceOf(err error) ([]Frame, bool) {
+	var bt interface{ Backtrace() []Frame }
+	if !errors.As(err, &bt) {
+		return nil, false
+	}
+	frames := bt.Backtrace()
+	return frames, len(frames) > 0
+}
+
+// decodeBacktrace decodes the raw frames of a response: numbers are
+// positional arguments, strings optional ones. Decoding stops at the
+// first frame of another kind.
+func decodeBacktrace(raw []json.RawMessage) []Frame {
+	frames := make([]Frame, 0, len(raw))
+	for _, r := range raw {
+		var f Frame
+		if err := json.Unmarshal(r, &f.Arg); err != nil {
+			if err := json.Unmarshal(r, &f.Opt); err != nil || f.Opt == "" {
+				break
+			}
+		}
+		frames = append(frames, f)
+	}
+	return frames
+}
+
 // MapError converts a server error response into a typed Go error.
 // Returns nil for non-error response types.
 func MapError(resp *Response) error {
@@ -75,6 +143,8 @@ func mapRuntimeError(msg string, errType proto.ErrorType, bt []json.RawMessage)
 		return &ReqlNonExistenceError{Msg: msg, backtrace: bt}
 	case proto.ErrorPermission:
 		return &ReqlPermissionError{Msg: msg, backtrace: bt}
+	case proto.ErrorOpFailed, proto.ErrorOpIndeterminate:
+		return &ReqlAvailabilityError{Msg: msg, Indeterminate: errType == proto.ErrorOpIndeterminate, backtrace: bt}
 	default:
 		return &ReqlRuntimeError{Msg: msg, backtrace: bt}
 	}
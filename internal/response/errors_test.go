@@ -3,6 +3,8 @@ package response
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -115,6 +117,30 @@ func TestMapError_PermissionError(t *testing.T) {
 	}
 }
 
+func TestMapError_AvailabilityError(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		errType       proto.ErrorType
+		indeterminate bool
+	}{
+		{proto.ErrorOpFailed, false},
+		{proto.ErrorOpIndeterminate, true},
+	} {
+		resp := &Response{
+			Type:    proto.ResponseRuntimeError,
+			ErrType: tc.errType,
+			Results: rawMessages(`"Primary replica for shard [-inf, +inf) not available"`),
+		}
+		var e *ReqlAvailabilityError
+		if err := MapError(resp); !errors.As(err, &e) {
+			t.Fatalf("ErrType %d: expected *ReqlAvailabilityError, got %T", tc.errType, err)
+		}
+		if e.Indeterminate != tc.indeterminate {
+			t.Errorf("ErrType %d: Indeterminate = %v, want %v", tc.errType, e.Indeterminate, tc.indeterminate)
+		}
+	}
+}
+
 func TestMapError_BacktraceInMessage(t *testing.T) {
 	t.Parallel()
 	resp := &Response{
@@ -168,3 +194,27 @@ func TestMapError_NonError(t *testing.T) {
 		t.Errorf("expected nil for non-error response, got %v", err)
 	}
 }
+
+func TestBacktraceOf(t *testing.T) {
+	t.Parallel()
+	resp := &Response{
+		Type:      proto.ResponseRuntimeError,
+		Results:   rawMessages(`"Expected type NUMBER but found STRING."`),
+		Backtrace: rawMessages(`1`, `"index"`, `0`),
+	}
+	frames, ok := BacktraceOf(fmt.Errorf("query: %w", MapError(resp)))
+	want := []Frame{{Arg: 1}, {Opt: "index"}, {Arg: 0}}
+	if !ok || !reflect.DeepEqual(frames, want) {
+		t.Errorf("BacktraceOf = %v, %v; want %v", frames, ok, want)
+	}
+	if _, ok := BacktraceOf(MapError(&Response{Type: proto.ResponseCompileError, Results: rawMessages(`"x"`)})); ok {
+		t.Error("no frames: want false")
+	}
+	if _, ok := BacktraceOf(errors.New("io")); ok {
+		t.Error("not a server error: want false")
+	}
+	bad := decodeBacktrace(rawMessages(`0`, `{"x":1}`, `1`))
+	if !reflect.DeepEqual(bad, []Frame{{Arg: 0}}) {
+		t.Errorf("decode stops at an unknown frame: got %v", bad)
+	}
+}
@@ -1,7 +1,9 @@
 package response
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -11,6 +13,7 @@ const reqlTypeKey = "$reql_type$"
 // ConvertPseudoTypes recursively converts RethinkDB pseudo-types to native Go types:
 //   - TIME -> time.Time (epoch_time + timezone)
 //   - BINARY -> []byte (base64-decoded data)
+//   - GROUPED_DATA -> []interface{} of {"group": ..., "reduction": ...} maps
 //   - GEOMETRY -> pass-through (no conversion needed)
 //
 // Plain values and maps without $reql_type$ are returned unchanged.
@@ -25,6 +28,30 @@ func ConvertPseudoTypes(v interface{}) interface{} {
 	}
 }
 
+// Decode unmarshals raw into dest with pseudo-types converted, so TIME and
+// BINARY values fill time.Time and []byte fields. A *interface{} dest gets
+// the converted value itself. Rows without pseudo-types are decoded once.
+func Decode(raw json.RawMessage, dest interface{}) error {
+	if !bytes.Contains(raw, []byte(reqlTypeKey)) {
+		return json.Unmarshal(raw, dest)
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+	v = ConvertPseudoTypes(v)
+	if p, ok := dest.(*interface{}); ok {
+		*p = v
+		return nil
+	}
+	// time.Time and []byte marshal to the forms json.Unmarshal reads back
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
 func convertMap(m map[string]interface{}) interface{} {
 	rt, ok := m[reqlTypeKey]
 	if !ok {
@@ -40,6 +67,8 @@ func convertMap(m map[string]interface{}) interface{} {
 		return convertTime(m)
 	case "BINARY":
 		return convertBinary(m)
+	case "GROUPED_DATA":
+		return convertGrouped(m)
 	default:
 		// GEOMETRY and unknown pseudo-types: pass through as-is
 		return m
@@ -94,6 +123,54 @@ func convertBinary(m map[string]interface{}) interface{} {
 	return b
 }
 
+func convertGrouped(m map[string]interface{}) interface{} {
+	data, ok := m["data"].([]interface{})
+	if !ok {
+		return m
+	}
+	out := make([]interface{}, 0, len(data))
+	for _, item := range data {
+		pair, ok := item.([]interface{})
+		if !ok || len(pair) != 2 {
+			return m
+		}
+		out = append(out, map[string]interface{}{
+			"group":     ConvertPseudoTypes(pair[0]),
+			"reduction": ConvertPseudoTypes(pair[1]),
+		})
+	}
+	return out
+}
+
+// Group is one group of a GROUPED_DATA result, as returned by group().
+type Group struct {
+	Group     json.RawMessage `json:"group"`
+	Reduction json.RawMessage `json:"reduction"`
+}
+
+// Grouped returns the groups of a GROUPED_DATA pseudo-type in server order;
+// ok is false when raw is any other value.
+func Grouped(raw json.RawMessage) (groups []Group, ok bool) {
+	if !bytes.Contains(raw, []byte("GROUPED_DATA")) {
+		return nil, false
+	}
+	var v struct {
+		Type string              `json:"$reql_type$"`
+		Data [][]json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(raw, &v) != nil || v.Type != "GROUPED_DATA" {
+		return nil, false
+	}
+	groups = make([]Group, 0, len(v.Data))
+	for _, pair := range v.Data {
+		if len(pair) != 2 {
+			return nil, false
+		}
+		groups = append(groups, Group{Group: pair[0], Reduction: pair[1]})
+	}
+	return groups, true
+}
+
 func convertSlice(s []interface{}) []interface{} {
 	result := make([]interface{}, len(s))
 	for i, v := range s {
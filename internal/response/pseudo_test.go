@@ -1,6 +1,7 @@
 package response
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -240,3 +241,80 @@ func TestConvertPseudoTypes_SliceNested(t *testing.T) {
 		t.Errorf("got %v, want plain", s[1])
 	}
 }
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+	var doc struct {
+		ID      int       `json:"id"`
+		At      time.Time `json:"at"`
+		Data    []byte    `json:"data"`
+		Geo     map[string]interface{}
+		Missing string `json:"missing"`
+	}
+	raw := []byte(`{"id":7,"at":{"$reql_type$":"TIME","epoch_time":1376436985.5,"timezone":"+05:30"},` +
+		`"data":{"$reql_type$":"BINARY","data":"aGVsbG8="},"Geo":{"$reql_type$":"GEOMETRY","type":"Point"}}`)
+	if err := Decode(raw, &doc); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if doc.ID != 7 || string(doc.Data) != "hello" || doc.Geo["type"] != "Point" {
+		t.Errorf("doc = %+v", doc)
+	}
+	if want := time.Unix(1376436985, 5e8); !doc.At.Equal(want) {
+		t.Errorf("at = %v, want %v", doc.At, want)
+	}
+	if _, off := doc.At.Zone(); off != 5*3600+30*60 {
+		t.Errorf("zone offset = %d", off)
+	}
+
+	var v interface{}
+	if err := Decode([]byte(`[{"$reql_type$":"BINARY","data":"AQI="}]`), &v); err != nil {
+		t.Fatalf("Decode into interface: %v", err)
+	}
+	if s, ok := v.([]interface{}); !ok || len(s) != 1 || string(s[0].([]byte)) != "\x01\x02" { //nolint:forcetypeassert
+		t.Errorf("v = %#v", v)
+	}
+
+	var n int
+	if err := Decode([]byte(`"x"`), &n); err == nil {
+		t.Error("Decode accepted a string for an int")
+	}
+}
+
+func TestGrouped(t *testing.T) {
+	t.Parallel()
+	raw := json.RawMessage(`{"$reql_type$":"GROUPED_DATA","data":[["a",2],[["x",1],{"n":{"$reql_type$":"TIME","epoch_time":0,"timezone":"+00:00"}}]]}`)
+	groups, ok := Grouped(raw)
+	if !ok || len(groups) != 2 {
+		t.Fatalf("Grouped = %v, %v", groups, ok)
+	}
+	if string(groups[0].Group) != `"a"` || string(groups[0].Reduction) != "2" || string(groups[1].Group) != `["x",1]` {
+		t.Errorf("groups = %s", groups)
+	}
+	for _, other := range []string{`{"group":"GROUPED_DATA"}`, `[1,2]`, `{"$reql_type$":"GROUPED_DATA","data":[[1]]}`} {
+		if _, ok := Grouped(json.RawMessage(other)); ok {
+			t.Errorf("Grouped(%s) ok", other)
+		}
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		t.Fatal(err)
+	}
+	conv, ok := ConvertPseudoTypes(v).([]interface{})
+	if !ok || len(conv) != 2 {
+		t.Fatalf("ConvertPseudoTypes = %#v", conv)
+	}
+	second, _ := conv[1].(map[string]interface{})
+	red, _ := second["reduction"].(map[string]interface{})
+	if _, isTime := red["n"].(time.Time); !isTime {
+		t.Errorf("reduction not converted: %#v", second)
+	}
+
+	var typed []struct {
+		Group     string `json:"group"`
+		Reduction int    `json:"reduction"`
+	}
+	if err := Decode(json.RawMessage(`{"$reql_type$":"GROUPED_DATA","data":[["a",2],["b",3]]}`), &typed); err != nil || len(typed) != 2 || typed[1].Reduction != 3 {
+		t.Errorf("Decode = %+v, %v", typed, err)
+	}
+}
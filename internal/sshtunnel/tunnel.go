@@ -0,0 +1,251 @@
+// Package sshtunnel dials TCP connections through an SSH server, replacing
+// a manual `ssh -L` port forward.
+package sshtunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ErrClosed is returned by DialContext after Close.
+var ErrClosed = errors.New("sshtunnel: tunnel closed")
+
+// defaultKeys are the private keys under ~/.ssh tried when no key file is
+// given, in the order OpenSSH tries them.
+var defaultKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// Config describes the SSH server to tunnel through.
+type Config struct {
+	// Target is "[user@]host[:port]"; the user defaults to the OS user and
+	// the port to 22.
+	Target string
+	// KeyFile is a private key to authenticate with. Without it the keys of
+	// ssh-agent ($SSH_AUTH_SOCK) and the default ~/.ssh keys are tried.
+	KeyFile string
+	// KnownHosts is the known_hosts file the server key is verified
+	// against; empty means ~/.ssh/known_hosts.
+	KnownHosts string
+	// Logger, if set, receives connect records at info level.
+	Logger *slog.Logger
+}
+
+// Tunnel holds one SSH connection, opened on the first dial and reopened
+// after it drops; every dial is a new channel over it.
+type Tunnel struct {
+	cfg  Config
+	user string
+	addr string
+
+	mu     sync.Mutex // guards client and closed; held while connecting
+	client *ssh.Client
+	closed bool
+}
+
+// New validates cfg; the SSH connection is opened by the first DialContext.
+func New(cfg Config) (*Tunnel, error) {
+	usr, addr, err := ParseTarget(cfg.Target)
+	if err != nil {
+		return nil, err
+	}
+	return &Tunnel{cfg: cfg, user: usr, addr: addr}, nil
+}
+
+// ParseTarget splits "[user@]host[:port]" into the user (the OS user when
+// omitted) and a "host:port" address (port 22 when omitted).
+func ParseTarget(target string) (usr, addr string, err error) {
+	usr, host, ok := strings.Cut(target, "@")
+	if !ok {
+		host, usr = usr, ""
+	}
+	if usr == "" {
+		u, err := user.Current()
+		if err != nil {
+			return "", "", fmt.Errorf("no user in %q and %w", target, err)
+		}
+		usr = u.Username
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "22")
+	}
+	if h, _, _ := net.SplitHostPort(host); h == "" {
+		return "", "", fmt.Errorf("missing host in %q", target)
+	}
+	return usr, host, nil
+}
+
+// DialContext opens a connection to addr as seen from the SSH server.
+func (t *Tunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := t.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nc, err := client.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: forward to %s: %w", t.addr, addr, err)
+	}
+	return nc, nil
+}
+
+// Close closes the SSH connection and every connection dialed through it.
+func (t *Tunnel) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.client == nil {
+		return nil
+	}
+	err := t.client.Close()
+	t.client = nil
+	return err
+}
+
+// connect returns the open SSH client, dialing one if there is none.
+func (t *Tunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, ErrClosed
+	}
+	if t.client != nil {
+		return t.client, nil
+	}
+	client, err := t.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w", t.addr, err)
+	}
+	t.client = client
+	go func() {
+		_ = client.Wait()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.client == client {
+			t.client = nil
+		}
+	}()
+	return client, nil
+}
+
+func (t *Tunnel) dial(ctx context.Context) (*ssh.Client, error) {
+	hostKey, err := t.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+	signers, closeAgent, err := t.signers()
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
+
+	if t.cfg.Logger != nil {
+		t.cfg.Logger.Info("ssh connecting", "addr", t.addr, "user", t.user)
+	}
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, err
+	}
+	// the SSH handshake has no context; cancellation closes the socket
+	stop := context.AfterFunc(ctx, func() { _ = nc.Close() })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = nc.SetDeadline(deadline)
+	}
+	sc, chans, reqs, err := ssh.NewClientConn(nc, t.addr, &ssh.ClientConfig{
+		User:            t.user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: hostKey,
+	})
+	if err != nil {
+		_ = nc.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	_ = nc.SetDeadline(time.Time{})
+	if t.cfg.Logger != nil {
+		t.cfg.Logger.Info("ssh connected", "addr", t.addr, "server_version", string(sc.ServerVersion()))
+	}
+	return ssh.NewClient(sc, chans, reqs), nil
+}
+
+// hostKeyCallback verifies the server key against the known_hosts file.
+func (t *Tunnel) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	path := t.cfg.KnownHosts
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("known_hosts: %w", err)
+		}
+		path = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	cb, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("known_hosts: %w (connect once with ssh to record the host key)", err)
+	}
+	return cb, nil
+}
+
+// signers collects the keys to offer: KeyFile alone when set, otherwise
+// the ssh-agent keys followed by the unencrypted default keys. The returned
+// func closes the agent connection once authentication is done.
+func (t *Tunnel) signers() ([]ssh.Signer, func(), error) {
+	if t.cfg.KeyFile != "" {
+		s, err := loadKey(t.cfg.KeyFile)
+		if err != nil {
+			return nil, func() {}, err
+		}
+		return []ssh.Signer{s}, func() {}, nil
+	}
+	var signers []ssh.Signer
+	closeAgent := func() {}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if ac, err := net.Dial("unix", sock); err == nil {
+			closeAgent = func() { _ = ac.Close() }
+			if s, err := agent.NewClient(ac).Signers(); err == nil {
+				signers = append(signers, s...)
+			}
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range defaultKeys {
+			if s, err := loadKey(filepath.Join(home, ".ssh", name)); err == nil {
+				signers = append(signers, s)
+			}
+		}
+	}
+	if len(signers) == 0 {
+		closeAgent()
+		return nil, func() {}, errors.New("no usable keys: pass --ssh-key or add a key to ssh-agent")
+	}
+	return signers, closeAgent, nil
+}
+
+func loadKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user-supplied key path
+	if err != nil {
+		return nil, fmt.Errorf("ssh key: %w", err)
+	}
+	s, err := ssh.ParsePrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, fmt.Errorf("ssh key %s is encrypted; add it to ssh-agent instead", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ssh key %s: %w", path, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,327 @@
+package sshtunnel
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshServer is an in-process SSH server that accepts one client key and
+// serves direct-tcpip (ssh -L style) channels.
+type sshServer struct {
+	addr    string
+	hostKey ssh.PublicKey
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func startSSHServer(t *testing.T, clientKey ssh.PublicKey) *sshServer {
+	t.Helper()
+	hostSigner := newSigner(t)
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if !bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, errors.New("unknown key")
+			}
+			return nil, nil
+		},
+	}
+	cfg.AddHostKey(hostSigner)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	s := &sshServer{addr: ln.Addr().String(), hostKey: hostSigner.PublicKey()}
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, nc)
+			s.mu.Unlock()
+			go serveSSH(nc, cfg)
+		}
+	}()
+	t.Cleanup(s.dropAll)
+	return s
+}
+
+// dropAll closes every accepted SSH connection.
+func (s *sshServer) dropAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, nc := range s.conns {
+		_ = nc.Close()
+	}
+}
+
+func serveSSH(nc net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(nc, cfg)
+	if err != nil {
+		_ = nc.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nch := range chans {
+		var dest struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+		if nch.ChannelType() != "direct-tcpip" || ssh.Unmarshal(nch.ExtraData(), &dest) != nil {
+			_ = nch.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		target, err := net.Dial("tcp", net.JoinHostPort(dest.Host, strconv.Itoa(int(dest.Port))))
+		if err != nil {
+			_ = nch.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		ch, creqs, err := nch.Accept()
+		if err != nil {
+			_ = target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(creqs)
+		go func() {
+			_, _ = io.Copy(ch, target)
+			_ = ch.Close()
+		}()
+		go func() {
+			_, _ = io.Copy(target, ch)
+			_ = target.Close()
+		}()
+	}
+}
+
+// startEcho starts a TCP server echoing every connection back.
+func startEcho(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = c.Close() }()
+				_, _ = io.Copy(c, c)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func newSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// writeKey writes an unencrypted client key and returns its signer and path.
+func writeKey(t *testing.T) (ssh.Signer, string) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, path
+}
+
+// writeKnownHosts writes a known_hosts file trusting key for addr.
+func writeKnownHosts(t *testing.T, addr string, key ssh.PublicKey) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, key) + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// startTunnel starts an SSH server and a Tunnel to it.
+func startTunnel(t *testing.T) (*sshServer, *Tunnel) {
+	t.Helper()
+	signer, keyFile := writeKey(t)
+	srv := startSSHServer(t, signer.PublicKey())
+	tun, err := New(Config{Target: "ops@" + srv.addr, KeyFile: keyFile, KnownHosts: writeKnownHosts(t, srv.addr, srv.hostKey)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = tun.Close() })
+	return srv, tun
+}
+
+func echoThrough(t *testing.T, tun *Tunnel, target string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	nc, err := tun.DialContext(ctx, "tcp", target)
+	if err != nil {
+		t.Fatalf("DialContext: %v", err)
+	}
+	defer func() { _ = nc.Close() }()
+	if _, err := nc.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(nc, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("echo: %q, %v", buf, err)
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in, user, addr string
+	}{
+		{"ops@bastion", "ops", "bastion:22"},
+		{"ops@bastion:2222", "ops", "bastion:2222"},
+		{"ops@[::1]:2222", "ops", "[::1]:2222"},
+		{"ops@::1", "ops", "[::1]:22"},
+	}
+	for _, tc := range tests {
+		usr, addr, err := ParseTarget(tc.in)
+		if err != nil || usr != tc.user || addr != tc.addr {
+			t.Errorf("ParseTarget(%q) = %q, %q, %v; want %q, %q", tc.in, usr, addr, err, tc.user, tc.addr)
+		}
+	}
+	if usr, _, err := ParseTarget("bastion"); err != nil || usr == "" {
+		t.Errorf("no user: got %q, %v; want the OS user", usr, err)
+	}
+	if _, _, err := ParseTarget("ops@"); err == nil {
+		t.Error("missing host accepted")
+	}
+}
+
+func TestTunnelForwards(t *testing.T) {
+	t.Parallel()
+	echo := startEcho(t)
+	srv, tun := startTunnel(t)
+	echoThrough(t, tun, echo)
+	echoThrough(t, tun, echo)
+
+	srv.mu.Lock()
+	n := len(srv.conns)
+	srv.mu.Unlock()
+	if n != 1 {
+		t.Errorf("SSH connections = %d, want 1 shared by both dials", n)
+	}
+}
+
+func TestTunnelReconnectsAfterDrop(t *testing.T) {
+	t.Parallel()
+	echo := startEcho(t)
+	srv, tun := startTunnel(t)
+	echoThrough(t, tun, echo)
+
+	srv.dropAll()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		tun.mu.Lock()
+		gone := tun.client == nil
+		tun.mu.Unlock()
+		if gone {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("dropped SSH connection not noticed after 2s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	echoThrough(t, tun, echo)
+}
+
+func TestTunnelRejectsUnknownHostKey(t *testing.T) {
+	t.Parallel()
+	signer, keyFile := writeKey(t)
+	srv := startSSHServer(t, signer.PublicKey())
+	// trust a different key for the server address
+	knownHosts := writeKnownHosts(t, srv.addr, newSigner(t).PublicKey())
+
+	tun, _ := New(Config{Target: "ops@" + srv.addr, KeyFile: keyFile, KnownHosts: knownHosts})
+	defer func() { _ = tun.Close() }()
+	_, err := tun.DialContext(context.Background(), "tcp", "127.0.0.1:1")
+	if err == nil || !strings.Contains(err.Error(), "key mismatch") {
+		t.Errorf("got %v, want host key mismatch", err)
+	}
+}
+
+func TestTunnelMissingKnownHosts(t *testing.T) {
+	t.Parallel()
+	_, keyFile := writeKey(t)
+	tun, _ := New(Config{Target: "ops@127.0.0.1:1", KeyFile: keyFile, KnownHosts: filepath.Join(t.TempDir(), "none")})
+	_, err := tun.DialContext(context.Background(), "tcp", "127.0.0.1:1")
+	if err == nil || !strings.Contains(err.Error(), "connect once with ssh") {
+		t.Errorf("got %v, want known_hosts hint", err)
+	}
+}
+
+func TestTunnelClosed(t *testing.T) {
+	t.Parallel()
+	tun, _ := New(Config{Target: "ops@127.0.0.1:1"})
+	_ = tun.Close()
+	if _, err := tun.DialContext(context.Background(), "tcp", "127.0.0.1:1"); !errors.Is(err, ErrClosed) {
+		t.Errorf("got %v, want ErrClosed", err)
+	}
+}
+
+func TestLoadKeyEncrypted(t *testing.T) {
+	t.Parallel()
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadKey(path); err == nil || !strings.Contains(err.Error(), "add it to ssh-agent") {
+		t.Errorf("got %v, want encrypted key hint", err)
+	}
+}
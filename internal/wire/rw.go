@@ -7,17 +7,39 @@ import (
 	"r-cli/internal/proto"
 )
 
+// FrameTooLargeError reports a response frame over the read limit. Its
+// payload has been skipped, so the stream is still aligned on the next frame.
+type FrameTooLargeError struct {
+	Token uint64
+	Size  uint32
+	Limit uint32
+}
+
+func (e *FrameTooLargeError) Error() string {
+	return fmt.Sprintf("response of %d bytes for token %d exceeds the %d-byte limit", e.Size, e.Token, e.Limit)
+}
+
 // ReadResponse reads a RethinkDB wire frame from r: 12-byte header then payload.
 func ReadResponse(r io.Reader) (token uint64, payload []byte, err error) {
+	return ReadResponseLimit(r, proto.MaxFrameSize)
+}
+
+// ReadResponseLimit is ReadResponse with a payload size limit. A larger
+// frame is read and discarded and returned as a *FrameTooLargeError, so the
+// caller can fail that query and keep reading.
+func ReadResponseLimit(r io.Reader, limit uint32) (token uint64, payload []byte, err error) {
 	var hdr [12]byte
 	if _, err = io.ReadFull(r, hdr[:]); err != nil {
 		return 0, nil, fmt.Errorf("read header: %w", err)
 	}
 	token, length := DecodeHeader(hdr)
-	if length > proto.MaxFrameSize {
-		return 0, nil, fmt.Errorf("payload length %d exceeds max %d", length, proto.MaxFrameSize)
+	if length > limit {
+		if _, err := io.CopyN(io.Discard, r, int64(length)); err != nil {
+			return 0, nil, fmt.Errorf("skip payload: %w", err)
+		}
+		return token, nil, &FrameTooLargeError{Token: token, Size: length, Limit: limit}
 	}
-	payload = make([]byte, length) //nolint:gosec // G115: bounded by proto.MaxFrameSize check above
+	payload = make([]byte, length)
 	if _, err = io.ReadFull(r, payload); err != nil {
 		return 0, nil, fmt.Errorf("read payload: %w", err)
 	}
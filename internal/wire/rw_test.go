@@ -90,6 +90,32 @@ func TestReadResponseOversizedPayload(t *testing.T) {
 	}
 }
 
+func TestReadResponseLimitSkipsFrame(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteQuery(&buf, 7, []byte(`{"t":1,"r":["too big"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteQuery(&buf, 8, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	token, payload, err := ReadResponseLimit(&buf, 8)
+	var tooLarge *FrameTooLargeError
+	if !errors.As(err, &tooLarge) || token != 7 || payload != nil {
+		t.Fatalf("got token=%d payload=%q err=%v, want FrameTooLargeError for token 7", token, payload, err)
+	}
+	if tooLarge.Size != 23 || tooLarge.Limit != 8 {
+		t.Errorf("error = %+v", tooLarge)
+	}
+	if want := "response of 23 bytes for token 7 exceeds the 8-byte limit"; err.Error() != want {
+		t.Errorf("message = %q, want %q", err, want)
+	}
+	token, payload, err = ReadResponseLimit(&buf, 8)
+	if err != nil || token != 8 || string(payload) != "{}" {
+		t.Errorf("next frame: token=%d payload=%q err=%v", token, payload, err)
+	}
+}
+
 // errWriter always returns an error on Write.
 type errWriter struct{ err error }
 